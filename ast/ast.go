@@ -25,11 +25,53 @@ const (
 	TypeIdentifierList
 	TypeSwitchCase
 	TypeNull
+	TypeClause
 )
 
 type RenderOptions struct {
 	LowerCase        bool
 	IdentifierQuoted bool
+	// KeywordCase takes precedence over LowerCase when it is set
+	KeywordCase Case
+	// IdentifierCase applies to the identifiers that are not quoted
+	IdentifierCase Case
+}
+
+// Case is the letter case to render the words in.
+type Case string
+
+const (
+	CasePreserve   Case = "preserve"
+	CaseUpper      Case = "upper"
+	CaseLower      Case = "lower"
+	CaseCapitalize Case = "capitalize"
+)
+
+func (c Case) IsValid() bool {
+	switch c {
+	case "", CasePreserve, CaseUpper, CaseLower, CaseCapitalize:
+		return true
+	}
+	return false
+}
+
+// Apply returns s in the case. An empty case preserves s.
+func (c Case) Apply(s string) string {
+	switch c {
+	case CaseUpper:
+		return strings.ToUpper(s)
+	case CaseLower:
+		return strings.ToLower(s)
+	case CaseCapitalize:
+		words := strings.Split(strings.ToLower(s), " ")
+		for i, w := range words {
+			if r := []rune(w); len(r) > 0 {
+				words[i] = strings.ToUpper(string(r[0])) + string(r[1:])
+			}
+		}
+		return strings.Join(words, " ")
+	}
+	return s
 }
 
 type Node interface {
@@ -116,6 +158,10 @@ type MemberIdentifier struct {
 	Child       Node
 	ChildTok    *SQLToken
 	ChildIdent  *Identifier
+	// Catalog is the part before the parent of the names of three parts,
+	// such as the database of db.schema.table, or nil
+	Catalog    Node
+	CatalogTok *SQLToken
 }
 
 func NewMemberIdentifierParent(nodes []Node, parent Node) *MemberIdentifier {
@@ -140,6 +186,20 @@ func NewMemberIdentifier(nodes []Node, parent Node, child Node) *MemberIdentifie
 	return memberIdentifier
 }
 
+// NewMemberIdentifierCatalog returns the name of three parts, whose child is
+// nil until it is written.
+func NewMemberIdentifierCatalog(nodes []Node, catalog, parent, child Node) *MemberIdentifier {
+	memberIdentifier := NewMemberIdentifierParent(nodes, parent)
+	if child != nil {
+		memberIdentifier = NewMemberIdentifier(nodes, parent, child)
+	}
+	memberIdentifier.Catalog = catalog
+	if tok, ok := catalog.(Token); ok {
+		memberIdentifier.CatalogTok = tok.GetToken()
+	}
+	return memberIdentifier
+}
+
 func (mi *MemberIdentifier) String() string {
 	var strs []string
 	for _, t := range mi.Toks {
@@ -242,6 +302,7 @@ func (i *Identifier) Render(opts *RenderOptions) string {
 	tmpOpts := &RenderOptions{
 		LowerCase:        false,
 		IdentifierQuoted: opts.IdentifierQuoted,
+		IdentifierCase:   opts.IdentifierCase,
 	}
 	return i.Tok.Render(tmpOpts)
 }
@@ -472,6 +533,10 @@ type SQLToken struct {
 	Value interface{}
 	From  token.Pos
 	To    token.Pos
+	// Raw is the text of the token in the source, which may differ from
+	// String such as in the escaped quotes of the strings. It is empty for
+	// the tokens not read by the parser.
+	Raw string
 }
 
 func NewSQLToken(tok *token.Token) *SQLToken {
@@ -529,6 +594,15 @@ func (t *SQLToken) String() string {
 	}
 }
 
+// Source returns the text of the token in the source, or String if it is not
+// read by the parser.
+func (t *SQLToken) Source() string {
+	if t.Raw != "" {
+		return t.Raw
+	}
+	return t.String()
+}
+
 func (t *SQLToken) NoQuoteString() string {
 	switch v := t.Value.(type) {
 	case *token.SQLWord:
@@ -570,9 +644,15 @@ func renderSQLWord(v *token.SQLWord, opts *RenderOptions) string {
 			v.QuoteStyle = '`'
 			return v.String()
 		}
+		if v.QuoteStyle == 0 {
+			return opts.IdentifierCase.Apply(v.String())
+		}
 		return v.String()
 	}
 	// is keyword
+	if opts.KeywordCase != "" {
+		return opts.KeywordCase.Apply(v.String())
+	}
 	if opts.LowerCase {
 		return strings.ToLower(v.String())
 	}
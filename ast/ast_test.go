@@ -0,0 +1,189 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/parser"
+)
+
+func TestSource(t *testing.T) {
+	testcases := []struct {
+		name    string
+		dialect dialect.Dialect
+		input   string
+	}{
+		{
+			name:    "quotes and comments",
+			dialect: &dialect.GenericSQLDialect{},
+			input:   "SELECT a, \"b\" FROM t -- the table\n/* filter */ WHERE x = 'it''s' AND y <> 1.5e3;\r\n",
+		},
+		{
+			name:    "national strings and parameters",
+			dialect: &dialect.MSSQLDialect{},
+			input:   "select N'x', [d], @v, ?\t;",
+		},
+		{
+			name:    "dollar quoted strings",
+			dialect: &dialect.PostgreSQLDialect{},
+			input:   "SELECT $$body$$, $tag$b$tag$, a::int, E'\\n' FROM t;\nSELECT '日本'",
+		},
+		{
+			name:    "mysql",
+			dialect: &dialect.MySQLDialect{},
+			input:   "SELECT `a` FROM t # hash\nWHERE b = \"c\"",
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parser.ParseDialect(tt.input, tt.dialect)
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if got := ast.Source(parsed); got != tt.input {
+				t.Errorf("unmatched source, got %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+	parsed, err := parser.Parse("SELECT ci.Name FROM city AS ci WHERE ci.ID IN (SELECT ID FROM country)")
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	var idents []string
+	depth, maxDepth := 0, 0
+	ast.Inspect(parsed, func(node ast.Node) bool {
+		if node == nil {
+			depth--
+			return false
+		}
+		depth++
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		switch node.(type) {
+		case *ast.MemberIdentifier, *ast.Identifier:
+			idents = append(idents, node.String())
+			depth--
+			return false
+		}
+		return true
+	})
+	want := []string{"ci.Name", "city", "ci", "ci.ID", "ID", "country"}
+	if d := cmp.Diff(want, idents); d != "" {
+		t.Errorf("unmatched identifiers (-want +got):\n%s", d)
+	}
+	if depth != 0 {
+		t.Errorf("unmatched calls after the children, %d left", depth)
+	}
+	if maxDepth < 4 {
+		t.Errorf("the subquery is not inspected, depth %d", maxDepth)
+	}
+}
+
+func TestClauses(t *testing.T) {
+	type clause struct {
+		Kind ast.ClauseKind
+		Text string
+	}
+	testcases := []struct {
+		name    string
+		dialect dialect.Dialect
+		input   string
+		want    []clause
+	}{
+		{
+			name:  "select",
+			input: "-- cities\nSELECT ID, Name FROM city WHERE ID IN (SELECT ID FROM city) GROUP BY Name ORDER BY Name LIMIT 1;",
+			want: []clause{
+				{ast.ClauseSelect, "-- cities\nSELECT ID, Name "},
+				{ast.ClauseFrom, "FROM city "},
+				{ast.ClauseWhere, "WHERE ID IN (SELECT ID FROM city) "},
+				{ast.ClauseGroupBy, "GROUP BY Name "},
+				{ast.ClauseOrderBy, "ORDER BY Name "},
+				{ast.ClauseLimit, "LIMIT 1;"},
+			},
+		},
+		{
+			name:  "with and union",
+			input: "WITH c AS (SELECT 1) SELECT * FROM c UNION ALL SELECT 2",
+			want: []clause{
+				{ast.ClauseWith, "WITH c AS (SELECT 1) "},
+				{ast.ClauseSelect, "SELECT * "},
+				{ast.ClauseFrom, "FROM c "},
+				{ast.ClauseUnion, "UNION ALL "},
+				{ast.ClauseSelect, "SELECT 2"},
+			},
+		},
+		{
+			name:  "insert select",
+			input: "INSERT INTO city (ID) SELECT ID FROM country",
+			want: []clause{
+				{ast.ClauseInsert, "INSERT INTO city (ID) "},
+				{ast.ClauseSelect, "SELECT ID "},
+				{ast.ClauseFrom, "FROM country"},
+			},
+		},
+		{
+			name:    "update",
+			dialect: &dialect.PostgreSQLDialect{},
+			input:   "UPDATE city SET Name = 'a' WHERE ID = 1 RETURNING ID",
+			want: []clause{
+				{ast.ClauseUpdate, "UPDATE city "},
+				{ast.ClauseSet, "SET Name = 'a' "},
+				{ast.ClauseWhere, "WHERE ID = 1 "},
+				{ast.ClauseReturning, "RETURNING ID"},
+			},
+		},
+		{
+			name:  "select for update",
+			input: "SELECT * FROM city FOR UPDATE",
+			want: []clause{
+				{ast.ClauseSelect, "SELECT * "},
+				{ast.ClauseFrom, "FROM city FOR UPDATE"},
+			},
+		},
+		{
+			name:  "delete",
+			input: "DELETE FROM city WHERE ID = 1",
+			want: []clause{
+				{ast.ClauseDelete, "DELETE FROM city "},
+				{ast.ClauseWhere, "WHERE ID = 1"},
+			},
+		},
+		{
+			name:  "other statement",
+			input: "CREATE TABLE t (ID int REFERENCES city ON DELETE CASCADE)",
+			want: []clause{
+				{ast.ClauseOther, "CREATE TABLE t (ID int REFERENCES city ON DELETE CASCADE)"},
+			},
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.dialect
+			if d == nil {
+				d = &dialect.GenericSQLDialect{}
+			}
+			parsed, err := parser.ParseDialect(tt.input, d)
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			stmt, ok := parsed.GetTokens()[0].(*ast.Statement)
+			if !ok {
+				t.Fatalf("not a statement, %T", parsed.GetTokens()[0])
+			}
+			var got []clause
+			for _, c := range ast.Clauses(stmt) {
+				got = append(got, clause{c.Kind, ast.Source(c)})
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched clauses (-want +got):\n%s", d)
+			}
+		})
+	}
+}
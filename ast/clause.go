@@ -0,0 +1,201 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/sqls-server/sqls/token"
+)
+
+// ClauseKind is the kind of a clause, the keywords starting it.
+type ClauseKind string
+
+const (
+	// ClauseOther is the statement that is not split into the clauses, or
+	// the part of it before the first clause
+	ClauseOther     ClauseKind = ""
+	ClauseWith      ClauseKind = "WITH"
+	ClauseSelect    ClauseKind = "SELECT"
+	ClauseFrom      ClauseKind = "FROM"
+	ClauseWhere     ClauseKind = "WHERE"
+	ClauseGroupBy   ClauseKind = "GROUP BY"
+	ClauseHaving    ClauseKind = "HAVING"
+	ClauseWindow    ClauseKind = "WINDOW"
+	ClauseQualify   ClauseKind = "QUALIFY"
+	ClauseOrderBy   ClauseKind = "ORDER BY"
+	ClauseLimit     ClauseKind = "LIMIT"
+	ClauseOffset    ClauseKind = "OFFSET"
+	ClauseFetch     ClauseKind = "FETCH"
+	ClauseUnion     ClauseKind = "UNION"
+	ClauseIntersect ClauseKind = "INTERSECT"
+	ClauseExcept    ClauseKind = "EXCEPT"
+	ClauseInsert    ClauseKind = "INSERT INTO"
+	ClauseValues    ClauseKind = "VALUES"
+	ClauseUpdate    ClauseKind = "UPDATE"
+	ClauseSet       ClauseKind = "SET"
+	ClauseDelete    ClauseKind = "DELETE FROM"
+	ClauseReturning ClauseKind = "RETURNING"
+)
+
+// statementClauses are the clauses starting the statements split into the
+// clauses, and the clauses that the statements may start with after them.
+var statementClauses = map[ClauseKind][]ClauseKind{
+	ClauseWith:   {ClauseSelect, ClauseInsert, ClauseUpdate, ClauseDelete},
+	ClauseSelect: nil,
+	ClauseInsert: {ClauseSelect},
+	ClauseUpdate: nil,
+	ClauseDelete: nil,
+	ClauseValues: nil,
+}
+
+// followingClauses are the clauses following the other clauses in the
+// statements.
+var followingClauses = map[ClauseKind]bool{
+	ClauseFrom:      true,
+	ClauseWhere:     true,
+	ClauseGroupBy:   true,
+	ClauseHaving:    true,
+	ClauseWindow:    true,
+	ClauseQualify:   true,
+	ClauseOrderBy:   true,
+	ClauseLimit:     true,
+	ClauseOffset:    true,
+	ClauseFetch:     true,
+	ClauseUnion:     true,
+	ClauseIntersect: true,
+	ClauseExcept:    true,
+	ClauseValues:    true,
+	ClauseSet:       true,
+	ClauseReturning: true,
+}
+
+// Clause is a part of a statement from the keywords of the clause to the next
+// clause. The clauses are not in the trees parsed, and are split from the
+// statements by Clauses.
+type Clause struct {
+	Kind ClauseKind
+	Toks []Node
+}
+
+func (c *Clause) String() string {
+	return joinString(c.Toks)
+}
+func (c *Clause) Render(opts *RenderOptions) string {
+	return joinRender(c.Toks, opts)
+}
+func (c *Clause) Type() NodeType        { return TypeClause }
+func (c *Clause) GetTokens() []Node     { return c.Toks }
+func (c *Clause) SetTokens(toks []Node) { c.Toks = toks }
+func (c *Clause) Pos() token.Pos        { return findFrom(c) }
+func (c *Clause) End() token.Pos        { return findTo(c) }
+
+// Clauses splits the statement into the clauses of SELECT, INSERT, UPDATE,
+// DELETE and VALUES. The whitespaces and the comments before the first clause
+// are in it, and the other statements are a clause of ClauseOther. The
+// clauses in the parentheses, such as the subqueries, are not split.
+func Clauses(stmt TokenList) []*Clause {
+	var clauses []*Clause
+	var cur *Clause
+	var leading []Node
+	for _, node := range stmt.GetTokens() {
+		kind, ok := clauseKindOf(node)
+		if ok && startsClause(cur, kind) {
+			cur = &Clause{Kind: kind, Toks: append(leading, node)}
+			clauses = append(clauses, cur)
+			leading = nil
+			continue
+		}
+		if cur == nil {
+			leading = append(leading, node)
+			if !isSpace(node) {
+				// The statement is not split
+				cur = &Clause{Kind: ClauseOther, Toks: leading}
+				clauses = append(clauses, cur)
+				leading = nil
+			}
+			continue
+		}
+		cur.Toks = append(cur.Toks, node)
+	}
+	if len(leading) > 0 {
+		clauses = append(clauses, &Clause{Kind: ClauseOther, Toks: leading})
+	}
+	return clauses
+}
+
+func startsClause(cur *Clause, kind ClauseKind) bool {
+	if cur == nil {
+		_, ok := statementClauses[kind]
+		return ok
+	}
+	if cur.Kind == ClauseOther {
+		return false
+	}
+	for _, k := range statementClauses[cur.Kind] {
+		if k == kind {
+			return true
+		}
+	}
+	switch cur.Kind {
+	case ClauseUnion, ClauseIntersect, ClauseExcept:
+		if kind == ClauseSelect {
+			return true
+		}
+	}
+	return followingClauses[kind]
+}
+
+func clauseKindOf(node Node) (ClauseKind, bool) {
+	var words []string
+	switch node := node.(type) {
+	case *MultiKeyword:
+		for _, k := range node.Keywords {
+			words = append(words, keywordOf(k))
+		}
+	case Token:
+		words = append(words, keywordOf(node))
+	}
+	kind := ClauseKind(strings.Join(words, " "))
+	switch kind {
+	// INSERT and DELETE without INTO and FROM
+	case "INSERT":
+		return ClauseInsert, true
+	case "DELETE":
+		return ClauseDelete, true
+	case ClauseWith, ClauseSelect, ClauseFrom, ClauseWhere, ClauseGroupBy,
+		ClauseHaving, ClauseWindow, ClauseQualify, ClauseOrderBy, ClauseLimit,
+		ClauseOffset, ClauseFetch, ClauseUnion, ClauseIntersect, ClauseExcept,
+		ClauseInsert, ClauseValues, ClauseUpdate, ClauseSet, ClauseDelete,
+		ClauseReturning:
+		return kind, true
+	}
+	return "", false
+}
+
+// keywordOf returns the word of the token not quoted in upper case, or empty.
+func keywordOf(node Node) string {
+	tok, ok := node.(Token)
+	if !ok {
+		return ""
+	}
+	t := tok.GetToken()
+	if t.Kind != token.SQLKeyword {
+		return ""
+	}
+	w, ok := t.Value.(*token.SQLWord)
+	if !ok || w.QuoteStyle != 0 {
+		return ""
+	}
+	return strings.ToUpper(w.Value)
+}
+
+func isSpace(node Node) bool {
+	tok, ok := node.(Token)
+	if !ok {
+		return false
+	}
+	switch tok.GetToken().Kind {
+	case token.Whitespace, token.Comment, token.MultilineComment:
+		return true
+	}
+	return false
+}
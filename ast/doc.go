@@ -0,0 +1,13 @@
+// Package ast is the syntax tree of the SQL parsed by the parser package,
+// which other tools such as the custom linters can build on.
+//
+// A Query parsed has a Statement for each statement, and the nodes of the
+// tokens and the groups of them, such as Identifier, MemberIdentifier,
+// Aliased, Parenthesis and FunctionLiteral. Every node has its range in the
+// source by Pos and End, and the groups are TokenList.
+//
+// The trees are visited by Walk and Inspect, and the statements of the
+// queries are split into their clauses by Clauses. Source returns the text of
+// a node as it is in the source, so the text of the whole query is the same
+// as the source parsed.
+package ast
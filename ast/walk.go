@@ -0,0 +1,63 @@
+package ast
+
+import "strings"
+
+// Visitor visits the nodes by Walk. The children of the node are visited by
+// the visitor w returned by Visit if it is not nil, and then w.Visit(nil) is
+// called.
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk visits the node and its children in depth first, in the order of the
+// source.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	if list, ok := node.(TokenList); ok {
+		for _, n := range list.GetTokens() {
+			Walk(v, n)
+		}
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect visits the node and its children by calling f(node). The children
+// are visited when f returns true, and then f(nil) is called.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Tokens returns the tokens of the node in the order of the source.
+func Tokens(node Node) []*SQLToken {
+	var toks []*SQLToken
+	Inspect(node, func(n Node) bool {
+		if tok, ok := n.(Token); ok {
+			toks = append(toks, tok.GetToken())
+			return false
+		}
+		return true
+	})
+	return toks
+}
+
+// Source returns the text of the node in the source. The text of the parsed
+// query is the same as the source, including the whitespaces and the
+// comments.
+func Source(node Node) string {
+	var b strings.Builder
+	for _, tok := range Tokens(node) {
+		b.WriteString(tok.Source())
+	}
+	return b.String()
+}
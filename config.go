@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/handler"
+	"github.com/sqls-server/sqls/internal/logging"
+)
+
+// validateConfig prints the problems of the config file, the one of the
+// argument, of --config or of the user, and the results of connecting to its
+// connections. It exits with 1 if there are any problems.
+func validateConfig(c *cli.Context) error {
+	fpath := c.Args().First()
+	if fpath == "" {
+		fpath = c.String("config")
+	}
+	if fpath == "" {
+		fpath = config.YamlConfigPath
+	}
+	cfg, problems, err := config.Check(fpath)
+	if err != nil {
+		return err
+	}
+	w := c.App.Writer
+	for _, p := range problems {
+		fmt.Fprintf(w, "%s: %s\n", fpath, p)
+	}
+	failed := len(problems)
+	if cfg != nil && !c.Bool("skip-connections") {
+		for i, conn := range cfg.Connections {
+			if conn == nil || conn.Validate() != nil {
+				continue
+			}
+			name := config.ConnectionName(i, conn)
+			if err := pingConnection(conn, c.Duration("timeout")); err != nil {
+				failed++
+				fmt.Fprintf(w, "connection %s: %s\n", name, err)
+				continue
+			}
+			fmt.Fprintf(w, "connection %s: ok\n", name)
+		}
+	}
+	if failed > 0 {
+		return cli.Exit(fmt.Sprintf("found %d problems", failed), 1)
+	}
+	return nil
+}
+
+// pingConnection opens the connection and pings the database in the timeout.
+func pingConnection(cfg *database.DBConfig, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := logging.Recovered(slog.Default(), recover(), "pinging "+cfg.Alias); err != nil {
+				errc <- err
+			}
+		}()
+		conn, err := database.Open(cfg)
+		if err != nil {
+			errc <- fmt.Errorf("cannot connect, %w", err)
+			return
+		}
+		defer conn.Close()
+		if err := conn.Conn.PingContext(ctx); err != nil {
+			errc <- fmt.Errorf("cannot ping, %w", err)
+			return
+		}
+		errc <- nil
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("cannot connect in %s", timeout)
+	}
+}
+
+// listConfig prints the config that the server uses in the current
+// directory, with the passwords and the secrets of the connections masked.
+func listConfig(c *cli.Context) error {
+	server := handler.NewServer()
+	defer server.Stop()
+	if err := loadConfig(server, c.String("config")); err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := server.OpenWorkspace(wd); err != nil {
+		return err
+	}
+
+	effective := server.EffectiveConfig()
+	cfg := *effective
+	cfg.Connections = make([]*database.DBConfig, len(effective.Connections))
+	for i, conn := range effective.Connections {
+		if conn != nil {
+			cfg.Connections[i] = conn.Masked()
+		}
+	}
+	b, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return fmt.Errorf("cannot marshal config, %w", err)
+	}
+	// The settings not set are omitted
+	var settings yaml.MapSlice
+	if err := yaml.Unmarshal(b, &settings); err != nil {
+		return fmt.Errorf("cannot unmarshal config, %w", err)
+	}
+	omitted := omitEmpty(settings)
+	if omitted == nil {
+		omitted = yaml.MapSlice{}
+	}
+	b, err = yaml.Marshal(omitted)
+	if err != nil {
+		return fmt.Errorf("cannot marshal config, %w", err)
+	}
+	_, err = c.App.Writer.Write(b)
+	return err
+}
+
+// omitEmpty returns the value without the items of the zero values, or nil if
+// it is the zero value.
+func omitEmpty(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		items := yaml.MapSlice{}
+		for _, item := range v {
+			if value := omitEmpty(item.Value); value != nil {
+				items = append(items, yaml.MapItem{Key: item.Key, Value: value})
+			}
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return items
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			// The items of the lists are kept for their indexes
+			if items[i] = omitEmpty(item); items[i] == nil {
+				items[i] = yaml.MapSlice{}
+			}
+		}
+		return items
+	case string:
+		if v == "" || v == "0s" {
+			return nil
+		}
+	case int:
+		if v == 0 {
+			return nil
+		}
+	case bool:
+		if !v {
+			return nil
+		}
+	case nil:
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,176 @@
+package dialect
+
+// Feature is a syntax of SQL that not all the databases support.
+type Feature string
+
+const (
+	FeatureReturning       Feature = "RETURNING"
+	FeatureFullOuterJoin   Feature = "FULL OUTER JOIN"
+	FeatureLimit           Feature = "LIMIT"
+	FeatureTop             Feature = "TOP"
+	FeatureFetchFirst      Feature = "FETCH FIRST"
+	FeatureIlike           Feature = "ILIKE"
+	FeatureCte             Feature = "WITH"
+	FeatureWindowFunction  Feature = "OVER"
+	FeatureLateral         Feature = "LATERAL"
+	FeatureApply           Feature = "APPLY"
+	FeatureOnConflict      Feature = "ON CONFLICT"
+	FeatureOnDuplicateKey  Feature = "ON DUPLICATE KEY UPDATE"
+	FeatureDistinctOn      Feature = "DISTINCT ON"
+	FeatureDoubleColonCast Feature = "::"
+	FeatureMerge           Feature = "MERGE"
+)
+
+// unsupportedMySQL8 are the features that MySQL 8 does not support, with the
+// alternatives.
+var unsupportedMySQL8 = map[Feature]string{
+	FeatureReturning:       "",
+	FeatureFullOuterJoin:   "use UNION of LEFT JOIN and RIGHT JOIN",
+	FeatureTop:             "use LIMIT",
+	FeatureFetchFirst:      "use LIMIT",
+	FeatureIlike:           "use LIKE, which is case insensitive with the default collation",
+	FeatureApply:           "use LATERAL",
+	FeatureOnConflict:      "use ON DUPLICATE KEY UPDATE",
+	FeatureDistinctOn:      "",
+	FeatureDoubleColonCast: "use CAST",
+	FeatureMerge:           "use INSERT ... ON DUPLICATE KEY UPDATE",
+}
+
+var unsupportedMySQL57 = merge(unsupportedMySQL8, map[Feature]string{
+	FeatureCte:            "use a subquery",
+	FeatureWindowFunction: "",
+	FeatureLateral:        "",
+	FeatureApply:          "",
+})
+
+var unsupportedFeatures = map[DatabaseDriver]map[Feature]string{
+	DatabaseDriverMySQL:   unsupportedMySQL8,
+	DatabaseDriverMySQL8:  unsupportedMySQL8,
+	DatabaseDriverMySQL57: unsupportedMySQL57,
+	DatabaseDriverMySQL56: unsupportedMySQL57,
+	DatabaseDriverPostgreSQL: {
+		FeatureTop:            "use LIMIT",
+		FeatureApply:          "use LATERAL",
+		FeatureOnDuplicateKey: "use ON CONFLICT",
+	},
+	DatabaseDriverSQLite3: {
+		FeatureTop:             "use LIMIT",
+		FeatureFetchFirst:      "use LIMIT",
+		FeatureIlike:           "use LIKE, which is case insensitive for ASCII",
+		FeatureLateral:         "",
+		FeatureApply:           "",
+		FeatureOnDuplicateKey:  "use ON CONFLICT",
+		FeatureDistinctOn:      "",
+		FeatureDoubleColonCast: "use CAST",
+		FeatureMerge:           "use INSERT ... ON CONFLICT",
+	},
+	DatabaseDriverMssql: {
+		FeatureReturning:       "use OUTPUT",
+		FeatureLimit:           "use TOP or OFFSET ... FETCH",
+		FeatureIlike:           "use LIKE, which is case insensitive with the default collation",
+		FeatureLateral:         "use APPLY",
+		FeatureOnConflict:      "use MERGE",
+		FeatureOnDuplicateKey:  "use MERGE",
+		FeatureDistinctOn:      "",
+		FeatureDoubleColonCast: "use CAST",
+	},
+	DatabaseDriverOracle: {
+		FeatureLimit:           "use FETCH FIRST",
+		FeatureTop:             "use FETCH FIRST",
+		FeatureIlike:           "use LOWER with LIKE",
+		FeatureOnConflict:      "use MERGE",
+		FeatureOnDuplicateKey:  "use MERGE",
+		FeatureDistinctOn:      "",
+		FeatureDoubleColonCast: "use CAST",
+	},
+	DatabaseDriverH2: {
+		FeatureApply:      "",
+		FeatureOnConflict: "use MERGE",
+	},
+	DatabaseDriverVertica: {
+		FeatureReturning:      "",
+		FeatureTop:            "use LIMIT",
+		FeatureApply:          "",
+		FeatureOnConflict:     "use MERGE",
+		FeatureOnDuplicateKey: "use MERGE",
+	},
+	DatabaseDriverClickhouse: {
+		FeatureReturning:      "",
+		FeatureApply:          "",
+		FeatureOnConflict:     "",
+		FeatureOnDuplicateKey: "",
+		FeatureMerge:          "",
+	},
+}
+
+func merge(base, more map[Feature]string) map[Feature]string {
+	merged := map[Feature]string{}
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range more {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Unsupported reports whether the database of the driver does not support the
+// feature, and returns the alternative if there is one. The features of the
+// unknown drivers are supported.
+func Unsupported(driver DatabaseDriver, feature Feature) (alternative string, unsupported bool) {
+	alternative, unsupported = unsupportedFeatures[driver][feature]
+	return alternative, unsupported
+}
+
+// functionDrivers are the databases supporting the functions that are not
+// portable.
+var functionDrivers = map[string][]DatabaseDriver{
+	"NVL":          {DatabaseDriverOracle, DatabaseDriverVertica, DatabaseDriverH2},
+	"IFNULL":       {DatabaseDriverMySQL, DatabaseDriverSQLite3, DatabaseDriverH2, DatabaseDriverVertica, DatabaseDriverClickhouse},
+	"GETDATE":      {DatabaseDriverMssql},
+	"NOW":          {DatabaseDriverMySQL, DatabaseDriverPostgreSQL, DatabaseDriverH2, DatabaseDriverVertica, DatabaseDriverClickhouse},
+	"LEN":          {DatabaseDriverMssql},
+	"CHARINDEX":    {DatabaseDriverMssql},
+	"DATEADD":      {DatabaseDriverMssql, DatabaseDriverH2},
+	"GROUP_CONCAT": {DatabaseDriverMySQL, DatabaseDriverSQLite3, DatabaseDriverH2},
+	"STRING_AGG":   {DatabaseDriverPostgreSQL, DatabaseDriverMssql},
+	"LISTAGG":      {DatabaseDriverOracle, DatabaseDriverVertica, DatabaseDriverH2},
+	"DATE_FORMAT":  {DatabaseDriverMySQL},
+	"TO_CHAR":      {DatabaseDriverOracle, DatabaseDriverPostgreSQL, DatabaseDriverVertica, DatabaseDriverH2},
+	"TO_DATE":      {DatabaseDriverOracle, DatabaseDriverPostgreSQL, DatabaseDriverVertica, DatabaseDriverH2},
+	"DECODE":       {DatabaseDriverOracle, DatabaseDriverVertica, DatabaseDriverH2},
+}
+
+// UnsupportedFunction reports whether the database of the driver does not
+// have the function. The functions of the unknown drivers and the ones that
+// are not known to be specific to some databases are supported.
+func UnsupportedFunction(driver DatabaseDriver, upperName string) bool {
+	drivers, ok := functionDrivers[upperName]
+	if !ok {
+		return false
+	}
+	if _, known := unsupportedFeatures[driver]; !known {
+		return false
+	}
+	switch driver {
+	case DatabaseDriverMySQL8, DatabaseDriverMySQL57, DatabaseDriverMySQL56:
+		driver = DatabaseDriverMySQL
+	}
+	for _, d := range drivers {
+		if d == driver {
+			return false
+		}
+	}
+	return true
+}
+
+// TransactionalDDL reports whether the DDL statements of the database of the
+// driver are rolled back with the transactions. The others commit them as
+// they run.
+func TransactionalDDL(driver DatabaseDriver) bool {
+	switch driver {
+	case DatabaseDriverPostgreSQL, DatabaseDriverSQLite3, DatabaseDriverMssql:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,297 @@
+package dialect
+
+var genericDataTypes = []string{
+	"BIGINT",
+	"BINARY",
+	"BLOB",
+	"BOOLEAN",
+	"CHAR",
+	"CHARACTER",
+	"CLOB",
+	"DATE",
+	"DECIMAL",
+	"DOUBLE PRECISION",
+	"FLOAT",
+	"INT",
+	"INTEGER",
+	"INTERVAL",
+	"NCHAR",
+	"NUMERIC",
+	"REAL",
+	"SMALLINT",
+	"TIME",
+	"TIMESTAMP",
+	"VARBINARY",
+	"VARCHAR",
+}
+
+var mysqlDataTypes = []string{
+	"BIGINT",
+	"BINARY",
+	"BIT",
+	"BLOB",
+	"BOOLEAN",
+	"CHAR",
+	"DATE",
+	"DATETIME",
+	"DECIMAL",
+	"DOUBLE",
+	"ENUM",
+	"FLOAT",
+	"GEOMETRY",
+	"INT",
+	"INTEGER",
+	"JSON",
+	"LONGBLOB",
+	"LONGTEXT",
+	"MEDIUMBLOB",
+	"MEDIUMINT",
+	"MEDIUMTEXT",
+	"NUMERIC",
+	"POINT",
+	"SET",
+	"SMALLINT",
+	"TEXT",
+	"TIME",
+	"TIMESTAMP",
+	"TINYBLOB",
+	"TINYINT",
+	"TINYTEXT",
+	"VARBINARY",
+	"VARCHAR",
+	"YEAR",
+}
+
+var postgresqlDataTypes = []string{
+	"BIGINT",
+	"BIGSERIAL",
+	"BIT",
+	"BOOLEAN",
+	"BYTEA",
+	"CHAR",
+	"CIDR",
+	"DATE",
+	"DATERANGE",
+	"DOUBLE PRECISION",
+	"INET",
+	"INT4RANGE",
+	"INT8RANGE",
+	"INTEGER",
+	"INTERVAL",
+	"JSON",
+	"JSONB",
+	"MACADDR",
+	"MONEY",
+	"NUMERIC",
+	"NUMRANGE",
+	"POINT",
+	"REAL",
+	"SERIAL",
+	"SMALLINT",
+	"SMALLSERIAL",
+	"TEXT",
+	"TIME",
+	"TIMESTAMP",
+	"TIMESTAMPTZ",
+	"TIMETZ",
+	"TSQUERY",
+	"TSRANGE",
+	"TSTZRANGE",
+	"TSVECTOR",
+	"UUID",
+	"VARBIT",
+	"VARCHAR",
+	"XML",
+}
+
+var sqliteDataTypes = []string{
+	"ANY",
+	"BLOB",
+	"INTEGER",
+	"NUMERIC",
+	"REAL",
+	"TEXT",
+}
+
+var mssqlDataTypes = []string{
+	"BIGINT",
+	"BINARY",
+	"BIT",
+	"CHAR",
+	"DATE",
+	"DATETIME",
+	"DATETIME2",
+	"DATETIMEOFFSET",
+	"DECIMAL",
+	"FLOAT",
+	"GEOGRAPHY",
+	"GEOMETRY",
+	"HIERARCHYID",
+	"IMAGE",
+	"INT",
+	"MONEY",
+	"NCHAR",
+	"NTEXT",
+	"NUMERIC",
+	"NVARCHAR",
+	"REAL",
+	"ROWVERSION",
+	"SMALLDATETIME",
+	"SMALLINT",
+	"SMALLMONEY",
+	"SQL_VARIANT",
+	"TEXT",
+	"TIME",
+	"TINYINT",
+	"UNIQUEIDENTIFIER",
+	"VARBINARY",
+	"VARCHAR",
+	"XML",
+}
+
+var oracleDataTypes = []string{
+	"BFILE",
+	"BINARY_DOUBLE",
+	"BINARY_FLOAT",
+	"BLOB",
+	"CHAR",
+	"CLOB",
+	"DATE",
+	"FLOAT",
+	"INTERVAL DAY TO SECOND",
+	"INTERVAL YEAR TO MONTH",
+	"JSON",
+	"LONG",
+	"NCHAR",
+	"NCLOB",
+	"NUMBER",
+	"NVARCHAR2",
+	"RAW",
+	"ROWID",
+	"TIMESTAMP",
+	"TIMESTAMP WITH LOCAL TIME ZONE",
+	"TIMESTAMP WITH TIME ZONE",
+	"UROWID",
+	"VARCHAR2",
+	"XMLTYPE",
+}
+
+var h2DataTypes = []string{
+	"ARRAY",
+	"BIGINT",
+	"BINARY",
+	"BINARY VARYING",
+	"BLOB",
+	"BOOLEAN",
+	"CHARACTER",
+	"CHARACTER VARYING",
+	"CLOB",
+	"DATE",
+	"DECFLOAT",
+	"DOUBLE PRECISION",
+	"ENUM",
+	"GEOMETRY",
+	"INTEGER",
+	"INTERVAL",
+	"JSON",
+	"NUMERIC",
+	"REAL",
+	"SMALLINT",
+	"TIME",
+	"TIME WITH TIME ZONE",
+	"TIMESTAMP",
+	"TIMESTAMP WITH TIME ZONE",
+	"TINYINT",
+	"UUID",
+	"VARCHAR",
+	"VARCHAR_IGNORECASE",
+}
+
+var verticaDataTypes = []string{
+	"BIGINT",
+	"BINARY",
+	"BOOLEAN",
+	"CHAR",
+	"DATE",
+	"DECIMAL",
+	"FLOAT",
+	"GEOGRAPHY",
+	"GEOMETRY",
+	"INT",
+	"INTEGER",
+	"INTERVAL",
+	"LONG VARBINARY",
+	"LONG VARCHAR",
+	"MONEY",
+	"NUMBER",
+	"NUMERIC",
+	"SMALLINT",
+	"TIME",
+	"TIMESTAMP",
+	"TIMESTAMPTZ",
+	"TIMETZ",
+	"TINYINT",
+	"UUID",
+	"VARBINARY",
+	"VARCHAR",
+}
+
+// clickhouseDataTypes are case sensitive.
+var clickhouseDataTypes = []string{
+	"Array",
+	"Bool",
+	"Date",
+	"Date32",
+	"DateTime",
+	"DateTime64",
+	"Decimal",
+	"Enum8",
+	"Enum16",
+	"FixedString",
+	"Float32",
+	"Float64",
+	"IPv4",
+	"IPv6",
+	"Int8",
+	"Int16",
+	"Int32",
+	"Int64",
+	"Int128",
+	"Int256",
+	"JSON",
+	"LowCardinality",
+	"Map",
+	"Nullable",
+	"String",
+	"Tuple",
+	"UInt8",
+	"UInt16",
+	"UInt32",
+	"UInt64",
+	"UInt128",
+	"UInt256",
+	"UUID",
+}
+
+var dataTypes = map[DatabaseDriver][]string{
+	DatabaseDriverMySQL:      mysqlDataTypes,
+	DatabaseDriverMySQL8:     mysqlDataTypes,
+	DatabaseDriverMySQL57:    mysqlDataTypes,
+	DatabaseDriverMySQL56:    mysqlDataTypes,
+	DatabaseDriverPostgreSQL: postgresqlDataTypes,
+	DatabaseDriverSQLite3:    sqliteDataTypes,
+	DatabaseDriverMssql:      mssqlDataTypes,
+	DatabaseDriverOracle:     oracleDataTypes,
+	DatabaseDriverH2:         h2DataTypes,
+	DatabaseDriverVertica:    verticaDataTypes,
+	DatabaseDriverClickhouse: clickhouseDataTypes,
+}
+
+// DataBaseDataTypes returns the data types of the columns of the database,
+// or the ones of standard SQL for the unknown driver.
+func DataBaseDataTypes(driver DatabaseDriver) []string {
+	if types, ok := dataTypes[driver]; ok {
+		return types
+	}
+	return genericDataTypes
+}
@@ -32,3 +32,80 @@ func (*GenericSQLDialect) IsPlaceHolderPart(r rune) bool {
 }
 
 var _ Dialect = &GenericSQLDialect{}
+
+// KeywordDialect is a dialect with keywords in addition to the standard SQL
+// keywords.
+type KeywordDialect interface {
+	Dialect
+	IsKeyword(upperWord string) bool
+}
+
+// MSSQLDialect reads the identifiers delimited with brackets and the names of
+// the temporary tables of T-SQL.
+type MSSQLDialect struct {
+	GenericSQLDialect
+}
+
+func (*MSSQLDialect) IsIdentifierStart(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '@' || r == '#'
+}
+
+func (*MSSQLDialect) IsIdentifierPart(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '@' || r == '_' || r == '#'
+}
+
+func (*MSSQLDialect) IsDelimitedIdentifierStart(r rune) bool {
+	return r == '"' || r == '['
+}
+
+func (*MSSQLDialect) IsKeyword(upperWord string) bool {
+	switch upperWord {
+	case "TOP", "TIES", "OUTPUT", "NOLOCK":
+		return true
+	}
+	return false
+}
+
+var _ KeywordDialect = &MSSQLDialect{}
+
+type PostgreSQLDialect struct {
+	GenericSQLDialect
+}
+
+func (*PostgreSQLDialect) IsKeyword(upperWord string) bool {
+	switch upperWord {
+	case "ILIKE", "RETURNING":
+		return true
+	}
+	return false
+}
+
+var _ KeywordDialect = &PostgreSQLDialect{}
+
+type MySQLDialect struct {
+	GenericSQLDialect
+}
+
+func (*MySQLDialect) IsKeyword(upperWord string) bool {
+	switch upperWord {
+	case "REGEXP", "RLIKE", "STRAIGHT_JOIN":
+		return true
+	}
+	return false
+}
+
+var _ KeywordDialect = &MySQLDialect{}
+
+// ForDriver returns the dialect to read the queries of the database driver.
+func ForDriver(driver DatabaseDriver) Dialect {
+	switch driver {
+	case DatabaseDriverMssql:
+		return &MSSQLDialect{}
+	case DatabaseDriverPostgreSQL:
+		return &PostgreSQLDialect{}
+	case DatabaseDriverMySQL, DatabaseDriverMySQL8, DatabaseDriverMySQL57, DatabaseDriverMySQL56:
+		return &MySQLDialect{}
+	default:
+		return &GenericSQLDialect{}
+	}
+}
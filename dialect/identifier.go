@@ -0,0 +1,68 @@
+package dialect
+
+import "strings"
+
+// IdentifierRules are the rules of the database to match the identifiers of
+// the queries with the names of the objects.
+//
+// The identifiers not quoted match regardless of their letter case on most
+// databases. PostgreSQL folds them to lower case and Oracle to upper case,
+// and both match the quoted ones only with the names of the same letter
+// case. MySQL matches the names of the databases and the tables by
+// lower_case_table_names, which makes them case sensitive on Linux by
+// default, and the names of the columns regardless of their letter case and
+// accents. SQL Server and SQLite match all of them case insensitively.
+type IdentifierRules struct {
+	// Fold folds the identifiers not quoted, which match only the names of
+	// the same letter case after folding if it is set
+	Fold func(string) string
+	// TablesCaseSensitive is whether the names of the schemas and the tables
+	// match only the identifiers of the same letter case, quoted or not
+	TablesCaseSensitive bool
+	// ColumnsAccentInsensitive is whether the names of the columns match the
+	// identifiers regardless of their accents
+	ColumnsAccentInsensitive bool
+}
+
+// NewIdentifierRules returns the rules of the identifiers of the database.
+// The names of the tables of MySQL are case insensitive until
+// lower_case_table_names of the server is known.
+func NewIdentifierRules(driver DatabaseDriver) IdentifierRules {
+	switch driver {
+	case DatabaseDriverPostgreSQL:
+		return IdentifierRules{Fold: strings.ToLower}
+	case DatabaseDriverOracle:
+		return IdentifierRules{Fold: strings.ToUpper}
+	case DatabaseDriverMySQL, DatabaseDriverMySQL8, DatabaseDriverMySQL57, DatabaseDriverMySQL56:
+		return IdentifierRules{ColumnsAccentInsensitive: true}
+	}
+	return IdentifierRules{}
+}
+
+// Unquote returns the identifier without the quotes, and whether it is
+// quoted by the double quotes, the brackets or the backquotes.
+func Unquote(ident string) (string, bool) {
+	if len(ident) < 2 {
+		return ident, false
+	}
+	var end byte
+	switch ident[0] {
+	case '"':
+		end = '"'
+	case '[':
+		end = ']'
+	case '`':
+		end = '`'
+	default:
+		return ident, false
+	}
+	if ident[len(ident)-1] != end {
+		return ident, false
+	}
+	inner := ident[1 : len(ident)-1]
+	if end != ']' {
+		q := string(end)
+		inner = strings.ReplaceAll(inner, q+q, q)
+	}
+	return inner, true
+}
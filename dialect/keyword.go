@@ -126,6 +126,7 @@ var match = map[string]KeywordKind{
 	"ESCAPE":                           Matched,
 	"EVERY":                            Matched,
 	"EXCEPT":                           Matched,
+	"EXCLUDE":                          Matched,
 	"EXEC":                             Matched,
 	"EXECUTE":                          Matched,
 	"EXISTS":                           Matched,
@@ -228,6 +229,7 @@ var match = map[string]KeywordKind{
 	"OPEN":                             Matched,
 	"OR":                               Matched,
 	"ORDER":                            Matched,
+	"OTHERS":                           Matched,
 	"OUT":                              Matched,
 	"OUTER":                            Matched,
 	"OVER":                             Matched,
@@ -251,6 +253,7 @@ var match = map[string]KeywordKind{
 	"PREPARE":                          Matched,
 	"PRIMARY":                          Matched,
 	"PROCEDURE":                        Matched,
+	"QUALIFY":                          Matched,
 	"RANGE":                            Matched,
 	"RANK":                             Matched,
 	"READS":                            Matched,
@@ -319,6 +322,7 @@ var match = map[string]KeywordKind{
 	"TABLESAMPLE":                      Matched,
 	"TEXT":                             Matched,
 	"THEN":                             Matched,
+	"TIES":                             Matched,
 	"TIME":                             Matched,
 	"TIMESTAMP":                        Matched,
 	"TIMEZONE_HOUR":                    Matched,
@@ -0,0 +1,213 @@
+package dialect
+
+import "strings"
+
+var mysql8ReservedWords = []string{
+	"ACCESSIBLE", "ADD", "ALL", "ALTER", "ANALYZE", "AND", "AS", "ASC", "ASENSITIVE",
+	"BEFORE", "BETWEEN", "BIGINT", "BINARY", "BLOB", "BOTH", "BY",
+	"CALL", "CASCADE", "CASE", "CHANGE", "CHAR", "CHARACTER", "CHECK", "COLLATE", "COLUMN", "CONDITION", "CONSTRAINT", "CONTINUE", "CONVERT", "CREATE", "CROSS", "CUBE", "CUME_DIST", "CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "CURRENT_USER", "CURSOR",
+	"DATABASE", "DATABASES", "DAY_HOUR", "DAY_MICROSECOND", "DAY_MINUTE", "DAY_SECOND", "DEC", "DECIMAL", "DECLARE", "DEFAULT", "DELAYED", "DELETE", "DENSE_RANK", "DESC", "DESCRIBE", "DETERMINISTIC", "DISTINCT", "DISTINCTROW", "DIV", "DOUBLE", "DROP", "DUAL",
+	"EACH", "ELSE", "ELSEIF", "EMPTY", "ENCLOSED", "ESCAPED", "EXCEPT", "EXISTS", "EXIT", "EXPLAIN",
+	"FALSE", "FETCH", "FIRST_VALUE", "FLOAT", "FLOAT4", "FLOAT8", "FOR", "FORCE", "FOREIGN", "FROM", "FULLTEXT", "FUNCTION",
+	"GENERATED", "GET", "GRANT", "GROUP", "GROUPING", "GROUPS",
+	"HAVING", "HIGH_PRIORITY", "HOUR_MICROSECOND", "HOUR_MINUTE", "HOUR_SECOND",
+	"IF", "IGNORE", "IN", "INDEX", "INFILE", "INNER", "INOUT", "INSENSITIVE", "INSERT", "INT", "INT1", "INT2", "INT3", "INT4", "INT8", "INTEGER", "INTERSECT", "INTERVAL", "INTO", "IO_AFTER_GTIDS", "IO_BEFORE_GTIDS", "IS", "ITERATE",
+	"JOIN", "JSON_TABLE",
+	"KEY", "KEYS", "KILL",
+	"LAG", "LAST_VALUE", "LATERAL", "LEAD", "LEADING", "LEAVE", "LEFT", "LIKE", "LIMIT", "LINEAR", "LINES", "LOAD", "LOCALTIME", "LOCALTIMESTAMP", "LOCK", "LONG", "LONGBLOB", "LONGTEXT", "LOOP", "LOW_PRIORITY",
+	"MASTER_BIND", "MASTER_SSL_VERIFY_SERVER_CERT", "MATCH", "MAXVALUE", "MEDIUMBLOB", "MEDIUMINT", "MEDIUMTEXT", "MIDDLEINT", "MINUTE_MICROSECOND", "MINUTE_SECOND", "MOD", "MODIFIES",
+	"NATURAL", "NOT", "NO_WRITE_TO_BINLOG", "NTH_VALUE", "NTILE", "NULL", "NUMERIC",
+	"OF", "ON", "OPTIMIZE", "OPTIMIZER_COSTS", "OPTION", "OPTIONALLY", "OR", "ORDER", "OUT", "OUTER", "OUTFILE", "OVER",
+	"PARTITION", "PERCENT_RANK", "PRECISION", "PRIMARY", "PROCEDURE", "PURGE",
+	"RANGE", "RANK", "READ", "READS", "READ_WRITE", "REAL", "RECURSIVE", "REFERENCES", "REGEXP", "RELEASE", "RENAME", "REPEAT", "REPLACE", "REQUIRE", "RESIGNAL", "RESTRICT", "RETURN", "REVOKE", "RIGHT", "RLIKE", "ROW", "ROWS", "ROW_NUMBER",
+	"SCHEMA", "SCHEMAS", "SECOND_MICROSECOND", "SELECT", "SENSITIVE", "SEPARATOR", "SET", "SHOW", "SIGNAL", "SMALLINT", "SPATIAL", "SPECIFIC", "SQL", "SQLEXCEPTION", "SQLSTATE", "SQLWARNING", "SQL_BIG_RESULT", "SQL_CALC_FOUND_ROWS", "SQL_SMALL_RESULT", "SSL", "STARTING", "STORED", "STRAIGHT_JOIN", "SYSTEM",
+	"TABLE", "TERMINATED", "THEN", "TINYBLOB", "TINYINT", "TINYTEXT", "TO", "TRAILING", "TRIGGER", "TRUE",
+	"UNDO", "UNION", "UNIQUE", "UNLOCK", "UNSIGNED", "UPDATE", "USAGE", "USE", "USING", "UTC_DATE", "UTC_TIME", "UTC_TIMESTAMP",
+	"VALUES", "VARBINARY", "VARCHAR", "VARCHARACTER", "VARYING", "VIRTUAL",
+	"WHEN", "WHERE", "WHILE", "WINDOW", "WITH", "WRITE",
+	"XOR",
+	"YEAR_MONTH",
+	"ZEROFILL",
+}
+
+// mysql8OnlyReservedWords are the reserved words of MySQL 8 that are not
+// reserved in MySQL 5.7.
+var mysql8OnlyReservedWords = []string{
+	"CUBE", "CUME_DIST", "DENSE_RANK", "EMPTY", "EXCEPT", "FIRST_VALUE", "FUNCTION", "GROUPING", "GROUPS", "INTERSECT", "JSON_TABLE",
+	"LAG", "LAST_VALUE", "LATERAL", "LEAD", "NTH_VALUE", "NTILE", "OF", "OVER", "PERCENT_RANK", "RANK", "RECURSIVE", "ROW", "ROWS",
+	"ROW_NUMBER", "SYSTEM", "WINDOW",
+}
+
+var postgresqlReservedWords = []string{
+	"ALL", "ANALYSE", "ANALYZE", "AND", "ANY", "ARRAY", "AS", "ASC", "ASYMMETRIC", "AUTHORIZATION",
+	"BINARY", "BOTH",
+	"CASE", "CAST", "CHECK", "COLLATE", "COLLATION", "COLUMN", "CONCURRENTLY", "CONSTRAINT", "CREATE", "CROSS", "CURRENT_CATALOG", "CURRENT_DATE", "CURRENT_ROLE", "CURRENT_SCHEMA", "CURRENT_TIME", "CURRENT_TIMESTAMP", "CURRENT_USER",
+	"DEFAULT", "DEFERRABLE", "DESC", "DISTINCT", "DO",
+	"ELSE", "END", "EXCEPT",
+	"FALSE", "FETCH", "FOR", "FOREIGN", "FREEZE", "FROM", "FULL",
+	"GRANT", "GROUP",
+	"HAVING",
+	"ILIKE", "IN", "INITIALLY", "INNER", "INTERSECT", "INTO", "IS", "ISNULL",
+	"JOIN",
+	"LATERAL", "LEADING", "LEFT", "LIKE", "LIMIT", "LOCALTIME", "LOCALTIMESTAMP",
+	"NATURAL", "NOT", "NOTNULL", "NULL",
+	"OFFSET", "ON", "ONLY", "OR", "ORDER", "OUTER", "OVERLAPS",
+	"PLACING", "PRIMARY",
+	"REFERENCES", "RETURNING", "RIGHT",
+	"SELECT", "SESSION_USER", "SIMILAR", "SOME", "SYMMETRIC",
+	"TABLE", "TABLESAMPLE", "THEN", "TO", "TRAILING", "TRUE",
+	"UNION", "UNIQUE", "USER", "USING",
+	"VARIADIC", "VERBOSE",
+	"WHEN", "WHERE", "WINDOW", "WITH",
+}
+
+var sqliteReservedWords = []string{
+	"ADD", "ALL", "ALTER", "AND", "AS", "AUTOINCREMENT",
+	"BETWEEN",
+	"CASE", "CHECK", "COLLATE", "COMMIT", "CONSTRAINT", "CREATE",
+	"DEFAULT", "DEFERRABLE", "DELETE", "DISTINCT", "DROP",
+	"ELSE", "ESCAPE", "EXCEPT", "EXISTS",
+	"FOREIGN", "FROM",
+	"GROUP",
+	"HAVING",
+	"IF", "IN", "INDEX", "INSERT", "INTERSECT", "INTO", "IS", "ISNULL",
+	"JOIN",
+	"LIMIT",
+	"NOT", "NOTNULL", "NULL",
+	"ON", "OR", "ORDER",
+	"PRIMARY",
+	"REFERENCES",
+	"SELECT", "SET",
+	"TABLE", "THEN", "TO", "TRANSACTION",
+	"UNION", "UNIQUE", "UPDATE", "USING",
+	"VALUES",
+	"WHEN", "WHERE",
+}
+
+var mssqlReservedWords = []string{
+	"ADD", "ALL", "ALTER", "AND", "ANY", "AS", "ASC", "AUTHORIZATION",
+	"BACKUP", "BEGIN", "BETWEEN", "BREAK", "BROWSE", "BULK", "BY",
+	"CASCADE", "CASE", "CHECK", "CHECKPOINT", "CLOSE", "CLUSTERED", "COALESCE", "COLLATE", "COLUMN", "COMMIT", "COMPUTE", "CONSTRAINT", "CONTAINS", "CONTAINSTABLE", "CONTINUE", "CONVERT", "CREATE", "CROSS", "CURRENT", "CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "CURRENT_USER", "CURSOR",
+	"DATABASE", "DBCC", "DEALLOCATE", "DECLARE", "DEFAULT", "DELETE", "DENY", "DESC", "DISK", "DISTINCT", "DISTRIBUTED", "DOUBLE", "DROP", "DUMP",
+	"ELSE", "END", "ERRLVL", "ESCAPE", "EXCEPT", "EXEC", "EXECUTE", "EXISTS", "EXIT", "EXTERNAL",
+	"FETCH", "FILE", "FILLFACTOR", "FOR", "FOREIGN", "FREETEXT", "FREETEXTTABLE", "FROM", "FULL", "FUNCTION",
+	"GOTO", "GRANT", "GROUP",
+	"HAVING", "HOLDLOCK",
+	"IDENTITY", "IDENTITY_INSERT", "IDENTITYCOL", "IF", "IN", "INDEX", "INNER", "INSERT", "INTERSECT", "INTO", "IS",
+	"JOIN",
+	"KEY", "KILL",
+	"LEFT", "LIKE", "LINENO", "LOAD",
+	"MERGE",
+	"NATIONAL", "NOCHECK", "NONCLUSTERED", "NOT", "NULL", "NULLIF",
+	"OF", "OFF", "OFFSETS", "ON", "OPEN", "OPENDATASOURCE", "OPENQUERY", "OPENROWSET", "OPENXML", "OPTION", "OR", "ORDER", "OUTER", "OVER",
+	"PERCENT", "PIVOT", "PLAN", "PRECISION", "PRIMARY", "PRINT", "PROC", "PROCEDURE", "PUBLIC",
+	"RAISERROR", "READ", "READTEXT", "RECONFIGURE", "REFERENCES", "REPLICATION", "RESTORE", "RESTRICT", "RETURN", "REVERT", "REVOKE", "RIGHT", "ROLLBACK", "ROWCOUNT", "ROWGUIDCOL", "RULE",
+	"SAVE", "SCHEMA", "SECURITYAUDIT", "SELECT", "SEMANTICKEYPHRASETABLE", "SEMANTICSIMILARITYDETAILSTABLE", "SEMANTICSIMILARITYTABLE", "SESSION_USER", "SET", "SETUSER", "SHUTDOWN", "SOME", "STATISTICS", "SYSTEM_USER",
+	"TABLE", "TABLESAMPLE", "TEXTSIZE", "THEN", "TO", "TOP", "TRAN", "TRANSACTION", "TRIGGER", "TRUNCATE", "TRY_CONVERT", "TSEQUAL",
+	"UNION", "UNIQUE", "UNPIVOT", "UPDATE", "UPDATETEXT", "USE", "USER",
+	"VALUES", "VARYING", "VIEW",
+	"WAITFOR", "WHEN", "WHERE", "WHILE", "WITH", "WITHIN", "WRITETEXT",
+}
+
+// SELECT keyword FROM v$reserved_words WHERE reserved = 'Y';
+var oracleSQLReservedWords = []string{
+	"ACCESS", "ADD", "ALL", "ALTER", "AND", "ANY", "AS", "ASC", "AUDIT",
+	"BETWEEN", "BY",
+	"CHAR", "CHECK", "CLUSTER", "COLUMN", "COMMENT", "COMPRESS", "CONNECT", "CREATE", "CURRENT",
+	"DATE", "DECIMAL", "DEFAULT", "DELETE", "DESC", "DISTINCT", "DROP",
+	"ELSE", "EXCLUSIVE", "EXISTS",
+	"FILE", "FLOAT", "FOR", "FROM",
+	"GRANT", "GROUP",
+	"HAVING",
+	"IDENTIFIED", "IMMEDIATE", "IN", "INCREMENT", "INDEX", "INITIAL", "INSERT", "INTEGER", "INTERSECT", "INTO", "IS",
+	"LEVEL", "LIKE", "LOCK", "LONG",
+	"MAXEXTENTS", "MINUS", "MLSLABEL", "MODE", "MODIFY",
+	"NOAUDIT", "NOCOMPRESS", "NOT", "NOWAIT", "NULL", "NUMBER",
+	"OF", "OFFLINE", "ON", "ONLINE", "OPTION", "OR", "ORDER",
+	"PCTFREE", "PRIOR", "PUBLIC",
+	"RAW", "RENAME", "RESOURCE", "REVOKE", "ROW", "ROWID", "ROWNUM", "ROWS",
+	"SELECT", "SESSION", "SET", "SHARE", "SIZE", "SMALLINT", "START", "SUCCESSFUL", "SYNONYM", "SYSDATE",
+	"TABLE", "THEN", "TO", "TRIGGER",
+	"UID", "UNION", "UNIQUE", "UPDATE", "USER",
+	"VALIDATE", "VALUES", "VARCHAR", "VARCHAR2", "VIEW",
+	"WHENEVER", "WHERE", "WITH",
+}
+
+var h2ReservedWords = []string{
+	"ALL", "AND", "ANY", "ARRAY", "AS", "ASYMMETRIC", "AUTHORIZATION",
+	"BETWEEN", "BOTH",
+	"CASE", "CAST", "CHECK", "CONSTRAINT", "CROSS", "CURRENT_CATALOG", "CURRENT_DATE", "CURRENT_PATH", "CURRENT_ROLE", "CURRENT_SCHEMA", "CURRENT_TIME", "CURRENT_TIMESTAMP", "CURRENT_USER",
+	"DAY", "DEFAULT", "DISTINCT",
+	"ELSE", "END", "EXCEPT", "EXISTS",
+	"FALSE", "FETCH", "FOR", "FOREIGN", "FROM", "FULL",
+	"GROUP", "GROUPS",
+	"HAVING", "HOUR",
+	"IF", "ILIKE", "IN", "INNER", "INTERSECT", "INTERVAL", "IS",
+	"JOIN",
+	"KEY",
+	"LEADING", "LEFT", "LIKE", "LIMIT", "LOCALTIME", "LOCALTIMESTAMP",
+	"MINUS", "MINUTE", "MONTH",
+	"NATURAL", "NOT", "NULL",
+	"OFFSET", "ON", "OR", "ORDER", "OVER",
+	"PARTITION", "PRIMARY",
+	"QUALIFY",
+	"RANGE", "REGEXP", "RIGHT", "ROW", "ROWNUM", "ROWS",
+	"SECOND", "SELECT", "SESSION_USER", "SET", "SOME", "SYMMETRIC", "SYSTEM_USER",
+	"TABLE", "TO", "TOP", "TRAILING", "TRUE",
+	"UESCAPE", "UNION", "UNIQUE", "UNKNOWN", "USER", "USING",
+	"VALUE", "VALUES",
+	"WHEN", "WHERE", "WINDOW", "WITH",
+	"YEAR",
+}
+
+func wordSet(words []string, without ...string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range words {
+		set[w] = true
+	}
+	for _, w := range without {
+		delete(set, w)
+	}
+	return set
+}
+
+var mysql8Reserved = wordSet(mysql8ReservedWords)
+var mysql57Reserved = wordSet(mysql8ReservedWords, mysql8OnlyReservedWords...)
+
+// reservedWords are the words that the databases do not accept as the
+// identifiers without the quotes. ClickHouse has no reserved words.
+var reservedWords = map[DatabaseDriver]map[string]bool{
+	DatabaseDriverMySQL:      mysql8Reserved,
+	DatabaseDriverMySQL8:     mysql8Reserved,
+	DatabaseDriverMySQL57:    mysql57Reserved,
+	DatabaseDriverMySQL56:    mysql57Reserved,
+	DatabaseDriverPostgreSQL: wordSet(postgresqlReservedWords),
+	DatabaseDriverSQLite3:    wordSet(sqliteReservedWords),
+	DatabaseDriverMssql:      wordSet(mssqlReservedWords),
+	DatabaseDriverOracle:     wordSet(oracleSQLReservedWords),
+	DatabaseDriverH2:         wordSet(h2ReservedWords),
+	DatabaseDriverVertica:    wordSet(verticaReservedWords),
+}
+
+// IsReserved reports whether the word is reserved in the database of the
+// driver, which needs the quotes to be an identifier.
+func IsReserved(driver DatabaseDriver, upperWord string) bool {
+	return reservedWords[driver][upperWord]
+}
+
+// QuoteIdentifier quotes the identifier in the style of the database of the
+// driver. The identifier is folded to the case that the database folds the
+// identifiers without the quotes to, so that it still names the same object.
+func QuoteIdentifier(driver DatabaseDriver, ident string) string {
+	switch driver {
+	case DatabaseDriverMySQL, DatabaseDriverMySQL8, DatabaseDriverMySQL57, DatabaseDriverMySQL56, DatabaseDriverClickhouse:
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	case DatabaseDriverMssql:
+		return "[" + strings.ReplaceAll(ident, "]", "]]") + "]"
+	case DatabaseDriverPostgreSQL:
+		ident = strings.ToLower(ident)
+	case DatabaseDriverOracle, DatabaseDriverH2:
+		ident = strings.ToUpper(ident)
+	}
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
@@ -1,5 +1,7 @@
 package dialect
 
+import "strings"
+
 var sqliteKeywords = []string{
 	"ABORT",
 	"ACTION",
@@ -147,3 +149,90 @@ var sqliteKeywords = []string{
 	"WITH",
 	"WITHOUT",
 }
+
+// SQLitePragma is a pragma of SQLite.
+type SQLitePragma struct {
+	Name string
+	// Values are the values of the pragma when they are enumerated
+	Values []string
+	// Table is whether the argument of the pragma is a table
+	Table bool
+}
+
+var sqliteBooleanValues = []string{"ON", "OFF"}
+
+var sqlitePragmas = []*SQLitePragma{
+	{Name: "analysis_limit"},
+	{Name: "application_id"},
+	{Name: "auto_vacuum", Values: []string{"NONE", "FULL", "INCREMENTAL"}},
+	{Name: "automatic_index", Values: sqliteBooleanValues},
+	{Name: "busy_timeout"},
+	{Name: "cache_size"},
+	{Name: "cache_spill", Values: sqliteBooleanValues},
+	{Name: "case_sensitive_like", Values: sqliteBooleanValues},
+	{Name: "cell_size_check", Values: sqliteBooleanValues},
+	{Name: "checkpoint_fullfsync", Values: sqliteBooleanValues},
+	{Name: "collation_list"},
+	{Name: "compile_options"},
+	{Name: "data_version"},
+	{Name: "database_list"},
+	{Name: "defer_foreign_keys", Values: sqliteBooleanValues},
+	{Name: "encoding", Values: []string{"'UTF-8'", "'UTF-16'", "'UTF-16le'", "'UTF-16be'"}},
+	{Name: "foreign_key_check", Table: true},
+	{Name: "foreign_key_list", Table: true},
+	{Name: "foreign_keys", Values: sqliteBooleanValues},
+	{Name: "freelist_count"},
+	{Name: "fullfsync", Values: sqliteBooleanValues},
+	{Name: "function_list"},
+	{Name: "hard_heap_limit"},
+	{Name: "ignore_check_constraints", Values: sqliteBooleanValues},
+	{Name: "incremental_vacuum"},
+	{Name: "index_info"},
+	{Name: "index_list", Table: true},
+	{Name: "index_xinfo"},
+	{Name: "integrity_check", Table: true},
+	{Name: "journal_mode", Values: []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}},
+	{Name: "journal_size_limit"},
+	{Name: "legacy_alter_table", Values: sqliteBooleanValues},
+	{Name: "locking_mode", Values: []string{"NORMAL", "EXCLUSIVE"}},
+	{Name: "max_page_count"},
+	{Name: "mmap_size"},
+	{Name: "module_list"},
+	{Name: "optimize"},
+	{Name: "page_count"},
+	{Name: "page_size"},
+	{Name: "pragma_list"},
+	{Name: "query_only", Values: sqliteBooleanValues},
+	{Name: "quick_check", Table: true},
+	{Name: "read_uncommitted", Values: sqliteBooleanValues},
+	{Name: "recursive_triggers", Values: sqliteBooleanValues},
+	{Name: "reverse_unordered_selects", Values: sqliteBooleanValues},
+	{Name: "secure_delete", Values: []string{"ON", "OFF", "FAST"}},
+	{Name: "shrink_memory"},
+	{Name: "soft_heap_limit"},
+	{Name: "synchronous", Values: []string{"OFF", "NORMAL", "FULL", "EXTRA"}},
+	{Name: "table_info", Table: true},
+	{Name: "table_list", Table: true},
+	{Name: "table_xinfo", Table: true},
+	{Name: "temp_store", Values: []string{"DEFAULT", "FILE", "MEMORY"}},
+	{Name: "threads"},
+	{Name: "trusted_schema", Values: sqliteBooleanValues},
+	{Name: "user_version"},
+	{Name: "wal_autocheckpoint"},
+	{Name: "wal_checkpoint", Values: []string{"PASSIVE", "FULL", "RESTART", "TRUNCATE"}},
+}
+
+// SQLitePragmas returns the pragmas of SQLite in the order of their names.
+func SQLitePragmas() []*SQLitePragma {
+	return sqlitePragmas
+}
+
+// LookupSQLitePragma returns the pragma of the name regardless of its case.
+func LookupSQLitePragma(name string) (*SQLitePragma, bool) {
+	for _, p := range sqlitePragmas {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/sqls-server/sqls/internal/handler"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// execute runs the query of the arguments, or the statements of stdin, on the
+// connection of the config, and prints the results. The statements are read
+// in the REPL if stdin is a terminal.
+func execute(c *cli.Context) error {
+	server := handler.NewServer()
+	defer server.Stop()
+	if err := loadConfig(server, c.String("config")); err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := server.OpenWorkspace(wd); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if err := server.UseConnection(ctx, c.String("connection")); err != nil {
+		return err
+	}
+
+	vertical := c.Bool("vertical")
+	text := strings.Join(c.Args().Slice(), " ")
+	if text == "" {
+		if isTerminal(int(os.Stdin.Fd())) {
+			return repl(ctx, server, vertical)
+		}
+		if text, err = readInput("-"); err != nil {
+			return err
+		}
+	}
+	res, err := server.ExecuteText(ctx, text, vertical)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(c.App.Writer, res)
+	return nil
+}
+
+// repl reads the statements ending with ; from the terminal and prints their
+// results, completing the words by Tab as the editors do.
+func repl(ctx context.Context, server *handler.Server, vertical bool) error {
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	defer restore()
+	out := os.Stdout
+
+	// lines are of the statement being entered
+	var lines []string
+	editor := newLineEditor(os.Stdin, out, func(line []rune) ([]string, int) {
+		return completionWords(server, lines, line)
+	})
+	fmt.Fprint(out, "Enter the statements ending with ;, \\x to switch the vertical results and \\q to quit.\r\n")
+	for {
+		prompt := "sqls> "
+		if len(lines) > 0 {
+			prompt = "   -> "
+		}
+		line, err := editor.readLine(prompt)
+		switch {
+		case errors.Is(err, errInterrupted):
+			lines = nil
+			continue
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return err
+		}
+		if len(lines) == 0 {
+			switch strings.TrimSpace(line) {
+			case `\q`, "exit", "quit":
+				return nil
+			case `\x`:
+				vertical = !vertical
+				fmt.Fprintf(out, "vertical results: %t\r\n", vertical)
+				continue
+			case "":
+				continue
+			}
+		}
+		lines = append(lines, line)
+		text := strings.Join(lines, "\n")
+		if !strings.HasSuffix(strings.TrimSpace(text), ";") {
+			continue
+		}
+		lines = nil
+		res, err := server.ExecuteText(ctx, text, vertical)
+		if err != nil {
+			res = "ERROR: " + err.Error() + "\n"
+		}
+		// The terminal in the raw mode does not return the carriage
+		fmt.Fprint(out, strings.ReplaceAll(res, "\n", "\r\n"))
+	}
+}
+
+// completionWords returns the words of the completion of the word before the
+// cursor at the end of the line, after the lines of the statement entered.
+func completionWords(server *handler.Server, lines []string, line []rune) ([]string, int) {
+	text := strings.Join(append(append([]string{}, lines...), string(line)), "\n")
+	items, err := server.CompleteText(text, lsp.Position{Line: len(lines), Character: len(line)})
+	if err != nil {
+		return nil, 0
+	}
+	start := len(line)
+	for start > 0 && (unicode.IsLetter(line[start-1]) || unicode.IsDigit(line[start-1]) || line[start-1] == '_') {
+		start--
+	}
+	prefix := strings.ToLower(string(line[start:]))
+	words := []string{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		word := item.Label
+		if item.InsertText != "" && item.InsertTextFormat != lsp.SnippetTextFormat {
+			word = item.InsertText
+		}
+		if seen[word] || !strings.HasPrefix(strings.ToLower(word), prefix) {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+	return words, len(line) - start
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/sqls-server/sqls/internal/handler"
+)
+
+// format formats the files, or stdin by -, by the config of the user and the
+// workspace of the current directory, as the server formats the documents.
+func format(c *cli.Context) error {
+	write := c.Bool("write")
+	check := c.Bool("check")
+	files := c.Args().Slice()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	server := handler.NewServer()
+	defer server.Stop()
+	if err := loadConfig(server, c.String("config")); err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := server.OpenWorkspace(wd); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	unformatted := 0
+	for _, fpath := range files {
+		text, err := readInput(fpath)
+		if err != nil {
+			return err
+		}
+		formatted, err := server.FormatText(ctx, fpath, text)
+		if err != nil {
+			return fmt.Errorf("cannot format %s, %w", fpath, err)
+		}
+		switch {
+		case check:
+			if formatted != text {
+				unformatted++
+				fmt.Fprintln(c.App.Writer, inputName(fpath))
+			}
+		case write && fpath != "-":
+			if formatted == text {
+				continue
+			}
+			info, err := os.Stat(fpath)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(fpath, []byte(formatted), info.Mode().Perm()); err != nil {
+				return fmt.Errorf("cannot write %s, %w", fpath, err)
+			}
+		default:
+			fmt.Fprint(c.App.Writer, formatted)
+		}
+	}
+	if unformatted > 0 {
+		return cli.Exit(fmt.Sprintf("%d files are not formatted", unformatted), 1)
+	}
+	return nil
+}
+
+func readInput(fpath string) (string, error) {
+	if fpath == "-" {
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, os.Stdin); err != nil {
+			return "", fmt.Errorf("cannot read stdin, %w", err)
+		}
+		return buf.String(), nil
+	}
+	b, err := os.ReadFile(fpath)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s, %w", fpath, err)
+	}
+	return string(b), nil
+}
+
+func inputName(fpath string) string {
+	if fpath == "-" {
+		return "<stdin>"
+	}
+	return fpath
+}
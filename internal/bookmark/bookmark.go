@@ -0,0 +1,140 @@
+package bookmark
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Bookmark struct {
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store keeps the named queries. If path is not empty the bookmarks are
+// persisted to it as JSON.
+type Store struct {
+	path      string
+	mu        sync.Mutex
+	loaded    bool
+	bookmarks map[string]*Bookmark
+}
+
+func NewStore(path string) *Store {
+	return &Store{
+		path:      path,
+		bookmarks: make(map[string]*Bookmark),
+	}
+}
+
+func (s *Store) load() error {
+	if s.loaded || s.path == "" {
+		s.loaded = true
+		return nil
+	}
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.loaded = true
+			return nil
+		}
+		return fmt.Errorf("cannot read bookmarks, %w", err)
+	}
+	var bookmarks []*Bookmark
+	if err := json.Unmarshal(b, &bookmarks); err != nil {
+		return fmt.Errorf("cannot unmarshal bookmarks, %w", err)
+	}
+	for _, bm := range bookmarks {
+		s.bookmarks[bm.Name] = bm
+	}
+	s.loaded = true
+	return nil
+}
+
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal bookmarks, %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("cannot create bookmarks directory, %w", err)
+	}
+	if err := os.WriteFile(s.path, b, 0644); err != nil {
+		return fmt.Errorf("cannot write bookmarks, %w", err)
+	}
+	return nil
+}
+
+func (s *Store) sorted() []*Bookmark {
+	bookmarks := make([]*Bookmark, 0, len(s.bookmarks))
+	for _, bm := range s.bookmarks {
+		bookmarks = append(bookmarks, bm)
+	}
+	sort.Slice(bookmarks, func(i, j int) bool {
+		return bookmarks[i].Name < bookmarks[j].Name
+	})
+	return bookmarks
+}
+
+// Save adds the bookmark, replacing the one with the same name.
+func (s *Store) Save(name, query string) error {
+	if name == "" {
+		return errors.New("bookmark name is empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.bookmarks[name] = &Bookmark{
+		Name:      name,
+		Query:     query,
+		CreatedAt: time.Now(),
+	}
+	return s.save()
+}
+
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	if _, ok := s.bookmarks[name]; !ok {
+		return fmt.Errorf("bookmark not found, %q", name)
+	}
+	delete(s.bookmarks, name)
+	return s.save()
+}
+
+func (s *Store) Get(name string) (*Bookmark, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	bm, ok := s.bookmarks[name]
+	if !ok {
+		return nil, fmt.Errorf("bookmark not found, %q", name)
+	}
+	return bm, nil
+}
+
+// List returns the bookmarks sorted by name.
+func (s *Store) List() ([]*Bookmark, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s.sorted(), nil
+}
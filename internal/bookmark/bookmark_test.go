@@ -0,0 +1,60 @@
+package bookmark
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".sqls", "bookmarks.json")
+	store := NewStore(path)
+	for _, bm := range []struct{ name, query string }{
+		{"large_cities", "SELECT * FROM city WHERE population > 1000000"},
+		{"countries", "SELECT * FROM country"},
+		{"large_cities", "SELECT * FROM city WHERE population > 5000000"},
+		{"languages", "SELECT * FROM countrylanguage"},
+	} {
+		if err := store.Save(bm.name, bm.query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Save("", "SELECT 1"); err == nil {
+		t.Error("empty name must fail")
+	}
+	if err := store.Delete("languages"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("languages"); err == nil {
+		t.Error("deleting missing bookmark must fail")
+	}
+
+	// reload from the persisted file
+	store = NewStore(path)
+	bookmarks, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [][2]string{}
+	for _, bm := range bookmarks {
+		got = append(got, [2]string{bm.Name, bm.Query})
+	}
+	want := [][2]string{
+		{"countries", "SELECT * FROM country"},
+		{"large_cities", "SELECT * FROM city WHERE population > 5000000"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	bm, err := store.Get("countries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bm.Query != "SELECT * FROM country" {
+		t.Errorf("got %q", bm.Query)
+	}
+	if _, err := store.Get("languages"); err == nil {
+		t.Error("deleted bookmark must not be found")
+	}
+}
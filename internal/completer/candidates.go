@@ -2,64 +2,204 @@ package completer
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
 	"github.com/sqls-server/sqls/internal/database"
 	"github.com/sqls-server/sqls/internal/lsp"
 	"github.com/sqls-server/sqls/parser/parseutil"
 )
 
-func (c *Completer) keywordCandidates(lower bool, keywords []string) []lsp.CompletionItem {
+var (
+	// memberCommitCharacters accept the tables, the schemas and the
+	// subqueries when their members are typed
+	memberCommitCharacters = []string{"."}
+	// callCommitCharacters accept the functions when their arguments are
+	// typed
+	callCommitCharacters = []string{"("}
+)
+
+// MarkDeprecated tags the item deprecated if the comment of its object says
+// so.
+func MarkDeprecated(item *lsp.CompletionItem, comment string) {
+	if database.IsDeprecated(comment) {
+		item.Tags = []lsp.CompletionItemTag{lsp.CompletionItemTagDeprecated}
+		item.Deprecated = true
+	}
+}
+
+func (c *Completer) keywordCandidates(keywordCase ast.Case, keywords []string) []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 	for _, k := range keywords {
 		candidate := lsp.CompletionItem{
-			Label:  k,
+			Label:  keywordCase.Apply(k),
 			Kind:   lsp.KeywordCompletion,
 			Detail: "keyword",
 		}
-		if lower {
-			candidate.Label = strings.ToLower(candidate.Label)
-		}
 		candidates = append(candidates, candidate)
 	}
 	return candidates
 }
 
-func (c *Completer) functionCandidates(lower bool, keywords []string) []lsp.CompletionItem {
+func (c *Completer) functionCandidates(keywordCase ast.Case, keywords []string) []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 	for _, k := range keywords {
 		candidate := lsp.CompletionItem{
-			Label:  k,
-			Kind:   lsp.FunctionCompletion,
-			Detail: "Function",
+			Label:            keywordCase.Apply(k),
+			Kind:             lsp.FunctionCompletion,
+			Detail:           "Function",
+			CommitCharacters: callCommitCharacters,
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// dataTypeCandidates returns the data types in the keyword case, except the
+// case sensitive ones in mixed case such as the ones of ClickHouse.
+func (c *Completer) dataTypeCandidates(keywordCase ast.Case, types []string) []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	for _, t := range types {
+		label := t
+		if strings.ToUpper(t) == t {
+			label = keywordCase.Apply(t)
 		}
-		if lower {
-			candidate.Label = strings.ToLower(candidate.Label)
+		candidate := lsp.CompletionItem{
+			Label:  label,
+			Kind:   lsp.TypeParameterCompletion,
+			Detail: "data type",
 		}
 		candidates = append(candidates, candidate)
 	}
 	return candidates
 }
 
+// userTypeCandidates returns the types defined by the users and by the
+// extensions.
+func (c *Completer) userTypeCandidates() []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	for _, t := range c.DBCache.Types {
+		candidate := lsp.CompletionItem{
+			Label:  t.Name,
+			Kind:   lsp.TypeParameterCompletion,
+			Detail: string(t.Kind) + " type",
+		}
+		if doc := database.TypeDoc(t); doc != "" {
+			candidate.Documentation = &lsp.MarkupContent{
+				Kind:  lsp.Markdown,
+				Value: doc,
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// compositeFieldCandidates returns the fields of the composite type of the
+// column, which the table or its alias may qualify.
+func (c *Completer) compositeFieldCandidates(tables []*parseutil.TableInfo, column string) []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	qualifier, name := "", column
+	if i := strings.LastIndex(column, "."); i >= 0 {
+		qualifier, name = column[:i], column[i+1:]
+	}
+	for _, table := range tables {
+		if qualifier != "" && !c.DBCache.MatchIdentifier(qualifier, table.Name) && !c.DBCache.MatchIdentifier(qualifier, table.Alias) {
+			continue
+		}
+		cols, ok := c.DBCache.TableColumns(table.Catalog, table.DatabaseSchema, table.Name)
+		if !ok {
+			continue
+		}
+		for _, col := range cols {
+			if !c.DBCache.MatchColumn(name, col.Name) {
+				continue
+			}
+			t, ok := c.DBCache.Type(col.Type)
+			if !ok || t.Kind != database.TypeKindComposite {
+				continue
+			}
+			for _, field := range t.Fields {
+				candidates = append(candidates, lsp.CompletionItem{
+					Label:  field.Name,
+					Kind:   lsp.FieldCompletion,
+					Detail: "field of " + t.Name + ", " + field.Type,
+				})
+			}
+			return candidates
+		}
+	}
+	return candidates
+}
+
+// tableColumnCandidates returns the columns defined in CREATE TABLE and the
+// ones of the table in the database.
+func (c *Completer) tableColumnCandidates(table *parseutil.TableInfo, defined []string) []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	listed := map[string]bool{}
+	for _, name := range defined {
+		if listed[name] {
+			continue
+		}
+		listed[name] = true
+		candidates = append(candidates, lsp.CompletionItem{
+			Label:        name,
+			LabelDetails: &lsp.CompletionItemLabelDetails{Description: table.Name},
+			Kind:         lsp.FieldCompletion,
+			Detail:       columnDetail(table.Name),
+		})
+	}
+	if c.DBCache == nil {
+		return candidates
+	}
+	for _, candidate := range c.columnCandidates([]*parseutil.TableInfo{table}, noneParent) {
+		if !listed[candidate.Label] {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// referencedColumnCandidates returns the primary key of the table of
+// REFERENCES, or all the columns when the key is not known.
+func (c *Completer) referencedColumnCandidates(table *parseutil.TableInfo) []lsp.CompletionItem {
+	columns, ok := c.DBCache.TableColumns(table.Catalog, table.DatabaseSchema, table.Name)
+	if !ok {
+		return []lsp.CompletionItem{}
+	}
+	keys := []*database.ColumnDesc{}
+	for _, column := range columns {
+		if column.IsPrimaryKey() {
+			keys = append(keys, column)
+		}
+	}
+	if len(keys) == 0 {
+		keys = columns
+	}
+	return generateColumnCandidates(table.Name, keys, c.DBCache)
+}
+
 func (c *Completer) columnCandidates(targetTables []*parseutil.TableInfo, parent *completionParent) []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 
 	switch parent.Type {
 	case ParentTypeNone:
+		// The pseudo tables such as excluded of ON CONFLICT are the tables
+		// listed already
+		listed := map[string]bool{}
 		for _, table := range targetTables {
-			if table.DatabaseSchema != "" && table.Name != "" {
-				columns, ok := c.DBCache.ColumnDatabase(table.DatabaseSchema, table.Name)
-				if !ok {
-					continue
-				}
-				candidates = append(candidates, generateColumnCandidates(table.Name, columns)...)
-			} else if table.Name != "" {
-				columns, ok := c.DBCache.ColumnDescs(table.Name)
-				if !ok {
-					continue
-				}
-				candidates = append(candidates, generateColumnCandidates(table.Name, columns)...)
+			key := table.Catalog + "\t" + table.DatabaseSchema + "\t" + table.Name
+			if listed[key] || table.Name == "" {
+				continue
 			}
+			listed[key] = true
+			columns, ok := c.DBCache.TableColumns(table.Catalog, table.DatabaseSchema, table.Name)
+			if !ok {
+				continue
+			}
+			candidates = append(candidates, generateColumnCandidates(table.Name, columns, c.DBCache)...)
 		}
 	case ParentTypeSchema:
 		// pass
@@ -69,20 +209,11 @@ func (c *Completer) columnCandidates(targetTables []*parseutil.TableInfo, parent
 				continue
 			}
 
-			var columns []*database.ColumnDesc
-			var ok bool
-
-			if table.DatabaseSchema != "" {
-				columns, ok = c.DBCache.ColumnDatabase(table.DatabaseSchema, table.Name)
-
-			} else {
-				columns, ok = c.DBCache.ColumnDescs(table.Name)
-			}
-
+			columns, ok := c.DBCache.TableColumns(table.Catalog, table.DatabaseSchema, table.Name)
 			if !ok {
 				continue
 			}
-			candidates = append(candidates, generateColumnCandidates(table.Name, columns)...)
+			candidates = append(candidates, generateColumnCandidates(table.Name, columns, c.DBCache)...)
 		}
 	case ParentTypeSubQuery:
 		// pass
@@ -90,23 +221,71 @@ func (c *Completer) columnCandidates(targetTables []*parseutil.TableInfo, parent
 	return candidates
 }
 
-func generateColumnCandidates(tableName string, columns []*database.ColumnDesc) []lsp.CompletionItem {
+// assignableColumnCandidates leaves out the columns whose values cannot be
+// assigned, such as the generated columns and the identity columns GENERATED
+// ALWAYS.
+func (c *Completer) assignableColumnCandidates(candidates []lsp.CompletionItem, tables []*parseutil.TableInfo) []lsp.CompletionItem {
+	assignable := []lsp.CompletionItem{}
+	for _, candidate := range candidates {
+		if col, ok := c.candidateColumn(candidate, tables); ok && !col.IsAssignable() {
+			continue
+		}
+		assignable = append(assignable, candidate)
+	}
+	return assignable
+}
+
+// candidateColumn returns the column of the candidate of the tables.
+func (c *Completer) candidateColumn(candidate lsp.CompletionItem, tables []*parseutil.TableInfo) (*database.ColumnDesc, bool) {
+	for _, table := range tables {
+		if candidate.Detail != columnDetail(table.Name) {
+			continue
+		}
+		cols, _ := c.DBCache.TableColumns(table.Catalog, table.DatabaseSchema, table.Name)
+		for _, col := range cols {
+			if col.Name == candidate.Label {
+				return col, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func generateColumnCandidates(tableName string, columns []*database.ColumnDesc, dbCache *database.DBCache) []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 	for _, column := range columns {
 		candidate := lsp.CompletionItem{
-			Label:  column.Name,
-			Kind:   lsp.FieldCompletion,
-			Detail: columnDetail(tableName),
-			Documentation: lsp.MarkupContent{
+			Label:        column.Name,
+			LabelDetails: columnLabelDetails(tableName, column),
+			Kind:         lsp.FieldCompletion,
+			Detail:       columnDetail(tableName),
+			Documentation: &lsp.MarkupContent{
 				Kind:  lsp.Markdown,
-				Value: database.ColumnDoc(tableName, column),
+				Value: database.ColumnDoc(tableName, column, dbCache),
 			},
 		}
+		MarkDeprecated(&candidate, column.Comment)
 		candidates = append(candidates, candidate)
 	}
 	return candidates
 }
 
+// columnLabelDetails shows the type of the column after its name and the
+// table owning it, so that the columns of the same name in the tables joined
+// are told apart.
+func columnLabelDetails(tableName string, column *database.ColumnDesc) *lsp.CompletionItemLabelDetails {
+	details := &lsp.CompletionItemLabelDetails{
+		Description: tableName,
+	}
+	if column.Type != "" {
+		details.Detail = " " + column.Type
+	}
+	if column.Schema != "" {
+		details.Description = column.Schema + "." + tableName
+	}
+	return details
+}
+
 func columnDetail(tableName string) string {
 	detail := strings.Join(
 		[]string{
@@ -124,7 +303,7 @@ func (c *Completer) ReferencedTableCandidates(targetTables []*parseutil.TableInf
 	for _, targetTable := range targetTables {
 		includeTables := []*parseutil.TableInfo{}
 		var schemaTables []string
-		if (targetTable.DatabaseSchema != "") {
+		if targetTable.DatabaseSchema != "" {
 			schemaTables, _ = c.DBCache.SortedTablesByDBName(targetTable.DatabaseSchema)
 		} else {
 			schemaTables = c.DBCache.SortedTables()
@@ -158,11 +337,14 @@ func (c *Completer) TableCandidates(parent *completionParent, targetTables []*pa
 			}
 			excludeTables = append(excludeTables, table)
 		}
-		candidates = append(candidates, generateTableCandidates(excludeTables, c.DBCache)...)
+		candidates = append(candidates, c.partitionCandidates(generateTableCandidates(excludeTables, c.DBCache), "")...)
 	case ParentTypeSchema:
 		tables, ok := c.DBCache.SortedTablesByDBName(parent.Name)
+		if parent.Catalog != "" {
+			tables, ok = c.DBCache.CatalogTables(parent.Catalog, parent.Name)
+		}
 		if ok {
-			candidates = append(candidates, generateTableCandidatesBySchema(parent.Name, tables, c.DBCache)...)
+			candidates = append(candidates, c.partitionCandidates(generateTableCandidatesBySchema(parent.Name, tables, c.DBCache), parent.Name)...)
 		}
 	case ParentTypeTable:
 		// pass
@@ -172,13 +354,46 @@ func (c *Completer) TableCandidates(parent *completionParent, targetTables []*pa
 	return candidates
 }
 
+// partitionCandidates leaves the partitions out of the tables of the schema,
+// or puts them after their partitioned tables, as PartitionCompletion is.
+func (c *Completer) partitionCandidates(candidates []lsp.CompletionItem, schemaName string) []lsp.CompletionItem {
+	if c.PartitionCompletion == "show" || len(c.DBCache.Partitions) == 0 {
+		return candidates
+	}
+	sortTexts := map[string]string{}
+	for _, candidate := range candidates {
+		sortTexts[candidate.Label] = candidate.SortText + candidate.Label
+	}
+	tables := []lsp.CompletionItem{}
+	for _, candidate := range candidates {
+		parent, _, ok := c.DBCache.PartitionOf(schemaName, candidate.Label)
+		if !ok {
+			tables = append(tables, candidate)
+			continue
+		}
+		if c.PartitionCompletion != "group" {
+			continue
+		}
+		candidate.Detail = "partition of " + parent.Table
+		// The space sorts the partitions right after their table
+		candidate.SortText = database.Coalesce(sortTexts[parent.Table], parent.Table) + " "
+		tables = append(tables, candidate)
+	}
+	return tables
+}
+
 func (c *Completer) joinCandidates(lastTable *parseutil.TableInfo,
 	targetTables, allTables []*parseutil.TableInfo,
-	joinOn, lowercaseKeywords bool) []lsp.CompletionItem {
+	joinOn bool, keywordCase ast.Case) []lsp.CompletionItem {
 	var candidates []lsp.CompletionItem
 	if len(c.DBCache.ForeignKeys) == 0 {
 		return candidates
 	}
+	// The foreign keys are of the names in the database
+	targetTables = databaseTables(targetTables, c.DBCache)
+	if lastTable != nil {
+		lastTable = databaseTables([]*parseutil.TableInfo{lastTable}, c.DBCache)[0]
+	}
 
 	tMap := make(map[string]*parseutil.TableInfo)
 	for _, t := range targetTables {
@@ -223,13 +438,28 @@ func (c *Completer) joinCandidates(lastTable *parseutil.TableInfo,
 		for _, fks := range v {
 			for _, fk := range fks {
 				candidates = append(candidates, generateForeignKeyCandidate(k, tMap, aliases,
-					fk, joinOn, lowercaseKeywords))
+					fk, joinOn, keywordCase))
 			}
 		}
 	}
 	return candidates
 }
 
+// databaseTables returns the tables of the query named as in the database,
+// such as clients of Clients.
+func databaseTables(tables []*parseutil.TableInfo, cache *database.DBCache) []*parseutil.TableInfo {
+	res := make([]*parseutil.TableInfo, 0, len(tables))
+	for _, t := range tables {
+		if name, ok := cache.TableName(t.Name); ok && name != t.Name {
+			renamed := *t
+			renamed.Name = name
+			t = &renamed
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
 func resolveTables(t *parseutil.TableInfo, cache *database.DBCache) []*parseutil.TableInfo {
 	if _, ok := cache.ColumnDescs(t.Name); ok {
 		return []*parseutil.TableInfo{t}
@@ -266,7 +496,7 @@ func generateForeignKeyCandidate(target string,
 	tMap map[string]*parseutil.TableInfo,
 	aliases map[string]interface{},
 	fk *database.ForeignKey,
-	joinOn, lowercaseKeywords bool) lsp.CompletionItem {
+	joinOn bool, keywordCase ast.Case) lsp.CompletionItem {
 	var tAlias string
 	if joinOn {
 		tAlias = tMap[target].Alias
@@ -291,18 +521,12 @@ func generateForeignKeyCandidate(target string,
 	}
 	if !joinOn {
 		builder[1].alias = fmt.Sprintf("${1:%s}", tAlias)
-		onKw := "ON"
-		if lowercaseKeywords {
-			onKw = "on"
-		}
+		onKw := keywordCase.Apply("ON")
 		for _, b := range builder {
 			b.sb.WriteString(fmt.Sprintf("%s %s %s ", target, b.alias, onKw))
 		}
 	}
-	andKw := " AND "
-	if lowercaseKeywords {
-		andKw = " and "
-	}
+	andKw := " " + keywordCase.Apply("AND") + " "
 	prefix := ""
 	for _, cur := range *fk {
 		tIdx, rIdx := 0, 1
@@ -339,16 +563,18 @@ func generateTableCandidates(tables []string, dbCache *database.DBCache) []lsp.C
 	candidates := []lsp.CompletionItem{}
 	for _, tableName := range tables {
 		candidate := lsp.CompletionItem{
-			Label:  tableName,
-			Kind:   lsp.ClassCompletion,
-			Detail: "table",
+			Label:            tableName,
+			Kind:             lsp.ClassCompletion,
+			Detail:           "table",
+			CommitCharacters: memberCommitCharacters,
 		}
-		cols, ok := dbCache.ColumnDescs(tableName)
-		if ok {
-			candidate.Documentation = lsp.MarkupContent{
-				Kind:  lsp.Markdown,
-				Value: database.TableDoc(tableName, cols),
-			}
+		if _, ok := dbCache.ColumnDescs(tableName); ok {
+			candidate.Data = &TableDocData{Table: tableName}
+		}
+		// The tables of the schemas later in the search path follow the others
+		if schemaName, rank, ok := dbCache.TableSchema(tableName); ok && rank > 0 {
+			candidate.Detail = "table of " + schemaName
+			candidate.SortText = strings.Repeat("~", rank)
 		}
 		candidates = append(candidates, candidate)
 	}
@@ -359,16 +585,13 @@ func generateTableCandidatesBySchema(schemaName string, tables []string, dbCache
 	candidates := []lsp.CompletionItem{}
 	for _, tableName := range tables {
 		candidate := lsp.CompletionItem{
-			Label:  tableName,
-			Kind:   lsp.ClassCompletion,
-			Detail: "table",
+			Label:            tableName,
+			Kind:             lsp.ClassCompletion,
+			Detail:           "table",
+			CommitCharacters: memberCommitCharacters,
 		}
-		cols, ok := dbCache.ColumnDatabase(schemaName, tableName)
-		if ok {
-			candidate.Documentation = lsp.MarkupContent{
-				Kind:  lsp.Markdown,
-				Value: database.TableDoc(tableName, cols),
-			}
+		if _, ok := dbCache.ColumnDatabase(schemaName, tableName); ok {
+			candidate.Data = &TableDocData{Schema: schemaName, Table: tableName}
 		}
 		candidates = append(candidates, candidate)
 	}
@@ -385,23 +608,14 @@ func generateTableCandidatesByInfos(tables []*parseutil.TableInfo, dbCache *data
 			detail = "aliased table"
 		}
 		candidate := lsp.CompletionItem{
-			Label:  name,
-			Kind:   lsp.ClassCompletion,
-			Detail: detail,
-		}
-		var cols []*database.ColumnDesc
-		var ok bool
-
-		if table.DatabaseSchema != "" {
-			cols, ok = dbCache.ColumnDatabase(table.DatabaseSchema, table.Name)
-		} else {
-			cols, ok = dbCache.ColumnDescs(table.Name)
+			Label:            name,
+			Kind:             lsp.ClassCompletion,
+			Detail:           detail,
+			CommitCharacters: memberCommitCharacters,
 		}
-		if ok {
-			candidate.Documentation = lsp.MarkupContent{
-				Kind:  lsp.Markdown,
-				Value: database.TableDoc(table.Name, cols),
-			}
+		data := &TableDocData{Schema: table.DatabaseSchema, Table: table.Name}
+		if _, ok := data.columns(dbCache); ok {
+			candidate.Data = data
 		}
 		candidates = append(candidates, candidate)
 	}
@@ -412,10 +626,11 @@ func (c *Completer) SubQueryCandidates(infos []*parseutil.SubQueryInfo) []lsp.Co
 	candidates := []lsp.CompletionItem{}
 	for _, info := range infos {
 		candidate := lsp.CompletionItem{
-			Label:  info.Name,
-			Kind:   lsp.FieldCompletion,
-			Detail: "subquery",
-			Documentation: lsp.MarkupContent{
+			Label:            info.Name,
+			Kind:             lsp.ClassCompletion,
+			Detail:           "subquery",
+			CommitCharacters: memberCommitCharacters,
+			Documentation: &lsp.MarkupContent{
 				Kind:  lsp.Markdown,
 				Value: database.SubqueryDoc(info.Name, info.Views, c.DBCache),
 			},
@@ -437,10 +652,11 @@ func (c *Completer) SubQueryColumnCandidates(infos []*parseutil.SubQueryInfo) []
 					}
 					for _, tableCol := range tableCols {
 						candidate := lsp.CompletionItem{
-							Label:  tableCol.Name,
-							Kind:   lsp.FieldCompletion,
-							Detail: subQueryColumnDetail(info.Name),
-							Documentation: lsp.MarkupContent{
+							Label:        tableCol.Name,
+							LabelDetails: columnLabelDetails(col.ParentTable.Name, tableCol),
+							Kind:         lsp.FieldCompletion,
+							Detail:       subQueryColumnDetail(info.Name),
+							Documentation: &lsp.MarkupContent{
 								Kind:  lsp.Markdown,
 								Value: database.SubqueryColumnDoc(tableCol.Name, info.Views, c.DBCache),
 							},
@@ -452,7 +668,7 @@ func (c *Completer) SubQueryColumnCandidates(infos []*parseutil.SubQueryInfo) []
 						Label:  col.DisplayName(),
 						Kind:   lsp.FieldCompletion,
 						Detail: subQueryColumnDetail(info.Name),
-						Documentation: lsp.MarkupContent{
+						Documentation: &lsp.MarkupContent{
 							Kind:  lsp.Markdown,
 							Value: database.SubqueryColumnDoc(col.DisplayName(), info.Views, c.DBCache),
 						},
@@ -476,16 +692,157 @@ func subQueryColumnDetail(subQueryAliasName string) string {
 	return detail
 }
 
+// setOperationColumnCandidates returns the output columns of the first SELECT
+// of UNION, INTERSECT and EXCEPT.
+func (c *Completer) setOperationColumnCandidates(cols []*parseutil.SubQueryColumn) []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	for _, col := range cols {
+		if col.ParentTable != nil && col.ColumnName == "*" {
+			columns, ok := c.DBCache.ColumnDescs(col.ParentTable.Name)
+			if !ok {
+				continue
+			}
+			candidates = append(candidates, generateColumnCandidates(col.ParentTable.Name, columns, c.DBCache)...)
+			continue
+		}
+		candidate := lsp.CompletionItem{
+			Label:  col.DisplayName(),
+			Kind:   lsp.FieldCompletion,
+			Detail: "output column",
+		}
+		if col.ParentTable != nil {
+			if column, ok := c.DBCache.Column(col.ParentTable.Name, col.ColumnName); ok {
+				candidate.Documentation = &lsp.MarkupContent{
+					Kind:  lsp.Markdown,
+					Value: database.ColumnDoc(col.ParentTable.Name, column, c.DBCache),
+				}
+				candidate.LabelDetails = columnLabelDetails(col.ParentTable.Name, column)
+				MarkDeprecated(&candidate, column.Comment)
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
 func (c *Completer) SchemaCandidates() []lsp.CompletionItem {
 	candidates := []lsp.CompletionItem{}
 	dbs := c.DBCache.SortedSchemas()
 	for _, db := range dbs {
+		candidate := lsp.CompletionItem{
+			Label:            db,
+			Kind:             lsp.ModuleCompletion,
+			Detail:           "schema",
+			CommitCharacters: memberCommitCharacters,
+		}
+		candidates = append(candidates, candidate)
+	}
+	// The other databases qualify the schemas in the names of three parts
+	if c.DBCache.CrossDatabase {
+		for _, db := range c.DBCache.Databases {
+			if _, ok := c.DBCache.Database(db); ok {
+				continue
+			}
+			candidate := lsp.CompletionItem{
+				Label:            db,
+				Kind:             lsp.ModuleCompletion,
+				Detail:           "database",
+				CommitCharacters: memberCommitCharacters,
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// databaseCandidates returns the databases of the server. The names of the
+// new databases of CREATE DATABASE and ATTACH are flagged as the duplicates
+// when the databases exist.
+func (c *Completer) databaseCandidates(create bool) []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	for _, db := range c.DBCache.Databases {
 		candidate := lsp.CompletionItem{
 			Label:  db,
 			Kind:   lsp.ModuleCompletion,
-			Detail: "schema",
+			Detail: "database",
+		}
+		if create {
+			candidate.Detail = "database, exists"
+			candidate.Documentation = &lsp.MarkupContent{
+				Kind:  lsp.Markdown,
+				Value: fmt.Sprintf("The database `%s` exists already", db),
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// pragmaCandidates returns the pragmas of SQLite.
+func pragmaCandidates() []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	for _, p := range dialect.SQLitePragmas() {
+		candidate := lsp.CompletionItem{
+			Label:  p.Name,
+			Kind:   lsp.PropertyCompletion,
+			Detail: "pragma",
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// roleCandidates returns the roles and the users of the database.
+func (c *Completer) roleCandidates() []lsp.CompletionItem {
+	candidates := []lsp.CompletionItem{}
+	for _, role := range c.DBCache.Roles {
+		candidate := lsp.CompletionItem{
+			Label:  role,
+			Kind:   lsp.VariableCompletion,
+			Detail: "role",
 		}
 		candidates = append(candidates, candidate)
 	}
 	return candidates
 }
+
+// fileCandidates returns the files and the directories whose names start with
+// the last part of the path, which replace it. The relative paths are the
+// ones from the working directory.
+func fileCandidates(path string, pos lsp.Position) []lsp.CompletionItem {
+	dir, prefix := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		dir, prefix = path[:i+1], path[i+1:]
+	}
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return []lsp.CompletionItem{}
+	}
+	start := lsp.Position{Line: pos.Line, Character: pos.Character - len([]rune(prefix))}
+	candidates := []lsp.CompletionItem{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		kind, detail := lsp.FileCompletion, "file"
+		if entry.IsDir() {
+			name += "/"
+			kind, detail = lsp.FolderCompletion, "directory"
+		}
+		candidates = append(candidates, lsp.CompletionItem{
+			Label:  name,
+			Kind:   kind,
+			Detail: detail,
+			TextEdit: &lsp.TextEdit{
+				Range:   lsp.Range{Start: start, End: pos},
+				NewText: name,
+			},
+		})
+	}
+	return candidates
+}
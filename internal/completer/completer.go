@@ -34,6 +34,15 @@ const (
 	CompletionTypeSchema
 	CompletionTypeJoin
 	CompletionTypeJoinOn
+	CompletionTypeSetOperationColumn
+	CompletionTypeSyntaxKeyword
+	CompletionTypeDataType
+	CompletionTypeTableColumn
+	CompletionTypeReferencedColumn
+	CompletionTypeFile
+	CompletionTypeDatabase
+	CompletionTypePragma
+	CompletionTypeCompositeField
 )
 
 func (ct completionType) String() string {
@@ -64,6 +73,24 @@ func (ct completionType) String() string {
 		return "Join clause"
 	case CompletionTypeJoinOn:
 		return "Join On condition"
+	case CompletionTypeSetOperationColumn:
+		return "SetOperationColumn"
+	case CompletionTypeSyntaxKeyword:
+		return "SyntaxKeyword"
+	case CompletionTypeDataType:
+		return "DataType"
+	case CompletionTypeTableColumn:
+		return "TableColumn"
+	case CompletionTypeReferencedColumn:
+		return "ReferencedColumn"
+	case CompletionTypeFile:
+		return "File"
+	case CompletionTypeDatabase:
+		return "Database"
+	case CompletionTypePragma:
+		return "Pragma"
+	case CompletionTypeCompositeField:
+		return "CompositeField"
 	default:
 		return ""
 	}
@@ -72,6 +99,19 @@ func (ct completionType) String() string {
 type Completer struct {
 	DBCache *database.DBCache
 	Driver  dialect.DatabaseDriver
+	// KeywordCase takes precedence over lowercaseKeywords when it is set
+	KeywordCase ast.Case
+	// IdentifierCase applies to the names of tables, columns and schemas
+	// that are not quoted
+	IdentifierCase ast.Case
+	// PartitionCompletion is "hide" (the default), "group" or "show", whether
+	// the partitions of the partitioned tables are left out of the tables,
+	// follow their tables or are completed as the other tables
+	PartitionCompletion string
+	// Document parses the text again only in the statements changed from
+	// the last completion of the document. It is in the generic dialect, and
+	// the whole text is parsed when it is nil.
+	Document *parser.Document
 }
 
 func NewCompleter(dbCache *database.DBCache) *Completer {
@@ -90,15 +130,22 @@ func completionTypeIs(completionTypes []completionType, expect completionType) b
 }
 
 func (c *Completer) Complete(text string, params lsp.CompletionParams, lowercaseKeywords bool) ([]lsp.CompletionItem, error) {
-	parsed, err := parser.Parse(text)
-	if err != nil {
-		return nil, err
-	}
-
 	pos := token.Pos{
 		Line: params.Position.Line,
 		Col:  params.Position.Character,
 	}
+	body := dollarQuotedBody(text, pos)
+	var parsed ast.TokenList
+	var err error
+	if c.Document != nil && body == text {
+		parsed, err = c.Document.Parse(text)
+	} else {
+		parsed, err = parser.Parse(body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	text = body
 
 	nodeWalker := parseutil.NewNodeWalker(parsed, pos)
 	ctx := getCompletionTypes(nodeWalker)
@@ -106,6 +153,84 @@ func (c *Completer) Complete(text string, params lsp.CompletionParams, lowercase
 		return nil, err
 	}
 
+	// The definitions of CREATE TABLE and ALTER TABLE
+	ddl, err := parseutil.ExtractDDL(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	if ddl != nil {
+		ctx = getDDLCompletionTypes(ddl)
+	}
+
+	// GRANT, REVOKE and the role statements
+	grant, err := parseutil.ExtractGrant(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	if grant != nil {
+		ctx = getGrantCompletionTypes(grant)
+	}
+
+	// COPY, \copy and LOAD DATA
+	copyStmt, err := parseutil.ExtractCopy(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	if copyStmt != nil {
+		ctx = getCopyCompletionTypes(copyStmt)
+	}
+
+	// USE and the statements on the databases
+	dbStmt, err := parseutil.ExtractDatabase(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	if dbStmt != nil {
+		ctx = getDatabaseCompletionTypes(dbStmt)
+	}
+
+	// PRAGMA of SQLite
+	if c.Driver == dialect.DatabaseDriverSQLite3 {
+		pragma, err := parseutil.ExtractPragma(parsed, pos)
+		if err != nil {
+			return nil, err
+		}
+		if pragma != nil {
+			ctx = getPragmaCompletionTypes(pragma)
+		}
+	}
+
+	// The types of :: and CAST()
+	isCast, err := parseutil.IsCastType(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	if isCast {
+		ctx = &CompletionContext{
+			types:  []completionType{CompletionTypeDataType},
+			parent: noneParent,
+		}
+	}
+
+	// The fields of the composite column in the parentheses, (address).
+	composite := compositeColumn(text, params.Position.Line+1, params.Position.Character)
+	if composite != "" {
+		ctx = &CompletionContext{
+			types:  []completionType{CompletionTypeCompositeField},
+			parent: noneParent,
+		}
+	}
+
+	// ORDER BY of UNION, INTERSECT and EXCEPT sorts the results by the
+	// output columns of the first SELECT
+	setOperation, err := parseutil.ExtractSetOperation(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	if setOperation != nil && setOperation.IsOrderBy(pos) && ctx.parent == noneParent && completionTypeIs(ctx.types, CompletionTypeColumn) {
+		ctx.types = []completionType{CompletionTypeSetOperationColumn}
+	}
+
 	definedTables, err := parseutil.ExtractTable(parsed, pos)
 	if err != nil {
 		return nil, err
@@ -117,55 +242,77 @@ func (c *Completer) Complete(text string, params lsp.CompletionParams, lowercase
 
 	lastWord := getLastWord(text, params.Position.Line+1, params.Position.Character)
 	withBackQuote := strings.HasPrefix(lastWord, "`")
+	identifiers := func(candidates []lsp.CompletionItem) []lsp.CompletionItem {
+		if withBackQuote {
+			return toQuotedCandidates(candidates)
+		}
+		return withIdentifierCase(candidates, c.IdentifierCase)
+	}
+	keywordCase := c.KeywordCase
+	if keywordCase == "" {
+		keywordCase = ast.CaseUpper
+		if lowercaseKeywords {
+			keywordCase = ast.CaseLower
+		}
+	}
 
 	var items []lsp.CompletionItem
 
 	if c.DBCache != nil {
 		if completionTypeIs(ctx.types, CompletionTypeColumn) {
 			candidates := c.columnCandidates(definedTables, ctx.parent)
-			if withBackQuote {
-				candidates = toQuotedCandidates(candidates)
+			if ctx.insert {
+				candidates = c.assignableColumnCandidates(candidates, definedTables)
 			}
-			items = append(items, candidates...)
+			items = append(items, identifiers(candidates)...)
 		}
 		if completionTypeIs(ctx.types, CompletionTypeReferencedTable) {
 			candidates := c.ReferencedTableCandidates(definedTables)
-			if withBackQuote {
-				candidates = toQuotedCandidates(candidates)
-			}
-			items = append(items, candidates...)
+			items = append(items, identifiers(candidates)...)
 		}
 		if completionTypeIs(ctx.types, CompletionTypeTable) {
 			excl := definedTables
-			if completionTypeIs(ctx.types, CompletionTypeJoin) {
+			if completionTypeIs(ctx.types, CompletionTypeJoin) || ddl != nil || grant != nil || copyStmt != nil {
 				excl = nil
 			}
 			candidates := c.TableCandidates(ctx.parent, excl)
-			if withBackQuote {
-				candidates = toQuotedCandidates(candidates)
-			}
-			items = append(items, candidates...)
+			items = append(items, identifiers(candidates)...)
 		}
 		if completionTypeIs(ctx.types, CompletionTypeSchema) {
 			candidates := c.SchemaCandidates()
-			if withBackQuote {
-				candidates = toQuotedCandidates(candidates)
-			}
-			items = append(items, candidates...)
+			items = append(items, identifiers(candidates)...)
+		}
+		if completionTypeIs(ctx.types, CompletionTypeCompositeField) {
+			candidates := c.compositeFieldCandidates(definedTables, composite)
+			items = append(items, identifiers(candidates)...)
+		}
+		if completionTypeIs(ctx.types, CompletionTypeDatabase) {
+			candidates := c.databaseCandidates(dbStmt.Create)
+			items = append(items, identifiers(candidates)...)
 		}
 		if completionTypeIs(ctx.types, CompletionTypeSubQuery) {
 			candidates := c.SubQueryCandidates(definedSubQueries)
-			if withBackQuote {
-				candidates = toQuotedCandidates(candidates)
-			}
-			items = append(items, candidates...)
+			items = append(items, identifiers(candidates)...)
 		}
 		if completionTypeIs(ctx.types, CompletionTypeSubQueryColumn) {
 			candidates := c.SubQueryColumnCandidates(definedSubQueries)
-			if withBackQuote {
-				candidates = toQuotedCandidates(candidates)
+			items = append(items, identifiers(candidates)...)
+		}
+		if completionTypeIs(ctx.types, CompletionTypeUser) {
+			candidates := c.roleCandidates()
+			items = append(items, identifiers(candidates)...)
+		}
+		if completionTypeIs(ctx.types, CompletionTypeReferencedColumn) {
+			candidates := c.referencedColumnCandidates(ddl.Referenced)
+			items = append(items, identifiers(candidates)...)
+		}
+		if completionTypeIs(ctx.types, CompletionTypeSetOperationColumn) {
+			cols, err := setOperation.Columns()
+			if err != nil {
+				return nil, err
 			}
-			items = append(items, candidates...)
+			candidates := c.setOperationColumnCandidates(cols)
+			items = append(items, identifiers(candidates)...)
 		}
 		joinOn := completionTypeIs(ctx.types, CompletionTypeJoinOn)
 		if completionTypeIs(ctx.types, CompletionTypeJoin) || joinOn {
@@ -177,7 +324,7 @@ func (c *Completer) Complete(text string, params lsp.CompletionParams, lowercase
 			if err != nil {
 				return nil, err
 			}
-			candidates := c.joinCandidates(table, tables, definedTables, joinOn, lowercaseKeywords)
+			candidates := c.joinCandidates(table, tables, definedTables, joinOn, keywordCase)
 			if withBackQuote {
 				candidates = toQuotedCandidates(candidates) // what to do here?
 			}
@@ -186,24 +333,52 @@ func (c *Completer) Complete(text string, params lsp.CompletionParams, lowercase
 	}
 
 	if completionTypeIs(ctx.types, CompletionTypeKeyword) {
-		drivers := dialect.DataBaseKeywords(c.Driver)
-		items = append(items, c.keywordCandidates(lowercaseKeywords, drivers)...)
+		// The keywords which may follow in the clause of the query
+		clause, err := parseutil.ExtractClause(parsed, pos)
+		if err != nil {
+			return nil, err
+		}
+		drivers := filterClauseKeywords(dialect.DataBaseKeywords(c.Driver), clause)
+		items = append(items, c.keywordCandidates(keywordCase, drivers)...)
 	}
 	if completionTypeIs(ctx.types, CompletionTypeFunction) {
 		drivers := dialect.DataBaseFunctions(c.Driver)
-		items = append(items, c.functionCandidates(lowercaseKeywords, drivers)...)
+		items = append(items, c.functionCandidates(keywordCase, drivers)...)
+	}
+	if completionTypeIs(ctx.types, CompletionTypeSyntaxKeyword) {
+		items = append(items, c.keywordCandidates(keywordCase, ctx.keywords)...)
+	}
+	if completionTypeIs(ctx.types, CompletionTypeDataType) {
+		types := dialect.DataBaseDataTypes(c.Driver)
+		items = append(items, c.dataTypeCandidates(keywordCase, types)...)
+		if c.DBCache != nil {
+			items = append(items, identifiers(c.userTypeCandidates())...)
+		}
+	}
+	if completionTypeIs(ctx.types, CompletionTypePragma) {
+		items = append(items, pragmaCandidates()...)
+	}
+	if completionTypeIs(ctx.types, CompletionTypeTableColumn) {
+		candidates := c.tableColumnCandidates(ctx.table, ctx.columns)
+		items = append(items, identifiers(candidates)...)
 	}
 
 	items = filterCandidates(items, lastWord)
+	// The paths are filtered by the names in them, which are not the words
+	if completionTypeIs(ctx.types, CompletionTypeFile) {
+		items = append(items, fileCandidates(copyStmt.Path, params.Position)...)
+	}
 	populateSortText(items)
 
 	return items, nil
 }
 
 // Override the sort text for each completion item.
+// The sort texts given to the candidates, such as to the tables later in the
+// search path, rank them after the others of the same kinds.
 func populateSortText(items []lsp.CompletionItem) {
 	for i := range items {
-		items[i].SortText = getSortTextPrefix(items[i].Kind) + items[i].Label
+		items[i].SortText = getSortTextPrefix(items[i].Kind) + items[i].SortText + items[i].Label
 	}
 }
 
@@ -261,6 +436,8 @@ const (
 type completionParent struct {
 	Type ParentType
 	Name string
+	// Catalog is the database of the schema of the names of three parts
+	Catalog string
 }
 
 var noneParent = &completionParent{Type: ParentTypeNone}
@@ -268,6 +445,15 @@ var noneParent = &completionParent{Type: ParentTypeNone}
 type CompletionContext struct {
 	types  []completionType
 	parent *completionParent
+	// keywords are the keywords that the syntax allows at the position
+	keywords []string
+	// table is the table of CompletionTypeTableColumn, and columns are the
+	// columns of it defined before the position
+	table   *parseutil.TableInfo
+	columns []string
+	// insert is whether the columns are the ones of the column list of
+	// INSERT, which leaves out the columns that cannot be assigned
+	insert bool
 }
 
 func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
@@ -277,6 +463,8 @@ func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
 
 	syntaxPos := parseutil.CheckSyntaxPosition(nw)
 	var t []completionType
+	var keywords []string
+	var insert bool
 	p := noneParent
 	switch {
 	case syntaxPos == parseutil.ColName:
@@ -343,6 +531,9 @@ func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
 				Type: ParentTypeSchema,
 				Name: mi.ParentTok.NoQuoteString(),
 			}
+			if mi.CatalogTok != nil {
+				p.Catalog = mi.CatalogTok.NoQuoteString()
+			}
 		} else {
 			t = []completionType{
 				CompletionTypeTable,
@@ -398,17 +589,155 @@ func getCompletionTypes(nw *parseutil.NodeWalker) *CompletionContext {
 			CompletionTypeColumn,
 			CompletionTypeView,
 		}
+		insert = true
+	case syntaxPos == parseutil.InsertValue && nw.CurNodeIs(memberIdentifierMatcher):
+		// the columns of the source table of MERGE
+		mi := nw.CurNodeTopMatched(memberIdentifierMatcher).(*ast.MemberIdentifier)
+		t = []completionType{
+			CompletionTypeColumn,
+			CompletionTypeSubQueryColumn,
+		}
+		p = &completionParent{
+			Type: ParentTypeTable,
+			Name: mi.ParentTok.NoQuoteString(),
+		}
+	case syntaxPos == parseutil.WindowFrame:
+		t = []completionType{
+			CompletionTypeSyntaxKeyword,
+		}
+		keywords = []string{"BETWEEN", "UNBOUNDED PRECEDING", "CURRENT ROW"}
+	case syntaxPos == parseutil.FrameStart:
+		t = []completionType{
+			CompletionTypeSyntaxKeyword,
+		}
+		keywords = []string{"UNBOUNDED PRECEDING", "CURRENT ROW"}
+	case syntaxPos == parseutil.FrameEnd:
+		t = []completionType{
+			CompletionTypeSyntaxKeyword,
+		}
+		keywords = []string{"UNBOUNDED FOLLOWING", "CURRENT ROW"}
 	default:
 		t = []completionType{
 			CompletionTypeKeyword,
 		}
 	}
 	return &CompletionContext{
-		types:  t,
-		parent: p,
+		types:    t,
+		parent:   p,
+		keywords: keywords,
+		insert:   insert,
 	}
 }
 
+func getDDLCompletionTypes(ddl *parseutil.DDL) *CompletionContext {
+	ctx := &CompletionContext{parent: noneParent}
+	switch ddl.Position {
+	case parseutil.DDLKeyword:
+		ctx.types = []completionType{CompletionTypeSyntaxKeyword}
+		ctx.keywords = ddl.Keywords
+	case parseutil.DDLDataType:
+		ctx.types = []completionType{CompletionTypeDataType}
+	case parseutil.DDLTable:
+		ctx.types = []completionType{
+			CompletionTypeTable,
+			CompletionTypeSchema,
+		}
+	case parseutil.DDLTableColumn:
+		ctx.types = []completionType{CompletionTypeTableColumn}
+		ctx.table = ddl.Table
+		ctx.columns = ddl.Columns
+	case parseutil.DDLReferencedColumn:
+		ctx.types = []completionType{CompletionTypeReferencedColumn}
+	}
+	return ctx
+}
+
+func getGrantCompletionTypes(grant *parseutil.Grant) *CompletionContext {
+	ctx := &CompletionContext{parent: noneParent, keywords: grant.Keywords}
+	switch grant.Position {
+	case parseutil.GrantKeyword:
+		ctx.types = []completionType{CompletionTypeSyntaxKeyword}
+	case parseutil.GrantPrivilege:
+		ctx.types = []completionType{
+			CompletionTypeSyntaxKeyword,
+			CompletionTypeUser,
+		}
+	case parseutil.GrantObject:
+		ctx.types = []completionType{
+			CompletionTypeSyntaxKeyword,
+			CompletionTypeTable,
+			CompletionTypeSchema,
+		}
+	case parseutil.GrantTable:
+		ctx.types = []completionType{
+			CompletionTypeTable,
+			CompletionTypeSchema,
+		}
+	case parseutil.GrantSchema:
+		ctx.types = []completionType{CompletionTypeSchema}
+	case parseutil.GrantRole:
+		ctx.types = []completionType{
+			CompletionTypeSyntaxKeyword,
+			CompletionTypeUser,
+		}
+	}
+	return ctx
+}
+
+func getCopyCompletionTypes(copyStmt *parseutil.Copy) *CompletionContext {
+	ctx := &CompletionContext{parent: noneParent, keywords: copyStmt.Keywords}
+	switch copyStmt.Position {
+	case parseutil.CopyKeyword:
+		ctx.types = []completionType{CompletionTypeSyntaxKeyword}
+	case parseutil.CopyTable:
+		ctx.types = []completionType{
+			CompletionTypeTable,
+			CompletionTypeSchema,
+		}
+	case parseutil.CopyColumn:
+		ctx.types = []completionType{CompletionTypeTableColumn}
+		ctx.table = copyStmt.Table
+	case parseutil.CopyFile:
+		ctx.types = []completionType{CompletionTypeFile}
+	}
+	return ctx
+}
+
+func getDatabaseCompletionTypes(dbStmt *parseutil.Database) *CompletionContext {
+	ctx := &CompletionContext{parent: noneParent, keywords: dbStmt.Keywords}
+	switch dbStmt.Position {
+	case parseutil.DatabaseKeyword:
+		ctx.types = []completionType{CompletionTypeSyntaxKeyword}
+	case parseutil.DatabaseName:
+		ctx.types = []completionType{
+			CompletionTypeSyntaxKeyword,
+			CompletionTypeDatabase,
+		}
+	}
+	return ctx
+}
+
+func getPragmaCompletionTypes(pragma *parseutil.Pragma) *CompletionContext {
+	ctx := &CompletionContext{parent: noneParent}
+	switch pragma.Position {
+	case parseutil.PragmaName:
+		ctx.types = []completionType{CompletionTypePragma}
+	case parseutil.PragmaValue:
+		// The values that are not enumerated, such as the numbers, are not
+		// completed
+		p, ok := dialect.LookupSQLitePragma(pragma.Name)
+		switch {
+		case !ok:
+		case p.Table:
+			ctx.types = []completionType{CompletionTypeTable}
+		case len(p.Values) > 0:
+			ctx.types = []completionType{CompletionTypeSyntaxKeyword}
+			ctx.keywords = p.Values
+		}
+	}
+	return ctx
+}
+
 func filterCandidates(candidates []lsp.CompletionItem, lastWord string) []lsp.CompletionItem {
 	filtered := []lsp.CompletionItem{}
 	for _, candidate := range candidates {
@@ -443,6 +772,21 @@ func getLastWord(text string, line, char int) string {
 	return ss[len(ss)-1]
 }
 
+// compositeColumnRe is the column in the parentheses followed by the field
+// being typed, such as (c.address).st
+var compositeColumnRe = regexp.MustCompile(`\(\s*((?:[\w"]+\.)?[\w"]+)\s*\)\.\w*$`)
+
+// compositeColumn returns the column whose fields are accessed at the
+// position, or "".
+func compositeColumn(text string, line, char int) string {
+	s := getLine(getBeforeCursorText(text, line, char), line)
+	m := compositeColumnRe.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
 func getBeforeCursorText(text string, line, char int) string {
 	writer := bytes.NewBufferString("")
 	scanner := bufio.NewScanner(strings.NewReader(text))
@@ -460,6 +804,73 @@ func getBeforeCursorText(text string, line, char int) string {
 	return writer.String()
 }
 
+// dollarQuotedBody returns the text with the characters out of the dollar
+// quoted string at the position replaced with the spaces, so that the body of
+// a function of PostgreSQL is parsed as the statements at their positions. The
+// text is returned as it is if the position is not in a dollar quoted string.
+func dollarQuotedBody(text string, pos token.Pos) string {
+	for {
+		tokenizer := token.NewTokenizer(strings.NewReader(text), &dialect.GenericSQLDialect{})
+		toks, err := tokenizer.Tokenize()
+		if err != nil {
+			return text
+		}
+		var from, to token.Pos
+		found := false
+		for _, tok := range toks {
+			if tok.Kind != token.DollarQuotedString {
+				continue
+			}
+			value, _ := tok.Value.(string)
+			tag := value[:strings.Index(value[1:], "$")+2]
+			from, to = tok.From, tok.To
+			from.Col += len([]rune(tag))
+			// unclosed at the end of the text
+			if len(value) >= len(tag)*2 && strings.HasSuffix(value, tag) {
+				to.Col -= len([]rune(tag))
+			}
+			if token.ComparePos(from, pos) <= 0 && token.ComparePos(pos, to) <= 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return text
+		}
+
+		var b strings.Builder
+		var cur token.Pos
+		for _, r := range text {
+			switch {
+			case r == '\n':
+				b.WriteRune(r)
+				cur.Line++
+				cur.Col = 0
+				continue
+			case token.ComparePos(cur, from) < 0 || token.ComparePos(cur, to) >= 0:
+				b.WriteRune(' ')
+			default:
+				b.WriteRune(r)
+			}
+			cur.Col++
+		}
+		// the dollar quoted strings in the body are parsed again
+		text = b.String()
+	}
+}
+
+func withIdentifierCase(candidates []lsp.CompletionItem, c ast.Case) []lsp.CompletionItem {
+	if c == "" || c == ast.CasePreserve {
+		return candidates
+	}
+	cased := make([]lsp.CompletionItem, len(candidates))
+	for i, candidate := range candidates {
+		candidate.Label = c.Apply(candidate.Label)
+		cased[i] = candidate
+	}
+	return cased
+}
+
 func toQuotedCandidates(candidates []lsp.CompletionItem) []lsp.CompletionItem {
 	quotedCandidates := make([]lsp.CompletionItem, len(candidates))
 	for i, candidate := range candidates {
@@ -2,9 +2,13 @@ package completer
 
 import (
 	"reflect"
+	"sort"
 	"testing"
 
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/internal/database"
 	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/token"
 )
 
 func TestGetBeforeCursorText(t *testing.T) {
@@ -65,6 +69,29 @@ FROM
 	}
 }
 
+func TestDollarQuotedBody(t *testing.T) {
+	input := "CREATE FUNCTION f() AS $$\nBEGIN\n  EXECUTE $q$SELECT 1$q$;\nEND;\n$$ LANGUAGE sql;"
+	tests := []struct {
+		name string
+		in   string
+		pos  token.Pos
+		out  string
+	}{
+		{"out of body", input, token.Pos{Line: 0, Col: 3}, input},
+		{"body", input, token.Pos{Line: 1, Col: 2}, "                         \nBEGIN\n  EXECUTE $q$SELECT 1$q$;\nEND;\n                "},
+		{"nested", input, token.Pos{Line: 2, Col: 14}, "                         \n     \n             SELECT 1    \n    \n                "},
+		{"unclosed", "SELECT $$ab", token.Pos{Line: 0, Col: 11}, "         ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dollarQuotedBody(tt.in, tt.pos)
+			if tt.out != got {
+				t.Errorf("want %#v, got %#v", tt.out, got)
+			}
+		})
+	}
+}
+
 func Test_completionTypeIs(t *testing.T) {
 	type args struct {
 	}
@@ -112,10 +139,11 @@ func Test_completionTypeIs(t *testing.T) {
 
 func TestComplete(t *testing.T) {
 	tests := []struct {
-		name      string
-		text      string
-		lowerCase bool
-		expected  []lsp.CompletionItem
+		name        string
+		text        string
+		lowerCase   bool
+		keywordCase ast.Case
+		expected    []lsp.CompletionItem
 	}{
 		{
 			name: "keyword",
@@ -142,11 +170,26 @@ func TestComplete(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "keyword-capitalize",
+			text:        "sel",
+			lowerCase:   true,
+			keywordCase: ast.CaseCapitalize,
+			expected: []lsp.CompletionItem{
+				{
+					Label:    "Select",
+					Kind:     lsp.KeywordCompletion,
+					Detail:   "keyword",
+					SortText: "9999Select",
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
 			c := NewCompleter(nil)
+			c.KeywordCase = tt.keywordCase
 			got, err := c.Complete("sel", lsp.CompletionParams{
 				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
 					Position: lsp.Position{
@@ -200,3 +243,321 @@ func TestGenerateAlias(t *testing.T) {
 		})
 	}
 }
+
+func TestWithIdentifierCase(t *testing.T) {
+	candidates := []lsp.CompletionItem{
+		{Label: "CITY", Kind: lsp.ClassCompletion},
+		{Label: "CountryCode", Kind: lsp.FieldCompletion},
+	}
+	got := withIdentifierCase(candidates, ast.CaseLower)
+	if got[0].Label != "city" || got[1].Label != "countrycode" {
+		t.Errorf("unexpected labels, %+v", got)
+	}
+	if candidates[0].Label != "CITY" {
+		t.Error("the candidates must not be changed")
+	}
+	if got := withIdentifierCase(candidates, ast.CasePreserve); got[1].Label != "CountryCode" {
+		t.Errorf("preserve must keep the label, got %q", got[1].Label)
+	}
+}
+
+func TestCandidateKinds(t *testing.T) {
+	c := NewCompleter(nil)
+	functions := c.functionCandidates(ast.CaseUpper, []string{"COUNT"})
+	if functions[0].Kind != lsp.FunctionCompletion || !reflect.DeepEqual(functions[0].CommitCharacters, []string{"("}) {
+		t.Errorf("unexpected function candidate, %+v", functions[0])
+	}
+	tables := generateTableCandidates([]string{"city"}, &database.DBCache{})
+	if tables[0].Kind != lsp.ClassCompletion || !reflect.DeepEqual(tables[0].CommitCharacters, []string{"."}) {
+		t.Errorf("unexpected table candidate, %+v", tables[0])
+	}
+
+	columns := generateColumnCandidates("city", []*database.ColumnDesc{
+		{ColumnBase: database.ColumnBase{Name: "ID"}},
+		{ColumnBase: database.ColumnBase{Name: "Code"}, Comment: "Deprecated: use CountryCode"},
+	}, nil)
+	if columns[0].Kind != lsp.FieldCompletion || columns[0].Deprecated || len(columns[0].Tags) != 0 {
+		t.Errorf("unexpected column candidate, %+v", columns[0])
+	}
+	if !columns[1].Deprecated || !reflect.DeepEqual(columns[1].Tags, []lsp.CompletionItemTag{lsp.CompletionItemTagDeprecated}) {
+		t.Errorf("expected deprecated column candidate, %+v", columns[1])
+	}
+}
+
+func TestDatabaseCandidates(t *testing.T) {
+	cache := database.NewDBCache("dbo")
+	cache.AddTable("dbo", "orders", nil)
+	cache.Databases = []string{"archive", "dbo"}
+	c := NewCompleter(cache)
+
+	databases := c.databaseCandidates(false)
+	if len(databases) != 2 || databases[0].Detail != "database" || databases[0].Documentation != nil {
+		t.Errorf("unexpected database candidates, %+v", databases)
+	}
+	// The databases exist already for CREATE DATABASE
+	databases = c.databaseCandidates(true)
+	if databases[0].Detail != "database, exists" || databases[0].Documentation == nil {
+		t.Errorf("expected duplicate database candidate, %+v", databases[0])
+	}
+
+	labels := func(items []lsp.CompletionItem) []string {
+		var l []string
+		for _, item := range items {
+			l = append(l, item.Label)
+		}
+		return l
+	}
+	if got := labels(c.SchemaCandidates()); !reflect.DeepEqual(got, []string{"dbo"}) {
+		t.Errorf("unexpected schema candidates, %v", got)
+	}
+	// The other databases qualify the schemas
+	cache.CrossDatabase = true
+	if got := labels(c.SchemaCandidates()); !reflect.DeepEqual(got, []string{"dbo", "archive"}) {
+		t.Errorf("unexpected schema candidates with cross database, %v", got)
+	}
+}
+
+func TestCompleteUserTypes(t *testing.T) {
+	cache := database.NewDBCache("public")
+	cache.AddTable("public", "customers", []*database.ColumnDesc{
+		{ColumnBase: database.ColumnBase{Schema: "public", Table: "customers", Name: "id"}, Type: "integer"},
+		{ColumnBase: database.ColumnBase{Schema: "public", Table: "customers", Name: "address"}, Type: "address"},
+	})
+	cache.Types = []*database.TypeDesc{
+		{Schema: "public", Name: "address", Kind: database.TypeKindComposite, Fields: []*database.TypeField{
+			{Name: "street", Type: "text"},
+			{Name: "zip", Type: "character varying(10)"},
+		}},
+		{Schema: "public", Name: "mood", Kind: database.TypeKindEnum, Labels: []string{"sad", "ok", "happy"}},
+	}
+	tests := []struct {
+		name string
+		text string
+		col  int
+		want []string
+		bad  []string
+	}{
+		{
+			name: "cast",
+			text: "SELECT id::",
+			want: []string{"mood", "address"},
+		},
+		{
+			name: "cast function",
+			text: "SELECT CAST(id AS ",
+			want: []string{"mood", "address"},
+		},
+		{
+			name: "composite field",
+			text: "SELECT (c.address). FROM customers c",
+			col:  len("SELECT (c.address)."),
+			want: []string{"street", "zip"},
+			bad:  []string{"id", "mood"},
+		},
+		{
+			name: "not composite",
+			text: "SELECT (id). FROM customers",
+			col:  len("SELECT (id)."),
+			bad:  []string{"street", "zip"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCompleter(cache)
+			col := tt.col
+			if col == 0 {
+				col = len(tt.text)
+			}
+			got, err := c.Complete(tt.text, lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					Position: lsp.Position{Line: 0, Character: col},
+				},
+			}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			labels := map[string]bool{}
+			for _, item := range got {
+				labels[item.Label] = true
+			}
+			for _, w := range tt.want {
+				if !labels[w] {
+					t.Errorf("%q is not completed", w)
+				}
+			}
+			for _, b := range tt.bad {
+				if labels[b] {
+					t.Errorf("%q is completed unexpectedly", b)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchPathTableCandidates(t *testing.T) {
+	cache := database.NewDBCache("app")
+	cache.AddTable("app", "orders", nil)
+	cache.AddTable("public", "accounts", nil)
+	cache.SearchPath = []string{"app", "public"}
+	c := NewCompleter(cache)
+
+	got, err := c.Complete("SELECT * FROM ", lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			Position: lsp.Position{Line: 0, Character: len("SELECT * FROM ")},
+		},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := map[string]lsp.CompletionItem{}
+	for _, item := range got {
+		if item.Kind == lsp.ClassCompletion {
+			tables[item.Label] = item
+		}
+	}
+	orders, accounts := tables["orders"], tables["accounts"]
+	if orders.Detail != "table" || accounts.Detail != "table of public" {
+		t.Fatalf("unexpected table candidates, %+v", tables)
+	}
+	// The tables of the first schema of the path come first
+	if orders.SortText >= accounts.SortText {
+		t.Errorf("unexpected order of tables, %q and %q", orders.SortText, accounts.SortText)
+	}
+}
+
+func TestPartitionCandidates(t *testing.T) {
+	cache := database.NewDBCache("public")
+	for _, table := range []string{"orders", "orders_2023", "orders_2024", "orders_archive"} {
+		cache.AddTable("public", table, nil)
+	}
+	cache.Partitions = []*database.PartitionDesc{
+		{
+			Schema:   "public",
+			Table:    "orders",
+			Strategy: "RANGE",
+			Key:      "ordered_at",
+			Partitions: []*database.PartitionTable{
+				{Schema: "public", Name: "orders_2023"},
+				{Schema: "public", Name: "orders_2024"},
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		completion string
+		text       string
+		want       []string
+		wantDetail string
+	}{
+		{
+			name: "hide",
+			text: "SELECT * FROM ",
+			want: []string{"orders", "orders_archive"},
+		},
+		{
+			name: "hide of schema",
+			text: "SELECT * FROM public.",
+			want: []string{"orders", "orders_archive"},
+		},
+		{
+			name:       "group",
+			completion: "group",
+			text:       "SELECT * FROM ",
+			want:       []string{"orders", "orders_2023", "orders_2024", "orders_archive"},
+			wantDetail: "partition of orders",
+		},
+		{
+			name:       "show",
+			completion: "show",
+			text:       "SELECT * FROM ",
+			want:       []string{"orders", "orders_2023", "orders_2024", "orders_archive"},
+			wantDetail: "table",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCompleter(cache)
+			c.PartitionCompletion = tt.completion
+			got, err := c.Complete(tt.text, lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					Position: lsp.Position{Line: 0, Character: len(tt.text)},
+				},
+			}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var tables []lsp.CompletionItem
+			for _, item := range got {
+				if item.Kind == lsp.ClassCompletion {
+					tables = append(tables, item)
+				}
+			}
+			// The partitions are grouped under their table by the sort texts
+			sort.Slice(tables, func(i, j int) bool { return tables[i].SortText < tables[j].SortText })
+			var labels []string
+			for _, item := range tables {
+				labels = append(labels, item.Label)
+				if item.Label == "orders_2024" && item.Detail != tt.wantDetail {
+					t.Errorf("unexpected detail of partition, %q", item.Detail)
+				}
+			}
+			if !reflect.DeepEqual(labels, tt.want) {
+				t.Errorf("unexpected tables, %v", labels)
+			}
+		})
+	}
+}
+
+func TestInsertColumnCandidates(t *testing.T) {
+	cache := database.NewDBCache("public")
+	cache.AddTable("public", "invoice", []*database.ColumnDesc{
+		{ColumnBase: database.ColumnBase{Schema: "public", Table: "invoice", Name: "id"}, Identity: database.IdentityAlways},
+		{ColumnBase: database.ColumnBase{Schema: "public", Table: "invoice", Name: "number"}, Identity: database.IdentityByDefault},
+		{ColumnBase: database.ColumnBase{Schema: "public", Table: "invoice", Name: "amount"}},
+		{ColumnBase: database.ColumnBase{Schema: "public", Table: "invoice", Name: "total"}, Generated: true},
+	})
+	tests := []struct {
+		name string
+		text string
+		col  int
+		want []string
+	}{
+		{
+			name: "insert columns",
+			text: "INSERT INTO invoice (",
+			want: []string{"number", "amount"},
+		},
+		{
+			name: "select columns",
+			text: "SELECT  FROM invoice",
+			col:  len("SELECT "),
+			want: []string{"id", "number", "amount", "total"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCompleter(cache)
+			col := tt.col
+			if col == 0 {
+				col = len(tt.text)
+			}
+			got, err := c.Complete(tt.text, lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					Position: lsp.Position{Line: 0, Character: col},
+				},
+			}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var columns []string
+			for _, item := range got {
+				if item.Kind == lsp.FieldCompletion {
+					columns = append(columns, item.Label)
+				}
+			}
+			if !reflect.DeepEqual(columns, tt.want) {
+				t.Errorf("unexpected columns, %v", columns)
+			}
+		})
+	}
+}
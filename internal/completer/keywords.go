@@ -0,0 +1,101 @@
+package completer
+
+import (
+	"github.com/sqls-server/sqls/parser/parseutil"
+)
+
+// The keywords of the parts shared by the clauses
+var (
+	statementKeywords = []string{
+		"ALTER", "ANALYZE", "ATTACH", "BEGIN", "CALL", "COMMENT", "COMMIT",
+		"COPY", "CREATE", "DECLARE", "DELETE", "DESC", "DESCRIBE", "DETACH",
+		"DO", "DROP", "EXEC", "EXECUTE", "EXPLAIN", "GRANT", "INSERT", "LOAD",
+		"LOCK", "MERGE", "PRAGMA", "REINDEX", "RELEASE", "RENAME", "REPLACE",
+		"RESET", "REVOKE", "ROLLBACK", "SAVEPOINT", "SELECT", "SET", "SHOW",
+		"START", "TRUNCATE", "UPDATE", "UPSERT", "USE", "VACUUM", "VALUES",
+		"WITH",
+	}
+	expressionKeywords = []string{
+		"AND", "ANY", "ALL", "BETWEEN", "CASE", "CAST", "COLLATE", "ELSE",
+		"END", "ESCAPE", "EXISTS", "FALSE", "GLOB", "ILIKE", "IN", "IS",
+		"LIKE", "NOT", "NULL", "OR", "REGEXP", "RLIKE", "SIMILAR", "SOME",
+		"THEN", "TRUE", "UNKNOWN", "WHEN", "XOR",
+	}
+	joinKeywords = []string{
+		"CROSS", "FULL", "INNER", "JOIN", "LATERAL", "LEFT", "NATURAL",
+		"OUTER", "RIGHT",
+	}
+	// queryTailKeywords start the clauses after the conditions of the query
+	queryTailKeywords = []string{
+		"BY", "EXCEPT", "FETCH", "FOR", "GROUP", "HAVING", "INTERSECT",
+		"LIMIT", "MINUS", "OFFSET", "ORDER", "QUALIFY", "RETURNING", "UNION",
+		"WINDOW",
+	}
+)
+
+// clauseKeywords are the keywords which may follow in the clauses, of which
+// the ones of the dialect are completed. The keywords of all the statements
+// are completed in the clauses not listed.
+var clauseKeywords = map[parseutil.Clause][][]string{
+	parseutil.ClauseStatement: {statementKeywords},
+	parseutil.ClauseSelect: {expressionKeywords, {
+		"AS", "DISTINCT", "DISTINCTROW", "EXCEPT", "FILTER", "FROM", "INTERSECT",
+		"INTO", "LIMIT", "MINUS", "ORDER", "OVER", "PARTITION", "BY", "TOP",
+		"UNION", "WHERE", "WITHIN",
+	}},
+	parseutil.ClauseFrom: {joinKeywords, queryTailKeywords, {
+		"AS", "ON", "USING", "WHERE",
+	}},
+	parseutil.ClauseJoinOn: {expressionKeywords, joinKeywords, queryTailKeywords, {
+		// WHEN MATCHED of MERGE
+		"MATCHED", "WHERE",
+	}},
+	parseutil.ClauseWhere:  {expressionKeywords, queryTailKeywords},
+	parseutil.ClauseHaving: {expressionKeywords, queryTailKeywords},
+	parseutil.ClauseGroupBy: {queryTailKeywords, {
+		"CUBE", "GROUPING", "ROLLUP", "SETS", "WITH",
+	}},
+	parseutil.ClauseOrderBy: {{
+		"ASC", "BY", "COLLATE", "DESC", "EXCEPT", "FETCH", "FIRST", "FOR",
+		"INTERSECT", "LAST", "LIMIT", "MINUS", "NULLS", "OFFSET", "UNION",
+	}},
+	parseutil.ClauseLimit: {{
+		"FETCH", "FIRST", "FOR", "NEXT", "OFFSET", "ONLY", "ROW", "ROWS",
+		"TIES", "WITH",
+	}},
+	parseutil.ClauseInto: {{
+		"AS", "DEFAULT", "IGNORE", "INTO", "OVERRIDING", "SELECT", "SET",
+		"USING", "VALUES", "WITH",
+	}},
+	parseutil.ClauseValues: {{
+		"CONFLICT", "DEFAULT", "DO", "DUPLICATE", "FALSE", "KEY", "NOTHING",
+		"NULL", "ON", "RETURNING", "TRUE", "UPDATE",
+	}},
+	parseutil.ClauseUpdate: {joinKeywords, {
+		"AS", "FROM", "SET",
+	}},
+	parseutil.ClauseSet: {expressionKeywords, {
+		"DEFAULT", "FROM", "RETURNING", "WHERE",
+	}},
+}
+
+// filterClauseKeywords returns the keywords which may follow in the clause.
+func filterClauseKeywords(keywords []string, clause parseutil.Clause) []string {
+	lists, ok := clauseKeywords[clause]
+	if !ok {
+		return keywords
+	}
+	allowed := map[string]bool{}
+	for _, list := range lists {
+		for _, keyword := range list {
+			allowed[keyword] = true
+		}
+	}
+	filtered := []string{}
+	for _, keyword := range keywords {
+		if allowed[keyword] {
+			filtered = append(filtered, keyword)
+		}
+	}
+	return filtered
+}
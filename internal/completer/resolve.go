@@ -0,0 +1,63 @@
+package completer
+
+import (
+	"encoding/json"
+
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// TableDocData is the data of the completion items of the tables, whose
+// documentation is resolved by completionItem/resolve for the item selected,
+// not to send the documentation of all the tables of the wide schemas.
+type TableDocData struct {
+	// URI is the document completed, of the tables of its migrations
+	URI    string `json:"uri,omitempty"`
+	Schema string `json:"schema,omitempty"`
+	Table  string `json:"table"`
+}
+
+func (d *TableDocData) columns(dbCache *database.DBCache) ([]*database.ColumnDesc, bool) {
+	if d.Schema != "" {
+		return dbCache.ColumnDatabase(d.Schema, d.Table)
+	}
+	return dbCache.ColumnDescs(d.Table)
+}
+
+// ParseTableDocData returns the data of the item of a table sent back by the
+// client, which is decoded from JSON.
+func ParseTableDocData(data interface{}) (*TableDocData, bool) {
+	if data == nil {
+		return nil, false
+	}
+	if d, ok := data.(*TableDocData); ok {
+		return d, true
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, false
+	}
+	var d TableDocData
+	if err := json.Unmarshal(b, &d); err != nil || d.Table == "" {
+		return nil, false
+	}
+	return &d, true
+}
+
+// Resolve sets the documentation of the item of a table.
+func (c *Completer) Resolve(item lsp.CompletionItem) lsp.CompletionItem {
+	if item.Documentation != nil || c.DBCache == nil {
+		return item
+	}
+	data, ok := ParseTableDocData(item.Data)
+	if !ok {
+		return item
+	}
+	if cols, ok := data.columns(c.DBCache); ok {
+		item.Documentation = &lsp.MarkupContent{
+			Kind:  lsp.Markdown,
+			Value: c.DBCache.TableDoc(data.Table, cols),
+		}
+	}
+	return item
+}
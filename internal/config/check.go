@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/sqls-server/sqls/internal/database"
+	"gopkg.in/yaml.v2"
+)
+
+// Check reads the config file as GetConfig does, and returns the config with
+// all of its problems instead of the first one: the keys that sqls does not
+// know, the values of the wrong types and the invalid settings of each
+// connection. The config is nil if the file is not YAML.
+func Check(fp string) (*Config, []error, error) {
+	expandPath, err := expand(fp)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := os.ReadFile(expandPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read config, %w", err)
+	}
+
+	problems := []error{}
+	cfg := NewConfig()
+	if err := yaml.UnmarshalStrict(b, cfg); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, append(problems, fmt.Errorf("invalid yaml, %w", err)), nil
+		}
+		for _, msg := range typeErr.Errors {
+			problems = append(problems, errors.New(msg))
+		}
+		// The keys not known are ignored as the server does
+		cfg = NewConfig()
+		if err := yaml.Unmarshal(b, cfg); err != nil && !errors.As(err, &typeErr) {
+			return nil, append(problems, fmt.Errorf("invalid yaml, %w", err)), nil
+		}
+	}
+
+	top := *cfg
+	top.Connections = nil
+	top.FileConnections = nil
+	if err := top.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	aliases := map[string]bool{}
+	for i, conn := range cfg.Connections {
+		if conn == nil {
+			problems = append(problems, fmt.Errorf("connection %s, empty", ConnectionName(i, conn)))
+			continue
+		}
+		if err := conn.Validate(); err != nil {
+			problems = append(problems, fmt.Errorf("connection %s, %w", ConnectionName(i, conn), err))
+		}
+		if conn.Alias != "" {
+			if aliases[conn.Alias] {
+				problems = append(problems, fmt.Errorf("duplicate: connections[].alias %s", conn.Alias))
+			}
+			aliases[conn.Alias] = true
+		}
+	}
+	if err := cfg.validateFileConnections(); err != nil {
+		problems = append(problems, err)
+	}
+	return cfg, problems, nil
+}
+
+// ConnectionName returns the alias of the connection, or its 1-based index
+// if it has no alias.
+func ConnectionName(index int, conn *database.DBConfig) string {
+	if conn != nil && conn.Alias != "" {
+		return conn.Alias
+	}
+	return "#" + strconv.Itoa(index+1)
+}
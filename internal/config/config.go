@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/sqls-server/sqls/ast"
 	"github.com/sqls-server/sqls/internal/database"
 	"gopkg.in/yaml.v2"
 )
@@ -15,22 +20,434 @@ var (
 	ErrNotFoundConfig = errors.New("NotFound Config")
 )
 
+const (
+	CommaStyleTrailing = "trailing"
+	CommaStyleLeading  = "leading"
+
+	IndentStyleTab   = "tab"
+	IndentStyleSpace = "space"
+
+	PartitionCompletionHide  = "hide"
+	PartitionCompletionGroup = "group"
+	PartitionCompletionShow  = "show"
+
+	TemplateEngineJinja  = "jinja"
+	TemplateEngineGo     = "go"
+	TemplateEnginePrintf = "printf"
+)
+
 var (
-	YamlConfigPath = configFilePath("config.yml")
+	YamlConfigPath  = configFilePath("config.yml")
+	HistoryFilePath = configFilePath("history.json")
 )
 
 type Config struct {
-	LowercaseKeywords bool                 `json:"lowercaseKeywords" yaml:"lowercaseKeywords"`
-	Connections       []*database.DBConfig `json:"connections" yaml:"connections"`
+	LowercaseKeywords   bool                   `json:"lowercaseKeywords" yaml:"lowercaseKeywords"`
+	KeywordCase         ast.Case               `json:"keywordCase" yaml:"keywordCase"`
+	IdentifierCase      ast.Case               `json:"identifierCase" yaml:"identifierCase"`
+	CommaStyle          string                 `json:"commaStyle" yaml:"commaStyle"`
+	MaxLineWidth        int                    `json:"maxLineWidth" yaml:"maxLineWidth"`
+	IndentStyle         string                 `json:"indentStyle" yaml:"indentStyle"`
+	IndentWidth         int                    `json:"indentWidth" yaml:"indentWidth"`
+	AlignColumns        bool                   `json:"alignColumns" yaml:"alignColumns"`
+	PartitionCompletion string                 `json:"partitionCompletion" yaml:"partitionCompletion"`
+	ExternalFormatter   *ExternalFormatter     `json:"externalFormatter" yaml:"externalFormatter"`
+	Template            *Template              `json:"template" yaml:"template"`
+	Lint                *Lint                  `json:"lint" yaml:"lint"`
+	Notebook            bool                   `json:"notebook" yaml:"notebook"`
+	ResultFormat        *database.ResultFormat `json:"resultFormat" yaml:"resultFormat"`
+	Connections         []*database.DBConfig   `json:"connections" yaml:"connections"`
+	FileConnections     []*FileConnection      `json:"fileConnections" yaml:"fileConnections"`
+	// TrustedWorkspaces is the directories whose workspace configs may run
+	// commands, read only from the user config
+	TrustedWorkspaces []string `json:"trustedWorkspaces" yaml:"trustedWorkspaces"`
+}
+
+// ExternalFormatter formats the documents with a command instead of the
+// formatter of sqls. The command is run by the shell in the directory of the
+// document, reads the document from stdin and writes the result to stdout.
+type ExternalFormatter struct {
+	Command string `json:"command" yaml:"command"`
+	// Timeout is in seconds, defaultExternalFormatterTimeout if 0
+	Timeout int `json:"timeout" yaml:"timeout"`
+}
+
+const defaultExternalFormatterTimeout = 10
+
+func (f *ExternalFormatter) Validate() error {
+	if strings.TrimSpace(f.Command) == "" {
+		return errors.New("required: externalFormatter.command")
+	}
+	if f.Timeout < 0 {
+		return errors.New("invalid: externalFormatter.timeout")
+	}
+	return nil
+}
+
+func (f *ExternalFormatter) TimeoutDuration() time.Duration {
+	if f.Timeout == 0 {
+		return defaultExternalFormatterTimeout * time.Second
+	}
+	return time.Duration(f.Timeout) * time.Second
+}
+
+// Template preprocesses the regions of the templates in the documents, such as
+// the models of dbt, replacing them by the placeholders for the completion,
+// the formatting and the linting.
+type Template struct {
+	// Engine is jinja, go for text/template or printf for the verbs of
+	// fmt.Sprintf
+	Engine string `json:"engine" yaml:"engine"`
+	// ResolveRefs replaces {{ ref('model') }} and {{ source('schema', 'table') }}
+	// by the relations
+	ResolveRefs bool `json:"resolveRefs" yaml:"resolveRefs"`
+}
+
+func (t *Template) Validate() error {
+	switch t.Engine {
+	case "", TemplateEngineJinja, TemplateEngineGo, TemplateEnginePrintf:
+	default:
+		return errors.New("invalid: template.engine")
+	}
+	return nil
+}
+
+// Enabled reports whether the templates are preprocessed.
+func (t *Template) Enabled() bool {
+	return t != nil && t.Engine != ""
+}
+
+const (
+	LintSeverityOff         = "off"
+	LintSeverityError       = "error"
+	LintSeverityWarning     = "warning"
+	LintSeverityInformation = "information"
+	LintSeverityHint        = "hint"
+
+	LintRuleSelectStar           = "selectStar"
+	LintRuleImplicitCrossJoin    = "implicitCrossJoin"
+	LintRuleDeleteWithoutWhere   = "deleteWithoutWhere"
+	LintRuleKeywordCase          = "keywordCase"
+	LintRuleAmbiguousColumn      = "ambiguousColumn"
+	LintRuleNotEqual             = "notEqual"
+	LintRuleMissingJoinCondition = "missingJoinCondition"
+	LintRuleTypeMismatch         = "typeMismatch"
+	LintRuleEqualsNull           = "equalsNull"
+	LintRuleNotInNullable        = "notInNullable"
+	LintRuleUnusedCte            = "unusedCte"
+	LintRuleUnusedAlias          = "unusedAlias"
+	LintRuleUnusedColumn         = "unusedColumn"
+	LintRuleDialectCompatibility = "dialectCompatibility"
+	LintRuleReservedWord         = "reservedWord"
+	LintRuleSchemaMismatch       = "schemaMismatch"
+	LintRuleSetOperationColumns  = "setOperationColumns"
+	LintRuleSqlc                 = "sqlc"
+)
+
+// defaultLintRules are the severities of the rules that are not configured.
+// The rules about the style are off unless they are enabled.
+var defaultLintRules = map[string]string{
+	LintRuleSelectStar:           LintSeverityOff,
+	LintRuleImplicitCrossJoin:    LintSeverityWarning,
+	LintRuleDeleteWithoutWhere:   LintSeverityWarning,
+	LintRuleKeywordCase:          LintSeverityOff,
+	LintRuleAmbiguousColumn:      LintSeverityWarning,
+	LintRuleNotEqual:             LintSeverityOff,
+	LintRuleMissingJoinCondition: LintSeverityWarning,
+	LintRuleTypeMismatch:         LintSeverityWarning,
+	LintRuleEqualsNull:           LintSeverityWarning,
+	LintRuleNotInNullable:        LintSeverityWarning,
+	LintRuleUnusedCte:            LintSeverityWarning,
+	LintRuleUnusedAlias:          LintSeverityHint,
+	LintRuleUnusedColumn:         LintSeverityOff,
+	LintRuleDialectCompatibility: LintSeverityWarning,
+	LintRuleReservedWord:         LintSeverityWarning,
+	LintRuleSchemaMismatch:       LintSeverityWarning,
+	LintRuleSetOperationColumns:  LintSeverityWarning,
+	LintRuleSqlc:                 LintSeverityWarning,
+}
+
+// Lint sets the severities of the lint rules by their names, off to disable
+// a rule.
+type Lint struct {
+	Rules   map[string]string `json:"rules" yaml:"rules"`
+	Explain *LintExplain      `json:"explain" yaml:"explain"`
+}
+
+// LintExplain runs EXPLAIN, never EXPLAIN ANALYZE, of the SELECT statements
+// when the documents are saved, and publishes the problems of the plans as
+// diagnostics.
+type LintExplain struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// Timeout is in seconds for all the statements of a document,
+	// defaultExplainTimeout if 0
+	Timeout int `json:"timeout" yaml:"timeout"`
+	// LargeRows is the number of the rows of the sequential scans to report,
+	// defaultExplainLargeRows if 0
+	LargeRows int `json:"largeRows" yaml:"largeRows"`
+}
+
+const (
+	defaultExplainTimeout   = 2
+	defaultExplainLargeRows = 10000
+)
+
+func (e *LintExplain) Validate() error {
+	if e.Timeout < 0 {
+		return errors.New("invalid: lint.explain.timeout")
+	}
+	if e.LargeRows < 0 {
+		return errors.New("invalid: lint.explain.largeRows")
+	}
+	return nil
+}
+
+func (e *LintExplain) TimeoutDuration() time.Duration {
+	if e.Timeout == 0 {
+		return defaultExplainTimeout * time.Second
+	}
+	return time.Duration(e.Timeout) * time.Second
+}
+
+func (e *LintExplain) LargeRowsOrDefault() int {
+	if e.LargeRows == 0 {
+		return defaultExplainLargeRows
+	}
+	return e.LargeRows
+}
+
+// ExplainEnabled reports whether the plans of the statements are checked.
+func (l *Lint) ExplainEnabled() bool {
+	return l != nil && l.Explain != nil && l.Explain.Enabled
+}
+
+func (l *Lint) Validate() error {
+	for rule, severity := range l.Rules {
+		if _, ok := defaultLintRules[rule]; !ok {
+			return errors.New("invalid: lint.rules." + rule)
+		}
+		switch severity {
+		case LintSeverityOff, LintSeverityError, LintSeverityWarning, LintSeverityInformation, LintSeverityHint:
+		default:
+			return errors.New("invalid: lint.rules." + rule)
+		}
+	}
+	if l.Explain != nil {
+		if err := l.Explain.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RuleSeverity returns the severity of the rule, which is the default one if
+// the rule is not configured.
+func (l *Lint) RuleSeverity(rule string) string {
+	if l != nil {
+		if severity, ok := l.Rules[rule]; ok {
+			return severity
+		}
+	}
+	return defaultLintRules[rule]
+}
+
+// FileConnection maps the files matching the glob pattern to the connection
+// with the alias. The pattern is relative to the workspace root, ** matches
+// any number of directories and a pattern without a slash matches the base
+// name of the file.
+type FileConnection struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Alias   string `json:"alias" yaml:"alias"`
 }
 
 func (c *Config) Validate() error {
+	if !c.KeywordCase.IsValid() {
+		return errors.New("invalid: keywordCase")
+	}
+	if !c.IdentifierCase.IsValid() {
+		return errors.New("invalid: identifierCase")
+	}
+	switch c.CommaStyle {
+	case "", CommaStyleTrailing, CommaStyleLeading:
+	default:
+		return errors.New("invalid: commaStyle")
+	}
+	if c.MaxLineWidth < 0 {
+		return errors.New("invalid: maxLineWidth")
+	}
+	switch c.IndentStyle {
+	case "", IndentStyleTab, IndentStyleSpace:
+	default:
+		return errors.New("invalid: indentStyle")
+	}
+	if c.IndentWidth < 0 {
+		return errors.New("invalid: indentWidth")
+	}
+	switch c.PartitionCompletion {
+	case "", PartitionCompletionHide, PartitionCompletionGroup, PartitionCompletionShow:
+	default:
+		return errors.New("invalid: partitionCompletion")
+	}
+	if c.ExternalFormatter != nil {
+		if err := c.ExternalFormatter.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Template != nil {
+		if err := c.Template.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Lint != nil {
+		if err := c.Lint.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.ResultFormat != nil {
+		if err := c.ResultFormat.Validate(); err != nil {
+			return err
+		}
+	}
 	if len(c.Connections) > 0 {
-		return c.Connections[0].Validate()
+		if err := c.Connections[0].Validate(); err != nil {
+			return err
+		}
+	}
+	return c.validateFileConnections()
+}
+
+// TrustsWorkspace reports whether the workspace of the root is one of the
+// trusted workspaces or in one of them.
+func (c *Config) TrustsWorkspace(root string) bool {
+	if c == nil || root == "" {
+		return false
+	}
+	root = filepath.Clean(root)
+	for _, w := range c.TrustedWorkspaces {
+		dir, err := expand(w)
+		if err != nil || dir == "" {
+			continue
+		}
+		rel, err := filepath.Rel(filepath.Clean(dir), root)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveCommands removes the commands run to connect to the databases, the
+// extensions and the fixtures loaded to SQLite and the external formatter, and
+// returns the fields removed.
+func (c *Config) RemoveCommands() []string {
+	var removed []string
+	if c.ExternalFormatter != nil {
+		c.ExternalFormatter = nil
+		removed = append(removed, "externalFormatter.command")
+	}
+	for i, conn := range c.Connections {
+		if conn.PasswdCmd != "" {
+			conn.PasswdCmd = ""
+			removed = append(removed, fmt.Sprintf("connections[%d].passwordCmd", i))
+		}
+		if conn.DataSourceNameCmd != "" {
+			conn.DataSourceNameCmd = ""
+			removed = append(removed, fmt.Sprintf("connections[%d].dsnCmd", i))
+		}
+		if conn.CloudSQLCfg != nil && conn.CloudSQLCfg.TokenCmd != "" {
+			cloudSQL := *conn.CloudSQLCfg
+			cloudSQL.TokenCmd = ""
+			conn.CloudSQLCfg = &cloudSQL
+			removed = append(removed, fmt.Sprintf("connections[%d].cloudSQL.tokenCmd", i))
+		}
+		if conn.SQLiteCfg != nil && (len(conn.SQLiteCfg.Extensions) > 0 || conn.SQLiteCfg.Fixture != "") {
+			if len(conn.SQLiteCfg.Extensions) > 0 {
+				removed = append(removed, fmt.Sprintf("connections[%d].sqlite.extensions", i))
+			}
+			if conn.SQLiteCfg.Fixture != "" {
+				removed = append(removed, fmt.Sprintf("connections[%d].sqlite.fixture", i))
+			}
+			conn.SQLiteCfg = nil
+		}
+	}
+	return removed
+}
+
+func (c *Config) validateFileConnections() error {
+	for _, fc := range c.FileConnections {
+		if fc.Pattern == "" {
+			return errors.New("required: fileConnections[].pattern")
+		}
+		if _, err := globRegexp(fc.Pattern); err != nil {
+			return errors.New("invalid: fileConnections[].pattern")
+		}
+		if _, ok := c.connectionIndex(fc.Alias); !ok {
+			return errors.New("invalid: fileConnections[].alias")
+		}
 	}
 	return nil
 }
 
+func (c *Config) connectionIndex(alias string) (int, bool) {
+	for i, conn := range c.Connections {
+		if conn.Alias == alias {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// FileConnectionIndex returns the index of the connection mapped to the file
+// by the first matching pattern. relPath is the slash separated path of the
+// file relative to the workspace root.
+func (c *Config) FileConnectionIndex(relPath string) (int, bool) {
+	for _, fc := range c.FileConnections {
+		re, err := globRegexp(fc.Pattern)
+		if err != nil {
+			continue
+		}
+		target := relPath
+		if !strings.Contains(fc.Pattern, "/") {
+			target = path.Base(relPath)
+		}
+		if re.MatchString(target) {
+			return c.connectionIndex(fc.Alias)
+		}
+	}
+	return 0, false
+}
+
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var buf strings.Builder
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// **/ matches zero or more directories
+					i++
+					buf.WriteString("(?:.*/)?")
+				} else {
+					buf.WriteString(".*")
+				}
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
+
 func NewConfig() *Config {
 	cfg := &Config{}
 	cfg.LowercaseKeywords = false
@@ -83,6 +500,12 @@ func (c *Config) Load(fp string) error {
 	return nil
 }
 
+// WorkspaceConfigPath returns the path of the workspace local config, which
+// overrides the user config.
+func WorkspaceConfigPath(rootPath string) string {
+	return filepath.Join(rootPath, ".sqls", "config.yml")
+}
+
 func IsFileExist(fPath string) bool {
 	_, err := os.Stat(fPath)
 	return err == nil || !os.IsNotExist(err)
@@ -112,6 +112,87 @@ func TestGetConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "failed validation, invalid: connections[].proto",
 		},
+		{
+			name: "invalid keyword case",
+			args: args{
+				fp: "invalid_keyword_case.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, invalid: keywordCase",
+		},
+		{
+			name: "invalid max line width",
+			args: args{
+				fp: "invalid_max_line_width.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, invalid: maxLineWidth",
+		},
+		{
+			name: "invalid indent style",
+			args: args{
+				fp: "invalid_indent_style.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, invalid: indentStyle",
+		},
+		{
+			name: "invalid partition completion",
+			args: args{
+				fp: "invalid_partition_completion.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, invalid: partitionCompletion",
+		},
+		{
+			name: "no external formatter command",
+			args: args{
+				fp: "no_external_formatter_command.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, required: externalFormatter.command",
+		},
+		{
+			name: "invalid template engine",
+			args: args{
+				fp: "invalid_template_engine.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, invalid: template.engine",
+		},
+		{
+			name: "invalid lint rule",
+			args: args{
+				fp: "invalid_lint_rule.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, invalid: lint.rules.deleteWithWhere",
+		},
+		{
+			name: "invalid lint explain timeout",
+			args: args{
+				fp: "invalid_lint_explain_timeout.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, invalid: lint.explain.timeout",
+		},
+		{
+			name: "invalid result format binary",
+			args: args{
+				fp: "invalid_result_format_binary.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, invalid: resultFormat.binary",
+		},
 		{
 			name: "no path",
 			args: args{
@@ -182,6 +263,41 @@ func TestGetConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "failed validation, required: connections[].sshConfig.privateKey",
 		},
+		{
+			name: "file connections",
+			args: args{
+				fp: "file_connections.yml",
+			},
+			want: &Config{
+				Connections: []*database.DBConfig{
+					{
+						Alias:          "main",
+						Driver:         "sqlite3",
+						DataSourceName: "file:/home/sqls-server/main.db",
+					},
+					{
+						Alias:          "analytics",
+						Driver:         "sqlite3",
+						DataSourceName: "file:/home/sqls-server/analytics.db",
+					},
+				},
+				FileConnections: []*FileConnection{
+					{
+						Pattern: "analytics/**.sql",
+						Alias:   "analytics",
+					},
+				},
+			},
+		},
+		{
+			name: "file connections with unknown alias",
+			args: args{
+				fp: "file_connections_unknown_alias.yml",
+			},
+			want:    nil,
+			wantErr: true,
+			errMsg:  "failed validation, invalid: fileConnections[].alias",
+		},
 	}
 	for _, tt := range tests {
 		packageDir, err := os.Getwd()
@@ -208,3 +324,101 @@ func TestGetConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_FileConnectionIndex(t *testing.T) {
+	cfg := &Config{
+		Connections: []*database.DBConfig{
+			{Alias: "main"},
+			{Alias: "analytics"},
+			{Alias: "reports"},
+		},
+		FileConnections: []*FileConnection{
+			{Pattern: "analytics/**.sql", Alias: "analytics"},
+			{Pattern: "**/reports/*.sql", Alias: "reports"},
+			{Pattern: "main_?.sql", Alias: "main"},
+		},
+	}
+	tests := []struct {
+		path      string
+		wantIndex int
+		wantOK    bool
+	}{
+		{path: "analytics/daily.sql", wantIndex: 1, wantOK: true},
+		{path: "analytics/2020/01/daily.sql", wantIndex: 1, wantOK: true},
+		{path: "analytics/daily.txt", wantOK: false},
+		{path: "reports/weekly.sql", wantIndex: 2, wantOK: true},
+		{path: "sales/reports/weekly.sql", wantIndex: 2, wantOK: true},
+		{path: "sales/reports/2020/weekly.sql", wantOK: false},
+		{path: "sales/main_1.sql", wantIndex: 0, wantOK: true},
+		{path: "sales/main_10.sql", wantOK: false},
+		{path: "query.sql", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			index, ok := cfg.FileConnectionIndex(tt.path)
+			if ok != tt.wantOK || index != tt.wantIndex {
+				t.Errorf("FileConnectionIndex() = %d, %v, want %d, %v", index, ok, tt.wantIndex, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name        string
+		fp          string
+		connections int
+		want        []string
+	}{
+		{
+			name:        "basic",
+			fp:          "basic.yml",
+			connections: 4,
+			want:        []string{},
+		},
+		{
+			name:        "problems",
+			fp:          "check_problems.yml",
+			connections: 4,
+			want: []string{
+				"line 2: field keywordcase not found in type config.Config",
+				"line 16: field hostname not found in type database.DBConfig",
+				"invalid: commaStyle",
+				"connection main, required: connections[].user",
+				"duplicate: connections[].alias main",
+				"connection #4, invalid: connections[].driver",
+				"invalid: fileConnections[].alias",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, problems, err := Check(filepath.Join("testdata", tt.fp))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cfg.Connections) != tt.connections {
+				t.Errorf("unmatched connections: want %d, got %d", tt.connections, len(cfg.Connections))
+			}
+			got := []string{}
+			for _, p := range problems {
+				got = append(got, p.Error())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatched problems: (-want +got)\n%s", diff)
+			}
+		})
+	}
+
+	if _, _, err := Check(filepath.Join("testdata", "not_found.yml")); err == nil {
+		t.Error("expected error")
+	}
+	invalid := filepath.Join(t.TempDir(), "invalid.yml")
+	if err := os.WriteFile(invalid, []byte("connections:\n  - alias: [main\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, problems, err := Check(invalid)
+	if err != nil || cfg != nil || len(problems) != 1 {
+		t.Errorf("expected the problem of yaml, got %v, %v", problems, err)
+	}
+}
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/sqls-server/sqls/ast"
+)
+
+// sqlfluffConfigNames are the names of the configs of sqlfluff in the
+// workspace root, in the order of the precedence.
+var sqlfluffConfigNames = []string{".sqlfluff", ".sqlfluffrc"}
+
+// sqlfluffRules are the rules of sqlfluff overlapping the lint rules, by their
+// codes. The names of the rules are also accepted.
+var sqlfluffRules = map[string]struct {
+	name string
+	rule string
+}{
+	"AL05": {name: "aliasing.unused", rule: LintRuleUnusedAlias},
+	"AM04": {name: "ambiguous.column_count", rule: LintRuleSelectStar},
+	"AM07": {name: "ambiguous.set_columns", rule: LintRuleSetOperationColumns},
+	"AM08": {name: "ambiguous.join_condition", rule: LintRuleMissingJoinCondition},
+	"CP01": {name: "capitalisation.keywords", rule: LintRuleKeywordCase},
+	"CV01": {name: "convention.not_equal", rule: LintRuleNotEqual},
+	"CV05": {name: "convention.is_null", rule: LintRuleEqualsNull},
+	"RF02": {name: "references.qualification", rule: LintRuleAmbiguousColumn},
+	"RF04": {name: "references.keywords", rule: LintRuleReservedWord},
+	"ST03": {name: "structure.unused_cte", rule: LintRuleUnusedCte},
+}
+
+// sqlfluffLegacyCodes are the codes of the rules before sqlfluff 2.0.
+var sqlfluffLegacyCodes = map[string]string{
+	"L010": "CP01",
+	"L014": "CP02",
+	"L019": "LT04",
+	"L025": "AL05",
+	"L027": "RF02",
+	"L029": "RF04",
+	"L044": "AM04",
+	"L045": "ST03",
+	"L049": "CV05",
+	"L061": "CV01",
+	"L068": "AM07",
+}
+
+// Sqlfluff is the part of a config of sqlfluff that sqls understands: the
+// settings of the formatter and the severities of the lint rules.
+type Sqlfluff struct {
+	KeywordCase    ast.Case
+	IdentifierCase ast.Case
+	CommaStyle     string
+	MaxLineWidth   int
+	IndentStyle    string
+	IndentWidth    int
+	// Rules are the severities of the lint rules enabled or disabled by the
+	// rules and exclude_rules of sqlfluff
+	Rules map[string]string
+}
+
+// SqlfluffConfigPath returns the path of the config of sqlfluff in the
+// workspace root, or an empty string if there is none.
+func SqlfluffConfigPath(rootPath string) string {
+	for _, name := range sqlfluffConfigNames {
+		fpath := filepath.Join(rootPath, name)
+		if IsFileExist(fpath) {
+			return fpath
+		}
+	}
+	return ""
+}
+
+// LoadSqlfluff reads the config of sqlfluff. The settings that sqls does not
+// have are ignored.
+func LoadSqlfluff(fp string) (*Sqlfluff, error) {
+	file, err := os.Open(fp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open sqlfluff config, %w", err)
+	}
+	defer file.Close()
+
+	sections, err := parseIni(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read sqlfluff config, %w", err)
+	}
+	return newSqlfluff(sections), nil
+}
+
+// parseIni parses the INI format of the configparser of Python, which
+// sqlfluff uses. The names of the keys are lower cased and the indented lines
+// continue the values.
+func parseIni(r io.Reader) (map[string]map[string]string, error) {
+	sections := map[string]map[string]string{}
+	var section map[string]string
+	var key string
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		if key != "" && (line[0] == ' ' || line[0] == '\t') {
+			section[key] += "\n" + trimmed
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if _, ok := sections[name]; !ok {
+				sections[name] = map[string]string{}
+			}
+			section, key = sections[name], ""
+			continue
+		}
+		sep := strings.IndexAny(trimmed, "=:")
+		if section == nil || sep < 0 {
+			return nil, fmt.Errorf("invalid line %d: %s", n, trimmed)
+		}
+		key = strings.ToLower(strings.TrimSpace(trimmed[:sep]))
+		section[key] = strings.TrimSpace(trimmed[sep+1:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+func newSqlfluff(sections map[string]map[string]string) *Sqlfluff {
+	// The value of the first section having the key
+	get := func(key string, names ...string) string {
+		for _, name := range names {
+			if v, ok := sections[name][key]; ok {
+				return v
+			}
+		}
+		return ""
+	}
+	ruleSections := func(code string) []string {
+		names := []string{"sqlfluff:rules:" + code, "sqlfluff:rules:" + sqlfluffRuleName(code)}
+		for legacy, c := range sqlfluffLegacyCodes {
+			if c == code {
+				names = append(names, "sqlfluff:rules:"+legacy)
+			}
+		}
+		return names
+	}
+
+	sf := &Sqlfluff{}
+	sf.KeywordCase = sqlfluffCase(get("capitalisation_policy", ruleSections("CP01")...))
+	sf.IdentifierCase = sqlfluffCase(get("extended_capitalisation_policy", ruleSections("CP02")...))
+	if sf.IdentifierCase == "" {
+		sf.IdentifierCase = sqlfluffCase(get("capitalisation_policy", ruleSections("CP02")...))
+	}
+
+	commaStyle := get("line_position", "sqlfluff:layout:type:comma")
+	if commaStyle == "" {
+		commaStyle = get("comma_style", ruleSections("LT04")...)
+	}
+	switch commaStyle {
+	case CommaStyleLeading, CommaStyleTrailing:
+		sf.CommaStyle = commaStyle
+	}
+
+	// The settings of the indentation and the line length were in the rules
+	// section before sqlfluff 2.0
+	switch get("indent_unit", "sqlfluff:indentation", "sqlfluff:rules") {
+	case "space":
+		sf.IndentStyle = IndentStyleSpace
+	case "tab":
+		sf.IndentStyle = IndentStyleTab
+	}
+	if n, err := strconv.Atoi(get("tab_space_size", "sqlfluff:indentation", "sqlfluff:rules")); err == nil && n > 0 {
+		sf.IndentWidth = n
+	}
+	if n, err := strconv.Atoi(get("max_line_length", "sqlfluff", "sqlfluff:rules")); err == nil && n > 0 {
+		sf.MaxLineWidth = n
+	}
+
+	selected, all := sqlfluffSelectors(get("rules", "sqlfluff"))
+	excluded, _ := sqlfluffSelectors(get("exclude_rules", "sqlfluff"))
+	sf.Rules = map[string]string{}
+	for code, r := range sqlfluffRules {
+		enabled := (all || selected.match(code)) && !excluded.match(code)
+		// sqls only checks that the not equal operator is <>
+		if code == "CV01" && get("preferred_not_equal_style", ruleSections(code)...) == "c_style" {
+			enabled = false
+		}
+		switch {
+		case !enabled:
+			sf.Rules[r.rule] = LintSeverityOff
+		case defaultLintRules[r.rule] == LintSeverityOff:
+			sf.Rules[r.rule] = LintSeverityWarning
+		}
+	}
+	return sf
+}
+
+func sqlfluffRuleName(code string) string {
+	if r, ok := sqlfluffRules[code]; ok {
+		return r.name
+	}
+	switch code {
+	case "CP02":
+		return "capitalisation.identifiers"
+	case "LT04":
+		return "layout.commas"
+	}
+	return code
+}
+
+// sqlfluffCase returns the case of the capitalisation policy, or an empty
+// string for the policies that sqls does not have, such as consistent.
+func sqlfluffCase(policy string) ast.Case {
+	switch policy {
+	case "upper":
+		return ast.CaseUpper
+	case "lower":
+		return ast.CaseLower
+	case "capitalise":
+		return ast.CaseCapitalize
+	}
+	return ""
+}
+
+// sqlfluffSelector matches the rules by the codes, the names, the legacy
+// codes and the groups of the names such as capitalisation.
+type sqlfluffSelector []string
+
+// sqlfluffSelectors parses the comma separated list of the rules. all is true
+// if the list selects all the rules or has the selectors that sqls does not
+// know, such as core, not to disable the rules by mistake.
+func sqlfluffSelectors(list string) (selector sqlfluffSelector, all bool) {
+	if list == "" {
+		return nil, true
+	}
+	for _, s := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '\n' }) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if code, ok := sqlfluffLegacyCodes[strings.ToUpper(s)]; ok {
+			s = code
+		}
+		if strings.EqualFold(s, "all") || !isSqlfluffSelector(s) {
+			all = true
+		}
+		selector = append(selector, s)
+	}
+	return selector, all
+}
+
+// sqlfluffGroups are the groups of the rules of sqlfluff by the names.
+var sqlfluffGroups = map[string]bool{
+	"aliasing":       true,
+	"ambiguous":      true,
+	"capitalisation": true,
+	"convention":     true,
+	"jinja":          true,
+	"layout":         true,
+	"references":     true,
+	"structure":      true,
+	"tsql":           true,
+}
+
+// isSqlfluffSelector reports whether the selector is a code, a name or a
+// group of the rules of sqlfluff.
+func isSqlfluffSelector(s string) bool {
+	if sqlfluffGroups[s] {
+		return true
+	}
+	if len(s) == 4 && unicode.IsLetter(rune(s[0])) && unicode.IsLetter(rune(s[1])) {
+		_, err := strconv.Atoi(s[2:])
+		return err == nil
+	}
+	return strings.Contains(s, ".")
+}
+
+func (sel sqlfluffSelector) match(code string) bool {
+	name := sqlfluffRules[code].name
+	for _, s := range sel {
+		if strings.EqualFold(s, code) || s == name || strings.HasPrefix(name, s+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// WithSqlfluff returns a copy of the config with the settings of sqlfluff that
+// the config does not set.
+func (c *Config) WithSqlfluff(sf *Sqlfluff) *Config {
+	merged := *c
+	if merged.KeywordCase == "" && !merged.LowercaseKeywords {
+		merged.KeywordCase = sf.KeywordCase
+	}
+	if merged.IdentifierCase == "" {
+		merged.IdentifierCase = sf.IdentifierCase
+	}
+	if merged.CommaStyle == "" {
+		merged.CommaStyle = sf.CommaStyle
+	}
+	if merged.MaxLineWidth == 0 {
+		merged.MaxLineWidth = sf.MaxLineWidth
+	}
+	if merged.IndentStyle == "" {
+		merged.IndentStyle = sf.IndentStyle
+	}
+	if merged.IndentWidth == 0 {
+		merged.IndentWidth = sf.IndentWidth
+	}
+
+	lint := &Lint{Rules: map[string]string{}}
+	if c.Lint != nil {
+		lint.Explain = c.Lint.Explain
+	}
+	for rule, severity := range sf.Rules {
+		lint.Rules[rule] = severity
+	}
+	if c.Lint != nil {
+		for rule, severity := range c.Lint.Rules {
+			lint.Rules[rule] = severity
+		}
+	}
+	merged.Lint = lint
+	return &merged
+}
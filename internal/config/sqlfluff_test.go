@@ -0,0 +1,149 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/ast"
+)
+
+func TestLoadSqlfluff(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    *Sqlfluff
+		wantErr bool
+	}{
+		{
+			name: "basic",
+			file: "basic.sqlfluff",
+			want: &Sqlfluff{
+				KeywordCase:    ast.CaseUpper,
+				IdentifierCase: ast.CaseLower,
+				CommaStyle:     CommaStyleLeading,
+				MaxLineWidth:   100,
+				IndentStyle:    IndentStyleSpace,
+				IndentWidth:    2,
+				Rules: map[string]string{
+					LintRuleSelectStar:           LintSeverityWarning,
+					LintRuleKeywordCase:          LintSeverityWarning,
+					LintRuleNotEqual:             LintSeverityOff,
+					LintRuleMissingJoinCondition: LintSeverityOff,
+					LintRuleAmbiguousColumn:      LintSeverityOff,
+				},
+			},
+		},
+		{
+			name: "legacy",
+			file: "legacy.sqlfluff",
+			want: &Sqlfluff{
+				KeywordCase:  ast.CaseCapitalize,
+				CommaStyle:   CommaStyleTrailing,
+				MaxLineWidth: 80,
+				IndentStyle:  IndentStyleTab,
+				IndentWidth:  4,
+				Rules: map[string]string{
+					LintRuleSelectStar:           LintSeverityWarning,
+					LintRuleKeywordCase:          LintSeverityWarning,
+					LintRuleNotEqual:             LintSeverityWarning,
+					LintRuleUnusedAlias:          LintSeverityOff,
+					LintRuleMissingJoinCondition: LintSeverityOff,
+					LintRuleEqualsNull:           LintSeverityOff,
+					LintRuleAmbiguousColumn:      LintSeverityOff,
+					LintRuleReservedWord:         LintSeverityOff,
+					LintRuleSetOperationColumns:  LintSeverityOff,
+				},
+			},
+		},
+		{
+			name: "groups",
+			file: "groups.sqlfluff",
+			want: &Sqlfluff{
+				Rules: map[string]string{
+					LintRuleSelectStar:           LintSeverityOff,
+					LintRuleKeywordCase:          LintSeverityOff,
+					LintRuleNotEqual:             LintSeverityOff,
+					LintRuleUnusedAlias:          LintSeverityOff,
+					LintRuleMissingJoinCondition: LintSeverityOff,
+					LintRuleEqualsNull:           LintSeverityOff,
+					LintRuleAmbiguousColumn:      LintSeverityOff,
+					LintRuleReservedWord:         LintSeverityOff,
+					LintRuleSetOperationColumns:  LintSeverityOff,
+				},
+			},
+		},
+		{
+			name: "unknown selector",
+			file: "core.sqlfluff",
+			want: &Sqlfluff{
+				Rules: map[string]string{
+					LintRuleSelectStar:  LintSeverityWarning,
+					LintRuleKeywordCase: LintSeverityWarning,
+					LintRuleNotEqual:    LintSeverityWarning,
+					LintRuleUnusedCte:   LintSeverityOff,
+				},
+			},
+		},
+		{
+			name:    "no section",
+			file:    "invalid.sqlfluff",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadSqlfluff(filepath.Join("testdata", "sqlfluff", tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSqlfluff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithSqlfluff(t *testing.T) {
+	sf := &Sqlfluff{
+		KeywordCase:  ast.CaseUpper,
+		CommaStyle:   CommaStyleLeading,
+		MaxLineWidth: 100,
+		IndentWidth:  2,
+		Rules: map[string]string{
+			LintRuleSelectStar:  LintSeverityWarning,
+			LintRuleKeywordCase: LintSeverityWarning,
+			LintRuleUnusedCte:   LintSeverityOff,
+		},
+	}
+	cfg := &Config{
+		KeywordCase: ast.CaseLower,
+		IndentWidth: 4,
+		Lint: &Lint{
+			Rules: map[string]string{
+				LintRuleSelectStar: LintSeverityError,
+			},
+		},
+	}
+	want := &Config{
+		KeywordCase:  ast.CaseLower,
+		CommaStyle:   CommaStyleLeading,
+		MaxLineWidth: 100,
+		IndentWidth:  4,
+		Lint: &Lint{
+			Rules: map[string]string{
+				LintRuleSelectStar:  LintSeverityError,
+				LintRuleKeywordCase: LintSeverityWarning,
+				LintRuleUnusedCte:   LintSeverityOff,
+			},
+		},
+	}
+	if diff := cmp.Diff(want, cfg.WithSqlfluff(sf)); diff != "" {
+		t.Errorf("unmatch (- want, + got):\n%s", diff)
+	}
+	// The config is not changed
+	if len(cfg.Lint.Rules) != 1 || cfg.CommaStyle != "" {
+		t.Errorf("config is changed: %+v", cfg)
+	}
+}
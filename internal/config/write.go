@@ -0,0 +1,112 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sqls-server/sqls/internal/database"
+	"gopkg.in/yaml.v3"
+)
+
+// AddConnection appends the connection to the connections of the config
+// file, keeping the rest of the file with its comments. The file is created
+// if it does not exist.
+func AddConnection(fp string, conn *database.DBConfig) error {
+	b, err := os.ReadFile(fp)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot read config, %w", err)
+	}
+	mode := os.FileMode(0600)
+	if fi, err := os.Stat(fp); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("failed unmarshal yaml, %w", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return errors.New("invalid config, the top level must be a mapping")
+	}
+
+	var conns *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "connections" {
+			conns = root.Content[i+1]
+			break
+		}
+	}
+	switch {
+	case conns == nil:
+		conns = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, stringNode("connections"), conns)
+	case conns.Kind == yaml.ScalarNode && conns.Tag == "!!null":
+		// connections: without any entry
+		*conns = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	case conns.Kind != yaml.SequenceNode:
+		return errors.New("invalid config, connections must be a list")
+	}
+	conns.Style = 0
+	conns.Content = append(conns.Content, connectionNode(conn))
+
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed marshal yaml, %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed marshal yaml, %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fp), 0700); err != nil {
+		return fmt.Errorf("cannot create config directory, %w", err)
+	}
+	if err := os.WriteFile(fp, buf.Bytes(), mode); err != nil {
+		return fmt.Errorf("cannot write config, %w", err)
+	}
+	return nil
+}
+
+// connectionNode returns the mapping of the connection without the keys
+// that are not set.
+func connectionNode(conn *database.DBConfig) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	add := func(key, value string) {
+		if value != "" {
+			node.Content = append(node.Content, stringNode(key), stringNode(value))
+		}
+	}
+	add("alias", conn.Alias)
+	add("driver", string(conn.Driver))
+	add("dataSourceName", conn.DataSourceName)
+	add("proto", string(conn.Proto))
+	add("user", conn.User)
+	add("passwd", conn.Passwd)
+	add("passwordStore", conn.PasswdStore)
+	add("host", conn.Host)
+	if conn.Port != 0 {
+		node.Content = append(node.Content,
+			stringNode("port"),
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(conn.Port)},
+		)
+	}
+	add("path", conn.Path)
+	add("dbName", conn.DBName)
+	return node
+}
+
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
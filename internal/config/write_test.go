@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/internal/database"
+)
+
+func TestAddConnection(t *testing.T) {
+	conn := &database.DBConfig{
+		Alias:  "dev",
+		Driver: "mysql",
+		Proto:  database.ProtoTCP,
+		User:   "root",
+		Passwd: "123",
+		Host:   "127.0.0.1",
+		Port:   3306,
+		DBName: "world",
+	}
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{
+			name: "new file",
+			want: `connections:
+  - alias: dev
+    driver: mysql
+    proto: tcp
+    user: root
+    passwd: "123"
+    host: 127.0.0.1
+    port: 3306
+    dbName: world
+`,
+		},
+		{
+			name: "existing connections",
+			yaml: `# user config
+lowercaseKeywords: true
+connections:
+  # local database
+  - alias: local
+    driver: sqlite3
+    dataSourceName: /tmp/local.db
+`,
+			want: `# user config
+lowercaseKeywords: true
+connections:
+  # local database
+  - alias: local
+    driver: sqlite3
+    dataSourceName: /tmp/local.db
+  - alias: dev
+    driver: mysql
+    proto: tcp
+    user: root
+    passwd: "123"
+    host: 127.0.0.1
+    port: 3306
+    dbName: world
+`,
+		},
+		{
+			name: "empty connections",
+			yaml: "lowercaseKeywords: true\nconnections:\n",
+			want: `lowercaseKeywords: true
+connections:
+  - alias: dev
+    driver: mysql
+    proto: tcp
+    user: root
+    passwd: "123"
+    host: 127.0.0.1
+    port: 3306
+    dbName: world
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := filepath.Join(t.TempDir(), "sqls", "config.yml")
+			if tt.yaml != "" {
+				if err := os.MkdirAll(filepath.Dir(fp), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(fp, []byte(tt.yaml), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := AddConnection(fp, conn); err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(fp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, string(b)); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+
+			cfg, err := GetConfig(fp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(conn, cfg.Connections[len(cfg.Connections)-1]); diff != "" {
+				t.Errorf("unmatch connection (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/rds/auth"
+)
+
+const (
+	AuthTypeAWSIAM = "aws-iam"
+)
+
+type AWSConfig struct {
+	Region  string `json:"region" yaml:"region"`
+	Profile string `json:"profile" yaml:"profile"`
+}
+
+// rdsAuthToken generates the IAM auth token of an RDS endpoint, which is valid
+// for 15 minutes, with the credentials of the AWS SDK. The credentials are
+// loaded on the first token and refreshed by the SDK.
+type rdsAuthToken struct {
+	endpoint string
+	user     string
+	aws      AWSConfig
+
+	mu  sync.Mutex
+	cfg *aws.Config
+}
+
+func newRDSAuthToken(endpoint, user string, awsCfg *AWSConfig) *rdsAuthToken {
+	t := &rdsAuthToken{
+		endpoint: endpoint,
+		user:     user,
+	}
+	if awsCfg != nil {
+		t.aws = *awsCfg
+	}
+	return t
+}
+
+func (t *rdsAuthToken) Get(ctx context.Context) (string, error) {
+	cfg, err := t.config(ctx)
+	if err != nil {
+		return "", err
+	}
+	return auth.BuildAuthToken(ctx, t.endpoint, cfg.Region, t.user, cfg.Credentials)
+}
+
+// config loads the region and the credentials of the profile as the AWS CLI
+// does, from the environment variables, the shared files, SSO or the
+// instance role.
+func (t *rdsAuthToken) config(ctx context.Context) (*aws.Config, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cfg != nil {
+		return t.cfg, nil
+	}
+	var opts []func(*config.LoadOptions) error
+	if t.aws.Region != "" {
+		opts = append(opts, config.WithRegion(t.aws.Region))
+	}
+	if t.aws.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(t.aws.Profile))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load AWS config, %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("cannot find AWS region, set connections[].aws.region or AWS_REGION")
+	}
+	if cfg.Credentials == nil {
+		return nil, errors.New("cannot find AWS credentials")
+	}
+	t.cfg = &cfg
+	return t.cfg, nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_rdsAuthToken_Get(t *testing.T) {
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials")
+	if err := os.WriteFile(credentials, []byte(`[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default
+
+[prod]
+aws_access_key_id = AKIAPROD
+aws_secret_access_key = prod
+aws_session_token = session/token+value
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	ctx := context.Background()
+
+	token := newRDSAuthToken("db.abc.ap-northeast-1.rds.amazonaws.com:3306", "iam_user", &AWSConfig{Region: "ap-northeast-1", Profile: "prod"})
+	got, err := token.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := "db.abc.ap-northeast-1.rds.amazonaws.com:3306?"
+	if !strings.HasPrefix(got, prefix) {
+		t.Fatalf("unexpected token %s", got)
+	}
+	q, err := url.ParseQuery(strings.TrimPrefix(got, prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"Action":               "connect",
+		"DBUser":               "iam_user",
+		"X-Amz-Algorithm":      "AWS4-HMAC-SHA256",
+		"X-Amz-Expires":        "900",
+		"X-Amz-Security-Token": "session/token+value",
+	}
+	for k, v := range want {
+		if got := q.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+	if cred := q.Get("X-Amz-Credential"); !strings.HasPrefix(cred, "AKIAPROD/") || !strings.HasSuffix(cred, "/ap-northeast-1/rds-db/aws4_request") {
+		t.Errorf("unexpected credential %q", cred)
+	}
+
+	noRegion := newRDSAuthToken("db:3306", "iam_user", nil)
+	if _, err := noRegion.Get(ctx); err == nil {
+		t.Errorf("expected error without region")
+	}
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/sqls-server/sqls/dialect"
+)
+
+const (
+	AuthTypeAzureAD = "azure-ad"
+
+	AzureADMethodManagedIdentity = "managedIdentity"
+	AzureADMethodDeviceCode      = "deviceCode"
+	AzureADMethodClientSecret    = "clientSecret"
+
+	// The public client of Azure CLI, used for the device code flow unless
+	// a client ID is configured
+	azureCLIClientID = "04b07795-8ddb-461a-bbee-02f9e1bf7b46"
+)
+
+var azureADResources = map[dialect.DatabaseDriver]string{
+	dialect.DatabaseDriverMssql:      "https://database.windows.net/",
+	dialect.DatabaseDriverPostgreSQL: "https://ossrdbms-aad.database.windows.net",
+}
+
+// DeviceCodePrompt shows the message of the device code flow, which tells
+// the user the code to enter and where.
+var DeviceCodePrompt = func(message string) {
+	slog.Info(message)
+}
+
+type AzureADConfig struct {
+	Method       string `json:"method" yaml:"method"`
+	TenantID     string `json:"tenantId" yaml:"tenantId"`
+	ClientID     string `json:"clientId" yaml:"clientId"`
+	ClientSecret string `json:"clientSecret" yaml:"clientSecret"`
+	Authority    string `json:"authority" yaml:"authority"`
+}
+
+func (c *AzureADConfig) Validate() error {
+	switch c.Method {
+	case AzureADMethodManagedIdentity:
+	case AzureADMethodDeviceCode:
+		if c.TenantID == "" {
+			return errors.New("required: connections[].azure.tenantId")
+		}
+	case AzureADMethodClientSecret:
+		if c.TenantID == "" {
+			return errors.New("required: connections[].azure.tenantId")
+		}
+		if c.ClientID == "" {
+			return errors.New("required: connections[].azure.clientId")
+		}
+		if c.ClientSecret == "" {
+			return errors.New("required: connections[].azure.clientSecret")
+		}
+	default:
+		return errors.New("invalid: connections[].azure.method")
+	}
+	return nil
+}
+
+// azureADToken acquires the access token of the database resource with the
+// credential of azidentity, which caches it until it is about to expire and
+// refreshes it.
+type azureADToken struct {
+	cred     azcore.TokenCredential
+	resource string
+}
+
+func newAzureADToken(cfg *AzureADConfig, driver dialect.DatabaseDriver) (*azureADToken, error) {
+	cred, err := cfg.credential()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create Azure AD credential, %w", err)
+	}
+	return &azureADToken{
+		cred:     cred,
+		resource: azureADResources[driver],
+	}, nil
+}
+
+func (t *azureADToken) Get(ctx context.Context) (string, error) {
+	token, err := t.cred.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{strings.TrimSuffix(t.resource, "/") + "/.default"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot get Azure AD token, %w", err)
+	}
+	return token.Token, nil
+}
+
+// credential returns the credential of the method, on the cloud of the
+// authority.
+func (c *AzureADConfig) credential() (azcore.TokenCredential, error) {
+	clientOptions := azcore.ClientOptions{
+		Cloud: cloud.AzurePublic,
+	}
+	if c.Authority != "" {
+		clientOptions.Cloud.ActiveDirectoryAuthorityHost = c.Authority
+	}
+	switch c.Method {
+	case AzureADMethodManagedIdentity:
+		opts := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		if c.ClientID != "" {
+			opts.ID = azidentity.ClientID(c.ClientID)
+		}
+		return azidentity.NewManagedIdentityCredential(opts)
+	case AzureADMethodDeviceCode:
+		clientID := c.ClientID
+		if clientID == "" {
+			clientID = azureCLIClientID
+		}
+		return azidentity.NewDeviceCodeCredential(&azidentity.DeviceCodeCredentialOptions{
+			ClientOptions: clientOptions,
+			TenantID:      c.TenantID,
+			ClientID:      clientID,
+			UserPrompt: func(ctx context.Context, m azidentity.DeviceCodeMessage) error {
+				DeviceCodePrompt(m.Message)
+				return nil
+			},
+		})
+	case AzureADMethodClientSecret:
+		return azidentity.NewClientSecretCredential(c.TenantID, c.ClientID, c.ClientSecret, &azidentity.ClientSecretCredentialOptions{
+			ClientOptions: clientOptions,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported method %q", c.Method)
+	}
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/sqls-server/sqls/dialect"
+)
+
+type fakeTokenCredential struct {
+	scopes []string
+	err    error
+}
+
+func (c *fakeTokenCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.scopes = append(c.scopes, opts.Scopes...)
+	if c.err != nil {
+		return azcore.AccessToken{}, c.err
+	}
+	return azcore.AccessToken{Token: "token"}, nil
+}
+
+func TestAzureADToken_Get(t *testing.T) {
+	tests := []struct {
+		driver  dialect.DatabaseDriver
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{
+			driver: dialect.DatabaseDriverMssql,
+			want:   "https://database.windows.net/.default",
+		},
+		{
+			driver: dialect.DatabaseDriverPostgreSQL,
+			want:   "https://ossrdbms-aad.database.windows.net/.default",
+		},
+		{
+			driver:  dialect.DatabaseDriverMssql,
+			err:     errors.New("invalid_client"),
+			want:    "https://database.windows.net/.default",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %v", tt.driver, tt.err), func(t *testing.T) {
+			cred := &fakeTokenCredential{err: tt.err}
+			token := &azureADToken{cred: cred, resource: azureADResources[tt.driver]}
+			got, err := token.Get(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != "token" {
+				t.Errorf("got %q", got)
+			}
+			if len(cred.scopes) != 1 || cred.scopes[0] != tt.want {
+				t.Errorf("unexpected scopes %v, want %q", cred.scopes, tt.want)
+			}
+		})
+	}
+}
+
+func TestAzureADConfig_credential(t *testing.T) {
+	tests := []struct {
+		cfg     *AzureADConfig
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			cfg:  &AzureADConfig{Method: AzureADMethodManagedIdentity, ClientID: "app"},
+			want: &azidentity.ManagedIdentityCredential{},
+		},
+		{
+			cfg:  &AzureADConfig{Method: AzureADMethodDeviceCode, TenantID: "tenant"},
+			want: &azidentity.DeviceCodeCredential{},
+		},
+		{
+			cfg:  &AzureADConfig{Method: AzureADMethodClientSecret, TenantID: "tenant", ClientID: "app", ClientSecret: "secret", Authority: "https://login.microsoftonline.us"},
+			want: &azidentity.ClientSecretCredential{},
+		},
+		{
+			cfg:     &AzureADConfig{Method: "password"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cfg.Method, func(t *testing.T) {
+			got, err := tt.cfg.credential()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("credential() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && fmt.Sprintf("%T", got) != fmt.Sprintf("%T", tt.want) {
+				t.Errorf("got %T, want %T", got, tt.want)
+			}
+		})
+	}
+}
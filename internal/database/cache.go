@@ -4,10 +4,18 @@ import (
 	"context"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/logging"
 )
 
 type DBCacheGenerator struct {
-	repo DBRepository
+	repo  DBRepository
+	rules dialect.IdentifierRules
 }
 
 func NewDBCacheUpdater(repo DBRepository) *DBCacheGenerator {
@@ -18,7 +26,10 @@ func NewDBCacheUpdater(repo DBRepository) *DBCacheGenerator {
 
 func (u *DBCacheGenerator) GenerateDBCachePrimary(ctx context.Context) (*DBCache, error) {
 	var err error
-	dbCache := &DBCache{}
+	u.rules = u.identifierRules(ctx)
+	dbCache := &DBCache{
+		rules: u.rules,
+	}
 	dbCache.defaultSchema, err = u.repo.CurrentSchema(ctx)
 	if err != nil {
 		return nil, err
@@ -29,7 +40,7 @@ func (u *DBCacheGenerator) GenerateDBCachePrimary(ctx context.Context) (*DBCache
 	}
 	dbCache.Schemas = make(map[string]string)
 	for index, element := range schemas {
-		dbCache.Schemas[strings.ToUpper(index)] = element
+		dbCache.Schemas[dbCache.key(index)] = element
 	}
 
 	if dbCache.defaultSchema == "" {
@@ -46,21 +57,52 @@ func (u *DBCacheGenerator) GenerateDBCachePrimary(ctx context.Context) (*DBCache
 	}
 	dbCache.SchemaTables = make(map[string][]string)
 	for index, element := range schemaTables {
-		dbCache.SchemaTables[strings.ToUpper(index)] = element
+		dbCache.SchemaTables[dbCache.key(index)] = element
 	}
 
 	dbCache.ColumnsWithParent, err = u.genColumnCacheCurrent(ctx, dbCache.defaultSchema)
 	if err != nil {
 		return nil, err
 	}
+	if sr, ok := u.repo.(SearchPathRepository); ok {
+		dbCache.SearchPath, err = sr.SearchPath(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// The tables of the other schemas of the path are not qualified either
+		for _, schemaName := range dbCache.SearchPath {
+			if dbCache.MatchIdentifier(schemaName, dbCache.defaultSchema) {
+				continue
+			}
+			cols, err := u.genColumnCacheCurrent(ctx, schemaName)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range cols {
+				dbCache.ColumnsWithParent[k] = v
+			}
+		}
+	}
 	dbCache.ForeignKeys, err = u.genForeignKeysCache(ctx, dbCache.defaultSchema)
 	if err != nil {
 		return nil, err
 	}
+	// The roles are not visible to the users without the privileges of them
+	dbCache.Roles, _ = u.repo.Roles(ctx)
+	dbCache.Databases, _ = u.repo.Databases(ctx)
+	_, dbCache.CrossDatabase = u.repo.(CatalogRepository)
+	// The tables are completed without the types and the partitions
+	if tr, ok := u.repo.(TypeRepository); ok {
+		dbCache.Types, _ = tr.Types(ctx)
+	}
+	if pr, ok := u.repo.(PartitionRepository); ok {
+		dbCache.Partitions, _ = pr.Partitions(ctx)
+	}
 	return dbCache, nil
 }
 
 func (u *DBCacheGenerator) GenerateDBCacheSecondary(ctx context.Context) (map[string][]*ColumnDesc, error) {
+	u.rules = u.identifierRules(ctx)
 	return u.genColumnCacheAll(ctx)
 }
 
@@ -71,7 +113,7 @@ func (u *DBCacheGenerator) genSchemaCache(ctx context.Context) (map[string]strin
 	}
 	databaseMap := map[string]string{}
 	for _, db := range dbs {
-		databaseMap[strings.ToUpper(db)] = db
+		databaseMap[u.key(db)] = db
 	}
 	return databaseMap, nil
 }
@@ -81,7 +123,7 @@ func (u *DBCacheGenerator) genColumnCacheCurrent(ctx context.Context, schemaName
 	if err != nil {
 		return nil, err
 	}
-	return genColumnMap(columnDescs), nil
+	return genColumnMap(columnDescs, u.rules), nil
 }
 
 func (u *DBCacheGenerator) genColumnCacheAll(ctx context.Context) (map[string][]*ColumnDesc, error) {
@@ -89,7 +131,7 @@ func (u *DBCacheGenerator) genColumnCacheAll(ctx context.Context) (map[string][]
 	if err != nil {
 		return nil, err
 	}
-	return genColumnMap(columnDescs), nil
+	return genColumnMap(columnDescs, u.rules), nil
 }
 
 func (u *DBCacheGenerator) genForeignKeysCache(ctx context.Context, schemaName string) (map[string]map[string][]*ForeignKey, error) {
@@ -118,25 +160,168 @@ func (u *DBCacheGenerator) genForeignKeysCache(ctx context.Context, schemaName s
 	return retVal, nil
 }
 
-func genColumnMap(columnDescs []*ColumnDesc) map[string][]*ColumnDesc {
+func genColumnMap(columnDescs []*ColumnDesc, rules dialect.IdentifierRules) map[string][]*ColumnDesc {
 	columnMap := map[string][]*ColumnDesc{}
 	for _, desc := range columnDescs {
-		key := columnDatabaseKey(desc.Schema, desc.Table)
+		key := columnDatabaseKey(desc.Schema, desc.Table, rules)
 		columnMap[key] = append(columnMap[key], desc)
 	}
 	return columnMap
 }
 
 type DBCache struct {
-	defaultSchema     string
+	defaultSchema string
+	// rules are the rules of the database to match the identifiers with the
+	// names, which are case insensitive for the cache without the database
+	rules             dialect.IdentifierRules
 	Schemas           map[string]string
 	SchemaTables      map[string][]string
 	ColumnsWithParent map[string][]*ColumnDesc
 	ForeignKeys       map[string]map[string][]*ForeignKey
+	Roles             []string
+	// SearchPath are the schemas whose tables the names not qualified refer
+	// to, in the order of the path. The default schema is the only one of the
+	// path if it is empty.
+	SearchPath []string
+	// Databases are the databases of the server
+	Databases []string
+	// CrossDatabase is whether the tables of the other databases are
+	// referred to by the names of three parts
+	CrossDatabase bool
+	// Types are the types defined by the users and by the extensions
+	Types []*TypeDesc
+	// Partitions are the partitioned tables
+	Partitions []*PartitionDesc
+	// Catalogs are the caches of the other databases of the server, which the
+	// names of three parts refer to, by their names. They are cached on demand.
+	Catalogs map[string]*DBCache
+}
+
+// NewDBCache returns the empty cache of the default schema, such as of the
+// relations of a project not connected to the database.
+func NewDBCache(defaultSchema string) *DBCache {
+	return &DBCache{
+		defaultSchema:     defaultSchema,
+		Schemas:           make(map[string]string),
+		SchemaTables:      make(map[string][]string),
+		ColumnsWithParent: make(map[string][]*ColumnDesc),
+		ForeignKeys:       make(map[string]map[string][]*ForeignKey),
+		Catalogs:          make(map[string]*DBCache),
+	}
+}
+
+// DefaultSchema returns the schema of the tables not qualified.
+func (dc *DBCache) DefaultSchema() string {
+	return dc.defaultSchema
+}
+
+// Clone returns a copy of the cache whose maps can be changed without
+// changing the cache.
+func (dc *DBCache) Clone() *DBCache {
+	clone := NewDBCache(dc.defaultSchema)
+	clone.rules = dc.rules
+	for k, v := range dc.Schemas {
+		clone.Schemas[k] = v
+	}
+	for k, v := range dc.SchemaTables {
+		clone.SchemaTables[k] = v
+	}
+	for k, v := range dc.ColumnsWithParent {
+		clone.ColumnsWithParent[k] = v
+	}
+	for k, v := range dc.ForeignKeys {
+		clone.ForeignKeys[k] = v
+	}
+	for k, v := range dc.Catalogs {
+		clone.Catalogs[k] = v
+	}
+	clone.Roles = dc.Roles
+	clone.SearchPath = dc.SearchPath
+	clone.Databases = dc.Databases
+	clone.CrossDatabase = dc.CrossDatabase
+	clone.Types = dc.Types
+	clone.Partitions = dc.Partitions
+	return clone
+}
+
+// AddCatalog adds the cache of the tables of another database of the server
+// and their columns. The database without the columns is cached too, not to
+// describe it again.
+func (dc *DBCache) AddCatalog(catalog string, cols []*ColumnDesc) {
+	c := NewDBCache("")
+	c.rules = dc.rules
+	var tableCols []*ColumnDesc
+	for i, col := range cols {
+		tableCols = append(tableCols, col)
+		if i+1 < len(cols) && cols[i+1].Schema == col.Schema && cols[i+1].Table == col.Table {
+			continue
+		}
+		c.AddTable(col.Schema, col.Table, tableCols)
+		tableCols = nil
+	}
+	dc.Catalogs[dc.identKeys(catalog)[0]] = c
+}
+
+// HasCatalog reports whether the database of the server is cached.
+func (dc *DBCache) HasCatalog(catalog string) bool {
+	_, ok := dc.catalog(catalog)
+	return ok
+}
+
+// CatalogTables returns the tables of the schema of another database of the
+// server.
+func (dc *DBCache) CatalogTables(catalog, schemaName string) ([]string, bool) {
+	c, ok := dc.catalog(catalog)
+	if !ok {
+		return nil, false
+	}
+	return c.SortedTablesByDBName(schemaName)
+}
+
+// TableColumns returns the columns of the table of the name of up to three
+// parts, whose parts omitted are of the current database and schema.
+func (dc *DBCache) TableColumns(catalog, schemaName, tableName string) ([]*ColumnDesc, bool) {
+	switch {
+	case catalog != "":
+		c, ok := dc.catalog(catalog)
+		if !ok {
+			return nil, false
+		}
+		return c.ColumnDatabase(schemaName, tableName)
+	case schemaName != "":
+		return dc.ColumnDatabase(schemaName, tableName)
+	}
+	return dc.ColumnDescs(tableName)
+}
+
+// AddTable adds the table of the schema and its columns to the cache, or
+// replaces the columns of the table cached.
+func (dc *DBCache) AddTable(schemaName, tableName string, cols []*ColumnDesc) {
+	key := dc.key(schemaName)
+	if _, ok := dc.Schemas[key]; !ok && schemaName != "" {
+		dc.Schemas[key] = schemaName
+	}
+	tables := dc.SchemaTables[key]
+	found := false
+	for _, tbl := range tables {
+		if dc.key(tableName) == dc.key(tbl) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		// Not to append to the array shared with the cache cloned
+		dc.SchemaTables[key] = append(tables[:len(tables):len(tables)], tableName)
+	}
+	dc.ColumnsWithParent[dc.columnKey(schemaName, tableName)] = cols
 }
 
 func (dc *DBCache) Database(dbName string) (db string, ok bool) {
-	db, ok = dc.Schemas[strings.ToUpper(dbName)]
+	for _, key := range dc.identKeys(dbName) {
+		if db, ok = dc.Schemas[key]; ok {
+			return
+		}
+	}
 	return
 }
 
@@ -150,39 +335,287 @@ func (dc *DBCache) SortedSchemas() []string {
 }
 
 func (dc *DBCache) SortedTablesByDBName(dbName string) (tbls []string, ok bool) {
-	tbls, ok = dc.SchemaTables[strings.ToUpper(dbName)]
+	tbls, ok = dc.schemaTables(dbName)
 	sort.Strings(tbls)
 	return
 }
 
+// searchPath returns the schemas whose tables the names not qualified refer
+// to, in the order of the path.
+func (dc *DBCache) searchPath() []string {
+	if len(dc.SearchPath) == 0 {
+		return []string{dc.defaultSchema}
+	}
+	return dc.SearchPath
+}
+
+// SortedTables returns the tables which the names not qualified refer to.
+// The tables of the schemas earlier in the path hide the ones of the same
+// names later.
 func (dc *DBCache) SortedTables() []string {
-	tbls, _ := dc.SortedTablesByDBName(dc.defaultSchema)
+	tbls := []string{}
+	seen := map[string]bool{}
+	for _, schemaName := range dc.searchPath() {
+		schemaTables, _ := dc.SortedTablesByDBName(schemaName)
+		for _, tbl := range schemaTables {
+			if k := dc.key(tbl); !seen[k] {
+				seen[k] = true
+				tbls = append(tbls, tbl)
+			}
+		}
+	}
+	sort.Strings(tbls)
 	return tbls
 }
 
+// TableSchema returns the schema of the table which the name not qualified
+// refers to, and the position of the schema in the path.
+func (dc *DBCache) TableSchema(tableName string) (schemaName string, rank int, ok bool) {
+	for i, s := range dc.searchPath() {
+		if _, ok := dc.ColumnDatabase(s, tableName); ok {
+			return s, i, true
+		}
+		tbls, _ := dc.schemaTables(s)
+		for _, tbl := range tbls {
+			if dc.MatchIdentifier(tableName, tbl) {
+				return s, i, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
 func (dc *DBCache) ColumnDescs(tableName string) (cols []*ColumnDesc, ok bool) {
-	cols, ok = dc.ColumnsWithParent[columnDatabaseKey(dc.defaultSchema, tableName)]
+	for _, schemaName := range dc.searchPath() {
+		cols, ok = dc.ColumnDatabase(schemaName, tableName)
+		if ok {
+			return
+		}
+	}
 	return
 }
 
 func (dc *DBCache) ColumnDatabase(dbName, tableName string) (cols []*ColumnDesc, ok bool) {
-	cols, ok = dc.ColumnsWithParent[columnDatabaseKey(dbName, tableName)]
+	for _, dbKey := range dc.identKeys(dbName) {
+		for _, tableKey := range dc.identKeys(tableName) {
+			if cols, ok = dc.ColumnsWithParent[dbKey+"\t"+tableKey]; ok {
+				return
+			}
+		}
+	}
 	return
 }
 
+// schemaTables returns the tables of the schema which the identifier refers
+// to.
+func (dc *DBCache) schemaTables(schemaName string) ([]string, bool) {
+	for _, key := range dc.identKeys(schemaName) {
+		if tbls, ok := dc.SchemaTables[key]; ok {
+			return tbls, true
+		}
+	}
+	return nil, false
+}
+
+// catalog returns the cache of the database of the server which the
+// identifier refers to.
+func (dc *DBCache) catalog(catalog string) (*DBCache, bool) {
+	for _, key := range dc.identKeys(catalog) {
+		if c, ok := dc.Catalogs[key]; ok {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
 func (dc *DBCache) Column(tableName, colName string) (*ColumnDesc, bool) {
-	cols, ok := dc.ColumnsWithParent[columnDatabaseKey(dc.defaultSchema, tableName)]
+	cols, ok := dc.ColumnDescs(tableName)
 	if !ok {
 		return nil, false
 	}
 	for _, col := range cols {
-		if strings.EqualFold(col.Name, colName) {
+		if dc.MatchColumn(colName, col.Name) {
 			return col, true
 		}
 	}
 	return nil, false
 }
 
-func columnDatabaseKey(dbName, tableName string) string {
-	return strings.ToUpper(dbName) + "\t" + strings.ToUpper(tableName)
+// ColumnForeignKeys returns the columns which the column references by the
+// foreign keys, and the columns which reference it.
+func (dc *DBCache) ColumnForeignKeys(tableName, colName string) (references, referencedBy []*ColumnBase) {
+	if dc == nil {
+		return nil, nil
+	}
+	seen := map[*ForeignKey]bool{}
+	for table, refs := range dc.ForeignKeys {
+		if !dc.MatchIdentifier(tableName, table) {
+			continue
+		}
+		for _, fks := range refs {
+			for _, fk := range fks {
+				if seen[fk] {
+					continue
+				}
+				seen[fk] = true
+				for _, pair := range *fk {
+					if dc.matchColumn(pair[0], tableName, colName) {
+						references = append(references, pair[1])
+					}
+					if dc.matchColumn(pair[1], tableName, colName) {
+						referencedBy = append(referencedBy, pair[0])
+					}
+				}
+			}
+		}
+	}
+	sortColumnBases(references)
+	sortColumnBases(referencedBy)
+	return references, referencedBy
+}
+
+func (dc *DBCache) matchColumn(cb *ColumnBase, tableName, colName string) bool {
+	return dc.MatchIdentifier(tableName, cb.Table) && dc.MatchColumn(colName, cb.Name)
+}
+
+func sortColumnBases(cols []*ColumnBase) {
+	sort.Slice(cols, func(i, j int) bool {
+		if cols[i].Table != cols[j].Table {
+			return cols[i].Table < cols[j].Table
+		}
+		return cols[i].Name < cols[j].Name
+	})
+}
+
+// TableName returns the name of the table in the database which the
+// identifier in the query refers to, such as clients of Clients.
+func (dc *DBCache) TableName(tableName string) (string, bool) {
+	cols, ok := dc.ColumnDescs(tableName)
+	if !ok || len(cols) == 0 {
+		return "", false
+	}
+	return cols[0].Table, true
+}
+
+// MatchIdentifier reports whether the identifier in the query refers to the
+// schema or the table of the name, by the rules of the database. The quoted
+// identifier keeps its letter case on PostgreSQL and Oracle.
+func (dc *DBCache) MatchIdentifier(ident, name string) bool {
+	if dc == nil {
+		ident, _ = dialect.Unquote(ident)
+		return strings.EqualFold(ident, name)
+	}
+	return matchIdentifier(ident, name, dc.rules, foldIdentifier)
+}
+
+// MatchColumn reports whether the identifier in the query refers to the
+// column of the name, by the rules of the database.
+func (dc *DBCache) MatchColumn(ident, name string) bool {
+	if dc == nil {
+		return dc.MatchIdentifier(ident, name)
+	}
+	return matchIdentifier(ident, name, dc.rules, foldColumn)
+}
+
+// key returns the key of the maps of the name of the database.
+func (dc *DBCache) key(name string) string {
+	return foldIdentifier(name, true, dc.rules)
+}
+
+// identKeys returns the keys of the names which the identifier in the query
+// refers to, in the order to look them up.
+func (dc *DBCache) identKeys(ident string) []string {
+	return identifierKeys(ident, dc.rules)
+}
+
+func (dc *DBCache) columnKey(dbName, tableName string) string {
+	return columnDatabaseKey(dbName, tableName, dc.rules)
+}
+
+// identifierRules returns the rules of the identifiers of the database, with
+// the letter case of the names of the tables set on the server.
+func (u *DBCacheGenerator) identifierRules(ctx context.Context) dialect.IdentifierRules {
+	rules := dialect.NewIdentifierRules(u.repo.Driver())
+	if tr, ok := u.repo.(TableNameCaseRepository); ok {
+		sensitive, err := tr.TableNamesCaseSensitive(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Warn("cannot get the letter case of the table names", "err", err)
+		}
+		rules.TablesCaseSensitive = sensitive
+	}
+	return rules
+}
+
+func (u *DBCacheGenerator) key(name string) string {
+	return foldIdentifier(name, true, u.rules)
+}
+
+func columnDatabaseKey(dbName, tableName string, rules dialect.IdentifierRules) string {
+	return foldIdentifier(dbName, true, rules) + "\t" + foldIdentifier(tableName, true, rules)
+}
+
+// matchIdentifier reports whether the identifier matches the name by the
+// folding. The identifier not quoted matches the name as it is too, as the
+// names of the database and the identifiers whose quotes the parser removes
+// do.
+func matchIdentifier(ident, name string, rules dialect.IdentifierRules, fold func(string, bool, dialect.IdentifierRules) string) bool {
+	ident, quoted := dialect.Unquote(ident)
+	key := fold(name, true, rules)
+	return fold(ident, quoted, rules) == key || (!quoted && fold(ident, true, rules) == key)
+}
+
+// identifierKeys returns the keys of the names which the identifier matches.
+func identifierKeys(ident string, rules dialect.IdentifierRules) []string {
+	ident, quoted := dialect.Unquote(ident)
+	key := foldIdentifier(ident, quoted, rules)
+	if exact := foldIdentifier(ident, true, rules); exact != key {
+		return []string{key, exact}
+	}
+	return []string{key}
+}
+
+// foldIdentifier returns the key of the names of the schemas and the tables
+// which the identifier matches. The names of the database are folded as the
+// quoted identifiers.
+func foldIdentifier(name string, quoted bool, rules dialect.IdentifierRules) string {
+	switch {
+	case rules.Fold != nil && !quoted:
+		return rules.Fold(name)
+	case rules.Fold != nil, rules.TablesCaseSensitive:
+		return name
+	}
+	return strings.ToUpper(name)
+}
+
+// foldColumn returns the key of the names of the columns which the
+// identifier matches, without the accents if the database ignores them.
+func foldColumn(name string, quoted bool, rules dialect.IdentifierRules) string {
+	if rules.Fold != nil {
+		return foldIdentifier(name, quoted, rules)
+	}
+	if rules.ColumnsAccentInsensitive && !isASCII(name) {
+		name = removeAccents(name)
+	}
+	return strings.ToUpper(name)
+}
+
+// removeAccents removes the combining marks of the letters, such as the
+// acute accent of é.
+func removeAccents(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return norm.NFC.String(b.String())
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,329 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestCacheMatchIdentifier(t *testing.T) {
+	cols := []*ColumnDesc{
+		{ColumnBase: ColumnBase{Schema: "public", Table: "clients", Name: "id"}},
+		{ColumnBase: ColumnBase{Schema: "public", Table: "clients", Name: "prénom"}},
+	}
+	tests := []struct {
+		name   string
+		driver dialect.DatabaseDriver
+		table  string
+		column string
+		want   bool
+	}{
+		{
+			name:   "same",
+			table:  "clients",
+			column: "id",
+			want:   true,
+		},
+		{
+			name:   "case",
+			table:  "Clients",
+			column: "ID",
+			want:   true,
+		},
+		{
+			name:   "accent sensitive",
+			table:  "clients",
+			column: "prenom",
+			want:   false,
+		},
+		{
+			name:   "accent insensitive",
+			driver: dialect.DatabaseDriverMySQL,
+			table:  "CLIENTS",
+			column: "Prenom",
+			want:   true,
+		},
+		{
+			name:   "other column",
+			driver: dialect.DatabaseDriverMySQL,
+			table:  "clients",
+			column: "nom",
+			want:   false,
+		},
+		{
+			name:   "folded by postgresql",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			table:  "Clients",
+			column: "ID",
+			want:   true,
+		},
+		{
+			name:   "quoted of other case on postgresql",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			table:  "clients",
+			column: `"ID"`,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewDBCache("public")
+			cache.rules = dialect.NewIdentifierRules(tt.driver)
+			cache.AddTable("public", "clients", cols)
+
+			if name, ok := cache.TableName(tt.table); !ok || name != "clients" {
+				t.Errorf("unexpected table name %q, %v", name, ok)
+			}
+			_, got := cache.Column(tt.table, tt.column)
+			if got != tt.want {
+				t.Errorf("unexpected column found %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheIdentifierRules(t *testing.T) {
+	postgres := dialect.NewIdentifierRules(dialect.DatabaseDriverPostgreSQL)
+	oracle := dialect.NewIdentifierRules(dialect.DatabaseDriverOracle)
+	mysql := dialect.NewIdentifierRules(dialect.DatabaseDriverMySQL)
+	mysqlCaseSensitive := mysql
+	mysqlCaseSensitive.TablesCaseSensitive = true
+	tests := []struct {
+		name   string
+		rules  dialect.IdentifierRules
+		tables []string
+		table  string
+		want   string
+	}{
+		{
+			name:   "quoted mixed case of postgresql",
+			rules:  postgres,
+			tables: []string{"Clients", "clients"},
+			table:  `"Clients"`,
+			want:   "Clients",
+		},
+		{
+			name:   "quoted lower case of postgresql",
+			rules:  postgres,
+			tables: []string{"Clients", "clients"},
+			table:  `"clients"`,
+			want:   "clients",
+		},
+		{
+			name:   "not quoted of postgresql",
+			rules:  postgres,
+			tables: []string{"Clients", "clients"},
+			table:  "CLIENTS",
+			want:   "clients",
+		},
+		{
+			name:   "quoted of other case of postgresql",
+			rules:  postgres,
+			tables: []string{"Clients"},
+			table:  `"clients"`,
+			want:   "",
+		},
+		{
+			name:   "not quoted of mixed case of postgresql",
+			rules:  postgres,
+			tables: []string{"Clients"},
+			table:  "CLIENTS",
+			want:   "",
+		},
+		{
+			name:   "name of postgresql",
+			rules:  postgres,
+			tables: []string{"Clients"},
+			table:  "Clients",
+			want:   "Clients",
+		},
+		{
+			name:   "not quoted of oracle",
+			rules:  oracle,
+			tables: []string{"Clients", "CLIENTS"},
+			table:  "clients",
+			want:   "CLIENTS",
+		},
+		{
+			name:   "quoted of oracle",
+			rules:  oracle,
+			tables: []string{"Clients", "CLIENTS"},
+			table:  `"Clients"`,
+			want:   "Clients",
+		},
+		{
+			name:   "lower_case_table_names of mysql",
+			rules:  mysql,
+			tables: []string{"Clients"},
+			table:  "`CLIENTS`",
+			want:   "Clients",
+		},
+		{
+			name:   "case sensitive of mysql",
+			rules:  mysqlCaseSensitive,
+			tables: []string{"Clients"},
+			table:  "clients",
+			want:   "",
+		},
+		{
+			name:   "quoted case sensitive of mysql",
+			rules:  mysqlCaseSensitive,
+			tables: []string{"Clients", "clients"},
+			table:  "`Clients`",
+			want:   "Clients",
+		},
+		{
+			name:   "accents of table of mysql",
+			rules:  mysql,
+			tables: []string{"clés"},
+			table:  "cles",
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewDBCache("public")
+			cache.rules = tt.rules
+			for _, table := range tt.tables {
+				cache.AddTable("public", table, []*ColumnDesc{
+					{ColumnBase: ColumnBase{Schema: "public", Table: table, Name: "id"}},
+				})
+			}
+			if got, _ := cache.TableName(tt.table); got != tt.want {
+				t.Errorf("unexpected table %q, want %q", got, tt.want)
+			}
+			if tt.want != "" && !cache.MatchIdentifier(tt.table, tt.want) {
+				t.Errorf("%s does not match %s", tt.table, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheCatalog(t *testing.T) {
+	cache := NewDBCache("dbo")
+	cache.AddTable("dbo", "orders", []*ColumnDesc{
+		{ColumnBase: ColumnBase{Schema: "dbo", Table: "orders", Name: "id"}},
+	})
+	cache.AddCatalog("Archive", []*ColumnDesc{
+		{ColumnBase: ColumnBase{Schema: "dbo", Table: "orders", Name: "id"}},
+		{ColumnBase: ColumnBase{Schema: "dbo", Table: "orders", Name: "ordered_at"}},
+		{ColumnBase: ColumnBase{Schema: "dbo", Table: "customers", Name: "id"}},
+		{ColumnBase: ColumnBase{Schema: "sales", Table: "orders", Name: "id"}},
+	})
+	cache.AddCatalog("empty", nil)
+
+	if !cache.HasCatalog("archive") || !cache.HasCatalog("empty") || cache.HasCatalog("other") {
+		t.Error("unexpected databases cached")
+	}
+	tables, _ := cache.CatalogTables("archive", "dbo")
+	if diff := cmp.Diff([]string{"customers", "orders"}, tables); diff != "" {
+		t.Errorf("unexpected tables (- want, + got):\n%s", diff)
+	}
+	tests := []struct {
+		catalog string
+		schema  string
+		table   string
+		want    int
+	}{
+		{"", "", "orders", 1},
+		{"", "dbo", "orders", 1},
+		{"archive", "dbo", "orders", 2},
+		{"archive", "sales", "orders", 1},
+		{"archive", "dbo", "customers", 1},
+		{"empty", "dbo", "orders", 0},
+		{"other", "dbo", "orders", 0},
+	}
+	for _, tt := range tests {
+		cols, _ := cache.TableColumns(tt.catalog, tt.schema, tt.table)
+		if len(cols) != tt.want {
+			t.Errorf("%s.%s.%s: expected %d columns, got %d", tt.catalog, tt.schema, tt.table, tt.want, len(cols))
+		}
+	}
+	// The databases are kept by the clones
+	if !cache.Clone().HasCatalog("archive") {
+		t.Error("database is not cloned")
+	}
+}
+
+func TestCacheType(t *testing.T) {
+	cache := NewDBCache("public")
+	cache.Types = []*TypeDesc{
+		{Schema: "public", Name: "mood", Kind: TypeKindEnum, Labels: []string{"sad", "ok"}},
+		{Schema: "extensions", Name: "citext", Kind: TypeKindBase},
+	}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"mood", true},
+		{"MOOD", true},
+		{`"mood"`, true},
+		{"public.mood", true},
+		{"extensions.citext", true},
+		{"public.citext", false},
+		{"text", false},
+	}
+	for _, tt := range tests {
+		if _, got := cache.Type(tt.name); got != tt.want {
+			t.Errorf("%s: unexpected type found %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if doc := TypeDoc(cache.Types[0]); doc != "`mood` enum of 'sad', 'ok'\n" {
+		t.Errorf("unexpected document of enum, %q", doc)
+	}
+}
+
+func TestCacheSearchPath(t *testing.T) {
+	cache := NewDBCache("app")
+	cache.AddTable("app", "orders", []*ColumnDesc{
+		{ColumnBase: ColumnBase{Schema: "app", Table: "orders", Name: "id"}},
+	})
+	cache.AddTable("public", "orders", []*ColumnDesc{
+		{ColumnBase: ColumnBase{Schema: "public", Table: "orders", Name: "legacy_id"}},
+	})
+	cache.AddTable("public", "countries", []*ColumnDesc{
+		{ColumnBase: ColumnBase{Schema: "public", Table: "countries", Name: "code"}},
+	})
+	cache.AddTable("audit", "logs", nil)
+
+	// Only the default schema without the path
+	if diff := cmp.Diff([]string{"orders"}, cache.SortedTables()); diff != "" {
+		t.Errorf("unexpected tables (- want, + got):\n%s", diff)
+	}
+	if _, ok := cache.ColumnDescs("countries"); ok {
+		t.Error("unexpected table out of the path")
+	}
+
+	cache.SearchPath = []string{"app", "public"}
+	if diff := cmp.Diff([]string{"countries", "orders"}, cache.SortedTables()); diff != "" {
+		t.Errorf("unexpected tables of the path (- want, + got):\n%s", diff)
+	}
+	// The tables of the schemas earlier in the path hide the others
+	if _, ok := cache.Column("orders", "id"); !ok {
+		t.Error("orders is not of the first schema of the path")
+	}
+	if _, ok := cache.Column("countries", "code"); !ok {
+		t.Error("countries is not found in the path")
+	}
+	tests := []struct {
+		table  string
+		schema string
+		rank   int
+		ok     bool
+	}{
+		{"orders", "app", 0, true},
+		{"COUNTRIES", "public", 1, true},
+		{"logs", "", 0, false},
+	}
+	for _, tt := range tests {
+		schema, rank, ok := cache.TableSchema(tt.table)
+		if schema != tt.schema || rank != tt.rank || ok != tt.ok {
+			t.Errorf("%s: unexpected schema %q, %d, %v", tt.table, schema, rank, ok)
+		}
+	}
+	if got := cache.Clone().SearchPath; len(got) != 2 {
+		t.Errorf("search path is not cloned, %v", got)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
 	"net"
 	"net/url"
 	"strconv"
@@ -12,6 +11,7 @@ import (
 
 	clickhouse "github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/logging"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -33,12 +33,18 @@ func clickhouseOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 	}
 
 	if dbConnCfg.SSHCfg != nil {
-		dbConn, dbSSHConn, err := openClickhouseViaSSH(dsn, dbConnCfg.SSHCfg)
+		dbConn, dbSSHConn, err := openClickhouseViaSSH(dsn, dbConnCfg.SSHCfg, dbConnCfg.TLSCfg)
 		if err != nil {
 			return nil, err
 		}
 		conn = dbConn
 		sshConn = dbSSHConn
+	} else if dbConnCfg.TLSCfg != nil {
+		conf, err := genClickhouseOptions(dsn, dbConnCfg.TLSCfg)
+		if err != nil {
+			return nil, err
+		}
+		conn = clickhouse.OpenDB(conf)
 	} else {
 		dbConn, err := sql.Open("clickhouse", dsn)
 		if err != nil {
@@ -51,8 +57,7 @@ func clickhouseOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 		return nil, err
 	}
 
-	conn.SetMaxIdleConns(DefaultMaxIdleConns)
-	conn.SetMaxOpenConns(DefaultMaxOpenConns)
+	setConnPool(conn, dbConnCfg)
 
 	return &DBConnection{
 		Conn:    conn,
@@ -60,17 +65,12 @@ func clickhouseOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 	}, nil
 }
 
-func openClickhouseViaSSH(dsn string, sshCfg *SSHConfig) (*sql.DB, *ssh.Client, error) {
-	sshConfig, err := sshCfg.ClientConfig()
+func openClickhouseViaSSH(dsn string, sshCfg *SSHConfig, tlsCfg *TLSConfig) (*sql.DB, *ssh.Client, error) {
+	conf, err := genClickhouseOptions(dsn, tlsCfg)
 	if err != nil {
 		return nil, nil, err
 	}
-	sshConn, err := ssh.Dial("tcp", sshCfg.Endpoint(), sshConfig)
-	if err != nil {
-		return nil, nil, fmt.Errorf("cannot ssh dial, %w", err)
-	}
-
-	conf, err := clickhouse.ParseDSN(dsn)
+	sshConn, err := sshCfg.Dial()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -83,6 +83,28 @@ func openClickhouseViaSSH(dsn string, sshCfg *SSHConfig) (*sql.DB, *ssh.Client,
 	return conn, sshConn, nil
 }
 
+// genClickhouseOptions parses dsn and overrides the TLS setting with tlsCfg
+// if it is set.
+func genClickhouseOptions(dsn string, tlsCfg *TLSConfig) (*clickhouse.Options, error) {
+	conf, err := clickhouse.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		var host string
+		if len(conf.Addr) > 0 {
+			host, _, err = net.SplitHostPort(conf.Addr[0])
+			if err != nil {
+				host = conf.Addr[0]
+			}
+		}
+		if conf.TLS, err = tlsCfg.Config(host); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
 func genClickhouseDsn(dbConfig *DBConfig) (string, error) {
 	if dbConfig.DataSourceName != "" {
 		return dbConfig.DataSourceName, nil
@@ -251,7 +273,7 @@ WHERE  ( c.table_schema = currentDatabase()
        AND c.table_name NOT LIKE '%inner%'
 `)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tableInfos := []*ColumnDesc{}
@@ -306,7 +328,7 @@ WHERE  ( c.table_schema = ?
        AND c.table_name NOT LIKE '%inner%'
 `, schemaName)
 	if err != nil {
-		log.Println("schema", schemaName, err.Error())
+		logging.FromContext(ctx).Warn("cannot describe schema", "schema", schemaName, "err", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -336,16 +358,25 @@ func (*clickhouseSQLDBRepository) DescribeForeignKeysBySchema(ctx context.Contex
 	return nil, nil
 }
 
+func (db *clickhouseSQLDBRepository) Roles(ctx context.Context) ([]string, error) {
+	return queryNames(ctx, db.Conn, `
+	SELECT name FROM system.users
+	UNION ALL
+	SELECT name FROM system.roles
+	ORDER BY name
+	`)
+}
+
 func (*clickhouseSQLDBRepository) Driver() dialect.DatabaseDriver {
 	return dialect.DatabaseDriverClickhouse
 }
 
-func (db *clickhouseSQLDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
-	return db.Conn.ExecContext(ctx, query)
+func (db *clickhouseSQLDBRepository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Conn.ExecContext(ctx, query, args...)
 }
 
-func (db *clickhouseSQLDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
-	return db.Conn.QueryContext(ctx, query)
+func (db *clickhouseSQLDBRepository) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.Conn.QueryContext(ctx, query, args...)
 }
 
 func (db *clickhouseSQLDBRepository) SchemaTables(ctx context.Context) (map[string][]string, error) {
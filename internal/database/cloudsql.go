@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/cloudsqlconn"
+	"golang.org/x/oauth2"
+)
+
+const (
+	cloudSQLIPTypePublic  = "public"
+	cloudSQLIPTypePrivate = "private"
+	// The expiry of the tokens printed by tokenCmd is unknown, so they are
+	// used only for a while
+	cloudSQLTokenLifetime = 5 * time.Minute
+)
+
+// CloudSQLConfig connects to a Cloud SQL instance by its connection name with
+// the Cloud SQL Go Connector.
+type CloudSQLConfig struct {
+	// Instance is the instance connection name, project:region:instance
+	Instance string `json:"instance" yaml:"instance"`
+	IPType   string `json:"ipType" yaml:"ipType"`
+	IAMAuth  bool   `json:"iamAuth" yaml:"iamAuth"`
+	// TokenCmd prints the OAuth2 access token of the Google account, which is
+	// used instead of the application default credentials
+	TokenCmd string `json:"tokenCmd" yaml:"tokenCmd"`
+}
+
+func (c *CloudSQLConfig) Validate() error {
+	if len(strings.Split(c.Instance, ":")) != 3 {
+		return errors.New("invalid: connections[].cloudSQL.instance, specify project:region:instance")
+	}
+	switch c.IPType {
+	case "", cloudSQLIPTypePublic, cloudSQLIPTypePrivate:
+	default:
+		return errors.New("invalid: connections[].cloudSQL.ipType")
+	}
+	return nil
+}
+
+// cloudSQLDialer dials the instance of the config.
+type cloudSQLDialer struct {
+	instance string
+	dialer   *cloudsqlconn.Dialer
+}
+
+// newCloudSQLDialer returns the dialer which connects to the instance over
+// TLS with the ephemeral certificate issued by the SQL Admin API.
+func newCloudSQLDialer(ctx context.Context, cfg *CloudSQLConfig, opts ...cloudsqlconn.Option) (*cloudSQLDialer, error) {
+	dialer, err := cloudsqlconn.NewDialer(ctx, append(cfg.options(), opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create Cloud SQL dialer, %w", err)
+	}
+	return &cloudSQLDialer{
+		instance: cfg.Instance,
+		dialer:   dialer,
+	}, nil
+}
+
+func (c *CloudSQLConfig) options() []cloudsqlconn.Option {
+	var opts []cloudsqlconn.Option
+	if c.IPType == cloudSQLIPTypePrivate {
+		opts = append(opts, cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPrivateIP()))
+	}
+	if c.IAMAuth {
+		opts = append(opts, cloudsqlconn.WithIAMAuthN())
+	}
+	if c.TokenCmd != "" {
+		ts := oauth2.ReuseTokenSource(nil, &commandTokenSource{cmd: c.TokenCmd})
+		if c.IAMAuth {
+			opts = append(opts, cloudsqlconn.WithIAMAuthNTokenSources(ts, ts))
+		} else {
+			opts = append(opts, cloudsqlconn.WithTokenSource(ts))
+		}
+	}
+	return opts
+}
+
+func (d *cloudSQLDialer) Dial(ctx context.Context) (net.Conn, error) {
+	conn, err := d.dialer.Dial(ctx, d.instance)
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial Cloud SQL instance, %w", err)
+	}
+	return conn, nil
+}
+
+func (d *cloudSQLDialer) Close() error {
+	return d.dialer.Close()
+}
+
+// commandTokenSource is the source of the access tokens printed by tokenCmd.
+type commandTokenSource struct {
+	cmd string
+}
+
+func (ts *commandTokenSource) Token() (*oauth2.Token, error) {
+	token, err := runCommand(ts.cmd)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get Google access token, %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: strings.TrimSpace(token),
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(cloudSQLTokenLifetime),
+	}, nil
+}
@@ -0,0 +1,283 @@
+package database
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/cloudsqlconn"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}
+}
+
+func (ca *testCA) issue(t *testing.T, cn string, pub interface{}, usage x509.ExtKeyUsage) []byte {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, pub, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// testCloudSQLInstance is an instance which echoes back over TLS, and the SQL
+// Admin API of which issues the client certificates by its CA.
+type testCloudSQLInstance struct {
+	ln    net.Listener
+	admin *httptest.Server
+
+	mu          sync.Mutex
+	generated   int
+	accessToken string
+}
+
+func newTestCloudSQLInstance(t *testing.T, ca, serverCA *testCA) *testCloudSQLInstance {
+	t.Helper()
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverCert := serverCA.issue(t, "my-project:my-instance", &serverKey.PublicKey, x509.ExtKeyUsageServerAuth)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	inst := &testCloudSQLInstance{ln: ln}
+	inst.admin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/sql/v1beta4/projects/my-project/instances/my-instance/connectSettings":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"region":          "us-central1",
+				"backendType":     "SECOND_GEN",
+				"databaseVersion": "POSTGRES_15",
+				"serverCaCert":    map[string]string{"cert": ca.pem},
+				"ipAddresses": []map[string]string{
+					{"type": "PRIVATE", "ipAddress": "10.0.0.1"},
+					{"type": "PRIMARY", "ipAddress": "127.0.0.1"},
+				},
+			})
+		case "/sql/v1beta4/projects/my-project/instances/my-instance:generateEphemeralCert":
+			var req map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			inst.mu.Lock()
+			inst.generated++
+			inst.accessToken = req["access_token"]
+			inst.mu.Unlock()
+			block, _ := pem.Decode([]byte(req["public_key"]))
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			der := ca.issue(t, "client", pub, x509.ExtKeyUsageClientAuth)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ephemeralCert": map[string]string{
+					"cert": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(inst.admin.Close)
+	return inst
+}
+
+// dialer returns the dialer of the instance, which dials the listener instead
+// of the port 3307 of the address and reports the address.
+func (inst *testCloudSQLInstance) dialer(t *testing.T, cfg *CloudSQLConfig, dialed func(addr string)) *cloudSQLDialer {
+	t.Helper()
+	var dialer net.Dialer
+	d, err := newCloudSQLDialer(context.Background(), cfg,
+		cloudsqlconn.WithAdminAPIEndpoint(inst.admin.URL+"/"),
+		cloudsqlconn.WithDialFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed(addr)
+			return dialer.DialContext(ctx, network, inst.ln.Addr().String())
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func TestCloudSQLDialer_Dial(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("token command is run with sh")
+	}
+	ca := newTestCA(t)
+	inst := newTestCloudSQLInstance(t, ca, ca)
+
+	tests := []struct {
+		cfg  *CloudSQLConfig
+		addr string
+	}{
+		{
+			cfg:  &CloudSQLConfig{Instance: "my-project:us-central1:my-instance", IAMAuth: true, TokenCmd: "echo test-token"},
+			addr: "127.0.0.1:3307",
+		},
+		{
+			cfg:  &CloudSQLConfig{Instance: "my-project:us-central1:my-instance", IPType: "private", TokenCmd: "echo test-token"},
+			addr: "10.0.0.1:3307",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			inst.mu.Lock()
+			inst.generated, inst.accessToken = 0, ""
+			inst.mu.Unlock()
+			var dialed []string
+			d := inst.dialer(t, tt.cfg, func(addr string) { dialed = append(dialed, addr) })
+			for i := 0; i < 2; i++ {
+				conn, err := d.Dial(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := conn.Write([]byte("ping")); err != nil {
+					t.Fatal(err)
+				}
+				buf := make([]byte, 4)
+				if _, err := io.ReadFull(conn, buf); err != nil {
+					t.Fatal(err)
+				}
+				if string(buf) != "ping" {
+					t.Errorf("unexpected response %q", buf)
+				}
+				conn.Close()
+			}
+			for _, addr := range dialed {
+				if addr != tt.addr {
+					t.Errorf("dialed %s, want %s", addr, tt.addr)
+				}
+			}
+			inst.mu.Lock()
+			defer inst.mu.Unlock()
+			if inst.generated == 0 {
+				t.Error("ephemeral certificate is not generated")
+			}
+			// With IAM database authentication the certificate carries the identity
+			if tt.cfg.IAMAuth && inst.accessToken != "test-token" {
+				t.Errorf("access token is not sent for IAM auth, %q", inst.accessToken)
+			}
+			if !tt.cfg.IAMAuth && inst.accessToken != "" {
+				t.Errorf("access token is sent without IAM auth, %q", inst.accessToken)
+			}
+		})
+	}
+}
+
+func TestCloudSQLDialer_DialUntrustedServer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("token command is run with sh")
+	}
+	// The certificate of the instance is not issued by its CA
+	inst := newTestCloudSQLInstance(t, newTestCA(t), newTestCA(t))
+	d := inst.dialer(t, &CloudSQLConfig{
+		Instance: "my-project:us-central1:my-instance",
+		TokenCmd: "echo test-token",
+	}, func(string) {})
+	if conn, err := d.Dial(context.Background()); err == nil {
+		conn.Close()
+		t.Fatal("connected to the instance whose certificate is not issued by its CA")
+	}
+}
+
+func TestCloudSQLConfig_Validate(t *testing.T) {
+	tests := []struct {
+		cfg     *CloudSQLConfig
+		wantErr bool
+	}{
+		{cfg: &CloudSQLConfig{Instance: "project:region:instance"}},
+		{cfg: &CloudSQLConfig{Instance: "project:region:instance", IPType: "private"}},
+		{cfg: &CloudSQLConfig{Instance: "project:instance"}, wantErr: true},
+		{cfg: &CloudSQLConfig{Instance: "project:region:instance", IPType: "psa"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
@@ -2,11 +2,10 @@ package database
 
 import (
 	"errors"
-	"fmt"
-	"os"
+	"net"
+	"strconv"
 
 	"github.com/sqls-server/sqls/dialect"
-	"golang.org/x/crypto/ssh"
 )
 
 type Proto string
@@ -19,24 +18,136 @@ const (
 )
 
 type DBConfig struct {
-	Alias          string                 `json:"alias" yaml:"alias"`
-	Driver         dialect.DatabaseDriver `json:"driver" yaml:"driver"`
-	DataSourceName string                 `json:"dataSourceName" yaml:"dataSourceName"`
-	Proto          Proto                  `json:"proto" yaml:"proto"`
-	User           string                 `json:"user" yaml:"user"`
-	Passwd         string                 `json:"passwd" yaml:"passwd"`
-	Host           string                 `json:"host" yaml:"host"`
-	Port           int                    `json:"port" yaml:"port"`
-	Path           string                 `json:"path" yaml:"path"`
-	DBName         string                 `json:"dbName" yaml:"dbName"`
-	Params         map[string]string      `json:"params" yaml:"params"`
-	SSHCfg         *SSHConfig             `json:"sshConfig" yaml:"sshConfig"`
+	Alias             string                 `json:"alias" yaml:"alias"`
+	Driver            dialect.DatabaseDriver `json:"driver" yaml:"driver"`
+	DataSourceName    string                 `json:"dataSourceName" yaml:"dataSourceName"`
+	DataSourceNameCmd string                 `json:"dsnCmd" yaml:"dsnCmd"`
+	Proto             Proto                  `json:"proto" yaml:"proto"`
+	User              string                 `json:"user" yaml:"user"`
+	Passwd            string                 `json:"passwd" yaml:"passwd"`
+	PasswdCmd         string                 `json:"passwordCmd" yaml:"passwordCmd"`
+	PasswdStore       string                 `json:"passwordStore" yaml:"passwordStore"`
+	Host              string                 `json:"host" yaml:"host"`
+	Port              int                    `json:"port" yaml:"port"`
+	Path              string                 `json:"path" yaml:"path"`
+	DBName            string                 `json:"dbName" yaml:"dbName"`
+	Params            map[string]string      `json:"params" yaml:"params"`
+	SSHCfg            *SSHConfig             `json:"sshConfig" yaml:"sshConfig"`
+	TLSCfg            *TLSConfig             `json:"tls" yaml:"tls"`
+	AuthType          string                 `json:"authType" yaml:"authType"`
+	AWSCfg            *AWSConfig             `json:"aws" yaml:"aws"`
+	CloudSQLCfg       *CloudSQLConfig        `json:"cloudSQL" yaml:"cloudSQL"`
+	AzureADCfg        *AzureADConfig         `json:"azure" yaml:"azure"`
+	KerberosCfg       *KerberosConfig        `json:"kerberos" yaml:"kerberos"`
+	VaultCfg          *VaultConfig           `json:"vault" yaml:"vault"`
+	SQLiteCfg         *SQLiteConfig          `json:"sqlite" yaml:"sqlite"`
+	MaxIdleConns      int                    `json:"maxIdleConns" yaml:"maxIdleConns"`
+	MaxOpenConns      int                    `json:"maxOpenConns" yaml:"maxOpenConns"`
+	ConnMaxLifetime   Duration               `json:"connMaxLifetime" yaml:"connMaxLifetime"`
+	ConnMaxIdleTime   Duration               `json:"connMaxIdleTime" yaml:"connMaxIdleTime"`
 }
 
 func (c *DBConfig) Validate() error {
 	if c.Driver == "" {
 		return errors.New("required: connections[].driver")
 	}
+	if err := c.validatePool(); err != nil {
+		return err
+	}
+	if err := c.validatePasswordStore(); err != nil {
+		return err
+	}
+	if c.VaultCfg != nil {
+		if c.Passwd != "" || c.PasswdCmd != "" || c.PasswdStore != "" {
+			return errors.New("invalid: connections[].vault cannot be used with connections[].passwd, connections[].passwordCmd or connections[].passwordStore")
+		}
+		if c.AuthType != "" {
+			return errors.New("invalid: connections[].vault cannot be used with connections[].authType")
+		}
+		if err := c.VaultCfg.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.TLSCfg != nil {
+		switch c.Driver {
+		case dialect.DatabaseDriverSQLite3, dialect.DatabaseDriverH2, dialect.DatabaseDriverOracle:
+			return errors.New("invalid: connections[].tls is not supported by the driver")
+		}
+		if err := c.TLSCfg.Validate(); err != nil {
+			return err
+		}
+	}
+
+	switch c.AuthType {
+	case "":
+	case AuthTypeAWSIAM:
+		switch c.Driver {
+		case
+			dialect.DatabaseDriverMySQL,
+			dialect.DatabaseDriverMySQL8,
+			dialect.DatabaseDriverMySQL57,
+			dialect.DatabaseDriverMySQL56,
+			dialect.DatabaseDriverPostgreSQL:
+		default:
+			return errors.New("invalid: connections[].authType is not supported by the driver")
+		}
+	case AuthTypeAzureAD:
+		switch c.Driver {
+		case dialect.DatabaseDriverMssql, dialect.DatabaseDriverPostgreSQL:
+		default:
+			return errors.New("invalid: connections[].authType is not supported by the driver")
+		}
+		if c.AzureADCfg == nil {
+			return errors.New("required: connections[].azure")
+		}
+		if err := c.AzureADCfg.Validate(); err != nil {
+			return err
+		}
+	case AuthTypeKerberos:
+		switch c.Driver {
+		case dialect.DatabaseDriverMssql, dialect.DatabaseDriverPostgreSQL:
+		default:
+			return errors.New("invalid: connections[].authType is not supported by the driver")
+		}
+		if c.KerberosCfg != nil {
+			if err := c.KerberosCfg.Validate(c.Driver); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New("invalid: connections[].authType")
+	}
+
+	if c.SQLiteCfg != nil {
+		if c.Driver != dialect.DatabaseDriverSQLite3 {
+			return errors.New("invalid: connections[].sqlite is not supported by the driver")
+		}
+		if err := c.SQLiteCfg.Validate(c); err != nil {
+			return err
+		}
+	}
+
+	if c.CloudSQLCfg != nil {
+		switch c.Driver {
+		case
+			dialect.DatabaseDriverMySQL,
+			dialect.DatabaseDriverMySQL8,
+			dialect.DatabaseDriverMySQL57,
+			dialect.DatabaseDriverMySQL56,
+			dialect.DatabaseDriverPostgreSQL:
+		default:
+			return errors.New("invalid: connections[].cloudSQL is not supported by the driver")
+		}
+		if c.SSHCfg != nil {
+			return errors.New("invalid: connections[].cloudSQL cannot be used with connections[].sshConfig")
+		}
+		if !c.hasUser() && !c.hasDataSourceName() {
+			return errors.New("required: connections[].user")
+		}
+		// The instance connection name takes the place of the address
+		return c.CloudSQLCfg.Validate()
+	}
 
 	switch c.Driver {
 	case
@@ -46,12 +157,12 @@ func (c *DBConfig) Validate() error {
 		dialect.DatabaseDriverMySQL56,
 		dialect.DatabaseDriverPostgreSQL,
 		dialect.DatabaseDriverVertica:
-		if c.DataSourceName == "" && c.Proto == "" {
+		if !c.hasDataSourceName() && c.Proto == "" {
 			return errors.New("required: connections[].dataSourceName or connections[].proto")
 		}
 
-		if c.DataSourceName == "" && c.Proto != "" {
-			if c.User == "" {
+		if !c.hasDataSourceName() && c.Proto != "" {
+			if !c.hasUser() {
 				return errors.New("required: connections[].user")
 			}
 			switch c.Proto {
@@ -72,15 +183,15 @@ func (c *DBConfig) Validate() error {
 		}
 	case dialect.DatabaseDriverSQLite3:
 	case dialect.DatabaseDriverH2:
-		if c.DataSourceName == "" {
+		if !c.hasDataSourceName() {
 			return errors.New("required: connections[].dataSourceName")
 		}
 	case dialect.DatabaseDriverMssql:
-		if c.DataSourceName == "" && c.Proto == "" {
+		if !c.hasDataSourceName() && c.Proto == "" {
 			return errors.New("required: connections[].dataSourceName or connections[].proto")
 		}
-		if c.DataSourceName == "" && c.Proto != "" {
-			if c.User == "" {
+		if !c.hasDataSourceName() && c.Proto != "" {
+			if !c.hasUser() && c.AuthType != AuthTypeAzureAD && c.AuthType != AuthTypeKerberos {
 				return errors.New("required: connections[].user")
 			}
 			switch c.Proto {
@@ -94,14 +205,14 @@ func (c *DBConfig) Validate() error {
 			}
 		}
 	case dialect.DatabaseDriverOracle:
-		if c.DataSourceName == "" && c.Proto == "" {
+		if !c.hasDataSourceName() && c.Proto == "" {
 			return errors.New("required: connections[].dataSourceName or connections[].proto")
 		}
-		if c.DataSourceName == "" {
-			if c.User == "" {
+		if !c.hasDataSourceName() {
+			if !c.hasUser() {
 				return errors.New("required: connections[].user")
 			}
-			if c.Passwd == "" {
+			if c.Passwd == "" && c.PasswdCmd == "" && c.PasswdStore == "" && c.VaultCfg == nil {
 				return errors.New("required: connections[].Passwd")
 			}
 			if c.Host == "" {
@@ -115,12 +226,12 @@ func (c *DBConfig) Validate() error {
 			}
 		}
 	case dialect.DatabaseDriverClickhouse:
-		if c.DataSourceName == "" && c.Proto == "" {
+		if !c.hasDataSourceName() && c.Proto == "" {
 			return errors.New("required: connections[].dataSourceName or connections[].proto")
 		}
 
-		if c.DataSourceName == "" && c.Proto != "" {
-			if c.User == "" {
+		if !c.hasDataSourceName() && c.Proto != "" {
+			if !c.hasUser() {
 				return errors.New("required: connections[].user")
 			}
 			switch c.Proto {
@@ -143,54 +254,54 @@ func (c *DBConfig) Validate() error {
 	return nil
 }
 
+// hasUser reports whether the user is configured or read from Vault.
+func (c *DBConfig) hasUser() bool {
+	return c.User != "" || c.VaultCfg != nil
+}
+
+func (c *DBConfig) hasDataSourceName() bool {
+	return c.DataSourceName != "" || c.DataSourceNameCmd != ""
+}
+
 type SSHConfig struct {
-	Host       string `json:"host" yaml:"host"`
-	Port       int    `json:"port" yaml:"port"`
-	User       string `json:"user" yaml:"user"`
-	PassPhrase string `json:"passPhrase" yaml:"passPhrase"`
-	PrivateKey string `json:"privateKey" yaml:"privateKey"`
+	Host                  string       `json:"host" yaml:"host"`
+	Port                  int          `json:"port" yaml:"port"`
+	User                  string       `json:"user" yaml:"user"`
+	PassPhrase            string       `json:"passPhrase" yaml:"passPhrase"`
+	PrivateKey            string       `json:"privateKey" yaml:"privateKey"`
+	UseAgent              bool         `json:"useAgent" yaml:"useAgent"`
+	UseSSHConfig          bool         `json:"useSSHConfig" yaml:"useSSHConfig"`
+	StrictHostKeyChecking bool         `json:"strictHostKeyChecking" yaml:"strictHostKeyChecking"`
+	KnownHostsFile        string       `json:"knownHostsFile" yaml:"knownHostsFile"`
+	ProxyJump             []*SSHConfig `json:"proxyJump" yaml:"proxyJump"`
 }
 
 func (s *SSHConfig) Validate() error {
 	if s.Host == "" {
 		return errors.New("required: connections[]sshConfig.host")
 	}
+	// The user and the identity may be given by ~/.ssh/config
+	if s.UseSSHConfig {
+		return nil
+	}
 	if s.User == "" {
 		return errors.New("required: connections[].sshConfig.user")
 	}
-	if s.PrivateKey == "" {
+	if s.PrivateKey == "" && !s.UseAgent {
 		return errors.New("required: connections[].sshConfig.privateKey")
 	}
+	for _, jump := range s.ProxyJump {
+		if err := jump.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (s *SSHConfig) Endpoint() string {
-	return fmt.Sprintf("%s:%d", s.Host, s.Port)
-}
-
-func (s *SSHConfig) ClientConfig() (*ssh.ClientConfig, error) {
-	buffer, err := os.ReadFile(s.PrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("cannot read SSH private key file, PrivateKey=%s, %w", s.PrivateKey, err)
-	}
-
-	var key ssh.Signer
-	if s.PassPhrase != "" {
-		key, err = ssh.ParsePrivateKeyWithPassphrase(buffer, []byte(s.PassPhrase))
-		if err != nil {
-			return nil, fmt.Errorf("cannot parse SSH private key file with passphrase, PrivateKey=%s, %w", s.PrivateKey, err)
-		}
-	} else {
-		key, err = ssh.ParsePrivateKey(buffer)
-		if err != nil {
-			return nil, fmt.Errorf("cannot parse SSH private key file, PrivateKey=%s, %w", s.PrivateKey, err)
-		}
-	}
-
-	sshConfig := &ssh.ClientConfig{
-		User:            s.User,
-		Auth:            []ssh.AuthMethod{ssh.PublicKeys(key)},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	port := s.Port
+	if port == 0 {
+		port = defaultSSHPort
 	}
-	return sshConfig, nil
+	return net.JoinHostPort(s.Host, strconv.Itoa(port))
 }
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/sqls-server/sqls/dialect"
@@ -30,9 +31,31 @@ type DBRepository interface {
 	SchemaTables(ctx context.Context) (map[string][]string, error)
 	DescribeDatabaseTable(ctx context.Context) ([]*ColumnDesc, error)
 	DescribeDatabaseTableBySchema(ctx context.Context, schemaName string) ([]*ColumnDesc, error)
-	Exec(ctx context.Context, query string) (sql.Result, error)
-	Query(ctx context.Context, query string) (*sql.Rows, error)
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 	DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error)
+	Roles(ctx context.Context) ([]string, error)
+}
+
+// CatalogRepository is the repository of the databases whose names of three
+// parts refer to the tables of the other databases of the server, such as
+// db.schema.table of SQL Server.
+type CatalogRepository interface {
+	DescribeCatalogTable(ctx context.Context, catalog string) ([]*ColumnDesc, error)
+}
+
+// SearchPathRepository is the repository of the databases whose names not
+// qualified refer to the tables of the schemas of a path, such as search_path
+// of PostgreSQL.
+type SearchPathRepository interface {
+	SearchPath(ctx context.Context) ([]string, error)
+}
+
+// TableNameCaseRepository is the repository of the databases whose names of
+// the tables are case sensitive by the setting of the server, such as
+// lower_case_table_names of MySQL.
+type TableNameCaseRepository interface {
+	TableNamesCaseSensitive(ctx context.Context) (bool, error)
 }
 
 type DBOption struct {
@@ -53,8 +76,29 @@ type ColumnDesc struct {
 	Key     string
 	Default sql.NullString
 	Extra   string
+	// Comment is the description of the column, of the database or of the
+	// docs of dbt
+	Comment string
+	// Generated is whether the database computes the values of the column
+	// from the other columns
+	Generated bool
+	// Identity is whether the database numbers the values of the column
+	Identity Identity
 }
 
+// Identity is how the database numbers the values of a column.
+type Identity string
+
+const (
+	// IdentityAlways is the column whose values cannot be assigned, such as
+	// GENERATED ALWAYS AS IDENTITY and IDENTITY of SQL Server
+	IdentityAlways Identity = "always"
+	// IdentityByDefault is the column numbered unless its values are
+	// assigned, such as AUTO_INCREMENT, serial and GENERATED BY DEFAULT AS
+	// IDENTITY
+	IdentityByDefault Identity = "by default"
+)
+
 type ForeignKey [][2]*ColumnBase
 
 type fkItemDesc struct {
@@ -66,6 +110,46 @@ type fkItemDesc struct {
 	refColumn string
 }
 
+// IsPrimaryKey reports whether the column is in the primary key, which MySQL
+// describes with PRI and the others with YES.
+func (cd *ColumnDesc) IsPrimaryKey() bool {
+	return cd.Key == "YES" || cd.Key == "PRI"
+}
+
+// IsAssignable reports whether the values of the column can be assigned by
+// INSERT and UPDATE, which the generated columns and the identity columns
+// GENERATED ALWAYS cannot.
+func (cd *ColumnDesc) IsAssignable() bool {
+	return !cd.Generated && cd.Identity != IdentityAlways
+}
+
+// setGenerated sets Generated and Identity of the column from Extra, which
+// the drivers describe in the words of MySQL (auto_increment, VIRTUAL
+// GENERATED and STORED GENERATED), of the standard (GENERATED ... AS IDENTITY
+// and GENERATED ALWAYS AS (...)), of SQL Server (identity and computed), and
+// serial and rowid of PostgreSQL and SQLite.
+func (cd *ColumnDesc) setGenerated() {
+	extra := strings.ToUpper(cd.Extra)
+	switch {
+	case strings.Contains(extra, "ALWAYS AS IDENTITY"), extra == "IDENTITY":
+		cd.Identity = IdentityAlways
+	case strings.Contains(extra, "BY DEFAULT AS IDENTITY"), strings.Contains(extra, "AUTO_INCREMENT"), extra == "SERIAL", extra == "ROWID":
+		cd.Identity = IdentityByDefault
+	}
+	cd.Generated = strings.Contains(extra, "VIRTUAL GENERATED") || strings.Contains(extra, "STORED GENERATED") ||
+		strings.HasPrefix(extra, "GENERATED ALWAYS AS (") || extra == "COMPUTED"
+}
+
+// deprecatedPattern matches the comments of the objects not to be used any
+// more, such as "Deprecated: use name instead".
+var deprecatedPattern = regexp.MustCompile(`(?i)\bdeprecated\b`)
+
+// IsDeprecated reports whether the comment or the description of an object
+// says it is deprecated.
+func IsDeprecated(comment string) bool {
+	return deprecatedPattern.MatchString(comment)
+}
+
 func (cd *ColumnDesc) OnelineDesc() string {
 	items := []string{}
 	if cd.Type != "" {
@@ -82,12 +166,37 @@ func (cd *ColumnDesc) OnelineDesc() string {
 	return strings.Join(items, " ")
 }
 
-func ColumnDoc(tableName string, colDesc *ColumnDesc) string {
+// ColumnDoc returns the description of the column in Markdown, with the
+// columns of the foreign keys of the cache which it references and which
+// reference it.
+func ColumnDoc(tableName string, colDesc *ColumnDesc, dbCache *DBCache) string {
 	buf := new(bytes.Buffer)
 	fmt.Fprintf(buf, "`%s`.`%s` column", tableName, colDesc.Name)
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf, colDesc.OnelineDesc())
+	if t, ok := dbCache.Type(colDesc.Type); ok {
+		if doc := TypeDoc(t); doc != "" {
+			fmt.Fprintln(buf)
+			fmt.Fprint(buf, doc)
+		}
+	}
+	references, referencedBy := dbCache.ColumnForeignKeys(tableName, colDesc.Name)
+	if len(references) > 0 || len(referencedBy) > 0 {
+		fmt.Fprintln(buf)
+	}
+	for _, col := range references {
+		fmt.Fprintf(buf, "- references `%s`.`%s`", col.Table, col.Name)
+		fmt.Fprintln(buf)
+	}
+	for _, col := range referencedBy {
+		fmt.Fprintf(buf, "- referenced by `%s`.`%s`", col.Table, col.Name)
+		fmt.Fprintln(buf)
+	}
+	if colDesc.Comment != "" {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, colDesc.Comment)
+	}
 	return buf.String()
 }
 
@@ -100,21 +209,43 @@ func Coalesce(str ...string) string {
 	return ""
 }
 
+// TableDoc returns the table of the columns in Markdown. The comments of the
+// columns are in the last column if any of them has one.
 func TableDoc(tableName string, cols []*ColumnDesc) string {
+	commented := false
+	for _, col := range cols {
+		if col.Comment != "" {
+			commented = true
+			break
+		}
+	}
 	buf := new(bytes.Buffer)
 	fmt.Fprintf(buf, "# `%s` table", tableName)
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf)
 	fmt.Fprintln(buf)
-	fmt.Fprintln(buf, "| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |")
-	fmt.Fprintln(buf, "| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |")
+	if commented {
+		fmt.Fprintln(buf, "| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; | Comment&nbsp;&nbsp; |")
+		fmt.Fprintln(buf, "| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- | :------------------ |")
+	} else {
+		fmt.Fprintln(buf, "| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |")
+		fmt.Fprintln(buf, "| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |")
+	}
 	for _, col := range cols {
 		fmt.Fprintf(buf, "| `%s` | `%s` | `%s` | `%s` | %s |", col.Name, col.Type, col.Key, Coalesce(col.Default.String, "-"), col.Extra)
+		if commented {
+			fmt.Fprintf(buf, " %s |", markdownCell(col.Comment))
+		}
 		fmt.Fprintln(buf)
 	}
 	return buf.String()
 }
 
+// markdownCell returns the text on a line of a cell of a Markdown table.
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(text)
+}
+
 func SubqueryDoc(name string, views []*parseutil.SubQueryView, dbCache *DBCache) string {
 	buf := new(bytes.Buffer)
 	fmt.Fprintf(buf, "%s subquery", name)
@@ -218,3 +349,22 @@ func parseForeignKeys(rows *sql.Rows, schemaName string) ([]*ForeignKey, error)
 	}
 	return retVal, nil
 }
+
+// queryNames returns the values of the first column of the rows of the
+// query, such as the names of the roles.
+func queryNames(ctx context.Context, conn *sql.DB, query string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
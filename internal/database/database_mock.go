@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"regexp"
 
 	"github.com/sqls-server/sqls/dialect"
 )
@@ -18,6 +19,10 @@ type MockDBRepository struct {
 	MockExec                          func(context.Context, string) (sql.Result, error)
 	MockQuery                         func(context.Context, string) (*sql.Rows, error)
 	MockDescribeForeignKeysBySchema   func(context.Context, string) ([]*ForeignKey, error)
+	MockRoles                         func(context.Context) ([]string, error)
+	MockDescribeCatalogTable          func(context.Context, string) ([]*ColumnDesc, error)
+	MockWarnings                      func(context.Context, string) []string
+	MockTypes                         func(context.Context) ([]*TypeDesc, error)
 }
 
 func NewMockDBRepository(_ *sql.DB) DBRepository {
@@ -65,9 +70,32 @@ func NewMockDBRepository(_ *sql.DB) DBRepository {
 		MockDescribeForeignKeysBySchema: func(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 			return foreignKeys, nil
 		},
+		MockRoles: func(ctx context.Context) ([]string, error) {
+			return dummyRoles, nil
+		},
+		MockDescribeCatalogTable: func(ctx context.Context, catalog string) ([]*ColumnDesc, error) {
+			if catalog == "archive" {
+				return dummyArchiveColumns, nil
+			}
+			return nil, nil
+		},
+		MockTypes: func(ctx context.Context) ([]*TypeDesc, error) {
+			return dummyTypes, nil
+		},
+		MockWarnings: func(ctx context.Context, query string) []string {
+			var warnings []string
+			for _, m := range mockNoticePattern.FindAllStringSubmatch(query, -1) {
+				warnings = append(warnings, "NOTICE: "+m[1])
+			}
+			return warnings
+		},
 	}
 }
 
+// mockNoticePattern is RAISE NOTICE of PostgreSQL, whose messages are the
+// warnings of the mock
+var mockNoticePattern = regexp.MustCompile(`(?i)RAISE NOTICE '([^']*)'`)
+
 func (m *MockDBRepository) Driver() dialect.DatabaseDriver {
 	return "mock"
 }
@@ -104,18 +132,69 @@ func (m *MockDBRepository) DescribeDatabaseTableBySchema(ctx context.Context, sc
 	return m.MockDescribeDatabaseTableBySchema(ctx, schemaName)
 }
 
-func (m *MockDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
+func (m *MockDBRepository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	return m.MockExec(ctx, query)
 }
 
-func (m *MockDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
+func (m *MockDBRepository) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	return m.MockQuery(ctx, query)
 }
 
+func (m *MockDBRepository) ExecWarnings(ctx context.Context, query string, args ...interface{}) (sql.Result, []string, error) {
+	result, err := m.MockExec(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, m.MockWarnings(ctx, query), nil
+}
+
+func (m *MockDBRepository) QueryWarnings(ctx context.Context, query string, fn func(*sql.Rows) error, args ...interface{}) ([]string, error) {
+	rows, err := m.MockQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(rows); err != nil {
+		return nil, err
+	}
+	return m.MockWarnings(ctx, query), nil
+}
+
 func (m *MockDBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 	return m.MockDescribeForeignKeysBySchema(ctx, schemaName)
 }
 
+func (m *MockDBRepository) Roles(ctx context.Context) ([]string, error) {
+	return m.MockRoles(ctx)
+}
+
+func (m *MockDBRepository) DescribeCatalogTable(ctx context.Context, catalog string) ([]*ColumnDesc, error) {
+	return m.MockDescribeCatalogTable(ctx, catalog)
+}
+
+func (m *MockDBRepository) Types(ctx context.Context) ([]*TypeDesc, error) {
+	return m.MockTypes(ctx)
+}
+
+var dummyTypes = []*TypeDesc{
+	{
+		Schema: "world",
+		Name:   "address",
+		Kind:   TypeKindComposite,
+		Fields: []*TypeField{
+			{Name: "street", Type: "text"},
+			{Name: "zip", Type: "character varying(10)"},
+		},
+	},
+	{Schema: "world", Name: "citext", Kind: TypeKindBase},
+	{Schema: "world", Name: "email", Kind: TypeKindDomain, BaseType: "citext"},
+	{Schema: "world", Name: "mood", Kind: TypeKindEnum, Labels: []string{"sad", "ok", "happy"}},
+}
+
+var dummyRoles = []string{
+	"admin",
+	"reporting",
+}
+
 var dummyDatabases = []string{
 	"information_schema",
 	"mysql",
@@ -130,6 +209,31 @@ var dummyDatabaseTables = map[string][]string{
 		"countrylanguage",
 	},
 }
+
+// dummyArchiveColumns are the columns of the database archive of the server,
+// which the names of three parts refer to
+var dummyArchiveColumns = []*ColumnDesc{
+	{
+		ColumnBase: ColumnBase{
+			Schema: "dbo",
+			Table:  "orders",
+			Name:   "OrderID",
+		},
+		Type: "int",
+		Null: "NO",
+		Key:  "YES",
+	},
+	{
+		ColumnBase: ColumnBase{
+			Schema: "dbo",
+			Table:  "orders",
+			Name:   "OrderedAt",
+		},
+		Type: "datetime",
+		Null: "YES",
+		Key:  "NO",
+	},
+}
 var dummyTables = []string{
 	"city",
 	"country",
@@ -149,7 +253,8 @@ var dummyCityColumns = []*ColumnDesc{
 			String: "<null>",
 			Valid:  false,
 		},
-		Extra: "auto_increment",
+		Extra:    "auto_increment",
+		Identity: IdentityByDefault,
 	},
 	{
 		ColumnBase: ColumnBase{
@@ -226,7 +331,8 @@ var dummyCountryColumns = []*ColumnDesc{
 			String: "<null>",
 			Valid:  false,
 		},
-		Extra: "auto_increment",
+		Extra:    "auto_increment",
+		Identity: IdentityByDefault,
 	},
 	{
 		ColumnBase: ColumnBase{
@@ -301,7 +407,8 @@ var dummyCountryColumns = []*ColumnDesc{
 			String: "0.00",
 			Valid:  false,
 		},
-		Extra: "auto_increment",
+		Extra:    "auto_increment",
+		Identity: IdentityByDefault,
 	},
 	{
 		ColumnBase: ColumnBase{
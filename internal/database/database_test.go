@@ -0,0 +1,49 @@
+package database
+
+import "testing"
+
+func TestColumnDescSetGenerated(t *testing.T) {
+	tests := []struct {
+		extra      string
+		generated  bool
+		identity   Identity
+		assignable bool
+	}{
+		{"", false, "", true},
+		{"auto_increment", false, IdentityByDefault, true},
+		{"DEFAULT_GENERATED on update CURRENT_TIMESTAMP", false, "", true},
+		{"VIRTUAL GENERATED", true, "", false},
+		{"STORED GENERATED", true, "", false},
+		{"GENERATED ALWAYS AS IDENTITY", false, IdentityAlways, false},
+		{"GENERATED BY DEFAULT AS IDENTITY", false, IdentityByDefault, true},
+		{"GENERATED ALWAYS AS ((price * quantity)) STORED", true, "", false},
+		{"serial", false, IdentityByDefault, true},
+		{"rowid", false, IdentityByDefault, true},
+		{"identity", false, IdentityAlways, false},
+		{"computed", true, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.extra, func(t *testing.T) {
+			desc := &ColumnDesc{Extra: tt.extra}
+			desc.setGenerated()
+			if desc.Generated != tt.generated || desc.Identity != tt.identity || desc.IsAssignable() != tt.assignable {
+				t.Errorf("unexpected column, generated %v, identity %q, assignable %v", desc.Generated, desc.Identity, desc.IsAssignable())
+			}
+		})
+	}
+}
+
+func TestSetOracleGenerated(t *testing.T) {
+	desc := &ColumnDesc{}
+	desc.Default.String, desc.Default.Valid = "\"PRICE\"*\"QUANTITY\" ", true
+	setOracleGenerated(desc, "YES", "")
+	if !desc.Generated || desc.Default.Valid || desc.Extra != `GENERATED ALWAYS AS ("PRICE"*"QUANTITY") VIRTUAL` {
+		t.Errorf("unexpected virtual column, %+v", desc)
+	}
+
+	desc = &ColumnDesc{}
+	setOracleGenerated(desc, "NO", "BY DEFAULT")
+	if desc.Identity != IdentityByDefault || desc.Extra != "GENERATED BY DEFAULT AS IDENTITY" {
+		t.Errorf("unexpected identity column, %+v", desc)
+	}
+}
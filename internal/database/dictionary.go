@@ -0,0 +1,175 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// DictionaryFormat is the format of a data dictionary.
+type DictionaryFormat string
+
+const (
+	DictionaryMarkdown DictionaryFormat = "markdown"
+	DictionaryHTML     DictionaryFormat = "html"
+)
+
+// ParseDictionaryFormat returns the format of the name, markdown if it is
+// empty.
+func ParseDictionaryFormat(name string) (DictionaryFormat, error) {
+	switch strings.ToLower(name) {
+	case "", "markdown", "md":
+		return DictionaryMarkdown, nil
+	case "html", "htm":
+		return DictionaryHTML, nil
+	}
+	return "", fmt.Errorf("unsupported data dictionary format: %s", name)
+}
+
+// Extension returns the extension of the files of the format.
+func (f DictionaryFormat) Extension() string {
+	if f == DictionaryHTML {
+		return "html"
+	}
+	return "md"
+}
+
+// dictionaryTable is a table of a data dictionary with its foreign keys.
+type dictionaryTable struct {
+	name    string
+	columns []*ColumnDesc
+	// references are the foreign keys of the table, and referencedBy the ones
+	// of the other tables referring to it
+	references   []*ForeignKey
+	referencedBy []*ForeignKey
+}
+
+// DataDictionary returns the document of the tables of the default schema of
+// the cache with their columns, types, comments and foreign keys in the format.
+func DataDictionary(cache *DBCache, title string, format DictionaryFormat) string {
+	tables := dictionaryTables(cache)
+	if format == DictionaryHTML {
+		return htmlDictionary(tables, title)
+	}
+	return markdownDictionary(tables, title)
+}
+
+func dictionaryTables(cache *DBCache) []*dictionaryTable {
+	tables := []*dictionaryTable{}
+	byName := map[string]*dictionaryTable{}
+	for _, name := range cache.SortedTables() {
+		cols, _ := cache.ColumnDescs(name)
+		t := &dictionaryTable{name: name, columns: cols}
+		tables = append(tables, t)
+		byName[strings.ToUpper(name)] = t
+	}
+
+	// The foreign keys are cached for both of the tables
+	seen := map[*ForeignKey]bool{}
+	for _, refs := range cache.ForeignKeys {
+		for _, fks := range refs {
+			for _, fk := range fks {
+				if seen[fk] || len(*fk) == 0 {
+					continue
+				}
+				seen[fk] = true
+				if t, ok := byName[strings.ToUpper((*fk)[0][0].Table)]; ok {
+					t.references = append(t.references, fk)
+				}
+				if t, ok := byName[strings.ToUpper((*fk)[0][1].Table)]; ok {
+					t.referencedBy = append(t.referencedBy, fk)
+				}
+			}
+		}
+	}
+	for _, t := range tables {
+		sortForeignKeys(t.references)
+		sortForeignKeys(t.referencedBy)
+	}
+	return tables
+}
+
+func sortForeignKeys(fks []*ForeignKey) {
+	sort.Slice(fks, func(i, j int) bool {
+		return foreignKeyString(fks[i]) < foreignKeyString(fks[j])
+	})
+}
+
+// foreignKeyString returns the columns of the foreign key and the columns
+// they refer to, such as city.CountryCode -> country.Code.
+func foreignKeyString(fk *ForeignKey) string {
+	cols, refs := []string{}, []string{}
+	for _, pair := range *fk {
+		cols = append(cols, pair[0].Name)
+		refs = append(refs, pair[1].Name)
+	}
+	return fmt.Sprintf("%s.%s -> %s.%s",
+		(*fk)[0][0].Table, strings.Join(cols, ", "),
+		(*fk)[0][1].Table, strings.Join(refs, ", "))
+}
+
+func markdownDictionary(tables []*dictionaryTable, title string) string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# Data dictionary of %s\n\n", title)
+	for _, t := range tables {
+		fmt.Fprintf(buf, "- `%s`\n", t.name)
+	}
+	for _, t := range tables {
+		fmt.Fprintln(buf)
+		buf.WriteString(TableDoc(t.name, t.columns))
+		writeForeignKeys := func(title string, fks []*ForeignKey) {
+			if len(fks) == 0 {
+				return
+			}
+			fmt.Fprintf(buf, "\n%s\n\n", title)
+			for _, fk := range fks {
+				fmt.Fprintf(buf, "- `%s`\n", foreignKeyString(fk))
+			}
+		}
+		writeForeignKeys("Foreign keys:", t.references)
+		writeForeignKeys("Referenced by:", t.referencedBy)
+	}
+	return buf.String()
+}
+
+func htmlDictionary(tables []*dictionaryTable, title string) string {
+	buf := new(bytes.Buffer)
+	e := html.EscapeString
+	fmt.Fprintln(buf, "<!DOCTYPE html>")
+	fmt.Fprintln(buf, "<html>")
+	fmt.Fprintf(buf, "<head><meta charset=\"utf-8\"><title>Data dictionary of %s</title></head>\n", e(title))
+	fmt.Fprintln(buf, "<body>")
+	fmt.Fprintf(buf, "<h1>Data dictionary of %s</h1>\n", e(title))
+	fmt.Fprintln(buf, "<ul>")
+	for _, t := range tables {
+		fmt.Fprintf(buf, "<li><a href=\"#%s\"><code>%s</code></a></li>\n", e(t.name), e(t.name))
+	}
+	fmt.Fprintln(buf, "</ul>")
+	for _, t := range tables {
+		fmt.Fprintf(buf, "<h2 id=\"%s\"><code>%s</code> table</h2>\n", e(t.name), e(t.name))
+		fmt.Fprintln(buf, "<table>")
+		fmt.Fprintln(buf, "<tr><th>Name</th><th>Type</th><th>Primary key</th><th>Default</th><th>Extra</th><th>Comment</th></tr>")
+		for _, col := range t.columns {
+			fmt.Fprintf(buf, "<tr><td><code>%s</code></td><td><code>%s</code></td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				e(col.Name), e(col.Type), e(col.Key), e(Coalesce(col.Default.String, "-")), e(col.Extra), e(col.Comment))
+		}
+		fmt.Fprintln(buf, "</table>")
+		writeForeignKeys := func(title string, fks []*ForeignKey) {
+			if len(fks) == 0 {
+				return
+			}
+			fmt.Fprintf(buf, "<p>%s</p>\n<ul>\n", title)
+			for _, fk := range fks {
+				fmt.Fprintf(buf, "<li><code>%s</code></li>\n", e(foreignKeyString(fk)))
+			}
+			fmt.Fprintln(buf, "</ul>")
+		}
+		writeForeignKeys("Foreign keys:", t.references)
+		writeForeignKeys("Referenced by:", t.referencedBy)
+	}
+	fmt.Fprintln(buf, "</body>")
+	fmt.Fprintln(buf, "</html>")
+	return buf.String()
+}
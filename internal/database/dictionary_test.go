@@ -0,0 +1,79 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func dictionaryCache() *DBCache {
+	cache := NewDBCache("shop")
+	cache.AddTable("shop", "customer", []*ColumnDesc{
+		{ColumnBase: ColumnBase{Table: "customer", Name: "id"}, Type: "int", Key: "PRI", Comment: "the customer"},
+		{ColumnBase: ColumnBase{Table: "customer", Name: "name"}, Type: "text", Comment: "full | display\nname"},
+	})
+	cache.AddTable("shop", "orders", []*ColumnDesc{
+		{ColumnBase: ColumnBase{Table: "orders", Name: "id"}, Type: "int", Key: "PRI"},
+		{ColumnBase: ColumnBase{Table: "orders", Name: "customer_id"}, Type: "int"},
+	})
+	fk := &ForeignKey{
+		{
+			{Table: "orders", Name: "customer_id"},
+			{Table: "customer", Name: "id"},
+		},
+	}
+	cache.ForeignKeys["orders"] = map[string][]*ForeignKey{"customer": {fk}}
+	cache.ForeignKeys["customer"] = map[string][]*ForeignKey{"orders": {fk}}
+	return cache
+}
+
+func TestDataDictionary(t *testing.T) {
+	want := "# Data dictionary of shop\n" +
+		"\n" +
+		"- `customer`\n" +
+		"- `orders`\n" +
+		"\n" +
+		"# `customer` table\n" +
+		"\n" +
+		"\n" +
+		"| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; | Comment&nbsp;&nbsp; |\n" +
+		"| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- | :------------------ |\n" +
+		"| `id` | `int` | `PRI` | `-` |  | the customer |\n" +
+		"| `name` | `text` | `` | `-` |  | full \\| display name |\n" +
+		"\n" +
+		"Referenced by:\n" +
+		"\n" +
+		"- `orders.customer_id -> customer.id`\n" +
+		"\n" +
+		"# `orders` table\n" +
+		"\n" +
+		"\n" +
+		"| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |\n" +
+		"| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |\n" +
+		"| `id` | `int` | `PRI` | `-` |  |\n" +
+		"| `customer_id` | `int` | `` | `-` |  |\n" +
+		"\n" +
+		"Foreign keys:\n" +
+		"\n" +
+		"- `orders.customer_id -> customer.id`\n"
+	got := DataDictionary(dictionaryCache(), "shop", DictionaryMarkdown)
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched data dictionary (-want +got):\n%s", d)
+	}
+}
+
+func TestDataDictionaryHTML(t *testing.T) {
+	got := DataDictionary(dictionaryCache(), "shop & co", DictionaryHTML)
+	for _, want := range []string{
+		"<h1>Data dictionary of shop &amp; co</h1>\n",
+		"<li><a href=\"#customer\"><code>customer</code></a></li>\n",
+		"<h2 id=\"orders\"><code>orders</code> table</h2>\n",
+		"<tr><td><code>name</code></td><td><code>text</code></td><td></td><td>-</td><td></td><td>full | display\nname</td></tr>\n",
+		"<p>Foreign keys:</p>\n<ul>\n<li><code>orders.customer_id -&gt; customer.id</code></li>\n</ul>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("data dictionary does not contain %q:\n%s", want, got)
+		}
+	}
+}
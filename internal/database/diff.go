@@ -0,0 +1,137 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// SchemaDiff is the differences of the tables of the default schemas of the
+// source and the target.
+type SchemaDiff struct {
+	Source string
+	Target string
+	// MissingTables are the tables of the source that the target does not have
+	MissingTables []string
+	// ExtraTables are the tables that only the target has
+	ExtraTables []string
+	Columns     []*ColumnDiff
+}
+
+// ColumnDiff is a column of a table of both that is missing in one of them or
+// whose types differ. The column missing is nil.
+type ColumnDiff struct {
+	Table  string
+	Column string
+	Source *ColumnDesc
+	Target *ColumnDesc
+}
+
+// DiffSchemas compares the tables and the columns of the default schemas of
+// the caches of the source and the target named by the names.
+func DiffSchemas(source, target *DBCache, sourceName, targetName string) *SchemaDiff {
+	d := &SchemaDiff{Source: sourceName, Target: targetName}
+	targetTables := map[string]string{}
+	for _, table := range target.SortedTables() {
+		targetTables[strings.ToUpper(table)] = table
+	}
+	sourceTables := map[string]bool{}
+	for _, table := range source.SortedTables() {
+		sourceTables[strings.ToUpper(table)] = true
+		targetTable, ok := targetTables[strings.ToUpper(table)]
+		if !ok {
+			d.MissingTables = append(d.MissingTables, table)
+			continue
+		}
+		sourceCols, _ := source.ColumnDescs(table)
+		targetCols, _ := target.ColumnDescs(targetTable)
+		d.Columns = append(d.Columns, diffColumns(table, sourceCols, targetCols)...)
+	}
+	for _, table := range target.SortedTables() {
+		if !sourceTables[strings.ToUpper(table)] {
+			d.ExtraTables = append(d.ExtraTables, table)
+		}
+	}
+	return d
+}
+
+func diffColumns(table string, sourceCols, targetCols []*ColumnDesc) []*ColumnDiff {
+	diffs := []*ColumnDiff{}
+	targets := map[string]*ColumnDesc{}
+	for _, col := range targetCols {
+		targets[strings.ToUpper(col.Name)] = col
+	}
+	sources := map[string]bool{}
+	for _, col := range sourceCols {
+		sources[strings.ToUpper(col.Name)] = true
+		targetCol := targets[strings.ToUpper(col.Name)]
+		if targetCol == nil || !sameColumnType(col, targetCol) {
+			diffs = append(diffs, &ColumnDiff{Table: table, Column: col.Name, Source: col, Target: targetCol})
+		}
+	}
+	for _, col := range targetCols {
+		if !sources[strings.ToUpper(col.Name)] {
+			diffs = append(diffs, &ColumnDiff{Table: table, Column: col.Name, Target: col})
+		}
+	}
+	return diffs
+}
+
+// sameColumnType reports whether the types and the nullability of the columns
+// are the same, ignoring the cases and the spaces of the types.
+func sameColumnType(a, b *ColumnDesc) bool {
+	normalize := func(typ string) string {
+		return strings.ToLower(strings.Join(strings.Fields(typ), ""))
+	}
+	if normalize(a.Type) != normalize(b.Type) {
+		return false
+	}
+	return a.Null == "" || b.Null == "" || strings.EqualFold(a.Null, b.Null)
+}
+
+// Empty reports whether the schemas have no differences.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.MissingTables) == 0 && len(d.ExtraTables) == 0 && len(d.Columns) == 0
+}
+
+// Render returns the report of the differences in Markdown.
+func (d *SchemaDiff) Render() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# Schema diff of %s and %s\n", d.Source, d.Target)
+	if d.Empty() {
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, "No differences.")
+		return buf.String()
+	}
+	writeTables := func(title string, tables []string) {
+		if len(tables) == 0 {
+			return
+		}
+		fmt.Fprintf(buf, "\n## %s\n\n", title)
+		for _, table := range tables {
+			fmt.Fprintf(buf, "- %s\n", table)
+		}
+	}
+	writeTables("Tables missing in "+d.Target, d.MissingTables)
+	writeTables("Tables only in "+d.Target, d.ExtraTables)
+	if len(d.Columns) > 0 {
+		fmt.Fprintf(buf, "\n## Columns\n\n")
+		fmt.Fprintf(buf, "| Table | Column | %s | %s |\n", d.Source, d.Target)
+		fmt.Fprintln(buf, "|-------|--------|---|---|")
+		for _, c := range d.Columns {
+			fmt.Fprintf(buf, "| %s | %s | %s | %s |\n", c.Table, c.Column, diffColumnType(c.Source), diffColumnType(c.Target))
+		}
+	}
+	return buf.String()
+}
+
+func diffColumnType(col *ColumnDesc) string {
+	if col == nil {
+		return "missing"
+	}
+	typ := col.Type
+	if strings.EqualFold(col.Null, "NO") {
+		typ += " NOT NULL"
+	}
+	return typ
+}
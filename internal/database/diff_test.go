@@ -0,0 +1,83 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	column := func(table, name, typ, null string) *ColumnDesc {
+		return &ColumnDesc{
+			ColumnBase: ColumnBase{Table: table, Name: name},
+			Type:       typ,
+			Null:       null,
+		}
+	}
+	prod := NewDBCache("public")
+	prod.AddTable("public", "customer", []*ColumnDesc{
+		column("customer", "id", "integer", "NO"),
+		column("customer", "email", "text", "NO"),
+		column("customer", "name", "varchar(100)", "YES"),
+	})
+	prod.AddTable("public", "orders", []*ColumnDesc{
+		column("orders", "id", "integer", "NO"),
+	})
+	staging := NewDBCache("public")
+	staging.AddTable("public", "Customer", []*ColumnDesc{
+		column("Customer", "ID", "INTEGER", "NO"),
+		column("Customer", "name", "varchar(100)", "NO"),
+		column("Customer", "nickname", "text", "YES"),
+	})
+	staging.AddTable("public", "tmp", []*ColumnDesc{
+		column("tmp", "id", "integer", ""),
+	})
+
+	cases := []struct {
+		name   string
+		source *DBCache
+		target *DBCache
+		want   string
+	}{
+		{
+			name:   "differences",
+			source: prod,
+			target: staging,
+			want: `# Schema diff of prod and staging
+
+## Tables missing in staging
+
+- orders
+
+## Tables only in staging
+
+- tmp
+
+## Columns
+
+| Table | Column | prod | staging |
+|-------|--------|---|---|
+| customer | email | text NOT NULL | missing |
+| customer | name | varchar(100) | varchar(100) NOT NULL |
+| customer | nickname | missing | text |
+`,
+		},
+		{
+			name:   "no differences",
+			source: prod,
+			target: prod,
+			want: `# Schema diff of prod and staging
+
+No differences.
+`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffSchemas(tt.source, tt.target, "prod", "staging").Render()
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched report (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -18,23 +18,48 @@ type DBConnection struct {
 	Conn    *sql.DB
 	SSHConn *ssh.Client
 	Driver  dialect.DatabaseDriver
+
+	vaultLease     *vaultLease
+	cloudSQLDialer *cloudSQLDialer
+	// release is called instead of closing the connection shared by the
+	// registry
+	release func() error
 }
 
 func (db *DBConnection) Close() error {
 	if db == nil {
 		return nil
 	}
-	if err := db.Conn.Close(); err != nil {
-		return err
+	if db.release != nil {
+		return db.release()
+	}
+	if db.Conn != nil {
+		if err := db.Conn.Close(); err != nil {
+			return err
+		}
 	}
 	if db.SSHConn != nil {
 		if err := db.SSHConn.Close(); err != nil {
 			return err
 		}
 	}
+	if db.vaultLease != nil {
+		db.vaultLease.stop()
+	}
+	if db.cloudSQLDialer != nil {
+		if err := db.cloudSQLDialer.Close(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// CredentialsExpiring reports whether the credentials of the connection
+// cannot be renewed and expire soon, so that it has to be opened again.
+func (db *DBConnection) CredentialsExpiring() bool {
+	return db != nil && db.vaultLease != nil && db.vaultLease.expiring()
+}
+
 func RegisterOpen(name dialect.DatabaseDriver, opener Opener) {
 	if _, ok := driverOpeners[name]; ok {
 		panic(fmt.Sprintf("driver open %s method is already registered", name))
@@ -60,7 +85,20 @@ func Open(cfg *DBConfig) (*DBConnection, error) {
 	if !ok {
 		return nil, fmt.Errorf("driver not found, %s", cfg.Driver)
 	}
-	return OpenFn(cfg)
+	resolved, lease, err := cfg.resolve()
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve connection config, %w", err)
+	}
+	conn, err := OpenFn(resolved)
+	if lease != nil {
+		if err != nil {
+			lease.stop()
+			return nil, err
+		}
+		conn.vaultLease = lease
+		lease.start()
+	}
+	return conn, err
 }
 
 func CreateRepository(driver dialect.DatabaseDriver, db *sql.DB) (DBRepository, error) {
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+// mysqlDefaultExpression matches the defaults of MySQL that are written
+// without the quotes, the numbers, NULL and the functions of the time.
+var mysqlDefaultExpression = regexp.MustCompile(`(?i)^(-?[0-9]+(\.[0-9]+)?|NULL|CURRENT_TIMESTAMP(\([0-9]*\))?|b'[01]*'|\(.*\))$`)
+
+// DumpSchema returns the CREATE TABLE of the tables of the cache, with their
+// columns, primary keys and foreign keys, as a schema dump that the
+// lint --schema and diffSchemas read without the database. The tables of
+// the default schema are not qualified.
+func DumpSchema(cache *DBCache, driver dialect.DatabaseDriver) string {
+	buf := new(bytes.Buffer)
+	for _, key := range dumpTableKeys(cache) {
+		cols := cache.ColumnsWithParent[key]
+		if len(cols) == 0 {
+			continue
+		}
+		if buf.Len() > 0 {
+			fmt.Fprintln(buf)
+		}
+		schemaName, tableName := cols[0].Schema, cols[0].Table
+		name := importIdentifier(driver, tableName)
+		if schemaName != "" && !strings.EqualFold(schemaName, cache.DefaultSchema()) {
+			name = importIdentifier(driver, schemaName) + "." + name
+		}
+		defs := []string{}
+		primaryKeys := []string{}
+		for _, col := range cols {
+			defs = append(defs, dumpColumn(col, driver))
+			if col.IsPrimaryKey() {
+				primaryKeys = append(primaryKeys, importIdentifier(driver, col.Name))
+			}
+		}
+		if len(primaryKeys) > 0 {
+			defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+		}
+		for _, fk := range dumpForeignKeys(cache, schemaName, tableName) {
+			defs = append(defs, dumpForeignKey(cache, fk, driver))
+		}
+		fmt.Fprintf(buf, "CREATE TABLE %s (\n    %s\n);\n", name, strings.Join(defs, ",\n    "))
+	}
+	return buf.String()
+}
+
+// dumpTableKeys returns the keys of the columns of the tables, the ones of the
+// default schema first and then the ones of the other schemas by the names.
+func dumpTableKeys(cache *DBCache) []string {
+	keys := make([]string, 0, len(cache.ColumnsWithParent))
+	for key := range cache.ColumnsWithParent {
+		keys = append(keys, key)
+	}
+	defaultPrefix := cache.columnKey(cache.DefaultSchema(), "")
+	sort.Slice(keys, func(i, j int) bool {
+		di, dj := strings.HasPrefix(keys[i], defaultPrefix), strings.HasPrefix(keys[j], defaultPrefix)
+		if di != dj {
+			return di
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func dumpColumn(col *ColumnDesc, driver dialect.DatabaseDriver) string {
+	def := importIdentifier(driver, col.Name)
+	if col.Type != "" {
+		def += " " + col.Type
+	}
+	if col.Null == "NO" && !col.IsPrimaryKey() {
+		def += " NOT NULL"
+	}
+	if col.Default.Valid {
+		def += " DEFAULT " + dumpDefault(col.Default.String, driver)
+	}
+	if isMySQL(driver) && strings.Contains(strings.ToLower(col.Extra), "auto_increment") {
+		def += " AUTO_INCREMENT"
+	}
+	return def
+}
+
+// dumpDefault returns the default of the column as an expression. MySQL
+// returns the literals of the strings without the quotes.
+func dumpDefault(def string, driver dialect.DatabaseDriver) string {
+	if isMySQL(driver) && !mysqlDefaultExpression.MatchString(def) {
+		return importLiteral(driver, def)
+	}
+	return def
+}
+
+// dumpForeignKeys returns the foreign keys referencing the other tables from
+// the columns of the table, in the order of the columns.
+func dumpForeignKeys(cache *DBCache, schemaName, tableName string) []*ForeignKey {
+	fks := []*ForeignKey{}
+	seen := map[*ForeignKey]bool{}
+	for _, refs := range cache.ForeignKeys {
+		for _, candidates := range refs {
+			for _, fk := range candidates {
+				if seen[fk] || len(*fk) == 0 {
+					continue
+				}
+				seen[fk] = true
+				from := (*fk)[0][0]
+				if strings.EqualFold(from.Table, tableName) && strings.EqualFold(Coalesce(from.Schema, schemaName), schemaName) {
+					fks = append(fks, fk)
+				}
+			}
+		}
+	}
+	sort.Slice(fks, func(i, j int) bool {
+		return foreignKeyColumns(fks[i]) < foreignKeyColumns(fks[j])
+	})
+	return fks
+}
+
+func dumpForeignKey(cache *DBCache, fk *ForeignKey, driver dialect.DatabaseDriver) string {
+	ref := (*fk)[0][1]
+	refName := importIdentifier(driver, ref.Table)
+	if ref.Schema != "" && !strings.EqualFold(ref.Schema, cache.DefaultSchema()) {
+		refName = importIdentifier(driver, ref.Schema) + "." + refName
+	}
+	cols := make([]string, len(*fk))
+	refCols := make([]string, len(*fk))
+	for i, pair := range *fk {
+		cols[i] = importIdentifier(driver, pair[0].Name)
+		refCols[i] = importIdentifier(driver, pair[1].Name)
+	}
+	return fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", strings.Join(cols, ", "), refName, strings.Join(refCols, ", "))
+}
+
+// foreignKeyColumns returns the names of the referencing columns of the
+// foreign key.
+func foreignKeyColumns(fk *ForeignKey) string {
+	names := make([]string, len(*fk))
+	for i, pair := range *fk {
+		names[i] = pair[0].Name
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestDumpSchema(t *testing.T) {
+	column := func(schema, table, name, typ, null, key string) *ColumnDesc {
+		return &ColumnDesc{
+			ColumnBase: ColumnBase{Schema: schema, Table: table, Name: name},
+			Type:       typ,
+			Null:       null,
+			Key:        key,
+		}
+	}
+	newCache := func(defaultSchema, statusDefault string) *DBCache {
+		cache := NewDBCache(defaultSchema)
+		status := column(defaultSchema, "orders", "status", "varchar(10)", "NO", "")
+		status.Default = sql.NullString{String: statusDefault, Valid: true}
+		id := column(defaultSchema, "customer", "id", "int", "NO", "PRI")
+		id.Extra = "auto_increment"
+		cache.AddTable(defaultSchema, "orders", []*ColumnDesc{
+			column(defaultSchema, "orders", "id", "int", "NO", "PRI"),
+			column(defaultSchema, "orders", "customer_id", "int", "YES", ""),
+			status,
+		})
+		cache.AddTable(defaultSchema, "customer", []*ColumnDesc{
+			id,
+			column(defaultSchema, "customer", "order", "text", "YES", ""),
+		})
+		cache.AddTable("audit", "log", []*ColumnDesc{
+			column("audit", "log", "message", "text", "YES", ""),
+		})
+		fk := &ForeignKey{
+			{
+				{Schema: defaultSchema, Table: "orders", Name: "customer_id"},
+				{Schema: defaultSchema, Table: "customer", Name: "id"},
+			},
+		}
+		cache.ForeignKeys["orders"] = map[string][]*ForeignKey{"customer": {fk}}
+		cache.ForeignKeys["customer"] = map[string][]*ForeignKey{"orders": {fk}}
+		return cache
+	}
+
+	cases := []struct {
+		name   string
+		cache  *DBCache
+		driver dialect.DatabaseDriver
+		want   string
+	}{
+		{
+			name:   "mysql",
+			cache:  newCache("shop", "new"),
+			driver: dialect.DatabaseDriverMySQL,
+			want: "CREATE TABLE customer (\n" +
+				"    id int AUTO_INCREMENT,\n" +
+				"    `order` text,\n" +
+				"    PRIMARY KEY (id)\n" +
+				");\n" +
+				"\n" +
+				"CREATE TABLE orders (\n" +
+				"    id int,\n" +
+				"    customer_id int,\n" +
+				"    status varchar(10) NOT NULL DEFAULT 'new',\n" +
+				"    PRIMARY KEY (id),\n" +
+				"    FOREIGN KEY (customer_id) REFERENCES customer (id)\n" +
+				");\n" +
+				"\n" +
+				"CREATE TABLE audit.log (\n" +
+				"    message text\n" +
+				");\n",
+		},
+		{
+			name:   "postgresql",
+			cache:  newCache("public", "'new'::character varying"),
+			driver: dialect.DatabaseDriverPostgreSQL,
+			want: "CREATE TABLE customer (\n" +
+				"    id int,\n" +
+				"    \"order\" text,\n" +
+				"    PRIMARY KEY (id)\n" +
+				");\n" +
+				"\n" +
+				"CREATE TABLE orders (\n" +
+				"    id int,\n" +
+				"    customer_id int,\n" +
+				"    status varchar(10) NOT NULL DEFAULT 'new'::character varying,\n" +
+				"    PRIMARY KEY (id),\n" +
+				"    FOREIGN KEY (customer_id) REFERENCES customer (id)\n" +
+				");\n" +
+				"\n" +
+				"CREATE TABLE audit.log (\n" +
+				"    message text\n" +
+				");\n",
+		},
+		{
+			name:   "empty",
+			cache:  NewDBCache("main"),
+			driver: dialect.DatabaseDriverSQLite3,
+			want:   "",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DumpSchema(tt.cache, tt.driver)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatched value: (-want +got)\n%s", diff)
+			}
+		})
+	}
+}
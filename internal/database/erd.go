@@ -0,0 +1,281 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ERDiagramFormat is the language of an ER diagram.
+type ERDiagramFormat string
+
+const (
+	ERDiagramMermaid  ERDiagramFormat = "mermaid"
+	ERDiagramPlantUML ERDiagramFormat = "plantuml"
+	ERDiagramGraphviz ERDiagramFormat = "graphviz"
+)
+
+// erDiagramExtensions are the extensions of the files of the formats, which
+// the editors render by.
+var erDiagramExtensions = map[ERDiagramFormat]string{
+	ERDiagramMermaid:  "mmd",
+	ERDiagramPlantUML: "puml",
+	ERDiagramGraphviz: "dot",
+}
+
+// erDiagramLanguageIDs are the language identifiers of the formats.
+var erDiagramLanguageIDs = map[ERDiagramFormat]string{
+	ERDiagramMermaid:  "mermaid",
+	ERDiagramPlantUML: "plantuml",
+	ERDiagramGraphviz: "dot",
+}
+
+// mermaidNamePattern matches the characters that Mermaid does not allow in the
+// names of the entities and the types of the attributes.
+var mermaidNamePattern = regexp.MustCompile(`[^A-Za-z0-9_()\[\]-]`)
+
+// ParseERDiagramFormat returns the format of the name, mermaid if it is empty.
+func ParseERDiagramFormat(name string) (ERDiagramFormat, error) {
+	if name == "" {
+		return ERDiagramMermaid, nil
+	}
+	f := ERDiagramFormat(strings.ToLower(name))
+	if f == "dot" {
+		f = ERDiagramGraphviz
+	}
+	if _, ok := erDiagramExtensions[f]; !ok {
+		return "", fmt.Errorf("unsupported diagram format: %s", name)
+	}
+	return f, nil
+}
+
+// Extension returns the extension of the files of the format.
+func (f ERDiagramFormat) Extension() string {
+	return erDiagramExtensions[f]
+}
+
+// LanguageID returns the language identifier of the documents of the format.
+func (f ERDiagramFormat) LanguageID() string {
+	return erDiagramLanguageIDs[f]
+}
+
+// ERDiagram is the tables and the relations of their foreign keys to draw.
+type ERDiagram struct {
+	Tables    []*ERTable
+	Relations []*ERRelation
+}
+
+// ERTable is a table of an ER diagram.
+type ERTable struct {
+	Name    string
+	Columns []*ColumnDesc
+	// foreignKeys are the names of the columns referencing the other tables
+	foreignKeys map[string]bool
+}
+
+// ERRelation is a foreign key from the columns of the table to the ones of the
+// referenced table.
+type ERRelation struct {
+	Table      string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+}
+
+// NewERDiagram returns the ER diagram of the tables of the cache, all the
+// tables of the default schema if none is given. The relations are the
+// foreign keys between the tables in the diagram.
+func NewERDiagram(cache *DBCache, tableNames []string) *ERDiagram {
+	if len(tableNames) == 0 {
+		tableNames = cache.SortedTables()
+	}
+	d := &ERDiagram{}
+	tables := map[string]*ERTable{}
+	for _, name := range tableNames {
+		key := strings.ToUpper(name)
+		if _, ok := tables[key]; ok {
+			continue
+		}
+		cols, ok := cache.ColumnDescs(name)
+		if !ok {
+			continue
+		}
+		t := &ERTable{Name: name, Columns: cols, foreignKeys: map[string]bool{}}
+		if len(cols) > 0 {
+			t.Name = cols[0].Table
+		}
+		tables[key] = t
+		d.Tables = append(d.Tables, t)
+	}
+	sort.Slice(d.Tables, func(i, j int) bool {
+		return d.Tables[i].Name < d.Tables[j].Name
+	})
+
+	// The foreign keys are cached for both of the tables
+	seen := map[*ForeignKey]bool{}
+	for _, refs := range cache.ForeignKeys {
+		for _, fks := range refs {
+			for _, fk := range fks {
+				if seen[fk] || len(*fk) == 0 {
+					continue
+				}
+				seen[fk] = true
+				t, ok := tables[strings.ToUpper((*fk)[0][0].Table)]
+				if !ok {
+					continue
+				}
+				ref, ok := tables[strings.ToUpper((*fk)[0][1].Table)]
+				if !ok {
+					continue
+				}
+				r := &ERRelation{Table: t.Name, RefTable: ref.Name}
+				for _, pair := range *fk {
+					r.Columns = append(r.Columns, pair[0].Name)
+					r.RefColumns = append(r.RefColumns, pair[1].Name)
+					t.foreignKeys[strings.ToUpper(pair[0].Name)] = true
+				}
+				d.Relations = append(d.Relations, r)
+			}
+		}
+	}
+	sort.Slice(d.Relations, func(i, j int) bool {
+		a, b := d.Relations[i], d.Relations[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		if a.RefTable != b.RefTable {
+			return a.RefTable < b.RefTable
+		}
+		return strings.Join(a.Columns, ",") < strings.Join(b.Columns, ",")
+	})
+	return d
+}
+
+// Render returns the text of the diagram in the format.
+func (d *ERDiagram) Render(format ERDiagramFormat) string {
+	switch format {
+	case ERDiagramPlantUML:
+		return d.renderPlantUML()
+	case ERDiagramGraphviz:
+		return d.renderGraphviz()
+	default:
+		return d.renderMermaid()
+	}
+}
+
+func (d *ERDiagram) renderMermaid() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintln(buf, "erDiagram")
+	for _, t := range d.Tables {
+		fmt.Fprintf(buf, "    %s {\n", mermaidName(t.Name))
+		for _, col := range t.Columns {
+			fmt.Fprintf(buf, "        %s %s", mermaidType(col.Type), mermaidName(col.Name))
+			if keys := t.keys(col); len(keys) > 0 {
+				fmt.Fprintf(buf, " %s", strings.Join(keys, ", "))
+			}
+			fmt.Fprintln(buf)
+		}
+		fmt.Fprintln(buf, "    }")
+	}
+	for _, r := range d.Relations {
+		fmt.Fprintf(buf, "    %s ||--o{ %s : %q\n", mermaidName(r.RefTable), mermaidName(r.Table), strings.Join(r.Columns, ", "))
+	}
+	return buf.String()
+}
+
+func (d *ERDiagram) renderPlantUML() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintln(buf, "@startuml")
+	fmt.Fprintln(buf, "hide circle")
+	for _, t := range d.Tables {
+		fmt.Fprintf(buf, "entity %q {\n", t.Name)
+		for _, col := range t.Columns {
+			prefix := "  "
+			if col.IsPrimaryKey() {
+				prefix = "  * "
+			}
+			fmt.Fprintf(buf, "%s%s : %s", prefix, col.Name, col.Type)
+			for _, key := range t.keys(col) {
+				fmt.Fprintf(buf, " <<%s>>", key)
+			}
+			fmt.Fprintln(buf)
+		}
+		fmt.Fprintln(buf, "}")
+	}
+	for _, r := range d.Relations {
+		fmt.Fprintf(buf, "%q ||--o{ %q : %s\n", r.RefTable, r.Table, strings.Join(r.Columns, ", "))
+	}
+	fmt.Fprintln(buf, "@enduml")
+	return buf.String()
+}
+
+func (d *ERDiagram) renderGraphviz() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintln(buf, "digraph er {")
+	fmt.Fprintln(buf, "  rankdir=LR;")
+	fmt.Fprintln(buf, "  node [shape=record];")
+	for _, t := range d.Tables {
+		fields := []string{}
+		for _, col := range t.Columns {
+			field := fmt.Sprintf("<%s> %s : %s", recordEscape(col.Name), recordEscape(col.Name), recordEscape(col.Type))
+			if keys := t.keys(col); len(keys) > 0 {
+				field += " " + strings.Join(keys, ", ")
+			}
+			fields = append(fields, field+`\l`)
+		}
+		fmt.Fprintf(buf, "  %q [label=\"{%s|%s}\"];\n", t.Name, recordEscape(t.Name), strings.Join(fields, ""))
+	}
+	for _, r := range d.Relations {
+		fmt.Fprintf(buf, "  %q:%q -> %q:%q [label=%q];\n", r.Table, r.Columns[0], r.RefTable, r.RefColumns[0], strings.Join(r.Columns, ", "))
+	}
+	fmt.Fprintln(buf, "}")
+	return buf.String()
+}
+
+// keys returns PK and FK of the column in the primary key and the foreign
+// keys.
+func (t *ERTable) keys(col *ColumnDesc) []string {
+	keys := []string{}
+	if col.IsPrimaryKey() {
+		keys = append(keys, "PK")
+	}
+	if t.foreignKeys[strings.ToUpper(col.Name)] {
+		keys = append(keys, "FK")
+	}
+	return keys
+}
+
+func mermaidName(name string) string {
+	name = mermaidNamePattern.ReplaceAllString(name, "_")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// mermaidType returns the type of the column, without the arguments such as of
+// decimal(10,2) and enum('a','b') that Mermaid does not allow.
+func mermaidType(typ string) string {
+	if mermaidNamePattern.MatchString(typ) {
+		if i := strings.Index(typ, "("); i > 0 {
+			typ = typ[:i]
+		}
+	}
+	return mermaidName(typ)
+}
+
+// recordEscape escapes the characters of the labels of the records of
+// Graphviz.
+func recordEscape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"{", `\{`,
+		"}", `\}`,
+		"|", `\|`,
+		"<", `\<`,
+		">", `\>`,
+	).Replace(s)
+}
@@ -0,0 +1,156 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestERDiagram(t *testing.T) {
+	cache := NewDBCache("shop")
+	column := func(table, name, typ, key string) *ColumnDesc {
+		return &ColumnDesc{
+			ColumnBase: ColumnBase{Schema: "shop", Table: table, Name: name},
+			Type:       typ,
+			Key:        key,
+		}
+	}
+	cache.AddTable("shop", "customer", []*ColumnDesc{
+		column("customer", "id", "int", "PRI"),
+		column("customer", "name", "varchar(100)", ""),
+	})
+	cache.AddTable("shop", "orders", []*ColumnDesc{
+		column("orders", "id", "int", "PRI"),
+		column("orders", "customer_id", "int", ""),
+		column("orders", "total", "decimal(10,2)", ""),
+	})
+	cache.AddTable("shop", "audit", []*ColumnDesc{
+		column("audit", "id", "int", "PRI"),
+	})
+	fk := &ForeignKey{
+		{
+			{Schema: "shop", Table: "orders", Name: "customer_id"},
+			{Schema: "shop", Table: "customer", Name: "id"},
+		},
+	}
+	cache.ForeignKeys["orders"] = map[string][]*ForeignKey{"customer": {fk}}
+	cache.ForeignKeys["customer"] = map[string][]*ForeignKey{"orders": {fk}}
+
+	cases := []struct {
+		name   string
+		tables []string
+		format ERDiagramFormat
+		want   string
+	}{
+		{
+			name:   "mermaid of the tables",
+			tables: []string{"ORDERS", "customer"},
+			format: ERDiagramMermaid,
+			want: `erDiagram
+    customer {
+        int id PK
+        varchar(100) name
+    }
+    orders {
+        int id PK
+        int customer_id FK
+        decimal total
+    }
+    customer ||--o{ orders : "customer_id"
+`,
+		},
+		{
+			name:   "mermaid of the schema",
+			format: ERDiagramMermaid,
+			want: `erDiagram
+    audit {
+        int id PK
+    }
+    customer {
+        int id PK
+        varchar(100) name
+    }
+    orders {
+        int id PK
+        int customer_id FK
+        decimal total
+    }
+    customer ||--o{ orders : "customer_id"
+`,
+		},
+		{
+			name:   "relation out of the tables",
+			tables: []string{"orders"},
+			format: ERDiagramMermaid,
+			want: `erDiagram
+    orders {
+        int id PK
+        int customer_id
+        decimal total
+    }
+`,
+		},
+		{
+			name:   "plantuml",
+			tables: []string{"orders", "customer"},
+			format: ERDiagramPlantUML,
+			want: `@startuml
+hide circle
+entity "customer" {
+  * id : int <<PK>>
+  name : varchar(100)
+}
+entity "orders" {
+  * id : int <<PK>>
+  customer_id : int <<FK>>
+  total : decimal(10,2)
+}
+"customer" ||--o{ "orders" : customer_id
+@enduml
+`,
+		},
+		{
+			name:   "graphviz",
+			tables: []string{"orders", "customer"},
+			format: ERDiagramGraphviz,
+			want: `digraph er {
+  rankdir=LR;
+  node [shape=record];
+  "customer" [label="{customer|<id> id : int PK\l<name> name : varchar(100)\l}"];
+  "orders" [label="{orders|<id> id : int PK\l<customer_id> customer_id : int FK\l<total> total : decimal(10,2)\l}"];
+  "orders":"customer_id" -> "customer":"id" [label="customer_id"];
+}
+`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewERDiagram(cache, tt.tables).Render(tt.format)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched diagram (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestParseERDiagramFormat(t *testing.T) {
+	cases := []struct {
+		input string
+		want  ERDiagramFormat
+		err   bool
+	}{
+		{input: "", want: ERDiagramMermaid},
+		{input: "PlantUML", want: ERDiagramPlantUML},
+		{input: "dot", want: ERDiagramGraphviz},
+		{input: "svg", err: true},
+	}
+	for _, tt := range cases {
+		got, err := ParseERDiagramFormat(tt.input)
+		if (err != nil) != tt.err {
+			t.Errorf("unexpected error of %q, %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("unmatched format of %q, want %q, got %q", tt.input, tt.want, got)
+		}
+	}
+}
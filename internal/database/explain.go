@@ -0,0 +1,598 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/token"
+)
+
+// misestimateRatio is the ratio between estimated and actual rows above
+// which a plan node is flagged.
+const misestimateRatio = 10
+
+type PlanNode struct {
+	Operation  string
+	Detail     string
+	Cost       float64
+	Rows       float64
+	ActualTime float64
+	ActualRows float64
+	Loops      float64
+	Analyzed   bool
+	Children   []*PlanNode
+	// Table is the table that the node reads
+	Table string
+	// RowsExamined is the number of the rows that MySQL reads from the table
+	RowsExamined float64
+	// Filesort and Temporary are true for the sorting and the temporary
+	// table of MySQL
+	Filesort  bool
+	Temporary bool
+	// JoinBuffer is the algorithm of MySQL joining the table without an index
+	JoinBuffer string
+}
+
+type Plan struct {
+	Nodes []*PlanNode
+	// Text is set when the database returns an already formatted plan.
+	Text string
+}
+
+// readOnlyKeywords are the first keywords of the queries which do not change
+// the database.
+var readOnlyKeywords = map[string]bool{
+	"SELECT": true,
+	"WITH":   true,
+	"VALUES": true,
+	"TABLE":  true,
+}
+
+// writeKeywords are the keywords of the statements which change the database,
+// such as the ones in the WITH queries of PostgreSQL and SELECT INTO.
+var writeKeywords = map[string]bool{
+	"INSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"MERGE":    true,
+	"UPSERT":   true,
+	"TRUNCATE": true,
+	"CREATE":   true,
+	"DROP":     true,
+	"ALTER":    true,
+	"INTO":     true,
+	"CALL":     true,
+	"COPY":     true,
+	"GRANT":    true,
+	"REVOKE":   true,
+}
+
+// IsReadOnlyQuery reports whether query is a query which does not change the
+// database, which EXPLAIN ANALYZE can run.
+func IsReadOnlyQuery(query string) bool {
+	tokens, err := token.NewTokenizer(strings.NewReader(query), &dialect.GenericSQLDialect{}).Tokenize()
+	if err != nil {
+		return false
+	}
+	first, prev := "", ""
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case token.Whitespace, token.Comment, token.MultilineComment, token.LParen:
+			continue
+		}
+		w, ok := tok.Value.(*token.SQLWord)
+		if tok.Kind != token.SQLKeyword || !ok || w.QuoteStyle != 0 {
+			if first == "" {
+				return false
+			}
+			prev = ""
+			continue
+		}
+		keyword := strings.ToUpper(w.Value)
+		if first == "" {
+			if !readOnlyKeywords[keyword] {
+				return false
+			}
+			first = keyword
+		}
+		// FOR UPDATE locks the rows only
+		if writeKeywords[keyword] && !(keyword == "UPDATE" && prev == "FOR") {
+			return false
+		}
+		prev = keyword
+	}
+	return first != ""
+}
+
+// ExplainStatement returns the statement that shows the execution plan of
+// query in the dialect of driver. Note that analyze actually executes query.
+func ExplainStatement(driver dialect.DatabaseDriver, query string, analyze bool) (string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	switch driver {
+	case dialect.DatabaseDriverPostgreSQL:
+		if analyze {
+			return "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) " + query, nil
+		}
+		return "EXPLAIN (FORMAT JSON) " + query, nil
+	case dialect.DatabaseDriverMySQL, dialect.DatabaseDriverMySQL8:
+		if analyze {
+			return "EXPLAIN ANALYZE " + query, nil
+		}
+		return "EXPLAIN FORMAT=JSON " + query, nil
+	case dialect.DatabaseDriverMySQL57, dialect.DatabaseDriverMySQL56:
+		if analyze {
+			return "", fmt.Errorf("explain analyze is not supported, %s", driver)
+		}
+		return "EXPLAIN FORMAT=JSON " + query, nil
+	case dialect.DatabaseDriverSQLite3:
+		if analyze {
+			return "", fmt.Errorf("explain analyze is not supported, %s", driver)
+		}
+		return "EXPLAIN QUERY PLAN " + query, nil
+	case dialect.DatabaseDriverClickhouse:
+		if analyze {
+			return "EXPLAIN PIPELINE " + query, nil
+		}
+		return "EXPLAIN " + query, nil
+	case dialect.DatabaseDriverVertica:
+		if analyze {
+			return "", fmt.Errorf("explain analyze is not supported, %s", driver)
+		}
+		return "EXPLAIN " + query, nil
+	}
+	return "", fmt.Errorf("explain is not supported, %s", driver)
+}
+
+// ParsePlan converts the rows returned by the statement of
+// ExplainStatement into a plan.
+func ParsePlan(driver dialect.DatabaseDriver, columns []string, rows [][]string) (*Plan, error) {
+	switch driver {
+	case dialect.DatabaseDriverPostgreSQL:
+		return parsePostgreSQLPlan(rows)
+	case dialect.DatabaseDriverMySQL, dialect.DatabaseDriverMySQL8, dialect.DatabaseDriverMySQL57, dialect.DatabaseDriverMySQL56:
+		if len(rows) == 1 && len(rows[0]) == 1 && strings.HasPrefix(strings.TrimSpace(rows[0][0]), "{") {
+			return parseMySQLPlan(rows[0][0])
+		}
+		return textPlan(rows), nil
+	case dialect.DatabaseDriverSQLite3:
+		return parseSQLite3Plan(columns, rows)
+	}
+	return textPlan(rows), nil
+}
+
+func textPlan(rows [][]string) *Plan {
+	lines := []string{}
+	for _, row := range rows {
+		lines = append(lines, strings.Join(row, " "))
+	}
+	return &Plan{Text: strings.Join(lines, "\n")}
+}
+
+func parsePostgreSQLPlan(rows [][]string) (*Plan, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, fmt.Errorf("cannot parse plan, empty result")
+	}
+	var explained []struct {
+		Plan map[string]interface{} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(rows[0][0]), &explained); err != nil {
+		return nil, fmt.Errorf("cannot parse plan, %w", err)
+	}
+	plan := &Plan{}
+	for _, e := range explained {
+		if e.Plan != nil {
+			plan.Nodes = append(plan.Nodes, newPostgreSQLPlanNode(e.Plan))
+		}
+	}
+	return plan, nil
+}
+
+func newPostgreSQLPlanNode(m map[string]interface{}) *PlanNode {
+	node := &PlanNode{
+		Operation: jsonString(m, "Node Type"),
+		Cost:      jsonFloat(m, "Total Cost"),
+		Rows:      jsonFloat(m, "Plan Rows"),
+	}
+	details := []string{}
+	if v := jsonString(m, "Join Type"); v != "" {
+		details = append(details, v)
+	}
+	if v := jsonString(m, "Relation Name"); v != "" {
+		node.Table = v
+		if alias := jsonString(m, "Alias"); alias != "" && alias != v {
+			v = v + " " + alias
+		}
+		details = append(details, "on "+v)
+	}
+	if v := jsonString(m, "Index Name"); v != "" {
+		details = append(details, "using "+v)
+	}
+	for _, key := range []string{"Index Cond", "Hash Cond", "Merge Cond", "Join Filter", "Filter"} {
+		if v := jsonString(m, key); v != "" {
+			details = append(details, strings.ToLower(key)+": "+v)
+		}
+	}
+	node.Detail = strings.Join(details, " ")
+	if _, ok := m["Actual Rows"]; ok {
+		node.Analyzed = true
+		node.ActualTime = jsonFloat(m, "Actual Total Time")
+		node.ActualRows = jsonFloat(m, "Actual Rows")
+		node.Loops = jsonFloat(m, "Actual Loops")
+	}
+	if plans, ok := m["Plans"].([]interface{}); ok {
+		for _, p := range plans {
+			if child, ok := p.(map[string]interface{}); ok {
+				node.Children = append(node.Children, newPostgreSQLPlanNode(child))
+			}
+		}
+	}
+	return node
+}
+
+// mysqlOperations are the keys of a MySQL JSON plan that represent an
+// operation over the nested plan.
+var mysqlOperations = map[string]bool{
+	"query_block":                true,
+	"ordering_operation":         true,
+	"grouping_operation":         true,
+	"duplicates_removal":         true,
+	"windowing":                  true,
+	"union_result":               true,
+	"materialized_from_subquery": true,
+}
+
+func parseMySQLPlan(text string) (*Plan, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &m); err != nil {
+		return nil, fmt.Errorf("cannot parse plan, %w", err)
+	}
+	return &Plan{Nodes: mysqlPlanNodes(m)}, nil
+}
+
+func mysqlPlanNodes(v interface{}) []*PlanNode {
+	nodes := []*PlanNode{}
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			nodes = append(nodes, mysqlPlanNodes(e)...)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child, ok := v[k].(map[string]interface{})
+			switch {
+			case k == "table" && ok:
+				nodes = append(nodes, newMySQLTableNode(child))
+			case mysqlOperations[k] && ok:
+				node := &PlanNode{
+					Operation: k,
+					Cost:      mysqlCost(child),
+					Filesort:  jsonBool(child, "using_filesort"),
+					Temporary: jsonBool(child, "using_temporary_table"),
+				}
+				if k == "query_block" {
+					node.Operation = "select"
+					if id := jsonString(child, "select_id"); id != "" {
+						node.Detail = "#" + id
+					}
+				}
+				node.Children = mysqlPlanNodes(child)
+				nodes = append(nodes, node)
+			case k == "nested_loop" || k == "attached_subqueries" || k == "query_specifications":
+				nodes = append(nodes, mysqlPlanNodes(v[k])...)
+			}
+		}
+	}
+	return nodes
+}
+
+func newMySQLTableNode(m map[string]interface{}) *PlanNode {
+	node := &PlanNode{
+		Operation:    jsonString(m, "access_type"),
+		Cost:         mysqlCost(m),
+		Rows:         jsonFloat(m, "rows_produced_per_join"),
+		Table:        jsonString(m, "table_name"),
+		RowsExamined: jsonFloat(m, "rows_examined_per_scan"),
+		JoinBuffer:   jsonString(m, "using_join_buffer"),
+	}
+	if node.Operation == "" {
+		node.Operation = "table"
+	}
+	details := []string{"on " + jsonString(m, "table_name")}
+	if v := jsonString(m, "key"); v != "" {
+		details = append(details, "using "+v)
+	}
+	if v := jsonString(m, "attached_condition"); v != "" {
+		details = append(details, "filter: "+v)
+	}
+	node.Detail = strings.Join(details, " ")
+	node.Children = mysqlPlanNodes(m)
+	return node
+}
+
+func mysqlCost(m map[string]interface{}) float64 {
+	costInfo, ok := m["cost_info"].(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	for _, key := range []string{"query_cost", "prefix_cost", "sort_cost", "read_cost"} {
+		if v := jsonFloat(costInfo, key); v != 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+func parseSQLite3Plan(columns []string, rows [][]string) (*Plan, error) {
+	idIdx, parentIdx, detailIdx := -1, -1, -1
+	for i, c := range columns {
+		switch c {
+		case "id":
+			idIdx = i
+		case "parent":
+			parentIdx = i
+		case "detail":
+			detailIdx = i
+		}
+	}
+	if idIdx < 0 || parentIdx < 0 || detailIdx < 0 {
+		return textPlan(rows), nil
+	}
+
+	plan := &Plan{}
+	nodes := map[string]*PlanNode{}
+	for _, row := range rows {
+		node := &PlanNode{Operation: row[detailIdx]}
+		nodes[row[idIdx]] = node
+		if parent, ok := nodes[row[parentIdx]]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			plan.Nodes = append(plan.Nodes, node)
+		}
+	}
+	return plan, nil
+}
+
+func jsonString(m map[string]interface{}, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func jsonBool(m map[string]interface{}, key string) bool {
+	v, _ := m[key].(bool)
+	return v
+}
+
+func jsonFloat(m map[string]interface{}, key string) float64 {
+	switch v := m[key].(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+// Render formats the plan as an indented tree. The most expensive node is
+// marked and nodes whose estimated rows are far from the actual rows are
+// flagged.
+func (p *Plan) Render() string {
+	if p.Text != "" {
+		return p.Text + "\n"
+	}
+	var maxCost *PlanNode
+	var walk func(nodes []*PlanNode)
+	walk = func(nodes []*PlanNode) {
+		for _, n := range nodes {
+			if c := n.selfCost(); c > 0 && (maxCost == nil || c > maxCost.selfCost()) {
+				maxCost = n
+			}
+			walk(n.Children)
+		}
+	}
+	walk(p.Nodes)
+
+	buf := new(bytes.Buffer)
+	var render func(nodes []*PlanNode, indent string)
+	render = func(nodes []*PlanNode, indent string) {
+		for i, n := range nodes {
+			branch, next := "├─ ", "│  "
+			if i == len(nodes)-1 {
+				branch, next = "└─ ", "   "
+			}
+			fmt.Fprint(buf, indent, branch, n.summary())
+			if n == maxCost {
+				fmt.Fprint(buf, "  <- highest cost")
+			}
+			if n.misestimated() {
+				fmt.Fprint(buf, "  <- rows misestimated")
+			}
+			fmt.Fprintln(buf)
+			render(n.Children, indent+next)
+		}
+	}
+	render(p.Nodes, "")
+	return buf.String()
+}
+
+// Estimate returns the estimated cost and rows of the whole plan, such as
+// cost=12.5 rows=100. The plans without the estimates, such as the ones of
+// SQLite, return the operations of their leaves instead, and the plans of the
+// texts return an empty string.
+func (p *Plan) Estimate() string {
+	var cost, rows float64
+	for _, n := range p.Nodes {
+		cost += n.totalCost()
+		rows += n.estimatedRows()
+	}
+	estimates := []string{}
+	if cost > 0 {
+		estimates = append(estimates, "cost="+formatPlanNumber(cost))
+	}
+	if rows > 0 {
+		estimates = append(estimates, "rows="+formatPlanNumber(rows))
+	}
+	if len(estimates) > 0 {
+		return strings.Join(estimates, " ")
+	}
+
+	leaves := []string{}
+	var walk func(nodes []*PlanNode)
+	walk = func(nodes []*PlanNode) {
+		for _, n := range nodes {
+			if len(n.Children) == 0 {
+				leaves = append(leaves, n.Operation)
+			}
+			walk(n.Children)
+		}
+	}
+	walk(p.Nodes)
+	return strings.Join(leaves, "; ")
+}
+
+// estimatedRows is the rows that the node returns. The operations of MySQL
+// without the rows take the rows of their last children, the last tables of
+// the joins.
+func (n *PlanNode) estimatedRows() float64 {
+	if n.Rows > 0 || len(n.Children) == 0 {
+		return n.Rows
+	}
+	return n.Children[len(n.Children)-1].estimatedRows()
+}
+
+func (n *PlanNode) summary() string {
+	items := []string{n.Operation}
+	if n.Detail != "" {
+		items = append(items, n.Detail)
+	}
+	estimates := []string{}
+	if n.Cost > 0 {
+		estimates = append(estimates, "cost="+formatPlanNumber(n.Cost))
+	}
+	if n.Rows > 0 {
+		estimates = append(estimates, "rows="+formatPlanNumber(n.Rows))
+	}
+	if len(estimates) > 0 {
+		items = append(items, "("+strings.Join(estimates, " ")+")")
+	}
+	if n.Analyzed {
+		items = append(items, fmt.Sprintf("(actual time=%s rows=%s loops=%s)",
+			formatPlanNumber(n.ActualTime), formatPlanNumber(n.ActualRows), formatPlanNumber(n.Loops)))
+	}
+	return strings.Join(items, " ")
+}
+
+// selfCost is the cost of the node excluding the cost of its children.
+func (n *PlanNode) selfCost() float64 {
+	c := n.Cost
+	for _, child := range n.Children {
+		c -= child.totalCost()
+	}
+	if c < 0 {
+		return 0
+	}
+	return c
+}
+
+// totalCost is the cost of the node including its children. Nodes without
+// a cost of their own take the costs of their children.
+func (n *PlanNode) totalCost() float64 {
+	if n.Cost > 0 {
+		return n.Cost
+	}
+	var c float64
+	for _, child := range n.Children {
+		c += child.totalCost()
+	}
+	return c
+}
+
+func (n *PlanNode) misestimated() bool {
+	if !n.Analyzed {
+		return false
+	}
+	estimated, actual := n.Rows, n.ActualRows
+	if estimated < 1 {
+		estimated = 1
+	}
+	if actual < 1 {
+		actual = 1
+	}
+	return estimated/actual >= misestimateRatio || actual/estimated >= misestimateRatio
+}
+
+func formatPlanNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// mysqlClauses are the clauses of the operations of MySQL that sort the rows
+// or use the temporary tables.
+var mysqlClauses = map[string]string{
+	"ordering_operation": "ORDER BY",
+	"grouping_operation": "GROUP BY",
+	"duplicates_removal": "DISTINCT",
+	"windowing":          "window functions",
+}
+
+// Hints returns the problems of the performance in the plan: the sequential
+// scans of the tables of largeRows or more rows, the joins without the indexes
+// on the join keys, and the sorting and the temporary tables of MySQL and
+// SQLite.
+func (p *Plan) Hints(largeRows float64) []string {
+	hints := []string{}
+	seen := map[string]bool{}
+	add := func(hint string) {
+		if !seen[hint] {
+			seen[hint] = true
+			hints = append(hints, hint)
+		}
+	}
+	var walk func(nodes []*PlanNode, parent *PlanNode)
+	walk = func(nodes []*PlanNode, parent *PlanNode) {
+		for i, n := range nodes {
+			switch {
+			case n.Operation == "Seq Scan":
+				if parent != nil && parent.Operation == "Nested Loop" && i == 1 {
+					add(fmt.Sprintf("nested loop scans all rows of %s for each row, add an index on the join key", n.Table))
+				} else if n.Rows >= largeRows {
+					add(fmt.Sprintf("sequential scan on %s of about %s rows", n.Table, formatPlanNumber(n.Rows)))
+				}
+			case n.JoinBuffer != "":
+				add(fmt.Sprintf("join of %s does not use an index, add an index on the join key", n.Table))
+			case n.Operation == "ALL" && n.RowsExamined >= largeRows:
+				add(fmt.Sprintf("full table scan on %s of about %s rows", n.Table, formatPlanNumber(n.RowsExamined)))
+			case strings.HasPrefix(n.Operation, "USE TEMP B-TREE FOR "):
+				add("temporary B-tree for " + strings.TrimPrefix(n.Operation, "USE TEMP B-TREE FOR "))
+			case strings.HasPrefix(n.Operation, "SEARCH ") && strings.Contains(n.Operation, " USING AUTOMATIC "):
+				table := strings.Fields(n.Operation)[1]
+				add(fmt.Sprintf("automatic index on %s is built for the join, add an index on the join key", table))
+			}
+			if clause, ok := mysqlClauses[n.Operation]; ok {
+				if n.Filesort {
+					add("filesort for " + clause)
+				}
+				if n.Temporary {
+					add("temporary table for " + clause)
+				}
+			}
+			walk(n.Children, n)
+		}
+	}
+	walk(p.Nodes, nil)
+	return hints
+}
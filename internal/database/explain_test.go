@@ -0,0 +1,322 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestExplainStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  dialect.DatabaseDriver
+		query   string
+		analyze bool
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "postgresql",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			query:  "SELECT * FROM city;",
+			want:   "EXPLAIN (FORMAT JSON) SELECT * FROM city",
+		},
+		{
+			name:    "postgresql analyze",
+			driver:  dialect.DatabaseDriverPostgreSQL,
+			query:   "SELECT * FROM city",
+			analyze: true,
+			want:    "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) SELECT * FROM city",
+		},
+		{
+			name:   "mysql",
+			driver: dialect.DatabaseDriverMySQL,
+			query:  "SELECT * FROM city",
+			want:   "EXPLAIN FORMAT=JSON SELECT * FROM city",
+		},
+		{
+			name:    "mysql analyze",
+			driver:  dialect.DatabaseDriverMySQL8,
+			query:   "SELECT * FROM city",
+			analyze: true,
+			want:    "EXPLAIN ANALYZE SELECT * FROM city",
+		},
+		{
+			name:   "sqlite3",
+			driver: dialect.DatabaseDriverSQLite3,
+			query:  "SELECT * FROM city",
+			want:   "EXPLAIN QUERY PLAN SELECT * FROM city",
+		},
+		{
+			name:    "sqlite3 analyze",
+			driver:  dialect.DatabaseDriverSQLite3,
+			query:   "SELECT * FROM city",
+			analyze: true,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported",
+			driver:  dialect.DatabaseDriverMssql,
+			query:   "SELECT * FROM city",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExplainStatement(tt.driver, tt.query, tt.analyze)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExplainStatement() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "SELECT * FROM city", want: true},
+		{query: "  -- cities\nselect * from city", want: true},
+		{query: "(SELECT 1) UNION (SELECT 2)", want: true},
+		{query: "WITH c AS (SELECT * FROM city) SELECT * FROM c", want: true},
+		{query: "SELECT * FROM city FOR UPDATE", want: true},
+		{query: `SELECT "delete" FROM city`, want: true},
+		{query: "VALUES (1), (2)", want: true},
+		{query: "DELETE FROM city WHERE id = 1"},
+		{query: "UPDATE city SET name = 'a'"},
+		{query: "INSERT INTO city VALUES (1)"},
+		{query: "WITH d AS (DELETE FROM city RETURNING *) SELECT * FROM d"},
+		{query: "SELECT * INTO city_copy FROM city"},
+		{query: "CREATE TABLE city_copy AS SELECT * FROM city"},
+		{query: "TRUNCATE city"},
+		{query: ""},
+	}
+	for _, tt := range tests {
+		if got := IsReadOnlyQuery(tt.query); got != tt.want {
+			t.Errorf("IsReadOnlyQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParsePlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  dialect.DatabaseDriver
+		columns []string
+		rows    [][]string
+		want    string
+	}{
+		{
+			name:    "postgresql",
+			driver:  dialect.DatabaseDriverPostgreSQL,
+			columns: []string{"QUERY PLAN"},
+			rows: [][]string{{`[{"Plan": {"Node Type": "Hash Join", "Join Type": "Inner", "Total Cost": 40.5, "Plan Rows": 100, "Hash Cond": "(ci.countrycode = co.code)",
+				"Plans": [
+					{"Node Type": "Seq Scan", "Relation Name": "city", "Alias": "ci", "Total Cost": 20.5, "Plan Rows": 4079},
+					{"Node Type": "Hash", "Total Cost": 10, "Plan Rows": 239, "Plans": [
+						{"Node Type": "Seq Scan", "Relation Name": "country", "Alias": "co", "Total Cost": 8, "Plan Rows": 239}
+					]}
+				]}}]`}},
+			want: "" +
+				"└─ Hash Join Inner hash cond: (ci.countrycode = co.code) (cost=40.5 rows=100)\n" +
+				"   ├─ Seq Scan on city ci (cost=20.5 rows=4079)  <- highest cost\n" +
+				"   └─ Hash (cost=10 rows=239)\n" +
+				"      └─ Seq Scan on country co (cost=8 rows=239)\n",
+		},
+		{
+			name:    "postgresql analyze",
+			driver:  dialect.DatabaseDriverPostgreSQL,
+			columns: []string{"QUERY PLAN"},
+			rows: [][]string{{`[{"Plan": {"Node Type": "Seq Scan", "Relation Name": "city", "Alias": "city", "Total Cost": 20.5, "Plan Rows": 1,
+				"Filter": "(population > 100)", "Actual Total Time": 0.8, "Actual Rows": 4079, "Actual Loops": 1}}]`}},
+			want: "" +
+				"└─ Seq Scan on city filter: (population > 100) (cost=20.5 rows=1) (actual time=0.8 rows=4079 loops=1)  <- highest cost  <- rows misestimated\n",
+		},
+		{
+			name:    "mysql",
+			driver:  dialect.DatabaseDriverMySQL,
+			columns: []string{"EXPLAIN"},
+			rows: [][]string{{`{"query_block": {"select_id": 1, "cost_info": {"query_cost": "411.55"},
+				"ordering_operation": {"using_filesort": true,
+					"table": {"table_name": "city", "access_type": "ALL", "rows_produced_per_join": 4046, "cost_info": {"prefix_cost": "411.55"}}}}}`}},
+			want: "" +
+				"└─ select #1 (cost=411.55)\n" +
+				"   └─ ordering_operation\n" +
+				"      └─ ALL on city (cost=411.55 rows=4046)  <- highest cost\n",
+		},
+		{
+			name:    "mysql analyze",
+			driver:  dialect.DatabaseDriverMySQL8,
+			columns: []string{"EXPLAIN"},
+			rows:    [][]string{{"-> Table scan on city  (cost=411 rows=4046) (actual time=0.05..1.6 rows=4079 loops=1)"}},
+			want:    "-> Table scan on city  (cost=411 rows=4046) (actual time=0.05..1.6 rows=4079 loops=1)\n",
+		},
+		{
+			name:    "sqlite3",
+			driver:  dialect.DatabaseDriverSQLite3,
+			columns: []string{"id", "parent", "notused", "detail"},
+			rows: [][]string{
+				{"2", "0", "0", "CO-ROUTINE sub"},
+				{"5", "2", "0", "SCAN city"},
+				{"20", "0", "0", "SCAN sub"},
+			},
+			want: "" +
+				"├─ CO-ROUTINE sub\n" +
+				"│  └─ SCAN city\n" +
+				"└─ SCAN sub\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := ParsePlan(tt.driver, tt.columns, tt.rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := plan.Render(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanHints(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  dialect.DatabaseDriver
+		columns []string
+		rows    [][]string
+		want    []string
+	}{
+		{
+			name:    "postgresql",
+			driver:  dialect.DatabaseDriverPostgreSQL,
+			columns: []string{"QUERY PLAN"},
+			rows: [][]string{{`[{"Plan": {"Node Type": "Nested Loop", "Total Cost": 900, "Plan Rows": 100,
+				"Plans": [
+					{"Node Type": "Seq Scan", "Relation Name": "city", "Alias": "ci", "Total Cost": 800, "Plan Rows": 40790},
+					{"Node Type": "Seq Scan", "Relation Name": "country", "Alias": "co", "Total Cost": 8, "Plan Rows": 1}
+				]}}]`}},
+			want: []string{
+				"sequential scan on city of about 40790 rows",
+				"nested loop scans all rows of country for each row, add an index on the join key",
+			},
+		},
+		{
+			name:    "mysql",
+			driver:  dialect.DatabaseDriverMySQL,
+			columns: []string{"EXPLAIN"},
+			rows: [][]string{{`{"query_block": {"select_id": 1,
+				"grouping_operation": {"using_temporary_table": true, "using_filesort": true,
+					"nested_loop": [
+						{"table": {"table_name": "city", "access_type": "ALL", "rows_examined_per_scan": 40790}},
+						{"table": {"table_name": "country", "access_type": "ALL", "rows_examined_per_scan": 239, "using_join_buffer": "hash join"}}
+					]}}}`}},
+			want: []string{
+				"filesort for GROUP BY",
+				"temporary table for GROUP BY",
+				"full table scan on city of about 40790 rows",
+				"join of country does not use an index, add an index on the join key",
+			},
+		},
+		{
+			name:    "small tables",
+			driver:  dialect.DatabaseDriverMySQL,
+			columns: []string{"EXPLAIN"},
+			rows:    [][]string{{`{"query_block": {"select_id": 1, "table": {"table_name": "city", "access_type": "ALL", "rows_examined_per_scan": 4079}}}`}},
+			want:    []string{},
+		},
+		{
+			name:    "sqlite3",
+			driver:  dialect.DatabaseDriverSQLite3,
+			columns: []string{"id", "parent", "notused", "detail"},
+			rows: [][]string{
+				{"3", "0", "0", "SCAN city"},
+				{"5", "0", "0", "SEARCH country USING AUTOMATIC COVERING INDEX (code=?)"},
+				{"20", "0", "0", "USE TEMP B-TREE FOR ORDER BY"},
+			},
+			want: []string{
+				"automatic index on country is built for the join, add an index on the join key",
+				"temporary B-tree for ORDER BY",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := ParsePlan(tt.driver, tt.columns, tt.rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, plan.Hints(10000)); diff != "" {
+				t.Errorf("unmatched hints (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlanEstimate(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  dialect.DatabaseDriver
+		columns []string
+		rows    [][]string
+		want    string
+	}{
+		{
+			name:    "postgresql",
+			driver:  dialect.DatabaseDriverPostgreSQL,
+			columns: []string{"QUERY PLAN"},
+			rows: [][]string{{`[{"Plan": {"Node Type": "Hash Join", "Total Cost": 40.5, "Plan Rows": 100,
+				"Plans": [
+					{"Node Type": "Seq Scan", "Relation Name": "city", "Total Cost": 20.5, "Plan Rows": 4079},
+					{"Node Type": "Seq Scan", "Relation Name": "country", "Total Cost": 8, "Plan Rows": 239}
+				]}}]`}},
+			want: "cost=40.5 rows=100",
+		},
+		{
+			name:    "mysql",
+			driver:  dialect.DatabaseDriverMySQL,
+			columns: []string{"EXPLAIN"},
+			rows: [][]string{{`{"query_block": {"select_id": 1, "cost_info": {"query_cost": "520.3"},
+				"nested_loop": [
+					{"table": {"table_name": "country", "access_type": "ALL", "rows_produced_per_join": 239, "cost_info": {"prefix_cost": "25.4"}}},
+					{"table": {"table_name": "city", "access_type": "ref", "rows_produced_per_join": 4046, "cost_info": {"prefix_cost": "520.3"}}}
+				]}}`}},
+			want: "cost=520.3 rows=4046",
+		},
+		{
+			name:    "sqlite3",
+			driver:  dialect.DatabaseDriverSQLite3,
+			columns: []string{"id", "parent", "notused", "detail"},
+			rows: [][]string{
+				{"3", "0", "0", "SCAN city"},
+				{"5", "0", "0", "SEARCH country USING INTEGER PRIMARY KEY (rowid=?)"},
+			},
+			want: "SCAN city; SEARCH country USING INTEGER PRIMARY KEY (rowid=?)",
+		},
+		{
+			name:    "text",
+			driver:  dialect.DatabaseDriverClickhouse,
+			columns: []string{"explain"},
+			rows:    [][]string{{"ReadFromMergeTree"}},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := ParsePlan(tt.driver, tt.columns, tt.rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := plan.Estimate(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+var (
+	fakeFirstNames = []string{"Alice", "Bob", "Carol", "David", "Emma", "Frank", "Grace", "Hiro", "Ines", "Jamal", "Keiko", "Liam", "Maria", "Noah", "Olga", "Priya"}
+	fakeLastNames  = []string{"Smith", "Johnson", "Garcia", "Tanaka", "Muller", "Rossi", "Kowalski", "Silva", "Kim", "Nguyen", "Brown", "Dubois"}
+	fakeCities     = []string{"Tokyo", "Berlin", "Lisbon", "Toronto", "Nairobi", "Sydney", "Lima", "Seoul", "Oslo", "Austin", "Madrid", "Dublin"}
+	fakeCountries  = []string{"Japan", "Germany", "Portugal", "Canada", "Kenya", "Australia", "Peru", "Korea", "Norway", "Spain", "Ireland"}
+	fakeStreets    = []string{"Main St", "Oak Ave", "Maple Rd", "Station Rd", "High St", "Park Ln", "River Dr"}
+	fakeDomains    = []string{"example.com", "example.org", "example.net"}
+	fakeWords      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor"}
+)
+
+var (
+	fakeTypeArgs   = regexp.MustCompile(`\(([^)]*)\)`)
+	fakeEnumValues = regexp.MustCompile(`'((?:[^']|'')*)'`)
+	fakeIntType    = regexp.MustCompile(`^((tiny|small|medium|big)?int(eger|[248])?|(small|big)?serial[248]?)$`)
+)
+
+// fakeTable is a table of the rows generated, with the values of the columns
+// by their upper case names for the foreign keys of the other tables.
+type fakeTable struct {
+	name    string
+	columns []*ColumnDesc
+	values  map[string][]interface{}
+}
+
+// FakeInserts returns the INSERT statements of the rows of the fake values of
+// the table, which are generated by the types and the names of its columns.
+// The tables that the table refers to by its foreign keys are generated
+// first, and the columns of the foreign keys have the values of their rows.
+// The integer keys start from a number chosen by the seed, not to conflict
+// with the rows of the table.
+func FakeInserts(cache *DBCache, driver dialect.DatabaseDriver, table string, rows int, seed int64) ([]string, error) {
+	if _, ok := cache.ColumnDescs(table); !ok {
+		return nil, fmt.Errorf("table %s is not found", table)
+	}
+	g := &fakeGenerator{
+		cache:  cache,
+		driver: driver,
+		rand:   rand.New(rand.NewSource(seed)),
+		rows:   rows,
+		tables: map[string]*fakeTable{},
+	}
+	g.visit(table)
+
+	stmts := []string{}
+	for _, t := range g.order {
+		data := &ImportData{}
+		indexes := []int{}
+		for i, col := range t.columns {
+			data.Columns = append(data.Columns, col.Name)
+			indexes = append(indexes, i)
+		}
+		for r := 0; r < rows; r++ {
+			row := []interface{}{}
+			for _, col := range t.columns {
+				row = append(row, t.values[strings.ToUpper(col.Name)][r])
+			}
+			data.Rows = append(data.Rows, row)
+		}
+		stmts = append(stmts, ImportStatements(driver, t.name, data, data.Columns, indexes, rows)...)
+	}
+	return stmts, nil
+}
+
+type fakeGenerator struct {
+	cache  *DBCache
+	driver dialect.DatabaseDriver
+	rand   *rand.Rand
+	rows   int
+	// tables are the tables visited by their upper case names, and order the
+	// ones generated with the tables referred to first
+	tables map[string]*fakeTable
+	order  []*fakeTable
+}
+
+// visit generates the rows of the table after the tables it refers to. The
+// tables referring to each other are generated once, and the foreign keys to
+// the tables not generated yet have the fake values of their types.
+func (g *fakeGenerator) visit(name string) *fakeTable {
+	if t, ok := g.tables[strings.ToUpper(name)]; ok {
+		return t
+	}
+	described, _ := g.cache.ColumnDescs(name)
+	// The generated columns are left out of INSERT
+	cols := []*ColumnDesc{}
+	for _, col := range described {
+		if col.IsAssignable() {
+			cols = append(cols, col)
+		}
+	}
+	t := &fakeTable{name: name, columns: cols, values: map[string][]interface{}{}}
+	g.tables[strings.ToUpper(name)] = t
+
+	references := map[string][]interface{}{}
+	for _, fk := range g.foreignKeys(name) {
+		parentName := (*fk)[0][1].Table
+		if strings.EqualFold(parentName, name) {
+			continue
+		}
+		if _, ok := g.cache.ColumnDescs(parentName); !ok {
+			continue
+		}
+		parent := g.visit(parentName)
+		for _, pair := range *fk {
+			if values, ok := parent.values[strings.ToUpper(pair[1].Name)]; ok {
+				references[strings.ToUpper(pair[0].Name)] = values
+			}
+		}
+	}
+
+	for _, col := range cols {
+		key := strings.ToUpper(col.Name)
+		values := make([]interface{}, g.rows)
+		if parentValues, ok := references[key]; ok && len(parentValues) > 0 {
+			for i := range values {
+				values[i] = parentValues[g.rand.Intn(len(parentValues))]
+			}
+		} else {
+			g.columnValues(col, values)
+		}
+		t.values[key] = values
+	}
+	g.order = append(g.order, t)
+	return t
+}
+
+// foreignKeys returns the foreign keys of the columns of the table.
+func (g *fakeGenerator) foreignKeys(table string) []*ForeignKey {
+	res := []*ForeignKey{}
+	seen := map[*ForeignKey]bool{}
+	for _, refs := range g.cache.ForeignKeys {
+		for _, fks := range refs {
+			for _, fk := range fks {
+				if seen[fk] || len(*fk) == 0 || !strings.EqualFold((*fk)[0][0].Table, table) {
+					continue
+				}
+				seen[fk] = true
+				res = append(res, fk)
+			}
+		}
+	}
+	sortForeignKeys(res)
+	return res
+}
+
+// columnValues sets the fake values of the column, which are unique for the
+// primary keys and the unique keys.
+func (g *fakeGenerator) columnValues(col *ColumnDesc, values []interface{}) {
+	unique := col.Key == "PRI" || col.Key == "UNI"
+	typ := strings.ToLower(strings.TrimSpace(col.Type))
+	// The base type is the first word, such as of int(11) unsigned and
+	// character varying(20)
+	base := typ
+	if i := strings.Index(base, "("); i >= 0 {
+		base = base[:i]
+	}
+	if fields := strings.Fields(base); len(fields) > 0 {
+		base = fields[0]
+	}
+	args := []int{}
+	if m := fakeTypeArgs.FindStringSubmatch(typ); m != nil {
+		for _, arg := range strings.Split(m[1], ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(arg)); err == nil {
+				args = append(args, n)
+			}
+		}
+	}
+
+	if strings.HasPrefix(base, "enum") {
+		choices := []string{}
+		for _, m := range fakeEnumValues.FindAllStringSubmatch(col.Type, -1) {
+			choices = append(choices, strings.ReplaceAll(m[1], "''", "'"))
+		}
+		for i := range values {
+			if len(choices) > 0 {
+				values[i] = choices[g.rand.Intn(len(choices))]
+			}
+		}
+		return
+	}
+
+	start := 1000 + g.rand.Intn(1000000)
+	switch base {
+	case "tinyint":
+		start = 1
+	case "smallint", "int2", "smallserial":
+		start = 1000 + g.rand.Intn(20000)
+	}
+	seen := map[string]bool{}
+	for i := range values {
+		var v interface{}
+		for try := 0; ; try++ {
+			v = g.value(col, base, args, start+i)
+			// The values of the unique keys are generated again unless they
+			// are unique by the sequence
+			text := fmt.Sprint(v)
+			if !unique || !seen[text] || try >= 100 {
+				seen[text] = true
+				break
+			}
+		}
+		values[i] = v
+	}
+}
+
+// value returns a fake value of the column of the type. The integers of the
+// keys are the sequence from the number.
+func (g *fakeGenerator) value(col *ColumnDesc, base string, args []int, seq int) interface{} {
+	name := strings.ToLower(col.Name)
+	isKey := col.Key == "PRI" || col.Key == "UNI"
+	switch {
+	case base == "tinyint" && len(args) == 1 && args[0] == 1,
+		strings.HasPrefix(base, "bool"), base == "bit":
+		return g.rand.Intn(2) == 1
+	case fakeIntType.MatchString(base):
+		switch {
+		case isKey:
+			return json.Number(strconv.Itoa(seq))
+		case strings.Contains(name, "age"):
+			return json.Number(strconv.Itoa(18 + g.rand.Intn(70)))
+		case strings.Contains(name, "year"):
+			return json.Number(strconv.Itoa(1950 + g.rand.Intn(75)))
+		case base == "tinyint":
+			return json.Number(strconv.Itoa(g.rand.Intn(128)))
+		}
+		return json.Number(strconv.Itoa(1 + g.rand.Intn(100000)))
+	case strings.HasPrefix(base, "decimal"), strings.HasPrefix(base, "numeric"), strings.HasPrefix(base, "number"),
+		strings.HasPrefix(base, "float"), strings.HasPrefix(base, "double"), base == "real", base == "money":
+		scale := 2
+		if len(args) == 2 {
+			scale = args[1]
+		} else if len(args) == 1 && (strings.HasPrefix(base, "number") || strings.HasPrefix(base, "numeric")) {
+			scale = 0
+		}
+		if scale == 0 {
+			if isKey {
+				return json.Number(strconv.Itoa(seq))
+			}
+			return json.Number(strconv.Itoa(1 + g.rand.Intn(100000)))
+		}
+		limit := 100000.0
+		if len(args) > 0 && args[0]-scale < 5 {
+			limit = 1
+			for i := 0; i < args[0]-scale; i++ {
+				limit *= 10
+			}
+		}
+		return json.Number(strconv.FormatFloat(g.rand.Float64()*limit*0.99, 'f', scale, 64))
+	case base == "interval":
+		return fmt.Sprintf("%d days", 1+g.rand.Intn(365))
+	case base == "date":
+		return g.time().Format("2006-01-02")
+	case strings.HasPrefix(base, "datetime"), strings.HasPrefix(base, "timestamp"):
+		return g.time().Format("2006-01-02 15:04:05")
+	case strings.HasPrefix(base, "time"):
+		return g.time().Format("15:04:05")
+	case base == "uuid", base == "uniqueidentifier":
+		b := make([]byte, 16)
+		g.rand.Read(b)
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case strings.HasPrefix(base, "json"):
+		return "{}"
+	case strings.Contains(base, "char"), strings.Contains(base, "text"), strings.Contains(base, "string"), strings.Contains(base, "clob"):
+		length := 0
+		if len(args) > 0 {
+			length = args[0]
+		}
+		return g.text(name, base, length, isKey, seq)
+	}
+	if col.Null == "YES" {
+		return nil
+	}
+	return ""
+}
+
+// text returns a fake text for the name of the column, no longer than the
+// length unless it is 0.
+func (g *fakeGenerator) text(name, base string, length int, isKey bool, seq int) string {
+	pick := func(words []string) string {
+		return words[g.rand.Intn(len(words))]
+	}
+	var s string
+	switch {
+	case base == "char" && length > 0 && length <= 10:
+		// The codes such as of the countries and the currencies
+		b := make([]byte, length)
+		for i := range b {
+			b[i] = byte('A' + g.rand.Intn(26))
+		}
+		return string(b)
+	case strings.Contains(name, "email"):
+		s = fmt.Sprintf("%s.%s%d@%s", strings.ToLower(pick(fakeFirstNames)), strings.ToLower(pick(fakeLastNames)), seq, pick(fakeDomains))
+	case strings.Contains(name, "first"):
+		s = pick(fakeFirstNames)
+	case strings.Contains(name, "last") || strings.Contains(name, "surname"):
+		s = pick(fakeLastNames)
+	case strings.Contains(name, "city"):
+		s = pick(fakeCities)
+	case strings.Contains(name, "country"):
+		s = pick(fakeCountries)
+	case strings.Contains(name, "phone"):
+		s = fmt.Sprintf("+1-555-%04d", g.rand.Intn(10000))
+	case strings.Contains(name, "url") || strings.Contains(name, "website"):
+		s = fmt.Sprintf("https://%s/%s", pick(fakeDomains), pick(fakeWords))
+	case strings.Contains(name, "address") || strings.Contains(name, "street"):
+		s = fmt.Sprintf("%d %s", 1+g.rand.Intn(999), pick(fakeStreets))
+	case strings.Contains(name, "name"):
+		s = pick(fakeFirstNames) + " " + pick(fakeLastNames)
+	default:
+		words := []string{}
+		for i := 0; i < 2+g.rand.Intn(4); i++ {
+			words = append(words, pick(fakeWords))
+		}
+		s = strings.Join(words, " ")
+	}
+	if isKey && !strings.Contains(name, "email") {
+		s = fmt.Sprintf("%s %d", s, seq)
+	}
+	// The keys keep their sequences at the ends
+	if length > 0 && len(s) > length {
+		if isKey {
+			s = s[len(s)-length:]
+		} else {
+			s = strings.TrimSpace(s[:length])
+		}
+	}
+	return s
+}
+
+// time returns a fake time of the last ten years before 2025.
+func (g *fakeGenerator) time() time.Time {
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	return start.Add(time.Duration(g.rand.Int63n(int64(10 * 365 * 24 * time.Hour))))
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"encoding/json"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func fakeCache() *DBCache {
+	cache := NewDBCache("shop")
+	cache.AddTable("shop", "customer", []*ColumnDesc{
+		{ColumnBase: ColumnBase{Table: "customer", Name: "id"}, Type: "int(11)", Key: "PRI"},
+		{ColumnBase: ColumnBase{Table: "customer", Name: "name"}, Type: "varchar(10)"},
+		{ColumnBase: ColumnBase{Table: "customer", Name: "email"}, Type: "text", Key: "UNI"},
+		{ColumnBase: ColumnBase{Table: "customer", Name: "country"}, Type: "char(3)"},
+	})
+	cache.AddTable("shop", "orders", []*ColumnDesc{
+		{ColumnBase: ColumnBase{Table: "orders", Name: "id"}, Type: "bigint", Key: "PRI"},
+		{ColumnBase: ColumnBase{Table: "orders", Name: "customer_id"}, Type: "int(11)"},
+		{ColumnBase: ColumnBase{Table: "orders", Name: "total"}, Type: "decimal(8,2)"},
+		{ColumnBase: ColumnBase{Table: "orders", Name: "status"}, Type: "enum('new','paid')"},
+		{ColumnBase: ColumnBase{Table: "orders", Name: "paid"}, Type: "tinyint(1)"},
+		{ColumnBase: ColumnBase{Table: "orders", Name: "created"}, Type: "timestamp without time zone"},
+	})
+	fk := &ForeignKey{
+		{
+			{Table: "orders", Name: "customer_id"},
+			{Table: "customer", Name: "id"},
+		},
+	}
+	cache.ForeignKeys["orders"] = map[string][]*ForeignKey{"customer": {fk}}
+	cache.ForeignKeys["customer"] = map[string][]*ForeignKey{"orders": {fk}}
+	return cache
+}
+
+func TestFakeInserts(t *testing.T) {
+	cache := fakeCache()
+	got, err := FakeInserts(cache, dialect.DatabaseDriverMySQL, "orders", 5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 ||
+		!strings.HasPrefix(got[0], "INSERT INTO customer (id, name, email, country) VALUES\n") ||
+		!strings.HasPrefix(got[1], "INSERT INTO orders (id, customer_id, total, status, paid, created) VALUES\n") {
+		t.Fatalf("the table referred to must be inserted first, %q", got)
+	}
+	again, err := FakeInserts(cache, dialect.DatabaseDriverMySQL, "orders", 5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(got, again); d != "" {
+		t.Errorf("the rows of the same seed must be the same (-first +second):\n%s", d)
+	}
+
+	if _, err := FakeInserts(cache, dialect.DatabaseDriverMySQL, "missing", 5, 1); err == nil {
+		t.Error("missing table must fail")
+	}
+}
+
+func TestFakeValues(t *testing.T) {
+	g := &fakeGenerator{
+		cache:  fakeCache(),
+		driver: dialect.DatabaseDriverPostgreSQL,
+		rand:   rand.New(rand.NewSource(2)),
+		rows:   20,
+		tables: map[string]*fakeTable{},
+	}
+	orders := g.visit("orders")
+	customer := g.tables["CUSTOMER"]
+	if customer == nil || len(g.order) != 2 || g.order[0] != customer {
+		t.Fatalf("the customers must be generated first, %+v", g.order)
+	}
+
+	ids := map[interface{}]bool{}
+	for _, id := range customer.values["ID"] {
+		if ids[id] {
+			t.Errorf("duplicate id %v", id)
+		}
+		ids[id] = true
+	}
+	emails := map[interface{}]bool{}
+	for _, email := range customer.values["EMAIL"] {
+		if emails[email] || !strings.Contains(email.(string), "@example.") {
+			t.Errorf("unexpected email %v", email)
+		}
+		emails[email] = true
+	}
+	code := regexp.MustCompile(`^[A-Z]{3}$`)
+	decimal := regexp.MustCompile(`^\d{1,6}\.\d\d$`)
+	timestamp := regexp.MustCompile(`^20\d\d-\d\d-\d\d \d\d:\d\d:\d\d$`)
+	for i := 0; i < g.rows; i++ {
+		if name := customer.values["NAME"][i].(string); name == "" || len(name) > 10 {
+			t.Errorf("unexpected name %q", name)
+		}
+		if country := customer.values["COUNTRY"][i].(string); !code.MatchString(country) {
+			t.Errorf("unexpected country %q", country)
+		}
+		if id := orders.values["CUSTOMER_ID"][i]; !ids[id] {
+			t.Errorf("customer_id %v is not of the customers", id)
+		}
+		if total := orders.values["TOTAL"][i].(json.Number); !decimal.MatchString(string(total)) {
+			t.Errorf("unexpected total %v", total)
+		}
+		if status := orders.values["STATUS"][i]; status != "new" && status != "paid" {
+			t.Errorf("unexpected status %v", status)
+		}
+		if _, ok := orders.values["PAID"][i].(bool); !ok {
+			t.Errorf("unexpected paid %v", orders.values["PAID"][i])
+		}
+		if created := orders.values["CREATED"][i].(string); !timestamp.MatchString(created) {
+			t.Errorf("unexpected created %q", created)
+		}
+	}
+}
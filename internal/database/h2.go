@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
 
 	_ "github.com/CodinGame/h2go"
 	"github.com/sqls-server/sqls/dialect"
@@ -32,6 +31,7 @@ func h2Open(dbConnCfg *DBConfig) (*DBConnection, error) {
 		return nil, err
 	}
 	conn = dbConn
+	setConnPool(conn, dbConnCfg)
 
 	return &DBConnection{
 		Conn:   conn,
@@ -79,7 +79,7 @@ func (db *H2DBRepository) Schemas(ctx context.Context) ([]string, error) {
 	SELECT schema_name FROM information_schema.schemata
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	schemas := []string{}
@@ -141,7 +141,7 @@ func (db *H2DBRepository) Tables(ctx context.Context) ([]string, error) {
 		table_name
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tables := []string{}
@@ -183,7 +183,7 @@ func (db *H2DBRepository) DescribeDatabaseTable(ctx context.Context) ([]*ColumnD
 		c.ordinal_position
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tableInfos := []*ColumnDesc{}
@@ -238,7 +238,7 @@ func (db *H2DBRepository) DescribeDatabaseTableBySchema(ctx context.Context, sch
 		c.ordinal_position
 	`, schemaName))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tableInfos := []*ColumnDesc{}
@@ -262,14 +262,23 @@ func (db *H2DBRepository) DescribeDatabaseTableBySchema(ctx context.Context, sch
 	return tableInfos, nil
 }
 
-func (db *H2DBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
-	return db.Conn.ExecContext(ctx, query)
+func (db *H2DBRepository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Conn.ExecContext(ctx, query, args...)
 }
 
-func (db *H2DBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
-	return db.Conn.QueryContext(ctx, query)
+func (db *H2DBRepository) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.Conn.QueryContext(ctx, query, args...)
 }
 
 func (db *H2DBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 	return nil, fmt.Errorf("describe foreign keys is not supported")
 }
+
+func (db *H2DBRepository) Roles(ctx context.Context) ([]string, error) {
+	return queryNames(ctx, db.Conn, `
+	SELECT USER_NAME FROM INFORMATION_SCHEMA.USERS
+	UNION
+	SELECT ROLE_NAME FROM INFORMATION_SCHEMA.ROLES
+	ORDER BY 1
+	`)
+}
@@ -0,0 +1,294 @@
+package database
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+const (
+	ImportCSV  = "csv"
+	ImportJSON = "json"
+)
+
+// ImportData is the rows of a CSV or a JSON file to import into a table, with
+// the columns of the header of the CSV or the keys of the JSON objects.
+type ImportData struct {
+	Format  string
+	Columns []string
+	// Rows have the strings of the fields of CSV and the values of JSON, nil
+	// for the empty fields and null
+	Rows [][]interface{}
+}
+
+// ReadImportFile reads the rows of the CSV file, or the TSV file, or the JSON
+// file of an array of objects.
+func ReadImportFile(fpath string) (*ImportData, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open import file, %w", err)
+	}
+	defer f.Close()
+	switch strings.ToLower(filepath.Ext(fpath)) {
+	case ".csv":
+		return readImportCSV(f, ',')
+	case ".tsv":
+		return readImportCSV(f, '\t')
+	case ".json":
+		return readImportJSON(f)
+	}
+	return nil, fmt.Errorf("unsupported import file, %s", fpath)
+}
+
+func readImportCSV(r io.Reader, comma rune) (*ImportData, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CSV, %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("cannot read CSV, no header")
+	}
+	data := &ImportData{Format: ImportCSV, Columns: records[0]}
+	for _, record := range records[1:] {
+		row := make([]interface{}, len(record))
+		for i, field := range record {
+			if field != "" {
+				row[i] = field
+			}
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	return data, nil
+}
+
+func readImportJSON(r io.Reader) (*ImportData, error) {
+	var objects []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, fmt.Errorf("cannot read JSON, an array of objects is expected, %w", err)
+	}
+	data := &ImportData{Format: ImportJSON}
+	indexes := map[string]int{}
+	for _, obj := range objects {
+		keys, values, err := jsonObject(obj)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read JSON, %w", err)
+		}
+		// The keys missing in the objects are null
+		row := make([]interface{}, len(data.Columns))
+		for i, key := range keys {
+			index, ok := indexes[key]
+			if !ok {
+				index = len(data.Columns)
+				indexes[key] = index
+				data.Columns = append(data.Columns, key)
+				row = append(row, nil)
+			}
+			row[index] = values[i]
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	for i, row := range data.Rows {
+		for len(row) < len(data.Columns) {
+			row = append(row, nil)
+		}
+		data.Rows[i] = row
+	}
+	return data, nil
+}
+
+// jsonObject returns the keys and the values of the object in the order of
+// the text. The numbers are json.Number, and the objects and the arrays are
+// their texts.
+func jsonObject(obj json.RawMessage) ([]string, []interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("an object is expected, %s", obj)
+	}
+	keys, values := []string{}, []interface{}{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		var v interface{}
+		switch raw[0] {
+		case '{', '[':
+			v = string(raw)
+		default:
+			d := json.NewDecoder(bytes.NewReader(raw))
+			d.UseNumber()
+			if err := d.Decode(&v); err != nil {
+				return nil, nil, err
+			}
+		}
+		keys = append(keys, tok.(string))
+		values = append(values, v)
+	}
+	return keys, values, nil
+}
+
+// ImportColumns maps the columns of the file to the columns of the table. The
+// mapping gives the column of the table of a column of the file, or skips it
+// by an empty column, and the other columns are of the same names ignoring
+// the case. It returns the columns of the table and the indexes of the
+// columns of the file for them.
+func ImportColumns(source []string, table []*ColumnDesc, mapping map[string]string) ([]string, []int, error) {
+	for src := range mapping {
+		found := false
+		for _, col := range source {
+			if strings.EqualFold(col, src) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("column %s is not in the import file", src)
+		}
+	}
+
+	columns, indexes, unmapped := []string{}, []int{}, []string{}
+	for i, col := range source {
+		name, mapped := col, false
+		for src, dst := range mapping {
+			if strings.EqualFold(src, col) {
+				name, mapped = dst, true
+			}
+		}
+		if mapped && name == "" {
+			continue
+		}
+		target := ""
+		for _, desc := range table {
+			if strings.EqualFold(desc.Name, name) {
+				target = desc.Name
+				break
+			}
+		}
+		switch {
+		case target != "":
+			columns = append(columns, target)
+			indexes = append(indexes, i)
+		case mapped:
+			return nil, nil, fmt.Errorf("column %s is not in the table", name)
+		default:
+			unmapped = append(unmapped, col)
+		}
+	}
+	if len(unmapped) > 0 {
+		return nil, nil, fmt.Errorf("cannot map the columns %s to the table, map them by <file column>:<table column> or skip them by <file column>:", strings.Join(unmapped, ", "))
+	}
+	return columns, indexes, nil
+}
+
+// ImportStatements returns the INSERT statements of the rows of the data into
+// the columns of the table, of batch rows each. The values of the columns are
+// of the indexes of the columns of the data. Oracle, whose VALUES has a row,
+// has a statement for each row.
+func ImportStatements(driver dialect.DatabaseDriver, table string, data *ImportData, columns []string, indexes []int, batch int) []string {
+	if batch < 1 || driver == dialect.DatabaseDriverOracle {
+		batch = 1
+	}
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = importIdentifier(driver, col)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES", table, strings.Join(names, ", "))
+
+	stmts := []string{}
+	for start := 0; start < len(data.Rows); start += batch {
+		end := start + batch
+		if end > len(data.Rows) {
+			end = len(data.Rows)
+		}
+		values := []string{}
+		for _, row := range data.Rows[start:end] {
+			literals := make([]string, len(indexes))
+			for i, index := range indexes {
+				var v interface{}
+				if index < len(row) {
+					v = row[index]
+				}
+				literals[i] = importLiteral(driver, v)
+			}
+			values = append(values, "("+strings.Join(literals, ", ")+")")
+		}
+		sep := " "
+		if len(values) > 1 {
+			sep = "\n  "
+		}
+		stmts = append(stmts, insert+sep+strings.Join(values, ",\n  "))
+	}
+	return stmts
+}
+
+// ImportCopyStatement returns the COPY of PostgreSQL loading the CSV file
+// into the columns of the table, which are of all the columns of the file in
+// their order.
+func ImportCopyStatement(table string, columns []string) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = importIdentifier(dialect.DatabaseDriverPostgreSQL, col)
+	}
+	return fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv, HEADER true)", table, strings.Join(names, ", "))
+}
+
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// importIdentifier quotes the column if it is not an identifier without the
+// quotes in the dialect of the driver. The name of the column of the table is
+// quoted as it is, without folding its case.
+func importIdentifier(driver dialect.DatabaseDriver, name string) string {
+	if plainIdentifier.MatchString(name) && !dialect.IsReserved(driver, strings.ToUpper(name)) {
+		return name
+	}
+	switch {
+	case isMySQL(driver), driver == dialect.DatabaseDriverClickhouse:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case driver == dialect.DatabaseDriverMssql:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func importLiteral(driver dialect.DatabaseDriver, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case json.Number:
+		return v.String()
+	case bool:
+		switch driver {
+		case dialect.DatabaseDriverMssql, dialect.DatabaseDriverOracle:
+			if v {
+				return "1"
+			}
+			return "0"
+		}
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case string:
+		if isMySQL(driver) {
+			v = strings.ReplaceAll(v, `\`, `\\`)
+		}
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return importLiteral(driver, fmt.Sprint(v))
+}
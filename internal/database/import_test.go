@@ -0,0 +1,201 @@
+package database
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestReadImportFile(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		text string
+		want *ImportData
+	}{
+		{
+			name: "csv",
+			file: "users.csv",
+			text: "id,name,note\n1,\"O'Brien, Pat\",\n2,Lee,\"multi\nline\"\n",
+			want: &ImportData{
+				Format:  ImportCSV,
+				Columns: []string{"id", "name", "note"},
+				Rows: [][]interface{}{
+					{"1", "O'Brien, Pat", nil},
+					{"2", "Lee", "multi\nline"},
+				},
+			},
+		},
+		{
+			name: "tsv",
+			file: "users.tsv",
+			text: "id\tname\n1\tPat\n",
+			want: &ImportData{
+				Format:  ImportCSV,
+				Columns: []string{"id", "name"},
+				Rows:    [][]interface{}{{"1", "Pat"}},
+			},
+		},
+		{
+			name: "json",
+			file: "users.json",
+			text: `[{"id": 1, "name": "Pat", "active": true}, {"name": null, "id": 2.5, "tags": ["a"]}]`,
+			want: &ImportData{
+				Format:  ImportJSON,
+				Columns: []string{"id", "name", "active", "tags"},
+				Rows: [][]interface{}{
+					{json.Number("1"), "Pat", true, nil},
+					{json.Number("2.5"), nil, nil, `["a"]`},
+				},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			fpath := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(fpath, []byte(tt.text), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadImportFile(fpath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched data (-want +got):\n%s", d)
+			}
+		})
+	}
+
+	fpath := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(fpath, []byte(`{"id": 1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadImportFile(fpath); err == nil {
+		t.Error("JSON other than an array must fail")
+	}
+}
+
+func TestImportColumns(t *testing.T) {
+	table := []*ColumnDesc{
+		{ColumnBase: ColumnBase{Table: "users", Name: "ID"}},
+		{ColumnBase: ColumnBase{Table: "users", Name: "full_name"}},
+		{ColumnBase: ColumnBase{Table: "users", Name: "created"}},
+	}
+	cases := []struct {
+		name        string
+		mapping     map[string]string
+		wantColumns []string
+		wantIndexes []int
+		wantErr     string
+	}{
+		{
+			name:    "unmapped",
+			wantErr: "cannot map the columns name, note to the table, map them by <file column>:<table column> or skip them by <file column>:",
+		},
+		{
+			name:        "mapped",
+			mapping:     map[string]string{"Name": "FULL_NAME", "note": ""},
+			wantColumns: []string{"ID", "full_name"},
+			wantIndexes: []int{0, 1},
+		},
+		{
+			name:    "unknown table column",
+			mapping: map[string]string{"name": "nickname", "note": ""},
+			wantErr: "column nickname is not in the table",
+		},
+		{
+			name:    "unknown file column",
+			mapping: map[string]string{"email": "full_name"},
+			wantErr: "column email is not in the import file",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, indexes, err := ImportColumns([]string{"id", "name", "note"}, table, tt.mapping)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.wantColumns, columns); d != "" {
+				t.Errorf("unmatched columns (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.wantIndexes, indexes); d != "" {
+				t.Errorf("unmatched indexes (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestImportStatements(t *testing.T) {
+	data := &ImportData{
+		Columns: []string{"id", "name", "active", "skipped"},
+		Rows: [][]interface{}{
+			{json.Number("1"), `O'Brien \ Pat`, true, "x"},
+			{json.Number("2"), nil, false, "y"},
+			{json.Number("3"), "Lee", nil, "z"},
+		},
+	}
+	cases := []struct {
+		name   string
+		driver dialect.DatabaseDriver
+		order  string
+		batch  int
+		want   []string
+	}{
+		{
+			name:   "postgresql",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			order:  "order",
+			batch:  2,
+			want: []string{
+				"INSERT INTO users (id, \"order\", active) VALUES\n  (1, 'O''Brien \\ Pat', TRUE),\n  (2, NULL, FALSE)",
+				"INSERT INTO users (id, \"order\", active) VALUES (3, 'Lee', NULL)",
+			},
+		},
+		{
+			name:   "mysql",
+			driver: dialect.DatabaseDriverMySQL,
+			order:  "order",
+			batch:  3,
+			want: []string{
+				"INSERT INTO users (id, `order`, active) VALUES\n  (1, 'O''Brien \\\\ Pat', TRUE),\n  (2, NULL, FALSE),\n  (3, 'Lee', NULL)",
+			},
+		},
+		{
+			name:   "oracle",
+			driver: dialect.DatabaseDriverOracle,
+			order:  "ORDER",
+			batch:  100,
+			want: []string{
+				"INSERT INTO users (id, \"ORDER\", active) VALUES (1, 'O''Brien \\ Pat', 1)",
+				"INSERT INTO users (id, \"ORDER\", active) VALUES (2, NULL, 0)",
+				"INSERT INTO users (id, \"ORDER\", active) VALUES (3, 'Lee', NULL)",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ImportStatements(tt.driver, "users", data, []string{"id", tt.order, "active"}, []int{0, 1, 2}, tt.batch)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched statements (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestImportCopyStatement(t *testing.T) {
+	want := `COPY users (id, "Full Name") FROM STDIN WITH (FORMAT csv, HEADER true)`
+	if got := ImportCopyStatement("users", []string{"id", "Full Name"}); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/credentials"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/sqls-server/sqls/dialect"
+)
+
+const AuthTypeKerberos = "kerberos"
+
+type KerberosConfig struct {
+	Keytab          string `json:"keytab" yaml:"keytab"`
+	CredentialCache string `json:"credentialCache" yaml:"credentialCache"`
+	Principal       string `json:"principal" yaml:"principal"`
+	SPN             string `json:"spn" yaml:"spn"`
+}
+
+func (c *KerberosConfig) Validate(driver dialect.DatabaseDriver) error {
+	if driver != dialect.DatabaseDriverMssql {
+		return nil
+	}
+	// SQL Server authenticates with the credentials of the Windows logon
+	if c.Keytab != "" {
+		return errors.New("invalid: connections[].kerberos.keytab is not supported by the driver")
+	}
+	if c.CredentialCache != "" {
+		return errors.New("invalid: connections[].kerberos.credentialCache is not supported by the driver")
+	}
+	if c.Principal != "" {
+		return errors.New("invalid: connections[].kerberos.principal is not supported by the driver")
+	}
+	return nil
+}
+
+// gssClient is the GSSAPI provider of pgconn for a connection, which is
+// closed after the authentication.
+type gssClient interface {
+	pgconn.GSS
+	Close()
+}
+
+func init() {
+	// The servers which ask the connections without the kerberos config for
+	// GSSAPI are given the default credentials
+	pgconn.RegisterGSSProvider(func() (pgconn.GSS, error) {
+		return newKerberosGSS(&KerberosConfig{})
+	})
+}
+
+// kerberosGSS authenticates with SPNEGO by the Kerberos client logged in
+// with the credentials of the config.
+type kerberosGSS struct {
+	client *client.Client
+}
+
+// newKerberosGSS logs in with the keytab of the principal, or with the
+// credential cache.
+func newKerberosGSS(cfg *KerberosConfig) (*kerberosGSS, error) {
+	krb5conf, err := loadKrb5Config()
+	if err != nil {
+		return nil, err
+	}
+	var cl *client.Client
+	if cfg.Keytab != "" {
+		kt, err := keytab.Load(cfg.Keytab)
+		if err != nil {
+			return nil, fmt.Errorf("kerberos error: cannot load keytab %s, %w", cfg.Keytab, err)
+		}
+		username, realm, err := keytabPrincipal(kt, cfg.Principal, krb5conf.LibDefaults.DefaultRealm)
+		if err != nil {
+			return nil, err
+		}
+		cl = client.NewWithKeytab(username, realm, kt, krb5conf, client.DisablePAFXFAST(true))
+	} else {
+		path, err := credentialCachePath(cfg.CredentialCache)
+		if err != nil {
+			return nil, err
+		}
+		ccache, err := credentials.LoadCCache(path)
+		if err != nil {
+			return nil, fmt.Errorf("kerberos error: cannot load credential cache %s, %w", path, err)
+		}
+		principal := ccache.GetClientPrincipalName().PrincipalNameString() + "@" + ccache.GetClientRealm()
+		if cfg.Principal != "" && cfg.Principal != principal {
+			return nil, fmt.Errorf("kerberos error: credential cache %s is of %s, not of %s", path, principal, cfg.Principal)
+		}
+		cl, err = client.NewFromCCache(ccache, krb5conf, client.DisablePAFXFAST(true))
+		if err != nil {
+			return nil, fmt.Errorf("kerberos error: %w", err)
+		}
+	}
+	if err := cl.AffirmLogin(); err != nil {
+		cl.Destroy()
+		return nil, fmt.Errorf("kerberos error: cannot login, %w", err)
+	}
+	return &kerberosGSS{client: cl}, nil
+}
+
+// loadKrb5Config loads the Kerberos configuration of KRB5_CONFIG, or of
+// /etc/krb5.conf.
+func loadKrb5Config() (*config.Config, error) {
+	path := os.Getenv("KRB5_CONFIG")
+	if path == "" {
+		path = "/etc/krb5.conf"
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("kerberos error: %w", err)
+	}
+	return cfg, nil
+}
+
+// keytabPrincipal returns the user name and the realm of the principal, or
+// of the first principal of the keytab if it is not configured.
+func keytabPrincipal(kt *keytab.Keytab, principal, defaultRealm string) (string, string, error) {
+	if principal == "" {
+		if len(kt.Entries) == 0 {
+			return "", "", errors.New("kerberos error: keytab has no principal")
+		}
+		p := kt.Entries[0].Principal
+		return strings.Join(p.Components, "/"), p.Realm, nil
+	}
+	username, realm, ok := strings.Cut(principal, "@")
+	if !ok {
+		realm = defaultRealm
+	}
+	return username, realm, nil
+}
+
+// credentialCachePath returns the file of the credential cache, KRB5CCNAME
+// or the default one of the user unless it is configured.
+func credentialCachePath(ccache string) (string, error) {
+	if ccache == "" {
+		ccache = os.Getenv("KRB5CCNAME")
+	}
+	if ccache == "" {
+		u, err := user.Current()
+		if err != nil {
+			return "", fmt.Errorf("kerberos error: %w", err)
+		}
+		return "/tmp/krb5cc_" + u.Uid, nil
+	}
+	kind, path, ok := strings.Cut(ccache, ":")
+	if !ok {
+		return ccache, nil
+	}
+	if kind != "FILE" {
+		return "", fmt.Errorf("kerberos error: credential cache %s is not supported, only FILE is", ccache)
+	}
+	return path, nil
+}
+
+func (g *kerberosGSS) GetInitToken(host string, service string) ([]byte, error) {
+	return g.GetInitTokenFromSPN(service + "/" + host)
+}
+
+func (g *kerberosGSS) GetInitTokenFromSPN(spn string) ([]byte, error) {
+	// The realm of the service is resolved by the domain of the host
+	name, _, _ := strings.Cut(spn, "@")
+	token, err := spnego.SPNEGOClient(g.client, name).InitSecContext()
+	if err != nil {
+		return nil, fmt.Errorf("kerberos error: cannot get service ticket of %s, %w", spn, err)
+	}
+	return token.Marshal()
+}
+
+func (g *kerberosGSS) Continue(inToken []byte) (bool, []byte, error) {
+	var token spnego.SPNEGOToken
+	if err := token.Unmarshal(inToken); err != nil {
+		return false, nil, fmt.Errorf("kerberos error: invalid token of server, %w", err)
+	}
+	if !token.Resp || token.NegTokenResp.State() != spnego.NegStateAcceptCompleted {
+		return false, nil, errors.New("kerberos error: security context is not accepted by server")
+	}
+	return true, nil, nil
+}
+
+func (g *kerberosGSS) Close() {
+	g.client.Destroy()
+}
+
+// postgresKerberosSPN returns the service principal name of the server,
+// "postgres/host" unless it is configured.
+func postgresKerberosSPN(cfg *KerberosConfig, host string) string {
+	if cfg != nil && cfg.SPN != "" {
+		return cfg.SPN
+	}
+	return "postgres/" + host
+}
+
+// kerberosBuildFrontend returns the frontend of pgconn which authenticates
+// the connection with the GSSAPI provider of it, as the provider registered
+// to pgconn is global and is given nothing of the connection but the service
+// principal name.
+func kerberosBuildFrontend(build pgconn.BuildFrontendFunc, spn string, newGSS func() (gssClient, error)) pgconn.BuildFrontendFunc {
+	return func(r io.Reader, w io.Writer) pgconn.Frontend {
+		return &kerberosFrontend{
+			Frontend: build(r, w),
+			w:        w,
+			spn:      spn,
+			newGSS:   newGSS,
+		}
+	}
+}
+
+type kerberosFrontend struct {
+	pgconn.Frontend
+	w      io.Writer
+	spn    string
+	newGSS func() (gssClient, error)
+}
+
+func (f *kerberosFrontend) Receive() (pgproto3.BackendMessage, error) {
+	msg, err := f.Frontend.Receive()
+	if _, ok := msg.(*pgproto3.AuthenticationGSS); !ok || err != nil {
+		return msg, err
+	}
+	return f.authenticate()
+}
+
+// authenticate exchanges the tokens of GSSAPI with the server, and returns the
+// message after them to pgconn.
+func (f *kerberosFrontend) authenticate() (pgproto3.BackendMessage, error) {
+	gss, err := f.newGSS()
+	if err != nil {
+		return nil, err
+	}
+	defer gss.Close()
+	token, err := gss.GetInitTokenFromSPN(f.spn)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, err := f.w.Write((&pgproto3.GSSResponse{Data: token}).Encode(nil)); err != nil {
+			return nil, err
+		}
+		msg, err := f.Frontend.Receive()
+		if err != nil {
+			return nil, err
+		}
+		cont, ok := msg.(*pgproto3.AuthenticationGSSContinue)
+		if !ok {
+			// Such as the error of the authentication
+			return msg, nil
+		}
+		done, next, err := gss.Continue(cont.Data)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return f.Frontend.Receive()
+		}
+		token = next
+	}
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestPostgresKerberosSPN(t *testing.T) {
+	if got := postgresKerberosSPN(nil, "db.example.com"); got != "postgres/db.example.com" {
+		t.Errorf("got %q", got)
+	}
+	cfg := &KerberosConfig{SPN: "pgsql/db.example.com@EXAMPLE.COM"}
+	if got := postgresKerberosSPN(cfg, "db.example.com"); got != cfg.SPN {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestKerberosConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  dialect.DatabaseDriver
+		cfg     *KerberosConfig
+		wantErr bool
+	}{
+		{name: "postgres keytab", driver: dialect.DatabaseDriverPostgreSQL, cfg: &KerberosConfig{Keytab: "sqls.keytab", Principal: "sqls@EXAMPLE.COM"}},
+		{name: "mssql spn", driver: dialect.DatabaseDriverMssql, cfg: &KerberosConfig{SPN: "MSSQLSvc/db.example.com:1433"}},
+		{name: "mssql keytab", driver: dialect.DatabaseDriverMssql, cfg: &KerberosConfig{Keytab: "sqls.keytab"}, wantErr: true},
+		{name: "mssql credential cache", driver: dialect.DatabaseDriverMssql, cfg: &KerberosConfig{CredentialCache: "/tmp/krb5cc"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(tt.driver); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	cfg := &DBConfig{Driver: dialect.DatabaseDriverMySQL, AuthType: AuthTypeKerberos, Proto: ProtoTCP, User: "root", Host: "localhost"}
+	if err := cfg.Validate(); err == nil {
+		t.Errorf("expected error for unsupported driver")
+	}
+	cfg = &DBConfig{Driver: dialect.DatabaseDriverMssql, AuthType: AuthTypeKerberos, Proto: ProtoTCP, Host: "localhost"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("user is not required for kerberos, %v", err)
+	}
+}
+
+func TestNewKerberosGSS_NoCredentials(t *testing.T) {
+	dir := t.TempDir()
+	krb5conf := filepath.Join(dir, "krb5.conf")
+	if err := os.WriteFile(krb5conf, []byte("[libdefaults]\n  default_realm = EXAMPLE.COM\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KRB5_CONFIG", krb5conf)
+	t.Setenv("KRB5CCNAME", "FILE:"+filepath.Join(dir, "krb5cc"))
+
+	cfgs := []*KerberosConfig{
+		{Keytab: filepath.Join(dir, "missing.keytab"), Principal: "sqls@EXAMPLE.COM"},
+		{CredentialCache: filepath.Join(dir, "missing.ccache")},
+		{},
+	}
+	for _, cfg := range cfgs {
+		if _, err := newKerberosGSS(cfg); err == nil {
+			t.Errorf("expected error without credentials, %+v", cfg)
+		}
+	}
+}
+
+func TestCredentialCachePath(t *testing.T) {
+	t.Setenv("KRB5CCNAME", "FILE:/tmp/krb5cc_env")
+	tests := []struct {
+		ccache  string
+		want    string
+		wantErr bool
+	}{
+		{ccache: "", want: "/tmp/krb5cc_env"},
+		{ccache: "/tmp/krb5cc_sqls", want: "/tmp/krb5cc_sqls"},
+		{ccache: "FILE:/tmp/krb5cc_sqls", want: "/tmp/krb5cc_sqls"},
+		{ccache: "KEYRING:persistent:1000", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ccache, func(t *testing.T) {
+			got, err := credentialCachePath(tt.ccache)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("credentialCachePath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("credentialCachePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeGSS struct {
+	spn    string
+	closed bool
+}
+
+func (g *fakeGSS) GetInitToken(host string, service string) ([]byte, error) {
+	return g.GetInitTokenFromSPN(service + "/" + host)
+}
+
+func (g *fakeGSS) GetInitTokenFromSPN(spn string) ([]byte, error) {
+	g.spn = spn
+	return []byte("init"), nil
+}
+
+func (g *fakeGSS) Continue(inToken []byte) (bool, []byte, error) {
+	switch string(inToken) {
+	case "more":
+		return false, []byte("next"), nil
+	case "accepted":
+		return true, nil, nil
+	}
+	return false, nil, errors.New("unexpected token")
+}
+
+func (g *fakeGSS) Close() {
+	g.closed = true
+}
+
+func TestKerberosBuildFrontend(t *testing.T) {
+	encode := func(msgs ...pgproto3.BackendMessage) []byte {
+		var b []byte
+		for _, msg := range msgs {
+			if _, ok := msg.(*pgproto3.AuthenticationGSS); ok {
+				// The encoder of pgproto3 writes the length without the type
+				b = append(b, 'R', 0, 0, 0, 8, 0, 0, 0, pgproto3.AuthTypeGSS)
+				continue
+			}
+			b = msg.Encode(b)
+		}
+		return b
+	}
+	tests := []struct {
+		name      string
+		in        []byte
+		wantOut   []byte
+		wantMsg   pgproto3.BackendMessage
+		wantSPN   string
+		wantError bool
+	}{
+		{
+			name: "gss",
+			in: encode(
+				&pgproto3.AuthenticationGSS{},
+				&pgproto3.AuthenticationGSSContinue{Data: []byte("more")},
+				&pgproto3.AuthenticationGSSContinue{Data: []byte("accepted")},
+				&pgproto3.AuthenticationOk{},
+			),
+			wantOut: append(
+				(&pgproto3.GSSResponse{Data: []byte("init")}).Encode(nil),
+				(&pgproto3.GSSResponse{Data: []byte("next")}).Encode(nil)...,
+			),
+			wantMsg: &pgproto3.AuthenticationOk{},
+			wantSPN: "postgres/db.example.com",
+		},
+		{
+			name: "rejected",
+			in: encode(
+				&pgproto3.AuthenticationGSS{},
+				&pgproto3.ErrorResponse{Severity: "FATAL", Code: "28000"},
+			),
+			wantOut: (&pgproto3.GSSResponse{Data: []byte("init")}).Encode(nil),
+			wantMsg: &pgproto3.ErrorResponse{Severity: "FATAL", Code: "28000"},
+			wantSPN: "postgres/db.example.com",
+		},
+		{
+			name: "invalid token",
+			in: encode(
+				&pgproto3.AuthenticationGSS{},
+				&pgproto3.AuthenticationGSSContinue{Data: []byte("invalid")},
+			),
+			wantOut:   (&pgproto3.GSSResponse{Data: []byte("init")}).Encode(nil),
+			wantSPN:   "postgres/db.example.com",
+			wantError: true,
+		},
+		{
+			name:    "not gss",
+			in:      encode(&pgproto3.AuthenticationOk{}),
+			wantMsg: &pgproto3.AuthenticationOk{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gss := &fakeGSS{}
+			build := func(r io.Reader, w io.Writer) pgconn.Frontend {
+				return pgproto3.NewFrontend(pgproto3.NewChunkReader(r), w)
+			}
+			out := &bytes.Buffer{}
+			frontend := kerberosBuildFrontend(build, "postgres/db.example.com", func() (gssClient, error) {
+				return gss, nil
+			})(bytes.NewReader(tt.in), out)
+
+			msg, err := frontend.Receive()
+			if (err != nil) != tt.wantError {
+				t.Fatalf("Receive() error = %v, wantError %v", err, tt.wantError)
+			}
+			if !tt.wantError && !reflect.DeepEqual(msg, tt.wantMsg) {
+				t.Errorf("Receive() = %#v, want %#v", msg, tt.wantMsg)
+			}
+			if !bytes.Equal(out.Bytes(), tt.wantOut) {
+				t.Errorf("written %q, want %q", out.Bytes(), tt.wantOut)
+			}
+			if gss.spn != tt.wantSPN {
+				t.Errorf("spn %q, want %q", gss.spn, tt.wantSPN)
+			}
+			if gss.closed != (tt.wantSPN != "") {
+				t.Errorf("closed %v", gss.closed)
+			}
+		})
+	}
+}
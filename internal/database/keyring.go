@@ -0,0 +1,84 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	PasswordStoreKeyring = "keyring"
+
+	keyringService = "sqls"
+)
+
+var ErrPasswordNotStored = errors.New("password is not stored in the keyring")
+
+// Keyring stores the passwords in the credential store of the OS, the
+// Keychain on macOS, the Credential Manager on Windows and the Secret
+// Service (libsecret) on the others.
+type Keyring interface {
+	Get(service, account string) (string, error)
+	Set(service, account, password string) error
+}
+
+// SystemKeyring is the keyring used by the connections with
+// passwordStore: keyring.
+var SystemKeyring Keyring = systemKeyring{}
+
+// systemKeyring uses the Keychain on macOS, the Credential Manager on Windows
+// and the Secret Service of GNOME Keyring, KeePassXC or KWallet on the others.
+type systemKeyring struct{}
+
+func (systemKeyring) Get(service, account string) (string, error) {
+	password, err := keyring.Get(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrPasswordNotStored
+	}
+	return password, err
+}
+
+func (systemKeyring) Set(service, account, password string) error {
+	return keyring.Set(service, account, password)
+}
+
+func (c *DBConfig) validatePasswordStore() error {
+	switch c.PasswdStore {
+	case "":
+		return nil
+	case PasswordStoreKeyring:
+	default:
+		return errors.New("invalid: connections[].passwordStore")
+	}
+	if c.Passwd != "" || c.PasswdCmd != "" {
+		return errors.New("invalid: connections[].passwordStore cannot be used with connections[].passwd or connections[].passwordCmd")
+	}
+	// The alias names the password in the keyring
+	if c.Alias == "" {
+		return errors.New("required: connections[].alias")
+	}
+	return nil
+}
+
+// StorePassword saves the password of the connection in the keyring.
+func StorePassword(cfg *DBConfig, password string) error {
+	if cfg.PasswdStore != PasswordStoreKeyring {
+		return fmt.Errorf("connection %q does not use the keyring, set passwordStore: %s", cfg.Alias, PasswordStoreKeyring)
+	}
+	if err := SystemKeyring.Set(keyringService, cfg.Alias, password); err != nil {
+		return fmt.Errorf("cannot store password in the keyring, %w", err)
+	}
+	return nil
+}
+
+func keyringPassword(cfg *DBConfig) (string, error) {
+	password, err := SystemKeyring.Get(keyringService, cfg.Alias)
+	if errors.Is(err, ErrPasswordNotStored) {
+		return "", fmt.Errorf("%w for connection %q, run the storePassword command", err, cfg.Alias)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot get password from the keyring, %w", err)
+	}
+	return password, nil
+}
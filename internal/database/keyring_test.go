@@ -0,0 +1,80 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeKeyring map[string]string
+
+func (k fakeKeyring) Get(service, account string) (string, error) {
+	password, ok := k[service+":"+account]
+	if !ok {
+		return "", ErrPasswordNotStored
+	}
+	return password, nil
+}
+
+func (k fakeKeyring) Set(service, account, password string) error {
+	k[service+":"+account] = password
+	return nil
+}
+
+func TestKeyring(t *testing.T) {
+	keyring := fakeKeyring{}
+	orig := SystemKeyring
+	SystemKeyring = keyring
+	defer func() { SystemKeyring = orig }()
+
+	cfg := &DBConfig{
+		Alias:       "dev",
+		Driver:      "mysql",
+		Proto:       ProtoTCP,
+		User:        "root",
+		Host:        "127.0.0.1",
+		PasswdStore: PasswordStoreKeyring,
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Resolve(); !errors.Is(err, ErrPasswordNotStored) {
+		t.Fatalf("Resolve() error = %v, want %v", err, ErrPasswordNotStored)
+	}
+
+	if err := StorePassword(cfg, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cfg.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Passwd != "secret" {
+		t.Errorf("password = %q, want %q", got.Passwd, "secret")
+	}
+
+	if err := StorePassword(&DBConfig{Alias: "other", Driver: "mysql"}, "secret"); err == nil {
+		t.Error("the connection without passwordStore must not store the password")
+	}
+}
+
+func TestDBConfig_validatePasswordStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *DBConfig
+		wantErr bool
+	}{
+		{name: "none", cfg: &DBConfig{}},
+		{name: "keyring", cfg: &DBConfig{Alias: "dev", PasswdStore: PasswordStoreKeyring}},
+		{name: "unknown", cfg: &DBConfig{Alias: "dev", PasswdStore: "vault"}, wantErr: true},
+		{name: "without alias", cfg: &DBConfig{PasswdStore: PasswordStoreKeyring}, wantErr: true},
+		{name: "with passwd", cfg: &DBConfig{Alias: "dev", Passwd: "secret", PasswdStore: PasswordStoreKeyring}, wantErr: true},
+		{name: "with passwordCmd", cfg: &DBConfig{Alias: "dev", PasswdCmd: "pass dev", PasswdStore: PasswordStoreKeyring}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validatePasswordStore(); (err != nil) != tt.wantErr {
+				t.Errorf("validatePasswordStore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
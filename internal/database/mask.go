@@ -0,0 +1,91 @@
+package database
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maskedSecret replaces the passwords and the other secrets of the
+// connections to print, as url.URL.Redacted does.
+const maskedSecret = "xxxxx"
+
+var (
+	// dsnPasswordParam matches the password of a data source name of the keys
+	// and the values, such as the ones of PostgreSQL and SQL Server.
+	dsnPasswordParam = regexp.MustCompile(`(?i)\b(password|pwd)(\s*=\s*)('[^']*'|[^;\s]*)`)
+	// dsnUserPassword matches the user and the password before the address of
+	// a data source name of MySQL, such as user:password@tcp(host:3306)/db.
+	dsnUserPassword = regexp.MustCompile(`^([^:@/]*):([^@]*)@`)
+)
+
+// Masked returns a copy of the config whose passwords, tokens and secrets are
+// masked, to print the connection.
+func (c *DBConfig) Masked() *DBConfig {
+	masked := *c
+	masked.DataSourceName = maskDataSourceName(c.DataSourceName)
+	masked.Passwd = maskSecret(c.Passwd)
+	if c.Params != nil {
+		masked.Params = map[string]string{}
+		for k, v := range c.Params {
+			if strings.Contains(strings.ToLower(k), "password") {
+				v = maskSecret(v)
+			}
+			masked.Params[k] = v
+		}
+	}
+	if c.SSHCfg != nil {
+		masked.SSHCfg = c.SSHCfg.masked()
+	}
+	if c.AzureADCfg != nil {
+		azure := *c.AzureADCfg
+		azure.ClientSecret = maskSecret(azure.ClientSecret)
+		masked.AzureADCfg = &azure
+	}
+	if c.VaultCfg != nil {
+		vault := *c.VaultCfg
+		vault.Token = maskSecret(vault.Token)
+		masked.VaultCfg = &vault
+	}
+	return &masked
+}
+
+func (s *SSHConfig) masked() *SSHConfig {
+	masked := *s
+	masked.PassPhrase = maskSecret(s.PassPhrase)
+	masked.ProxyJump = nil
+	for _, jump := range s.ProxyJump {
+		masked.ProxyJump = append(masked.ProxyJump, jump.masked())
+	}
+	return &masked
+}
+
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return maskedSecret
+}
+
+// maskDataSourceName masks the password of the URL, of the keys and the
+// values, or of MySQL in the data source name.
+func maskDataSourceName(dsn string) string {
+	if dsn == "" {
+		return ""
+	}
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			for k := range q {
+				if strings.Contains(strings.ToLower(k), "password") {
+					q.Set(k, maskedSecret)
+					u.RawQuery = q.Encode()
+				}
+			}
+			return u.Redacted()
+		}
+	}
+	dsn = dsnPasswordParam.ReplaceAllString(dsn, "${1}${2}"+maskedSecret)
+	return dsnUserPassword.ReplaceAllString(dsn, "${1}:"+maskedSecret+"@")
+}
@@ -3,12 +3,15 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"net/url"
+	"runtime"
 	"strconv"
+	"strings"
 
-	_ "github.com/denisenkom/go-mssqldb"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/denisenkom/go-mssqldb/msdsn"
 	"github.com/sqls-server/sqls/dialect"
 	"golang.org/x/crypto/ssh"
 )
@@ -31,17 +34,24 @@ func mssqlOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 	if dbConnCfg.SSHCfg != nil {
 		return nil, fmt.Errorf("connect via SSH is not supported")
 	}
-	dbConn, err := sql.Open("sqlserver", dsn)
-	if err != nil {
-		return nil, err
+	if dbConnCfg.TLSCfg != nil || dbConnCfg.AuthType != "" {
+		connector, err := genMssqlConnector(dsn, dbConnCfg)
+		if err != nil {
+			return nil, err
+		}
+		conn = sql.OpenDB(connector)
+	} else {
+		dbConn, err := sql.Open("sqlserver", dsn)
+		if err != nil {
+			return nil, err
+		}
+		conn = dbConn
 	}
-	conn = dbConn
 	if err = conn.Ping(); err != nil {
 		return nil, err
 	}
 
-	conn.SetMaxIdleConns(DefaultMaxIdleConns)
-	conn.SetMaxOpenConns(DefaultMaxOpenConns)
+	setConnPool(conn, dbConnCfg)
 
 	return &DBConnection{
 		Conn:    conn,
@@ -49,6 +59,44 @@ func mssqlOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 	}, nil
 }
 
+// genMssqlConnector returns the connector of dsn with the TLS setting of
+// the connection, which requires encryption, and the Azure AD token or
+// Kerberos authentication.
+func genMssqlConnector(dsn string, connCfg *DBConfig) (*mssql.Connector, error) {
+	cfg, _, err := msdsn.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if connCfg.TLSCfg != nil {
+		tlsConfig, err := connCfg.TLSCfg.Config(cfg.Host)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Encryption = msdsn.EncryptionRequired
+		cfg.TLSConfig = tlsConfig
+	}
+	switch connCfg.AuthType {
+	case AuthTypeAzureAD:
+		token, err := newAzureADToken(connCfg.AzureADCfg, dialect.DatabaseDriverMssql)
+		if err != nil {
+			return nil, err
+		}
+		return mssql.NewSecurityTokenConnector(cfg, token.Get)
+	case AuthTypeKerberos:
+		// The driver authenticates with Kerberos through SSPI using the
+		// credentials of the Windows logon when no user is given
+		if runtime.GOOS != "windows" {
+			return nil, errors.New("kerberos authentication for mssql is only supported on windows")
+		}
+		cfg.User = ""
+		cfg.Password = ""
+		if connCfg.KerberosCfg != nil && connCfg.KerberosCfg.SPN != "" {
+			cfg.ServerSPN = connCfg.KerberosCfg.SPN
+		}
+	}
+	return mssql.NewConnectorConfig(cfg), nil
+}
+
 type MssqlDBRepository struct {
 	Conn *sql.DB
 }
@@ -77,7 +125,7 @@ func (db *MssqlDBRepository) Databases(ctx context.Context) ([]string, error) {
 	SELECT name FROM sys.databases
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	databases := []string{}
@@ -110,7 +158,7 @@ func (db *MssqlDBRepository) Schemas(ctx context.Context) ([]string, error) {
 	SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	databases := []string{}
@@ -172,7 +220,7 @@ func (db *MssqlDBRepository) Tables(ctx context.Context) ([]string, error) {
 	  TABLE_NAME
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tables := []string{}
@@ -201,7 +249,11 @@ func (db *MssqlDBRepository) DescribeDatabaseTable(ctx context.Context) ([]*Colu
 			ELSE 'NO'
 		END,
 		c.COLUMN_DEFAULT,
-		''
+		CASE
+			WHEN COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsIdentity') = 1 THEN 'identity'
+			WHEN COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsComputed') = 1 THEN 'computed'
+			ELSE ''
+		END
 	FROM
 		INFORMATION_SCHEMA.COLUMNS c
 	LEFT JOIN
@@ -218,7 +270,7 @@ func (db *MssqlDBRepository) DescribeDatabaseTable(ctx context.Context) ([]*Colu
 		c.ORDINAL_POSITION
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tableInfos := []*ColumnDesc{}
@@ -237,6 +289,7 @@ func (db *MssqlDBRepository) DescribeDatabaseTable(ctx context.Context) ([]*Colu
 		if err != nil {
 			return nil, err
 		}
+		tableInfo.setGenerated()
 		tableInfos = append(tableInfos, &tableInfo)
 	}
 	return tableInfos, nil
@@ -257,7 +310,11 @@ func (db *MssqlDBRepository) DescribeDatabaseTableBySchema(ctx context.Context,
 			ELSE 'NO'
 		END,
 		c.COLUMN_DEFAULT,
-		''
+		CASE
+			WHEN COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsIdentity') = 1 THEN 'identity'
+			WHEN COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsComputed') = 1 THEN 'computed'
+			ELSE ''
+		END
 	FROM
 		INFORMATION_SCHEMA.COLUMNS c
 	LEFT JOIN
@@ -276,7 +333,7 @@ func (db *MssqlDBRepository) DescribeDatabaseTableBySchema(ctx context.Context,
 		c.ORDINAL_POSITION
 	`, schemaName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tableInfos := []*ColumnDesc{}
@@ -295,11 +352,83 @@ func (db *MssqlDBRepository) DescribeDatabaseTableBySchema(ctx context.Context,
 		if err != nil {
 			return nil, err
 		}
+		tableInfo.setGenerated()
 		tableInfos = append(tableInfos, &tableInfo)
 	}
 	return tableInfos, nil
 }
 
+// DescribeCatalogTable describes the columns of the tables of another
+// database of the server, which the names of three parts refer to.
+func (db *MssqlDBRepository) DescribeCatalogTable(ctx context.Context, catalog string) ([]*ColumnDesc, error) {
+	prefix := quoteMssqlIdentifier(catalog) + "."
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT
+		c.TABLE_SCHEMA,
+		c.TABLE_NAME,
+		c.COLUMN_NAME,
+		c.DATA_TYPE,
+		c.IS_NULLABLE,
+		CASE tc.CONSTRAINT_TYPE
+			WHEN 'PRIMARY KEY' THEN 'YES'
+			ELSE 'NO'
+		END,
+		c.COLUMN_DEFAULT,
+		CASE
+			WHEN COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_CATALOG) + '.' + QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsIdentity') = 1 THEN 'identity'
+			WHEN COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_CATALOG) + '.' + QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsComputed') = 1 THEN 'computed'
+			ELSE ''
+		END
+	FROM
+		`+prefix+`INFORMATION_SCHEMA.COLUMNS c
+	LEFT JOIN
+		`+prefix+`INFORMATION_SCHEMA.CONSTRAINT_COLUMN_USAGE ccu
+		ON c.TABLE_NAME = ccu.TABLE_NAME
+		AND c.COLUMN_NAME = ccu.COLUMN_NAME
+	LEFT JOIN `+prefix+`INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc ON
+		tc.TABLE_CATALOG = c.TABLE_CATALOG
+		AND tc.TABLE_SCHEMA = c.TABLE_SCHEMA
+		AND tc.TABLE_NAME = c.TABLE_NAME
+		AND tc.CONSTRAINT_NAME = ccu.CONSTRAINT_NAME
+	ORDER BY
+		c.TABLE_SCHEMA,
+		c.TABLE_NAME,
+		c.ORDINAL_POSITION
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe tables of database %s, %w", catalog, err)
+	}
+	defer rows.Close()
+	tableInfos := []*ColumnDesc{}
+	for rows.Next() {
+		var tableInfo ColumnDesc
+		err := rows.Scan(
+			&tableInfo.Schema,
+			&tableInfo.Table,
+			&tableInfo.Name,
+			&tableInfo.Type,
+			&tableInfo.Null,
+			&tableInfo.Key,
+			&tableInfo.Default,
+			&tableInfo.Extra,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tableInfo.setGenerated()
+		tableInfos = append(tableInfos, &tableInfo)
+	}
+	return tableInfos, rows.Err()
+}
+
+// quoteMssqlIdentifier quotes the identifier with the brackets, which cannot
+// be passed as a parameter of the query.
+func quoteMssqlIdentifier(ident string) string {
+	return "[" + strings.ReplaceAll(ident, "]", "]]") + "]"
+}
+
 func (db *MssqlDBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 	rows, err := db.Conn.QueryContext(
 		ctx,
@@ -325,18 +454,27 @@ func (db *MssqlDBRepository) DescribeForeignKeysBySchema(ctx context.Context, sc
 	order by fk.name, fkc.constraint_object_id
 		`, schemaName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 	return parseForeignKeys(rows, schemaName)
 }
 
-func (db *MssqlDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
-	return db.Conn.ExecContext(ctx, query)
+func (db *MssqlDBRepository) Roles(ctx context.Context) ([]string, error) {
+	return queryNames(ctx, db.Conn, `
+	SELECT name
+	FROM sys.database_principals
+	WHERE type IN ('S', 'U', 'G', 'R', 'E', 'X')
+	ORDER BY name
+	`)
+}
+
+func (db *MssqlDBRepository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Conn.ExecContext(ctx, query, args...)
 }
 
-func (db *MssqlDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
-	return db.Conn.QueryContext(ctx, query)
+func (db *MssqlDBRepository) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.Conn.QueryContext(ctx, query, args...)
 }
 
 func genMssqlConfig(connCfg *DBConfig) (string, error) {
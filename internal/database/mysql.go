@@ -3,10 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
-	"log"
 	"net"
 	"strconv"
+	"strings"
+	"sync/atomic"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/sqls-server/sqls/dialect"
@@ -29,36 +31,54 @@ func mysqlOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 		conn    *sql.DB
 		sshConn *ssh.Client
 	)
-	cfg, err := genMysqlConfig(dbConnCfg)
+	var cfg *mysql.Config
+	var cloudSQLDialer *cloudSQLDialer
+	var err error
+	if dbConnCfg.CloudSQLCfg != nil {
+		cfg, cloudSQLDialer, err = genCloudSQLMySQLConfig(dbConnCfg)
+	} else {
+		cfg, err = genMysqlConfig(dbConnCfg)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if dbConnCfg.TLSCfg != nil && dbConnCfg.CloudSQLCfg == nil {
+		if err := registerMySQLTLSConfig(cfg, dbConnCfg); err != nil {
+			return nil, err
+		}
+	}
 
 	if dbConnCfg.SSHCfg != nil {
-		dbConn, dbSSHConn, err := openMySQLViaSSH(cfg.FormatDSN(), dbConnCfg.SSHCfg)
+		dbConn, dbSSHConn, err := openMySQLViaSSH(cfg, dbConnCfg)
 		if err != nil {
 			return nil, err
 		}
 		conn = dbConn
 		sshConn = dbSSHConn
 	} else {
-		dbConn, err := sql.Open("mysql", cfg.FormatDSN())
+		dbConn, err := openMySQL(cfg, dbConnCfg)
 		if err != nil {
+			if cloudSQLDialer != nil {
+				cloudSQLDialer.Close()
+			}
 			return nil, err
 		}
 		conn = dbConn
 	}
 	if err := conn.Ping(); err != nil {
+		if cloudSQLDialer != nil {
+			cloudSQLDialer.Close()
+		}
 		return nil, fmt.Errorf("cannot ping to database, %w", err)
 	}
 
-	conn.SetMaxIdleConns(DefaultMaxIdleConns)
-	conn.SetMaxOpenConns(DefaultMaxOpenConns)
+	setConnPool(conn, dbConnCfg)
 
 	return &DBConnection{
-		Conn:    conn,
-		SSHConn: sshConn,
-		Driver:  dbConnCfg.Driver,
+		Conn:           conn,
+		SSHConn:        sshConn,
+		Driver:         dbConnCfg.Driver,
+		cloudSQLDialer: cloudSQLDialer,
 	}, nil
 }
 
@@ -70,23 +90,110 @@ func (d *MySQLViaSSHDialer) Dial(ctx context.Context, addr string) (net.Conn, er
 	return d.client.Dial("tcp", addr)
 }
 
-func openMySQLViaSSH(dsn string, sshCfg *SSHConfig) (*sql.DB, *ssh.Client, error) {
-	sshConfig, err := sshCfg.ClientConfig()
+func openMySQLViaSSH(cfg *mysql.Config, connCfg *DBConfig) (*sql.DB, *ssh.Client, error) {
+	sshConn, err := connCfg.SSHCfg.Dial()
 	if err != nil {
 		return nil, nil, err
 	}
-	sshConn, err := ssh.Dial("tcp", sshCfg.Endpoint(), sshConfig)
-	if err != nil {
-		return nil, nil, fmt.Errorf("cannot ssh dial, %w", err)
-	}
 	mysql.RegisterDialContext("mysql+tcp", (&MySQLViaSSHDialer{sshConn}).Dial)
-	conn, err := sql.Open("mysql", dsn)
+	conn, err := openMySQL(cfg, connCfg)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cannot connect database, %w", err)
 	}
 	return conn, sshConn, nil
 }
 
+func openMySQL(cfg *mysql.Config, connCfg *DBConfig) (*sql.DB, error) {
+	if connCfg.AuthType != AuthTypeAWSIAM {
+		return sql.Open("mysql", cfg.FormatDSN())
+	}
+	// The auth token is sent as a cleartext password, which RDS accepts only
+	// over TLS.
+	cfg = cfg.Clone()
+	cfg.AllowCleartextPasswords = true
+	if cfg.TLSConfig == "" || cfg.TLSConfig == "false" {
+		cfg.TLSConfig = "true"
+	}
+	return sql.OpenDB(&mysqlIAMConnector{
+		cfg:   cfg,
+		token: newRDSAuthToken(cfg.Addr, cfg.User, connCfg.AWSCfg),
+	}), nil
+}
+
+// mysqlIAMConnector connects with a fresh RDS auth token as the password.
+type mysqlIAMConnector struct {
+	cfg   *mysql.Config
+	token *rdsAuthToken
+}
+
+func (c *mysqlIAMConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	token, err := c.token.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate RDS auth token, %w", err)
+	}
+	cfg := c.cfg.Clone()
+	cfg.Passwd = token
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *mysqlIAMConnector) Driver() driver.Driver {
+	return mysql.MySQLDriver{}
+}
+
+func registerMySQLTLSConfig(cfg *mysql.Config, connCfg *DBConfig) error {
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		host = cfg.Addr
+	}
+	tlsConfig, err := connCfg.TLSCfg.Config(host)
+	if err != nil {
+		return err
+	}
+	name := tlsConfigName(connCfg)
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return fmt.Errorf("cannot register TLS config, %w", err)
+	}
+	cfg.TLSConfig = name
+	return nil
+}
+
+// cloudSQLMySQLNetworks numbers the networks of the Cloud SQL dialers, so that
+// closing a connection does not close the dialer of another one.
+var cloudSQLMySQLNetworks atomic.Int64
+
+// genCloudSQLMySQLConfig returns the config which connects to the Cloud SQL
+// instance through the dialer registered for it, and the dialer.
+func genCloudSQLMySQLConfig(connCfg *DBConfig) (*mysql.Config, *cloudSQLDialer, error) {
+	c := *connCfg
+	c.Proto = ProtoTCP
+	c.Host = "localhost"
+	cfg, err := genMysqlConfig(&c)
+	if err != nil {
+		return nil, nil, err
+	}
+	dialer, err := newCloudSQLDialer(context.Background(), connCfg.CloudSQLCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	network := fmt.Sprintf("cloudsql-%s-%d", strings.ReplaceAll(connCfg.CloudSQLCfg.Instance, ":", "-"), cloudSQLMySQLNetworks.Add(1))
+	mysql.RegisterDialContext(network, func(ctx context.Context, addr string) (net.Conn, error) {
+		return dialer.Dial(ctx)
+	})
+	cfg.Net = network
+	cfg.Addr = connCfg.CloudSQLCfg.Instance
+	// The dialer encrypts the connection
+	cfg.TLSConfig = ""
+	cfg.TLS = nil
+	if connCfg.CloudSQLCfg.IAMAuth {
+		cfg.AllowCleartextPasswords = true
+	}
+	return cfg, dialer, nil
+}
+
 func genMysqlConfig(connCfg *DBConfig) (*mysql.Config, error) {
 	cfg := mysql.NewConfig()
 
@@ -148,6 +255,17 @@ func (db *MySQLDBRepository) CurrentDatabase(ctx context.Context) (string, error
 	return database, nil
 }
 
+// TableNamesCaseSensitive reports whether the names of the databases and the
+// tables are case sensitive, which they are unless lower_case_table_names is
+// 1 or 2 as on Windows and macOS.
+func (db *MySQLDBRepository) TableNamesCaseSensitive(ctx context.Context) (bool, error) {
+	var lowerCase int
+	if err := db.Conn.QueryRowContext(ctx, "SELECT @@lower_case_table_names").Scan(&lowerCase); err != nil {
+		return false, err
+	}
+	return lowerCase == 0, nil
+}
+
 func (db *MySQLDBRepository) Databases(ctx context.Context) ([]string, error) {
 	rows, err := db.Conn.QueryContext(ctx, "select SCHEMA_NAME from information_schema.SCHEMATA")
 	if err != nil {
@@ -235,7 +353,8 @@ SELECT
 	IS_NULLABLE,
 	COLUMN_KEY,
 	COLUMN_DEFAULT,
-	EXTRA
+	EXTRA,
+	COLUMN_COMMENT
 FROM information_schema.COLUMNS
 `)
 	if err != nil {
@@ -254,10 +373,12 @@ FROM information_schema.COLUMNS
 			&tableInfo.Key,
 			&tableInfo.Default,
 			&tableInfo.Extra,
+			&tableInfo.Comment,
 		)
 		if err != nil {
 			return nil, err
 		}
+		tableInfo.setGenerated()
 		tableInfos = append(tableInfos, &tableInfo)
 	}
 	return tableInfos, nil
@@ -275,7 +396,8 @@ SELECT
 	IS_NULLABLE,
 	COLUMN_KEY,
 	COLUMN_DEFAULT,
-	EXTRA
+	EXTRA,
+	COLUMN_COMMENT
 FROM information_schema.COLUMNS
 WHERE information_schema.COLUMNS.TABLE_SCHEMA = ?
 `, schemaName)
@@ -295,10 +417,12 @@ WHERE information_schema.COLUMNS.TABLE_SCHEMA = ?
 			&tableInfo.Key,
 			&tableInfo.Default,
 			&tableInfo.Extra,
+			&tableInfo.Comment,
 		)
 		if err != nil {
 			return nil, err
 		}
+		tableInfo.setGenerated()
 		tableInfos = append(tableInfos, &tableInfo)
 	}
 	return tableInfos, nil
@@ -323,16 +447,75 @@ func (db *MySQLDBRepository) DescribeForeignKeysBySchema(ctx context.Context, sc
 			 kcu.ORDINAL_POSITION
 		`, schemaName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 	return parseForeignKeys(rows, schemaName)
 }
 
-func (db *MySQLDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
-	return db.Conn.ExecContext(ctx, query)
+// Partitions returns the partitioned tables of the current database and their
+// partitions, which are not tables of the schema.
+func (db *MySQLDBRepository) Partitions(ctx context.Context) ([]*PartitionDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT TABLE_SCHEMA, TABLE_NAME, PARTITION_NAME, PARTITION_METHOD,
+	       COALESCE(PARTITION_EXPRESSION, ''), COALESCE(PARTITION_DESCRIPTION, '')
+	FROM information_schema.PARTITIONS
+	WHERE TABLE_SCHEMA = DATABASE()
+	  AND PARTITION_NAME IS NOT NULL
+	ORDER BY TABLE_NAME, PARTITION_ORDINAL_POSITION
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe partitions, %w", err)
+	}
+	defer rows.Close()
+	partitions := []*PartitionDesc{}
+	var last *PartitionDesc
+	for rows.Next() {
+		var schema, table, method, expression, description string
+		pt := &PartitionTable{}
+		if err := rows.Scan(&schema, &table, &pt.Name, &method, &expression, &description); err != nil {
+			return nil, err
+		}
+		if last == nil || last.Table != table {
+			last = &PartitionDesc{
+				Schema:   schema,
+				Table:    table,
+				Strategy: method,
+				Key:      strings.ReplaceAll(expression, "`", ""),
+			}
+			partitions = append(partitions, last)
+		}
+		pt.Schema = schema
+		switch {
+		case description == "":
+		case strings.HasPrefix(method, "RANGE"):
+			pt.Bound = "VALUES LESS THAN (" + description + ")"
+		case strings.HasPrefix(method, "LIST"):
+			pt.Bound = "VALUES IN (" + description + ")"
+		}
+		last.Partitions = append(last.Partitions, pt)
+	}
+	return partitions, rows.Err()
+}
+
+func (db *MySQLDBRepository) Roles(ctx context.Context) ([]string, error) {
+	return queryNames(ctx, db.Conn, `SELECT DISTINCT User FROM mysql.user ORDER BY User`)
+}
+
+func (db *MySQLDBRepository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Conn.ExecContext(ctx, query, args...)
+}
+
+func (db *MySQLDBRepository) ExecWarnings(ctx context.Context, query string, args ...interface{}) (sql.Result, []string, error) {
+	return mysqlExecWarnings(ctx, db.Conn, query, args...)
+}
+
+func (db *MySQLDBRepository) QueryWarnings(ctx context.Context, query string, fn func(*sql.Rows) error, args ...interface{}) ([]string, error) {
+	return mysqlQueryWarnings(ctx, db.Conn, query, fn, args...)
 }
 
-func (db *MySQLDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
-	return db.Conn.QueryContext(ctx, query)
+func (db *MySQLDBRepository) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.Conn.QueryContext(ctx, query, args...)
 }
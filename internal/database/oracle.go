@@ -3,11 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
-	"log"
 	"strconv"
+	"strings"
 
 	_ "github.com/godror/godror"
 	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/logging"
 )
 
 func init() {
@@ -29,8 +30,7 @@ func oracleOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 		return nil, err
 	}
 
-	conn.SetMaxIdleConns(DefaultMaxIdleConns)
-	conn.SetMaxOpenConns(DefaultMaxOpenConns)
+	setConnPool(conn, dbConnCfg)
 
 	return &DBConnection{
 		Conn:   conn,
@@ -151,15 +151,19 @@ func (db *OracleDBRepository) DescribeDatabaseTable(ctx context.Context) ([]*Col
 		ctx,
 		`
 SELECT
-OWNER,
-TABLE_NAME,
-COLUMN_NAME,
-DATA_TYPE,
-NULLABLE,
+c.OWNER,
+c.TABLE_NAME,
+c.COLUMN_NAME,
+c.DATA_TYPE,
+c.NULLABLE,
 '',
-DATA_DEFAULT,
-''
-FROM SYS.ALL_TAB_COLUMNS
+c.DATA_DEFAULT,
+c.VIRTUAL_COLUMN,
+NVL(ic.GENERATION_TYPE, '')
+FROM SYS.ALL_TAB_COLS c
+LEFT JOIN SYS.ALL_TAB_IDENTITY_COLS ic
+ON ic.OWNER = c.OWNER AND ic.TABLE_NAME = c.TABLE_NAME AND ic.COLUMN_NAME = c.COLUMN_NAME
+WHERE c.HIDDEN_COLUMN = 'NO'
 `)
 	if err != nil {
 		return nil, err
@@ -168,6 +172,7 @@ FROM SYS.ALL_TAB_COLUMNS
 	tableInfos := []*ColumnDesc{}
 	for rows.Next() {
 		var tableInfo ColumnDesc
+		var virtual, generation sql.NullString
 		err := rows.Scan(
 			&tableInfo.Schema,
 			&tableInfo.Table,
@@ -176,11 +181,13 @@ FROM SYS.ALL_TAB_COLUMNS
 			&tableInfo.Null,
 			&tableInfo.Key,
 			&tableInfo.Default,
-			&tableInfo.Extra,
+			&virtual,
+			&generation,
 		)
 		if err != nil {
 			return nil, err
 		}
+		setOracleGenerated(&tableInfo, virtual.String, generation.String)
 		tableInfos = append(tableInfos, &tableInfo)
 	}
 	return tableInfos, nil
@@ -191,27 +198,31 @@ func (db *OracleDBRepository) DescribeDatabaseTableBySchema(ctx context.Context,
 		ctx,
 		`
 		SELECT
-		OWNER,
-		TABLE_NAME,
-		COLUMN_NAME,
-		DATA_TYPE,
-		CASE NULLABLE
+		c.OWNER,
+		c.TABLE_NAME,
+		c.COLUMN_NAME,
+		c.DATA_TYPE,
+		CASE c.NULLABLE
 		WHEN 'Y' THEN 'YES'
 		ELSE 'NO'
 		END,
 		'1',
-		DATA_DEFAULT,
-		'1'
-		FROM SYS.ALL_TAB_COLUMNS
-		WHERE OWNER = :1
+		c.DATA_DEFAULT,
+		c.VIRTUAL_COLUMN,
+		NVL(ic.GENERATION_TYPE, '')
+		FROM SYS.ALL_TAB_COLS c
+		LEFT JOIN SYS.ALL_TAB_IDENTITY_COLS ic
+		ON ic.OWNER = c.OWNER AND ic.TABLE_NAME = c.TABLE_NAME AND ic.COLUMN_NAME = c.COLUMN_NAME
+		WHERE c.OWNER = :1 AND c.HIDDEN_COLUMN = 'NO'
 `, schemaName)
 	if err != nil {
-		log.Println("schema", schemaName, err.Error())
+		logging.FromContext(ctx).Warn("cannot describe schema", "schema", schemaName, "err", err)
 		return nil, err
 	}
 	tableInfos := []*ColumnDesc{}
 	for rows.Next() {
 		var tableInfo ColumnDesc
+		var virtual, generation sql.NullString
 		err := rows.Scan(
 			&tableInfo.Schema,
 			&tableInfo.Table,
@@ -220,17 +231,33 @@ func (db *OracleDBRepository) DescribeDatabaseTableBySchema(ctx context.Context,
 			&tableInfo.Null,
 			&tableInfo.Key,
 			&tableInfo.Default,
-			&tableInfo.Extra,
+			&virtual,
+			&generation,
 		)
 		if err != nil {
 			return nil, err
 		}
+		setOracleGenerated(&tableInfo, virtual.String, generation.String)
 		tableInfos = append(tableInfos, &tableInfo)
 	}
 	defer rows.Close()
 	return tableInfos, nil
 }
 
+// setOracleGenerated sets the extra of the identity column of the generation
+// type, or of the virtual column of the expression, which Oracle describes as
+// the default of the column.
+func setOracleGenerated(desc *ColumnDesc, virtual, generation string) {
+	switch {
+	case generation != "":
+		desc.Extra = "GENERATED " + generation + " AS IDENTITY"
+	case virtual == "YES":
+		desc.Extra = "GENERATED ALWAYS AS (" + strings.TrimSpace(desc.Default.String) + ") VIRTUAL"
+		desc.Default = sql.NullString{}
+	}
+	desc.setGenerated()
+}
+
 func (db *OracleDBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 	rows, err := db.Conn.QueryContext(
 		ctx,
@@ -252,16 +279,25 @@ func (db *OracleDBRepository) DescribeForeignKeysBySchema(ctx context.Context, s
 	ORDER BY a.CONSTRAINT_NAME, a.POSITION
 		`, schemaName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 	return parseForeignKeys(rows, schemaName)
 }
 
-func (db *OracleDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
-	return db.Conn.ExecContext(ctx, query)
+func (db *OracleDBRepository) Roles(ctx context.Context) ([]string, error) {
+	return queryNames(ctx, db.Conn, `
+	SELECT username FROM all_users
+	UNION
+	SELECT granted_role FROM user_role_privs
+	ORDER BY 1
+	`)
+}
+
+func (db *OracleDBRepository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Conn.ExecContext(ctx, query, args...)
 }
 
-func (db *OracleDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
-	return db.Conn.QueryContext(ctx, query)
+func (db *OracleDBRepository) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.Conn.QueryContext(ctx, query, args...)
 }
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// PartitionDesc is a table partitioned by the declarative partitioning of
+// PostgreSQL or by the partitioning of MySQL.
+type PartitionDesc struct {
+	Schema string
+	Table  string
+	// Strategy is the method of the partitioning, such as RANGE, LIST, HASH
+	// or KEY
+	Strategy string
+	// Key is the columns or the expression partitioning the table
+	Key        string
+	Partitions []*PartitionTable
+}
+
+// PartitionTable is a partition of a partitioned table. The partitions of
+// PostgreSQL are the tables of their schemas, and the ones of MySQL are not.
+type PartitionTable struct {
+	Schema string
+	Name   string
+	// Bound is the values of the partition, such as FOR VALUES FROM (1) TO (10)
+	Bound string
+}
+
+// PartitionRepository is the repository of the databases whose partitioned
+// tables are cached.
+type PartitionRepository interface {
+	Partitions(ctx context.Context) ([]*PartitionDesc, error)
+}
+
+// Partition returns the partitioned table of the schema, or of any schema if
+// the schema is "".
+func (dc *DBCache) Partition(schemaName, tableName string) (*PartitionDesc, bool) {
+	if dc == nil {
+		return nil, false
+	}
+	for _, p := range dc.Partitions {
+		if schemaName != "" && !dc.MatchIdentifier(schemaName, p.Schema) {
+			continue
+		}
+		if dc.MatchIdentifier(tableName, p.Table) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// PartitionOf returns the partitioned table of which the table of the schema
+// is a partition, and the partition.
+func (dc *DBCache) PartitionOf(schemaName, tableName string) (*PartitionDesc, *PartitionTable, bool) {
+	if dc == nil {
+		return nil, nil, false
+	}
+	for _, p := range dc.Partitions {
+		for _, pt := range p.Partitions {
+			if schemaName != "" && !dc.MatchIdentifier(schemaName, pt.Schema) {
+				continue
+			}
+			if dc.MatchIdentifier(tableName, pt.Name) {
+				return p, pt, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// TableDoc returns the document of the columns of the table, followed by the
+// one of its partitions if it is partitioned or is a partition.
+func (dc *DBCache) TableDoc(tableName string, cols []*ColumnDesc) string {
+	doc := TableDoc(tableName, cols)
+	schemaName := ""
+	if len(cols) > 0 {
+		schemaName = cols[0].Schema
+	}
+	if partitionDoc := dc.PartitionDoc(schemaName, tableName); partitionDoc != "" {
+		doc += "\n" + partitionDoc
+	}
+	return doc
+}
+
+// PartitionDoc returns the document of the strategy, the key and the
+// partitions of the partitioned table, and of the table of which the table is
+// a partition, or "" if the table is neither.
+func (dc *DBCache) PartitionDoc(schemaName, tableName string) string {
+	buf := new(bytes.Buffer)
+	if parent, pt, ok := dc.PartitionOf(schemaName, tableName); ok {
+		fmt.Fprintf(buf, "Partition of `%s`", parent.Table)
+		if pt.Bound != "" {
+			fmt.Fprintf(buf, " %s", pt.Bound)
+		}
+		fmt.Fprintln(buf)
+	}
+	p, ok := dc.Partition(schemaName, tableName)
+	if !ok {
+		return buf.String()
+	}
+	if buf.Len() > 0 {
+		fmt.Fprintln(buf)
+	}
+	fmt.Fprintf(buf, "Partitioned by %s (`%s`)", p.Strategy, p.Key)
+	fmt.Fprintln(buf)
+	if len(p.Partitions) == 0 {
+		return buf.String()
+	}
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "| Partition&nbsp;&nbsp; | Bound&nbsp;&nbsp; |")
+	fmt.Fprintln(buf, "| :-------------------- | :---------------- |")
+	for _, pt := range p.Partitions {
+		fmt.Fprintf(buf, "| `%s` | %s |", pt.Name, markdownCell(Coalesce(pt.Bound, "-")))
+		fmt.Fprintln(buf)
+	}
+	return buf.String()
+}
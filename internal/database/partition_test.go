@@ -0,0 +1,55 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCachePartition(t *testing.T) {
+	cache := NewDBCache("public")
+	cache.Partitions = []*PartitionDesc{
+		{
+			Schema:   "public",
+			Table:    "orders",
+			Strategy: "RANGE",
+			Key:      "ordered_at",
+			Partitions: []*PartitionTable{
+				{Schema: "public", Name: "orders_2023", Bound: "FOR VALUES FROM ('2023-01-01') TO ('2024-01-01')"},
+				{Schema: "public", Name: "orders_2024", Bound: "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')"},
+			},
+		},
+	}
+
+	if _, ok := cache.Partition("", "ORDERS"); !ok {
+		t.Error("orders is not partitioned")
+	}
+	if _, ok := cache.Partition("sales", "orders"); ok {
+		t.Error("unexpected partitioned table of another schema")
+	}
+	parent, pt, ok := cache.PartitionOf("public", "orders_2024")
+	if !ok || parent.Table != "orders" || pt.Name != "orders_2024" {
+		t.Errorf("unexpected partition, %v, %v", parent, pt)
+	}
+	if _, _, ok := cache.PartitionOf("", "orders"); ok {
+		t.Error("orders is not a partition")
+	}
+
+	doc := cache.PartitionDoc("public", "orders")
+	for _, want := range []string{"Partitioned by RANGE (`ordered_at`)", "| `orders_2023` | FOR VALUES FROM ('2023-01-01') TO ('2024-01-01') |"} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("%q is not in the document of the partitioned table:\n%s", want, doc)
+		}
+	}
+	doc = cache.TableDoc("orders_2024", []*ColumnDesc{{ColumnBase: ColumnBase{Schema: "public", Table: "orders_2024", Name: "id"}}})
+	if !strings.Contains(doc, "# `orders_2024` table") || !strings.Contains(doc, "Partition of `orders` FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')") {
+		t.Errorf("unexpected document of the partition:\n%s", doc)
+	}
+	if doc := cache.PartitionDoc("public", "customers"); doc != "" {
+		t.Errorf("unexpected document of the table not partitioned, %q", doc)
+	}
+	// The tables are documented without the cache
+	var none *DBCache
+	if doc := none.TableDoc("orders", nil); doc != TableDoc("orders", nil) {
+		t.Errorf("unexpected document without the cache, %q", doc)
+	}
+}
@@ -0,0 +1,246 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/token"
+)
+
+type placeholderStyle int
+
+const (
+	// ? (positional)
+	placeholderQuestion placeholderStyle = 1 << iota
+	// $1
+	placeholderDollar
+	// :name or :1
+	placeholderColon
+	// @name
+	placeholderAt
+)
+
+var driverPlaceholderStyles = map[dialect.DatabaseDriver]placeholderStyle{
+	dialect.DatabaseDriverPostgreSQL: placeholderDollar,
+	dialect.DatabaseDriverSQLite3:    placeholderQuestion | placeholderColon | placeholderAt,
+	dialect.DatabaseDriverMssql:      placeholderAt,
+	dialect.DatabaseDriverOracle:     placeholderColon,
+}
+
+// Placeholder is a bind parameter in a query. Either Name or Index is set.
+type Placeholder struct {
+	// Name is the name of a named placeholder without the prefix
+	Name string
+	// Index is the 1-based position of a positional placeholder
+	Index int
+	// Text is the placeholder as written in the query
+	Text string
+}
+
+// Placeholders returns the distinct bind parameters of query in the
+// placeholder styles supported by driver. Named placeholders are in order of
+// appearance and positional ones in order of their position.
+func Placeholders(driver dialect.DatabaseDriver, query string) ([]*Placeholder, error) {
+	style, ok := driverPlaceholderStyles[driver]
+	if !ok {
+		style = placeholderQuestion
+	}
+
+	tokens, err := token.NewTokenizer(strings.NewReader(query), &dialect.GenericSQLDialect{}).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+
+	placeholders := []*Placeholder{}
+	seen := map[string]bool{}
+	declared := declaredVariables(tokens)
+	add := func(p *Placeholder) {
+		if seen[p.Text] || declared[strings.ToUpper(p.Text)] {
+			return
+		}
+		seen[p.Text] = true
+		placeholders = append(placeholders, p)
+	}
+	question := 0
+	for i, tok := range tokens {
+		var next *token.Token
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch {
+		case style&placeholderQuestion != 0 && tok.Kind == token.Char && tok.Value == "?":
+			question++
+			if next != nil && next.Kind == token.Number {
+				index, _ := strconv.Atoi(next.Value.(string))
+				add(&Placeholder{Index: index, Text: "?" + next.Value.(string)})
+				continue
+			}
+			placeholders = append(placeholders, &Placeholder{Index: question, Text: "?"})
+		case style&placeholderDollar != 0 && tok.Kind == token.Char && tok.Value == "$":
+			if next != nil && next.Kind == token.Number {
+				index, _ := strconv.Atoi(next.Value.(string))
+				add(&Placeholder{Index: index, Text: "$" + next.Value.(string)})
+			}
+		case style&placeholderColon != 0 && tok.Kind == token.Colon:
+			if next == nil {
+				continue
+			}
+			switch next.Kind {
+			case token.Number:
+				index, _ := strconv.Atoi(next.Value.(string))
+				add(&Placeholder{Index: index, Text: ":" + next.Value.(string)})
+			case token.SQLKeyword:
+				// :NEW and :OLD are the rows of the triggers of Oracle
+				if w, ok := next.Value.(*token.SQLWord); ok && w.QuoteStyle == 0 && !triggerRows[strings.ToUpper(w.Value)] {
+					add(&Placeholder{Name: w.Value, Text: ":" + w.Value})
+				}
+			}
+		case style&placeholderAt != 0 && tok.Kind == token.SQLKeyword:
+			w, ok := tok.Value.(*token.SQLWord)
+			if !ok || w.QuoteStyle != 0 || !strings.HasPrefix(w.Value, "@") {
+				continue
+			}
+			// @@ are system variables and a lone @ is an operator
+			name := w.Value[1:]
+			if name == "" || strings.HasPrefix(name, "@") {
+				continue
+			}
+			add(&Placeholder{Name: name, Text: w.Value})
+		}
+	}
+
+	positional := true
+	for _, p := range placeholders {
+		if p.Name != "" {
+			positional = false
+		}
+	}
+	if positional {
+		sort.SliceStable(placeholders, func(i, j int) bool {
+			return placeholders[i].Index < placeholders[j].Index
+		})
+	}
+	return placeholders, nil
+}
+
+var triggerRows = map[string]bool{
+	"NEW": true,
+	"OLD": true,
+}
+
+// declarationEnds are the statements which end DECLARE of MSSQL without a
+// semicolon.
+var declarationEnds = map[string]bool{
+	"SELECT":  true,
+	"INSERT":  true,
+	"UPDATE":  true,
+	"DELETE":  true,
+	"SET":     true,
+	"IF":      true,
+	"WHILE":   true,
+	"BEGIN":   true,
+	"EXEC":    true,
+	"EXECUTE": true,
+	"RETURN":  true,
+	"PRINT":   true,
+}
+
+// declaredVariables returns the @ variables which the query declares by
+// DECLARE and by the parameters of CREATE PROCEDURE and FUNCTION of MSSQL,
+// which are not bind parameters. The names are upper case.
+func declaredVariables(tokens []*token.Token) map[string]bool {
+	declared := map[string]bool{}
+	declaring, routine, expectName := false, false, false
+	depth := 0
+	prev := ""
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case token.Whitespace, token.Comment, token.MultilineComment:
+			continue
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+		case token.Comma:
+			expectName = declaring && depth == 0
+		case token.Semicolon:
+			declaring, routine, expectName = false, false, false
+		case token.SQLKeyword:
+			w, ok := tok.Value.(*token.SQLWord)
+			if !ok || w.QuoteStyle != 0 {
+				break
+			}
+			word := strings.ToUpper(w.Value)
+			switch {
+			case strings.HasPrefix(word, "@"):
+				if routine || (declaring && expectName) {
+					declared[word] = true
+				}
+			case word == "DECLARE":
+				declaring, expectName, depth = true, true, 0
+				prev = word
+				continue
+			case (word == "PROCEDURE" || word == "PROC" || word == "FUNCTION") && (prev == "CREATE" || prev == "ALTER"):
+				routine, depth = true, 0
+			case word == "AS" && routine && depth == 0:
+				routine = false
+			case declaring && depth == 0 && declarationEnds[word]:
+				declaring = false
+			}
+			expectName = false
+			prev = word
+			continue
+		}
+		expectName = expectName && tok.Kind == token.Comma
+		prev = ""
+	}
+	return declared
+}
+
+// BindParams returns the arguments for placeholders from params, which is
+// either a list of values in the order of the placeholders or a map keyed
+// by placeholder name or position.
+func BindParams(placeholders []*Placeholder, params interface{}) ([]interface{}, error) {
+	args := []interface{}{}
+	missing := []string{}
+	for i, p := range placeholders {
+		var v interface{}
+		var ok bool
+		switch params := params.(type) {
+		case []interface{}:
+			idx := i
+			if p.Index > 0 {
+				idx = p.Index - 1
+			}
+			if idx < len(params) {
+				v, ok = params[idx], true
+			}
+		case map[string]interface{}:
+			if p.Name != "" {
+				v, ok = params[p.Name]
+				if !ok {
+					v, ok = params[p.Text]
+				}
+			} else {
+				v, ok = params[strconv.Itoa(p.Index)]
+			}
+		}
+		if !ok {
+			missing = append(missing, p.Text)
+			continue
+		}
+		if p.Name != "" {
+			args = append(args, sql.Named(p.Name, v))
+		} else {
+			args = append(args, v)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("required parameters were not provided: %s", strings.Join(missing, ", "))
+	}
+	return args, nil
+}
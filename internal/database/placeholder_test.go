@@ -0,0 +1,176 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver dialect.DatabaseDriver
+		query  string
+		want   []*Placeholder
+	}{
+		{
+			name:   "question",
+			driver: dialect.DatabaseDriverMySQL,
+			query:  "SELECT * FROM city WHERE id = ? AND name = '?' AND countrycode = ? -- ?",
+			want: []*Placeholder{
+				{Index: 1, Text: "?"},
+				{Index: 2, Text: "?"},
+			},
+		},
+		{
+			name:   "dollar",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			query:  "SELECT * FROM city WHERE id = $2 AND population > $1 OR id = $2",
+			want: []*Placeholder{
+				{Index: 1, Text: "$1"},
+				{Index: 2, Text: "$2"},
+			},
+		},
+		{
+			name:   "dollar ignores casts",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			query:  "SELECT id::text FROM city",
+			want:   []*Placeholder{},
+		},
+		{
+			name:   "colon",
+			driver: dialect.DatabaseDriverOracle,
+			query:  "SELECT * FROM city WHERE id = :id AND name = :name AND id <> :id",
+			want: []*Placeholder{
+				{Name: "id", Text: ":id"},
+				{Name: "name", Text: ":name"},
+			},
+		},
+		{
+			name:   "at",
+			driver: dialect.DatabaseDriverMssql,
+			query:  "SELECT @@VERSION, * FROM city WHERE id = @id",
+			want: []*Placeholder{
+				{Name: "id", Text: "@id"},
+			},
+		},
+		{
+			name:   "declared variables",
+			driver: dialect.DatabaseDriverMssql,
+			query:  "DECLARE @n INT = 1, @t TABLE (id INT); SELECT @n, @id FROM city WHERE id > @N",
+			want: []*Placeholder{
+				{Name: "id", Text: "@id"},
+			},
+		},
+		{
+			name:   "declared variables without semicolon",
+			driver: dialect.DatabaseDriverMssql,
+			query:  "DECLARE @n INT SELECT @n, @id",
+			want: []*Placeholder{
+				{Name: "id", Text: "@id"},
+			},
+		},
+		{
+			name:   "procedure parameters",
+			driver: dialect.DatabaseDriverMssql,
+			query:  "CREATE PROCEDURE dbo.city_by_id @id INT, @name NVARCHAR(10) AS SELECT * FROM city WHERE id = @id AND name = @name AND code = @code",
+			want: []*Placeholder{
+				{Name: "code", Text: "@code"},
+			},
+		},
+		{
+			name:   "trigger rows",
+			driver: dialect.DatabaseDriverOracle,
+			query:  "CREATE TRIGGER city_audit BEFORE UPDATE ON city FOR EACH ROW BEGIN :new.updated := SYSDATE; INSERT INTO audit VALUES (:OLD.id, :id); END;",
+			want: []*Placeholder{
+				{Name: "id", Text: ":id"},
+			},
+		},
+		{
+			name:   "mysql variables are not placeholders",
+			driver: dialect.DatabaseDriverMySQL,
+			query:  "SELECT @total",
+			want:   []*Placeholder{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Placeholders(tt.driver, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	positional := []*Placeholder{
+		{Index: 1, Text: "$1"},
+		{Index: 2, Text: "$2"},
+	}
+	named := []*Placeholder{
+		{Name: "id", Text: ":id"},
+		{Name: "name", Text: ":name"},
+	}
+	tests := []struct {
+		name         string
+		placeholders []*Placeholder
+		params       interface{}
+		want         []interface{}
+		wantErr      bool
+	}{
+		{
+			name:         "positional list",
+			placeholders: positional,
+			params:       []interface{}{float64(1), "Kabul"},
+			want:         []interface{}{float64(1), "Kabul"},
+		},
+		{
+			name:         "positional map",
+			placeholders: positional,
+			params:       map[string]interface{}{"2": "Kabul", "1": float64(1)},
+			want:         []interface{}{float64(1), "Kabul"},
+		},
+		{
+			name:         "named map",
+			placeholders: named,
+			params:       map[string]interface{}{"id": float64(1), ":name": "Kabul"},
+			want:         []interface{}{sql.Named("id", float64(1)), sql.Named("name", "Kabul")},
+		},
+		{
+			name:         "named list",
+			placeholders: named,
+			params:       []interface{}{float64(1), "Kabul"},
+			want:         []interface{}{sql.Named("id", float64(1)), sql.Named("name", "Kabul")},
+		},
+		{
+			name:         "missing",
+			placeholders: named,
+			params:       map[string]interface{}{"id": float64(1)},
+			wantErr:      true,
+		},
+		{
+			name:         "no params",
+			placeholders: positional,
+			params:       nil,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BindParams(tt.placeholders, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BindParams() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration written as a string like "30s" or "5m" in the
+// config.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+func (d *Duration) set(v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(parsed)
+	case float64:
+		// A number is in seconds
+		*d = Duration(v * float64(time.Second))
+	case int:
+		*d = Duration(time.Duration(v) * time.Second)
+	default:
+		return fmt.Errorf("invalid duration %v", v)
+	}
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (c *DBConfig) validatePool() error {
+	if c.ConnMaxLifetime < 0 {
+		return errors.New("invalid: connections[].connMaxLifetime")
+	}
+	if c.ConnMaxIdleTime < 0 {
+		return errors.New("invalid: connections[].connMaxIdleTime")
+	}
+	return nil
+}
+
+// setConnPool applies the pool settings of the connection to conn. The
+// counts default to DefaultMaxIdleConns and DefaultMaxOpenConns, and
+// connections are reused forever unless the durations are set.
+func setConnPool(conn *sql.DB, connCfg *DBConfig) {
+	maxIdleConns := DefaultMaxIdleConns
+	if connCfg.MaxIdleConns != 0 {
+		maxIdleConns = connCfg.MaxIdleConns
+	}
+	maxOpenConns := DefaultMaxOpenConns
+	if connCfg.MaxOpenConns != 0 {
+		maxOpenConns = connCfg.MaxOpenConns
+	}
+	conn.SetMaxIdleConns(maxIdleConns)
+	conn.SetMaxOpenConns(maxOpenConns)
+	conn.SetConnMaxLifetime(time.Duration(connCfg.ConnMaxLifetime))
+	conn.SetConnMaxIdleTime(time.Duration(connCfg.ConnMaxIdleTime))
+}
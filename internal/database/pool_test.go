@@ -0,0 +1,72 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestDuration_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		json    string
+		want    Duration
+		wantErr bool
+	}{
+		{name: "string", yaml: `30m`, json: `"30m"`, want: Duration(30 * time.Minute)},
+		{name: "seconds", yaml: `90`, json: `90`, want: Duration(90 * time.Second)},
+		{name: "invalid", yaml: `soon`, json: `"soon"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var y Duration
+			if err := yaml.Unmarshal([]byte(tt.yaml), &y); (err != nil) != tt.wantErr {
+				t.Fatalf("yaml error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var j Duration
+			if err := json.Unmarshal([]byte(tt.json), &j); (err != nil) != tt.wantErr {
+				t.Fatalf("json error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if y != tt.want || j != tt.want {
+				t.Errorf("got %v (yaml), %v (json), want %v", time.Duration(y), time.Duration(j), time.Duration(tt.want))
+			}
+		})
+	}
+}
+
+func TestSetConnPool(t *testing.T) {
+	open := func(t *testing.T) *sql.DB {
+		t.Helper()
+		conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	conn := open(t)
+	setConnPool(conn, &DBConfig{})
+	if got := conn.Stats().MaxOpenConnections; got != DefaultMaxOpenConns {
+		t.Errorf("default max open connections = %d, want %d", got, DefaultMaxOpenConns)
+	}
+
+	conn = open(t)
+	setConnPool(conn, &DBConfig{MaxOpenConns: 2, MaxIdleConns: 1})
+	if got := conn.Stats().MaxOpenConnections; got != 2 {
+		t.Errorf("max open connections = %d, want 2", got)
+	}
+
+	cfg := &DBConfig{Driver: "sqlite3", ConnMaxLifetime: Duration(-time.Second)}
+	if err := cfg.Validate(); err == nil {
+		t.Error("negative connMaxLifetime must be invalid")
+	}
+}
@@ -3,8 +3,9 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net"
 	"net/url"
 	"sort"
@@ -15,6 +16,7 @@ import (
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/stdlib"
 	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/logging"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -28,31 +30,37 @@ func postgreSQLOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 		conn    *sql.DB
 		sshConn *ssh.Client
 	)
+	if dbConnCfg.CloudSQLCfg != nil {
+		return openPostgreSQLCloudSQL(dbConnCfg)
+	}
 	dsn, err := genPostgresConfig(dbConnCfg)
 	if err != nil {
 		return nil, err
 	}
 
 	if dbConnCfg.SSHCfg != nil {
-		dbConn, dbSSHConn, err := openPostgreSQLViaSSH(dsn, dbConnCfg.SSHCfg)
+		dbConn, dbSSHConn, err := openPostgreSQLViaSSH(dsn, dbConnCfg)
 		if err != nil {
 			return nil, err
 		}
 		conn = dbConn
 		sshConn = dbSSHConn
 	} else {
-		dbConn, err := sql.Open("pgx", dsn)
+		conf, err := genPostgresConnConfig(dsn, dbConnCfg.TLSCfg)
 		if err != nil {
 			return nil, err
 		}
-		conn = dbConn
+		opts, err := postgresOpenOptions(conf, dbConnCfg)
+		if err != nil {
+			return nil, err
+		}
+		conn = stdlib.OpenDB(*conf, opts...)
 	}
 	if err = conn.Ping(); err != nil {
 		return nil, err
 	}
 
-	conn.SetMaxIdleConns(DefaultMaxIdleConns)
-	conn.SetMaxOpenConns(DefaultMaxOpenConns)
+	setConnPool(conn, dbConnCfg)
 
 	return &DBConnection{
 		Conn:    conn,
@@ -60,27 +68,142 @@ func postgreSQLOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 	}, nil
 }
 
-func openPostgreSQLViaSSH(dsn string, sshCfg *SSHConfig) (*sql.DB, *ssh.Client, error) {
-	sshConfig, err := sshCfg.ClientConfig()
+func openPostgreSQLViaSSH(dsn string, connCfg *DBConfig) (*sql.DB, *ssh.Client, error) {
+	conf, err := genPostgresConnConfig(dsn, connCfg.TLSCfg)
 	if err != nil {
 		return nil, nil, err
 	}
-	sshConn, err := ssh.Dial("tcp", sshCfg.Endpoint(), sshConfig)
+	sshConn, err := connCfg.SSHCfg.Dial()
+	if err != nil {
+		return nil, nil, err
+	}
+	conf.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return sshConn.Dial(network, addr)
+	}
+	opts, err := postgresOpenOptions(conf, connCfg)
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot ssh dial, %w", err)
+		sshConn.Close()
+		return nil, nil, err
+	}
+
+	conn := stdlib.OpenDB(*conf, opts...)
+
+	return conn, sshConn, nil
+}
+
+// postgresOpenOptions returns the options to set a fresh auth token as the
+// password of each new connection, or the Kerberos credentials to use.
+func postgresOpenOptions(conf *pgx.ConnConfig, connCfg *DBConfig) ([]stdlib.OptionOpenDB, error) {
+	var tokenFn func(ctx context.Context) (string, error)
+	switch connCfg.AuthType {
+	case AuthTypeAWSIAM:
+		endpoint := net.JoinHostPort(conf.Host, strconv.Itoa(int(conf.Port)))
+		token := newRDSAuthToken(endpoint, conf.User, connCfg.AWSCfg)
+		tokenFn = func(ctx context.Context) (string, error) {
+			password, err := token.Get(ctx)
+			if err != nil {
+				return "", fmt.Errorf("cannot generate RDS auth token, %w", err)
+			}
+			return password, nil
+		}
+	case AuthTypeAzureAD:
+		token, err := newAzureADToken(connCfg.AzureADCfg, dialect.DatabaseDriverPostgreSQL)
+		if err != nil {
+			return nil, err
+		}
+		tokenFn = token.Get
+	case AuthTypeKerberos:
+		kerberosCfg := connCfg.KerberosCfg
+		if kerberosCfg == nil {
+			kerberosCfg = &KerberosConfig{}
+		}
+		newGSS := func() (gssClient, error) {
+			return newKerberosGSS(kerberosCfg)
+		}
+		return []stdlib.OptionOpenDB{
+			stdlib.OptionBeforeConnect(func(ctx context.Context, c *pgx.ConnConfig) error {
+				// The connection is authenticated with its own credentials
+				c.BuildFrontend = kerberosBuildFrontend(c.BuildFrontend, postgresKerberosSPN(kerberosCfg, c.Host), newGSS)
+				return nil
+			}),
+		}, nil
+	default:
+		return nil, nil
 	}
+	return []stdlib.OptionOpenDB{
+		stdlib.OptionBeforeConnect(func(ctx context.Context, c *pgx.ConnConfig) error {
+			password, err := tokenFn(ctx)
+			if err != nil {
+				return err
+			}
+			c.Password = password
+			return nil
+		}),
+	}, nil
+}
 
+func openPostgreSQLCloudSQL(connCfg *DBConfig) (*DBConnection, error) {
+	c := *connCfg
+	c.Proto = ProtoTCP
+	c.Host = "localhost"
+	dsn, err := genPostgresConfig(&c)
+	if err != nil {
+		return nil, err
+	}
 	conf, err := pgx.ParseConfig(dsn)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	dialer, err := newCloudSQLDialer(context.Background(), connCfg.CloudSQLCfg)
+	if err != nil {
+		return nil, err
 	}
 	conf.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
-		return sshConn.Dial(network, addr)
+		return dialer.Dial(ctx)
+	}
+	// The dialer encrypts the connection
+	conf.TLSConfig = nil
+	conf.Fallbacks = nil
+	conf.OnNotice = postgresNotices.onNotice
+
+	opts, err := postgresOpenOptions(conf, connCfg)
+	if err != nil {
+		dialer.Close()
+		return nil, err
+	}
+	conn := stdlib.OpenDB(*conf, opts...)
+	if err = conn.Ping(); err != nil {
+		conn.Close()
+		dialer.Close()
+		return nil, err
 	}
 
-	conn := stdlib.OpenDB(*conf)
+	setConnPool(conn, connCfg)
 
-	return conn, sshConn, nil
+	return &DBConnection{
+		Conn:           conn,
+		cloudSQLDialer: dialer,
+	}, nil
+}
+
+// genPostgresConnConfig parses dsn and overrides the TLS setting with tlsCfg
+// if it is set. The notices of the connections are collected.
+func genPostgresConnConfig(dsn string, tlsCfg *TLSConfig) (*pgx.ConnConfig, error) {
+	conf, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	conf.OnNotice = postgresNotices.onNotice
+	if tlsCfg != nil {
+		tlsConfig, err := tlsCfg.Config(conf.Host)
+		if err != nil {
+			return nil, err
+		}
+		conf.TLSConfig = tlsConfig
+		// Do not fall back to a plain connection
+		conf.Fallbacks = nil
+	}
+	return conf, nil
 }
 
 type PostgreSQLDBRepository struct {
@@ -111,7 +234,7 @@ func (db *PostgreSQLDBRepository) Databases(ctx context.Context) ([]string, erro
 	SELECT datname FROM pg_database
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	databases := []string{}
@@ -137,6 +260,26 @@ func (db *PostgreSQLDBRepository) CurrentSchema(ctx context.Context) (string, er
 	return "", nil
 }
 
+// SearchPath returns the schemas of the effective search_path of the session,
+// which the settings of the user and of the database have set when it was
+// connected. $user is replaced and the schemas not existing are left out.
+func (db *PostgreSQLDBRepository) SearchPath(ctx context.Context) ([]string, error) {
+	rows, err := db.Conn.QueryContext(ctx, "SELECT unnest(current_schemas(false))")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	schemas := []string{}
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, rows.Err()
+}
+
 func (db *PostgreSQLDBRepository) Schemas(ctx context.Context) ([]string, error) {
 	rows, err := db.Conn.QueryContext(
 		ctx,
@@ -144,7 +287,7 @@ func (db *PostgreSQLDBRepository) Schemas(ctx context.Context) ([]string, error)
 	SELECT schema_name FROM information_schema.schemata
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	databases := []string{}
@@ -206,7 +349,7 @@ func (db *PostgreSQLDBRepository) Tables(ctx context.Context) ([]string, error)
 	  table_name
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tables := []string{}
@@ -221,7 +364,7 @@ func (db *PostgreSQLDBRepository) Tables(ctx context.Context) ([]string, error)
 }
 
 func (db *PostgreSQLDBRepository) DescribeDatabaseTable(ctx context.Context) ([]*ColumnDesc, error) {
-	log.Println("repository: describing all database tables")
+	logging.FromContext(ctx).Debug("repository: describing all database tables")
 	rows, err := db.Conn.QueryContext(
 		ctx,
 		`
@@ -231,19 +374,15 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTable(ctx context.Context) ([]
 		CASE WHEN bt.typelem <> 0::oid
 		    AND bt.typlen = '-1'::integer THEN
 		    'ARRAY'::text
-		WHEN nbt.nspname = 'pg_catalog'::name THEN
-		    format_type(t.typbasetype, NULL::integer)
 		ELSE
-		    'USER-DEFINED'::text
+		    format_type(t.typbasetype, NULL::integer)
 		END
 	    ELSE
 		CASE WHEN t.typelem <> 0::oid
 		    AND t.typlen = '-1'::integer THEN
 		    'ARRAY'::text
-		WHEN nt.nspname = 'pg_catalog'::name THEN
-		    format_type(a.atttypid, NULL::integer)
 		ELSE
-		    'USER-DEFINED'::text
+		    format_type(a.atttypid, NULL::integer)
 		END
 	    END::information_schema.character_data AS data_type, CASE WHEN a.attnotnull
 		OR t.typtype = 'd'::"char"
@@ -260,7 +399,18 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTable(ctx context.Context) ([]
 	    ELSE
 		NULL::text
 	    END::information_schema.character_data AS column_default,
-	    ''
+	    CASE WHEN a.attidentity = 'a'::"char" THEN
+		'GENERATED ALWAYS AS IDENTITY'
+	    WHEN a.attidentity = 'd'::"char" THEN
+		'GENERATED BY DEFAULT AS IDENTITY'
+	    WHEN a.attgenerated <> ''::"char" THEN
+		'GENERATED ALWAYS AS (' || pg_get_expr(ad.adbin, ad.adrelid) || ') STORED'
+	    WHEN pg_get_expr(ad.adbin, ad.adrelid) LIKE 'nextval(%' THEN
+		'serial'
+	    ELSE
+		''
+	    END AS extra,
+	    COALESCE(col_description(c1.oid, a.attnum), '') AS column_comment
 	FROM pg_catalog.pg_class c1
 	    JOIN pg_catalog.pg_attribute a ON a.attrelid = c1.oid
 	    LEFT JOIN (pg_type t
@@ -277,7 +427,7 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTable(ctx context.Context) ([]
 	ORDER BY table_name, a.attnum
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tableInfos := []*ColumnDesc{}
@@ -292,17 +442,19 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTable(ctx context.Context) ([]
 			&tableInfo.Key,
 			&tableInfo.Default,
 			&tableInfo.Extra,
+			&tableInfo.Comment,
 		)
 		if err != nil {
 			return nil, err
 		}
+		tableInfo.setGenerated()
 		tableInfos = append(tableInfos, &tableInfo)
 	}
 	return tableInfos, nil
 }
 
 func (db *PostgreSQLDBRepository) DescribeDatabaseTableBySchema(ctx context.Context, schemaName string) ([]*ColumnDesc, error) {
-	log.Printf("repository: describing database tables in schema %s", schemaName)
+	logging.FromContext(ctx).Debug("repository: describing database tables", "schema", schemaName)
 
 	rows, err := db.Conn.QueryContext(
 		ctx,
@@ -313,19 +465,15 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTableBySchema(ctx context.Cont
 		CASE WHEN bt.typelem <> 0::oid
 		    AND bt.typlen = '-1'::integer THEN
 		    'ARRAY'::text
-		WHEN nbt.nspname = 'pg_catalog'::name THEN
-		    format_type(t.typbasetype, NULL::integer)
 		ELSE
-		    'USER-DEFINED'::text
+		    format_type(t.typbasetype, NULL::integer)
 		END
 	    ELSE
 		CASE WHEN t.typelem <> 0::oid
 		    AND t.typlen = '-1'::integer THEN
 		    'ARRAY'::text
-		WHEN nt.nspname = 'pg_catalog'::name THEN
-		    format_type(a.atttypid, NULL::integer)
 		ELSE
-		    'USER-DEFINED'::text
+		    format_type(a.atttypid, NULL::integer)
 		END
 	    END::information_schema.character_data AS data_type, CASE WHEN a.attnotnull
 		OR t.typtype = 'd'::"char"
@@ -342,7 +490,18 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTableBySchema(ctx context.Cont
 	    ELSE
 		NULL::text
 	    END::information_schema.character_data AS column_default,
-	    ''
+	    CASE WHEN a.attidentity = 'a'::"char" THEN
+		'GENERATED ALWAYS AS IDENTITY'
+	    WHEN a.attidentity = 'd'::"char" THEN
+		'GENERATED BY DEFAULT AS IDENTITY'
+	    WHEN a.attgenerated <> ''::"char" THEN
+		'GENERATED ALWAYS AS (' || pg_get_expr(ad.adbin, ad.adrelid) || ') STORED'
+	    WHEN pg_get_expr(ad.adbin, ad.adrelid) LIKE 'nextval(%' THEN
+		'serial'
+	    ELSE
+		''
+	    END AS extra,
+	    COALESCE(col_description(c1.oid, a.attnum), '') AS column_comment
 	FROM pg_catalog.pg_class c1
 	    JOIN pg_catalog.pg_attribute a ON a.attrelid = c1.oid
 	    LEFT JOIN (pg_type t
@@ -360,7 +519,7 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTableBySchema(ctx context.Cont
 	ORDER BY table_name, a.attnum
 	`, schemaName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tableInfos := []*ColumnDesc{}
@@ -375,17 +534,19 @@ func (db *PostgreSQLDBRepository) DescribeDatabaseTableBySchema(ctx context.Cont
 			&tableInfo.Key,
 			&tableInfo.Default,
 			&tableInfo.Extra,
+			&tableInfo.Comment,
 		)
 		if err != nil {
 			return nil, err
 		}
+		tableInfo.setGenerated()
 		tableInfos = append(tableInfos, &tableInfo)
 	}
 	return tableInfos, nil
 }
 
 func (db *PostgreSQLDBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
-	log.Printf("repository: describing foreign keys in schema %s", schemaName)
+	logging.FromContext(ctx).Debug("repository: describing foreign keys", "schema", schemaName)
 
 	rows, err := db.Conn.QueryContext(
 		ctx,
@@ -404,18 +565,206 @@ func (db *PostgreSQLDBRepository) DescribeForeignKeysBySchema(ctx context.Contex
 		ORDER BY constraint_name, a1.attnum;
 		`, schemaName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 	return parseForeignKeys(rows, schemaName)
 }
 
-func (db *PostgreSQLDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
-	return db.Conn.ExecContext(ctx, query)
+func (db *PostgreSQLDBRepository) Roles(ctx context.Context) ([]string, error) {
+	return queryNames(ctx, db.Conn, `SELECT rolname FROM pg_roles ORDER BY rolname`)
 }
 
-func (db *PostgreSQLDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
-	return db.Conn.QueryContext(ctx, query)
+// Partitions returns the tables partitioned by the declarative partitioning
+// and their partitions, which may be partitioned too.
+func (db *PostgreSQLDBRepository) Partitions(ctx context.Context) ([]*PartitionDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT n.nspname, c.relname, pg_get_partkeydef(c.oid)
+	FROM pg_catalog.pg_partitioned_table p
+	    JOIN pg_catalog.pg_class c ON c.oid = p.partrelid
+	    JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+	ORDER BY n.nspname, c.relname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe partitioned tables, %w", err)
+	}
+	defer rows.Close()
+	partitions := []*PartitionDesc{}
+	partitionMap := map[string]*PartitionDesc{}
+	for rows.Next() {
+		var p PartitionDesc
+		var keyDef string
+		if err := rows.Scan(&p.Schema, &p.Table, &keyDef); err != nil {
+			return nil, err
+		}
+		// RANGE (ordered_at)
+		p.Strategy, p.Key, _ = strings.Cut(keyDef, " ")
+		p.Key = strings.TrimSuffix(strings.TrimPrefix(p.Key, "("), ")")
+		partitions = append(partitions, &p)
+		partitionMap[p.Schema+"."+p.Table] = &p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	children, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT pn.nspname, pc.relname, n.nspname, c.relname, pg_get_expr(c.relpartbound, c.oid)
+	FROM pg_catalog.pg_inherits i
+	    JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid
+	    JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+	    JOIN pg_catalog.pg_class pc ON pc.oid = i.inhparent
+	    JOIN pg_catalog.pg_namespace pn ON pn.oid = pc.relnamespace
+	WHERE c.relispartition
+	ORDER BY pn.nspname, pc.relname, n.nspname, c.relname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe partitions, %w", err)
+	}
+	defer children.Close()
+	for children.Next() {
+		var schema, table string
+		var pt PartitionTable
+		if err := children.Scan(&schema, &table, &pt.Schema, &pt.Name, &pt.Bound); err != nil {
+			return nil, err
+		}
+		if p, ok := partitionMap[schema+"."+table]; ok {
+			p.Partitions = append(p.Partitions, &pt)
+		}
+	}
+	if err := children.Err(); err != nil {
+		return nil, err
+	}
+	return partitions, nil
+}
+
+// Types returns the enums, the domains, the composite types and the types of
+// the extensions of the schemas of the users.
+func (db *PostgreSQLDBRepository) Types(ctx context.Context) ([]*TypeDesc, error) {
+	rows, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT
+	    n.nspname,
+	    t.typname,
+	    t.typtype,
+	    CASE WHEN t.typtype = 'd' THEN
+		format_type(t.typbasetype, t.typtypmod)
+	    ELSE
+		''
+	    END
+	FROM pg_catalog.pg_type t
+	    JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace
+	    LEFT JOIN pg_catalog.pg_class c ON c.oid = t.typrelid
+	WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+	    AND n.nspname NOT LIKE 'pg_toast%'
+	    AND t.typtype IN ('b', 'c', 'd', 'e')
+	    AND t.typcategory <> 'A'
+	    AND (t.typtype <> 'c' OR c.relkind = 'c')
+	ORDER BY n.nspname, t.typname
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe types, %w", err)
+	}
+	defer rows.Close()
+	types := []*TypeDesc{}
+	typeMap := map[string]*TypeDesc{}
+	for rows.Next() {
+		var t TypeDesc
+		var kind string
+		if err := rows.Scan(&t.Schema, &t.Name, &kind, &t.BaseType); err != nil {
+			return nil, err
+		}
+		switch kind {
+		case "b":
+			t.Kind = TypeKindBase
+		case "c":
+			t.Kind = TypeKindComposite
+		case "d":
+			t.Kind = TypeKindDomain
+		case "e":
+			t.Kind = TypeKindEnum
+		}
+		types = append(types, &t)
+		typeMap[t.Schema+"."+t.Name] = &t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	labels, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT t.typnamespace::regnamespace::text, t.typname, e.enumlabel
+	FROM pg_catalog.pg_enum e
+	    JOIN pg_catalog.pg_type t ON t.oid = e.enumtypid
+	ORDER BY t.typnamespace, t.typname, e.enumsortorder
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe enum labels, %w", err)
+	}
+	defer labels.Close()
+	for labels.Next() {
+		var schema, name, label string
+		if err := labels.Scan(&schema, &name, &label); err != nil {
+			return nil, err
+		}
+		if t, ok := typeMap[schema+"."+name]; ok {
+			t.Labels = append(t.Labels, label)
+		}
+	}
+	if err := labels.Err(); err != nil {
+		return nil, err
+	}
+
+	fields, err := db.Conn.QueryContext(
+		ctx,
+		`
+	SELECT t.typnamespace::regnamespace::text, t.typname, a.attname, format_type(a.atttypid, a.atttypmod)
+	FROM pg_catalog.pg_type t
+	    JOIN pg_catalog.pg_class c ON c.oid = t.typrelid
+	    JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid
+	WHERE t.typtype = 'c'
+	    AND c.relkind = 'c'
+	    AND a.attnum > 0
+	    AND NOT a.attisdropped
+	ORDER BY t.typnamespace, t.typname, a.attnum
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe composite types, %w", err)
+	}
+	defer fields.Close()
+	for fields.Next() {
+		var schema, name string
+		var field TypeField
+		if err := fields.Scan(&schema, &name, &field.Name, &field.Type); err != nil {
+			return nil, err
+		}
+		if t, ok := typeMap[schema+"."+name]; ok {
+			t.Fields = append(t.Fields, &field)
+		}
+	}
+	return types, fields.Err()
+}
+
+func (db *PostgreSQLDBRepository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Conn.ExecContext(ctx, query, args...)
+}
+
+func (db *PostgreSQLDBRepository) ExecWarnings(ctx context.Context, query string, args ...interface{}) (sql.Result, []string, error) {
+	return postgresExecWarnings(ctx, db.Conn, query, args...)
+}
+
+func (db *PostgreSQLDBRepository) QueryWarnings(ctx context.Context, query string, fn func(*sql.Rows) error, args ...interface{}) ([]string, error) {
+	return postgresQueryWarnings(ctx, db.Conn, query, fn, args...)
+}
+
+func (db *PostgreSQLDBRepository) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.Conn.QueryContext(ctx, query, args...)
 }
 
 func genPostgresConfig(connCfg *DBConfig) (string, error) {
@@ -534,3 +883,31 @@ func (s *scanner) SkipSpaces() (rune, bool) {
 	}
 	return r, ok
 }
+
+func (db *PostgreSQLDBRepository) CopyFrom(ctx context.Context, query string, r io.Reader) (int64, error) {
+	conn, err := db.Conn.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("cannot get connection, %w", err)
+	}
+	defer conn.Close()
+	return copyFrom(ctx, conn, query, r)
+}
+
+// copyFrom runs COPY ... FROM STDIN by the protocol of PostgreSQL on the
+// connection opened by pgx.
+func copyFrom(ctx context.Context, conn *sql.Conn, query string, r io.Reader) (int64, error) {
+	var rowsAffected int64
+	err := conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("COPY FROM STDIN is supported only by postgresql")
+		}
+		tag, err := c.Conn().PgConn().CopyFrom(ctx, r, query)
+		if err != nil {
+			return err
+		}
+		rowsAffected = tag.RowsAffected()
+		return nil
+	})
+	return rowsAffected, err
+}
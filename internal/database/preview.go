@@ -0,0 +1,476 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/token"
+)
+
+// ErrNotPreviewable is returned for the statements other than UPDATE and
+// DELETE, whose rows are not previewed.
+var ErrNotPreviewable = errors.New("only UPDATE and DELETE can be previewed")
+
+// previewClauses end the clauses of UPDATE and DELETE.
+var previewClauses = map[string]bool{
+	"FROM":      true,
+	"USING":     true,
+	"SET":       true,
+	"WHERE":     true,
+	"ORDER":     true,
+	"LIMIT":     true,
+	"RETURNING": true,
+	"OUTPUT":    true,
+}
+
+// previewModifiers are the modifiers of MySQL after UPDATE and DELETE.
+var previewModifiers = map[string]bool{
+	"LOW_PRIORITY": true,
+	"QUICK":        true,
+	"IGNORE":       true,
+}
+
+// previewWriteWords change the rows, which the queries of WITH must not have
+// as they are run by the preview.
+var previewWriteWords = map[string]bool{
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+	"MERGE":  true,
+}
+
+// previewJoinWords start the joins of the tables.
+var previewJoinWords = map[string]bool{
+	"JOIN":          true,
+	"INNER":         true,
+	"LEFT":          true,
+	"RIGHT":         true,
+	"FULL":          true,
+	"CROSS":         true,
+	"NATURAL":       true,
+	"STRAIGHT_JOIN": true,
+}
+
+// previewStatement is the clauses of an UPDATE or a DELETE, the texts of the
+// query between the keywords.
+type previewStatement struct {
+	text string
+	toks []*token.Token
+	// clauses are the indexes of the tokens of the keywords of the clauses
+	clauses map[string]int
+	// starts are the indexes of the tokens of the keywords in the order
+	starts []int
+	// head is the index of the token of UPDATE or DELETE after WITH
+	head int
+	// with is the text of WITH before UPDATE or DELETE
+	with string
+	// top is the text of TOP of SQL Server
+	top string
+}
+
+// PreviewStatement returns the SELECT of the rows that the UPDATE or the
+// DELETE of the query changes, with the same tables and conditions. The rows
+// of UPDATE are followed by the values set, as the columns named new_ and the
+// names of the columns.
+func PreviewStatement(driver dialect.DatabaseDriver, query string) (string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	toks, err := token.NewTokenizer(strings.NewReader(query), dialect.ForDriver(driver)).Tokenize()
+	if err != nil {
+		return "", err
+	}
+	stmt := newPreviewStatement(query, toks)
+	if len(stmt.toks) == 0 {
+		return "", ErrNotPreviewable
+	}
+	if previewWord(stmt.toks[0]) == "WITH" {
+		if err := stmt.skipWith(); err != nil {
+			return "", err
+		}
+	}
+	switch previewWord(stmt.toks[stmt.head]) {
+	case "DELETE":
+		return stmt.deleteSelect(driver)
+	case "UPDATE":
+		return stmt.updateSelect(driver)
+	}
+	return "", ErrNotPreviewable
+}
+
+func newPreviewStatement(text string, toks []*token.Token) *previewStatement {
+	s := &previewStatement{text: text, clauses: map[string]int{}}
+	depth := 0
+	for _, tok := range toks {
+		switch tok.Kind {
+		case token.Whitespace, token.Comment, token.MultilineComment:
+			continue
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+		}
+		s.toks = append(s.toks, tok)
+		kw := previewWord(tok)
+		if depth != 0 || !previewClauses[kw] {
+			continue
+		}
+		if _, ok := s.clauses[kw]; !ok {
+			s.clauses[kw] = len(s.toks) - 1
+			s.starts = append(s.starts, len(s.toks)-1)
+		}
+	}
+	return s
+}
+
+// skipWith sets the head to UPDATE or DELETE after the queries of WITH.
+func (s *previewStatement) skipWith() error {
+	depth := 0
+	for i, tok := range s.toks {
+		switch tok.Kind {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+		}
+		kw := previewWord(tok)
+		if i == 0 || !previewWriteWords[kw] {
+			continue
+		}
+		if depth != 0 {
+			return errors.New("cannot preview the statement whose WITH changes the rows")
+		}
+		if kw != "UPDATE" && kw != "DELETE" {
+			break
+		}
+		s.head = i
+		s.with = s.clause(0, i)
+		return nil
+	}
+	return ErrNotPreviewable
+}
+
+func (s *previewStatement) deleteSelect(driver dialect.DatabaseDriver) (string, error) {
+	start := s.skipModifiers(s.head + 1)
+	from, ok := s.clauses["FROM"]
+	if !ok {
+		return "", errors.New("cannot preview DELETE without FROM")
+	}
+	using, hasUsing := s.clauses["USING"]
+	var columns, tables string
+	switch {
+	case from > start:
+		// DELETE t1, t2 FROM t1 JOIN t2 ... of MySQL
+		columns = allColumns(s.clause(start, from))
+		tables = s.clauseAfter(from)
+	case hasUsing && isMySQL(driver):
+		// DELETE FROM t1, t2 USING t1 JOIN t2 ... of MySQL
+		columns = allColumns(s.clauseAfter(from))
+		tables = s.clauseAfter(using)
+	case hasUsing:
+		columns = tableReference(s.clauseTokens(from+1)) + ".*"
+		tables = s.clauseAfter(from) + ", " + s.clauseAfter(using)
+	default:
+		columns = "*"
+		tables = s.clauseAfter(from)
+	}
+	return s.selectText(columns, tables), nil
+}
+
+func (s *previewStatement) updateSelect(driver dialect.DatabaseDriver) (string, error) {
+	start := s.skipModifiers(s.head + 1)
+	set, ok := s.clauses["SET"]
+	if !ok {
+		return "", errors.New("cannot preview UPDATE without SET")
+	}
+	target := s.clause(start, set)
+	targetToks := s.toks[start:set]
+	columns := "*"
+	tables := target
+	if from, ok := s.clauses["FROM"]; ok && from > set {
+		columns = tableReference(targetToks) + ".*"
+		if driver == dialect.DatabaseDriverMssql {
+			// UPDATE t SET ... FROM t JOIN ... of SQL Server has the target in FROM
+			tables = s.clauseAfter(from)
+		} else {
+			tables = target + ", " + s.clauseAfter(from)
+		}
+	} else if hasJoin(targetToks) {
+		columns = tableReference(targetToks) + ".*"
+	}
+	assignments, err := s.assignments(set)
+	if err != nil {
+		return "", err
+	}
+	for _, assignment := range assignments {
+		columns += ", " + assignment
+	}
+	return s.selectText(columns, tables), nil
+}
+
+// selectText returns the SELECT of the columns from the tables with the
+// queries of WITH, the conditions, the orders and the limits of the
+// statement.
+func (s *previewStatement) selectText(columns, tables string) string {
+	text := "SELECT " + columns + " FROM " + tables
+	if s.top != "" {
+		text = "SELECT " + s.top + " " + columns + " FROM " + tables
+	}
+	if s.with != "" {
+		text = s.with + " " + text
+	}
+	for _, kw := range []string{"WHERE", "ORDER", "LIMIT"} {
+		if i, ok := s.clauses[kw]; ok {
+			text += " " + s.clause(i, s.next(i))
+		}
+	}
+	return text
+}
+
+// assignments returns the values of the assignments of SET as the columns
+// named new_ and the names of the columns assigned. The lists of the columns
+// such as (a, b) = (1, 2) are assigned the values of the lists in the order.
+func (s *previewStatement) assignments(set int) ([]string, error) {
+	res := []string{}
+	for _, assignment := range splitList(s.clauseTokens(set + 1)) {
+		eq := -1
+		depth := 0
+		for j, tok := range assignment {
+			switch tok.Kind {
+			case token.LParen:
+				depth++
+			case token.RParen:
+				depth--
+			case token.Eq:
+				if depth == 0 && eq < 0 {
+					eq = j
+				}
+			}
+		}
+		if eq <= 0 || eq == len(assignment)-1 {
+			continue
+		}
+		target, value := assignment[:eq], assignment[eq+1:]
+		if target[0].Kind != token.LParen {
+			if col := assignedColumn(target); col != "" {
+				res = append(res, s.span(value[0], value[len(value)-1])+" AS "+col)
+			}
+			continue
+		}
+		cols := splitList(innerTokens(target))
+		if len(value) > 0 && previewWord(value[0]) == "ROW" {
+			value = value[1:]
+		}
+		values := splitList(innerTokens(value))
+		if len(values) != len(cols) || len(values) == 0 || previewWord(values[0][0]) == "SELECT" {
+			return nil, fmt.Errorf("cannot preview the assignment to the columns %s", s.span(target[0], target[len(target)-1]))
+		}
+		for k, col := range cols {
+			name := assignedColumn(col)
+			if name == "" || len(values[k]) == 0 {
+				return nil, fmt.Errorf("cannot preview the assignment to the columns %s", s.span(target[0], target[len(target)-1]))
+			}
+			res = append(res, s.span(values[k][0], values[k][len(values[k])-1])+" AS "+name)
+		}
+	}
+	return res, nil
+}
+
+// assignedColumn returns the column of the target of the assignment named
+// new_ and the name of the column, or the empty string if the target is not
+// a column.
+func assignedColumn(target []*token.Token) string {
+	last := target[len(target)-1]
+	if last.Kind != token.SQLKeyword {
+		return ""
+	}
+	col := last.Value.(*token.SQLWord)
+	return (&token.SQLWord{Value: "new_" + col.Value, QuoteStyle: col.QuoteStyle}).String()
+}
+
+// splitList returns the tokens of the items of the list separated by the
+// commas out of the parentheses.
+func splitList(toks []*token.Token) [][]*token.Token {
+	var items [][]*token.Token
+	depth := 0
+	begin := 0
+	for i, tok := range toks {
+		switch tok.Kind {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+		case token.Comma:
+			if depth == 0 {
+				items = append(items, toks[begin:i])
+				begin = i + 1
+			}
+		}
+	}
+	if begin < len(toks) {
+		items = append(items, toks[begin:])
+	}
+	return items
+}
+
+// innerTokens returns the tokens in the parentheses enclosing all the
+// tokens, or nil if they are not enclosed.
+func innerTokens(toks []*token.Token) []*token.Token {
+	if len(toks) < 2 || toks[0].Kind != token.LParen || toks[len(toks)-1].Kind != token.RParen {
+		return nil
+	}
+	depth := 0
+	for i, tok := range toks {
+		switch tok.Kind {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+		}
+		if depth == 0 && i != len(toks)-1 {
+			// (a) + (b) is not enclosed
+			return nil
+		}
+	}
+	return toks[1 : len(toks)-1]
+}
+
+// skipModifiers returns the index of the token after the modifiers from the
+// index, and keeps TOP of SQL Server to limit the rows of the preview.
+func (s *previewStatement) skipModifiers(i int) int {
+	for i < len(s.toks) && previewModifiers[previewWord(s.toks[i])] {
+		i++
+	}
+	if i >= len(s.toks) || previewWord(s.toks[i]) != "TOP" {
+		return i
+	}
+	begin := i
+	i++
+	if i < len(s.toks) && s.toks[i].Kind == token.LParen {
+		for depth := 0; i < len(s.toks); i++ {
+			if s.toks[i].Kind == token.LParen {
+				depth++
+			} else if s.toks[i].Kind == token.RParen {
+				depth--
+			}
+			if depth == 0 {
+				break
+			}
+		}
+	}
+	i++
+	if i < len(s.toks) && previewWord(s.toks[i]) == "PERCENT" {
+		i++
+	}
+	s.top = s.clause(begin, i)
+	return i
+}
+
+// next returns the index of the keyword of the clause after the index, or the
+// number of the tokens.
+func (s *previewStatement) next(i int) int {
+	end := len(s.toks)
+	for _, start := range s.starts {
+		if start > i && start < end {
+			end = start
+		}
+	}
+	return end
+}
+
+// clause returns the text of the tokens from the index before the end.
+func (s *previewStatement) clause(begin, end int) string {
+	if begin >= end {
+		return ""
+	}
+	return s.span(s.toks[begin], s.toks[end-1])
+}
+
+// clauseAfter returns the text of the clause of the keyword of the index
+// without the keyword.
+func (s *previewStatement) clauseAfter(i int) string {
+	return s.clause(i+1, s.next(i))
+}
+
+// clauseTokens returns the tokens from the index to the next clause.
+func (s *previewStatement) clauseTokens(i int) []*token.Token {
+	end := s.next(i - 1)
+	if i >= end {
+		return nil
+	}
+	return s.toks[i:end]
+}
+
+// span returns the text of the query from the token to the other.
+func (s *previewStatement) span(from, to *token.Token) string {
+	return s.text[posOffset(s.text, from.From):posOffset(s.text, to.To)]
+}
+
+// posOffset returns the byte offset of the position, whose column is of the
+// runes.
+func posOffset(text string, pos token.Pos) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	for col := 0; col < pos.Col && offset < len(text); col++ {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// tableReference returns the name of the table to refer to its columns, the
+// alias if it has one.
+func tableReference(toks []*token.Token) string {
+	ref := ""
+	for _, tok := range toks {
+		if tok.Kind == token.Comma || previewJoinWords[previewWord(tok)] {
+			break
+		}
+		if tok.Kind == token.SQLKeyword && previewWord(tok) != "AS" && previewWord(tok) != "ONLY" {
+			ref = tok.Value.(*token.SQLWord).String()
+		}
+	}
+	return ref
+}
+
+// allColumns returns the columns of the tables of the list such as t1, t2.
+func allColumns(tables string) string {
+	cols := []string{}
+	for _, t := range strings.Split(tables, ",") {
+		t = strings.TrimSuffix(strings.TrimSpace(t), ".*")
+		cols = append(cols, t+".*")
+	}
+	return strings.Join(cols, ", ")
+}
+
+func hasJoin(toks []*token.Token) bool {
+	for _, tok := range toks {
+		if previewJoinWords[previewWord(tok)] || tok.Kind == token.Comma {
+			return true
+		}
+	}
+	return false
+}
+
+func isMySQL(driver dialect.DatabaseDriver) bool {
+	switch driver {
+	case dialect.DatabaseDriverMySQL, dialect.DatabaseDriverMySQL8, dialect.DatabaseDriverMySQL57, dialect.DatabaseDriverMySQL56:
+		return true
+	}
+	return false
+}
+
+func previewWord(tok *token.Token) string {
+	w, ok := tok.Value.(*token.SQLWord)
+	if !ok || w.QuoteStyle != 0 {
+		return ""
+	}
+	return strings.ToUpper(w.Value)
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestPreviewStatement(t *testing.T) {
+	cases := []struct {
+		name   string
+		driver dialect.DatabaseDriver
+		input  string
+		want   string
+		err    bool
+	}{
+		{
+			name:   "delete",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "DELETE FROM city WHERE CountryCode = 'JPN';",
+			want:   "SELECT * FROM city WHERE CountryCode = 'JPN'",
+		},
+		{
+			name:   "delete without where",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "DELETE FROM city",
+			want:   "SELECT * FROM city",
+		},
+		{
+			name:   "delete using",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "DELETE FROM city AS c USING country co WHERE c.CountryCode = co.Code AND co.Name = 'Japan' RETURNING c.ID",
+			want:   "SELECT c.* FROM city AS c, country co WHERE c.CountryCode = co.Code AND co.Name = 'Japan'",
+		},
+		{
+			name:   "delete of tables of mysql",
+			driver: dialect.DatabaseDriverMySQL,
+			input:  "DELETE LOW_PRIORITY c FROM city c JOIN country co ON c.CountryCode = co.Code WHERE co.Name = 'Japan'",
+			want:   "SELECT c.* FROM city c JOIN country co ON c.CountryCode = co.Code WHERE co.Name = 'Japan'",
+		},
+		{
+			name:   "delete using of mysql",
+			driver: dialect.DatabaseDriverMySQL,
+			input:  "DELETE FROM c, co USING city c JOIN country co ON c.CountryCode = co.Code WHERE co.Name = 'Japan'",
+			want:   "SELECT c.*, co.* FROM city c JOIN country co ON c.CountryCode = co.Code WHERE co.Name = 'Japan'",
+		},
+		{
+			name:   "delete with subquery and limit",
+			driver: dialect.DatabaseDriverMySQL,
+			input:  "DELETE FROM city WHERE ID IN (SELECT ID FROM old_city WHERE 1 = 1) ORDER BY ID LIMIT 10",
+			want:   "SELECT * FROM city WHERE ID IN (SELECT ID FROM old_city WHERE 1 = 1) ORDER BY ID LIMIT 10",
+		},
+		{
+			name:   "update",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "UPDATE city SET Population = Population + 1, \"Name\" = upper(Name) WHERE ID = 1",
+			want:   "SELECT *, Population + 1 AS new_Population, upper(Name) AS \"new_Name\" FROM city WHERE ID = 1",
+		},
+		{
+			name:   "update from",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "UPDATE city c SET Population = co.Population FROM country co WHERE c.CountryCode = co.Code",
+			want:   "SELECT c.*, co.Population AS new_Population FROM city c, country co WHERE c.CountryCode = co.Code",
+		},
+		{
+			name:   "update from of sql server",
+			driver: dialect.DatabaseDriverMssql,
+			input:  "UPDATE c SET c.Population = 0 OUTPUT inserted.ID FROM city c JOIN country co ON c.CountryCode = co.Code WHERE co.Code = 'JPN'",
+			want:   "SELECT c.*, 0 AS new_Population FROM city c JOIN country co ON c.CountryCode = co.Code WHERE co.Code = 'JPN'",
+		},
+		{
+			name:   "update of tables of mysql",
+			driver: dialect.DatabaseDriverMySQL,
+			input:  "UPDATE city c LEFT JOIN country co ON c.CountryCode = co.Code SET c.Population = 0 WHERE co.Code IS NULL",
+			want:   "SELECT c.*, 0 AS new_Population FROM city c LEFT JOIN country co ON c.CountryCode = co.Code WHERE co.Code IS NULL",
+		},
+		{
+			name:   "delete top of sql server",
+			driver: dialect.DatabaseDriverMssql,
+			input:  "DELETE TOP (10) FROM t WHERE a = 1",
+			want:   "SELECT TOP (10) * FROM t WHERE a = 1",
+		},
+		{
+			name:   "delete top percent of sql server",
+			driver: dialect.DatabaseDriverMssql,
+			input:  "DELETE TOP (10) PERCENT FROM t WHERE a = 1",
+			want:   "SELECT TOP (10) PERCENT * FROM t WHERE a = 1",
+		},
+		{
+			name:   "update top of sql server",
+			driver: dialect.DatabaseDriverMssql,
+			input:  "UPDATE TOP (5) t SET a = 1 WHERE b = 2",
+			want:   "SELECT TOP (5) *, 1 AS new_a FROM t WHERE b = 2",
+		},
+		{
+			name:   "update list of columns",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "UPDATE t SET (a, b) = (1, 2) WHERE c = 3",
+			want:   "SELECT *, 1 AS new_a, 2 AS new_b FROM t WHERE c = 3",
+		},
+		{
+			name:   "update list of columns with row",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "UPDATE t SET d = 0, (a, \"B\") = ROW(f(1, 2), (3)) WHERE c = 3",
+			want:   "SELECT *, 0 AS new_d, f(1, 2) AS new_a, (3) AS \"new_B\" FROM t WHERE c = 3",
+		},
+		{
+			name:   "update list of columns from subquery",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "UPDATE t SET (a, b) = (SELECT x, y FROM u) WHERE c = 3",
+			err:    true,
+		},
+		{
+			name:   "update list of unmatched columns",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "UPDATE t SET (a, b) = (1) WHERE c = 3",
+			err:    true,
+		},
+		{
+			name:   "delete with",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "WITH old AS (SELECT id FROM t WHERE a < 0) DELETE FROM t WHERE id IN (SELECT id FROM old)",
+			want:   "WITH old AS (SELECT id FROM t WHERE a < 0) SELECT * FROM t WHERE id IN (SELECT id FROM old)",
+		},
+		{
+			name:   "update with",
+			driver: dialect.DatabaseDriverMySQL,
+			input:  "WITH v AS (SELECT 1 AS n) UPDATE t SET a = (SELECT n FROM v) WHERE b = 2",
+			want:   "WITH v AS (SELECT 1 AS n) SELECT *, (SELECT n FROM v) AS new_a FROM t WHERE b = 2",
+		},
+		{
+			name:   "delete with changing rows",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "WITH moved AS (DELETE FROM t RETURNING *) DELETE FROM u WHERE id IN (SELECT id FROM moved)",
+			err:    true,
+		},
+		{
+			name:   "insert with",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "WITH v AS (SELECT 1) INSERT INTO t SELECT * FROM v",
+			err:    true,
+		},
+		{
+			name:   "multiline",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "UPDATE city\nSET name = 'Tōkyō'\nWHERE id = 1",
+			want:   "SELECT *, 'Tōkyō' AS new_name FROM city WHERE id = 1",
+		},
+		{
+			name:   "select",
+			driver: dialect.DatabaseDriverPostgreSQL,
+			input:  "SELECT * FROM city",
+			err:    true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PreviewStatement(tt.driver, tt.input)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unmatched preview\nwant: %q\ngot:  %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ var queryMap = map[string]bool{
 	"LIST":     true, //  list permissions, roles, users [cassandra]
 
 	"EXEC": true, // execute a stored procedure that returns rows (not postgres)
+	"CALL": true, // call a procedure that may return the result sets (mysql)
 }
 
 // execMap is the map of SQL prefixes to execute.
@@ -246,7 +247,7 @@ func QueryExecType(prefix, sqlstr string) (string, bool) {
 	}
 
 	var pref string
-	sp := splitMultiSep(prefix, []string{" ", "\t", "\n"})
+	sp := splitMultiSep(trimLeadingComments(prefix), []string{" ", "\t", "\n"})
 	if len(sp) == 0 {
 		return pref, false
 	}
@@ -303,3 +304,42 @@ func QueryExecType(prefix, sqlstr string) (string, bool) {
 	}
 	return pref, false
 }
+
+// trimLeadingComments returns the query without the comments before it, such
+// as the metadata of the statement given by the comments.
+func trimLeadingComments(query string) string {
+	for {
+		query = strings.TrimLeft(query, " \t\r\n")
+		switch {
+		case strings.HasPrefix(query, "--"), strings.HasPrefix(query, "#"):
+			i := strings.IndexByte(query, '\n')
+			if i < 0 {
+				return ""
+			}
+			query = query[i+1:]
+		case strings.HasPrefix(query, "/*"):
+			i := strings.Index(query, "*/")
+			if i < 0 {
+				return ""
+			}
+			query = query[i+2:]
+		default:
+			return query
+		}
+	}
+}
+
+// IsCopyFromStdin reports whether the query is COPY ... FROM STDIN, whose rows
+// are sent by the client.
+func IsCopyFromStdin(query string) bool {
+	words := strings.Fields(strings.ToUpper(trimLeadingComments(query)))
+	if len(words) == 0 || words[0] != "COPY" {
+		return false
+	}
+	for i := 1; i+1 < len(words); i++ {
+		if words[i] == "FROM" && strings.TrimSuffix(words[i+1], ";") == "STDIN" {
+			return true
+		}
+	}
+	return false
+}
@@ -42,6 +42,13 @@ func TestQueryExecType(t *testing.T) {
 			wantPrefix:   "SELECT",
 			wantExecType: true,
 		},
+		{
+			name:         "leading comments",
+			prefix:       "-- name: ListCities :many\n/* sqls: connection=world */\nselect * from city",
+			sqlstr:       "",
+			wantPrefix:   "SELECT",
+			wantExecType: true,
+		},
 		{
 			name:         "start tab",
 			prefix:       "\tselect * from city",
@@ -63,6 +70,13 @@ func TestQueryExecType(t *testing.T) {
 			wantPrefix:   "EXPLAIN",
 			wantExecType: true,
 		},
+		{
+			name:         "call",
+			prefix:       "call list_cities('JPN');",
+			sqlstr:       "",
+			wantPrefix:   "CALL",
+			wantExecType: true,
+		},
 		{
 			name:         "insert",
 			prefix:       "insert into city values (8181, 'Kabul', 'AFG', 'Kabol', 1780000);",
@@ -90,3 +104,24 @@ func TestQueryExecType(t *testing.T) {
 		})
 	}
 }
+
+func TestIsCopyFromStdin(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "COPY city FROM STDIN", want: true},
+		{query: "copy city (ID, Name)\nfrom stdin with (format csv);", want: true},
+		{query: "COPY city FROM '/tmp/city.csv'", want: false},
+		{query: "-- sqls: connection=world\nCOPY city FROM STDIN", want: true},
+		{query: "COPY city TO STDOUT", want: false},
+		{query: "SELECT * FROM stdin", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := IsCopyFromStdin(tt.query); got != tt.want {
+				t.Errorf("IsCopyFromStdin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// Registry shares the connections of the same configs and the workers of
+// their caches between the sessions of the clients of a process, so that
+// the clients of the same database do not open their own pools and caches.
+type Registry struct {
+	mu      sync.Mutex
+	entries map[string]*registryEntry
+}
+
+type registryEntry struct {
+	conn   *DBConnection
+	worker *Worker
+	refs   int
+	// ready is closed once the connection is opened and its cache is
+	// generated, or err is set
+	ready chan struct{}
+	err   error
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		entries: make(map[string]*registryEntry),
+	}
+}
+
+// Acquire returns the connection of the config and the worker of its cache,
+// opening the connection and generating the cache unless another session
+// has. Closing the connection returned releases them, and the connection is
+// closed and the worker is stopped when no session uses them.
+func (r *Registry) Acquire(ctx context.Context, cfg *DBConfig) (*DBConnection, *Worker, error) {
+	key, err := registryKey(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	e, ok := r.entries[key]
+	if !ok {
+		e = &registryEntry{ready: make(chan struct{})}
+		r.entries[key] = e
+	}
+	e.refs++
+	r.mu.Unlock()
+
+	if !ok {
+		e.conn, e.worker, e.err = openWithCache(ctx, cfg)
+		close(e.ready)
+	}
+	select {
+	case <-e.ready:
+	case <-ctx.Done():
+		r.release(key, e)
+		return nil, nil, ctx.Err()
+	}
+	if e.err != nil {
+		r.release(key, e)
+		return nil, nil, e.err
+	}
+
+	// The copy of the connection releases the entry instead of closing it
+	conn := *e.conn
+	var once sync.Once
+	conn.release = func() error {
+		once.Do(func() {
+			r.release(key, e)
+		})
+		return nil
+	}
+	return &conn, e.worker, nil
+}
+
+// Len returns the number of the connections open.
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+func (r *Registry) release(key string, e *registryEntry) {
+	r.mu.Lock()
+	e.refs--
+	last := e.refs == 0
+	if last && r.entries[key] == e {
+		delete(r.entries, key)
+	}
+	r.mu.Unlock()
+	if !last {
+		return
+	}
+	if e.worker != nil {
+		e.worker.Stop()
+	}
+	if e.conn != nil {
+		e.conn.Close()
+	}
+}
+
+// openWithCache opens the connection of the config, and starts the worker
+// of its cache generated.
+func openWithCache(ctx context.Context, cfg *DBConfig) (*DBConnection, *Worker, error) {
+	conn, err := Open(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, err := CreateRepository(cfg.Driver, conn.Conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	worker := NewWorker()
+	worker.Start()
+	// The cache is generated without the context of the session, which the
+	// other sessions wait for
+	if err := worker.ReCache(context.WithoutCancel(ctx), repo); err != nil {
+		worker.Stop()
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, worker, nil
+}
+
+// registryKey returns the key of the connections of the same settings,
+// hashed not to keep the passwords in the keys.
+func registryKey(cfg *DBConfig) (string, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
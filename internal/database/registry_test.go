@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := NewRegistry()
+	cfg := &DBConfig{Driver: "sqlite3", DataSourceName: filepath.Join(t.TempDir(), "a.db")}
+
+	conn1, worker1, err := r.Acquire(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same settings share the connection and the cache
+	same := *cfg
+	conn2, worker2, err := r.Acquire(ctx, &same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn1.Conn != conn2.Conn || worker1 != worker2 || worker1.Cache() == nil {
+		t.Error("connection is not shared")
+	}
+	other, _, err := r.Acquire(ctx, &DBConfig{Driver: "sqlite3", DataSourceName: filepath.Join(t.TempDir(), "b.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Conn == conn1.Conn || r.Len() != 2 {
+		t.Errorf("connection of other settings is shared, %d connections", r.Len())
+	}
+	if err := other.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing twice releases the connection once
+	conn1.Close()
+	conn1.Close()
+	if r.Len() != 1 {
+		t.Fatalf("unmatched connections: want 1, got %d", r.Len())
+	}
+	if err := conn2.Conn.PingContext(ctx); err != nil {
+		t.Errorf("connection used by a session is closed, %v", err)
+	}
+	conn2.Close()
+	if r.Len() != 0 {
+		t.Fatalf("unmatched connections: want 0, got %d", r.Len())
+	}
+	if err := conn2.Conn.PingContext(ctx); err == nil {
+		t.Error("connection not used is not closed")
+	}
+
+	if _, _, err := r.Acquire(ctx, &DBConfig{Driver: "unknown"}); err == nil {
+		t.Error("expected error")
+	}
+	if r.Len() != 0 {
+		t.Errorf("failed connection is kept")
+	}
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	BinaryFormatText   = "text"
+	BinaryFormatHex    = "hex"
+	BinaryFormatBase64 = "base64"
+)
+
+const (
+	// defaultNullString is the text of NULL, which is null in JSON unless
+	// ResultFormat.Null is set
+	defaultNullString = "<nil>"
+	// truncationMarker ends the values cut to ResultFormat.MaxCellWidth
+	truncationMarker = "…"
+)
+
+// ResultFormat is how the values of the results of the queries are shown, in
+// the tables, the vertical rows, the notebooks and the JSON results alike.
+type ResultFormat struct {
+	// Null is the text of NULL
+	Null string `json:"null" yaml:"null"`
+	// TimeFormat is the layout of the dates and the times of Go, RFC 3339
+	// by default
+	TimeFormat string `json:"timeFormat" yaml:"timeFormat"`
+	// TimeZone is the name of the zone of the dates and the times such as
+	// UTC, Local or Asia/Tokyo, the zone returned by the driver by default
+	TimeZone string `json:"timeZone" yaml:"timeZone"`
+	// MaxCellWidth is the number of the characters of the values longer
+	// than which they are truncated, 0 for no limit
+	MaxCellWidth int `json:"maxCellWidth" yaml:"maxCellWidth"`
+	// Binary is the encoding of the binary values, text, hex or base64
+	Binary string `json:"binary" yaml:"binary"`
+}
+
+func (f *ResultFormat) Validate() error {
+	if f.TimeZone != "" {
+		if _, err := time.LoadLocation(f.TimeZone); err != nil {
+			return errors.New("invalid: resultFormat.timeZone")
+		}
+	}
+	if f.MaxCellWidth < 0 {
+		return errors.New("invalid: resultFormat.maxCellWidth")
+	}
+	switch f.Binary {
+	case "", BinaryFormatText, BinaryFormatHex, BinaryFormatBase64:
+	default:
+		return errors.New("invalid: resultFormat.binary")
+	}
+	return nil
+}
+
+// ResultFormatter formats the values of the rows of a query by the format.
+type ResultFormatter struct {
+	format ResultFormat
+	loc    *time.Location
+	// binary is whether the columns are of the binary types
+	binary []bool
+}
+
+// NewResultFormatter returns the formatter of the rows. The format may be nil
+// for the default.
+func NewResultFormatter(format *ResultFormat, rows *sql.Rows) (*ResultFormatter, error) {
+	f := &ResultFormatter{}
+	if format != nil {
+		f.format = *format
+	}
+	if f.format.TimeZone != "" {
+		loc, err := time.LoadLocation(f.format.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load time zone, %w", err)
+		}
+		f.loc = loc
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get query column types, %w", err)
+	}
+	f.binary = make([]bool, len(types))
+	for i, t := range types {
+		f.binary[i] = isBinaryType(t.DatabaseTypeName())
+	}
+	return f, nil
+}
+
+func isBinaryType(name string) bool {
+	name = strings.ToUpper(name)
+	switch name {
+	case "BYTEA", "IMAGE", "RAW", "LONG RAW", "BFILE":
+		return true
+	}
+	return strings.Contains(name, "BLOB") || strings.Contains(name, "BINARY")
+}
+
+// ScanRows scans the rows to the texts of the values.
+func (f *ResultFormatter) ScanRows(rows *sql.Rows) ([][]string, error) {
+	stringRows := [][]string{}
+	for rows.Next() {
+		row, err := scanRow(rows, len(f.binary))
+		if err != nil {
+			return nil, err
+		}
+		stringRow := make([]string, len(row))
+		for i, val := range row {
+			s, err := f.text(i, val)
+			if err != nil {
+				return nil, err
+			}
+			stringRow[i] = s
+		}
+		stringRows = append(stringRows, stringRow)
+	}
+	return stringRows, rows.Err()
+}
+
+// ScanValues scans the rows keeping the numbers and the booleans as they are,
+// and NULL as nil unless its text is set, for the clients which render the
+// rows by themselves.
+func (f *ResultFormatter) ScanValues(rows *sql.Rows) ([][]interface{}, error) {
+	valueRows := [][]interface{}{}
+	for rows.Next() {
+		row, err := scanRow(rows, len(f.binary))
+		if err != nil {
+			return nil, err
+		}
+		valueRow := make([]interface{}, len(row))
+		for i, val := range row {
+			v, err := f.value(i, val)
+			if err != nil {
+				return nil, err
+			}
+			valueRow[i] = v
+		}
+		valueRows = append(valueRows, valueRow)
+	}
+	return valueRows, rows.Err()
+}
+
+func (f *ResultFormatter) value(col int, val interface{}) (interface{}, error) {
+	switch v := val.(type) {
+	case nil:
+		if f.format.Null == "" {
+			return nil, nil
+		}
+	case bool, int64, int32, int, uint64, uint32, uint:
+		return v, nil
+	case float64:
+		// NaN and Inf are not of JSON
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return v, nil
+		}
+	case float32:
+		if !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0) {
+			return v, nil
+		}
+	}
+	return f.text(col, val)
+}
+
+func (f *ResultFormatter) text(col int, val interface{}) (string, error) {
+	var s string
+	switch v := val.(type) {
+	case nil:
+		s = f.format.Null
+		if s == "" {
+			s = defaultNullString
+		}
+		return s, nil
+	case time.Time:
+		if f.loc != nil {
+			v = v.In(f.loc)
+		}
+		layout := f.format.TimeFormat
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		s = v.Format(layout)
+	case []byte:
+		s = f.binaryText(col, v)
+	default:
+		var err error
+		s, err = sqlValToString(val)
+		if err != nil {
+			return "", err
+		}
+	}
+	return f.truncate(s), nil
+}
+
+// binaryText returns the text of the bytes, encoded if they are of a binary
+// column or not of UTF-8.
+func (f *ResultFormatter) binaryText(col int, b []byte) string {
+	if (col < len(f.binary) && f.binary[col]) || !utf8.Valid(b) {
+		switch f.format.Binary {
+		case BinaryFormatHex:
+			return "0x" + hex.EncodeToString(b)
+		case BinaryFormatBase64:
+			return base64.StdEncoding.EncodeToString(b)
+		}
+	}
+	return string(b)
+}
+
+func (f *ResultFormatter) truncate(s string) string {
+	width := f.format.MaxCellWidth
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + truncationMarker
+}
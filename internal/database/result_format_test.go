@@ -0,0 +1,102 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestResultFormatterText(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		format ResultFormat
+		binary bool
+		val    interface{}
+		want   string
+	}{
+		{name: "null", val: nil, want: "<nil>"},
+		{name: "null string", format: ResultFormat{Null: "NULL"}, val: nil, want: "NULL"},
+		{name: "time", val: at, want: "2024-01-02T03:04:05Z"},
+		{name: "time format", format: ResultFormat{TimeFormat: "2006-01-02 15:04"}, val: at, want: "2024-01-02 03:04"},
+		{name: "time zone", format: ResultFormat{TimeZone: "Asia/Tokyo"}, val: at, want: "2024-01-02T12:04:05+09:00"},
+		{name: "binary text", binary: true, val: []byte("abc"), want: "abc"},
+		{name: "binary hex", format: ResultFormat{Binary: BinaryFormatHex}, binary: true, val: []byte("abc"), want: "0x616263"},
+		{name: "binary base64", format: ResultFormat{Binary: BinaryFormatBase64}, binary: true, val: []byte("abc"), want: "YWJj"},
+		{name: "text of binary format", format: ResultFormat{Binary: BinaryFormatHex}, val: []byte("abc"), want: "abc"},
+		{name: "invalid utf-8", format: ResultFormat{Binary: BinaryFormatHex}, val: []byte{0xff, 0x00}, want: "0xff00"},
+		{name: "max cell width", format: ResultFormat{MaxCellWidth: 4}, val: "abcdef", want: "abc…"},
+		{name: "max cell width not exceeded", format: ResultFormat{MaxCellWidth: 6}, val: "abcdef", want: "abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &ResultFormatter{format: tt.format, binary: []bool{tt.binary}}
+			if tt.format.TimeZone != "" {
+				f.loc = tokyo
+			}
+			got, err := f.text(0, tt.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected text, got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultFormatterValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		format ResultFormat
+		val    interface{}
+		want   interface{}
+	}{
+		{name: "null", val: nil, want: nil},
+		{name: "null string", format: ResultFormat{Null: "NULL"}, val: nil, want: "NULL"},
+		{name: "number", format: ResultFormat{MaxCellWidth: 1}, val: int64(123), want: int64(123)},
+		{name: "NaN", val: math.NaN(), want: "NaN"},
+		{name: "text", format: ResultFormat{MaxCellWidth: 3}, val: []byte("abcd"), want: "ab…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &ResultFormatter{format: tt.format, binary: []bool{false}}
+			got, err := f.value(0, tt.val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected value, got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultFormatValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  ResultFormat
+		wantErr string
+	}{
+		{name: "default"},
+		{name: "valid", format: ResultFormat{TimeZone: "UTC", MaxCellWidth: 10, Binary: BinaryFormatBase64}},
+		{name: "time zone", format: ResultFormat{TimeZone: "Nowhere/Nothing"}, wantErr: "invalid: resultFormat.timeZone"},
+		{name: "max cell width", format: ResultFormat{MaxCellWidth: -1}, wantErr: "invalid: resultFormat.maxCellWidth"},
+		{name: "binary", format: ResultFormat{Binary: "octal"}, wantErr: "invalid: resultFormat.binary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.format.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("unexpected error, %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("unexpected error, got %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -29,35 +29,43 @@ func Columns(rows *sql.Rows) ([]string, error) {
 func ScanRows(rows *sql.Rows, columnLength int) ([][]string, error) {
 	stringRows := [][]string{}
 	for rows.Next() {
-		// scan to []interface{}
-		rowBuffer := make([]interface{}, columnLength)
-		for i := range rowBuffer {
-			rowBuffer[i] = new(interface{})
-		}
-		if err := rows.Scan(rowBuffer...); err != nil {
+		row, err := scanRow(rows, columnLength)
+		if err != nil {
 			return nil, err
 		}
 
 		stringRow := make([]string, columnLength)
-		for i, buf := range rowBuffer {
-			val, err := sqlValToString(buf)
+		for i, val := range row {
+			s, err := sqlValToString(val)
 			if err != nil {
 				return nil, err
 			}
-			stringRow[i] = val
+			stringRow[i] = s
 		}
 		stringRows = append(stringRows, stringRow)
 	}
-	return stringRows, nil
+	// The query canceled ends the rows with the error
+	return stringRows, rows.Err()
 }
 
-func sqlValToString(pointer interface{}) (string, error) {
-	res := ""
-	if pointer == nil {
-		return res, nil
+// scanRow scans the current row to the values as the driver returns them.
+func scanRow(rows *sql.Rows, columnLength int) ([]interface{}, error) {
+	rowBuffer := make([]interface{}, columnLength)
+	for i := range rowBuffer {
+		rowBuffer[i] = new(interface{})
+	}
+	if err := rows.Scan(rowBuffer...); err != nil {
+		return nil, err
 	}
+	row := make([]interface{}, columnLength)
+	for i, buf := range rowBuffer {
+		row[i] = *buf.(*interface{})
+	}
+	return row, nil
+}
 
-	val := *pointer.(*interface{})
+func sqlValToString(val interface{}) (string, error) {
+	res := ""
 	switch v := (val).(type) {
 	case []byte:
 		res = string(v)
@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
-	"log"
+	"os"
+	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"github.com/sqls-server/sqls/dialect"
 )
 
@@ -15,18 +18,90 @@ func init() {
 	RegisterFactory(dialect.DatabaseDriverSQLite3, NewSQLite3DBRepository)
 }
 
+// SQLiteConfig is the config of the connections of SQLite.
+type SQLiteConfig struct {
+	// Extensions are the paths of the extension modules loaded to the
+	// connections
+	Extensions []string `json:"extensions" yaml:"extensions"`
+	// Fixture is the path of the SQL file run on the in-memory database of
+	// each connection, such as of the schema to complete without the
+	// database file
+	Fixture string `json:"fixture" yaml:"fixture"`
+}
+
+func (c *SQLiteConfig) Validate(connCfg *DBConfig) error {
+	if c.Fixture != "" && connCfg.hasDataSourceName() && connCfg.DataSourceName != sqlite3Memory {
+		return errors.New("invalid: connections[].sqlite.fixture cannot be used with connections[].dataSourceName")
+	}
+	for _, ext := range c.Extensions {
+		if ext == "" {
+			return errors.New("invalid: connections[].sqlite.extensions")
+		}
+	}
+	return nil
+}
+
+const sqlite3Memory = ":memory:"
+
 func sqlite3Open(connCfg *DBConfig) (*DBConnection, error) {
-	conn, err := sql.Open("sqlite3", connCfg.DataSourceName)
-	if err != nil {
-		return nil, err
+	var conn *sql.DB
+	if connCfg.SQLiteCfg == nil {
+		var err error
+		conn, err = sql.Open("sqlite3", connCfg.DataSourceName)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		connector, err := newSQLite3Connector(connCfg.DataSourceName, connCfg.SQLiteCfg)
+		if err != nil {
+			return nil, err
+		}
+		conn = sql.OpenDB(connector)
 	}
-	conn.SetMaxIdleConns(DefaultMaxIdleConns)
-	conn.SetMaxOpenConns(DefaultMaxOpenConns)
+	setConnPool(conn, connCfg)
 	return &DBConnection{
-		Conn: conn,
+		Conn:   conn,
+		Driver: dialect.DatabaseDriverSQLite3,
 	}, nil
 }
 
+// sqlite3Connector opens the connections loading the extensions, and the
+// fixture to the in-memory databases.
+type sqlite3Connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func newSQLite3Connector(dsn string, cfg *SQLiteConfig) (*sqlite3Connector, error) {
+	d := &sqlite3.SQLiteDriver{}
+	for _, ext := range cfg.Extensions {
+		d.Extensions = append(d.Extensions, expandHome(ext))
+	}
+	if cfg.Fixture != "" {
+		b, err := os.ReadFile(expandHome(cfg.Fixture))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read fixture, %w", err)
+		}
+		fixture := string(b)
+		dsn = sqlite3Memory
+		d.ConnectHook = func(conn *sqlite3.SQLiteConn) error {
+			if _, err := conn.Exec(fixture, nil); err != nil {
+				return fmt.Errorf("cannot load fixture, %w", err)
+			}
+			return nil
+		}
+	}
+	return &sqlite3Connector{dsn: dsn, driver: d}, nil
+}
+
+func (c *sqlite3Connector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *sqlite3Connector) Driver() driver.Driver {
+	return c.driver
+}
+
 type SQLite3DBRepository struct {
 	Conn *sql.DB
 }
@@ -75,7 +150,7 @@ func (db *SQLite3DBRepository) Tables(ctx context.Context) ([]string, error) {
 	  name
 	`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tables := []string{}
@@ -90,15 +165,18 @@ func (db *SQLite3DBRepository) Tables(ctx context.Context) ([]string, error) {
 }
 
 func (db *SQLite3DBRepository) describeTable(ctx context.Context, tableName string) ([]*ColumnDesc, error) {
-	rows, err := db.Conn.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s);", tableName))
+	// table_xinfo describes the generated columns too, by hidden
+	rows, err := db.Conn.QueryContext(ctx, fmt.Sprintf("PRAGMA table_xinfo(%s);", tableName))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 	tableInfos := []*ColumnDesc{}
+	var pks []*ColumnDesc
 	for rows.Next() {
 		var id int
 		var nonnull int
+		var hidden int
 		var tableInfo ColumnDesc
 		err := rows.Scan(
 			&id,
@@ -107,6 +185,7 @@ func (db *SQLite3DBRepository) describeTable(ctx context.Context, tableName stri
 			&nonnull,
 			&tableInfo.Default,
 			&tableInfo.Key,
+			&hidden,
 		)
 		if err != nil {
 			return nil, err
@@ -117,9 +196,25 @@ func (db *SQLite3DBRepository) describeTable(ctx context.Context, tableName stri
 		} else {
 			tableInfo.Null = "YES"
 		}
+		switch hidden {
+		case 2:
+			tableInfo.Extra = "VIRTUAL GENERATED"
+		case 3:
+			tableInfo.Extra = "STORED GENERATED"
+		}
+		if tableInfo.Key != "0" {
+			pks = append(pks, &tableInfo)
+		}
 		tableInfos = append(tableInfos, &tableInfo)
 	}
-	return tableInfos, nil
+	// INTEGER PRIMARY KEY is the rowid, which is numbered unless assigned
+	if len(pks) == 1 && strings.EqualFold(pks[0].Type, "INTEGER") {
+		pks[0].Extra = "rowid"
+	}
+	for _, tableInfo := range tableInfos {
+		tableInfo.setGenerated()
+	}
+	return tableInfos, rows.Err()
 }
 
 func (db *SQLite3DBRepository) DescribeDatabaseTable(ctx context.Context) ([]*ColumnDesc, error) {
@@ -157,16 +252,21 @@ func (db *SQLite3DBRepository) DescribeForeignKeysBySchema(ctx context.Context,
 	ORDER BY 1, p."seq"
 		`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 	return parseForeignKeys(rows, schemaName)
 }
 
-func (db *SQLite3DBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
-	return db.Conn.ExecContext(ctx, query)
+func (db *SQLite3DBRepository) Roles(ctx context.Context) ([]string, error) {
+	// sqlite3 doesn't have users nor roles
+	return nil, nil
+}
+
+func (db *SQLite3DBRepository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Conn.ExecContext(ctx, query, args...)
 }
 
-func (db *SQLite3DBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
-	return db.Conn.QueryContext(ctx, query)
+func (db *SQLite3DBRepository) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.Conn.QueryContext(ctx, query, args...)
 }
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestSQLiteConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *DBConfig
+		wantErr string
+	}{
+		{
+			name: "fixture",
+			cfg: &DBConfig{
+				Driver:    dialect.DatabaseDriverSQLite3,
+				SQLiteCfg: &SQLiteConfig{Fixture: "schema.sql"},
+			},
+		},
+		{
+			name: "fixture in memory",
+			cfg: &DBConfig{
+				Driver:         dialect.DatabaseDriverSQLite3,
+				DataSourceName: ":memory:",
+				SQLiteCfg:      &SQLiteConfig{Fixture: "schema.sql"},
+			},
+		},
+		{
+			name: "fixture with file",
+			cfg: &DBConfig{
+				Driver:         dialect.DatabaseDriverSQLite3,
+				DataSourceName: "app.db",
+				SQLiteCfg:      &SQLiteConfig{Fixture: "schema.sql"},
+			},
+			wantErr: "invalid: connections[].sqlite.fixture cannot be used with connections[].dataSourceName",
+		},
+		{
+			name: "empty extension",
+			cfg: &DBConfig{
+				Driver:    dialect.DatabaseDriverSQLite3,
+				SQLiteCfg: &SQLiteConfig{Extensions: []string{""}},
+			},
+			wantErr: "invalid: connections[].sqlite.extensions",
+		},
+		{
+			name: "other driver",
+			cfg: &DBConfig{
+				Driver:         dialect.DatabaseDriverMySQL,
+				DataSourceName: "root@tcp(localhost:3306)/world",
+				SQLiteCfg:      &SQLiteConfig{Fixture: "schema.sql"},
+			},
+			wantErr: "invalid: connections[].sqlite is not supported by the driver",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error, %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("unexpected error, got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSQLite3Fixture(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "schema.sql")
+	schema := "CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT);\n" +
+		"CREATE TABLE country (code TEXT PRIMARY KEY);\n"
+	if err := os.WriteFile(fixture, []byte(schema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dbConn, err := sqlite3Open(&DBConfig{
+		Driver:    dialect.DatabaseDriverSQLite3,
+		SQLiteCfg: &SQLiteConfig{Fixture: fixture},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbConn.Close()
+
+	// Each connection has the tables of the fixture in its own database
+	ctx := context.Background()
+	var conns []*sql.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := dbConn.Conn.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+		var tables string
+		if err := conn.QueryRowContext(ctx, "SELECT group_concat(name) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil {
+			t.Fatal(err)
+		}
+		if tables != "city,country" {
+			t.Errorf("unexpected tables of connection %d, got %q", i, tables)
+		}
+	}
+	for _, conn := range conns {
+		conn.Close()
+	}
+
+	tables, err := (&SQLite3DBRepository{Conn: dbConn.Conn}).Tables(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"city", "country"}; !reflect.DeepEqual(tables, want) {
+		t.Errorf("unexpected tables, got %v, want %v", tables, want)
+	}
+}
+
+func TestSQLite3FixtureError(t *testing.T) {
+	if _, err := sqlite3Open(&DBConfig{
+		Driver:    dialect.DatabaseDriverSQLite3,
+		SQLiteCfg: &SQLiteConfig{Fixture: filepath.Join(t.TempDir(), "missing.sql")},
+	}); err == nil {
+		t.Error("expected error of missing fixture")
+	}
+
+	fixture := filepath.Join(t.TempDir(), "broken.sql")
+	if err := os.WriteFile(fixture, []byte("CREATE TABLE ("), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dbConn, err := sqlite3Open(&DBConfig{
+		Driver:    dialect.DatabaseDriverSQLite3,
+		SQLiteCfg: &SQLiteConfig{Fixture: fixture},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Conn.Ping(); err == nil {
+		t.Error("expected error of broken fixture")
+	}
+}
+
+func TestSQLite3ExtensionError(t *testing.T) {
+	dbConn, err := sqlite3Open(&DBConfig{
+		Driver:    dialect.DatabaseDriverSQLite3,
+		SQLiteCfg: &SQLiteConfig{Extensions: []string{filepath.Join(t.TempDir(), "missing")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbConn.Close()
+	if err := dbConn.Conn.Ping(); err == nil {
+		t.Error("expected error of missing extension")
+	}
+}
+
+func TestSQLite3DescribeGenerated(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "schema.sql")
+	schema := "CREATE TABLE item (id INTEGER PRIMARY KEY, price REAL NOT NULL, quantity INTEGER NOT NULL," +
+		" total REAL GENERATED ALWAYS AS (price * quantity) STORED, label TEXT AS (upper(name)), name TEXT DEFAULT 'none');\n" +
+		"CREATE TABLE pair (a INTEGER, b INTEGER, PRIMARY KEY (a, b));\n"
+	if err := os.WriteFile(fixture, []byte(schema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dbConn, err := sqlite3Open(&DBConfig{
+		Driver:    dialect.DatabaseDriverSQLite3,
+		SQLiteCfg: &SQLiteConfig{Fixture: fixture},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbConn.Close()
+
+	cols, err := (&SQLite3DBRepository{Conn: dbConn.Conn}).DescribeDatabaseTable(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, col := range cols {
+		got[col.Table+"."+col.Name] = fmt.Sprintf("%s/%v/%s/%s", col.Extra, col.Generated, col.Identity, col.Default.String)
+	}
+	want := map[string]string{
+		"item.id":       "rowid/false/by default/",
+		"item.price":    "/false//",
+		"item.quantity": "/false//",
+		"item.total":    "STORED GENERATED/true//",
+		"item.label":    "VIRTUAL GENERATED/true//",
+		"item.name":     "/false//'none'",
+		"pair.a":        "/false//",
+		"pair.b":        "/false//",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected columns, got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,321 @@
+package database
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const defaultSSHPort = 22
+
+// Dial connects to the SSH server through the jump hosts. Closing the
+// returned client closes the connections to the jump hosts too.
+func (s *SSHConfig) Dial() (*ssh.Client, error) {
+	target := s
+	if s.UseSSHConfig {
+		hosts, err := loadSSHConfig(defaultSSHConfigPath())
+		if err != nil {
+			return nil, err
+		}
+		target = s.withSSHConfig(hosts)
+	}
+
+	var client *ssh.Client
+	for _, hop := range append(append([]*SSHConfig{}, target.ProxyJump...), target) {
+		next, err := hop.dialVia(client)
+		if err != nil {
+			if client != nil {
+				client.Close()
+			}
+			return nil, err
+		}
+		if client != nil {
+			prev := client
+			go func() {
+				next.Wait()
+				prev.Close()
+			}()
+		}
+		client = next
+	}
+	return client, nil
+}
+
+func (s *SSHConfig) dialVia(via *ssh.Client) (*ssh.Client, error) {
+	clientConfig, closeAgent, err := s.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
+
+	if via == nil {
+		client, err := ssh.Dial("tcp", s.Endpoint(), clientConfig)
+		if err != nil {
+			return nil, fmt.Errorf("cannot ssh dial, %w", err)
+		}
+		return client, nil
+	}
+	conn, err := via.Dial("tcp", s.Endpoint())
+	if err != nil {
+		return nil, fmt.Errorf("cannot ssh dial via jump host, %w", err)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, s.Endpoint(), clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot ssh dial, %w", err)
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// ClientConfig returns the config to connect to the SSH server.
+func (s *SSHConfig) ClientConfig() (*ssh.ClientConfig, error) {
+	clientConfig, _, err := s.clientConfig()
+	return clientConfig, err
+}
+
+// clientConfig returns the config to connect to the SSH server and a function
+// to close the connection to the ssh-agent after the handshake.
+func (s *SSHConfig) clientConfig() (*ssh.ClientConfig, func(), error) {
+	closeAgent := func() {}
+	auth := []ssh.AuthMethod{}
+	if s.UseAgent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, nil, fmt.Errorf("cannot connect ssh-agent, SSH_AUTH_SOCK is not set")
+		}
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot connect ssh-agent, %w", err)
+		}
+		closeAgent = func() { conn.Close() }
+		auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+	if s.PrivateKey != "" {
+		key, err := s.signer()
+		if err != nil {
+			closeAgent()
+			return nil, nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(key))
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if s.StrictHostKeyChecking {
+		knownHostsFile := s.KnownHostsFile
+		if knownHostsFile == "" {
+			knownHostsFile = filepath.Join("~", ".ssh", "known_hosts")
+		}
+		cb, err := knownhosts.New(expandHome(knownHostsFile))
+		if err != nil {
+			closeAgent()
+			return nil, nil, fmt.Errorf("cannot read known hosts file, %w", err)
+		}
+		hostKeyCallback = cb
+	}
+
+	return &ssh.ClientConfig{
+		User:            s.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	}, closeAgent, nil
+}
+
+func (s *SSHConfig) signer() (ssh.Signer, error) {
+	privateKey := expandHome(s.PrivateKey)
+	buffer, err := os.ReadFile(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read SSH private key file, PrivateKey=%s, %w", s.PrivateKey, err)
+	}
+	if s.PassPhrase != "" {
+		key, err := ssh.ParsePrivateKeyWithPassphrase(buffer, []byte(s.PassPhrase))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse SSH private key file with passphrase, PrivateKey=%s, %w", s.PrivateKey, err)
+		}
+		return key, nil
+	}
+	key, err := ssh.ParsePrivateKey(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse SSH private key file, PrivateKey=%s, %w", s.PrivateKey, err)
+	}
+	return key, nil
+}
+
+// withSSHConfig returns a copy of the config completed with the options of
+// the matching hosts in ~/.ssh/config. The values set in sqls config take
+// precedence.
+func (s *SSHConfig) withSSHConfig(hosts []*sshConfigHost) *SSHConfig {
+	c := *s
+	alias := s.Host
+	if v := lookupSSHConfig(hosts, alias, "hostname"); v != "" {
+		c.Host = v
+	}
+	if c.User == "" {
+		c.User = lookupSSHConfig(hosts, alias, "user")
+	}
+	if c.Port == 0 {
+		c.Port, _ = strconv.Atoi(lookupSSHConfig(hosts, alias, "port"))
+	}
+	if c.PrivateKey == "" {
+		c.PrivateKey = lookupSSHConfig(hosts, alias, "identityfile")
+	}
+	if !c.StrictHostKeyChecking {
+		c.StrictHostKeyChecking = strings.EqualFold(lookupSSHConfig(hosts, alias, "stricthostkeychecking"), "yes")
+	}
+	if c.KnownHostsFile == "" {
+		// Only the first of the known hosts files is used
+		if files := strings.Fields(lookupSSHConfig(hosts, alias, "userknownhostsfile")); len(files) > 0 {
+			c.KnownHostsFile = files[0]
+		}
+	}
+	if c.PrivateKey == "" {
+		c.UseAgent = true
+	}
+
+	jumps := c.ProxyJump
+	if len(jumps) == 0 {
+		jumps = parseProxyJump(lookupSSHConfig(hosts, alias, "proxyjump"))
+	}
+	c.ProxyJump = make([]*SSHConfig, 0, len(jumps))
+	for _, jump := range jumps {
+		j := *jump
+		j.ProxyJump = nil
+		j.UseSSHConfig = true
+		c.ProxyJump = append(c.ProxyJump, j.withSSHConfig(hosts))
+	}
+	return &c
+}
+
+// parseProxyJump parses the ProxyJump value of ssh config, which is a comma
+// separated list of [user@]host[:port].
+func parseProxyJump(value string) []*SSHConfig {
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil
+	}
+	jumps := []*SSHConfig{}
+	for _, hop := range strings.Split(value, ",") {
+		hop = strings.TrimSpace(hop)
+		if hop == "" {
+			continue
+		}
+		jump := &SSHConfig{}
+		if i := strings.LastIndex(hop, "@"); i >= 0 {
+			jump.User, hop = hop[:i], hop[i+1:]
+		}
+		if host, port, err := net.SplitHostPort(hop); err == nil {
+			jump.Host = host
+			jump.Port, _ = strconv.Atoi(port)
+		} else {
+			jump.Host = hop
+		}
+		jumps = append(jumps, jump)
+	}
+	return jumps
+}
+
+type sshConfigHost struct {
+	patterns []string
+	options  map[string]string
+}
+
+func (h *sshConfigHost) match(alias string) bool {
+	matched := false
+	for _, pattern := range h.patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "!"), alias)
+		if ok && negated {
+			return false
+		}
+		if ok {
+			matched = true
+		}
+	}
+	return matched
+}
+
+func defaultSSHConfigPath() string {
+	return filepath.Join("~", ".ssh", "config")
+}
+
+func loadSSHConfig(fpath string) ([]*sshConfigHost, error) {
+	f, err := os.Open(expandHome(fpath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot open ssh config, %w", err)
+	}
+	defer f.Close()
+	return parseSSHConfig(f)
+}
+
+// parseSSHConfig parses the Host sections of ssh config. Match sections are
+// not supported and skipped.
+func parseSSHConfig(r io.Reader) ([]*sshConfigHost, error) {
+	// The options before the first Host section apply to all hosts
+	cur := &sshConfigHost{patterns: []string{"*"}, options: map[string]string{}}
+	hosts := []*sshConfigHost{cur}
+	skip := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value := line, ""
+		if i := strings.IndexAny(line, " \t="); i >= 0 {
+			key = line[:i]
+			value = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line[i:]), "="))
+		}
+		key = strings.ToLower(key)
+		value = strings.Trim(value, `"`)
+
+		switch key {
+		case "host":
+			cur = &sshConfigHost{patterns: strings.Fields(value), options: map[string]string{}}
+			hosts = append(hosts, cur)
+			skip = false
+		case "match":
+			skip = true
+		default:
+			// The first obtained value is used as ssh does
+			if _, ok := cur.options[key]; !ok && !skip {
+				cur.options[key] = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read ssh config, %w", err)
+	}
+	return hosts, nil
+}
+
+func lookupSSHConfig(hosts []*sshConfigHost, alias, key string) string {
+	for _, h := range hosts {
+		if v, ok := h.options[key]; ok && h.match(alias) {
+			return v
+		}
+	}
+	return ""
+}
+
+func expandHome(fpath string) string {
+	if fpath != "~" && !strings.HasPrefix(fpath, "~/") && !strings.HasPrefix(fpath, `~\`) {
+		return fpath
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fpath
+	}
+	return filepath.Join(homeDir, fpath[1:])
+}
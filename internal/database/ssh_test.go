@@ -0,0 +1,127 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSSHConfig_withSSHConfig(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "ssh_config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	hosts, err := parseSSHConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bastion := &SSHConfig{
+		Host:         "bastion.example.com",
+		Port:         2222,
+		User:         "jump",
+		PrivateKey:   "~/.ssh/bastion_ed25519",
+		UseSSHConfig: true,
+		ProxyJump:    []*SSHConfig{},
+	}
+	tests := []struct {
+		name string
+		cfg  *SSHConfig
+		want *SSHConfig
+	}{
+		{
+			name: "jump host",
+			cfg:  &SSHConfig{Host: "db-prod", UseSSHConfig: true},
+			want: &SSHConfig{
+				Host:                  "10.0.0.5",
+				User:                  "dbadmin",
+				PrivateKey:            "~/.ssh/first_ed25519",
+				UseSSHConfig:          true,
+				StrictHostKeyChecking: true,
+				KnownHostsFile:        "~/.ssh/known_hosts_db",
+				ProxyJump:             []*SSHConfig{bastion},
+			},
+		},
+		{
+			name: "negated pattern",
+			cfg:  &SSHConfig{Host: "db-public", UseSSHConfig: true},
+			want: &SSHConfig{
+				Host:         "db.example.com",
+				User:         "default",
+				PrivateKey:   "~/.ssh/id_ed25519",
+				UseSSHConfig: true,
+				ProxyJump:    []*SSHConfig{},
+			},
+		},
+		{
+			name: "sqls config takes precedence",
+			cfg: &SSHConfig{
+				Host:         "db-prod",
+				User:         "root",
+				Port:         22,
+				UseAgent:     true,
+				UseSSHConfig: true,
+				ProxyJump:    []*SSHConfig{{Host: "other.example.com", User: "other"}},
+			},
+			want: &SSHConfig{
+				Host:                  "10.0.0.5",
+				Port:                  22,
+				User:                  "root",
+				PrivateKey:            "~/.ssh/first_ed25519",
+				UseAgent:              true,
+				UseSSHConfig:          true,
+				StrictHostKeyChecking: true,
+				KnownHostsFile:        "~/.ssh/known_hosts_db",
+				ProxyJump: []*SSHConfig{
+					{
+						Host:         "other.example.com",
+						User:         "other",
+						PrivateKey:   "~/.ssh/id_ed25519",
+						UseSSHConfig: true,
+						ProxyJump:    []*SSHConfig{},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.withSSHConfig(hosts)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseProxyJump(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []*SSHConfig
+	}{
+		{value: "", want: nil},
+		{value: "none", want: nil},
+		{
+			value: "bastion",
+			want:  []*SSHConfig{{Host: "bastion"}},
+		},
+		{
+			value: "jump@bastion:2222, admin@10.0.0.1",
+			want: []*SSHConfig{
+				{Host: "bastion", Port: 2222, User: "jump"},
+				{Host: "10.0.0.1", User: "admin"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := parseProxyJump(tt.value)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+var envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Resolve returns a copy of the config with ${ENV_VAR} references expanded
+// and the values of passwordCmd and dsnCmd taken from the output of the
+// commands, the password of passwordStore taken from the keyring and the
+// credentials of vault taken from Vault. They are read every time a
+// connection is opened, so that the credentials are not stored in the
+// config.
+func (c *DBConfig) Resolve() (*DBConfig, error) {
+	r, lease, err := c.resolve()
+	if lease != nil {
+		// Only the connections opened with the credentials keep the lease
+		lease.stop()
+	}
+	return r, err
+}
+
+// resolve is Resolve that also returns the Vault lease of the credentials.
+func (c *DBConfig) resolve() (*DBConfig, *vaultLease, error) {
+	r := *c
+	var err error
+	expand := func(s string) string {
+		if err != nil {
+			return s
+		}
+		var v string
+		v, err = expandEnv(s)
+		return v
+	}
+	r.DataSourceName = expand(c.DataSourceName)
+	r.DataSourceNameCmd = expand(c.DataSourceNameCmd)
+	r.User = expand(c.User)
+	r.Passwd = expand(c.Passwd)
+	r.PasswdCmd = expand(c.PasswdCmd)
+	r.Host = expand(c.Host)
+	r.Path = expand(c.Path)
+	r.DBName = expand(c.DBName)
+	if c.Params != nil {
+		r.Params = make(map[string]string, len(c.Params))
+		for k, v := range c.Params {
+			r.Params[k] = expand(v)
+		}
+	}
+	var expandSSH func(*SSHConfig) *SSHConfig
+	expandSSH = func(c *SSHConfig) *SSHConfig {
+		ssh := *c
+		ssh.Host = expand(ssh.Host)
+		ssh.User = expand(ssh.User)
+		ssh.PassPhrase = expand(ssh.PassPhrase)
+		ssh.PrivateKey = expand(ssh.PrivateKey)
+		ssh.KnownHostsFile = expand(ssh.KnownHostsFile)
+		ssh.ProxyJump = nil
+		for _, jump := range c.ProxyJump {
+			ssh.ProxyJump = append(ssh.ProxyJump, expandSSH(jump))
+		}
+		return &ssh
+	}
+	if c.SSHCfg != nil {
+		r.SSHCfg = expandSSH(c.SSHCfg)
+	}
+	if c.TLSCfg != nil {
+		tls := *c.TLSCfg
+		tls.CAFile = expand(tls.CAFile)
+		tls.CertFile = expand(tls.CertFile)
+		tls.KeyFile = expand(tls.KeyFile)
+		tls.ServerName = expand(tls.ServerName)
+		r.TLSCfg = &tls
+	}
+	if c.AWSCfg != nil {
+		aws := *c.AWSCfg
+		aws.Region = expand(aws.Region)
+		aws.Profile = expand(aws.Profile)
+		r.AWSCfg = &aws
+	}
+	if c.CloudSQLCfg != nil {
+		cloudSQL := *c.CloudSQLCfg
+		cloudSQL.Instance = expand(cloudSQL.Instance)
+		cloudSQL.TokenCmd = expand(cloudSQL.TokenCmd)
+		r.CloudSQLCfg = &cloudSQL
+	}
+	if c.AzureADCfg != nil {
+		azure := *c.AzureADCfg
+		azure.TenantID = expand(azure.TenantID)
+		azure.ClientID = expand(azure.ClientID)
+		azure.ClientSecret = expand(azure.ClientSecret)
+		r.AzureADCfg = &azure
+	}
+	if c.KerberosCfg != nil {
+		kerberos := *c.KerberosCfg
+		kerberos.Keytab = expand(kerberos.Keytab)
+		kerberos.CredentialCache = expand(kerberos.CredentialCache)
+		kerberos.Principal = expand(kerberos.Principal)
+		kerberos.SPN = expand(kerberos.SPN)
+		r.KerberosCfg = &kerberos
+	}
+	if c.VaultCfg != nil {
+		vault := *c.VaultCfg
+		vault.Address = expand(vault.Address)
+		vault.Token = expand(vault.Token)
+		vault.Namespace = expand(vault.Namespace)
+		vault.Path = expand(vault.Path)
+		r.VaultCfg = &vault
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.PasswdCmd != "" {
+		if r.Passwd, err = runCommand(r.PasswdCmd); err != nil {
+			return nil, nil, err
+		}
+	}
+	if r.PasswdStore == PasswordStoreKeyring {
+		if r.Passwd, err = keyringPassword(&r); err != nil {
+			return nil, nil, err
+		}
+	}
+	if r.DataSourceNameCmd != "" {
+		if r.DataSourceName, err = runCommand(r.DataSourceNameCmd); err != nil {
+			return nil, nil, err
+		}
+	}
+	var lease *vaultLease
+	if r.VaultCfg != nil {
+		var user string
+		if user, r.Passwd, lease, err = vaultCredentials(r.VaultCfg); err != nil {
+			return nil, nil, err
+		}
+		if user != "" {
+			r.User = user
+		}
+	}
+	return &r, lease, nil
+}
+
+func expandEnv(s string) (string, error) {
+	var missing []string
+	expanded := envVarRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envVarRegexp.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable is not set, %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// commandTimeout is the time to wait for the commands printing the
+// credentials, such as the ones waiting for the input of the user.
+var commandTimeout = 30 * time.Second
+
+func runCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	// The children of the shell may keep the output open after it is killed
+	cmd.WaitDelay = time.Second
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("cannot execute command %q, timed out after %s", command, commandTimeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot execute command %q, %w, %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
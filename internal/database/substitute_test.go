@@ -0,0 +1,112 @@
+package database
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDBConfig_Resolve(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are run with sh")
+	}
+	t.Setenv("SQLS_TEST_USER", "root")
+	t.Setenv("SQLS_TEST_HOST", "127.0.0.1")
+	t.Setenv("SQLS_TEST_DSN", "file:/tmp/test.db")
+
+	tests := []struct {
+		name    string
+		cfg     *DBConfig
+		want    *DBConfig
+		wantErr bool
+	}{
+		{
+			name: "env",
+			cfg: &DBConfig{
+				Driver: "mysql",
+				User:   "${SQLS_TEST_USER}",
+				Passwd: "pa$$word",
+				Host:   "${SQLS_TEST_HOST}",
+				Params: map[string]string{"user": "${SQLS_TEST_USER}"},
+				SSHCfg: &SSHConfig{Host: "${SQLS_TEST_HOST}"},
+			},
+			want: &DBConfig{
+				Driver: "mysql",
+				User:   "root",
+				Passwd: "pa$$word",
+				Host:   "127.0.0.1",
+				Params: map[string]string{"user": "root"},
+				SSHCfg: &SSHConfig{Host: "127.0.0.1"},
+			},
+		},
+		{
+			name: "undefined env",
+			cfg: &DBConfig{
+				Driver: "mysql",
+				User:   "${SQLS_TEST_UNDEFINED}",
+			},
+			wantErr: true,
+		},
+		{
+			name: "password command",
+			cfg: &DBConfig{
+				Driver:    "mysql",
+				PasswdCmd: "echo secret",
+			},
+			want: &DBConfig{
+				Driver:    "mysql",
+				Passwd:    "secret",
+				PasswdCmd: "echo secret",
+			},
+		},
+		{
+			name: "dsn command",
+			cfg: &DBConfig{
+				Driver:            "sqlite3",
+				DataSourceNameCmd: "echo ${SQLS_TEST_DSN}",
+			},
+			want: &DBConfig{
+				Driver:            "sqlite3",
+				DataSourceName:    "file:/tmp/test.db",
+				DataSourceNameCmd: "echo file:/tmp/test.db",
+			},
+		},
+		{
+			name: "failed command",
+			cfg: &DBConfig{
+				Driver:    "mysql",
+				PasswdCmd: "exit 1",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.Resolve()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatch (- want, + got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are run with sh")
+	}
+	defer func(d time.Duration) { commandTimeout = d }(commandTimeout)
+	commandTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	if _, err := runCommand("sleep 10; echo secret"); err == nil {
+		t.Fatal("runCommand() error = nil, want the timeout")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("runCommand() returned after %s", d)
+	}
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSConfig is the driver independent TLS setting of a connection, which is
+// translated into the TLS setting of each driver.
+type TLSConfig struct {
+	CAFile     string `json:"caFile" yaml:"caFile"`
+	CertFile   string `json:"certFile" yaml:"certFile"`
+	KeyFile    string `json:"keyFile" yaml:"keyFile"`
+	ServerName string `json:"serverName" yaml:"serverName"`
+	SkipVerify bool   `json:"skipVerify" yaml:"skipVerify"`
+}
+
+func (t *TLSConfig) Validate() error {
+	if t.CertFile != "" && t.KeyFile == "" {
+		return errors.New("required: connections[].tls.keyFile")
+	}
+	if t.KeyFile != "" && t.CertFile == "" {
+		return errors.New("required: connections[].tls.certFile")
+	}
+	return nil
+}
+
+// Config returns the TLS config to connect to host. host is used as the
+// server name unless ServerName is set.
+func (t *TLSConfig) Config(host string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.SkipVerify,
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(expandHome(t.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA file, CAFile=%s, %w", t.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("cannot parse CA file, CAFile=%s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(expandHome(t.CertFile), expandHome(t.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate, CertFile=%s, %w", t.CertFile, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// tlsConfigName returns the name to register the TLS config of the
+// connection with the drivers which look up TLS configs by name.
+func tlsConfigName(connCfg *DBConfig) string {
+	if connCfg.Alias != "" {
+		return "sqls-" + connCfg.Alias
+	}
+	return fmt.Sprintf("sqls-%s-%d", connCfg.Host, connCfg.Port)
+}
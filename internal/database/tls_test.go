@@ -0,0 +1,124 @@
+package database
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and its key in PEM format.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sqls test"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSConfig_Config(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	tests := []struct {
+		name           string
+		cfg            *TLSConfig
+		wantServerName string
+		wantRootCAs    bool
+		wantCerts      int
+		wantErr        bool
+	}{
+		{
+			name:           "host as server name",
+			cfg:            &TLSConfig{},
+			wantServerName: "db.example.com",
+		},
+		{
+			name: "all",
+			cfg: &TLSConfig{
+				CAFile:     certFile,
+				CertFile:   certFile,
+				KeyFile:    keyFile,
+				ServerName: "db.internal",
+			},
+			wantServerName: "db.internal",
+			wantRootCAs:    true,
+			wantCerts:      1,
+		},
+		{
+			name:    "CA file not found",
+			cfg:     &TLSConfig{CAFile: filepath.Join(dir, "notfound.pem")},
+			wantErr: true,
+		},
+		{
+			name:    "invalid CA file",
+			cfg:     &TLSConfig{CAFile: keyFile},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.Config("db.example.com")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Config() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.ServerName != tt.wantServerName {
+				t.Errorf("ServerName = %q, want %q", got.ServerName, tt.wantServerName)
+			}
+			if (got.RootCAs != nil) != tt.wantRootCAs {
+				t.Errorf("RootCAs = %v, want %v", got.RootCAs, tt.wantRootCAs)
+			}
+			if len(got.Certificates) != tt.wantCerts {
+				t.Errorf("Certificates = %d, want %d", len(got.Certificates), tt.wantCerts)
+			}
+		})
+	}
+}
+
+func Test_genPostgresConnConfig(t *testing.T) {
+	conf, err := genPostgresConnConfig("host=db.example.com user=postgres sslmode=prefer", &TLSConfig{SkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.TLSConfig == nil || !conf.TLSConfig.InsecureSkipVerify || conf.TLSConfig.ServerName != "db.example.com" {
+		t.Errorf("unexpected TLS config, %+v", conf.TLSConfig)
+	}
+	if len(conf.Fallbacks) != 0 {
+		t.Errorf("unexpected fallbacks, %d", len(conf.Fallbacks))
+	}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+var (
+	ErrNoTransaction = errors.New("no transaction in progress")
+)
+
+// Executor runs queries against a database. It is satisfied by both
+// DBRepository and Transaction.
+type Executor interface {
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Copier runs COPY ... FROM STDIN sending the rows read from r, and returns
+// the number of the rows copied.
+type Copier interface {
+	CopyFrom(ctx context.Context, query string, r io.Reader) (int64, error)
+}
+
+// Transaction holds a dedicated connection from the pool so that
+// statements executed through it share the same session and transaction.
+type Transaction struct {
+	db        *sql.DB
+	conn      *sql.Conn
+	tx        *sql.Tx
+	driver    dialect.DatabaseDriver
+	StartedAt time.Time
+}
+
+func BeginTransaction(ctx context.Context, driver dialect.DatabaseDriver, db *sql.DB) (*Transaction, error) {
+	if db == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get connection, %w", err)
+	}
+	// The transaction outlives the request that started it, so it must not be
+	// bound to the request context.
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot begin transaction, %w", err)
+	}
+	return &Transaction{
+		db:        db,
+		conn:      conn,
+		tx:        tx,
+		driver:    driver,
+		StartedAt: time.Now(),
+	}, nil
+}
+
+func (t *Transaction) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+// ExecWarnings runs the statement in the transaction, and returns the
+// warnings of MySQL or the notices of PostgreSQL.
+func (t *Transaction) ExecWarnings(ctx context.Context, query string, args ...interface{}) (sql.Result, []string, error) {
+	if isMySQL(t.driver) {
+		result, err := t.tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings, err := mysqlWarnings(ctx, t.tx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result, warnings, nil
+	}
+	var result sql.Result
+	notices, err := postgresNotices.collect(t.conn, func() error {
+		var err error
+		result, err = t.tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, notices, nil
+}
+
+// QueryWarnings runs the query in the transaction, and returns the warnings
+// of MySQL or the notices of PostgreSQL.
+func (t *Transaction) QueryWarnings(ctx context.Context, query string, fn func(*sql.Rows) error, args ...interface{}) ([]string, error) {
+	if isMySQL(t.driver) {
+		if err := queryRows(ctx, t.tx, query, fn, args...); err != nil {
+			return nil, err
+		}
+		return mysqlWarnings(ctx, t.tx)
+	}
+	return postgresNotices.collect(t.conn, func() error {
+		return queryRows(ctx, t.tx, query, fn, args...)
+	})
+}
+
+func (t *Transaction) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+func (t *Transaction) CopyFrom(ctx context.Context, query string, r io.Reader) (int64, error) {
+	return copyFrom(ctx, t.conn, query, r)
+}
+
+// Of reports whether the transaction is on a connection of the pool.
+func (t *Transaction) Of(db *sql.DB) bool {
+	return t.db == db
+}
+
+func (t *Transaction) Commit() error {
+	if err := t.tx.Commit(); err != nil {
+		t.conn.Close()
+		return fmt.Errorf("cannot commit transaction, %w", err)
+	}
+	return t.conn.Close()
+}
+
+func (t *Transaction) Rollback() error {
+	if err := t.tx.Rollback(); err != nil {
+		t.conn.Close()
+		return fmt.Errorf("cannot rollback transaction, %w", err)
+	}
+	return t.conn.Close()
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TypeKind is the kind of a type defined by the users or by the extensions.
+type TypeKind string
+
+const (
+	TypeKindEnum      TypeKind = "enum"
+	TypeKindDomain    TypeKind = "domain"
+	TypeKindComposite TypeKind = "composite"
+	// TypeKindBase is the type of an extension, such as citext, hstore and
+	// geometry of PostGIS
+	TypeKindBase TypeKind = "base"
+)
+
+// TypeDesc is a type defined by the users or by the extensions.
+type TypeDesc struct {
+	Schema string
+	Name   string
+	Kind   TypeKind
+	// Labels are the values of the enum
+	Labels []string
+	// BaseType is the type of the values of the domain
+	BaseType string
+	// Fields are the fields of the composite type
+	Fields []*TypeField
+}
+
+// TypeField is a field of a composite type.
+type TypeField struct {
+	Name string
+	Type string
+}
+
+// TypeRepository is the repository of the databases whose types defined by
+// the users are cached, such as the enums, the domains and the composite
+// types of PostgreSQL.
+type TypeRepository interface {
+	Types(ctx context.Context) ([]*TypeDesc, error)
+}
+
+// Type returns the type of the name, which the schema may qualify.
+func (dc *DBCache) Type(name string) (*TypeDesc, bool) {
+	if dc == nil {
+		return nil, false
+	}
+	schema := ""
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		schema, name = name[:i], name[i+1:]
+	}
+	for _, t := range dc.Types {
+		if schema != "" && !dc.MatchIdentifier(schema, t.Schema) {
+			continue
+		}
+		if dc.MatchIdentifier(name, t.Name) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// TypeDoc returns the document of the labels of the enum, the type of the
+// domain or the fields of the composite type, or "" for the other types.
+func TypeDoc(t *TypeDesc) string {
+	buf := new(bytes.Buffer)
+	switch t.Kind {
+	case TypeKindEnum:
+		labels := make([]string, len(t.Labels))
+		for i, label := range t.Labels {
+			labels[i] = "'" + label + "'"
+		}
+		fmt.Fprintf(buf, "`%s` enum of %s", t.Name, strings.Join(labels, ", "))
+		fmt.Fprintln(buf)
+	case TypeKindDomain:
+		fmt.Fprintf(buf, "`%s` domain of `%s`", t.Name, t.BaseType)
+		fmt.Fprintln(buf)
+	case TypeKindComposite:
+		fmt.Fprintf(buf, "`%s` composite type", t.Name)
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf)
+		fmt.Fprintln(buf, "| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; |")
+		fmt.Fprintln(buf, "| :--------------- | :--------------- |")
+		for _, f := range t.Fields {
+			fmt.Fprintf(buf, "| `%s` | `%s` |", f.Name, f.Type)
+			fmt.Fprintln(buf)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,309 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sqls-server/sqls/internal/logging"
+)
+
+const (
+	vaultDefaultAddress = "https://127.0.0.1:8200"
+	vaultRequestTimeout = 10 * time.Second
+	// The credentials are rotated this long before the lease expires
+	vaultRotateLeeway = 2 * time.Minute
+	vaultMinRetryWait = 5 * time.Second
+)
+
+// VaultConfig reads the user and the password of the connection from a
+// secret of HashiCorp Vault, either a static KV secret or the dynamic
+// credentials of the database secrets engine.
+type VaultConfig struct {
+	Address     string `json:"address" yaml:"address"`
+	Token       string `json:"token" yaml:"token"`
+	Namespace   string `json:"namespace" yaml:"namespace"`
+	Path        string `json:"path" yaml:"path"`
+	UserKey     string `json:"userKey" yaml:"userKey"`
+	PasswordKey string `json:"passwordKey" yaml:"passwordKey"`
+}
+
+func (c *VaultConfig) Validate() error {
+	if c.Path == "" {
+		return errors.New("required: connections[].vault.path")
+	}
+	return nil
+}
+
+type vaultClient struct {
+	address   string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// newVaultClient takes the address, the token and the namespace that are not
+// configured from the environment in the same way as the vault CLI.
+func newVaultClient(cfg *VaultConfig) (*vaultClient, error) {
+	c := &vaultClient{
+		address:   cfg.Address,
+		token:     cfg.Token,
+		namespace: cfg.Namespace,
+		client:    http.DefaultClient,
+	}
+	if c.address == "" {
+		c.address = os.Getenv("VAULT_ADDR")
+	}
+	if c.address == "" {
+		c.address = vaultDefaultAddress
+	}
+	if c.namespace == "" {
+		c.namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if c.token == "" {
+		c.token = os.Getenv("VAULT_TOKEN")
+	}
+	if c.token == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		b, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+		if err != nil {
+			return nil, errors.New("vault token is not found, set connections[].vault.token or VAULT_TOKEN")
+		}
+		c.token = strings.TrimSpace(string(b))
+	}
+	return c, nil
+}
+
+type vaultSecret struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+func (c *vaultClient) read(path string) (*vaultSecret, error) {
+	var secret vaultSecret
+	if err := c.do(http.MethodGet, path, nil, &secret); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (c *vaultClient) renew(leaseID string, increment time.Duration) (*vaultSecret, error) {
+	body := map[string]interface{}{
+		"lease_id":  leaseID,
+		"increment": int(increment.Seconds()),
+	}
+	var secret vaultSecret
+	if err := c.do(http.MethodPut, "sys/leases/renew", body, &secret); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (c *vaultClient) revoke(leaseID string) error {
+	return c.do(http.MethodPut, "sys/leases/revoke", map[string]interface{}{"lease_id": leaseID}, nil)
+}
+
+func (c *vaultClient) do(method, path string, body, result interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), vaultRequestTimeout)
+	defer cancel()
+
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	endpoint := strings.TrimSuffix(c.address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", c.token)
+	req.Header.Set("X-Vault-Request", "true")
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var res struct {
+			Errors []string `json:"errors"`
+		}
+		if err := json.Unmarshal(b, &res); err == nil && len(res.Errors) > 0 {
+			return fmt.Errorf("%s, %s", resp.Status, strings.Join(res.Errors, ", "))
+		}
+		return fmt.Errorf("%s, %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	if result == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, result)
+}
+
+// vaultCredentials reads the user and the password from the secret. The
+// lease of dynamic credentials is returned to be kept alive while the
+// connection is open.
+func vaultCredentials(cfg *VaultConfig) (user, password string, lease *vaultLease, err error) {
+	client, err := newVaultClient(cfg)
+	if err != nil {
+		return "", "", nil, err
+	}
+	secret, err := client.read(cfg.Path)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("cannot read vault secret %s, %w", cfg.Path, err)
+	}
+	data := secret.Data
+	// The KV version 2 engine nests the secret in data
+	if inner, ok := data["data"].(map[string]interface{}); ok && secret.LeaseID == "" {
+		data = inner
+	}
+	userKey, passwordKey := cfg.UserKey, cfg.PasswordKey
+	if userKey == "" {
+		userKey = "username"
+	}
+	if passwordKey == "" {
+		passwordKey = "password"
+	}
+	user, _ = data[userKey].(string)
+	password, ok := data[passwordKey].(string)
+	if !ok {
+		return "", "", nil, fmt.Errorf("vault secret %s has no %s", cfg.Path, passwordKey)
+	}
+	if secret.LeaseID != "" {
+		lease = newVaultLease(client, secret)
+	}
+	return user, password, lease, nil
+}
+
+// vaultLease renews the lease of dynamic credentials in the background. Once
+// it cannot be extended any longer, the connection has to be opened again
+// with new credentials before it expires.
+type vaultLease struct {
+	client   *vaultClient
+	id       string
+	duration time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	expires time.Time
+	// final is set when the lease cannot be renewed any longer
+	final bool
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newVaultLease(client *vaultClient, secret *vaultSecret) *vaultLease {
+	duration := time.Duration(secret.LeaseDuration) * time.Second
+	return &vaultLease{
+		client:   client,
+		id:       secret.LeaseID,
+		duration: duration,
+		now:      time.Now,
+		expires:  time.Now().Add(duration),
+		final:    !secret.Renewable,
+		done:     make(chan struct{}),
+	}
+}
+
+func (l *vaultLease) start() {
+	go func() {
+		defer func() {
+			logging.Recovered(slog.Default(), recover(), "renewing vault lease")
+		}()
+		wait := l.duration * 2 / 3
+		for {
+			select {
+			case <-l.done:
+				return
+			case <-time.After(wait):
+			}
+			var ok bool
+			if wait, ok = l.renew(); !ok {
+				return
+			}
+		}
+	}()
+}
+
+// renew extends the lease, and returns the wait until the next renewal and
+// whether to renew it again.
+func (l *vaultLease) renew() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.final {
+		return 0, false
+	}
+	secret, err := l.client.renew(l.id, l.duration)
+	now := l.now()
+	if err != nil {
+		slog.Warn("cannot renew vault lease", "err", err)
+		remaining := l.expires.Sub(now)
+		if remaining <= l.leeway() {
+			l.final = true
+			return 0, false
+		}
+		wait := remaining / 3
+		if wait < vaultMinRetryWait {
+			wait = vaultMinRetryWait
+		}
+		return wait, true
+	}
+	granted := time.Duration(secret.LeaseDuration) * time.Second
+	l.expires = now.Add(granted)
+	if granted < l.duration || !secret.Renewable {
+		// The max TTL of the lease is reached
+		l.final = true
+		return 0, false
+	}
+	return granted * 2 / 3, true
+}
+
+func (l *vaultLease) leeway() time.Duration {
+	if d := l.duration / 3; d < vaultRotateLeeway {
+		return d
+	}
+	return vaultRotateLeeway
+}
+
+// expiring reports whether the credentials have to be replaced.
+func (l *vaultLease) expiring() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.final && !l.now().Before(l.expires.Add(-l.leeway()))
+}
+
+// stop ends the renewal and revokes the lease, since the credentials are not
+// used any longer.
+func (l *vaultLease) stop() {
+	l.stopOnce.Do(func() {
+		close(l.done)
+		if err := l.client.revoke(l.id); err != nil {
+			slog.Warn("cannot revoke vault lease", "err", err)
+		}
+	})
+}
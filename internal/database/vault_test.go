@@ -0,0 +1,236 @@
+package database
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestVault(t *testing.T, renewDuration *int) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/database/creds/readonly":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       "database/creds/readonly/abc",
+				"lease_duration": 3600,
+				"renewable":      true,
+				"data":           map[string]string{"username": "v-token-readonly", "password": "dynamic"},
+			})
+		case "/v1/secret/data/app":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"data":     map[string]string{"user": "app", "pass": "static"},
+					"metadata": map[string]interface{}{"version": 1},
+				},
+			})
+		case "/v1/sys/leases/renew":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["lease_id"] != "database/creds/readonly/abc" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"invalid lease"}})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"lease_id":       body["lease_id"],
+				"lease_duration": *renewDuration,
+				"renewable":      true,
+			})
+		case "/v1/sys/leases/revoke":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestVaultCredentials(t *testing.T) {
+	renewDuration := 3600
+	server, _ := newTestVault(t, &renewDuration)
+
+	tests := []struct {
+		name         string
+		cfg          *VaultConfig
+		wantUser     string
+		wantPassword string
+		wantLease    bool
+		wantErr      bool
+	}{
+		{
+			name:         "dynamic",
+			cfg:          &VaultConfig{Address: server.URL, Token: "root", Path: "database/creds/readonly"},
+			wantUser:     "v-token-readonly",
+			wantPassword: "dynamic",
+			wantLease:    true,
+		},
+		{
+			name:         "kv v2",
+			cfg:          &VaultConfig{Address: server.URL, Token: "root", Path: "secret/data/app", UserKey: "user", PasswordKey: "pass"},
+			wantUser:     "app",
+			wantPassword: "static",
+		},
+		{
+			name:    "missing password",
+			cfg:     &VaultConfig{Address: server.URL, Token: "root", Path: "secret/data/app"},
+			wantErr: true,
+		},
+		{
+			name:    "permission denied",
+			cfg:     &VaultConfig{Address: server.URL, Token: "invalid", Path: "database/creds/readonly"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, password, lease, err := vaultCredentials(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("vaultCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if user != tt.wantUser || password != tt.wantPassword {
+				t.Errorf("got %q/%q, want %q/%q", user, password, tt.wantUser, tt.wantPassword)
+			}
+			if (lease != nil) != tt.wantLease {
+				t.Errorf("lease = %v, wantLease %v", lease, tt.wantLease)
+			}
+		})
+	}
+}
+
+func TestVaultLease(t *testing.T) {
+	renewDuration := 3600
+	server, requests := newTestVault(t, &renewDuration)
+	client, err := newVaultClient(&VaultConfig{Address: server.URL, Token: "root"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	lease := newVaultLease(client, &vaultSecret{
+		LeaseID:       "database/creds/readonly/abc",
+		LeaseDuration: 3600,
+		Renewable:     true,
+	})
+	lease.now = func() time.Time { return now }
+
+	if wait, ok := lease.renew(); !ok || wait != 40*time.Minute {
+		t.Errorf("renew() = %v, %v, want %v, true", wait, ok, 40*time.Minute)
+	}
+	if lease.expiring() {
+		t.Error("renewed lease must not expire")
+	}
+
+	// The max TTL cuts the lease short
+	renewDuration = 600
+	if _, ok := lease.renew(); ok {
+		t.Error("lease reaching the max TTL must not be renewed again")
+	}
+	if lease.expiring() {
+		t.Error("lease must not expire before the leeway")
+	}
+	now = now.Add(9 * time.Minute)
+	if !lease.expiring() {
+		t.Error("lease must expire within the leeway")
+	}
+
+	lease.stop()
+	lease.stop()
+	want := []string{"PUT /v1/sys/leases/renew", "PUT /v1/sys/leases/renew", "PUT /v1/sys/leases/revoke"}
+	if got := *requests; len(got) != len(want) || got[2] != want[2] {
+		t.Errorf("got requests %v, want %v", got, want)
+	}
+}
+
+func TestOpenWithVault(t *testing.T) {
+	renewDuration := 3600
+	server, requests := newTestVault(t, &renewDuration)
+
+	cfg := &DBConfig{
+		Driver:         "sqlite3",
+		DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+		VaultCfg:       &VaultConfig{Address: server.URL, Token: "root", Path: "database/creds/readonly"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.vaultLease == nil || conn.CredentialsExpiring() {
+		t.Fatal("connection must keep the lease of the credentials")
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := *requests; got[len(got)-1] != "PUT /v1/sys/leases/revoke" {
+		t.Errorf("lease is not revoked on close, %v", got)
+	}
+}
+
+func TestDBConfig_ValidateVault(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *DBConfig
+		wantErr bool
+	}{
+		{
+			name: "user from vault",
+			cfg: &DBConfig{
+				Driver:   "postgresql",
+				Proto:    ProtoTCP,
+				Host:     "127.0.0.1",
+				VaultCfg: &VaultConfig{Path: "database/creds/readonly"},
+			},
+		},
+		{
+			name: "no path",
+			cfg: &DBConfig{
+				Driver:   "postgresql",
+				Proto:    ProtoTCP,
+				Host:     "127.0.0.1",
+				VaultCfg: &VaultConfig{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "with passwd",
+			cfg: &DBConfig{
+				Driver:   "postgresql",
+				Proto:    ProtoTCP,
+				User:     "postgres",
+				Passwd:   "secret",
+				Host:     "127.0.0.1",
+				VaultCfg: &VaultConfig{Path: "database/creds/readonly"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/sqls-server/sqls/dialect"
-	_ "github.com/vertica/vertica-sql-go"
-	"log"
+	vertica "github.com/vertica/vertica-sql-go"
+	"net/url"
 	"strconv"
+
+	"github.com/sqls-server/sqls/internal/logging"
 )
 
 func init() {
@@ -24,13 +26,18 @@ func verticaOpen(dbConnCfg *DBConfig) (*DBConnection, error) {
 		return nil, err
 	}
 
+	if dbConnCfg.TLSCfg != nil {
+		if DSName, err = registerVerticaTLSConfig(DSName, dbConnCfg); err != nil {
+			return nil, err
+		}
+	}
+
 	conn, err = sql.Open("vertica", DSName)
 	if err != nil {
 		return nil, err
 	}
 
-	conn.SetMaxIdleConns(DefaultMaxIdleConns)
-	conn.SetMaxOpenConns(DefaultMaxOpenConns)
+	setConnPool(conn, dbConnCfg)
 
 	return &DBConnection{
 		Conn:   conn,
@@ -55,6 +62,31 @@ func genVerticaConfig(connCfg *DBConfig) (string, error) {
 	return DSName, nil
 }
 
+// registerVerticaTLSConfig registers the TLS config of the connection and
+// returns dsn with the tlsmode selecting it.
+func registerVerticaTLSConfig(dsn string, connCfg *DBConfig) (string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse data source name, %w", err)
+	}
+	host := u.Hostname()
+	if host == "" {
+		host = connCfg.Host
+	}
+	tlsConfig, err := connCfg.TLSCfg.Config(host)
+	if err != nil {
+		return "", err
+	}
+	name := tlsConfigName(connCfg)
+	if err := vertica.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", fmt.Errorf("cannot register TLS config, %w", err)
+	}
+	q := u.Query()
+	q.Set("tlsmode", name)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 type VerticaDBRepository struct {
 	Conn *sql.DB
 }
@@ -204,7 +236,7 @@ func (db *VerticaDBRepository) DescribeDatabaseTableBySchema(ctx context.Context
          WHERE table_schema = ?
 `, schemaName)
 	if err != nil {
-		log.Println("schema", schemaName, err.Error())
+		logging.FromContext(ctx).Warn("cannot describe schema", "schema", schemaName, "err", err)
 		return nil, err
 	}
 	tableInfos := []*ColumnDesc{}
@@ -229,14 +261,23 @@ func (db *VerticaDBRepository) DescribeDatabaseTableBySchema(ctx context.Context
 	return tableInfos, nil
 }
 
-func (db *VerticaDBRepository) Exec(ctx context.Context, query string) (sql.Result, error) {
-	return db.Conn.ExecContext(ctx, query)
+func (db *VerticaDBRepository) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.Conn.ExecContext(ctx, query, args...)
 }
 
-func (db *VerticaDBRepository) Query(ctx context.Context, query string) (*sql.Rows, error) {
-	return db.Conn.QueryContext(ctx, query)
+func (db *VerticaDBRepository) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.Conn.QueryContext(ctx, query, args...)
 }
 
 func (db *VerticaDBRepository) DescribeForeignKeysBySchema(ctx context.Context, schemaName string) ([]*ForeignKey, error) {
 	return nil, fmt.Errorf("describe foreign keys is not supported")
 }
+
+func (db *VerticaDBRepository) Roles(ctx context.Context) ([]string, error) {
+	return queryNames(ctx, db.Conn, `
+	SELECT user_name FROM v_catalog.users
+	UNION
+	SELECT name FROM v_catalog.roles
+	ORDER BY 1
+	`)
+}
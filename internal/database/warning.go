@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4/stdlib"
+)
+
+// WarningExecutor runs the statements returning the warnings of the database
+// along with their results, the ones of SHOW WARNINGS of MySQL and the NOTICEs
+// of PostgreSQL such as of RAISE NOTICE. QueryWarnings passes the rows to fn,
+// and closes them before the warnings are fetched.
+type WarningExecutor interface {
+	ExecWarnings(ctx context.Context, query string, args ...interface{}) (sql.Result, []string, error)
+	QueryWarnings(ctx context.Context, query string, fn func(*sql.Rows) error, args ...interface{}) ([]string, error)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// mysqlWarnings returns the warnings of the last statement of the session.
+func mysqlWarnings(ctx context.Context, q queryer) ([]string, error) {
+	rows, err := q.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, fmt.Errorf("cannot show warnings, %w", err)
+	}
+	defer rows.Close()
+	var warnings []string
+	for rows.Next() {
+		var level, message string
+		var code int
+		if err := rows.Scan(&level, &code, &message); err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, fmt.Sprintf("%s %d: %s", level, code, message))
+	}
+	return warnings, rows.Err()
+}
+
+// mysqlExecWarnings runs the statement and SHOW WARNINGS on the same session.
+func mysqlExecWarnings(ctx context.Context, db *sql.DB, query string, args ...interface{}) (sql.Result, []string, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get connection, %w", err)
+	}
+	defer conn.Close()
+	result, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings, err := mysqlWarnings(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, warnings, nil
+}
+
+// mysqlQueryWarnings runs the query and SHOW WARNINGS on the same session.
+func mysqlQueryWarnings(ctx context.Context, db *sql.DB, query string, fn func(*sql.Rows) error, args ...interface{}) ([]string, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get connection, %w", err)
+	}
+	defer conn.Close()
+	if err := queryRows(ctx, conn, query, fn, args...); err != nil {
+		return nil, err
+	}
+	return mysqlWarnings(ctx, conn)
+}
+
+// queryRows runs the query, and passes the rows to fn.
+func queryRows(ctx context.Context, q queryer, query string, fn func(*sql.Rows) error, args ...interface{}) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if err := fn(rows); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// noticeCollector keeps the NOTICEs sent to the connections of PostgreSQL
+// while their statements run. The notices of the other times are dropped.
+type noticeCollector struct {
+	mu      sync.Mutex
+	notices map[*pgconn.PgConn][]string
+}
+
+var postgresNotices = &noticeCollector{notices: map[*pgconn.PgConn][]string{}}
+
+// onNotice is the notice handler of the connections of PostgreSQL.
+func (c *noticeCollector) onNotice(conn *pgconn.PgConn, n *pgconn.Notice) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if notices, ok := c.notices[conn]; ok {
+		c.notices[conn] = append(notices, fmt.Sprintf("%s: %s", n.Severity, n.Message))
+	}
+}
+
+// collect calls run with the connection, and returns the notices sent to it
+// meanwhile.
+func (c *noticeCollector) collect(conn *sql.Conn, run func() error) ([]string, error) {
+	var pgConn *pgconn.PgConn
+	if err := conn.Raw(func(driverConn interface{}) error {
+		if sc, ok := driverConn.(*stdlib.Conn); ok {
+			pgConn = sc.Conn().PgConn()
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if pgConn == nil {
+		return nil, run()
+	}
+
+	c.mu.Lock()
+	c.notices[pgConn] = []string{}
+	c.mu.Unlock()
+	err := run()
+	c.mu.Lock()
+	notices := c.notices[pgConn]
+	delete(c.notices, pgConn)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return notices, nil
+}
+
+// postgresExecWarnings runs the statement on a connection of the pool, and
+// returns the notices of the statement.
+func postgresExecWarnings(ctx context.Context, db *sql.DB, query string, args ...interface{}) (sql.Result, []string, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get connection, %w", err)
+	}
+	defer conn.Close()
+	var result sql.Result
+	notices, err := postgresNotices.collect(conn, func() error {
+		var err error
+		result, err = conn.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, notices, nil
+}
+
+// postgresQueryWarnings runs the query on a connection of the pool, and
+// returns the notices sent until its rows are read.
+func postgresQueryWarnings(ctx context.Context, db *sql.DB, query string, fn func(*sql.Rows) error, args ...interface{}) ([]string, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get connection, %w", err)
+	}
+	defer conn.Close()
+	return postgresNotices.collect(conn, func() error {
+		return queryRows(ctx, conn, query, fn, args...)
+	})
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgconn"
+)
+
+func TestNoticeCollector(t *testing.T) {
+	c := &noticeCollector{notices: map[*pgconn.PgConn][]string{}}
+	collecting, other := &pgconn.PgConn{}, &pgconn.PgConn{}
+	c.notices[collecting] = []string{}
+
+	c.onNotice(collecting, &pgconn.Notice{Severity: "NOTICE", Message: `table "item" does not exist, skipping`})
+	c.onNotice(other, &pgconn.Notice{Severity: "NOTICE", Message: "dropped"})
+
+	want := []string{`NOTICE: table "item" does not exist, skipping`}
+	if got := c.notices[collecting]; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected notices, got %v, want %v", got, want)
+	}
+	if _, ok := c.notices[other]; ok {
+		t.Error("the notices of the connection not collecting are kept")
+	}
+}
+
+func TestNoticeCollectorOtherDriver(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c := &noticeCollector{notices: map[*pgconn.PgConn][]string{}}
+	var result sql.Result
+	notices, err := c.collect(conn, func() error {
+		var err error
+		result, err = conn.ExecContext(ctx, "CREATE TABLE item (id INTEGER)")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || notices != nil {
+		t.Errorf("unexpected result, got %v, %v", result, notices)
+	}
+}
@@ -2,17 +2,23 @@ package database
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"sync"
+
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/metrics"
 )
 
 type Worker struct {
 	dbRepo  DBRepository
 	dbCache *DBCache
 
-	done   chan struct{}
-	update chan struct{}
-	lock   sync.Mutex
+	done     chan struct{}
+	update   chan struct{}
+	lock     sync.Mutex
+	stopOnce sync.Once
+	// exited is closed when the goroutine of Start returns
+	exited chan struct{}
 }
 
 func NewWorker() *Worker {
@@ -22,7 +28,11 @@ func NewWorker() *Worker {
 	}
 }
 
+// Cache returns the cache, which is replaced instead of being changed so
+// that the sessions sharing the worker read it while it is updated.
 func (w *Worker) Cache() *DBCache {
+	w.lock.Lock()
+	defer w.lock.Unlock()
 	return w.dbCache
 }
 
@@ -30,58 +40,136 @@ func (w *Worker) setCache(c *DBCache) {
 	w.lock.Lock()
 	defer w.lock.Unlock()
 	w.dbCache = c
+	recordCacheSize(c)
 }
 
 func (w *Worker) setColumnCache(col map[string][]*ColumnDesc) {
 	w.lock.Lock()
 	defer w.lock.Unlock()
 	if w.dbCache != nil {
-		w.dbCache.ColumnsWithParent = col
+		c := w.dbCache.Clone()
+		c.ColumnsWithParent = col
+		w.dbCache = c
+		recordCacheSize(c)
+	}
+}
+
+// LoadCatalog caches the tables of another database of the server which the
+// names of three parts refer to, unless it is cached or the database has no
+// such names. The database which cannot be described is cached empty, not to
+// query it again until the cache is regenerated.
+func (w *Worker) LoadCatalog(ctx context.Context, catalog string) error {
+	w.lock.Lock()
+	repo, cache := w.dbRepo, w.dbCache
+	w.lock.Unlock()
+	cr, ok := repo.(CatalogRepository)
+	if !ok || cache == nil || cache.HasCatalog(catalog) {
+		return nil
+	}
+	cols, err := cr.DescribeCatalogTable(ctx, catalog)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.dbCache != nil {
+		c := w.dbCache.Clone()
+		c.AddCatalog(catalog, cols)
+		w.dbCache = c
+	}
+	return err
+}
+
+// recordCacheSize records the numbers of the schemas, the tables, the columns
+// and the foreign keys of the cache to the metrics.
+func recordCacheSize(c *DBCache) {
+	var schemas, tables, columns, foreignKeys int
+	if c != nil {
+		schemas = len(c.Schemas)
+		for _, t := range c.SchemaTables {
+			tables += len(t)
+		}
+		for _, cols := range c.ColumnsWithParent {
+			columns += len(cols)
+		}
+		for _, fks := range c.ForeignKeys {
+			for _, fk := range fks {
+				foreignKeys += len(fk)
+			}
+		}
 	}
+	metrics.SetCacheSize("schemas", schemas)
+	metrics.SetCacheSize("tables", tables)
+	metrics.SetCacheSize("columns", columns)
+	metrics.SetCacheSize("foreign_keys", foreignKeys)
 }
 
 func (w *Worker) Start() {
+	w.exited = make(chan struct{})
 	go func() {
-		log.Println("db worker: start")
+		defer close(w.exited)
+		slog.Debug("db worker: start")
 		for {
 			select {
 			case <-w.done:
-				log.Println("db worker: done")
+				slog.Debug("db worker: done")
 				return
 			case <-w.update:
-				generator := NewDBCacheUpdater(w.dbRepo)
-				col, err := generator.GenerateDBCacheSecondary(context.Background())
-				if err != nil {
-					log.Println(err)
-				}
-				w.setColumnCache(col)
-				log.Println("db worker: Update db cache secondary complete")
+				w.updateSecondaryCache()
 			}
 		}
 	}()
 }
 
+func (w *Worker) updateSecondaryCache() {
+	// The worker keeps running for the next update
+	defer func() {
+		logging.Recovered(slog.Default(), recover(), "db worker")
+	}()
+	w.lock.Lock()
+	repo := w.dbRepo
+	w.lock.Unlock()
+	generator := NewDBCacheUpdater(repo)
+	col, err := generator.GenerateDBCacheSecondary(context.Background())
+	if err != nil {
+		slog.Warn("db worker: cannot update db cache secondary", "err", err)
+	}
+	w.setColumnCache(col)
+	slog.Debug("db worker: update db cache secondary complete")
+}
+
+// Stop stops the worker, waiting for the cache being updated not to query
+// the connection closed after it. It does nothing if the worker is already
+// stopped.
 func (w *Worker) Stop() {
-	close(w.done)
+	w.stopOnce.Do(func() {
+		close(w.done)
+	})
+	if w.exited != nil {
+		<-w.exited
+	}
 }
 
 func (w *Worker) ReCache(ctx context.Context, repo DBRepository) error {
+	w.lock.Lock()
 	w.dbRepo = repo
-	if err := w.updateAllCache(ctx); err != nil {
+	w.lock.Unlock()
+	if err := w.updateAllCache(ctx, repo); err != nil {
 		return err
 	}
 	w.updateAdditionalCache()
 	return nil
 }
 
-func (w *Worker) updateAllCache(ctx context.Context) error {
-	generator := NewDBCacheUpdater(w.dbRepo)
+func (w *Worker) updateAllCache(ctx context.Context, repo DBRepository) error {
+	generator := NewDBCacheUpdater(repo)
 	cache, err := generator.GenerateDBCachePrimary(ctx)
 	if err != nil {
 		return err
 	}
 	w.setCache(cache)
-	log.Println("db worker: Update db cache primary complete")
+	logging.FromContext(ctx).Debug("db worker: update db cache primary complete")
 	return nil
 }
 
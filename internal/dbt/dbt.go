@@ -0,0 +1,350 @@
+package dbt
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sqls-server/sqls/internal/database"
+	"gopkg.in/yaml.v2"
+)
+
+// ProjectFileName is the name of the file of a dbt project at its root.
+const ProjectFileName = "dbt_project.yml"
+
+// RelationKind is the kind of a relation of a dbt project.
+type RelationKind int
+
+const (
+	RelationModel RelationKind = iota
+	RelationSeed
+	RelationSource
+)
+
+func (k RelationKind) String() string {
+	switch k {
+	case RelationSeed:
+		return "seed"
+	case RelationSource:
+		return "source"
+	}
+	return "model"
+}
+
+// Column is a column documented in the properties of the relations.
+type Column struct {
+	Name        string `yaml:"name"`
+	Type        string `yaml:"data_type"`
+	Description string `yaml:"description"`
+}
+
+// Relation is a model, a seed or a table of a source of a dbt project.
+type Relation struct {
+	Kind RelationKind
+	Name string
+	// SourceName is the name of the source of the table of a source
+	SourceName string
+	// Schema and Identifier are the table of a source in the database
+	Schema      string
+	Identifier  string
+	Description string
+	Columns     []*Column
+}
+
+// Project is a dbt project, whose models, seeds and sources are the tables
+// of the documents referring to them by ref() and source().
+type Project struct {
+	Name      string
+	Root      string
+	Relations []*Relation
+}
+
+type projectFile struct {
+	Name       string   `yaml:"name"`
+	ModelPaths []string `yaml:"model-paths"`
+	SeedPaths  []string `yaml:"seed-paths"`
+	// The paths of the versions before dbt 1.0
+	SourcePaths []string `yaml:"source-paths"`
+	DataPaths   []string `yaml:"data-paths"`
+}
+
+type propertiesFile struct {
+	Models  []*relationProperties `yaml:"models"`
+	Seeds   []*relationProperties `yaml:"seeds"`
+	Sources []*sourceProperties   `yaml:"sources"`
+}
+
+type relationProperties struct {
+	Name        string    `yaml:"name"`
+	Identifier  string    `yaml:"identifier"`
+	Description string    `yaml:"description"`
+	Columns     []*Column `yaml:"columns"`
+}
+
+type sourceProperties struct {
+	Name        string                `yaml:"name"`
+	Schema      string                `yaml:"schema"`
+	Description string                `yaml:"description"`
+	Tables      []*relationProperties `yaml:"tables"`
+}
+
+// ProjectPath returns the path of the file of the dbt project at the root
+// path, or empty if it is not a dbt project.
+func ProjectPath(rootPath string) string {
+	fpath := filepath.Join(rootPath, ProjectFileName)
+	if _, err := os.Stat(fpath); err != nil {
+		return ""
+	}
+	return fpath
+}
+
+// Load reads the dbt project at the root path: the models of the SQL files
+// in the model paths, the seeds of the CSV files in the seed paths and the
+// properties of the models, the seeds and the sources of the YAML files in
+// both.
+func Load(rootPath string) (*Project, error) {
+	b, err := os.ReadFile(filepath.Join(rootPath, ProjectFileName))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read dbt project, %w", err)
+	}
+	var pf projectFile
+	if err := yaml.Unmarshal(b, &pf); err != nil {
+		return nil, fmt.Errorf("cannot parse dbt project, %w", err)
+	}
+	modelPaths := firstPaths([]string{"models"}, pf.ModelPaths, pf.SourcePaths)
+	seedPaths := firstPaths([]string{"seeds"}, pf.SeedPaths, pf.DataPaths)
+
+	l := &loader{relations: map[string]*Relation{}}
+	for _, dir := range modelPaths {
+		if err := l.walk(filepath.Join(rootPath, dir), RelationModel); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range seedPaths {
+		if err := l.walk(filepath.Join(rootPath, dir), RelationSeed); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &Project{Name: pf.Name, Root: rootPath}
+	for _, rel := range l.relations {
+		p.Relations = append(p.Relations, rel)
+	}
+	sort.Slice(p.Relations, func(i, j int) bool {
+		a, b := p.Relations[i], p.Relations[j]
+		if a.SourceName != b.SourceName {
+			return a.SourceName < b.SourceName
+		}
+		return a.Name < b.Name
+	})
+	return p, nil
+}
+
+// firstPaths returns the first paths set, or the default paths.
+func firstPaths(defaults []string, paths ...[]string) []string {
+	for _, p := range paths {
+		if len(p) > 0 {
+			return p
+		}
+	}
+	return defaults
+}
+
+// loader collects the relations of the files, whose properties and files may
+// be found in any order.
+type loader struct {
+	relations map[string]*Relation
+}
+
+func (l *loader) relation(kind RelationKind, sourceName, name string) *Relation {
+	key := kind.String() + "\t" + sourceName + "\t" + name
+	rel, ok := l.relations[key]
+	if !ok {
+		rel = &Relation{Kind: kind, SourceName: sourceName, Name: name}
+		l.relations[key] = rel
+	}
+	return rel
+}
+
+func (l *loader) walk(dir string, kind RelationKind) error {
+	err := filepath.WalkDir(dir, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if fpath != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+		switch strings.ToLower(filepath.Ext(fpath)) {
+		case ".sql":
+			if kind == RelationModel {
+				l.relation(RelationModel, "", name)
+			}
+		case ".csv":
+			if kind == RelationSeed {
+				return l.loadSeed(fpath, name)
+			}
+		case ".yml", ".yaml":
+			return l.loadProperties(fpath)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot read dbt project, %w", err)
+	}
+	return nil
+}
+
+// loadSeed reads the header of the seed as the columns, which the properties
+// may document.
+func (l *loader) loadSeed(fpath, name string) error {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rel := l.relation(RelationSeed, "", name)
+	header, err := csv.NewReader(f).Read()
+	if err != nil {
+		// An empty seed has no columns
+		return nil
+	}
+	for _, colName := range header {
+		if col := findColumn(rel.Columns, colName); col == nil {
+			rel.Columns = append(rel.Columns, &Column{Name: strings.TrimSpace(colName)})
+		}
+	}
+	return nil
+}
+
+func (l *loader) loadProperties(fpath string) error {
+	b, err := os.ReadFile(fpath)
+	if err != nil {
+		return err
+	}
+	var props propertiesFile
+	if err := yaml.Unmarshal(b, &props); err != nil {
+		return fmt.Errorf("cannot parse %s, %w", fpath, err)
+	}
+	for _, m := range props.Models {
+		l.relation(RelationModel, "", m.Name).document(m)
+	}
+	for _, s := range props.Seeds {
+		l.relation(RelationSeed, "", s.Name).document(s)
+	}
+	for _, src := range props.Sources {
+		for _, tbl := range src.Tables {
+			rel := l.relation(RelationSource, src.Name, tbl.Name)
+			rel.Schema = database.Coalesce(src.Schema, src.Name)
+			rel.Identifier = database.Coalesce(tbl.Identifier, tbl.Name)
+			rel.document(tbl)
+		}
+	}
+	return nil
+}
+
+// document sets the description and the columns of the properties.
+func (rel *Relation) document(props *relationProperties) {
+	if props.Description != "" {
+		rel.Description = strings.TrimSpace(props.Description)
+	}
+	for _, c := range props.Columns {
+		col := findColumn(rel.Columns, c.Name)
+		if col == nil {
+			col = &Column{Name: c.Name}
+			rel.Columns = append(rel.Columns, col)
+		}
+		col.Type = database.Coalesce(c.Type, col.Type)
+		col.Description = database.Coalesce(strings.TrimSpace(c.Description), col.Description)
+	}
+}
+
+func findColumn(cols []*Column, name string) *Column {
+	for _, col := range cols {
+		if strings.EqualFold(col.Name, strings.TrimSpace(name)) {
+			return col
+		}
+	}
+	return nil
+}
+
+// Models returns the models and the seeds, which ref() refers to.
+func (p *Project) Models() []*Relation {
+	var rels []*Relation
+	for _, rel := range p.Relations {
+		if rel.Kind != RelationSource {
+			rels = append(rels, rel)
+		}
+	}
+	return rels
+}
+
+// Sources returns the names of the sources.
+func (p *Project) Sources() []string {
+	var names []string
+	for _, rel := range p.Relations {
+		if rel.Kind == RelationSource && (len(names) == 0 || names[len(names)-1] != rel.SourceName) {
+			names = append(names, rel.SourceName)
+		}
+	}
+	return names
+}
+
+// SourceTables returns the tables of the source.
+func (p *Project) SourceTables(sourceName string) []*Relation {
+	var rels []*Relation
+	for _, rel := range p.Relations {
+		if rel.Kind == RelationSource && rel.SourceName == sourceName {
+			rels = append(rels, rel)
+		}
+	}
+	return rels
+}
+
+// Merge returns the cache with the relations of the project, the models and
+// the seeds in the default schema and the tables of the sources in the
+// schemas of the names of the sources, as ref() and source() are resolved.
+// The columns in the database are documented by the properties, and the
+// relations not in the database have the columns of the properties. The cache
+// is not changed, and may be nil not to be connected to the database.
+func (p *Project) Merge(cache *database.DBCache) *database.DBCache {
+	if cache == nil {
+		cache = database.NewDBCache("")
+	} else {
+		cache = cache.Clone()
+	}
+	for _, rel := range p.Relations {
+		schemaName, dbSchema, dbTable := cache.DefaultSchema(), cache.DefaultSchema(), rel.Name
+		if rel.Kind == RelationSource {
+			schemaName, dbSchema, dbTable = rel.SourceName, rel.Schema, rel.Identifier
+		}
+		var cols []*database.ColumnDesc
+		if dbCols, ok := cache.ColumnDatabase(dbSchema, dbTable); ok {
+			for _, dbCol := range dbCols {
+				col := *dbCol
+				col.Schema, col.Table = schemaName, rel.Name
+				if c := findColumn(rel.Columns, col.Name); c != nil {
+					col.Comment = c.Description
+				}
+				cols = append(cols, &col)
+			}
+		} else {
+			for _, c := range rel.Columns {
+				cols = append(cols, &database.ColumnDesc{
+					ColumnBase: database.ColumnBase{Schema: schemaName, Table: rel.Name, Name: c.Name},
+					Type:       c.Type,
+					Comment:    c.Description,
+				})
+			}
+		}
+		cache.AddTable(schemaName, rel.Name, cols)
+	}
+	return cache
+}
@@ -0,0 +1,106 @@
+package dbt
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/internal/database"
+)
+
+func TestLoad(t *testing.T) {
+	p, err := Load("testdata/project")
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	want := []*Relation{
+		{Kind: RelationModel, Name: "cities"},
+		{
+			Kind: RelationSeed,
+			Name: "country_codes",
+			Columns: []*Column{
+				{Name: "Code", Description: "The ISO 3166 code of the country."},
+				{Name: "Continent"},
+			},
+		},
+		{
+			Kind:        RelationModel,
+			Name:        "stg_city",
+			Description: "The cities staged.",
+			Columns: []*Column{
+				{Name: "ID", Type: "int", Description: "The identifier of the city."},
+				{Name: "Name", Type: "varchar"},
+			},
+		},
+		{Kind: RelationSource, SourceName: "world", Name: "city", Schema: "raw", Identifier: "city", Description: "The cities of the world."},
+		{Kind: RelationSource, SourceName: "world", Name: "country", Schema: "raw", Identifier: "countries"},
+	}
+	if p.Name != "world" {
+		t.Errorf("unmatched name %q", p.Name)
+	}
+	if d := cmp.Diff(want, p.Relations); d != "" {
+		t.Errorf("unmatched relations (-want +got):\n%s", d)
+	}
+	if d := cmp.Diff([]string{"world"}, p.Sources()); d != "" {
+		t.Errorf("unmatched sources (-want +got):\n%s", d)
+	}
+	if got := len(p.Models()); got != 3 {
+		t.Errorf("unmatched models %d", got)
+	}
+	if got := len(p.SourceTables("world")); got != 2 {
+		t.Errorf("unmatched source tables %d", got)
+	}
+
+	if _, err := Load("testdata"); err == nil {
+		t.Errorf("the directory without the project must not be loaded")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	p, err := Load("testdata/project")
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+
+	t.Run("not connected", func(t *testing.T) {
+		cache := p.Merge(nil)
+		if d := cmp.Diff([]string{"cities", "country_codes", "stg_city"}, cache.SortedTables()); d != "" {
+			t.Errorf("unmatched tables (-want +got):\n%s", d)
+		}
+		if d := cmp.Diff([]string{"world"}, cache.SortedSchemas()); d != "" {
+			t.Errorf("unmatched schemas (-want +got):\n%s", d)
+		}
+		col, ok := cache.Column("stg_city", "id")
+		if !ok {
+			t.Fatalf("column not found")
+		}
+		if col.Type != "int" || col.Comment != "The identifier of the city." {
+			t.Errorf("unmatched column %+v", col)
+		}
+	})
+
+	t.Run("connected", func(t *testing.T) {
+		db := database.NewDBCache("analytics")
+		db.AddTable("raw", "countries", []*database.ColumnDesc{
+			{ColumnBase: database.ColumnBase{Schema: "raw", Table: "countries", Name: "Code"}, Type: "char(3)"},
+		})
+		db.AddTable("analytics", "stg_city", []*database.ColumnDesc{
+			{ColumnBase: database.ColumnBase{Schema: "analytics", Table: "stg_city", Name: "ID"}, Type: "bigint"},
+		})
+		cache := p.Merge(db)
+
+		cols, ok := cache.ColumnDatabase("world", "country")
+		if !ok || len(cols) != 1 || cols[0].Type != "char(3)" || cols[0].Table != "country" {
+			t.Errorf("unmatched columns of the source %+v", cols)
+		}
+		col, ok := cache.Column("stg_city", "ID")
+		if !ok || col.Type != "bigint" || col.Comment != "The identifier of the city." {
+			t.Errorf("unmatched column of the model %+v", col)
+		}
+		if _, ok := db.ColumnDatabase("world", "country"); ok {
+			t.Errorf("the cache merged must not be changed")
+		}
+		if c, _ := db.Column("stg_city", "ID"); c.Comment != "" {
+			t.Errorf("the columns merged must not be changed")
+		}
+	})
+}
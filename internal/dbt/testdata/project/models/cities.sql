@@ -0,0 +1,3 @@
+SELECT c.ID, c.Name, cc.Continent
+FROM {{ ref('stg_city') }} AS c
+JOIN {{ ref('country_codes') }} AS cc ON c.CountryCode = cc.Code
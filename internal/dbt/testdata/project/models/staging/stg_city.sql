@@ -0,0 +1 @@
+SELECT ID, Name, CountryCode FROM {{ source('world', 'city') }}
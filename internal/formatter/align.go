@@ -0,0 +1,177 @@
+package formatter
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// aliasAlignNode marks the position before an alias of a select list, where
+// the spaces are inserted to line up the aliases.
+var aliasAlignNode = ast.NewItem(&token.Token{
+	Kind:  token.Whitespace,
+	Value: "",
+})
+
+type alignLine struct {
+	indent string
+	// marker is the index of the alias align node in the leaves, -1 if none
+	marker int
+	width  int
+}
+
+// alignAliases pads the aliases of the lines of a select list to the same
+// column. The lines of a list share the indentation, and the lines indented
+// deeper, such as the branches of CASE, are within the list.
+func alignAliases(formatted ast.Node, opts *ast.RenderOptions) ast.Node {
+	leaves := flattenNodes(formatted)
+	lines := []*alignLine{}
+	cur := &alignLine{marker: -1}
+	inIndent := true
+	var text strings.Builder
+	for i, leaf := range leaves {
+		if isLinebreak(leaf) {
+			lines = append(lines, cur)
+			cur = &alignLine{marker: -1}
+			inIndent = true
+			text.Reset()
+			continue
+		}
+		if leaf == aliasAlignNode {
+			cur.marker = i
+			cur.width = utf8.RuneCountInString(text.String())
+			continue
+		}
+		s := leaf.Render(opts)
+		if inIndent && isWhitespace(leaf) {
+			cur.indent += s
+			continue
+		}
+		inIndent = false
+		text.WriteString(s)
+	}
+	lines = append(lines, cur)
+
+	padding := map[int]int{}
+	var groups [][]*alignLine
+	alignGroup := func(group []*alignLine) {
+		width := 0
+		for _, l := range group {
+			if l.width > width {
+				width = l.width
+			}
+		}
+		for _, l := range group {
+			padding[l.marker] = width - l.width
+		}
+	}
+	for _, line := range lines {
+		// Close the lists indented deeper than the line
+		for len(groups) > 0 && len(groups[len(groups)-1][0].indent) > len(line.indent) {
+			alignGroup(groups[len(groups)-1])
+			groups = groups[:len(groups)-1]
+		}
+		if line.marker < 0 {
+			continue
+		}
+		if len(groups) > 0 && groups[len(groups)-1][0].indent == line.indent {
+			groups[len(groups)-1] = append(groups[len(groups)-1], line)
+			continue
+		}
+		groups = append(groups, []*alignLine{line})
+	}
+	for _, group := range groups {
+		alignGroup(group)
+	}
+
+	out := make([]ast.Node, 0, len(leaves))
+	for i, leaf := range leaves {
+		if leaf == aliasAlignNode {
+			out = append(out, whiteSpaceNodes(padding[i])...)
+			continue
+		}
+		out = append(out, leaf)
+	}
+	return &ast.ItemWith{Toks: out}
+}
+
+// valueAlignNode marks the position after a comma between the values of a
+// tuple, where the spaces are inserted to line up the values.
+var valueAlignNode = ast.NewItem(&token.Token{
+	Kind:  token.Whitespace,
+	Value: "",
+})
+
+type valueLine struct {
+	// markers are the indexes of the value align nodes in the leaves, and
+	// widths are the widths of the line before them
+	markers []int
+	widths  []int
+}
+
+// alignValues pads the values of the tuples on consecutive lines with the
+// same number of values to the same columns.
+func alignValues(formatted ast.Node, opts *ast.RenderOptions) ast.Node {
+	leaves := flattenNodes(formatted)
+	lines := []*valueLine{}
+	cur := &valueLine{}
+	width := 0
+	for i, leaf := range leaves {
+		if isLinebreak(leaf) {
+			lines = append(lines, cur)
+			cur = &valueLine{}
+			width = 0
+			continue
+		}
+		if leaf == valueAlignNode {
+			cur.markers = append(cur.markers, i)
+			cur.widths = append(cur.widths, width)
+			continue
+		}
+		width += utf8.RuneCountInString(leaf.Render(opts))
+	}
+	lines = append(lines, cur)
+
+	padding := map[int]int{}
+	alignGroup := func(group []*valueLine) {
+		shifts := make([]int, len(group))
+		for k := range group[0].markers {
+			column := 0
+			for i, l := range group {
+				if w := l.widths[k] + shifts[i]; w > column {
+					column = w
+				}
+			}
+			for i, l := range group {
+				pad := column - l.widths[k] - shifts[i]
+				padding[l.markers[k]] = pad
+				shifts[i] += pad
+			}
+		}
+	}
+	var group []*valueLine
+	for _, line := range lines {
+		if len(group) > 0 && len(line.markers) != len(group[0].markers) {
+			alignGroup(group)
+			group = nil
+		}
+		if len(line.markers) > 0 {
+			group = append(group, line)
+		}
+	}
+	if len(group) > 0 {
+		alignGroup(group)
+	}
+
+	out := make([]ast.Node, 0, len(leaves))
+	for i, leaf := range leaves {
+		if leaf == valueAlignNode {
+			out = append(out, whiteSpaceNodes(padding[i])...)
+			continue
+		}
+		out = append(out, leaf)
+	}
+	return &ast.ItemWith{Toks: out}
+}
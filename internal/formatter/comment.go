@@ -0,0 +1,288 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// The formatter rebuilds the whitespaces between the tokens, so the comments
+// are taken out of the formatted tokens and put back next to the tokens they
+// document in the source:
+//
+//   - a comment after a token on the same line stays at the end of its line
+//   - a comment on its own line stays on its own line before the next token
+//   - a block comment between two tokens on a line stays next to the nearer one
+type commentPlacement int
+
+const (
+	placeEndOfLine commentPlacement = iota
+	placeAfter
+	placeBefore
+	placeOwnLine
+)
+
+type attachedComment struct {
+	node      ast.Node
+	placement commentPlacement
+}
+
+type commentAttacher struct {
+	comments []ast.Node
+	// source are the tokens of the source, and tokens are the ones other
+	// than whitespaces and comments, both in the order of the source
+	source map[ast.Node]bool
+	tokens []ast.Node
+}
+
+func newCommentAttacher(parsed ast.Node) *commentAttacher {
+	a := &commentAttacher{source: map[ast.Node]bool{}}
+	for _, leaf := range flattenNodes(parsed) {
+		a.source[leaf] = true
+		switch {
+		case isComment(leaf):
+			a.comments = append(a.comments, leaf)
+		case !isWhitespace(leaf):
+			a.tokens = append(a.tokens, leaf)
+		}
+	}
+	return a
+}
+
+// attach returns the formatted node with the comments next to the tokens they
+// are attached to.
+func (a *commentAttacher) attach(formatted ast.Node) ast.Node {
+	if len(a.comments) == 0 {
+		return formatted
+	}
+	leaves := flattenNodes(formatted)
+	tokens := []ast.Node{}
+	for _, leaf := range leaves {
+		if a.source[leaf] && !isComment(leaf) && !isWhitespace(leaf) {
+			tokens = append(tokens, leaf)
+		}
+	}
+
+	before := map[ast.Node][]attachedComment{}
+	after := map[ast.Node][]attachedComment{}
+	ownLine := map[ast.Node]bool{}
+	var first, last []ast.Node
+	for _, c := range a.comments {
+		placement := a.placement(c)
+		if placement == placeOwnLine {
+			ownLine[c] = true
+		}
+		switch placement {
+		case placeEndOfLine, placeAfter:
+			if prev := lastBefore(tokens, c.Pos()); prev != nil {
+				after[prev] = append(after[prev], attachedComment{c, placement})
+			} else {
+				first = append(first, c)
+			}
+		default:
+			if next := firstAfter(tokens, c.End()); next != nil {
+				before[next] = append(before[next], attachedComment{c, placement})
+			} else {
+				last = append(last, c)
+			}
+		}
+	}
+
+	out := []ast.Node{}
+	var pending []ast.Node
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		out = trimTrailingSpaces(out)
+		for _, c := range pending {
+			if len(out) > 0 && !isLinebreak(out[len(out)-1]) {
+				out = append(out, whitespaceNode)
+			}
+			out = append(out, c)
+		}
+		pending = nil
+	}
+	for _, c := range first {
+		out = append(out, c, linebreakNode)
+	}
+	skipLinebreak := false
+	for _, leaf := range leaves {
+		if isComment(leaf) {
+			// The line break of the source after a comment on its own line
+			// is added again with the comment
+			skipLinebreak = ownLine[leaf]
+			continue
+		}
+		if skipLinebreak && a.source[leaf] && isLinebreak(leaf) {
+			skipLinebreak = false
+			continue
+		}
+		skipLinebreak = false
+		if isLinebreak(leaf) {
+			flush()
+		}
+		for _, c := range before[leaf] {
+			if c.placement == placeBefore {
+				if len(out) > 0 && !isWhitespace(out[len(out)-1]) {
+					out = append(out, whitespaceNode)
+				}
+				out = append(out, c.node, whitespaceNode)
+				continue
+			}
+			if len(pending) > 0 {
+				// The next token has to start a new line not to be commented
+				// out by the comment at the end of the line
+				flush()
+				out = append(out, linebreakNode)
+				out = append(out, lineIndent(out[:len(out)-1])...)
+			}
+			out = insertOwnLine(out, c.node)
+		}
+		out = append(out, leaf)
+		for _, c := range after[leaf] {
+			if c.placement == placeAfter && len(pending) == 0 {
+				out = append(out, whitespaceNode, c.node)
+				continue
+			}
+			pending = append(pending, c.node)
+		}
+	}
+	flush()
+	for _, c := range last {
+		out = trimTrailingSpaces(out)
+		if len(out) > 0 && !isLinebreak(out[len(out)-1]) {
+			out = append(out, linebreakNode)
+		}
+		out = append(out, c)
+	}
+	return &ast.ItemWith{Toks: out}
+}
+
+// placement decides where the comment goes from the tokens around it in the
+// source.
+func (a *commentAttacher) placement(c ast.Node) commentPlacement {
+	var prev, next ast.Node
+	for _, tok := range a.tokens {
+		if token.ComparePos(tok.End(), c.Pos()) <= 0 {
+			prev = tok
+			continue
+		}
+		if token.ComparePos(tok.Pos(), c.End()) >= 0 {
+			next = tok
+			break
+		}
+	}
+	prevOnLine := prev != nil && prev.End().Line == c.Pos().Line
+	nextOnLine := next != nil && next.Pos().Line == c.End().Line
+	if c.(*ast.Item).GetToken().Kind == token.Comment {
+		if prevOnLine {
+			return placeEndOfLine
+		}
+		return placeOwnLine
+	}
+	switch {
+	case prevOnLine && nextOnLine:
+		// The commas stay after the comments of the items they follow
+		if !isComma(next) && next.Pos().Col-c.End().Col < c.Pos().Col-prev.End().Col {
+			return placeBefore
+		}
+		return placeAfter
+	case prevOnLine:
+		return placeAfter
+	case nextOnLine:
+		return placeBefore
+	}
+	return placeOwnLine
+}
+
+// insertOwnLine puts the comment on a line before the current line, indented
+// in the same way.
+func insertOwnLine(out []ast.Node, comment ast.Node) []ast.Node {
+	start := 0
+	for i := len(out) - 1; i >= 0; i-- {
+		if isLinebreak(out[i]) {
+			start = i + 1
+			break
+		}
+	}
+	indent := lineIndent(out)
+	res := make([]ast.Node, 0, len(out)+len(indent)+2)
+	res = append(res, out[:start]...)
+	res = append(res, indent...)
+	res = append(res, comment, linebreakNode)
+	return append(res, out[start:]...)
+}
+
+// lineIndent returns the whitespaces at the start of the last line.
+func lineIndent(out []ast.Node) []ast.Node {
+	start := 0
+	for i := len(out) - 1; i >= 0; i-- {
+		if isLinebreak(out[i]) {
+			start = i + 1
+			break
+		}
+	}
+	indent := []ast.Node{}
+	for _, n := range out[start:] {
+		if !isWhitespace(n) {
+			break
+		}
+		indent = append(indent, n)
+	}
+	return indent
+}
+
+func trimTrailingSpaces(out []ast.Node) []ast.Node {
+	for len(out) > 0 && isWhitespace(out[len(out)-1]) && !isLinebreak(out[len(out)-1]) {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+func lastBefore(tokens []ast.Node, pos token.Pos) ast.Node {
+	var prev ast.Node
+	for _, tok := range tokens {
+		if token.ComparePos(tok.End(), pos) > 0 {
+			break
+		}
+		prev = tok
+	}
+	return prev
+}
+
+func firstAfter(tokens []ast.Node, pos token.Pos) ast.Node {
+	for _, tok := range tokens {
+		if token.ComparePos(tok.Pos(), pos) >= 0 {
+			return tok
+		}
+	}
+	return nil
+}
+
+// flattenNodes returns the tokens that the node is rendered from.
+func flattenNodes(node ast.Node) []ast.Node {
+	list, ok := node.(ast.TokenList)
+	if !ok {
+		return []ast.Node{node}
+	}
+	leaves := []ast.Node{}
+	for _, n := range list.GetTokens() {
+		leaves = append(leaves, flattenNodes(n)...)
+	}
+	return leaves
+}
+
+func isComment(node ast.Node) bool {
+	item, ok := node.(*ast.Item)
+	if !ok {
+		return false
+	}
+	kind := item.GetToken().Kind
+	return kind == token.Comment || kind == token.MultilineComment
+}
+
+func isLinebreak(node ast.Node) bool {
+	return isWhitespace(node) && strings.Contains(node.String(), "\n")
+}
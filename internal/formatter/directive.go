@@ -0,0 +1,98 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/token"
+)
+
+// The comment directives that keep parts of the document as written:
+//
+//	-- sqls-fmt: off    up to "-- sqls-fmt: on" or the end of the document
+//	-- sqls-fmt: skip   up to the end of the next statement
+const (
+	directiveFormatOff  = "sqls-fmt: off"
+	directiveFormatOn   = "sqls-fmt: on"
+	directiveFormatSkip = "sqls-fmt: skip"
+)
+
+// formatRegion is a part of the document that is formatted, starting at the
+// beginning of the line.
+type formatRegion struct {
+	line int
+	text string
+}
+
+// formatRegions splits the text into the lines to format, leaving out the
+// lines disabled by the directives and the meta-commands of psql such as
+// \copy.
+func formatRegions(text string, driver dialect.DatabaseDriver) ([]formatRegion, error) {
+	tokens, err := token.NewTokenizer(strings.NewReader(text), dialect.ForDriver(driver)).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(text, "\n")
+	disabled := make([]bool, len(lines))
+	disable := func(from, to int) {
+		for l := from; l <= to && l < len(lines); l++ {
+			disabled[l] = true
+		}
+	}
+
+	offLine := -1
+	skipLine := -1
+	for _, tok := range tokens {
+		if skipLine >= 0 && tok.Kind == token.Semicolon {
+			disable(skipLine, tok.To.Line)
+			skipLine = -1
+		}
+		if tok.Kind == token.Backslash && strings.TrimSpace(string([]rune(lines[tok.From.Line])[:tok.From.Col])) == "" {
+			disable(tok.From.Line, tok.From.Line)
+		}
+		if tok.Kind != token.Comment {
+			continue
+		}
+		v, _ := tok.Value.(string)
+		switch strings.TrimSpace(v) {
+		case directiveFormatOff:
+			if offLine < 0 {
+				offLine = tok.From.Line
+			}
+		case directiveFormatOn:
+			if offLine >= 0 {
+				disable(offLine, tok.From.Line)
+				offLine = -1
+			}
+		case directiveFormatSkip:
+			if skipLine < 0 {
+				skipLine = tok.From.Line
+			}
+		}
+	}
+	if offLine >= 0 {
+		disable(offLine, len(lines)-1)
+	}
+	if skipLine >= 0 {
+		disable(skipLine, len(lines)-1)
+	}
+
+	regions := []formatRegion{}
+	start := -1
+	for l := 0; l <= len(lines); l++ {
+		if l < len(lines) && !disabled[l] {
+			if start < 0 {
+				start = l
+			}
+			continue
+		}
+		if start >= 0 {
+			regions = append(regions, formatRegion{
+				line: start,
+				text: strings.Join(lines[start:l], "\n"),
+			})
+			start = -1
+		}
+	}
+	return regions, nil
+}
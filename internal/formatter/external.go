@@ -0,0 +1,75 @@
+package formatter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// FormatExternal formats the text with the command of the external
+// formatter, run in the directory dir if not empty.
+func FormatExternal(ctx context.Context, text string, cfg *config.ExternalFormatter, dir string) ([]lsp.TextEdit, error) {
+	if text == "" {
+		return nil, errors.New("empty")
+	}
+	timeout := cfg.TimeoutDuration()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", cfg.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", cfg.Command)
+	}
+	cmd.Dir = dir
+	// The children of the shell may keep the output open after it is killed
+	cmd.WaitDelay = time.Second
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("external formatter %q timed out after %s", cfg.Command, timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot execute external formatter %q, %w, %s", cfg.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	// Not to clear the document when the command writes the result elsewhere
+	if strings.TrimSpace(string(out)) == "" && strings.TrimSpace(text) != "" {
+		return nil, fmt.Errorf("external formatter %q wrote nothing to stdout", cfg.Command)
+	}
+	formatted := string(out)
+	if formatted == text {
+		return nil, nil
+	}
+	return []lsp.TextEdit{
+		{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: 0, Character: 0},
+				End:   endOfText(text),
+			},
+			NewText: formatted,
+		},
+	}, nil
+}
+
+// endOfText returns the position of the end of the text, counting the
+// characters in UTF-16 code units as LSP does.
+func endOfText(text string) lsp.Position {
+	lines := strings.Split(text, "\n")
+	last := lines[len(lines)-1]
+	return lsp.Position{
+		Line:      len(lines) - 1,
+		Character: len(utf16.Encode([]rune(last))),
+	}
+}
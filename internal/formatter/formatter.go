@@ -2,23 +2,48 @@ package formatter
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/sqls-server/sqls/ast"
 	"github.com/sqls-server/sqls/ast/astutil"
+	"github.com/sqls-server/sqls/dialect"
 	"github.com/sqls-server/sqls/internal/config"
 	"github.com/sqls-server/sqls/internal/lsp"
 	"github.com/sqls-server/sqls/parser"
 	"github.com/sqls-server/sqls/token"
 )
 
-func Format(text string, params lsp.DocumentFormattingParams, cfg *config.Config) ([]lsp.TextEdit, error) {
+// Format formats the text in the dialect of the database driver, which may
+// be empty when the driver is unknown.
+func Format(text string, params lsp.DocumentFormattingParams, cfg *config.Config, driver dialect.DatabaseDriver) ([]lsp.TextEdit, error) {
 	if text == "" {
 		return nil, errors.New("empty")
 	}
-	parsed, err := parser.Parse(text)
+	regions, err := formatRegions(text, driver)
 	if err != nil {
 		return nil, err
 	}
+	res := []lsp.TextEdit{}
+	for _, region := range regions {
+		if strings.TrimSpace(region.text) == "" {
+			continue
+		}
+		edit, err := formatText(region.text, params, cfg, driver)
+		if err != nil {
+			return nil, err
+		}
+		edit.Range.Start.Line += region.line
+		edit.Range.End.Line += region.line
+		res = append(res, edit)
+	}
+	return res, nil
+}
+
+func formatText(text string, params lsp.DocumentFormattingParams, cfg *config.Config, driver dialect.DatabaseDriver) (lsp.TextEdit, error) {
+	parsed, err := parser.ParseDialect(text, dialect.ForDriver(driver))
+	if err != nil {
+		return lsp.TextEdit{}, err
+	}
 
 	st := lsp.Position{
 		Line:      parsed.Pos().Line,
@@ -29,29 +54,72 @@ func Format(text string, params lsp.DocumentFormattingParams, cfg *config.Config
 		Character: parsed.End().Col,
 	}
 	env := &formatEnvironment{
-		options: params.Options,
+		options:      formattingOptions(params.Options, cfg),
+		leadingComma: cfg.CommaStyle == config.CommaStyleLeading,
+		maxLineWidth: cfg.MaxLineWidth,
+		align:        cfg.AlignColumns,
 	}
-	formatted := Eval(parsed, env)
+	formatted := formatSource(parsed, env)
 
 	opts := &ast.RenderOptions{
-		LowerCase: cfg.LowercaseKeywords,
-	}
-	res := []lsp.TextEdit{
-		{
-			Range: lsp.Range{
-				Start: st,
-				End:   en,
-			},
-			NewText: formatted.Render(opts),
+		LowerCase:      cfg.LowercaseKeywords,
+		KeywordCase:    cfg.KeywordCase,
+		IdentifierCase: cfg.IdentifierCase,
+	}
+	if env.align {
+		formatted = alignAliases(formatted, opts)
+	}
+	formatted = alignValues(formatted, opts)
+	return lsp.TextEdit{
+		Range: lsp.Range{
+			Start: st,
+			End:   en,
 		},
+		// The clauses put on their own lines, such as the joins, do not break
+		// the line before the text nor leave the spaces after it
+		NewText: strings.TrimRight(strings.TrimLeft(formatted.Render(opts), "\n\t "), "\t "),
+	}, nil
+}
+
+const defaultIndentWidth = 4
+
+// formattingOptions overrides the indentation requested by the client with
+// the one of the config.
+func formattingOptions(opts lsp.FormattingOptions, cfg *config.Config) lsp.FormattingOptions {
+	switch cfg.IndentStyle {
+	case config.IndentStyleTab:
+		opts.InsertSpaces = false
+	case config.IndentStyleSpace:
+		opts.InsertSpaces = true
 	}
-	return res, nil
+	if cfg.IndentWidth > 0 {
+		opts.TabSize = float64(cfg.IndentWidth)
+	}
+	if opts.InsertSpaces && opts.TabSize == 0 {
+		opts.TabSize = defaultIndentWidth
+	}
+	return opts
 }
 
 type formatEnvironment struct {
 	reader      *astutil.NodeReader
 	indentLevel int
 	options     lsp.FormattingOptions
+	// leadingComma puts the commas of lists at the start of the lines
+	leadingComma bool
+	// maxLineWidth is the width over which the function arguments are put
+	// one per line, 0 for no limit
+	maxLineWidth int
+	// align lines up the aliases of the select lists and the conditions of
+	// the joins
+	align           bool
+	inSelectList    bool
+	inJoinCondition bool
+}
+
+func (e *formatEnvironment) startClause() {
+	e.inSelectList = false
+	e.inJoinCondition = false
 }
 
 func (e *formatEnvironment) indentLevelReset() {
@@ -81,6 +149,10 @@ func (e *formatEnvironment) genIndent() []ast.Node {
 	return nodes
 }
 
+func (e *formatEnvironment) indentWidth() int {
+	return e.indentLevel * int(e.options.TabSize)
+}
+
 type prefixFormatFn func(nodes []ast.Node, reader *astutil.NodeReader, env formatEnvironment) ([]ast.Node, formatEnvironment)
 
 type prefixFormatMap struct {
@@ -95,6 +167,14 @@ func (pfm *prefixFormatMap) isMatch(reader *astutil.NodeReader) bool {
 	return false
 }
 
+// formatSource formats the parsed source and puts the comments back next to
+// the tokens they are attached to.
+func formatSource(node ast.Node, env *formatEnvironment) ast.Node {
+	attacher := newCommentAttacher(node)
+	formatted := attacher.attach(Eval(node, env))
+	return separateWords(formatted, attacher.source)
+}
+
 func Eval(node ast.Node, env *formatEnvironment) ast.Node {
 	switch node := node.(type) {
 	// case *ast.Query:
@@ -144,6 +224,8 @@ func formatItem(node ast.Node, env *formatEnvironment) ast.Node {
 			"LIMIT",
 			"WHEN",
 			"ELSE",
+			"WITH",
+			"EXISTS",
 		},
 	}
 	if whitespaceAfterMatcher.IsMatch(node) {
@@ -154,6 +236,10 @@ func formatItem(node ast.Node, env *formatEnvironment) ast.Node {
 			"BETWEEN",
 			"USING",
 			"THEN",
+			"AS",
+			"IN",
+			"OFFSET",
+			"OVER",
 		},
 	}
 	if whitespaceAroundMatcher.IsMatch(node) {
@@ -168,6 +254,9 @@ func formatItem(node ast.Node, env *formatEnvironment) ast.Node {
 			"JOIN",
 			"WHERE",
 			"HAVING",
+			"QUALIFY",
+			"WINDOW",
+			"RETURNING",
 			"LIMIT",
 			"UNION",
 			"VALUES",
@@ -181,13 +270,52 @@ func formatItem(node ast.Node, env *formatEnvironment) ast.Node {
 		results = unshift(results, env.genIndent()...)
 		results = unshift(results, linebreakNode)
 	}
+	clauseMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"FROM",
+			"INTO",
+			"JOIN",
+			"WHERE",
+			"HAVING",
+			"QUALIFY",
+			"WINDOW",
+			"RETURNING",
+			"LIMIT",
+			"UNION",
+			"VALUES",
+			"SET",
+			"EXCEPT",
+		},
+	}
+	if clauseMatcher.IsMatch(node) {
+		env.startClause()
+	}
 	indentBeforeMatcher := astutil.NodeMatcher{
 		ExpectKeyword: []string{
 			"ON",
 		},
 	}
+	// The query of a CTE or an INSERT starts after the closing parenthesis
+	selectKeywordMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"SELECT",
+		},
+	}
+	parenthesisMatcher := astutil.NodeMatcher{
+		NodeTypes: []ast.NodeType{ast.TypeParenthesis},
+	}
+	if selectKeywordMatcher.IsMatch(node) && env.reader != nil && env.reader.PrevNodeIs(true, parenthesisMatcher) {
+		results = unshift(results, env.genIndent()...)
+		results = unshift(results, linebreakNode)
+	}
 	if indentBeforeMatcher.IsMatch(node) {
 		env.indentLevelUp()
+		env.startClause()
+		env.inJoinCondition = true
+		if env.align {
+			// Right align ON with AND, so that the conditions line up
+			results = unshift(results, whitespaceNode)
+		}
 		results = unshift(results, env.genIndent()...)
 		results = unshift(results, linebreakNode)
 	}
@@ -200,6 +328,14 @@ func formatItem(node ast.Node, env *formatEnvironment) ast.Node {
 		},
 	}
 	if linebreakBeforeMatcher.IsMatch(node) {
+		orMatcher := astutil.NodeMatcher{
+			ExpectKeyword: []string{
+				"OR",
+			},
+		}
+		if env.align && env.inJoinCondition && orMatcher.IsMatch(node) {
+			results = unshift(results, whitespaceNode)
+		}
 		results = unshift(results, env.genIndent()...)
 		results = unshift(results, linebreakNode)
 	}
@@ -211,16 +347,34 @@ func formatItem(node ast.Node, env *formatEnvironment) ast.Node {
 			"FROM",
 			"WHERE",
 			"HAVING",
+			"QUALIFY",
+			"WINDOW",
+			"RETURNING",
 		},
 		ExpectTokens: []token.Kind{
 			token.LParen,
 		},
 	}
+	if selectKeywordMatcher.IsMatch(node) && env.reader != nil {
+		results = append(results, formatSelectTop(env.reader)...)
+	}
+	valuesMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"VALUES",
+		},
+	}
+	if valuesMatcher.IsMatch(node) && env.reader != nil {
+		results = append(results, formatValues(env)...)
+	}
 	if linebreakWithIndentAfterMatcher.IsMatch(node) {
 		results = append(results, linebreakNode)
 		env.indentLevelUp()
 		results = append(results, env.genIndent()...)
 	}
+	if selectKeywordMatcher.IsMatch(node) {
+		env.startClause()
+		env.inSelectList = true
+	}
 	indentAfterMatcher := astutil.NodeMatcher{
 		ExpectKeyword: []string{
 			"CASE",
@@ -229,25 +383,31 @@ func formatItem(node ast.Node, env *formatEnvironment) ast.Node {
 	if indentAfterMatcher.IsMatch(node) {
 		env.indentLevelUp()
 	}
-	linebreakAfterMatcher := astutil.NodeMatcher{
-		ExpectTokens: []token.Kind{
-			token.Comma,
+	setOperatorMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"UNION",
+			"EXCEPT",
 		},
 	}
-	if linebreakAfterMatcher.IsMatch(node) {
+	if setOperatorMatcher.IsMatch(node) {
 		results = append(results, linebreakNode)
 		results = append(results, env.genIndent()...)
 	}
-	commentAfterMatcher := astutil.NodeMatcher{
+	linebreakAfterMatcher := astutil.NodeMatcher{
 		ExpectTokens: []token.Kind{
-			token.Comment,
+			token.Comma,
 		},
 	}
-	if commentAfterMatcher.IsMatch(node) {
-		results = append(results, linebreakNode)
-		env.indentLevelDown()
+	if linebreakAfterMatcher.IsMatch(node) {
+		if env.leadingComma {
+			results = unshift(results, env.genIndent()...)
+			results = unshift(results, linebreakNode)
+			results = append(results, whitespaceNode)
+		} else {
+			results = append(results, linebreakNode)
+			results = append(results, env.genIndent()...)
+		}
 	}
-
 	breakStatementAfterMatcher := astutil.NodeMatcher{
 		ExpectTokens: []token.Kind{
 			token.Semicolon,
@@ -261,6 +421,159 @@ func formatItem(node ast.Node, env *formatEnvironment) ast.Node {
 	return &ast.ItemWith{Toks: results}
 }
 
+// formatSelectTop keeps the TOP clause of T-SQL on the line of SELECT,
+// reading it from the tokens after SELECT.
+func formatSelectTop(reader *astutil.NodeReader) []ast.Node {
+	topMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"TOP",
+		},
+	}
+	countMatcher := astutil.NodeMatcher{
+		NodeTypes:    []ast.NodeType{ast.TypeParenthesis},
+		ExpectTokens: []token.Kind{token.Number},
+	}
+	modifierMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"PERCENT",
+			"WITH",
+			"TIES",
+		},
+	}
+	if !reader.PeekNodeIs(true, topMatcher) {
+		return nil
+	}
+	top := reader.CopyReader()
+	top.NextNode(true)
+	if !top.PeekNodeIs(true, countMatcher) {
+		// A column named top
+		return nil
+	}
+
+	reader.NextNode(true)
+	results := []ast.Node{whitespaceNode, reader.CurNode}
+	reader.NextNode(true)
+	results = append(results, whitespaceNode, reader.CurNode)
+	for reader.PeekNodeIs(true, modifierMatcher) {
+		reader.NextNode(true)
+		results = append(results, whitespaceNode, reader.CurNode)
+	}
+	return results
+}
+
+var (
+	tupleMatcher = astutil.NodeMatcher{
+		NodeTypes: []ast.NodeType{ast.TypeParenthesis},
+	}
+	commaMatcher = astutil.NodeMatcher{
+		ExpectTokens: []token.Kind{token.Comma},
+	}
+	commentMatcher = astutil.NodeMatcher{
+		ExpectTokens: []token.Kind{token.Comment, token.MultilineComment},
+	}
+)
+
+// formatInsertColumns keeps the column list of INSERT INTO on the line of
+// the table, reading them from the tokens after INSERT INTO.
+func formatInsertColumns(env *formatEnvironment) []ast.Node {
+	tableMatcher := astutil.NodeMatcher{
+		NodeTypes: []ast.NodeType{ast.TypeIdentifier, ast.TypeMemberIdentifier},
+	}
+	reader := env.reader
+	columns := reader.CopyReader()
+	if !columns.PeekNodeIs(true, tableMatcher) {
+		return nil
+	}
+	columns.NextNode(true)
+	if !columns.PeekNodeIs(true, tupleMatcher) {
+		return nil
+	}
+
+	reader.NextNode(true)
+	results := []ast.Node{Eval(reader.CurNode, env), whitespaceNode}
+	reader.NextNode(true)
+	return append(results, formatTuple(reader.CurNode.(*ast.Parenthesis), env, false)...)
+}
+
+// formatValues puts the tuples of VALUES one per line, reading them from the
+// tokens after VALUES. The values of the tuples are lined up by alignValues.
+func formatValues(env *formatEnvironment) []ast.Node {
+	reader := env.reader
+	if !reader.PeekNodeIs(true, tupleMatcher) {
+		return nil
+	}
+	tuples := []*ast.Parenthesis{}
+	for {
+		reader.NextNode(true)
+		tuples = append(tuples, reader.CurNode.(*ast.Parenthesis))
+		next := skipComments(reader)
+		if !next.PeekNodeIs(true, commaMatcher) {
+			break
+		}
+		next.NextNode(true)
+		next = skipComments(next)
+		if !next.PeekNodeIs(true, tupleMatcher) {
+			break
+		}
+		reader.Index, reader.CurNode = next.Index, next.CurNode
+	}
+
+	results := []ast.Node{}
+	env.indentLevelUp()
+	for i, tuple := range tuples {
+		results = append(results, linebreakNode)
+		results = append(results, env.genIndent()...)
+		if env.leadingComma {
+			if i > 0 {
+				results = append(results, commaNode, whitespaceNode)
+			} else if len(tuples) > 1 {
+				// Line up the first tuple with the ones after the commas
+				results = append(results, whiteSpaceNodes(2)...)
+			}
+		}
+		results = append(results, formatTuple(tuple, env, true)...)
+		if i < len(tuples)-1 && !env.leadingComma {
+			results = append(results, commaNode)
+		}
+	}
+	return results
+}
+
+// formatTuple puts the values in the parentheses on a line. If align is
+// true, the values are marked to be lined up with the ones of the tuples
+// on the other lines.
+func formatTuple(tuple *ast.Parenthesis, env *formatEnvironment, align bool) []ast.Node {
+	// The parentheses of the source keep the comments around them in place
+	toks := tuple.GetTokens()
+	lparen, rparen := toks[0], toks[len(toks)-1]
+	if rparen.String() != ")" {
+		rparen = rparenNode
+	}
+	results := []ast.Node{lparen}
+	for i, value := range splitArguments(tuple.Inner().GetTokens()) {
+		if i > 0 {
+			results = append(results, commaNode)
+			if align {
+				results = append(results, valueAlignNode)
+			}
+			results = append(results, whitespaceNode)
+		}
+		valueEnv := *env
+		results = append(results, Eval(value, &valueEnv))
+	}
+	return append(results, rparen)
+}
+
+// skipComments returns a copy of the reader that has read the comments
+// ahead.
+func skipComments(reader *astutil.NodeReader) *astutil.NodeReader {
+	r := reader.CopyReader()
+	for r.PeekNodeIs(true, commentMatcher) {
+		r.NextNode(true)
+	}
+	return r
+}
+
 func formatMultiKeyword(node *ast.MultiKeyword, env *formatEnvironment) ast.Node {
 	results := []ast.Node{}
 	for i, kw := range node.GetKeywords() {
@@ -284,20 +597,44 @@ func formatMultiKeyword(node *ast.MultiKeyword, env *formatEnvironment) ast.Node
 		"GROUP BY",
 		"ORDER BY",
 	}
+	upsertKeywords := []string{
+		"ON CONFLICT",
+		"ON DUPLICATE KEY UPDATE",
+	}
+
+	// The keywords are matched with a single space between them, as they are
+	// formatted, whatever the spaces of the source are
+	keywords := &ast.ItemWith{Toks: append([]ast.Node{}, results...)}
 
 	whitespaceAfterMatcher := astutil.NodeMatcher{
-		ExpectKeyword: append(joinKeywords, insertKeyword),
+		ExpectKeyword: append(append(joinKeywords, upsertKeywords...), insertKeyword, "MERGE INTO"),
 	}
-	if whitespaceAfterMatcher.IsMatch(node) {
+	if whitespaceAfterMatcher.IsMatch(keywords) {
+		results = append(results, whitespaceNode)
+	}
+	insertMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{insertKeyword},
+	}
+	if insertMatcher.IsMatch(keywords) && env.reader != nil {
+		results = append(results, formatInsertColumns(env)...)
+	}
+	// The conflict target stays on the line of ON CONFLICT
+	conflictMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{"ON CONFLICT"},
+	}
+	if conflictMatcher.IsMatch(keywords) && env.reader != nil && env.reader.PeekNodeIs(true, tupleMatcher) {
+		env.reader.NextNode(true)
+		results = append(results, formatTuple(env.reader.CurNode.(*ast.Parenthesis), env, false)...)
 		results = append(results, whitespaceNode)
 	}
 
 	// Add an adjustment indent before the cursor
 	outdentBeforeMatcher := astutil.NodeMatcher{
-		ExpectKeyword: append(joinKeywords, byKeywords...),
+		ExpectKeyword: append(append(joinKeywords, byKeywords...), upsertKeywords...),
 	}
-	if outdentBeforeMatcher.IsMatch(node) {
+	if outdentBeforeMatcher.IsMatch(keywords) {
 		env.indentLevelDown()
+		env.startClause()
 		results = unshift(results, env.genIndent()...)
 		results = unshift(results, linebreakNode)
 	}
@@ -306,7 +643,7 @@ func formatMultiKeyword(node *ast.MultiKeyword, env *formatEnvironment) ast.Node
 	linebreakWithIndentAfterMatcher := astutil.NodeMatcher{
 		ExpectKeyword: byKeywords,
 	}
-	if linebreakWithIndentAfterMatcher.IsMatch(node) {
+	if linebreakWithIndentAfterMatcher.IsMatch(keywords) {
 		results = append(results, linebreakNode)
 		env.indentLevelUp()
 		results = append(results, env.genIndent()...)
@@ -316,22 +653,14 @@ func formatMultiKeyword(node *ast.MultiKeyword, env *formatEnvironment) ast.Node
 }
 
 func formatAliased(node *ast.Aliased, env *formatEnvironment) ast.Node {
-	var results []ast.Node
+	results := []ast.Node{Eval(node.RealName, env)}
+	if env.align && env.inSelectList {
+		results = append(results, aliasAlignNode)
+	}
 	if node.IsAs {
-		results = []ast.Node{
-			Eval(node.RealName, env),
-			whitespaceNode,
-			node.As,
-			whitespaceNode,
-			Eval(node.AliasedName, env),
-		}
-	} else {
-		results = []ast.Node{
-			Eval(node.RealName, env),
-			whitespaceNode,
-			Eval(node.AliasedName, env),
-		}
+		results = append(results, whitespaceNode, node.As)
 	}
+	results = append(results, whitespaceNode, Eval(node.AliasedName, env))
 	return &ast.ItemWith{Toks: results}
 }
 
@@ -355,12 +684,36 @@ func formatMemberIdentifier(node *ast.MemberIdentifier, env *formatEnvironment)
 	results := []ast.Node{
 		Eval(node.Parent, env),
 		periodNode,
-		Eval(node.Child, env),
+	}
+	// The member being typed has no child yet
+	if node.Child != nil {
+		results = append(results, Eval(node.Child, env))
 	}
 	return &ast.ItemWith{Toks: results}
 }
 
 func formatOperator(node *ast.Operator, env *formatEnvironment) ast.Node {
+	// The casts and the array subscripts of PostgreSQL are kept without the
+	// spaces
+	postfixMatcher := astutil.NodeMatcher{
+		ExpectTokens: []token.Kind{
+			token.DoubleColon,
+			token.LBracket,
+		},
+	}
+	if node.Operator != nil && postfixMatcher.IsMatch(node.Operator) {
+		results := []ast.Node{}
+		for _, tok := range node.GetTokens() {
+			if !isWhitespace(tok) {
+				results = append(results, Eval(tok, env))
+			}
+		}
+		return &ast.ItemWith{Toks: results}
+	}
+	if node.Left == nil || node.Right == nil {
+		// The operator is not known to the parser
+		return node
+	}
 	results := []ast.Node{
 		Eval(node.Left, env),
 		whitespaceNode,
@@ -372,6 +725,9 @@ func formatOperator(node *ast.Operator, env *formatEnvironment) ast.Node {
 }
 
 func formatComparison(node *ast.Comparison, env *formatEnvironment) ast.Node {
+	if node.Left == nil || node.Right == nil {
+		return node
+	}
 	results := []ast.Node{
 		Eval(node.Left, env),
 		whitespaceNode,
@@ -383,15 +739,29 @@ func formatComparison(node *ast.Comparison, env *formatEnvironment) ast.Node {
 }
 
 func formatParenthesis(node *ast.Parenthesis, env *formatEnvironment) ast.Node {
+	// The table hints of T-SQL stay on the line of the table
+	hintMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"WITH",
+		},
+	}
+	if env.reader != nil && env.reader.PrevNodeIs(true, hintMatcher) {
+		return node
+	}
+	if isWindowSpecification(node, env) {
+		return formatWindowSpecification(node)
+	}
 	results := []ast.Node{}
 	// results = append(results, whitespaceNode)
 	results = append(results, lparenNode)
 	startIndentLevel := env.indentLevel
+	inSelectList, inJoinCondition := env.inSelectList, env.inJoinCondition
 	env.indentLevelUp()
 	results = append(results, linebreakNode)
 	results = append(results, env.genIndent()...)
 	results = append(results, Eval(node.Inner(), env))
 	env.indentLevel = startIndentLevel
+	env.inSelectList, env.inJoinCondition = inSelectList, inJoinCondition
 	results = append(results, linebreakNode)
 	results = append(results, env.genIndent()...)
 	results = append(results, rparenNode)
@@ -399,17 +769,180 @@ func formatParenthesis(node *ast.Parenthesis, env *formatEnvironment) ast.Node {
 	return &ast.ItemWith{Toks: results}
 }
 
+var windowSpecificationMatcher = astutil.NodeMatcher{
+	ExpectKeyword: []string{
+		"PARTITION BY",
+		"ORDER BY",
+		"ROWS",
+		"ROWS BETWEEN",
+		"RANGE",
+		"RANGE BETWEEN",
+		"GROUPS",
+		"GROUPS BETWEEN",
+	},
+}
+
+// isWindowSpecification reports whether the parentheses are the window of
+// OVER, or the one defined in the WINDOW clause.
+func isWindowSpecification(node *ast.Parenthesis, env *formatEnvironment) bool {
+	if env.reader == nil {
+		return false
+	}
+	overMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"OVER",
+		},
+	}
+	if env.reader.PrevNodeIs(true, overMatcher) {
+		return true
+	}
+	asMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"AS",
+		},
+	}
+	if !env.reader.PrevNodeIs(true, asMatcher) {
+		return false
+	}
+	for _, tok := range node.Inner().GetTokens() {
+		if isWhitespace(tok) || isComment(tok) {
+			continue
+		}
+		return windowSpecificationMatcher.IsMatch(tok)
+	}
+	return false
+}
+
+// formatWindowSpecification keeps the window on one line, with the single
+// spaces between its words.
+func formatWindowSpecification(node *ast.Parenthesis) ast.Node {
+	results := []ast.Node{}
+	space := false
+	for _, leaf := range flattenNodes(node.Inner()) {
+		switch {
+		case isComment(leaf):
+			// The comments are put back after formatting
+		case isWhitespace(leaf):
+			space = len(results) > 0
+		default:
+			if space {
+				results = append(results, whitespaceNode)
+				space = false
+			}
+			results = append(results, leaf)
+		}
+	}
+	results = unshift(results, lparenNode)
+	results = append(results, rparenNode)
+	return &ast.ItemWith{Toks: results}
+}
+
 func formatFunctionLiteral(node *ast.FunctionLiteral, env *formatEnvironment) ast.Node {
 	results := []ast.Node{node}
+	toks := node.GetTokens()
+	if env.maxLineWidth == 0 || len(toks) != 2 {
+		return &ast.ItemWith{Toks: results}
+	}
+	args, ok := toks[1].(*ast.Parenthesis)
+	if !ok {
+		return &ast.ItemWith{Toks: results}
+	}
+	argList := splitArguments(args.Inner().GetTokens())
+	width := env.indentWidth() + len(toks[0].String()) + len("()")
+	for i, arg := range argList {
+		if i > 0 {
+			width += len(", ")
+		}
+		width += len(arg.String())
+	}
+	if width <= env.maxLineWidth || len(argList) < 2 {
+		return &ast.ItemWith{Toks: results}
+	}
+
+	// Put the arguments one per line
+	results = []ast.Node{toks[0], lparenNode}
+	startIndentLevel := env.indentLevel
+	env.indentLevelUp()
+	for i, arg := range argList {
+		results = append(results, linebreakNode)
+		results = append(results, env.genIndent()...)
+		if i > 0 && env.leadingComma {
+			results = append(results, commaNode, whitespaceNode)
+		}
+		results = append(results, arg)
+		if i < len(argList)-1 && !env.leadingComma {
+			results = append(results, commaNode)
+		}
+	}
+	env.indentLevel = startIndentLevel
+	results = append(results, linebreakNode)
+	results = append(results, env.genIndent()...)
+	results = append(results, rparenNode)
 	return &ast.ItemWith{Toks: results}
 }
 
+// splitArguments splits the tokens inside the parentheses of a function call
+// at the commas, dropping the whitespaces around the arguments and the
+// comments.
+func splitArguments(toks []ast.Node) []ast.Node {
+	args := []ast.Node{}
+	arg := []ast.Node{}
+	appendArg := func() {
+		for len(arg) > 0 && isWhitespace(arg[len(arg)-1]) {
+			arg = arg[:len(arg)-1]
+		}
+		if len(arg) > 0 {
+			args = append(args, &ast.ItemWith{Toks: arg})
+		}
+		arg = []ast.Node{}
+	}
+	var walk func(toks []ast.Node)
+	walk = func(toks []ast.Node) {
+		for _, tok := range toks {
+			switch {
+			case isComma(tok):
+				appendArg()
+			case isWhitespace(tok) && len(arg) == 0:
+			case isComment(tok):
+				// The comments are put back after formatting
+			default:
+				// The parser groups some of the arguments into identifier lists
+				if list, ok := tok.(*ast.IdentifierList); ok {
+					walk(list.GetTokens())
+					continue
+				}
+				arg = append(arg, tok)
+			}
+		}
+	}
+	walk(toks)
+	appendArg()
+	return args
+}
+
+func isComma(node ast.Node) bool {
+	item, ok := node.(*ast.Item)
+	return ok && item.GetToken().Kind == token.Comma
+}
+
+func isWhitespace(node ast.Node) bool {
+	item, ok := node.(*ast.Item)
+	return ok && item.GetToken().Kind == token.Whitespace
+}
+
 func formatIdentifierList(identifierList *ast.IdentifierList, env *formatEnvironment) ast.Node {
 	idents := identifierList.GetIdentifiers()
 	results := []ast.Node{}
 	for i, ident := range idents {
 		results = append(results, Eval(ident, env))
-		if i != len(idents)-1 {
+		if i == len(idents)-1 {
+			continue
+		}
+		if env.leadingComma {
+			results = append(results, linebreakNode)
+			results = append(results, env.genIndent()...)
+			results = append(results, commaNode, whitespaceNode)
+		} else {
 			results = append(results, commaNode, linebreakNode)
 			results = append(results, env.genIndent()...)
 		}
@@ -418,6 +951,7 @@ func formatIdentifierList(identifierList *ast.IdentifierList, env *formatEnviron
 }
 
 func formatTokenList(list ast.TokenList, env *formatEnvironment) ast.Node {
+	list.SetTokens(unnestInsert(list.GetTokens()))
 	results := []ast.Node{}
 	reader := astutil.NewNodeReader(list)
 	for reader.NextNode(true) {
@@ -428,6 +962,53 @@ func formatTokenList(list ast.TokenList, env *formatEnvironment) ast.Node {
 	return reader.Node
 }
 
+// unnestInsert takes the tuples of VALUES and the column list of INSERT INTO
+// out of the function calls that the parser makes of them when there is no
+// space before the parentheses.
+func unnestInsert(toks []ast.Node) []ast.Node {
+	valuesMatcher := astutil.NodeMatcher{
+		ExpectKeyword: []string{
+			"VALUES",
+		},
+	}
+	isValues := func(node ast.Node) bool {
+		fn, ok := node.(*ast.FunctionLiteral)
+		return ok && valuesMatcher.IsMatch(fn.GetTokens()[0])
+	}
+	afterInsert := func(res []ast.Node) bool {
+		for i := len(res) - 1; i >= 0; i-- {
+			if isWhitespace(res[i]) {
+				continue
+			}
+			mk, ok := res[i].(*ast.MultiKeyword)
+			if !ok {
+				return false
+			}
+			kws := mk.GetKeywords()
+			return len(kws) == 2 && strings.EqualFold(kws[0].String(), "INSERT") && strings.EqualFold(kws[1].String(), "INTO")
+		}
+		return false
+	}
+
+	res := make([]ast.Node, 0, len(toks))
+	for _, tok := range toks {
+		switch tok := tok.(type) {
+		case *ast.FunctionLiteral:
+			if isValues(tok) || afterInsert(res) {
+				res = append(res, tok.GetTokens()...)
+				continue
+			}
+		case *ast.IdentifierList:
+			if isValues(tok.GetTokens()[0]) {
+				res = append(res, unnestInsert(tok.GetTokens())...)
+				continue
+			}
+		}
+		res = append(res, tok)
+	}
+	return res
+}
+
 func formatNode(node ast.Node, env *formatEnvironment) ast.Node {
 	return node
 }
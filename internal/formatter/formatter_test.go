@@ -1,6 +1,7 @@
 package formatter
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -8,7 +9,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
 	"github.com/sqls-server/sqls/internal/config"
 	"github.com/sqls-server/sqls/internal/lsp"
 	"github.com/sqls-server/sqls/parser"
@@ -20,22 +24,321 @@ func TestEval(t *testing.T) {
 		input    string
 		params   lsp.DocumentFormattingParams
 		config   *config.Config
+		driver   dialect.DatabaseDriver
 		expected string
 	}{
 		{
 			name:     "InsertIntoFormat",
 			input:    "INSERT INTO users (NAME, email) VALUES ('john doe', 'example@host.com')",
-			expected: "INSERT INTO users(\n\tNAME,\n\temail\n)\nVALUES(\n\t'john doe',\n\t'example@host.com'\n)",
+			expected: "INSERT INTO users (NAME, email)\nVALUES\n\t('john doe', 'example@host.com')",
 			params:   lsp.DocumentFormattingParams{},
 			config: &config.Config{
 				LowercaseKeywords: false,
 			},
 		},
+		{
+			name:     "CapitalizeKeywords",
+			input:    "select id from users where id = 1",
+			expected: "Select\n\tid\nFrom\n\tusers\nWhere\n\tid = 1",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				LowercaseKeywords: true,
+				KeywordCase:       ast.CaseCapitalize,
+			},
+		},
+		{
+			name:     "LeadingCommaSelect",
+			input:    "SELECT id, name, count(*) FROM city GROUP BY id, name ORDER BY id, name",
+			expected: "SELECT\n\tid\n\t, name\n\t, COUNT(*)\nFROM\n\tcity\nGROUP BY\n\tid\n\t, name\nORDER BY\n\tid\n\t, name",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				CommaStyle: config.CommaStyleLeading,
+			},
+		},
+		{
+			name:     "LeadingCommaInsert",
+			input:    "INSERT INTO users (NAME, email) VALUES ('john doe', 'example@host.com'), ('jane doe', 'jane@host.com')",
+			expected: "INSERT INTO users (NAME, email)\nVALUES\n\t  ('john doe', 'example@host.com')\n\t, ('jane doe', 'jane@host.com')",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				CommaStyle: config.CommaStyleLeading,
+			},
+		},
+		{
+			name:     "TrailingCommaSelect",
+			input:    "SELECT id, name, count(*) FROM city GROUP BY id, name ORDER BY id, name",
+			expected: "SELECT\n\tid,\n\tname,\n\tCOUNT(*)\nFROM\n\tcity\nGROUP BY\n\tid,\n\tname\nORDER BY\n\tid,\n\tname",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "LowerIdentifiers",
+			input:    "SELECT u.ID FROM USERS u, \"Items\"",
+			expected: "SELECT\n\tu.id\nFROM\n\tusers u,\n\t\"Items\"",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				IdentifierCase: ast.CaseLower,
+			},
+		},
+		{
+			name:     "MaxLineWidthWrapsArguments",
+			input:    "SELECT concat(u.first_name, ' ', upper(u.last_name), ' <', u.email, '>') FROM users u",
+			expected: "SELECT\n\tconcat(\n\t\tu.first_name,\n\t\t' ',\n\t\tUPPER(u.last_name),\n\t\t' <',\n\t\tu.email,\n\t\t'>'\n\t)\nFROM\n\tusers u",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				MaxLineWidth: 40,
+			},
+		},
+		{
+			name:     "MaxLineWidthLeadingComma",
+			input:    "SELECT concat(u.first_name, ' ', upper(u.last_name), ' <', u.email, '>') FROM users u",
+			expected: "SELECT\n\tconcat(\n\t\tu.first_name\n\t\t, ' '\n\t\t, UPPER(u.last_name)\n\t\t, ' <'\n\t\t, u.email\n\t\t, '>'\n\t)\nFROM\n\tusers u",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				MaxLineWidth: 40,
+				CommaStyle:   config.CommaStyleLeading,
+			},
+		},
+		{
+			name:     "MaxLineWidthKeepsShortCall",
+			input:    "SELECT concat(first_name, last_name) FROM users",
+			expected: "SELECT\n\tconcat(first_name, last_name)\nFROM\n\tusers",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				MaxLineWidth: 40,
+			},
+		},
+		{
+			name:     "TrailingComments",
+			input:    "SELECT id, -- the id\n name FROM users -- all users\nWHERE id = 1 -- first\nAND name = 'a'",
+			expected: "SELECT\n\tid, -- the id\n\tname\nFROM\n\tusers -- all users\nWHERE\n\tid = 1 -- first\n\tAND name = 'a'",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "OwnLineComments",
+			input:    "-- active users\nSELECT id\n-- only the table of users\nFROM users\n-- the end",
+			expected: "-- active users\nSELECT\n\tid\n-- only the table of users\nFROM\n\tusers\n-- the end",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "BlockComments",
+			input:    "SELECT /* columns */ id, /* key */name FROM users",
+			expected: "SELECT /* columns */\n\tid,\n\t/* key */ name\nFROM\n\tusers",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "CommentsInWrappedArguments",
+			input:    "SELECT concat(first_name, -- given name\n last_name) FROM users",
+			expected: "SELECT\n\tconcat(\n\t\tfirst_name, -- given name\n\t\tlast_name\n\t)\nFROM\n\tusers",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				MaxLineWidth: 20,
+			},
+		},
+		{
+			name:     "IndentWithSpaces",
+			input:    "SELECT id FROM users WHERE id = 1",
+			expected: "SELECT\n  id\nFROM\n  users\nWHERE\n  id = 1",
+			params: lsp.DocumentFormattingParams{
+				Options: lsp.FormattingOptions{TabSize: 4, InsertSpaces: false},
+			},
+			config: &config.Config{
+				IndentStyle: config.IndentStyleSpace,
+				IndentWidth: 2,
+			},
+		},
+		{
+			name:     "IndentWithClientOptions",
+			input:    "SELECT id FROM users",
+			expected: "SELECT\n    id\nFROM\n    users",
+			params: lsp.DocumentFormattingParams{
+				Options: lsp.FormattingOptions{TabSize: 4, InsertSpaces: true},
+			},
+			config: &config.Config{},
+		},
+		{
+			name:     "NestedSubquery",
+			input:    "SELECT id FROM users WHERE id IN (SELECT user_id FROM orders WHERE EXISTS (SELECT 1 FROM items WHERE items.order_id = orders.id))",
+			expected: "SELECT\n  id\nFROM\n  users\nWHERE\n  id IN (\n    SELECT\n      user_id\n    FROM\n      orders\n    WHERE\n      EXISTS (\n        SELECT\n          1\n        FROM\n          items\n        WHERE\n          items.order_id = orders.id\n      )\n  )",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				IndentStyle: config.IndentStyleSpace,
+				IndentWidth: 2,
+			},
+		},
+		{
+			name:     "CommonTableExpressions",
+			input:    "WITH a AS (SELECT id FROM users), b AS (SELECT id FROM a) SELECT id FROM b",
+			expected: "WITH a AS (\n  SELECT\n    id\n  FROM\n    users\n),\nb AS (\n  SELECT\n    id\n  FROM\n    a\n)\nSELECT\n  id\nFROM\n  b",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				IndentStyle: config.IndentStyleSpace,
+				IndentWidth: 2,
+			},
+		},
+		{
+			name:     "NestedCase",
+			input:    "SELECT CASE WHEN a = 1 THEN CASE WHEN b = 1 THEN 1 ELSE 2 END ELSE 3 END FROM t",
+			expected: "SELECT\n  CASE\n    WHEN a = 1 THEN CASE\n      WHEN b = 1 THEN 1\n      ELSE 2\n    END\n    ELSE 3\n  END\nFROM\n  t",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				IndentStyle: config.IndentStyleSpace,
+				IndentWidth: 2,
+			},
+		},
+		{
+			name:     "Union",
+			input:    "SELECT a FROM t UNION SELECT b FROM u",
+			expected: "SELECT\n\ta\nFROM\n\tt\nUNION\nSELECT\n\tb\nFROM\n\tu",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "MssqlTopAndBrackets",
+			input:    "select top 10 [Id], [Name] from [dbo].[Users] u with (nolock)",
+			expected: "SELECT TOP 10\n\t[Id],\n\t[Name]\nFROM\n\t[dbo].[Users] U WITH (NOLOCK)",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				IdentifierCase: ast.CaseUpper,
+			},
+			driver: dialect.DatabaseDriverMssql,
+		},
+		{
+			name:     "ColumnNamedTop",
+			input:    "select top from t",
+			expected: "SELECT\n\ttop\nFROM\n\tt",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "PostgresCastAndReturning",
+			input:    "update t set a = b::int where c ilike 'x%' returning id",
+			expected: "update t\nset a = b::int\nwhere\n\tc ilike 'x%'\nreturning\n\tid",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				KeywordCase: ast.CaseLower,
+			},
+			driver: dialect.DatabaseDriverPostgreSQL,
+		},
+		{
+			name:     "MysqlBackticksAndLimit",
+			input:    "SELECT `Id` FROM `Users` LIMIT 10 OFFSET 5",
+			expected: "SELECT\n\t`Id`\nFROM\n\t`Users`\nLIMIT 10 OFFSET 5",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				IdentifierCase: ast.CaseLower,
+			},
+			driver: dialect.DatabaseDriverMySQL,
+		},
+		{
+			name:     "PostgreSQLOperators",
+			input:    "SELECT doc ->> 'name', doc->'a'->>'b', tags @> ARRAY['x'], arr[1], id::text, a||b FROM t",
+			expected: "SELECT\n\tdoc ->> 'name',\n\tdoc -> 'a' ->> 'b',\n\ttags @> ARRAY['x'],\n\tarr[1],\n\tid::TEXT,\n\ta || b\nFROM\n\tt",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+			driver:   dialect.DatabaseDriverPostgreSQL,
+		},
+		{
+			name:     "OnConflict",
+			input:    "INSERT INTO city (id, name) VALUES (1, 'a') ON CONFLICT (id) DO UPDATE SET name = excluded.name",
+			expected: "INSERT INTO city (id, name)\nVALUES\n\t(1, 'a')\nON CONFLICT (id) DO UPDATE\nSET name = excluded.name",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+			driver:   dialect.DatabaseDriverPostgreSQL,
+		},
+		{
+			name:     "OnDuplicateKeyUpdate",
+			input:    "INSERT INTO city (id, name) VALUES (1, 'a') ON DUPLICATE KEY UPDATE name = VALUES(name)",
+			expected: "INSERT INTO city (id, name)\nVALUES\n\t(1, 'a')\nON DUPLICATE KEY UPDATE name = VALUES(name)",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+			driver:   dialect.DatabaseDriverMySQL,
+		},
+		{
+			name:     "InsertValues",
+			input:    "insert into city(id,name) values(1,'Kabul'),(10,'Herat'), (100, 'Qandahar')",
+			expected: "INSERT INTO city (id, name)\nVALUES\n\t(1,   'Kabul'),\n\t(10,  'Herat'),\n\t(100, 'Qandahar')",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "InsertValuesLeadingComma",
+			input:    "INSERT INTO t VALUES (1, 'a'), (22, 'b')",
+			expected: "INSERT INTO t\nVALUES\n\t  (1,  'a')\n\t, (22, 'b')",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				CommaStyle: config.CommaStyleLeading,
+			},
+		},
+		{
+			name:     "InsertSelect",
+			input:    "INSERT INTO t (a, b) SELECT x, y FROM u",
+			expected: "INSERT INTO t (a, b)\nSELECT\n\tx,\n\ty\nFROM\n\tu",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "AlignAliases",
+			input:    "SELECT u.id AS user_id, count(o.id) AS order_count, u.email mail, CASE WHEN u.id = 1 THEN 'a' END AS k FROM users AS u",
+			expected: "SELECT\n  u.id        AS user_id,\n  COUNT(o.id) AS order_count,\n  u.email     mail,\n  CASE\n    WHEN u.id = 1 THEN 'a'\n  END         AS k\nFROM\n  users AS u",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				IndentStyle:  config.IndentStyleSpace,
+				IndentWidth:  2,
+				AlignColumns: true,
+			},
+		},
+		{
+			name:     "AlignJoinConditions",
+			input:    "SELECT o.id FROM users u JOIN orders o ON o.user_id = u.id AND o.state = 1 OR o.state = 2 WHERE u.id = 1 OR u.id = 2",
+			expected: "SELECT\n  o.id\nFROM\n  users u\nJOIN orders o\n   ON o.user_id = u.id\n  AND o.state = 1\n   OR o.state = 2\nWHERE\n  u.id = 1\n  OR u.id = 2",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				IndentStyle:  config.IndentStyleSpace,
+				IndentWidth:  2,
+				AlignColumns: true,
+			},
+		},
+		{
+			name:     "AlignAliasesInSubquery",
+			input:    "SELECT id AS i FROM users WHERE id IN (SELECT user_id AS uid, total AS t FROM orders)",
+			expected: "SELECT\n  id AS i\nFROM\n  users\nWHERE\n  id IN (\n    SELECT\n      user_id AS uid,\n      total   AS t\n    FROM\n      orders\n  )",
+			params:   lsp.DocumentFormattingParams{},
+			config: &config.Config{
+				IndentStyle:  config.IndentStyleSpace,
+				IndentWidth:  2,
+				AlignColumns: true,
+			},
+		},
+		{
+			name:     "WindowFrameAndQualify",
+			input:    "select id, row_number() over(partition by a\n order by b rows between unbounded preceding and current row) as rn from t qualify rn = 1",
+			expected: "SELECT\n\tid,\n\tROW_NUMBER() OVER (PARTITION BY a ORDER BY b ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS rn\nFROM\n\tt\nQUALIFY\n\trn = 1",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "WindowClause",
+			input:    "SELECT SUM(x) OVER w FROM t WINDOW w AS (ORDER BY b RANGE BETWEEN 1 PRECEDING AND 1 FOLLOWING EXCLUDE TIES) ORDER BY 1",
+			expected: "SELECT\n\tSUM(x) OVER w\nFROM\n\tt\nWINDOW\n\tw AS (ORDER BY b RANGE BETWEEN 1 PRECEDING AND 1 FOLLOWING EXCLUDE TIES)\nORDER BY\n\t1",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
+		{
+			name:     "MemberBeingTyped",
+			input:    "SELECT a FROM t JOIN u ON t.a = u.",
+			expected: "SELECT\n\ta\nFROM\n\tt\nJOIN u\n\tON t.a = u.",
+			params:   lsp.DocumentFormattingParams{},
+			config:   &config.Config{},
+		},
 	}
 
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
-			actual, _ := Format(tt.input, tt.params, tt.config)
+			actual, _ := Format(tt.input, tt.params, tt.config, tt.driver)
 			if actual[0].NewText != tt.expected {
 				t.Errorf("expected: %s, got %s", tt.expected, actual[0].NewText)
 			}
@@ -43,6 +346,201 @@ func TestEval(t *testing.T) {
 	}
 }
 
+func TestFormatDirectives(t *testing.T) {
+	testcases := []struct {
+		name     string
+		input    string
+		expected []lsp.TextEdit
+	}{
+		{
+			name:  "OffAndOn",
+			input: "SELECT a FROM t;\n-- sqls-fmt: off\nINSERT INTO t VALUES\n  (1,   2),\n  (10, 20);\n-- sqls-fmt: on\nSELECT b FROM u;",
+			expected: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 0, Character: 16}},
+					NewText: "SELECT\n\ta\nFROM\n\tt;\n",
+				},
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 6, Character: 0}, End: lsp.Position{Line: 6, Character: 16}},
+					NewText: "SELECT\n\tb\nFROM\n\tu;\n",
+				},
+			},
+		},
+		{
+			name:  "OffToEnd",
+			input: "SELECT a FROM t;\n-- sqls-fmt: off\nSELECT  b\nFROM    u;",
+			expected: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 0, Character: 16}},
+					NewText: "SELECT\n\ta\nFROM\n\tt;\n",
+				},
+			},
+		},
+		{
+			name:  "SkipStatement",
+			input: "-- sqls-fmt: skip\nSELECT  a,\n        b FROM t;\nSELECT c FROM u;",
+			expected: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 3, Character: 0}, End: lsp.Position{Line: 3, Character: 16}},
+					NewText: "SELECT\n\tc\nFROM\n\tu;\n",
+				},
+			},
+		},
+		{
+			name:  "PsqlMetaCommand",
+			input: "\\copy t (a, b) from 'data.csv' with (format csv)\nSELECT c FROM u;",
+			expected: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 1, Character: 0}, End: lsp.Position{Line: 1, Character: 16}},
+					NewText: "SELECT\n\tc\nFROM\n\tu;\n",
+				},
+			},
+		},
+		{
+			name:     "AllDisabled",
+			input:    "-- sqls-fmt: off\nSELECT  a FROM t;",
+			expected: []lsp.TextEdit{},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := Format(tt.input, lsp.DocumentFormattingParams{}, &config.Config{}, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("unmatched edits (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "idempotent", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs := map[string]*config.Config{
+		"Default": {},
+		"LeadingComma": {
+			CommaStyle:   config.CommaStyleLeading,
+			AlignColumns: true,
+		},
+		"Options": {
+			KeywordCase:  ast.CaseLower,
+			MaxLineWidth: 40,
+			IndentStyle:  config.IndentStyleSpace,
+			IndentWidth:  2,
+		},
+	}
+	for _, fname := range files {
+		b, err := os.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, cfg := range configs {
+			t.Run(filepath.Base(fname)+"/"+name, func(t *testing.T) {
+				once := formatAndApply(t, string(b), cfg)
+				twice := formatAndApply(t, once, cfg)
+				if diff := cmp.Diff(once, twice); diff != "" {
+					t.Errorf("formatted again (-once +twice):\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
+// formatAndApply returns the text with the formatting edits applied.
+func formatAndApply(t *testing.T, text string, cfg *config.Config) string {
+	t.Helper()
+	edits, err := Format(text, lsp.DocumentFormattingParams{}, cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset := func(lines []string, pos lsp.Position) int {
+		n := 0
+		for _, l := range lines[:pos.Line] {
+			n += len(l) + 1
+		}
+		return n + pos.Character
+	}
+	// The edits are in the order of the document
+	for i := len(edits) - 1; i >= 0; i-- {
+		lines := strings.Split(text, "\n")
+		start, end := offset(lines, edits[i].Range.Start), offset(lines, edits[i].Range.End)
+		text = text[:start] + edits[i].NewText + text[end:]
+	}
+	return text
+}
+
+func TestFormatExternal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands need sh")
+	}
+	testcases := []struct {
+		name     string
+		input    string
+		cfg      *config.ExternalFormatter
+		expected []lsp.TextEdit
+		errMsg   string
+	}{
+		{
+			name:  "Formatted",
+			input: "select a\nfrom t é",
+			cfg:   &config.ExternalFormatter{Command: "tr a-z A-Z"},
+			expected: []lsp.TextEdit{
+				{
+					Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 1, Character: 8}},
+					NewText: "SELECT A\nFROM T é",
+				},
+			},
+		},
+		{
+			name:     "Unchanged",
+			input:    "SELECT a FROM t",
+			cfg:      &config.ExternalFormatter{Command: "cat"},
+			expected: nil,
+		},
+		{
+			name:   "Failed",
+			input:  "SELECT a FROM t",
+			cfg:    &config.ExternalFormatter{Command: "echo 'parse error' >&2; exit 1"},
+			errMsg: `cannot execute external formatter "echo 'parse error' >&2; exit 1", exit status 1, parse error`,
+		},
+		{
+			name:   "NoOutput",
+			input:  "SELECT a FROM t",
+			cfg:    &config.ExternalFormatter{Command: "cat > /dev/null"},
+			errMsg: `external formatter "cat > /dev/null" wrote nothing to stdout`,
+		},
+		{
+			name:   "Timeout",
+			input:  "SELECT a FROM t",
+			cfg:    &config.ExternalFormatter{Command: "sleep 5", Timeout: 1},
+			errMsg: `external formatter "sleep 5" timed out after 1s`,
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := FormatExternal(context.Background(), tt.input, tt.cfg, t.TempDir())
+			if tt.errMsg != "" {
+				if err == nil || err.Error() != tt.errMsg {
+					t.Fatalf("expected error %q, got %v", tt.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("unmatched edits (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestRenderIdentifier(t *testing.T) {
 	testcases := []struct {
 		name     string
@@ -147,7 +645,7 @@ func TestFormat(t *testing.T) {
 			t.Fatal(err)
 		}
 		env := &formatEnvironment{}
-		formatted := Eval(parsed, env)
+		formatted := formatSource(parsed, env)
 		got := strings.TrimRight(formatted.Render(opts), "\n") + "\n"
 
 		b, err = os.ReadFile(fname[:len(fname)-4] + ".golden")
@@ -51,3 +51,34 @@ var commaNode = ast.NewItem(&token.Token{
 	Kind:  token.Comma,
 	Value: ",",
 })
+
+// separateWords puts a space between the words that the formatter has put
+// next to each other, which were apart in the source. Otherwise the
+// constructs that the formatter does not know, such as TOP 10, would be
+// joined into another word.
+func separateWords(formatted ast.Node, source map[ast.Node]bool) ast.Node {
+	leaves := flattenNodes(formatted)
+	out := make([]ast.Node, 0, len(leaves))
+	for i, leaf := range leaves {
+		if i > 0 {
+			prev := leaves[i-1]
+			if source[prev] && source[leaf] && isWord(prev) && isWord(leaf) && token.ComparePos(prev.End(), leaf.Pos()) != 0 {
+				out = append(out, whitespaceNode)
+			}
+		}
+		out = append(out, leaf)
+	}
+	return &ast.ItemWith{Toks: out}
+}
+
+func isWord(node ast.Node) bool {
+	tok, ok := node.(ast.Token)
+	if !ok {
+		return false
+	}
+	switch tok.GetToken().Kind {
+	case token.SQLKeyword, token.Number, token.SingleQuotedString, token.NationalStringLiteral, token.DollarQuotedString:
+		return true
+	}
+	return false
+}
@@ -0,0 +1 @@
+SELECT id, CASE WHEN a = 1 THEN 'one' WHEN a = 2 THEN 'two' ELSE 'many' END AS label FROM t WHERE EXISTS (SELECT 1 FROM u WHERE u.id = t.id) AND name LIKE 'A%';
@@ -0,0 +1,5 @@
+-- header
+SELECT a, -- the a
+  b /* the b */, c
+FROM t -- table
+WHERE x = 1; /* end */
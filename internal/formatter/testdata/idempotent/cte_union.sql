@@ -0,0 +1 @@
+WITH t AS (SELECT a FROM x WHERE b = 1), u AS (SELECT a FROM y) SELECT a FROM t UNION SELECT a FROM u EXCEPT SELECT a FROM z;
@@ -0,0 +1,5 @@
+SELECT a, b
+FROM t;
+-- sqls-fmt: skip
+SELECT  x ,y FROM z;
+SELECT q FROM r;
@@ -0,0 +1 @@
+SELECT coalesce(a, b, 'default value that is quite long'), upper(name), concat(first_name, ' ', last_name, ' ', middle_name, ' ', suffix) FROM people;
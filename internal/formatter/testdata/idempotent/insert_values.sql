@@ -0,0 +1,3 @@
+INSERT INTO city(id,name,country_code) VALUES(1,'Kabul','AFG'),(2,'Qandahar','AFG'), (3, 'Herat', 'AFG');
+insert   into  t values (1, 'a');
+INSERT INTO t (a, b) SELECT x, y FROM u WHERE z IN (1, 2, 3);
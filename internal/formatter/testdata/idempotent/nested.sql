@@ -0,0 +1,2 @@
+SELECT a FROM t WHERE b IN (1,2) AND c NOT IN (SELECT d FROM e);
+SELECT * FROM (SELECT a FROM t) sub;
@@ -0,0 +1 @@
+select a.id, b.name as bname, count(*) cnt from a inner join b on a.id = b.a_id and b.x > 1 left outer join c on c.id = b.c_id where a.x = 1 or a.y between 1 and 10 group by a.id, b.name having count(*) > 1 order by cnt desc limit 10 offset 5;
@@ -0,0 +1,4 @@
+select a from t   left   join u on t.id=u.id;
+
+
+select b from v order   by b;
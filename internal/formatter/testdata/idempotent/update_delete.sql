@@ -0,0 +1,2 @@
+UPDATE city SET name = 'x', population = population + 1 WHERE id = 1;
+DELETE FROM city WHERE id IN (SELECT id FROM old_city WHERE flag = 1);
@@ -0,0 +1,2 @@
+INSERT INTO t (a) VALUES (1), -- first
+(2) /* second */, (3);
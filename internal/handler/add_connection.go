@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+const (
+	wizardPasswordInConfig  = "Config file"
+	wizardPasswordInKeyring = "OS keyring"
+	wizardSaveAnyway        = "Save anyway"
+	wizardCancel            = "Cancel"
+)
+
+var errWizardCancelled = errors.New("adding connection is cancelled")
+
+var wizardDrivers = []dialect.DatabaseDriver{
+	dialect.DatabaseDriverMySQL,
+	dialect.DatabaseDriverPostgreSQL,
+	dialect.DatabaseDriverSQLite3,
+	dialect.DatabaseDriverMssql,
+	dialect.DatabaseDriverOracle,
+	dialect.DatabaseDriverVertica,
+	dialect.DatabaseDriverClickhouse,
+	dialect.DatabaseDriverH2,
+}
+
+var wizardDefaultPorts = map[dialect.DatabaseDriver]int{
+	dialect.DatabaseDriverMySQL:      3306,
+	dialect.DatabaseDriverPostgreSQL: 5432,
+	dialect.DatabaseDriverMssql:      1433,
+	dialect.DatabaseDriverOracle:     1521,
+	dialect.DatabaseDriverVertica:    5433,
+	dialect.DatabaseDriverClickhouse: 9000,
+}
+
+// connectionWizard asks the client for a new connection and adds it to the
+// user config. The answers arrive through the same connection as the
+// requests, so it runs in the background, and the reloaded config is adopted
+// by the next request.
+type connectionWizard struct {
+	mu      sync.Mutex
+	running bool
+	added   *config.Config
+}
+
+func (s *Server) addConnection(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	s.wizard.mu.Lock()
+	defer s.wizard.mu.Unlock()
+	if s.wizard.running {
+		return nil, errors.New("a connection is already being added")
+	}
+	s.wizard.running = true
+	go s.runConnectionWizard(conn, config.YamlConfigPath)
+	return nil, nil
+}
+
+func (s *Server) runConnectionWizard(conn *jsonrpc2.Conn, fp string) {
+	// The wizard can be run again
+	defer func() {
+		if err := logging.Recovered(slog.Default(), recover(), "adding connection"); err != nil {
+			s.wizard.mu.Lock()
+			s.wizard.running = false
+			s.wizard.mu.Unlock()
+		}
+	}()
+	ctx := context.Background()
+	added, err := promptConnection(ctx, conn, fp)
+	messenger := lsp.NewMessenger(conn)
+
+	s.wizard.mu.Lock()
+	defer s.wizard.mu.Unlock()
+	s.wizard.running = false
+	switch {
+	case errors.Is(err, errWizardCancelled):
+		slog.Info(err.Error())
+		return
+	case err != nil:
+		if err := messenger.ShowError(ctx, err.Error()); err != nil {
+			slog.Warn("cannot send error", "err", err)
+		}
+		return
+	}
+	s.wizard.added = added
+	alias := added.Connections[len(added.Connections)-1].Alias
+	if err := messenger.ShowInfo(ctx, fmt.Sprintf("added connection %s to %s", alias, fp)); err != nil {
+		slog.Warn("cannot send info", "err", err)
+	}
+}
+
+// adoptAddedConnection makes the user config with the connection added by the
+// wizard the config of the server.
+func (s *Server) adoptAddedConnection() {
+	s.wizard.mu.Lock()
+	defer s.wizard.mu.Unlock()
+	if s.wizard.added == nil {
+		return
+	}
+	s.DefaultFileCfg = s.wizard.added
+	s.wizard.added = nil
+}
+
+// promptConnection asks for the connection, tests it and writes it to the
+// config file. It returns the config reloaded from the file.
+func promptConnection(ctx context.Context, conn *jsonrpc2.Conn, fp string) (*config.Config, error) {
+	drivers := make([]string, len(wizardDrivers))
+	for i, d := range wizardDrivers {
+		drivers[i] = string(d)
+	}
+	driver, err := promptChoice(ctx, conn, "Select the driver of the new connection", drivers)
+	if err != nil {
+		return nil, err
+	}
+	connCfg := &database.DBConfig{Driver: dialect.DatabaseDriver(driver)}
+	if connCfg.Alias, err = promptInput(ctx, conn, "Alias of the connection", driver, false); err != nil {
+		return nil, err
+	}
+
+	var password string
+	switch connCfg.Driver {
+	case dialect.DatabaseDriverSQLite3, dialect.DatabaseDriverH2:
+		if connCfg.DataSourceName, err = promptInput(ctx, conn, "Data source name", "", false); err != nil {
+			return nil, err
+		}
+	default:
+		connCfg.Proto = database.ProtoTCP
+		if connCfg.Host, err = promptInput(ctx, conn, "Host", "127.0.0.1", false); err != nil {
+			return nil, err
+		}
+		port, err := promptInput(ctx, conn, "Port", strconv.Itoa(wizardDefaultPorts[connCfg.Driver]), false)
+		if err != nil {
+			return nil, err
+		}
+		if connCfg.Port, err = strconv.Atoi(port); err != nil {
+			return nil, fmt.Errorf("invalid port %q", port)
+		}
+		if connCfg.User, err = promptInput(ctx, conn, "User", "", false); err != nil {
+			return nil, err
+		}
+		if password, err = promptInput(ctx, conn, "Password", "", true); err != nil {
+			return nil, err
+		}
+		if connCfg.DBName, err = promptInput(ctx, conn, "Database name", "", false); err != nil {
+			return nil, err
+		}
+	}
+
+	// The connection is tested with the password, which is saved after that
+	testCfg := *connCfg
+	testCfg.Passwd = password
+	if err := testCfg.Validate(); err != nil {
+		return nil, err
+	}
+	useKeyring := false
+	if password != "" {
+		store, err := promptChoice(ctx, conn, "Where should the password be saved?", []string{wizardPasswordInConfig, wizardPasswordInKeyring})
+		if err != nil {
+			return nil, err
+		}
+		useKeyring = store == wizardPasswordInKeyring
+	}
+	if dbConn, err := database.Open(&testCfg); err != nil {
+		choice, err := promptChoice(ctx, conn, fmt.Sprintf("cannot connect to %s, %s", connCfg.Alias, err), []string{wizardSaveAnyway, wizardCancel})
+		if err != nil {
+			return nil, err
+		}
+		if choice != wizardSaveAnyway {
+			return nil, errWizardCancelled
+		}
+	} else {
+		dbConn.Close()
+	}
+
+	if useKeyring {
+		connCfg.PasswdStore = database.PasswordStoreKeyring
+		if err := database.StorePassword(connCfg, password); err != nil {
+			return nil, err
+		}
+	} else {
+		connCfg.Passwd = password
+	}
+	if err := config.AddConnection(fp, connCfg); err != nil {
+		return nil, err
+	}
+	return config.GetConfig(fp)
+}
+
+func promptChoice(ctx context.Context, conn *jsonrpc2.Conn, message string, choices []string) (string, error) {
+	params := lsp.ShowMessageRequestParams{
+		Type:    lsp.Info,
+		Message: message,
+	}
+	for _, c := range choices {
+		params.Actions = append(params.Actions, lsp.MessageActionItem{Title: c})
+	}
+	var res *lsp.MessageActionItem
+	if err := conn.Call(ctx, "window/showMessageRequest", params, &res); err != nil {
+		return "", fmt.Errorf("cannot ask for the connection, %w", err)
+	}
+	if res == nil {
+		return "", errWizardCancelled
+	}
+	return res.Title, nil
+}
+
+func promptInput(ctx context.Context, conn *jsonrpc2.Conn, prompt, value string, password bool) (string, error) {
+	params := lsp.InputBoxParams{
+		Prompt:   prompt,
+		Value:    value,
+		Password: password,
+	}
+	var res *string
+	if err := conn.Call(ctx, "sqls/inputBox", params, &res); err != nil {
+		return "", fmt.Errorf("cannot ask for the connection, %w", err)
+	}
+	if res == nil {
+		return "", errWizardCancelled
+	}
+	return *res, nil
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func TestAddConnection(t *testing.T) {
+	origPath := config.YamlConfigPath
+	config.YamlConfigPath = filepath.Join(t.TempDir(), "sqls", "config.yml")
+	defer func() { config.YamlConfigPath = origPath }()
+
+	answers := map[string]string{
+		"Alias of the connection": "local",
+		"Data source name":        filepath.Join(t.TempDir(), "local.db"),
+	}
+	messages := make(chan lsp.ShowMessageParams, 10)
+	tx := newTestContext()
+	tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		switch req.Method {
+		case "window/showMessageRequest":
+			var params lsp.ShowMessageRequestParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			for _, action := range params.Actions {
+				if action.Title == "sqlite3" {
+					return action, nil
+				}
+			}
+			return nil, nil
+		case "sqls/inputBox":
+			var params lsp.InputBoxParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			if answer, ok := answers[params.Prompt]; ok {
+				return answer, nil
+			}
+			return nil, nil
+		case "window/showMessage":
+			var params lsp.ShowMessageParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			messages <- params
+		}
+		return nil, nil
+	})
+	tx.setup(t)
+	defer tx.tearDown()
+
+	execute := func(command string) (string, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command: command,
+		}, &got)
+		res, _ := got.(string)
+		return res, err
+	}
+	if _, err := execute(CommandAddConnection); err != nil {
+		t.Fatal("add connection:", err)
+	}
+	select {
+	case msg := <-messages:
+		if msg.Type != lsp.Info || !strings.HasPrefix(msg.Message, "added connection local") {
+			t.Fatalf("unexpected message, %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection is not added")
+	}
+
+	cfg, err := config.GetConfig(config.YamlConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Connections) != 1 || cfg.Connections[0].Alias != "local" || cfg.Connections[0].DataSourceName != answers["Data source name"] {
+		t.Errorf("unexpected config, %+v", cfg.Connections[0])
+	}
+	got, err := execute(CommandListConnections)
+	if err != nil {
+		t.Fatal("list connections:", err)
+	}
+	if !strings.Contains(got, "1 sqlite3 local") {
+		t.Errorf("added connection is not used, got %q", got)
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+const (
+	bindParamsEnter = "Enter values"
+	bindParamsRun   = "Run as it is"
+)
+
+var errBindParamsCancelled = errors.New("entering the parameters is cancelled")
+
+// promptBindParams asks the user for the values of the placeholders of the
+// query by window/showMessageRequest and sqls/inputBox, and returns them by
+// the names or the positions of the placeholders. It returns nil to run the
+// query as it is, such as when the client does not answer the requests or the
+// user chooses not to bind them.
+func (s *Server) promptBindParams(ctx context.Context, conn *jsonrpc2.Conn, driver dialect.DatabaseDriver, query string) (interface{}, error) {
+	if conn == nil || !s.showMessageRequest {
+		return nil, nil
+	}
+	placeholders, err := database.Placeholders(driver, query)
+	if err != nil || len(placeholders) == 0 {
+		return nil, err
+	}
+
+	labels := make([]string, len(placeholders))
+	for i, p := range placeholders {
+		labels[i] = placeholderLabel(p)
+	}
+	params := lsp.ShowMessageRequestParams{
+		Type:    lsp.Info,
+		Message: fmt.Sprintf("The statement has the parameters %s", strings.Join(labels, ", ")),
+		Actions: []lsp.MessageActionItem{
+			{Title: bindParamsEnter},
+			{Title: bindParamsRun},
+		},
+	}
+	var choice *lsp.MessageActionItem
+	if err := conn.Call(ctx, "window/showMessageRequest", params, &choice); err != nil {
+		return nil, fmt.Errorf("cannot ask for the parameters, %w", err)
+	}
+	if choice == nil {
+		return nil, errBindParamsCancelled
+	}
+	if choice.Title != bindParamsEnter {
+		return nil, nil
+	}
+
+	values := map[string]interface{}{}
+	for i, p := range placeholders {
+		var value *string
+		if err := conn.Call(ctx, "sqls/inputBox", lsp.InputBoxParams{Prompt: "Value of " + labels[i]}, &value); err != nil {
+			return nil, fmt.Errorf("cannot ask for the parameters, %w", err)
+		}
+		if value == nil {
+			return nil, errBindParamsCancelled
+		}
+		if p.Name != "" {
+			values[p.Name] = *value
+		} else {
+			values[strconv.Itoa(p.Index)] = *value
+		}
+	}
+	return values, nil
+}
+
+// placeholderLabel returns the placeholder as written in the query, with the
+// position of the ? placeholders.
+func placeholderLabel(p *database.Placeholder) string {
+	if p.Text == "?" {
+		return fmt.Sprintf("?%d", p.Index)
+	}
+	return p.Text
+}
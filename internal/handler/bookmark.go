@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/bookmark"
+	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/token"
+)
+
+// workspacePath returns the local path of the workspace root.
+func workspacePath(params lsp.InitializeParams) string {
+	if params.RootURI != "" {
+		u, err := url.Parse(params.RootURI)
+		if err == nil && u.Scheme == "file" {
+			return filepath.FromSlash(u.Path)
+		}
+	}
+	return params.RootPath
+}
+
+func newBookmarkStore(rootPath string) *bookmark.Store {
+	if rootPath == "" {
+		return bookmark.NewStore("")
+	}
+	return bookmark.NewStore(filepath.Join(rootPath, ".sqls", "bookmarks.json"))
+}
+
+func (s *Server) saveBookmark(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) < 2 {
+		return nil, fmt.Errorf("required arguments were not provided: <File URI> <Bookmark Name>")
+	}
+	name, ok := params.Arguments[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the bookmark name as a string")
+	}
+	_, text, err := s.commandText(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// The optional position selects the statement under the cursor
+	if params.Range == nil && len(params.Arguments) >= 4 {
+		line, err := intArgument(params.Arguments[2])
+		if err != nil {
+			return nil, fmt.Errorf("specify the line as a number, %w", err)
+		}
+		character, err := intArgument(params.Arguments[3])
+		if err != nil {
+			return nil, fmt.Errorf("specify the character as a number, %w", err)
+		}
+		text, err = focusedStatementText(text, token.Pos{Line: line, Col: character})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query := strings.TrimSpace(text)
+	if query == "" {
+		return nil, errors.New("statement is empty")
+	}
+	if err := s.bookmarks.Save(name, query); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Server) listBookmarks(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	bookmarks, err := s.bookmarks.List()
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	for _, bm := range bookmarks {
+		fmt.Fprintf(buf, "%s %s", bm.Name, queryLabel(bm.Query))
+		fmt.Fprintln(buf)
+	}
+	return buf.String(), nil
+}
+
+func (s *Server) runBookmark(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if s.dbConn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	if len(params.Arguments) == 0 {
+		return nil, fmt.Errorf("required arguments were not provided: <Bookmark Name>")
+	}
+	name, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the bookmark name as a string")
+	}
+	bm, err := s.bookmarks.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := getStatements(bm.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	// The optional document URI runs the query in the transaction of the document
+	var uri string
+	if len(params.Arguments) > 1 {
+		uri, _ = params.Arguments[1].(string)
+	}
+	executor, err := s.executor(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := []string{}
+	for _, stmt := range stmts {
+		query := statementQuery(stmt)
+		if query == "" {
+			continue
+		}
+		queries = append(queries, query)
+	}
+	res, _, err := s.executeStatements(ctx, executor, s.curDBCfg.Driver, queries, false, commandBindParams(params.Arguments[1:]), commandCopyFile(params.Arguments[1:]))
+	return res, err
+}
+
+func (s *Server) deleteBookmark(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) == 0 {
+		return nil, fmt.Errorf("required arguments were not provided: <Bookmark Name>")
+	}
+	name, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the bookmark name as a string")
+	}
+	if err := s.bookmarks.Delete(name); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Server) bookmarkCompletionItems() []lsp.CompletionItem {
+	bookmarks, err := s.bookmarks.List()
+	if err != nil {
+		slog.Warn("cannot list bookmarks", "err", err)
+		return nil
+	}
+	items := []lsp.CompletionItem{}
+	for _, bm := range bookmarks {
+		items = append(items, lsp.CompletionItem{
+			Label:      bm.Name,
+			Kind:       lsp.SnippetCompletion,
+			Detail:     "bookmark",
+			InsertText: bm.Query,
+			Documentation: &lsp.MarkupContent{
+				Kind:  lsp.Markdown,
+				Value: "```sql\n" + bm.Query + "\n```",
+			},
+		})
+	}
+	return items
+}
+
+// atStatementStart reports whether the position is at the start of a
+// statement, before or in its first word.
+func atStatementStart(text string, pos lsp.Position) bool {
+	toks, err := token.NewTokenizer(strings.NewReader(text[:positionOffset(text, pos)]), &dialect.GenericSQLDialect{}).Tokenize()
+	if err != nil {
+		return false
+	}
+	for i := len(toks) - 1; i >= 0; i-- {
+		switch toks[i].Kind {
+		case token.Whitespace, token.Comment, token.MultilineComment:
+			continue
+		case token.Semicolon:
+			return true
+		case token.SQLKeyword:
+			// The first word being typed
+			if i == len(toks)-1 {
+				continue
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func focusedStatementText(text string, pos token.Pos) (string, error) {
+	stmts, err := getStatements(text)
+	if err != nil {
+		return "", err
+	}
+	for _, stmt := range stmts {
+		if token.ComparePos(pos, stmt.Pos()) >= 0 && token.ComparePos(pos, stmt.End()) <= 0 {
+			return statementQuery(stmt), nil
+		}
+	}
+	return "", fmt.Errorf("statement not found, position (%d, %d)", pos.Line, pos.Col)
+}
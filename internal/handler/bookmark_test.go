@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func TestBookmark(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Version:    0,
+			Text:       "CREATE TABLE item (id INTEGER);\nINSERT INTO item VALUES (1), (2);\nSELECT count(*) AS cnt FROM item;",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	execute := func(command string, args ...interface{}) (string, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: args,
+		}, &got)
+		res, _ := got.(string)
+		return res, err
+	}
+	if _, err := execute(CommandExecuteQuery, uri); err != nil {
+		t.Fatal("execute query:", err)
+	}
+
+	// save the statement under the cursor
+	if _, err := execute(CommandSaveBookmark, uri, "count_items", 2, 10); err != nil {
+		t.Fatal("save bookmark:", err)
+	}
+	got, err := execute(CommandListBookmarks)
+	if err != nil {
+		t.Fatal("list bookmarks:", err)
+	}
+	if want := "count_items SELECT count(*) AS cnt FROM item;\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got, err = execute(CommandRunBookmark, "count_items")
+	if err != nil {
+		t.Fatal("run bookmark:", err)
+	}
+	if !strings.Contains(got, "|   2 |") {
+		t.Errorf("unexpected result, got %q", got)
+	}
+	if _, err := execute(CommandRunBookmark, "missing"); err == nil {
+		t.Error("running missing bookmark must fail")
+	}
+
+	// The bookmarks are offered only where a statement starts
+	tests := []struct {
+		name string
+		pos  lsp.Position
+		want bool
+	}{
+		{name: "start of statement", pos: lsp.Position{Line: 2, Character: 0}, want: true},
+		{name: "first word", pos: lsp.Position{Line: 2, Character: 3}, want: true},
+		{name: "after first word", pos: lsp.Position{Line: 2, Character: 7}, want: false},
+		{name: "in statement", pos: lsp.Position{Line: 2, Character: 28}, want: false},
+		{name: "end of statement", pos: lsp.Position{Line: 2, Character: 33}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completionParams := lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+					Position:     tt.pos,
+				},
+			}
+			var items []lsp.CompletionItem
+			if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &items); err != nil {
+				t.Fatal("conn.Call textDocument/completion:", err)
+			}
+			got := false
+			for _, item := range items {
+				if item.Label == "count_items" && item.Detail == "bookmark" {
+					got = true
+				}
+			}
+			if got != tt.want {
+				t.Errorf("bookmark offered %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := execute(CommandDeleteBookmark, "count_items"); err != nil {
+		t.Fatal("delete bookmark:", err)
+	}
+	got, err = execute(CommandListBookmarks)
+	if err != nil {
+		t.Fatal("list bookmarks:", err)
+	}
+	if got != "" {
+		t.Errorf("deleted bookmark is listed, got %q", got)
+	}
+}
+
+func Test_workspacePath(t *testing.T) {
+	tests := []struct {
+		name   string
+		params lsp.InitializeParams
+		want   string
+	}{
+		{
+			name:   "root uri",
+			params: lsp.InitializeParams{RootURI: "file:///home/user/project", RootPath: "/ignored"},
+			want:   filepath.FromSlash("/home/user/project"),
+		},
+		{
+			name:   "root path",
+			params: lsp.InitializeParams{RootPath: "/home/user/project"},
+			want:   "/home/user/project",
+		},
+		{
+			name:   "none",
+			params: lsp.InitializeParams{},
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workspacePath(tt.params); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/parser/parseutil"
+	"github.com/sqls-server/sqls/token"
+)
+
+// loadCatalogs caches the other databases of the server, which the tables of
+// the statement at the position refer to by the names of three parts, to
+// complete and describe their tables as the ones of the database connected.
+func (s *Server) loadCatalogs(ctx context.Context, f *File, text string, pos lsp.Position) {
+	if s.dbConn == nil {
+		return
+	}
+	parsed, err := f.parse(text)
+	if err != nil {
+		return
+	}
+	tables, err := parseutil.ExtractTable(parsed, token.Pos{Line: pos.Line, Col: pos.Character})
+	if err != nil {
+		return
+	}
+	for _, table := range tables {
+		if table.Catalog == "" {
+			continue
+		}
+		if err := s.worker.LoadCatalog(ctx, table.Catalog); err != nil {
+			logging.FromContext(ctx).Warn("cannot cache database", "database", table.Catalog, "err", err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/sourcegraph/jsonrpc2"
 	"github.com/sqls-server/sqls/internal/completer"
@@ -20,20 +21,90 @@ func (s *Server) handleTextDocumentCompletion(ctx context.Context, conn *jsonrpc
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	c := completer.NewCompleter(s.worker.Cache())
+	if err := s.switchFileConnection(ctx, conn, params.TextDocument.URI); err != nil {
+		return nil, err
+	}
+	s.prepareDB(ctx, conn)
+	s.loadCatalogs(ctx, f, s.templateText(f, true).Text, params.Position)
+	items, err := s.completionItems(ctx, f, params)
+	if err != nil {
+		return nil, err
+	}
+	// The items of the text changed while they are found are not offered
+	if s.files.modified(params.TextDocument.URI, f) {
+		return nil, errContentModified(params.TextDocument.URI)
+	}
+	// The documentation of the tables is of the tables of the document
+	for _, item := range items {
+		if data, ok := item.Data.(*completer.TableDocData); ok {
+			data.URI = params.TextDocument.URI
+		}
+	}
+	return items, nil
+}
+
+// handleCompletionItemResolve sets the documentation of the item selected,
+// which is left out of the items of the tables not to send all of them.
+func (s *Server) handleCompletionItemResolve(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var item lsp.CompletionItem
+	if err := json.Unmarshal(*req.Params, &item); err != nil {
+		return nil, err
+	}
+
+	data, ok := completer.ParseTableDocData(item.Data)
+	if !ok {
+		return item, nil
+	}
+	c := completer.NewCompleter(s.dbCache(data.URI))
+	return c.Resolve(item), nil
+}
+
+// completionItems returns the items to complete at the position of the
+// document.
+func (s *Server) completionItems(ctx context.Context, f *File, params lsp.CompletionParams) ([]lsp.CompletionItem, error) {
+	// Offer recently executed queries in an empty buffer
+	if strings.TrimSpace(f.Text) == "" {
+		return append(s.recentQueryCompletionItems(), s.bookmarkCompletionItems()...), nil
+	}
+
+	c := completer.NewCompleter(s.dbCache(params.TextDocument.URI))
 	if s.dbConn != nil {
 		c.Driver = s.dbConn.Driver
 	} else {
 		c.Driver = ""
 	}
-	completionItems, err := c.Complete(f.Text, params, s.getConfig().LowercaseKeywords)
+	cfg := s.getConfig()
+	c.KeywordCase = cfg.KeywordCase
+	c.IdentifierCase = cfg.IdentifierCase
+	c.PartitionCompletion = cfg.PartitionCompletion
+	c.Document = f.document("")
+	tmpl := s.templateText(f, true)
+	// The statement of another connection is completed with its tables
+	if cache, driver, ok := s.statementCache(ctx, f, tmpl.Text, params.Position); ok {
+		c.DBCache, c.Driver = cache, driver
+	}
+	if items, ok := s.dbtCompletionItems(f.Text, params.Position); ok {
+		return items, nil
+	}
+	if tmpl.Contains(params.Position) {
+		return nil, nil
+	}
+	completionItems, err := c.Complete(tmpl.Text, params, cfg.LowercaseKeywords)
 	if err != nil {
 		return nil, err
 	}
+	// Offer the bookmarks where a statement starts, as the recent queries
+	if atStatementStart(f.Text, params.Position) {
+		completionItems = append(completionItems, s.bookmarkCompletionItems()...)
+	}
 	return completionItems, nil
 }
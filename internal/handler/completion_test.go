@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/sqls-server/sqls/internal/config"
 	"github.com/sqls-server/sqls/internal/database"
 	"github.com/sqls-server/sqls/internal/lsp"
@@ -55,6 +60,198 @@ var statementCase = []completionTestCase{
 	},
 }
 
+var catalogCase = []completionTestCase{
+	{
+		name:  "columns of table of another database",
+		input: "SELECT o. FROM archive.dbo.orders o",
+		line:  0,
+		col:   9,
+		want: []string{
+			"OrderID",
+			"OrderedAt",
+		},
+		bad: []string{
+			"ID",
+			"CountryCode",
+		},
+	},
+	{
+		name:  "tables of schema of another database",
+		input: "SELECT * FROM archive.dbo.",
+		line:  0,
+		col:   26,
+		want: []string{
+			"orders",
+		},
+		bad: []string{
+			"city",
+			"country",
+		},
+	},
+}
+
+var databaseCase = []completionTestCase{
+	{
+		name:  "use",
+		input: "USE ",
+		line:  0,
+		col:   4,
+		want: []string{
+			"world",
+			"information_schema",
+		},
+		bad: []string{
+			"city",
+			"SELECT",
+		},
+	},
+	{
+		name:  "use filtered",
+		input: "USE w",
+		line:  0,
+		col:   5,
+		want: []string{
+			"world",
+		},
+		bad: []string{
+			"sys",
+		},
+	},
+	{
+		name:  "create database",
+		input: "CREATE DATABASE ",
+		line:  0,
+		col:   16,
+		want: []string{
+			"IF NOT EXISTS",
+			"world",
+		},
+	},
+	{
+		name:  "create database if not exists",
+		input: "CREATE DATABASE IF NOT EXISTS ",
+		line:  0,
+		col:   30,
+		want: []string{
+			"world",
+		},
+		bad: []string{
+			"IF NOT EXISTS",
+		},
+	},
+	{
+		name:  "drop database if",
+		input: "DROP DATABASE IF ",
+		line:  0,
+		col:   17,
+		want: []string{
+			"EXISTS",
+		},
+		bad: []string{
+			"world",
+		},
+	},
+}
+
+var clauseKeywordCase = []completionTestCase{
+	{
+		name:  "statement start",
+		input: "SELECT 1;\n",
+		line:  1,
+		col:   0,
+		want: []string{
+			"SELECT",
+			"INSERT",
+			"CREATE",
+		},
+		bad: []string{
+			"WHERE",
+			"JOIN",
+		},
+	},
+	{
+		name:  "after from",
+		input: "SELECT * FROM city ",
+		line:  0,
+		col:   19,
+		want: []string{
+			"JOIN",
+			"LEFT",
+			"WHERE",
+			"GROUP",
+			"ORDER",
+		},
+		bad: []string{
+			"INSERT",
+			"DELETE",
+			"CREATE",
+		},
+	},
+	{
+		name:  "after where",
+		input: "SELECT * FROM city WHERE ID = 1 ",
+		line:  0,
+		col:   32,
+		want: []string{
+			"AND",
+			"OR",
+			"GROUP",
+			"ORDER",
+		},
+		bad: []string{
+			"JOIN",
+			"INSERT",
+			"CREATE",
+		},
+	},
+	{
+		name:  "after group by",
+		input: "SELECT CountryCode FROM city GROUP BY CountryCode ",
+		line:  0,
+		col:   50,
+		want: []string{
+			"HAVING",
+			"ORDER",
+			"LIMIT",
+		},
+		bad: []string{
+			"INSERT",
+			"DELETE",
+			"WHERE",
+		},
+	},
+	{
+		name:  "after order by",
+		input: "SELECT * FROM city ORDER BY ID ",
+		line:  0,
+		col:   31,
+		want: []string{
+			"ASC",
+			"DESC",
+			"LIMIT",
+		},
+		bad: []string{
+			"WHERE",
+			"JOIN",
+			"AND",
+		},
+	},
+	{
+		name:  "after update set",
+		input: "UPDATE city SET Name = 'a' ",
+		line:  0,
+		col:   27,
+		want: []string{
+			"WHERE",
+		},
+		bad: []string{
+			"JOIN",
+			"GROUP",
+			"INSERT",
+		},
+	},
+}
+
 var selectExprCase = []completionTestCase{
 	{
 		name:  "table columns",
@@ -848,150 +1045,902 @@ var subQueryCase = []completionTestCase{
 		},
 	},
 }
-var joinClauseCase = []completionTestCase{
+
+var procedureCase = []completionTestCase{
 	{
-		name:  "join tables",
-		input: "select CountryCode from city join ",
-		line:  0,
-		col:   34,
+		name:  "columns in dollar quoted function body",
+		input: "CREATE FUNCTION f() RETURNS int AS $$\nDECLARE\n  n int;\nBEGIN\n  SELECT ci. FROM city AS ci;\n  IF n > 0 THEN\n    RETURN n;\n  END IF;\nEND;\n$$ LANGUAGE plpgsql;",
+		line:  4,
+		col:   12,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+	},
+	{
+		name:  "tables in dollar quoted function body",
+		input: "CREATE FUNCTION f() RETURNS void AS $body$\nBEGIN\n  DELETE FROM \nEND;\n$body$ LANGUAGE plpgsql;",
+		line:  2,
+		col:   14,
 		want: []string{
-			"country c1 ON c1.Code = city.CountryCode",
 			"city",
 			"country",
 			"countrylanguage",
 		},
 	},
 	{
-		name:  "join tables with reference",
-		input: "select c.CountryCode from city c join ",
+		name:  "columns in procedure block",
+		input: "CREATE PROCEDURE p AS\nBEGIN\n  DECLARE @n int;\n  SELECT ci. FROM city AS ci;\n  IF @n !< 0\n    PRINT 'x';\nEND",
+		line:  3,
+		col:   12,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+	},
+}
+
+var postgresOperatorCase = []completionTestCase{
+	{
+		name:  "columns after json operators",
+		input: "SELECT c.Name->>'a', c.Name #> '{a,b}', c. FROM city AS c",
 		line:  0,
-		col:   38,
+		col:   42,
 		want: []string{
-			"country c1 ON c1.Code = c.CountryCode",
-			"city",
-			"country",
-			"countrylanguage",
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
 		},
 	},
 	{
-		name:  "join filtered tables",
-		input: "select CountryCode from city join co",
+		name:  "columns after casts and array subscripts",
+		input: "SELECT c.ID::text, c.Name[1], $$a;b$$, c. FROM city AS c",
 		line:  0,
-		col:   36,
+		col:   41,
 		want: []string{
-			"country c1 ON c1.Code = city.CountryCode",
-			"country",
-			"countrylanguage",
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
 		},
 	},
 	{
-		name:  "join filtered tables with reference",
-		input: "select c.CountryCode from city c join co",
+		name:  "columns in where after json containment",
+		input: "SELECT * FROM city AS c WHERE c.Name @> '{}' AND c.",
 		line:  0,
-		col:   40,
+		col:   51,
 		want: []string{
-			"country c1 ON c1.Code = c.CountryCode",
-			"country",
-			"countrylanguage",
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
 		},
 	},
+}
+
+var upsertCase = []completionTestCase{
 	{
-		name:  "left join tables",
-		input: "select CountryCode from city left join ",
+		name:  "merge target tables",
+		input: "MERGE INTO ",
 		line:  0,
-		col:   39,
+		col:   11,
 		want: []string{
-			"country c1 ON c1.Code = city.CountryCode",
 			"city",
 			"country",
 			"countrylanguage",
 		},
 	},
 	{
-		name:  "left join tables with reference",
-		input: "select c.CountryCode from city c left join ",
+		name:  "merge source tables",
+		input: "MERGE INTO city AS t USING ",
 		line:  0,
-		col:   43,
+		col:   27,
 		want: []string{
-			"country c1 ON c1.Code = c.CountryCode",
-			"city",
 			"country",
 			"countrylanguage",
 		},
 	},
 	{
-		name:  "left outer join tables",
-		input: "select CountryCode from city left outer join ",
+		name:  "merge condition columns",
+		input: "MERGE INTO city AS t USING country AS s ON t.",
 		line:  0,
 		col:   45,
 		want: []string{
-			"country c1 ON c1.Code = city.CountryCode",
-			"city",
-			"country",
-			"countrylanguage",
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
 		},
 	},
 	{
-		name:  "left outer join tables with reference",
-		input: "select c.CountryCode from city c left outer join ",
+		name:  "merge update set columns",
+		input: "MERGE INTO city AS t USING country AS s ON t.CountryCode = s.Code WHEN MATCHED THEN UPDATE SET ",
 		line:  0,
-		col:   49,
+		col:   95,
 		want: []string{
-			"country c1 ON c1.Code = c.CountryCode",
-			"city",
-			"country",
-			"countrylanguage",
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
 		},
 	},
-}
-
-var joinConditionCase = []completionTestCase{
 	{
-		name:  "join on columns",
-		input: "select * from city left join country on ",
+		name:  "merge update source columns",
+		input: "MERGE INTO city AS t USING country AS s ON t.CountryCode = s.Code WHEN MATCHED THEN UPDATE SET Name = s.",
 		line:  0,
-		col:   40,
+		col:   104,
 		want: []string{
-			"country.Code = city.CountryCode",
 			"Code",
-			"Name",
-			"CountryCode",
-			"Continent",
 			"Region",
 			"SurfaceArea",
-			"IndepYear",
-			"LifeExpectancy",
-			"GNP",
-			"GNPOld",
-			"LocalName",
-			"GovernmentForm",
-			"HeadOfState",
-			"Capital",
-			"Code2",
 		},
 	},
 	{
-		name:  "join on columns with reference",
-		input: "select * from city c left join country co on ",
+		name:  "merge insert columns",
+		input: "MERGE INTO city AS t USING country AS s ON t.CountryCode = s.Code WHEN NOT MATCHED THEN INSERT (",
 		line:  0,
-		col:   45,
+		col:   96,
 		want: []string{
-			"co.Code = c.CountryCode",
-			"Code",
+			"ID",
 			"Name",
 			"CountryCode",
-			"Continent",
+			"District",
+			"Population",
+		},
+	},
+	{
+		name:  "merge insert values",
+		input: "MERGE INTO city AS t USING country AS s ON t.CountryCode = s.Code WHEN NOT MATCHED THEN INSERT (Name) VALUES (s.",
+		line:  0,
+		col:   112,
+		want: []string{
+			"Code",
 			"Region",
 			"SurfaceArea",
-			"IndepYear",
-			"LifeExpectancy",
-			"GNP",
-			"GNPOld",
-			"LocalName",
-			"GovernmentForm",
-			"HeadOfState",
-			"Capital",
-			"Code2",
+		},
+	},
+	{
+		name:  "on conflict columns",
+		input: "INSERT INTO city (ID, Name) VALUES (1, 'a') ON CONFLICT (",
+		line:  0,
+		col:   57,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+	},
+	{
+		name:  "on conflict update set columns",
+		input: "INSERT INTO city (ID, Name) VALUES (1, 'a') ON CONFLICT (ID) DO UPDATE SET ",
+		line:  0,
+		col:   75,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+	},
+	{
+		name:  "on conflict excluded columns",
+		input: "INSERT INTO city (ID, Name) VALUES (1, 'a') ON CONFLICT (ID) DO UPDATE SET Name = excluded.",
+		line:  0,
+		col:   91,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+	},
+	{
+		name:  "on duplicate key update columns",
+		input: "INSERT INTO city (ID, Name) VALUES (1, 'a') ON DUPLICATE KEY UPDATE ",
+		line:  0,
+		col:   68,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+	},
+	{
+		name:  "on duplicate key values function columns",
+		input: "INSERT INTO city (ID, Name) VALUES (1, 'a') ON DUPLICATE KEY UPDATE Name = VALUES(",
+		line:  0,
+		col:   82,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+	},
+}
+
+var tableFunctionCase = []completionTestCase{
+	{
+		name:  "lateral join subquery columns",
+		input: "SELECT l. FROM city c JOIN LATERAL (SELECT Code AS code2, Region FROM country WHERE Code = c.CountryCode) l ON true",
+		line:  0,
+		col:   9,
+		want: []string{
+			"code2",
+			"Region",
+		},
+	},
+	{
+		name:  "lateral subquery columns",
+		input: "SELECT l. FROM city c, LATERAL (SELECT Code AS code2 FROM country) AS l",
+		line:  0,
+		col:   9,
+		want: []string{
+			"code2",
+		},
+	},
+	{
+		name:  "outer columns in lateral subquery",
+		input: "SELECT * FROM city c, LATERAL (SELECT Code FROM country WHERE Code = c. ) AS l",
+		line:  0,
+		col:   71,
+		want: []string{
+			"ID",
+			"Name",
+			"CountryCode",
+			"District",
+			"Population",
+		},
+	},
+	{
+		name:  "table function column definitions",
+		input: "SELECT r. FROM jsonb_to_recordset('[]') AS r(a int, b text)",
+		line:  0,
+		col:   9,
+		want: []string{
+			"a",
+			"b",
+		},
+	},
+	{
+		name:  "unnest column alias",
+		input: "SELECT u. FROM city c, unnest(ARRAY[1, 2]) u(val)",
+		line:  0,
+		col:   9,
+		want: []string{
+			"val",
+		},
+	},
+	{
+		name:  "set returning function columns",
+		input: "SELECT  FROM generate_series(1, 10) AS g(n)",
+		line:  0,
+		col:   7,
+		want: []string{
+			"n",
+		},
+	},
+	{
+		name:  "lateral table function columns",
+		input: "SELECT  FROM city c CROSS JOIN LATERAL unnest(ARRAY[1, 2]) AS u(val)",
+		line:  0,
+		col:   7,
+		want: []string{
+			"val",
+			"Name",
+		},
+	},
+}
+
+var setOperationCase = []completionTestCase{
+	{
+		name:  "later branch columns",
+		input: "SELECT ID, Name FROM city UNION SELECT  FROM country",
+		line:  0,
+		col:   39,
+		want: []string{
+			"Code",
+			"Region",
+		},
+		bad: []string{
+			"District",
+			"Population",
+		},
+	},
+	{
+		name:  "later branch where",
+		input: "SELECT ID FROM city UNION ALL SELECT Code FROM country WHERE  ",
+		line:  0,
+		col:   62,
+		want: []string{
+			"Code",
+			"Region",
+		},
+		bad: []string{
+			"District",
+		},
+	},
+	{
+		name:  "order by output names",
+		input: "SELECT ID AS city_id, Name FROM city UNION SELECT Code, Name FROM country ORDER BY ",
+		line:  0,
+		col:   83,
+		want: []string{
+			"city_id",
+			"Name",
+		},
+		bad: []string{
+			"Region",
+			"District",
+		},
+	},
+	{
+		name:  "order by output names with prefix",
+		input: "SELECT ID, Name FROM city EXCEPT SELECT Code, Name FROM country ORDER BY Na",
+		line:  0,
+		col:   75,
+		want: []string{
+			"Name",
+		},
+		bad: []string{
+			"city_id",
+		},
+	},
+	{
+		name:  "order by all columns",
+		input: "SELECT c.* FROM city c INTERSECT SELECT * FROM city ORDER BY ",
+		line:  0,
+		col:   61,
+		want: []string{
+			"ID",
+			"District",
+		},
+	},
+}
+
+var windowCase = []completionTestCase{
+	{
+		name:  "partition by columns",
+		input: "SELECT ROW_NUMBER() OVER (PARTITION BY  ) FROM city",
+		line:  0,
+		col:   39,
+		want: []string{
+			"CountryCode",
+			"District",
+		},
+	},
+	{
+		name:  "window order by columns",
+		input: "SELECT ROW_NUMBER() OVER (PARTITION BY CountryCode ORDER BY  ) FROM city",
+		line:  0,
+		col:   60,
+		want: []string{
+			"Population",
+		},
+	},
+	{
+		name:  "frame unit",
+		input: "SELECT SUM(Population) OVER (ORDER BY ID ROWS  ) FROM city",
+		line:  0,
+		col:   46,
+		want: []string{
+			"BETWEEN",
+			"UNBOUNDED PRECEDING",
+			"CURRENT ROW",
+		},
+		bad: []string{
+			"Population",
+			"UNBOUNDED FOLLOWING",
+		},
+	},
+	{
+		name:  "frame start",
+		input: "SELECT SUM(Population) OVER (ORDER BY ID ROWS BETWEEN  ) FROM city",
+		line:  0,
+		col:   54,
+		want: []string{
+			"UNBOUNDED PRECEDING",
+			"CURRENT ROW",
+		},
+		bad: []string{
+			"BETWEEN",
+			"Population",
+		},
+	},
+	{
+		name:  "frame end",
+		input: "SELECT SUM(Population) OVER (ORDER BY ID RANGE BETWEEN 1 PRECEDING AND  ) FROM city",
+		line:  0,
+		col:   71,
+		want: []string{
+			"UNBOUNDED FOLLOWING",
+			"CURRENT ROW",
+		},
+		bad: []string{
+			"UNBOUNDED PRECEDING",
+			"Population",
+		},
+	},
+	{
+		name:  "qualify condition",
+		input: "SELECT ID, ROW_NUMBER() OVER (PARTITION BY CountryCode ORDER BY Population DESC) AS rn FROM city QUALIFY  ",
+		line:  0,
+		col:   105,
+		want: []string{
+			"ID",
+			"District",
+		},
+	},
+}
+
+var ddlCase = []completionTestCase{
+	{
+		name:  "data type",
+		input: "CREATE TABLE city2 (ID INT, Name VAR",
+		line:  0,
+		col:   36,
+		want: []string{
+			"VARCHAR",
+			"VARBINARY",
+		},
+		bad: []string{
+			"Name",
+			"city",
+		},
+	},
+	{
+		name:  "column constraint",
+		input: "CREATE TABLE city2 (ID INT ",
+		line:  0,
+		col:   27,
+		want: []string{
+			"NOT NULL",
+			"PRIMARY KEY",
+			"REFERENCES",
+		},
+		bad: []string{
+			"ID",
+			"INTEGER",
+		},
+	},
+	{
+		name:  "references table",
+		input: "CREATE TABLE city2 (CountryCode CHAR(3) REFERENCES ",
+		line:  0,
+		col:   51,
+		want: []string{
+			"country",
+			"countrylanguage",
+		},
+		bad: []string{
+			"ID",
+		},
+	},
+	{
+		name:  "references primary key",
+		input: "CREATE TABLE city2 (CountryCode CHAR(3) REFERENCES country(",
+		line:  0,
+		col:   59,
+		want: []string{
+			"Code",
+		},
+		bad: []string{
+			"Name",
+			"Region",
+		},
+	},
+	{
+		name:  "referential action",
+		input: "CREATE TABLE city2 (CountryCode CHAR(3) REFERENCES country(Code) ON DELETE ",
+		line:  0,
+		col:   75,
+		want: []string{
+			"CASCADE",
+			"SET NULL",
+			"NO ACTION",
+		},
+		bad: []string{
+			"Code",
+			"country",
+		},
+	},
+	{
+		name:  "defined columns",
+		input: "CREATE TABLE city2 (ID INT, Name TEXT, PRIMARY KEY (",
+		line:  0,
+		col:   52,
+		want: []string{
+			"ID",
+			"Name",
+		},
+		bad: []string{
+			"Population",
+		},
+	},
+	{
+		name:  "alter table",
+		input: "ALTER TABLE ",
+		line:  0,
+		col:   12,
+		want: []string{
+			"city",
+			"country",
+		},
+		bad: []string{
+			"ID",
+		},
+	},
+	{
+		name:  "drop column",
+		input: "ALTER TABLE city DROP COLUMN ",
+		line:  0,
+		col:   29,
+		want: []string{
+			"District",
+			"Population",
+		},
+		bad: []string{
+			"Region",
+		},
+	},
+}
+
+var grantCase = []completionTestCase{
+	{
+		name:  "privileges and roles",
+		input: "GRANT ",
+		line:  0,
+		col:   6,
+		want: []string{
+			"SELECT",
+			"ALL PRIVILEGES",
+			"admin",
+			"reporting",
+		},
+		bad: []string{
+			"city",
+			"FROM",
+		},
+	},
+	{
+		name:  "object",
+		input: "GRANT SELECT ON ",
+		line:  0,
+		col:   16,
+		want: []string{
+			"TABLE",
+			"ALL TABLES IN SCHEMA",
+			"city",
+			"world",
+		},
+		bad: []string{
+			"admin",
+			"ID",
+		},
+	},
+	{
+		name:  "schema",
+		input: "GRANT USAGE ON SCHEMA w",
+		line:  0,
+		col:   23,
+		want: []string{
+			"world",
+		},
+		bad: []string{
+			"city",
+		},
+	},
+	{
+		name:  "grantee",
+		input: "GRANT SELECT ON city TO ",
+		line:  0,
+		col:   24,
+		want: []string{
+			"PUBLIC",
+			"admin",
+			"reporting",
+		},
+		bad: []string{
+			"city",
+		},
+	},
+	{
+		name:  "revoke option",
+		input: "REVOKE SELECT ON city FROM reporting ",
+		line:  0,
+		col:   37,
+		want: []string{
+			"CASCADE",
+			"RESTRICT",
+		},
+		bad: []string{
+			"admin",
+		},
+	},
+	{
+		name:  "drop role",
+		input: "DROP ROLE ",
+		line:  0,
+		col:   10,
+		want: []string{
+			"admin",
+			"reporting",
+		},
+		bad: []string{
+			"city",
+		},
+	},
+}
+
+var copyCase = []completionTestCase{
+	{
+		name:  "copy table",
+		input: "COPY ",
+		line:  0,
+		col:   5,
+		want: []string{
+			"city",
+			"country",
+		},
+		bad: []string{
+			"ID",
+		},
+	},
+	{
+		name:  "copy columns",
+		input: "COPY city (ID, ",
+		line:  0,
+		col:   15,
+		want: []string{
+			"Name",
+			"CountryCode",
+		},
+		bad: []string{
+			"city",
+			"Code",
+		},
+	},
+	{
+		name:  "copy source",
+		input: "COPY city FROM ",
+		line:  0,
+		col:   15,
+		want: []string{
+			"STDIN",
+			"PROGRAM",
+		},
+		bad: []string{
+			"STDOUT",
+			"city",
+		},
+	},
+	{
+		name:  "copy file",
+		input: "\\copy city from 'testdata/format_option",
+		line:  0,
+		col:   39,
+		want: []string{
+			"format_option_space2/",
+			"format_option_space4/",
+		},
+		bad: []string{
+			"format/",
+			"upper_case/",
+		},
+	},
+	{
+		name:  "copy options",
+		input: "COPY city FROM STDIN WITH (",
+		line:  0,
+		col:   27,
+		want: []string{
+			"FORMAT",
+			"HEADER",
+			"DELIMITER",
+		},
+		bad: []string{
+			"ID",
+		},
+	},
+	{
+		name:  "load data file",
+		input: "LOAD DATA LOCAL INFILE 'testdata/",
+		line:  0,
+		col:   33,
+		want: []string{
+			"format/",
+			"upper_case/",
+		},
+	},
+	{
+		name:  "load data clauses",
+		input: "LOAD DATA LOCAL INFILE 'city.csv' INTO TABLE city FIELDS ",
+		line:  0,
+		col:   57,
+		want: []string{
+			"TERMINATED BY",
+			"OPTIONALLY ENCLOSED BY",
+		},
+		bad: []string{
+			"ID",
+		},
+	},
+}
+
+var joinClauseCase = []completionTestCase{
+	{
+		name:  "join tables",
+		input: "select CountryCode from city join ",
+		line:  0,
+		col:   34,
+		want: []string{
+			"country c1 ON c1.Code = city.CountryCode",
+			"city",
+			"country",
+			"countrylanguage",
+		},
+	},
+	{
+		name:  "join tables with reference",
+		input: "select c.CountryCode from city c join ",
+		line:  0,
+		col:   38,
+		want: []string{
+			"country c1 ON c1.Code = c.CountryCode",
+			"city",
+			"country",
+			"countrylanguage",
+		},
+	},
+	{
+		name:  "join filtered tables",
+		input: "select CountryCode from city join co",
+		line:  0,
+		col:   36,
+		want: []string{
+			"country c1 ON c1.Code = city.CountryCode",
+			"country",
+			"countrylanguage",
+		},
+	},
+	{
+		name:  "join filtered tables with reference",
+		input: "select c.CountryCode from city c join co",
+		line:  0,
+		col:   40,
+		want: []string{
+			"country c1 ON c1.Code = c.CountryCode",
+			"country",
+			"countrylanguage",
+		},
+	},
+	{
+		name:  "left join tables",
+		input: "select CountryCode from city left join ",
+		line:  0,
+		col:   39,
+		want: []string{
+			"country c1 ON c1.Code = city.CountryCode",
+			"city",
+			"country",
+			"countrylanguage",
+		},
+	},
+	{
+		name:  "left join tables with reference",
+		input: "select c.CountryCode from city c left join ",
+		line:  0,
+		col:   43,
+		want: []string{
+			"country c1 ON c1.Code = c.CountryCode",
+			"city",
+			"country",
+			"countrylanguage",
+		},
+	},
+	{
+		name:  "left outer join tables",
+		input: "select CountryCode from city left outer join ",
+		line:  0,
+		col:   45,
+		want: []string{
+			"country c1 ON c1.Code = city.CountryCode",
+			"city",
+			"country",
+			"countrylanguage",
+		},
+	},
+	{
+		name:  "left outer join tables with reference",
+		input: "select c.CountryCode from city c left outer join ",
+		line:  0,
+		col:   49,
+		want: []string{
+			"country c1 ON c1.Code = c.CountryCode",
+			"city",
+			"country",
+			"countrylanguage",
+		},
+	},
+}
+
+var joinConditionCase = []completionTestCase{
+	{
+		name:  "join on columns of the tables in another case",
+		input: "select * from City left join Country on ",
+		line:  0,
+		col:   40,
+		want: []string{
+			"country.Code = city.CountryCode",
+			"Code",
+			"Continent",
+		},
+	},
+	{
+		name:  "join on columns",
+		input: "select * from city left join country on ",
+		line:  0,
+		col:   40,
+		want: []string{
+			"country.Code = city.CountryCode",
+			"Code",
+			"Name",
+			"CountryCode",
+			"Continent",
+			"Region",
+			"SurfaceArea",
+			"IndepYear",
+			"LifeExpectancy",
+			"GNP",
+			"GNPOld",
+			"LocalName",
+			"GovernmentForm",
+			"HeadOfState",
+			"Capital",
+			"Code2",
+		},
+	},
+	{
+		name:  "join on columns with reference",
+		input: "select * from city c left join country co on ",
+		line:  0,
+		col:   45,
+		want: []string{
+			"co.Code = c.CountryCode",
+			"Code",
+			"Name",
+			"CountryCode",
+			"Continent",
+			"Region",
+			"SurfaceArea",
+			"IndepYear",
+			"LifeExpectancy",
+			"GNP",
+			"GNPOld",
+			"LocalName",
+			"GovernmentForm",
+			"HeadOfState",
+			"Capital",
+			"Code2",
 		},
 	},
 }
@@ -1095,6 +2044,18 @@ func TestCompleteMain(t *testing.T) {
 		"col name":        colNameCase,
 		"case value":      caseValueCase,
 		"subquery":        subQueryCase,
+		"procedure":       procedureCase,
+		"postgres":        postgresOperatorCase,
+		"upsert":          upsertCase,
+		"table function":  tableFunctionCase,
+		"set operation":   setOperationCase,
+		"window":          windowCase,
+		"ddl":             ddlCase,
+		"grant":           grantCase,
+		"copy":            copyCase,
+		"clause keyword":  clauseKeywordCase,
+		"catalog":         catalogCase,
+		"database":        databaseCase,
 	}
 
 	for k, v := range testcaseMap {
@@ -1178,6 +2139,146 @@ func TestCompleteJoin(t *testing.T) {
 	}
 }
 
+func TestCompleteTemplate(t *testing.T) {
+	tx := newTestContext()
+	tx.initServer(t)
+	defer tx.tearDown()
+
+	cfg := &config.Config{
+		Template: &config.Template{Engine: config.TemplateEngineJinja, ResolveRefs: true},
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+
+	cases := []completionTestCase{
+		{
+			name:  "columns of ref",
+			input: "{{ config(materialized='view') }}\nSELECT ci. FROM {{ ref('city') }} AS ci\n{% if is_incremental() %}WHERE ci.ID > 1{% endif %}",
+			line:  1,
+			col:   10,
+			want: []string{
+				"ID",
+				"Name",
+				"CountryCode",
+			},
+		},
+		{
+			name:  "after expression",
+			input: "SELECT {{ col }}, ci. FROM city AS ci",
+			line:  0,
+			col:   21,
+			want: []string{
+				"ID",
+				"Name",
+			},
+		},
+		{
+			name:  "in expression",
+			input: "SELECT * FROM {{ ref('ci') }}",
+			line:  0,
+			col:   24,
+			bad: []string{
+				"city",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tx.textDocumentDidOpen(t, testFileURI, tt.input)
+
+			completionParams := lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{
+						URI: testFileURI,
+					},
+					Position: lsp.Position{
+						Line:      tt.line,
+						Character: tt.col,
+					},
+				},
+			}
+
+			var got []lsp.CompletionItem
+			if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &got); err != nil {
+				t.Fatal("conn.Call textDocument/completion:", err)
+			}
+			testCompletionItem(t, tt.want, tt.bad, got)
+		})
+	}
+}
+
+func TestCompleteEmbedded(t *testing.T) {
+	tx := newTestContext()
+	tx.initServer(t)
+	defer tx.tearDown()
+
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+
+	uri := "file:///main.go"
+	input := "package main\n\nfunc main() {\n\trows, err := db.Query(\"SELECT ci. FROM city AS ci\")\n\tfmt.Println(\"a.\")\n}\n"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "go",
+			Version:    0,
+			Text:       input,
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+
+	cases := []completionTestCase{
+		{
+			name: "in the query",
+			line: 3,
+			col:  34,
+			want: []string{
+				"ID",
+				"Name",
+				"CountryCode",
+			},
+		},
+		{
+			name: "out of the query",
+			line: 4,
+			col:  16,
+			bad: []string{
+				"ID",
+				"city",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			completionParams := lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{
+						URI: uri,
+					},
+					Position: lsp.Position{
+						Line:      tt.line,
+						Character: tt.col,
+					},
+				},
+			}
+
+			var got []lsp.CompletionItem
+			if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &got); err != nil {
+				t.Fatal("conn.Call textDocument/completion:", err)
+			}
+			testCompletionItem(t, tt.want, tt.bad, got)
+		})
+	}
+}
+
 func TestCompleteNoneDBConnection(t *testing.T) {
 	tx := newTestContext()
 	tx.initServer(t)
@@ -1195,6 +2296,17 @@ func TestCompleteNoneDBConnection(t *testing.T) {
 		"col name":        colNameCase,
 		"case value":      caseValueCase,
 		"subquery":        subQueryCase,
+		"procedure":       procedureCase,
+		"postgres":        postgresOperatorCase,
+		"upsert":          upsertCase,
+		"table function":  tableFunctionCase,
+		"set operation":   setOperationCase,
+		"window":          windowCase,
+		"ddl":             ddlCase,
+		"grant":           grantCase,
+		"copy":            copyCase,
+		"clause keyword":  clauseKeywordCase,
+		"catalog":         catalogCase,
 	}
 
 	for k, v := range testcaseMap {
@@ -1250,3 +2362,389 @@ func testCompletionItem(t *testing.T, expectLabels []string, badLabels []string,
 		}
 	}
 }
+
+func TestCompleteDbt(t *testing.T) {
+	tx := newTestContext()
+	tx.initServer(t)
+	defer tx.tearDown()
+
+	rootPath, err := filepath.Abs("../dbt/testdata/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.server.rootPath = rootPath
+	if err := tx.server.loadDbtProject(); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+
+	cases := []completionTestCase{
+		{
+			name:  "ref",
+			input: "SELECT * FROM {{ ref('",
+			line:  0,
+			col:   22,
+			want: []string{
+				"cities",
+				"country_codes",
+				"stg_city",
+			},
+			bad: []string{
+				"world",
+				"city",
+			},
+		},
+		{
+			name:  "source",
+			input: "SELECT * FROM {{ source(\"",
+			line:  0,
+			col:   25,
+			want: []string{
+				"world",
+			},
+			bad: []string{
+				"stg_city",
+			},
+		},
+		{
+			name:  "table of source",
+			input: "SELECT * FROM {{ source('world', 'ci') }}",
+			line:  0,
+			col:   36,
+			want: []string{
+				"city",
+				"country",
+			},
+		},
+		{
+			name:  "columns of model",
+			input: "SELECT c. FROM {{ ref('stg_city') }} AS c",
+			line:  0,
+			col:   9,
+			want: []string{
+				"ID",
+				"Name",
+			},
+		},
+		{
+			name:  "models as tables",
+			input: "SELECT * FROM ",
+			line:  0,
+			col:   14,
+			want: []string{
+				"city",
+				"stg_city",
+				"country_codes",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tx.textDocumentDidOpen(t, testFileURI, tt.input)
+
+			completionParams := lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{
+						URI: testFileURI,
+					},
+					Position: lsp.Position{
+						Line:      tt.line,
+						Character: tt.col,
+					},
+				},
+			}
+			var got []lsp.CompletionItem
+			if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &got); err != nil {
+				t.Fatal("conn.Call textDocument/completion:", err)
+			}
+			testCompletionItem(t, tt.want, tt.bad, got)
+		})
+	}
+}
+
+func TestCompleteMigrations(t *testing.T) {
+	tx := newTestContext()
+	tx.initServer(t)
+	defer tx.tearDown()
+
+	rootPath, err := filepath.Abs("../migration/testdata/workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.server.rootPath = rootPath
+	if err := tx.server.loadMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+	migrationURI := func(name string) string {
+		return "file://" + filepath.ToSlash(filepath.Join(rootPath, "migrations", name))
+	}
+
+	cases := []struct {
+		completionTestCase
+		uri string
+	}{
+		{
+			completionTestCase: completionTestCase{
+				name:  "tables of all migrations",
+				input: "SELECT * FROM ",
+				line:  0,
+				col:   14,
+				want: []string{
+					"city",
+					"users",
+					"posts",
+					"wallets",
+					"tags",
+				},
+			},
+			uri: testFileURI,
+		},
+		{
+			completionTestCase: completionTestCase{
+				name:  "tables of earlier migrations",
+				input: "SELECT * FROM ",
+				line:  0,
+				col:   14,
+				want: []string{
+					"city",
+					"users",
+				},
+				bad: []string{
+					"posts",
+					"wallets",
+				},
+			},
+			uri: migrationURI("000002_add_email.up.sql"),
+		},
+		{
+			completionTestCase: completionTestCase{
+				name:  "columns of earlier migrations",
+				input: "ALTER TABLE users ADD COLUMN age INT;\nSELECT  FROM users",
+				line:  1,
+				col:   7,
+				want: []string{
+					"id",
+					"name",
+					"email",
+					"age",
+				},
+				bad: []string{
+					"user_id",
+				},
+			},
+			uri: migrationURI("000010_create_posts.up.sql"),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tx.textDocumentDidOpen(t, tt.uri, tt.input)
+
+			completionParams := lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{
+						URI: tt.uri,
+					},
+					Position: lsp.Position{
+						Line:      tt.line,
+						Character: tt.col,
+					},
+				},
+			}
+			var got []lsp.CompletionItem
+			if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &got); err != nil {
+				t.Fatal("conn.Call textDocument/completion:", err)
+			}
+			testCompletionItem(t, tt.want, tt.bad, got)
+		})
+	}
+}
+
+func TestCompletePragma(t *testing.T) {
+	tx := newTestContext()
+	tx.initServer(t)
+	defer tx.tearDown()
+
+	fixture := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(fixture, []byte("CREATE TABLE city (id INTEGER, name TEXT);"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:    "sqlite3",
+				SQLiteCfg: &database.SQLiteConfig{Fixture: fixture},
+			},
+		},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+
+	cases := []completionTestCase{
+		{
+			name:  "pragma name",
+			input: "PRAGMA jour",
+			line:  0,
+			col:   11,
+			want: []string{
+				"journal_mode",
+				"journal_size_limit",
+			},
+			bad: []string{
+				"SELECT",
+				"foreign_keys",
+			},
+		},
+		{
+			name:  "pragma value",
+			input: "PRAGMA main.journal_mode = ",
+			line:  0,
+			col:   27,
+			want: []string{
+				"WAL",
+				"DELETE",
+			},
+			bad: []string{
+				"ON",
+			},
+		},
+		{
+			name:  "pragma table",
+			input: "PRAGMA table_info(",
+			line:  0,
+			col:   18,
+			want: []string{
+				"city",
+			},
+		},
+		{
+			name:  "pragma number",
+			input: "PRAGMA user_version = ",
+			line:  0,
+			col:   22,
+			bad: []string{
+				"city",
+				"ON",
+				"SELECT",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tx.textDocumentDidOpen(t, testFileURI, tt.input)
+
+			completionParams := lsp.CompletionParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{
+						URI: testFileURI,
+					},
+					Position: lsp.Position{
+						Line:      tt.line,
+						Character: tt.col,
+					},
+				},
+			}
+			var got []lsp.CompletionItem
+			if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &got); err != nil {
+				t.Fatal("conn.Call textDocument/completion:", err)
+			}
+			testCompletionItem(t, tt.want, tt.bad, got)
+		})
+	}
+}
+
+func TestCompletionItemResolve(t *testing.T) {
+	tx := newTestContext()
+	tx.initServer(t)
+	defer tx.tearDown()
+
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+	tx.textDocumentDidOpen(t, testFileURI, "SELECT * FROM ")
+
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: testFileURI},
+			Position:     lsp.Position{Line: 0, Character: 14},
+		},
+	}
+	var items []lsp.CompletionItem
+	if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &items); err != nil {
+		t.Fatal("conn.Call textDocument/completion:", err)
+	}
+	var city *lsp.CompletionItem
+	for i := range items {
+		if items[i].Label == "city" {
+			city = &items[i]
+		}
+	}
+	if city == nil {
+		t.Fatalf("table not completed: %v", items)
+	}
+	// The documentation of the tables is resolved for the item selected
+	if city.Documentation != nil {
+		t.Errorf("unexpected documentation before resolve: %q", city.Documentation.Value)
+	}
+
+	var got lsp.CompletionItem
+	if err := tx.conn.Call(tx.ctx, "completionItem/resolve", city, &got); err != nil {
+		t.Fatal("conn.Call completionItem/resolve:", err)
+	}
+	if got.Documentation == nil || !strings.HasPrefix(got.Documentation.Value, "# `city` table") {
+		t.Errorf("unexpected documentation: %+v", got.Documentation)
+	}
+	if got.Label != city.Label || got.Kind != city.Kind {
+		t.Errorf("unexpected item resolved: %+v", got)
+	}
+}
+
+func TestCompletionLabelDetails(t *testing.T) {
+	tx := newTestContext()
+	tx.initServer(t)
+	defer tx.tearDown()
+
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	}
+	tx.addWorkspaceConfig(t, cfg)
+	tx.textDocumentDidOpen(t, testFileURI, "SELECT  FROM city JOIN countrylanguage")
+
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: testFileURI},
+			Position:     lsp.Position{Line: 0, Character: 7},
+		},
+	}
+	var items []lsp.CompletionItem
+	if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &items); err != nil {
+		t.Fatal("conn.Call textDocument/completion:", err)
+	}
+	var got []lsp.CompletionItemLabelDetails
+	for _, item := range items {
+		if item.Label == "CountryCode" && item.LabelDetails != nil {
+			got = append(got, *item.LabelDetails)
+		}
+	}
+	want := []lsp.CompletionItemLabelDetails{
+		{Detail: " char(3)", Description: "world.city"},
+		{Detail: " char(3)", Description: "world.countrylanguage"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected label details (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// The commands that do not use the database connection
+var commandsWithoutDB = map[string]bool{
+	CommandShowConnections:       true,
+	CommandListConnections:       true,
+	CommandSwitchConnection:      true,
+	CommandSwitchConnectionAlias: true,
+	CommandSwitchDatabase:        true,
+	CommandShowHistory:           true,
+	CommandSearchHistory:         true,
+	CommandSaveBookmark:          true,
+	CommandListBookmarks:         true,
+	CommandDeleteBookmark:        true,
+	CommandStorePassword:         true,
+	CommandAddConnection:         true,
+	CommandDiffSchemas:           true,
+	CommandShowNotebook:          true,
+	CommandClearNotebook:         true,
+}
+
+// dbWarmup is a connection opened in the background together with its
+// cache. It is adopted by the request after it is done, unless the
+// connection to use has been changed in the meantime.
+type dbWarmup struct {
+	cfg    *database.DBConfig
+	index  int
+	dbName string
+
+	done   chan struct{}
+	conn   *database.DBConnection
+	worker *database.Worker
+	err    error
+}
+
+// connectDB opens the database connection unless it is open, waiting for
+// the connection opened in the background if any.
+func (s *Server) connectDB(ctx context.Context, conn *jsonrpc2.Conn) error {
+	if err := s.adoptWarmup(ctx, conn, true); err != nil {
+		return err
+	}
+	if s.dbConn != nil {
+		return nil
+	}
+	// The connections kept for the other documents stay open
+	if err := s.openDBConnection(ctx); err != nil {
+		return err
+	}
+	s.lastConnectErr = ""
+	return s.notifyActiveConnection(ctx, conn)
+}
+
+// prepareDB adopts the connection opened in the background, or starts
+// opening one, without waiting for it. Until the cache is ready the
+// requests are served without it.
+func (s *Server) prepareDB(ctx context.Context, conn *jsonrpc2.Conn) {
+	if err := s.adoptReconnectedDB(ctx, conn); err != nil {
+		logging.FromContext(ctx).Warn("cannot adopt reconnected database", "err", err)
+	}
+	if err := s.adoptWarmup(ctx, conn, false); err != nil {
+		s.showConnectError(ctx, conn, err)
+	}
+	s.warmUpDB()
+}
+
+func (s *Server) warmUpDB() {
+	if s.dbConn != nil || s.warmup != nil || s.topConnection() == nil {
+		return
+	}
+	connCfg, err := s.connectionConfig()
+	if err != nil {
+		slog.Warn("cannot warm up database", "err", err)
+		return
+	}
+	w := &dbWarmup{
+		cfg:    connCfg,
+		index:  s.curConnectionIndex,
+		dbName: s.curDBName,
+		done:   make(chan struct{}),
+	}
+	s.warmup = w
+	go func() {
+		defer close(w.done)
+		defer func() {
+			if err := logging.Recovered(slog.Default(), recover(), "connecting database"); err != nil {
+				w.err = err
+			}
+		}()
+		w.conn, w.worker, w.err = s.openDB(context.Background(), connCfg)
+	}()
+}
+
+// openDB opens the connection of the config and generates its cache, or
+// shares the ones of another session if Registry is set. The cache of the
+// connection not shared is of ownWorker.
+func (s *Server) openDB(ctx context.Context, connCfg *database.DBConfig) (*database.DBConnection, *database.Worker, error) {
+	if s.Registry != nil {
+		return s.Registry.Acquire(ctx, connCfg)
+	}
+	dbConn, err := database.Open(connCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, err := database.CreateRepository(connCfg.Driver, dbConn.Conn)
+	if err == nil {
+		err = s.ownWorker.ReCache(ctx, repo)
+	}
+	if err != nil {
+		dbConn.Close()
+		return nil, nil, err
+	}
+	return dbConn, s.ownWorker, nil
+}
+
+// adoptWarmup makes the connection opened in the background the connection
+// of the server once it is done.
+func (s *Server) adoptWarmup(ctx context.Context, conn *jsonrpc2.Conn, wait bool) error {
+	w := s.warmup
+	if w == nil {
+		return nil
+	}
+	if wait {
+		<-w.done
+	} else {
+		select {
+		case <-w.done:
+		default:
+			return nil
+		}
+	}
+	s.warmup = nil
+	if w.err != nil {
+		return w.err
+	}
+	if s.dbConn != nil || w.index != s.curConnectionIndex || w.dbName != s.curDBName {
+		// Another connection has been chosen meanwhile
+		w.conn.Close()
+		return nil
+	}
+	s.dbConn, s.worker = w.conn, w.worker
+	s.curDBCfg = w.cfg
+	s.health.watch(s.dbConn, s.curDBCfg)
+	s.lastConnectErr = ""
+	return s.notifyActiveConnection(ctx, conn)
+}
+
+// discardWarmup waits for the connection opened in the background and
+// closes it, so that it does not overwrite the cache of another one.
+func (s *Server) discardWarmup() {
+	w := s.warmup
+	if w == nil {
+		return
+	}
+	<-w.done
+	s.warmup = nil
+	if w.conn != nil {
+		w.conn.Close()
+	}
+}
+
+// showConnectError shows the error of the background connection once, not
+// to repeat it on every request that retries.
+func (s *Server) showConnectError(ctx context.Context, conn *jsonrpc2.Conn, err error) {
+	logging.FromContext(ctx).Warn("cannot connect database", "err", err)
+	if err.Error() == s.lastConnectErr {
+		return
+	}
+	s.lastConnectErr = err.Error()
+	if err := lsp.NewMessenger(conn).ShowError(ctx, err.Error()); err != nil {
+		logging.FromContext(ctx).Warn("cannot send error", "err", err)
+	}
+}
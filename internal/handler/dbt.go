@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sqls-server/sqls/internal/completer"
+	"github.com/sqls-server/sqls/internal/dbt"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+var (
+	refArgPattern         = regexp.MustCompile(`\bref\(\s*(?:['"]\w+['"]\s*,\s*)?['"]\w*$`)
+	sourceArgPattern      = regexp.MustCompile(`\bsource\(\s*['"]\w*$`)
+	sourceTableArgPattern = regexp.MustCompile(`\bsource\(\s*['"](\w+)['"]\s*,\s*['"]\w*$`)
+)
+
+// loadDbtProject loads the dbt project at the workspace root, if it is.
+func (s *Server) loadDbtProject() error {
+	s.dbtProject, s.projectCache = nil, nil
+	if s.rootPath == "" || dbt.ProjectPath(s.rootPath) == "" {
+		return nil
+	}
+	p, err := dbt.Load(s.rootPath)
+	if err != nil {
+		return err
+	}
+	s.dbtProject = p
+	return nil
+}
+
+// dbtCompletionItems returns the models and the seeds for the argument of
+// ref(), and the sources and their tables for the arguments of source(), if
+// the position is at them.
+func (s *Server) dbtCompletionItems(text string, pos lsp.Position) ([]lsp.CompletionItem, bool) {
+	if s.dbtProject == nil {
+		return nil, false
+	}
+	lines := strings.Split(text, "\n")
+	if pos.Line >= len(lines) {
+		return nil, false
+	}
+	line := []rune(lines[pos.Line])
+	if pos.Character > len(line) {
+		return nil, false
+	}
+	prefix := string(line[:pos.Character])
+	if strings.LastIndex(prefix, "{{") <= strings.LastIndex(prefix, "}}") {
+		return nil, false
+	}
+
+	items := []lsp.CompletionItem{}
+	switch {
+	case refArgPattern.MatchString(prefix):
+		for _, rel := range s.dbtProject.Models() {
+			items = append(items, relationCompletionItem(rel))
+		}
+	case sourceArgPattern.MatchString(prefix):
+		for _, name := range s.dbtProject.Sources() {
+			items = append(items, lsp.CompletionItem{
+				Label:  name,
+				Kind:   lsp.ModuleCompletion,
+				Detail: "dbt source",
+			})
+		}
+	default:
+		m := sourceTableArgPattern.FindStringSubmatch(prefix)
+		if m == nil {
+			return nil, false
+		}
+		for _, rel := range s.dbtProject.SourceTables(m[1]) {
+			items = append(items, relationCompletionItem(rel))
+		}
+	}
+	return items, true
+}
+
+func relationCompletionItem(rel *dbt.Relation) lsp.CompletionItem {
+	item := lsp.CompletionItem{
+		Label:  rel.Name,
+		Kind:   lsp.ClassCompletion,
+		Detail: "dbt " + rel.Kind.String(),
+	}
+	if rel.Description != "" {
+		item.Documentation = &lsp.MarkupContent{
+			Kind:  lsp.Markdown,
+			Value: rel.Description,
+		}
+	}
+	completer.MarkDeprecated(&item, rel.Description)
+	return item
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/sqls-server/sqls/ast/astutil"
 	"github.com/sqls-server/sqls/internal/database"
 	"github.com/sqls-server/sqls/internal/lsp"
-	"github.com/sqls-server/sqls/parser"
 	"github.com/sqls-server/sqls/parser/parseutil"
 	"github.com/sqls-server/sqls/token"
 )
@@ -25,20 +24,21 @@ func (s *Server) handleDefinition(ctx context.Context, conn *jsonrpc2.Conn, req
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	return definition(params.TextDocument.URI, f.Text, params, s.worker.Cache())
+	s.prepareDB(ctx, conn)
+	return definition(f, params.TextDocument.URI, s.templateText(f, true).Text, params, s.dbCache(params.TextDocument.URI))
 }
 
-func definition(url, text string, params lsp.DefinitionParams, dbCache *database.DBCache) (lsp.Definition, error) {
+func definition(f *File, url, text string, params lsp.DefinitionParams, dbCache *database.DBCache) (lsp.Definition, error) {
 	pos := token.Pos{
 		Line: params.Position.Line,
 		Col:  params.Position.Character + 1,
 	}
-	parsed, err := parser.Parse(text)
+	parsed, err := f.parse(text)
 	if err != nil {
 		return nil, err
 	}
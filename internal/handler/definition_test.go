@@ -84,6 +84,29 @@ var definitionTestCases = []struct {
 			},
 		},
 	},
+	{
+		name:  "alias of table of another database",
+		input: "SELECT o.OrderID FROM archive.dbo.orders o",
+		pos: lsp.Position{
+			Line:      0,
+			Character: 7,
+		},
+		want: []lsp.Location{
+			{
+				URI: testFileURI,
+				Range: lsp.Range{
+					Start: lsp.Position{
+						Line:      0,
+						Character: 41,
+					},
+					End: lsp.Position{
+						Line:      0,
+						Character: 42,
+					},
+				},
+			},
+		},
+	},
 }
 
 func TestDefinition(t *testing.T) {
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/internal/migration"
+)
+
+// diffSchemas returns the report of the differences of the schemas of the
+// source and the target, each a connection given by the index or the alias or
+// a schema dump given by the path of the SQL file. The source is the current
+// connection without the second argument.
+func (s *Server) diffSchemas(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	args := params.Arguments
+	switch len(args) {
+	case 1:
+		if err := s.connectDB(ctx, conn); err != nil {
+			return nil, err
+		}
+		args = []interface{}{float64(s.curConnectionIndex + 1), args[0]}
+	case 2:
+	default:
+		return nil, fmt.Errorf("required arguments were not provided: [<Connection Index or Alias or Schema File>] <Connection Index or Alias or Schema File>")
+	}
+
+	source, sourceName, err := s.schemaCache(ctx, args[0])
+	if err != nil {
+		return nil, err
+	}
+	target, targetName, err := s.schemaCache(ctx, args[1])
+	if err != nil {
+		return nil, err
+	}
+	return lsp.TextDocumentItem{
+		URI:        "sqls:/schema-diff.md",
+		LanguageID: "markdown",
+		Text:       database.DiffSchemas(source, target, sourceName, targetName).Render(),
+	}, nil
+}
+
+// schemaCache returns the cache of the tables of the connection or the schema
+// dump of the argument, and its name in the report. The connections other
+// than the current one are opened only to read their tables.
+func (s *Server) schemaCache(ctx context.Context, arg interface{}) (*database.DBCache, string, error) {
+	if fpath, ok := arg.(string); ok && strings.EqualFold(filepath.Ext(fpath), ".sql") {
+		return s.schemaDumpCache(fpath)
+	}
+	index, err := s.connectionIndex(arg)
+	if err != nil {
+		return nil, "", err
+	}
+	connCfg := s.getConnection(index)
+	name := connCfg.Alias
+	if name == "" {
+		name = fmt.Sprintf("connection %d", index+1)
+	}
+	if index == s.curConnectionIndex && s.dbConn != nil && s.worker.Cache() != nil {
+		return s.worker.Cache(), name, nil
+	}
+
+	dbConn, err := database.Open(connCfg)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot open connection %s, %w", name, err)
+	}
+	defer dbConn.Close()
+	repo, err := database.CreateRepository(connCfg.Driver, dbConn.Conn)
+	if err != nil {
+		return nil, "", err
+	}
+	cache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot read schema of %s, %w", name, err)
+	}
+	return cache, name, nil
+}
+
+// schemaDumpCache returns the tables of the CREATE TABLE of the schema dump,
+// whose relative path is of the workspace root.
+func (s *Server) schemaDumpCache(fpath string) (*database.DBCache, string, error) {
+	if !filepath.IsAbs(fpath) && s.rootPath != "" {
+		fpath = filepath.Join(s.rootPath, fpath)
+	}
+	b, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot read schema file, %w", err)
+	}
+	schema := migration.NewSchema()
+	if err := schema.Apply(string(b), s.documentDriver()); err != nil {
+		return nil, "", fmt.Errorf("cannot parse schema file, %w", err)
+	}
+	return schema.Cache(), filepath.Base(fpath), nil
+}
+
+// DumpSchema returns the CREATE TABLE of the tables of the database
+// connected, as a schema dump that the schemas are linted and compared with
+// without the database.
+func (s *Server) DumpSchema() (string, error) {
+	cache := s.worker.Cache()
+	if cache == nil {
+		return "", errors.New("database cache is not ready")
+	}
+	return database.DumpSchema(cache, s.documentDriver()), nil
+}
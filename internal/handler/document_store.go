@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/parser"
+)
+
+// errStaleVersion is returned for the changes of the versions older than
+// the one of the document, which arrive out of order.
+var errStaleVersion = errors.New("stale version")
+
+// File is a version of the document open by the client. The versions are
+// not changed once they are in the store, but replaced by the new ones, so
+// that a request keeps the text it reads while the document is changed.
+type File struct {
+	LanguageID string
+	Text       string
+	// Version is the version of the text sent by the client
+	Version int
+	// planDiagnostics are the problems of the plans of the statements found
+	// when the document is saved
+	planDiagnostics []lsp.Diagnostic
+	// planLenses are the estimated costs of the statements found when the
+	// document is saved
+	planLenses []lsp.CodeLens
+	// cache is shared by the versions of the document
+	cache *parseCache
+}
+
+// parseCache keeps the statements parsed of a document.
+type parseCache struct {
+	mu sync.Mutex
+	// documents keep the statements parsed in the dialects of the drivers,
+	// not to parse the whole text again on every change
+	documents map[dialect.DatabaseDriver]*parser.Document
+	// parsed is the whole text of the version parsed, which the requests
+	// on the same version share
+	version int
+	text    string
+	parsed  ast.TokenList
+	err     error
+}
+
+func (f *File) parseCache() *parseCache {
+	if f.cache == nil {
+		f.cache = &parseCache{}
+	}
+	return f.cache
+}
+
+// document returns the parsed statements of the file in the dialect of the
+// driver.
+func (f *File) document(driver dialect.DatabaseDriver) *parser.Document {
+	c := f.parseCache()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.documents == nil {
+		c.documents = make(map[dialect.DatabaseDriver]*parser.Document)
+	}
+	doc, ok := c.documents[driver]
+	if !ok {
+		doc = parser.NewDocument(dialect.ForDriver(driver))
+		c.documents[driver] = doc
+	}
+	return doc
+}
+
+// parse returns the text of the file parsed, such as with its templates
+// replaced, which is parsed once for the version.
+func (f *File) parse(text string) (ast.TokenList, error) {
+	c := f.parseCache()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.parsed != nil || c.err != nil {
+		if c.version == f.Version && c.text == text {
+			return c.parsed, c.err
+		}
+	}
+	parsed, err := parser.Parse(text)
+	c.version, c.text, c.parsed, c.err = f.Version, text, parsed, err
+	return parsed, err
+}
+
+// documentStore has the documents open by the client, which the requests
+// and the background work share.
+type documentStore struct {
+	mu    sync.RWMutex
+	files map[string]*File
+}
+
+func newDocumentStore() *documentStore {
+	return &documentStore{
+		files: make(map[string]*File),
+	}
+}
+
+// get returns the current version of the document.
+func (d *documentStore) get(uri string) (*File, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	f, ok := d.files[uri]
+	return f, ok
+}
+
+// put sets the document, such as of the statements of the REPL.
+func (d *documentStore) put(uri string, f *File) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.files[uri] = f
+}
+
+func (d *documentStore) open(uri, languageID string, version int, text string) *File {
+	f := &File{
+		LanguageID: languageID,
+		Text:       text,
+		Version:    version,
+		cache:      &parseCache{},
+	}
+	d.put(uri, f)
+	return f
+}
+
+// update replaces the document with the version of the text. The versions
+// older than the current one are rejected with errStaleVersion.
+func (d *documentStore) update(uri string, version int, text string) (*File, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f, ok := d.files[uri]
+	if !ok {
+		return nil, fmt.Errorf("document not found: %v", uri)
+	}
+	if version < f.Version {
+		return nil, fmt.Errorf("%w %d of %s, the document is of %d", errStaleVersion, version, uri, f.Version)
+	}
+	nf := *f
+	nf.Text, nf.Version = text, version
+	if f.Text != text {
+		// The positions of the plans are not of the new text
+		nf.planDiagnostics = nil
+		nf.planLenses = nil
+	}
+	d.files[uri] = &nf
+	return &nf, nil
+}
+
+// setPlans replaces the document with the problems and the estimated costs
+// of the plans of its version, unless the version is changed.
+func (d *documentStore) setPlans(uri string, f *File, diagnostics []lsp.Diagnostic, lenses []lsp.CodeLens) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cur, ok := d.files[uri]
+	if !ok || cur.Version != f.Version || cur.Text != f.Text {
+		return
+	}
+	nf := *cur
+	nf.planDiagnostics, nf.planLenses = diagnostics, lenses
+	d.files[uri] = &nf
+}
+
+func (d *documentStore) close(uri string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.files, uri)
+}
+
+// uris returns the URIs of the documents in their order.
+func (d *documentStore) uris() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	uris := make([]string, 0, len(d.files))
+	for uri := range d.files {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+// modified reports whether the document is changed from the version, or
+// closed, so that the results of the version are stale.
+func (d *documentStore) modified(uri string, f *File) bool {
+	cur, ok := d.get(uri)
+	return !ok || cur.Version != f.Version || cur.Text != f.Text
+}
+
+// errContentModified is the error of the requests on the versions of the
+// documents changed while they are served.
+func errContentModified(uri string) error {
+	return &jsonrpc2.Error{Code: lsp.CodeContentModified, Message: fmt.Sprintf("document modified: %s", uri)}
+}
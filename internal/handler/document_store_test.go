@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func TestDocumentStoreUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		text    string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "next version",
+			version: 2,
+			text:    "SELECT 2",
+			want:    "SELECT 2",
+		},
+		{
+			name:    "same version",
+			version: 1,
+			text:    "SELECT 2",
+			want:    "SELECT 2",
+		},
+		{
+			name:    "stale version",
+			version: 0,
+			text:    "SELECT 0",
+			want:    "SELECT 1",
+			wantErr: errStaleVersion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDocumentStore()
+			uri := "file:///test.sql"
+			opened := d.open(uri, "sql", 1, "SELECT 1")
+
+			_, err := d.update(uri, tt.version, tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error %v, want %v", err, tt.wantErr)
+			}
+			f, _ := d.get(uri)
+			if f.Text != tt.want {
+				t.Errorf("unexpected text %q, want %q", f.Text, tt.want)
+			}
+			// The version read before is not changed
+			if opened.Text != "SELECT 1" {
+				t.Errorf("the version opened is changed to %q", opened.Text)
+			}
+			if got, want := d.modified(uri, opened), tt.wantErr == nil; got != want {
+				t.Errorf("unexpected modified %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDocumentStorePlans(t *testing.T) {
+	d := newDocumentStore()
+	uri := "file:///test.sql"
+	f := d.open(uri, "sql", 1, "SELECT 1")
+	lenses := []lsp.CodeLens{{Command: &lsp.Command{Title: "estimated 1 row"}}}
+
+	d.setPlans(uri, f, nil, lenses)
+	cur, _ := d.get(uri)
+	if len(cur.planLenses) != 1 {
+		t.Fatalf("expected the plans of the version, got %v", cur.planLenses)
+	}
+
+	// The plans are of the text of the version
+	if _, err := d.update(uri, 2, "SELECT 2"); err != nil {
+		t.Fatal(err)
+	}
+	cur, _ = d.get(uri)
+	if len(cur.planLenses) != 0 {
+		t.Errorf("expected no plans of the text changed, got %v", cur.planLenses)
+	}
+	d.setPlans(uri, f, nil, lenses)
+	cur, _ = d.get(uri)
+	if len(cur.planLenses) != 0 {
+		t.Errorf("expected the plans of the old version ignored, got %v", cur.planLenses)
+	}
+}
+
+func TestFileParse(t *testing.T) {
+	d := newDocumentStore()
+	uri := "file:///test.sql"
+	f := d.open(uri, "sql", 1, "SELECT a FROM t")
+
+	first, err := f.parse(f.Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := f.parse(f.Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("expected the statements parsed of the version to be shared")
+	}
+
+	f, err = d.update(uri, 2, "SELECT b FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := f.parse(f.Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed == first || parsed.String() != "SELECT b FROM t" {
+		t.Errorf("unexpected statements of the new version: %q", parsed.String())
+	}
+}
+
+func TestDocumentStoreConcurrent(t *testing.T) {
+	d := newDocumentStore()
+	uri := "file:///test.sql"
+	d.open(uri, "sql", 0, "SELECT 0")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 100; i++ {
+			if _, err := d.update(uri, i, fmt.Sprintf("SELECT %d", i)); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			f, ok := d.get(uri)
+			if !ok {
+				t.Error("document not found")
+				return
+			}
+			// The text read is of its version
+			if want := fmt.Sprintf("SELECT %d", f.Version); f.Text != want {
+				t.Errorf("unexpected text %q of version %d", f.Text, f.Version)
+			}
+			if _, err := f.parse(f.Text); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -4,29 +4,64 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/sourcegraph/jsonrpc2"
 	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
 	"github.com/sqls-server/sqls/internal/database"
 	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/internal/metrics"
 	"github.com/sqls-server/sqls/parser"
+	"github.com/sqls-server/sqls/parser/parseutil"
+	"github.com/sqls-server/sqls/token"
 )
 
 const (
-	CommandExecuteQuery     = "executeQuery"
-	CommandShowDatabases    = "showDatabases"
-	CommandShowSchemas      = "showSchemas"
-	CommandShowConnections  = "showConnections"
-	CommandSwitchDatabase   = "switchDatabase"
-	CommandSwitchConnection = "switchConnections"
-	CommandShowTables       = "showTables"
+	CommandExecuteQuery          = "executeQuery"
+	CommandShowDatabases         = "showDatabases"
+	CommandShowSchemas           = "showSchemas"
+	CommandShowConnections       = "showConnections"
+	CommandSwitchDatabase        = "switchDatabase"
+	CommandSwitchConnection      = "switchConnections"
+	CommandListConnections       = "listConnections"
+	CommandSwitchConnectionAlias = "switchConnection"
+	CommandShowTables            = "showTables"
+	CommandBeginTransaction      = "beginTransaction"
+	CommandCommitTransaction     = "commitTransaction"
+	CommandRollbackTransaction   = "rollbackTransaction"
+	CommandExplainQuery          = "explainQuery"
+	CommandExplainAnalyzeQuery   = "explainAnalyzeQuery"
+	CommandShowHistory           = "showHistory"
+	CommandSearchHistory         = "searchHistory"
+	CommandExecuteHistory        = "executeHistory"
+	CommandSaveBookmark          = "saveBookmark"
+	CommandListBookmarks         = "listBookmarks"
+	CommandRunBookmark           = "runBookmark"
+	CommandDeleteBookmark        = "deleteBookmark"
+	CommandStorePassword         = "storePassword"
+	CommandAddConnection         = "addConnection"
+	CommandShowERDiagram         = "showERDiagram"
+	CommandDiffSchemas           = "diffSchemas"
+	CommandExportDataDictionary  = "exportDataDictionary"
+	CommandPreviewQuery          = "previewQuery"
+	CommandShowNotebook          = "showNotebook"
+	CommandClearNotebook         = "clearNotebook"
+	CommandImportData            = "importData"
+	CommandGenerateFakeData      = "generateFakeData"
+	CommandRunMigration          = "runMigration"
 )
 
 func (s *Server) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
@@ -45,6 +80,26 @@ func (s *Server) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrpc
 			Command:   CommandExecuteQuery,
 			Arguments: []interface{}{params.TextDocument.URI},
 		},
+		{
+			Title:     "Explain Query",
+			Command:   CommandExplainQuery,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
+		{
+			Title:     "Explain Analyze Query",
+			Command:   CommandExplainAnalyzeQuery,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
+		{
+			Title:     "Preview Affected Rows",
+			Command:   CommandPreviewQuery,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
+		{
+			Title:     "Run Migration",
+			Command:   CommandRunMigration,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
 		{
 			Title:     "Show Databases",
 			Command:   CommandShowDatabases,
@@ -70,13 +125,61 @@ func (s *Server) handleTextDocumentCodeAction(ctx context.Context, conn *jsonrpc
 			Command:   CommandSwitchConnection,
 			Arguments: []interface{}{},
 		},
+		{
+			Title:     "List Connections",
+			Command:   CommandListConnections,
+			Arguments: []interface{}{},
+		},
 		{
 			Title:     "Show Tables",
 			Command:   CommandShowTables,
 			Arguments: []interface{}{},
 		},
+		{
+			Title:     "Show ER Diagram",
+			Command:   CommandShowERDiagram,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
+		{
+			Title:     "Show Notebook",
+			Command:   CommandShowNotebook,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
+		{
+			Title:     "Show History",
+			Command:   CommandShowHistory,
+			Arguments: []interface{}{},
+		},
+		{
+			Title:     "List Bookmarks",
+			Command:   CommandListBookmarks,
+			Arguments: []interface{}{},
+		},
+		{
+			Title:     "Begin Transaction",
+			Command:   CommandBeginTransaction,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
+		{
+			Title:     "Commit Transaction",
+			Command:   CommandCommitTransaction,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
+		{
+			Title:     "Rollback Transaction",
+			Command:   CommandRollbackTransaction,
+			Arguments: []interface{}{params.TextDocument.URI},
+		},
 	}
-	return commands, nil
+
+	actions := []interface{}{}
+	for _, action := range s.quickFixActions(params.TextDocument.URI, params.Range) {
+		actions = append(actions, action)
+	}
+	for _, command := range commands {
+		actions = append(actions, command)
+	}
+	return actions, nil
 }
 
 func (s *Server) handleWorkspaceExecuteCommand(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
@@ -88,54 +191,321 @@ func (s *Server) handleWorkspaceExecuteCommand(ctx context.Context, conn *jsonrp
 	if err := json.Unmarshal(*req.Params, &params); err != nil {
 		return nil, err
 	}
+	if err := s.adoptReconnectedDB(ctx, conn); err != nil {
+		return nil, err
+	}
+	// The transaction is of the connection of the document
+	if (params.Command == CommandExecuteQuery || params.Command == CommandBeginTransaction) && len(params.Arguments) > 0 {
+		if uri, ok := params.Arguments[0].(string); ok {
+			if err := s.switchFileConnection(ctx, conn, uri); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !commandsWithoutDB[params.Command] {
+		if err := s.connectDB(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
 
 	switch params.Command {
 	case CommandExecuteQuery:
-		return s.executeQuery(ctx, params)
+		return s.executeQuery(ctx, conn, params)
 	case CommandShowDatabases:
 		return s.showDatabases(ctx, params)
 	case CommandShowSchemas:
 		return s.showSchemas(ctx, params)
 	case CommandShowConnections:
 		return s.showConnections(ctx, params)
+	case CommandListConnections:
+		return s.listConnections(ctx, params)
 	case CommandSwitchDatabase:
-		return s.switchDatabase(ctx, params)
-	case CommandSwitchConnection:
-		return s.switchConnections(ctx, params)
+		return s.switchDatabase(ctx, conn, params)
+	case CommandSwitchConnection, CommandSwitchConnectionAlias:
+		return s.switchConnections(ctx, conn, params)
 	case CommandShowTables:
 		return s.showTables(ctx, params)
+	case CommandShowERDiagram:
+		return s.showERDiagram(ctx, params)
+	case CommandDiffSchemas:
+		return s.diffSchemas(ctx, conn, params)
+	case CommandExportDataDictionary:
+		return s.exportDataDictionary(ctx, params)
+	case CommandExplainQuery:
+		return s.explainQuery(ctx, params, false)
+	case CommandExplainAnalyzeQuery:
+		return s.explainQuery(ctx, params, true)
+	case CommandPreviewQuery:
+		return s.previewQuery(ctx, params)
+	case CommandImportData:
+		return s.importData(ctx, params)
+	case CommandGenerateFakeData:
+		return s.generateFakeData(ctx, params)
+	case CommandRunMigration:
+		return s.runMigration(ctx, params)
+	case CommandShowNotebook:
+		return s.showNotebook(ctx, params)
+	case CommandClearNotebook:
+		return s.clearNotebook(ctx, params)
+	case CommandShowHistory:
+		return s.showHistory(ctx, params)
+	case CommandSearchHistory:
+		return s.searchHistory(ctx, params)
+	case CommandExecuteHistory:
+		return s.executeHistory(ctx, params)
+	case CommandSaveBookmark:
+		return s.saveBookmark(ctx, params)
+	case CommandListBookmarks:
+		return s.listBookmarks(ctx, params)
+	case CommandRunBookmark:
+		return s.runBookmark(ctx, params)
+	case CommandDeleteBookmark:
+		return s.deleteBookmark(ctx, params)
+	case CommandStorePassword:
+		return s.storePassword(ctx, params)
+	case CommandAddConnection:
+		return s.addConnection(ctx, conn, params)
+	case CommandBeginTransaction:
+		return s.beginTransaction(ctx, conn, params)
+	case CommandCommitTransaction:
+		return s.commitTransaction(ctx, conn, params)
+	case CommandRollbackTransaction:
+		return s.rollbackTransaction(ctx, conn, params)
 	}
 	return nil, fmt.Errorf("unsupported command: %v", params.Command)
 }
 
-func (s *Server) executeQuery(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+func (s *Server) executeQuery(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
 	// parse execute command arguments
 	if s.dbConn == nil {
 		return nil, errors.New("database connection is not open")
 	}
-	if len(params.Arguments) == 0 {
-		return nil, fmt.Errorf("required arguments were not provided: <File URI>")
+	uri, text, err := s.commandText(params)
+	if err != nil {
+		return nil, err
 	}
-	uri, ok := params.Arguments[0].(string)
+
+	// The optional arguments are the -show-vertical flag, the -notebook flag
+	// appending the results to the notebook of the document, the bind
+	// parameters given as a list or a map, the -copy-file=<path> flag
+	// giving the rows of COPY ... FROM STDIN, and -format=json returning the
+	// results as the data instead of the text
+	showVertical := false
+	notebook := s.getConfig().Notebook
+	jsonResults := false
+	for _, arg := range params.Arguments[1:] {
+		switch flag, _ := arg.(string); flag {
+		case "-show-vertical":
+			showVertical = true
+		case "-notebook":
+			notebook = true
+		case formatFlag + "json":
+			jsonResults = true
+		}
+	}
+	bindParams := commandBindParams(params.Arguments[1:])
+	copyFile := commandCopyFile(params.Arguments[1:])
+
+	stmts, err := getStatements(text)
+	if err != nil {
+		return nil, err
+	}
+
+	executor, err := s.executor(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	// The connections given by the comments of the statements are opened
+	// only for the command
+	opened := map[string]*database.DBConnection{}
+	defer func() {
+		for _, dbConn := range opened {
+			dbConn.Close()
+		}
+	}()
+	fileConn := s.fileConnection(uri)
+	buf := new(bytes.Buffer)
+	results := []*queryResult{}
+	for _, stmt := range stmts {
+		query := statementQuery(stmt)
+		if query == "" {
+			continue
+		}
+		stmtExecutor, driver, err := s.statementExecutor(uri, executor, statementAlias(stmt, fileConn), opened)
+		if err != nil {
+			return nil, err
+		}
+		stmtParams := bindParams
+		if stmtParams == nil {
+			stmtParams, err = s.promptBindParams(ctx, conn, driver, query)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if jsonResults {
+			res, err := s.executeResult(ctx, stmtExecutor, driver, query, stmtParams, copyFile)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, res)
+			if err := logWarnings(ctx, conn, res.Stats.Warnings); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		res, warnings, err := s.executeStatements(ctx, stmtExecutor, driver, []string{query}, showVertical, stmtParams, copyFile)
+		if notebook {
+			s.appendNotebook(uri, query, res, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(res)
+		if err := logWarnings(ctx, conn, warnings); err != nil {
+			return nil, err
+		}
+	}
+	if jsonResults {
+		return results, nil
+	}
+	if notebook {
+		return s.notebookDocument(uri), nil
+	}
+	return buf.String(), nil
+}
+
+// logWarnings sends the warnings of the database to the log of the client by
+// window/logMessage, such as the notices of RAISE NOTICE. conn is nil out of
+// the language server.
+func logWarnings(ctx context.Context, conn *jsonrpc2.Conn, warnings []string) error {
+	if conn == nil {
+		return nil
+	}
+	for _, warning := range warnings {
+		if err := conn.Notify(ctx, "window/logMessage", &lsp.LogMessageParams{
+			Type:    lsp.Warning,
+			Message: warning,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileConnection returns the alias of the connection given by the comments at
+// the top of the document, of the statements without their own even if the
+// statements of a range are run.
+func (s *Server) fileConnection(uri string) string {
+	f, ok := s.files.get(uri)
 	if !ok {
-		return nil, fmt.Errorf("specify the file uri as a string")
+		return ""
+	}
+	stmts, err := getStatements(f.Text)
+	if err != nil {
+		return ""
+	}
+	return parseutil.FileConnection(stmts)
+}
+
+// statementExecutor returns the executor of a statement and the driver of its
+// connection. The statement with the connection of "-- sqls: connection=..."
+// or "-- conn: ..." runs on it out of the transaction of the document, and the
+// connection is added to opened.
+func (s *Server) statementExecutor(uri string, executor database.Executor, alias string, opened map[string]*database.DBConnection) (database.Executor, dialect.DatabaseDriver, error) {
+	if alias == "" || alias == s.curDBCfg.Alias {
+		return executor, s.curDBCfg.Driver, nil
 	}
-	f, ok := s.files[uri]
+	if _, ok := s.transactions[uri]; ok {
+		return nil, "", fmt.Errorf("cannot run the statement on %s in the transaction of the document", alias)
+	}
+	index, err := s.connectionIndex(alias)
+	if err != nil {
+		return nil, "", err
+	}
+	connCfg := s.getConnection(index)
+	dbConn, ok := opened[alias]
 	if !ok {
-		return nil, fmt.Errorf("document not found, %q", uri)
+		dbConn, err = database.Open(connCfg)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot open connection %s, %w", alias, err)
+		}
+		opened[alias] = dbConn
 	}
+	repo, err := database.CreateRepository(connCfg.Driver, dbConn.Conn)
+	if err != nil {
+		return nil, "", err
+	}
+	return repo, connCfg.Driver, nil
+}
 
-	showVertical := false
-	if len(params.Arguments) > 1 {
-		showVerticalFlag, ok := params.Arguments[1].(string)
-		if ok {
-			if showVerticalFlag == "-show-vertical" {
-				showVertical = true
-			}
+// executeStatements runs the queries in order on the connection of the
+// driver, and returns their results and the warnings of the database. The
+// placeholders of each query are bound from bindParams, and COPY ... FROM
+// STDIN reads copyFile.
+func (s *Server) executeStatements(ctx context.Context, executor database.Executor, driver dialect.DatabaseDriver, queries []string, vertical bool, bindParams interface{}, copyFile string) (string, []string, error) {
+	buf := new(bytes.Buffer)
+	var warnings []string
+	for _, query := range queries {
+		args, err := statementArgs(driver, query, bindParams)
+		if err != nil {
+			return "", nil, err
 		}
+
+		var res string
+		var count int64
+		var stmtWarnings []string
+
+		start := time.Now()
+		if database.IsCopyFromStdin(query) {
+			res, count, err = s.copyFrom(ctx, executor, query, copyFile)
+		} else if _, isQuery := database.QueryExecType(query, ""); isQuery {
+			res, count, stmtWarnings, err = s.query(ctx, executor, query, vertical, args...)
+		} else {
+			res, count, stmtWarnings, err = s.exec(ctx, executor, query, vertical, args...)
+		}
+		s.recordHistory(query, start, count, err)
+		if err != nil {
+			return "", nil, err
+		}
+		fmt.Fprintln(buf, res)
+		warnings = append(warnings, stmtWarnings...)
+	}
+	return buf.String(), warnings, nil
+}
+
+// statementArgs returns the arguments of the placeholders of the query bound
+// from bindParams. The query is run as it is if bindParams is nil, as the
+// placeholders may be the variables of the script.
+func statementArgs(driver dialect.DatabaseDriver, query string, bindParams interface{}) ([]interface{}, error) {
+	if bindParams == nil {
+		return nil, nil
+	}
+	placeholders, err := database.Placeholders(driver, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(placeholders) == 0 {
+		return nil, nil
+	}
+	return database.BindParams(placeholders, bindParams)
+}
+
+// commandText returns the document URI given as the first command argument
+// and its text, limited to the command range if there is one.
+func (s *Server) commandText(params lsp.ExecuteCommandParams) (string, string, error) {
+	if len(params.Arguments) == 0 {
+		return "", "", fmt.Errorf("required arguments were not provided: <File URI>")
+	}
+	uri, ok := params.Arguments[0].(string)
+	if !ok {
+		return "", "", fmt.Errorf("specify the file uri as a string")
+	}
+	f, ok := s.files.get(uri)
+	if !ok {
+		return "", "", fmt.Errorf("document not found, %q", uri)
 	}
 
-	// extract target query
 	text := f.Text
 	if params.Range != nil {
 		text = extractRangeText(
@@ -146,36 +516,137 @@ func (s *Server) executeQuery(ctx context.Context, params lsp.ExecuteCommandPara
 			params.Range.End.Character,
 		)
 	}
+	return uri, text, nil
+}
+
+func (s *Server) explainQuery(ctx context.Context, params lsp.ExecuteCommandParams, analyze bool) (result interface{}, err error) {
+	if s.dbConn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	uri, text, err := s.commandText(params)
+	if err != nil {
+		return nil, err
+	}
 	stmts, err := getStatements(text)
 	if err != nil {
 		return nil, err
 	}
+	if analyze {
+		// EXPLAIN ANALYZE runs the statements
+		for _, stmt := range stmts {
+			if query := statementQuery(stmt); query != "" && !database.IsReadOnlyQuery(query) {
+				return nil, fmt.Errorf("cannot explain analyze the statement which changes the database, %q", query)
+			}
+		}
+	}
+	executor, err := s.executor(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
 
-	// execute statements
 	buf := new(bytes.Buffer)
 	for _, stmt := range stmts {
-		query := strings.TrimSpace(stmt.String())
+		query := statementQuery(stmt)
 		if query == "" {
 			continue
 		}
+		explain, err := database.ExplainStatement(s.curDBCfg.Driver, query, analyze)
+		if err != nil {
+			return nil, err
+		}
+		plan, err := s.queryPlan(ctx, executor, explain)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(buf, query)
+		fmt.Fprintln(buf, plan.Render())
+	}
+	return buf.String(), nil
+}
 
-		if _, isQuery := database.QueryExecType(query, ""); isQuery {
-			res, err := s.query(ctx, query, showVertical)
-			if err != nil {
-				return nil, err
-			}
-			fmt.Fprintln(buf, res)
-		} else {
-			res, err := s.exec(ctx, query, showVertical)
-			if err != nil {
-				return nil, err
-			}
-			fmt.Fprintln(buf, res)
+// previewQuery shows the rows that the UPDATE and the DELETE statements would
+// change by the SELECT of the same tables and conditions, without running them.
+func (s *Server) previewQuery(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if s.dbConn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	uri, text, err := s.commandText(params)
+	if err != nil {
+		return nil, err
+	}
+	showVertical := false
+	for _, arg := range params.Arguments[1:] {
+		if flag, ok := arg.(string); ok && flag == "-show-vertical" {
+			showVertical = true
 		}
 	}
+	bindParams := commandBindParams(params.Arguments[1:])
+	stmts, err := getStatements(text)
+	if err != nil {
+		return nil, err
+	}
+	executor, err := s.executor(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	for _, stmt := range stmts {
+		query := statementQuery(stmt)
+		if query == "" {
+			continue
+		}
+		preview, err := database.PreviewStatement(s.curDBCfg.Driver, query)
+		if errors.Is(err, database.ErrNotPreviewable) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		args, err := statementArgs(s.curDBCfg.Driver, preview, bindParams)
+		if err != nil {
+			return nil, err
+		}
+		res, _, _, err := s.query(ctx, executor, preview, showVertical, args...)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(buf, preview)
+		fmt.Fprintln(buf, res)
+	}
+	if buf.Len() == 0 {
+		return nil, database.ErrNotPreviewable
+	}
 	return buf.String(), nil
 }
 
+// explainPlan returns the plan of the query by EXPLAIN, which does not run it.
+func (s *Server) explainPlan(ctx context.Context, executor database.Executor, query string) (*database.Plan, error) {
+	explain, err := database.ExplainStatement(s.curDBCfg.Driver, query, false)
+	if err != nil {
+		return nil, err
+	}
+	return s.queryPlan(ctx, executor, explain)
+}
+
+func (s *Server) queryPlan(ctx context.Context, executor database.Executor, explain string) (*database.Plan, error) {
+	metrics.CountQuery("explain")
+	rows, err := executor.Query(ctx, explain)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := database.Columns(rows)
+	if err != nil {
+		return nil, err
+	}
+	stringRows, err := database.ScanRows(rows, len(columns))
+	if err != nil {
+		return nil, err
+	}
+	return database.ParsePlan(s.curDBCfg.Driver, columns, stringRows)
+}
+
 func extractRangeText(text string, startLine, startChar, endLine, endChar int) string {
 	writer := bytes.NewBufferString("")
 	scanner := bufio.NewScanner(strings.NewReader(text))
@@ -203,65 +674,185 @@ func extractRangeText(text string, startLine, startChar, endLine, endChar int) s
 	return writer.String()
 }
 
-func (s *Server) query(ctx context.Context, query string, vertical bool) (string, error) {
-	repo, err := s.newDBRepository(ctx)
-	if err != nil {
-		return "", err
-	}
-	rows, err := repo.Query(ctx, query)
-	if err != nil {
-		return "", err
+// executor returns the open transaction of the document if there is one,
+// otherwise the repository of the current connection.
+func (s *Server) executor(ctx context.Context, uri string) (database.Executor, error) {
+	if t, ok := s.transactions[uri]; ok {
+		return t, nil
 	}
-	columns, err := database.Columns(rows)
-	if err != nil {
-		return "", err
+	return s.newDBRepository(ctx)
+}
+
+// query runs the query, and returns the table of its rows, the number of them
+// and the warnings of the database.
+func (s *Server) query(ctx context.Context, executor database.Executor, query string, vertical bool, args ...interface{}) (string, int64, []string, error) {
+	type resultSet struct {
+		columns []string
+		rows    [][]string
 	}
-	stringRows, err := database.ScanRows(rows, len(columns))
+	var sets []resultSet
+	warnings, err := s.runQuery(ctx, executor, query, func(rows *sql.Rows) error {
+		// The procedures of SQL Server and CALL of MySQL return the result sets
+		// one after another
+		for {
+			columns, err := database.Columns(rows)
+			if err != nil {
+				return err
+			}
+			formatter, err := database.NewResultFormatter(s.getConfig().ResultFormat, rows)
+			if err != nil {
+				return err
+			}
+			stringRows, err := formatter.ScanRows(rows)
+			if err != nil {
+				return err
+			}
+			if len(sets) == 0 || len(columns) > 0 {
+				sets = append(sets, resultSet{columns: columns, rows: stringRows})
+			}
+			if !rows.NextResultSet() {
+				return rows.Err()
+			}
+		}
+	}, args...)
 	if err != nil {
-		return "", err
+		return "", 0, nil, err
 	}
 
 	buf := new(bytes.Buffer)
-	if vertical {
-		table := newVerticalTableWriter(buf)
-		table.setHeaders(columns)
-		for _, stringRow := range stringRows {
-			table.appendRow(stringRow)
+	var count int64
+	for i, set := range sets {
+		if i > 0 {
+			fmt.Fprintln(buf, "")
 		}
-		table.render()
-	} else {
-		table := tablewriter.NewWriter(buf)
-		table.SetHeader(columns)
-		for _, stringRow := range stringRows {
-			table.Append(stringRow)
+		if vertical {
+			table := newVerticalTableWriter(buf)
+			table.setHeaders(set.columns)
+			for _, stringRow := range set.rows {
+				table.appendRow(stringRow)
+			}
+			table.render()
+		} else {
+			table := tablewriter.NewWriter(buf)
+			table.SetHeader(set.columns)
+			for _, stringRow := range set.rows {
+				table.Append(stringRow)
+			}
+			table.Render()
 		}
-		table.Render()
+		fmt.Fprintf(buf, "%d rows in set", len(set.rows))
+		fmt.Fprintln(buf, "")
+		count += int64(len(set.rows))
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(buf, warning)
 	}
-	fmt.Fprintf(buf, "%d rows in set", len(stringRows))
-	fmt.Fprintln(buf, "")
 	fmt.Fprintln(buf, "")
-	return buf.String(), nil
+	return buf.String(), count, warnings, nil
 }
 
-func (s *Server) exec(ctx context.Context, query string, vertical bool) (string, error) {
-	repo, err := s.newDBRepository(ctx)
+// runQuery runs the query, and passes its rows to fn. It returns the warnings
+// of the database which tells them.
+func (s *Server) runQuery(ctx context.Context, executor database.Executor, query string, fn func(*sql.Rows) error, args ...interface{}) ([]string, error) {
+	metrics.CountQuery("query")
+	if we, ok := executor.(database.WarningExecutor); ok {
+		return we.QueryWarnings(ctx, query, fn, args...)
+	}
+	rows, err := executor.Query(ctx, query, args...)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	result, err := repo.Exec(ctx, query)
+	defer rows.Close()
+	return nil, fn(rows)
+}
+
+func (s *Server) exec(ctx context.Context, executor database.Executor, query string, vertical bool, args ...interface{}) (string, int64, []string, error) {
+	start := time.Now()
+	summary, err := s.execSummary(ctx, executor, query, args...)
 	if err != nil {
-		return "", err
+		return "", 0, nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "Query OK, %d row affected (%s)", summary.rowsAffected, time.Since(start).Round(time.Microsecond))
+	fmt.Fprintln(buf, "")
+	if summary.lastInsertID != nil {
+		fmt.Fprintf(buf, "Last insert ID: %d", *summary.lastInsertID)
+		fmt.Fprintln(buf, "")
+	}
+	for _, warning := range summary.warnings {
+		fmt.Fprintln(buf, warning)
+	}
+	fmt.Fprintln(buf, "")
+	return buf.String(), summary.rowsAffected, summary.warnings, nil
+}
+
+// execSummary is what the database tells of a statement which returns no
+// rows. lastInsertID is nil unless the statement inserts the rows and the
+// driver tells the ID.
+type execSummary struct {
+	rowsAffected int64
+	lastInsertID *int64
+	warnings     []string
+}
+
+// execSummary runs the statement, and returns the summary of it with the
+// warnings of the database which tells them.
+func (s *Server) execSummary(ctx context.Context, executor database.Executor, query string, args ...interface{}) (*execSummary, error) {
+	metrics.CountQuery("exec")
+	var result sql.Result
+	var warnings []string
+	var err error
+	if we, ok := executor.(database.WarningExecutor); ok {
+		result, warnings, err = we.ExecWarnings(ctx, query, args...)
+	} else {
+		result, err = executor.Exec(ctx, query, args...)
+	}
+	if err != nil {
+		return nil, err
 	}
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	summary := &execSummary{
+		rowsAffected: rowsAffected,
+		warnings:     warnings,
+	}
+	typ, _ := database.QueryExecType(query, "")
+	if typ = strings.ToUpper(typ); strings.HasPrefix(typ, "INSERT") || strings.HasPrefix(typ, "REPLACE") {
+		if id, err := result.LastInsertId(); err == nil && id > 0 {
+			summary.lastInsertID = &id
+		}
+	}
+	return summary, nil
+}
+
+// copyFrom runs COPY ... FROM STDIN sending the rows of the file.
+func (s *Server) copyFrom(ctx context.Context, executor database.Executor, query string, path string) (string, int64, error) {
+	copier, ok := executor.(database.Copier)
+	if !ok {
+		return "", 0, fmt.Errorf("COPY FROM STDIN is not supported by %s", s.curDBCfg.Driver)
+	}
+	if path == "" {
+		return "", 0, fmt.Errorf("specify the file of COPY FROM STDIN by %s<path>", copyFileFlag)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot open copy file, %w", err)
+	}
+	defer f.Close()
+	metrics.CountQuery("copy")
+	rowsAffected, err := copier.CopyFrom(ctx, query, f)
+	if err != nil {
+		return "", 0, err
 	}
 
 	buf := new(bytes.Buffer)
 	fmt.Fprintf(buf, "Query OK, %d row affected", rowsAffected)
 	fmt.Fprintln(buf, "")
 	fmt.Fprintln(buf, "")
-	return buf.String(), nil
+	return buf.String(), rowsAffected, nil
 }
 
 func (s *Server) showDatabases(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
@@ -288,7 +879,7 @@ func (s *Server) showSchemas(ctx context.Context, params lsp.ExecuteCommandParam
 	return strings.Join(schemas, "\n"), nil
 }
 
-func (s *Server) switchDatabase(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+func (s *Server) switchDatabase(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
 	if len(params.Arguments) != 1 {
 		return nil, fmt.Errorf("required arguments were not provided: <DB Name>")
 	}
@@ -296,6 +887,9 @@ func (s *Server) switchDatabase(ctx context.Context, params lsp.ExecuteCommandPa
 	if !ok {
 		return nil, fmt.Errorf("specify the db name as a string")
 	}
+	if err := s.confirmSwitch(ctx, conn); err != nil {
+		return nil, err
+	}
 
 	// Change current database
 	s.curDBName = dbName
@@ -305,10 +899,23 @@ func (s *Server) switchDatabase(ctx context.Context, params lsp.ExecuteCommandPa
 		return nil, err
 	}
 
+	if err := s.notifyActiveConnection(ctx, conn); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
 func (s *Server) showConnections(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	return strings.Join(s.connectionDescriptions(false), "\n"), nil
+}
+
+// listConnections is the same as showConnections except that the active
+// connection is marked with an asterisk.
+func (s *Server) listConnections(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	return strings.Join(s.connectionDescriptions(true), "\n"), nil
+}
+
+func (s *Server) connectionDescriptions(markActive bool) []string {
 	results := []string{}
 	conns := s.getConfig().Connections
 	for i, conn := range conns {
@@ -328,49 +935,118 @@ func (s *Server) showConnections(ctx context.Context, params lsp.ExecuteCommandP
 			}
 		}
 		res := fmt.Sprintf("%d %s %s %s", i+1, conn.Driver, conn.Alias, desc)
+		if markActive {
+			if i == s.curConnectionIndex {
+				res = "* " + res
+			} else {
+				res = "  " + res
+			}
+		}
 		results = append(results, res)
 	}
-	return strings.Join(results, "\n"), nil
+	return results
 }
 
-func (s *Server) switchConnections(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+func (s *Server) switchConnections(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
 	if len(params.Arguments) != 1 {
-		return nil, fmt.Errorf("required arguments were not provided: <Connection Index>")
+		return nil, fmt.Errorf("required arguments were not provided: <Connection Index or Alias>")
 	}
-	indexStr, ok := params.Arguments[0].(string)
-	if !ok {
-		return nil, fmt.Errorf("specify the connection index as a number")
+
+	index, err := s.connectionIndex(params.Arguments[0])
+	if err != nil {
+		return nil, err
+	}
+	if err := s.confirmSwitch(ctx, conn); err != nil {
+		return nil, err
 	}
 
-	var index int
+	// Reconnect database
+	s.curConnectionIndex = index
+	// The database switched by switchDatabase belongs to the previous connection
+	s.curDBName = ""
+
+	// close and reconnection to database
+	if err := s.reconnectionDB(ctx); err != nil {
+		return nil, err
+	}
 
-	cfg := s.getConfig()
-	if cfg != nil {
+	if err := s.notifyActiveConnection(ctx, conn); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// connectionIndex returns the index of the connection given by the 1-based
+// index or the alias.
+func (s *Server) connectionIndex(arg interface{}) (int, error) {
+	var index int
+	switch arg := arg.(type) {
+	case float64:
+		index = int(arg)
+	case string:
+		cfg := s.getConfig()
 		for i, conn := range cfg.Connections {
-			if conn.Alias == indexStr {
+			if conn.Alias == arg {
 				index = i + 1
 				break
 			}
 		}
-	}
-	if index <= 0 {
-		index, _ = strconv.Atoi(indexStr)
+		if index <= 0 {
+			index, _ = strconv.Atoi(arg)
+		}
+	default:
+		return 0, fmt.Errorf("specify the connection index as a number or the alias as a string")
 	}
 
-	if index <= 0 {
-		return nil, fmt.Errorf("specify the connection index as a number, %w", err)
+	if index <= 0 || len(s.getConfig().Connections) < index {
+		return 0, fmt.Errorf("connection not found, %v", arg)
 	}
-	index = index - 1
-
-	// Reconnect database
-	s.curConnectionIndex = index
+	return index - 1, nil
+}
 
-	// close and reconnection to database
-	if err := s.reconnectionDB(ctx); err != nil {
+// storePassword saves the password of the connection in the keyring. The
+// connection defaults to the current one.
+func (s *Server) storePassword(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) == 0 {
+		return nil, fmt.Errorf("required arguments were not provided: <Password> [Connection Index or Alias]")
+	}
+	password, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the password as a string")
+	}
+	index := s.curConnectionIndex
+	if len(params.Arguments) > 1 {
+		if index, err = s.connectionIndex(params.Arguments[1]); err != nil {
+			return nil, err
+		}
+	}
+	connCfg := s.getConnection(index)
+	if connCfg == nil {
+		return nil, ErrNoConnection
+	}
+	if err := database.StorePassword(connCfg, password); err != nil {
 		return nil, err
 	}
+	if index == s.curConnectionIndex {
+		// Show the error again if the stored password is still rejected
+		s.lastConnectErr = ""
+	}
+	return fmt.Sprintf("stored the password of %s", connCfg.Alias), nil
+}
 
-	return nil, nil
+// notifyActiveConnection sends the active connection to the client so that it
+// can be displayed, e.g. in the status line.
+func (s *Server) notifyActiveConnection(ctx context.Context, conn *jsonrpc2.Conn) error {
+	if s.curDBCfg == nil {
+		return nil
+	}
+	params := &lsp.ActiveConnectionParams{
+		Index:    s.curConnectionIndex + 1,
+		Alias:    s.curDBCfg.Alias,
+		Driver:   string(s.curDBCfg.Driver),
+		Database: s.curDBCfg.DBName,
+	}
+	return conn.Notify(ctx, "sqls/activeConnection", params)
 }
 
 func (s *Server) showTables(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
@@ -402,6 +1078,307 @@ func (s *Server) showTables(ctx context.Context, params lsp.ExecuteCommandParams
 	return strings.Join(results, "\n"), nil
 }
 
+// formatFlag is the prefix of the argument giving the format of the ER
+// diagram, mermaid, plantuml or graphviz, and of the data dictionary,
+// markdown or html.
+const formatFlag = "-format="
+
+// showERDiagram returns the ER diagram of the tables of the query of the
+// document given as the first argument, or of all the tables of the schema
+// without the document or the tables. The diagram is a virtual document for
+// the editor to open and render.
+func (s *Server) showERDiagram(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	format := database.ERDiagramMermaid
+	for _, arg := range params.Arguments {
+		if flag, ok := arg.(string); ok && strings.HasPrefix(flag, formatFlag) {
+			format, err = database.ParseERDiagramFormat(strings.TrimPrefix(flag, formatFlag))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	uri := ""
+	var tables []string
+	if len(params.Arguments) > 0 {
+		if arg, ok := params.Arguments[0].(string); ok && !strings.HasPrefix(arg, "-") {
+			var text string
+			uri, text, err = s.commandText(params)
+			if err != nil {
+				return nil, err
+			}
+			tables, err = queryTables(text)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	cache := s.dbCache(uri)
+	if cache == nil {
+		return nil, errors.New("database cache is not ready")
+	}
+	return lsp.TextDocumentItem{
+		URI:        "sqls:/er-diagram." + format.Extension(),
+		LanguageID: format.LanguageID(),
+		Text:       database.NewERDiagram(cache, tables).Render(format),
+	}, nil
+}
+
+// exportDataDictionary writes the data dictionary of the tables of the schema
+// to the file of the path given as an argument, whose extension .html makes it
+// HTML, or returns it as a virtual document without the path.
+func (s *Server) exportDataDictionary(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	path, formatName := "", ""
+	for _, arg := range params.Arguments {
+		str, ok := arg.(string)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(str, formatFlag) {
+			formatName = strings.TrimPrefix(str, formatFlag)
+		} else if path == "" {
+			path = str
+		}
+	}
+	if formatName == "" && path != "" {
+		formatName = strings.TrimPrefix(filepath.Ext(path), ".")
+		if !strings.EqualFold(formatName, "html") && !strings.EqualFold(formatName, "htm") {
+			formatName = ""
+		}
+	}
+	format, err := database.ParseDictionaryFormat(formatName)
+	if err != nil {
+		return nil, err
+	}
+	cache := s.dbCache("")
+	if cache == nil {
+		return nil, errors.New("database cache is not ready")
+	}
+	title := database.Coalesce(s.curDBCfg.Alias, cache.DefaultSchema(), string(s.curDBCfg.Driver))
+	text := database.DataDictionary(cache, title, format)
+	if path == "" {
+		return lsp.TextDocumentItem{
+			URI:        "sqls:/data-dictionary." + format.Extension(),
+			LanguageID: string(format),
+			Text:       text,
+		}, nil
+	}
+
+	if !filepath.IsAbs(path) && s.rootPath != "" {
+		path = filepath.Join(s.rootPath, path)
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		return nil, fmt.Errorf("cannot write data dictionary, %w", err)
+	}
+	return fmt.Sprintf("Data dictionary written to %s", path), nil
+}
+
+// queryTables returns the names of the tables that the statements of the text
+// refer to.
+func queryTables(text string) ([]string, error) {
+	parsed, err := parser.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	tables := []string{}
+	for _, stmt := range parsed.GetTokens() {
+		infos, err := parseutil.ExtractTable(parsed, stmt.End())
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.Name != "" {
+				tables = append(tables, info.Name)
+			}
+		}
+	}
+	return tables, nil
+}
+
+func (s *Server) beginTransaction(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if s.dbConn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	uri, err := transactionURI(params)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.transactions[uri]; ok {
+		return nil, fmt.Errorf("transaction already in progress, %q", uri)
+	}
+
+	t, err := database.BeginTransaction(ctx, s.curDBCfg.Driver, s.dbConn.Conn)
+	if err != nil {
+		return nil, err
+	}
+	s.transactions[uri] = t
+
+	if err := s.notifyTransactionStatus(ctx, conn, uri); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Server) commitTransaction(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	uri, err := transactionURI(params)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := s.transactions[uri]
+	if !ok {
+		return nil, database.ErrNoTransaction
+	}
+	delete(s.transactions, uri)
+	if err := t.Commit(); err != nil {
+		return nil, err
+	}
+
+	if err := s.notifyTransactionStatus(ctx, conn, uri); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Server) rollbackTransaction(ctx context.Context, conn *jsonrpc2.Conn, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	uri, err := transactionURI(params)
+	if err != nil {
+		return nil, err
+	}
+	t, ok := s.transactions[uri]
+	if !ok {
+		return nil, database.ErrNoTransaction
+	}
+	delete(s.transactions, uri)
+	if err := t.Rollback(); err != nil {
+		return nil, err
+	}
+
+	if err := s.notifyTransactionStatus(ctx, conn, uri); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Server) rollbackTransactions() {
+	for uri, t := range s.transactions {
+		if err := t.Rollback(); err != nil {
+			slog.Warn("cannot rollback transaction", "uri", uri, "err", err)
+		}
+		delete(s.transactions, uri)
+	}
+}
+
+// rollbackTransactionsOf rolls back the transactions on the connection, not
+// the ones on the connections kept for the other documents, and returns the
+// documents of them.
+func (s *Server) rollbackTransactionsOf(dbConn *database.DBConnection) []string {
+	uris := s.transactionURIsOf(dbConn)
+	for _, uri := range uris {
+		if err := s.transactions[uri].Rollback(); err != nil {
+			slog.Warn("cannot rollback transaction", "uri", uri, "err", err)
+		}
+		delete(s.transactions, uri)
+	}
+	return uris
+}
+
+// transactionURIsOf returns the documents whose transactions are on the
+// connection.
+func (s *Server) transactionURIsOf(dbConn *database.DBConnection) []string {
+	if dbConn == nil {
+		return nil
+	}
+	var uris []string
+	for uri, t := range s.transactions {
+		if t.Of(dbConn.Conn) {
+			uris = append(uris, uri)
+		}
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+// transactionsInProgress returns the error naming the documents whose
+// transactions are open, or nil if there are none.
+func (s *Server) transactionsInProgress() error {
+	if len(s.transactions) == 0 {
+		return nil
+	}
+	return fmt.Errorf("transaction in progress, commit or rollback it first, %s", strings.Join(s.transactionURIs(), ", "))
+}
+
+func (s *Server) transactionURIs() []string {
+	uris := make([]string, 0, len(s.transactions))
+	for uri := range s.transactions {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+const (
+	switchRollback = "Rollback and switch"
+	switchCancel   = "Cancel"
+)
+
+// confirmSwitch asks the user whether to roll back the open transactions,
+// which switching the connection closes, by window/showMessageRequest. It
+// returns the error of transactionsInProgress unless the user rolls them
+// back, such as when the client does not answer the request.
+func (s *Server) confirmSwitch(ctx context.Context, conn *jsonrpc2.Conn) error {
+	inProgress := s.transactionsInProgress()
+	if inProgress == nil || conn == nil || !s.showMessageRequest {
+		return inProgress
+	}
+	uris := s.transactionURIs()
+	params := lsp.ShowMessageRequestParams{
+		Type:    lsp.Warning,
+		Message: fmt.Sprintf("Switching the connection rolls back the transactions of %s", strings.Join(uris, ", ")),
+		Actions: []lsp.MessageActionItem{
+			{Title: switchRollback},
+			{Title: switchCancel},
+		},
+	}
+	var choice *lsp.MessageActionItem
+	if err := conn.Call(ctx, "window/showMessageRequest", params, &choice); err != nil {
+		return fmt.Errorf("cannot ask for rolling back the transactions, %w", err)
+	}
+	if choice == nil || choice.Title != switchRollback {
+		return inProgress
+	}
+	s.rollbackTransactions()
+	for _, uri := range uris {
+		if err := s.notifyTransactionStatus(ctx, conn, uri); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) transactionStatus(uri string) string {
+	t, ok := s.transactions[uri]
+	if !ok {
+		return fmt.Sprintf("No transaction: %s", uri)
+	}
+	return fmt.Sprintf("In transaction since %s: %s", t.StartedAt.Format("15:04:05"), uri)
+}
+
+func (s *Server) notifyTransactionStatus(ctx context.Context, conn *jsonrpc2.Conn, uri string) error {
+	messenger := lsp.NewMessenger(conn)
+	return messenger.ShowInfo(ctx, s.transactionStatus(uri))
+}
+
+func transactionURI(params lsp.ExecuteCommandParams) (string, error) {
+	if len(params.Arguments) == 0 {
+		return "", fmt.Errorf("required arguments were not provided: <File URI>")
+	}
+	uri, ok := params.Arguments[0].(string)
+	if !ok {
+		return "", fmt.Errorf("specify the file uri as a string")
+	}
+	return uri, nil
+}
+
 func getStatements(text string) ([]*ast.Statement, error) {
 	parsed, err := parser.Parse(text)
 	if err != nil {
@@ -419,6 +1396,24 @@ func getStatements(text string) ([]*ast.Statement, error) {
 	return stmts, nil
 }
 
+// statementQuery returns the query of the statement to run, without the
+// delimiter set by the DELIMITER directive ending it. The directives, which
+// are the commands of the MySQL client, are empty.
+func statementQuery(stmt *ast.Statement) string {
+	query := strings.TrimSpace(stmt.String())
+	last := lastToken(stmt)
+	if last == nil || last.GetToken().Kind != token.Semicolon {
+		return query
+	}
+	if first := firstToken(stmt); first != nil && strings.EqualFold(first.String(), "DELIMITER") {
+		return ""
+	}
+	if delimiter := last.String(); delimiter != ";" {
+		query = strings.TrimSpace(strings.TrimSuffix(query, delimiter))
+	}
+	return query
+}
+
 type verticalTableWriter struct {
 	writer       io.Writer
 	headers      []string
@@ -1,7 +1,17 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
 
 	"github.com/sqls-server/sqls/internal/config"
 	"github.com/sqls-server/sqls/internal/database"
@@ -55,6 +65,1296 @@ func Test_executeQuery(t *testing.T) {
 	// pass error
 }
 
+func TestExecuteQueryPromptsBindParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		choice  string
+		value   interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "entered",
+			choice: bindParamsEnter,
+			value:  "Kabul",
+			want:   "Kabul",
+		},
+		{
+			// The placeholder is not bound, which SQLite requires
+			name:    "run as it is",
+			choice:  bindParamsRun,
+			wantErr: true,
+		},
+		{
+			name:    "cancelled",
+			choice:  bindParamsEnter,
+			value:   nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer()
+			tx := &TestContext{
+				h:      server.Handler(),
+				ctx:    context.Background(),
+				server: server,
+			}
+			tx.initParams.Capabilities.Window = &lsp.WindowClientCapabilities{
+				ShowMessage: &lsp.ShowMessageRequestClientCapabilities{},
+			}
+			prompts := []string{}
+			tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+				switch req.Method {
+				case "window/showMessageRequest":
+					var params lsp.ShowMessageRequestParams
+					if err := json.Unmarshal(*req.Params, &params); err != nil {
+						return nil, err
+					}
+					prompts = append(prompts, params.Message)
+					return lsp.MessageActionItem{Title: tt.choice}, nil
+				case "sqls/inputBox":
+					var params lsp.InputBoxParams
+					if err := json.Unmarshal(*req.Params, &params); err != nil {
+						return nil, err
+					}
+					prompts = append(prompts, params.Prompt)
+					return tt.value, nil
+				}
+				return nil, nil
+			})
+			tx.setup(t)
+			defer tx.tearDown()
+			defer server.Stop()
+
+			tx.addWorkspaceConfig(t, &config.Config{
+				Connections: []*database.DBConfig{
+					{
+						Driver:         "sqlite3",
+						DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+					},
+				},
+			})
+			uri := "file:///params.sql"
+			tx.textDocumentDidOpen(t, uri, "SELECT :name AS name;")
+
+			var got interface{}
+			err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+				Command:   CommandExecuteQuery,
+				Arguments: []interface{}{uri},
+			}, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if res, _ := got.(string); !strings.Contains(res, tt.want) {
+				t.Errorf("got %q, want %q", res, tt.want)
+			}
+			if len(prompts) == 0 || prompts[0] != "The statement has the parameters :name" {
+				t.Errorf("unexpected prompts, %q", prompts)
+			}
+		})
+	}
+}
+
+func Test_transaction(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Version:    0,
+			Text:       "CREATE TABLE item (id INTEGER);",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	execute := func(command string) (string, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: []interface{}{uri},
+		}, &got)
+		res, _ := got.(string)
+		return res, err
+	}
+	change := func(text string) {
+		tx.setText(t, uri, text)
+	}
+
+	if _, err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("create table:", err)
+	}
+	if _, err := execute(CommandCommitTransaction); err == nil {
+		t.Fatal("commit without transaction must fail")
+	}
+
+	// rollback discards the insert
+	if _, err := execute(CommandBeginTransaction); err != nil {
+		t.Fatal("begin:", err)
+	}
+	if _, err := execute(CommandBeginTransaction); err == nil {
+		t.Fatal("nested begin must fail")
+	}
+	change("INSERT INTO item VALUES (1);")
+	if _, err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("insert:", err)
+	}
+	change("SELECT * FROM item;")
+	got, err := execute(CommandExecuteQuery)
+	if err != nil {
+		t.Fatal("select:", err)
+	}
+	if !strings.Contains(got, "1 rows in set") {
+		t.Errorf("inserted row is not visible in transaction, got %q", got)
+	}
+	if _, err := execute(CommandRollbackTransaction); err != nil {
+		t.Fatal("rollback:", err)
+	}
+	got, err = execute(CommandExecuteQuery)
+	if err != nil {
+		t.Fatal("select:", err)
+	}
+	if !strings.Contains(got, "0 rows in set") {
+		t.Errorf("rolled back row is visible, got %q", got)
+	}
+
+	// commit keeps the insert
+	if _, err := execute(CommandBeginTransaction); err != nil {
+		t.Fatal("begin:", err)
+	}
+	change("INSERT INTO item VALUES (2);")
+	if _, err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("insert:", err)
+	}
+	if _, err := execute(CommandCommitTransaction); err != nil {
+		t.Fatal("commit:", err)
+	}
+	change("SELECT * FROM item;")
+	got, err = execute(CommandExecuteQuery)
+	if err != nil {
+		t.Fatal("select:", err)
+	}
+	if !strings.Contains(got, "1 rows in set") {
+		t.Errorf("committed row is not visible, got %q", got)
+	}
+	if len(tx.server.transactions) != 0 {
+		t.Errorf("transactions remain open, %d", len(tx.server.transactions))
+	}
+
+	// reconnecting does not roll back the transaction
+	if _, err := execute(CommandBeginTransaction); err != nil {
+		t.Fatal("begin:", err)
+	}
+	if err := tx.server.ConnectDatabase(tx.ctx); err == nil {
+		t.Error("reconnect in transaction must fail")
+	}
+	if _, ok := tx.server.transactions[uri]; !ok {
+		t.Error("transaction is closed by reconnect")
+	}
+	if _, err := execute(CommandRollbackTransaction); err != nil {
+		t.Fatal("rollback:", err)
+	}
+}
+
+func Test_explainQuery(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Version:    0,
+			Text:       "CREATE TABLE item (id INTEGER);",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	execute := func(command string) (interface{}, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: []interface{}{uri},
+		}, &got)
+		return got, err
+	}
+	if _, err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("create table:", err)
+	}
+
+	tx.setText(t, uri, "SELECT * FROM item;")
+	got, err := execute(CommandExplainQuery)
+	if err != nil {
+		t.Fatal("explain:", err)
+	}
+	want := "SELECT * FROM item;\n└─ SCAN item\n\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := execute(CommandExplainAnalyzeQuery); err == nil {
+		t.Error("explain analyze must fail on sqlite3")
+	}
+
+	// The statements changing the database are not run by explain analyze
+	tx.setText(t, uri, "INSERT INTO item VALUES (1), (2);")
+	if _, err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("insert:", err)
+	}
+	count := func() string {
+		tx.setText(t, uri, "SELECT count(*) AS n FROM item;")
+		got, err := execute(CommandExecuteQuery)
+		if err != nil {
+			t.Fatal("count:", err)
+		}
+		return fmt.Sprint(got)
+	}
+	before := count()
+	for _, query := range []string{
+		"DELETE FROM item;",
+		"UPDATE item SET id = 3;",
+		"INSERT INTO item VALUES (4);",
+		"WITH d AS (DELETE FROM item RETURNING *) SELECT * FROM d;",
+	} {
+		tx.setText(t, uri, query)
+		_, err := execute(CommandExplainAnalyzeQuery)
+		if err == nil || !strings.Contains(err.Error(), "changes the database") {
+			t.Errorf("explain analyze %s: unexpected error %v", query, err)
+		}
+	}
+	if after := count(); after != before {
+		t.Errorf("rows are changed by explain analyze: %s, want %s", after, before)
+	}
+}
+
+func Test_previewQuery(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Version:    0,
+			Text:       "CREATE TABLE item (id INTEGER, name TEXT); INSERT INTO item VALUES (1, 'a'), (2, 'b'), (3, 'c');",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	execute := func(command string) (interface{}, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: []interface{}{uri},
+		}, &got)
+		return got, err
+	}
+	if _, err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("create table:", err)
+	}
+
+	tx.setText(t, uri, "SELECT 1; UPDATE item SET name = upper(name) WHERE id > 1; DELETE FROM item WHERE id = 3;")
+	got, err := execute(CommandPreviewQuery)
+	if err != nil {
+		t.Fatal("preview:", err)
+	}
+	want := "SELECT *, upper(name) AS new_name FROM item WHERE id > 1\n" +
+		"+----+------+----------+\n" +
+		"| ID | NAME | NEW NAME |\n" +
+		"+----+------+----------+\n" +
+		"|  2 | b    | B        |\n" +
+		"|  3 | c    | C        |\n" +
+		"+----+------+----------+\n" +
+		"2 rows in set\n\n\n" +
+		"SELECT * FROM item WHERE id = 3\n" +
+		"+----+------+\n" +
+		"| ID | NAME |\n" +
+		"+----+------+\n" +
+		"|  3 | c    |\n" +
+		"+----+------+\n" +
+		"1 rows in set\n\n\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	tx.setText(t, uri, "SELECT count(*) FROM item WHERE upper(name) = name;")
+	got, err = execute(CommandExecuteQuery)
+	if err != nil {
+		t.Fatal("count:", err)
+	}
+	if !strings.Contains(got.(string), "|        0 |") {
+		t.Errorf("preview must not change the rows, %q", got)
+	}
+	if _, err := execute(CommandPreviewQuery); err == nil {
+		t.Error("preview of SELECT must fail")
+	}
+}
+
+func Test_executeQueryWithParams(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Version:    0,
+			Text:       "CREATE TABLE item (id INTEGER, name TEXT);",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	execute := func(args ...interface{}) (string, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   CommandExecuteQuery,
+			Arguments: append([]interface{}{uri}, args...),
+		}, &got)
+		res, _ := got.(string)
+		return res, err
+	}
+	if _, err := execute(); err != nil {
+		t.Fatal("create table:", err)
+	}
+
+	tx.setText(t, uri, "INSERT INTO item VALUES (?, ?);")
+	res, err := execute([]interface{}{1, "apple"})
+	if err != nil {
+		t.Fatal("insert with list:", err)
+	}
+	if !strings.Contains(res, "Query OK, 1 row affected (") || !strings.Contains(res, "Last insert ID: 1") {
+		t.Errorf("unexpected summary of insert, got %q", res)
+	}
+	tx.setText(t, uri, "INSERT INTO item VALUES (:id, :name);")
+	if _, err := execute(map[string]interface{}{"id": 2, "name": "banana"}); err != nil {
+		t.Fatal("insert with map:", err)
+	}
+	_, err = execute(map[string]interface{}{"id": 3})
+	if err == nil || !strings.Contains(err.Error(), ":name") {
+		t.Errorf("missing parameter must be reported, got %v", err)
+	}
+
+	tx.setText(t, uri, "SELECT name FROM item WHERE id >= @id ORDER BY id;")
+	got, err := execute("-show-vertical", map[string]interface{}{"id": 1})
+	if err != nil {
+		t.Fatal("select:", err)
+	}
+	if !strings.Contains(got, "name | apple") || !strings.Contains(got, "name | banana") {
+		t.Errorf("unexpected result, got %q", got)
+	}
+}
+
+func Test_executeQueryJSON(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	text := "CREATE TABLE item (id INTEGER, name TEXT, price REAL);\n" +
+		"INSERT INTO item VALUES (1, 'apple', 1.5), (2, NULL, 2);\n" +
+		"SELECT id, name, price FROM item ORDER BY id;"
+	tx.textDocumentDidOpen(t, uri, text)
+
+	var got []*queryResult
+	err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri, "-format=json"},
+	}, &got)
+	if err != nil {
+		t.Fatal("executeQuery:", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("unexpected results, got %d", len(got))
+	}
+	if got[1].Stats.RowCount != 2 || len(got[1].Columns) != 0 {
+		t.Errorf("unexpected result of insert, got %+v", got[1])
+	}
+	if id := got[1].Stats.LastInsertID; id == nil || *id != 2 {
+		t.Errorf("unexpected last insert ID, got %v", id)
+	}
+	if got[0].Stats.LastInsertID != nil {
+		t.Errorf("unexpected last insert ID of create table, got %v", *got[0].Stats.LastInsertID)
+	}
+
+	sel := got[2]
+	wantColumns := []resultColumn{
+		{Name: "id", Type: "INTEGER"},
+		{Name: "name", Type: "TEXT"},
+		{Name: "price", Type: "REAL"},
+	}
+	if !reflect.DeepEqual(sel.Columns, wantColumns) {
+		t.Errorf("unexpected columns, got %+v, want %+v", sel.Columns, wantColumns)
+	}
+	wantRows := [][]interface{}{
+		{float64(1), "apple", 1.5},
+		{float64(2), nil, float64(2)},
+	}
+	if !reflect.DeepEqual(sel.Rows, wantRows) {
+		t.Errorf("unexpected rows, got %v, want %v", sel.Rows, wantRows)
+	}
+	if sel.Stats.RowCount != 2 || sel.Stats.Duration < 0 {
+		t.Errorf("unexpected stats, got %+v", sel.Stats)
+	}
+}
+
+func Test_executeQueryResultFormat(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		ResultFormat: &database.ResultFormat{
+			Null:         "(null)",
+			MaxCellWidth: 5,
+			Binary:       database.BinaryFormatHex,
+		},
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	tx.textDocumentDidOpen(t, uri, "CREATE TABLE item (name TEXT, data BLOB);\n"+
+		"INSERT INTO item VALUES ('watermelon', x'0102'), (NULL, NULL);")
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri},
+	}, nil); err != nil {
+		t.Fatal("insert:", err)
+	}
+
+	tx.setText(t, uri, "SELECT name, data FROM item ORDER BY name DESC;")
+	var text string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri, "-show-vertical"},
+	}, &text); err != nil {
+		t.Fatal("select:", err)
+	}
+	for _, want := range []string{"name | wate…", "data | 0x01…", "name | (null)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q is not in the result, got %q", want, text)
+		}
+	}
+
+	var got []*queryResult
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri, "-format=json"},
+	}, &got); err != nil {
+		t.Fatal("select json:", err)
+	}
+	wantRows := [][]interface{}{
+		{"wate…", "0x01…"},
+		{"(null)", "(null)"},
+	}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].Rows, wantRows) {
+		t.Errorf("unexpected json results, got %+v, want rows %v", got, wantRows)
+	}
+}
+
+func Test_executeQueryWarnings(t *testing.T) {
+	logs := make(chan lsp.LogMessageParams, 10)
+	tx := newTestContext()
+	tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "window/logMessage" {
+			var params lsp.LogMessageParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			logs <- params
+		}
+		return nil, nil
+	})
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	})
+	uri := "file:///test.sql"
+	tx.textDocumentDidOpen(t, uri, "DO $$ BEGIN RAISE NOTICE 'rows checked'; END $$;")
+
+	var got string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri},
+	}, &got); err != nil {
+		t.Fatal("executeQuery:", err)
+	}
+	if !strings.Contains(got, "Query OK, 22 row affected") || !strings.Contains(got, "NOTICE: rows checked") {
+		t.Errorf("unexpected result, got %q", got)
+	}
+	select {
+	case params := <-logs:
+		if params.Type != lsp.Warning || params.Message != "NOTICE: rows checked" {
+			t.Errorf("unexpected log message, got %+v", params)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notice is not logged")
+	}
+
+	var results []*queryResult
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri, "-format=json"},
+	}, &results); err != nil {
+		t.Fatal("executeQuery json:", err)
+	}
+	if len(results) != 1 || !reflect.DeepEqual(results[0].Stats.Warnings, []string{"NOTICE: rows checked"}) {
+		t.Errorf("unexpected json results, got %+v", results)
+	}
+}
+
+func Test_executeCopyFromStdin(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Version:    0,
+			Text:       "COPY item FROM STDIN WITH (FORMAT csv);",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	execute := func(command string, args ...interface{}) error {
+		var got interface{}
+		return tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: append([]interface{}{uri}, args...),
+		}, &got)
+	}
+
+	copyFile := filepath.Join(t.TempDir(), "item.csv")
+	if err := os.WriteFile(copyFile, []byte("1,apple\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := execute(CommandExecuteQuery, "-copy-file="+copyFile)
+	if err == nil || !strings.Contains(err.Error(), "not supported by sqlite3") {
+		t.Errorf("COPY FROM STDIN must not be sent to sqlite3, got %v", err)
+	}
+	if err := execute(CommandBeginTransaction); err != nil {
+		t.Fatal("begin:", err)
+	}
+	err = execute(CommandExecuteQuery, "-copy-file="+copyFile)
+	if err == nil || !strings.Contains(err.Error(), "supported only by postgresql") {
+		t.Errorf("COPY FROM STDIN in transaction must fail on sqlite3, got %v", err)
+	}
+	if err := execute(CommandRollbackTransaction); err != nil {
+		t.Fatal("rollback:", err)
+	}
+}
+
+func Test_executeStatementConnection(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	dir := t.TempDir()
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:          "main",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(dir, "main.db"),
+			},
+			{
+				Alias:          "analytics",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(dir, "analytics.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Version:    0,
+			Text:       "",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	execute := func(text string, command string) (interface{}, error) {
+		didChangeParams := lsp.DidChangeTextDocumentParams{
+			TextDocument: lsp.VersionedTextDocumentIdentifier{URI: uri},
+			ContentChanges: []lsp.TextDocumentContentChangeEvent{
+				{Text: text},
+			},
+		}
+		if err := tx.conn.Call(tx.ctx, "textDocument/didChange", didChangeParams, nil); err != nil {
+			t.Fatal("conn.Call textDocument/didChange:", err)
+		}
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: []interface{}{uri},
+		}, &got)
+		return got, err
+	}
+
+	got, err := execute("CREATE TABLE item (id int);\n"+
+		"-- sqls: connection=analytics\n"+
+		"CREATE TABLE report (id int);\n"+
+		"-- name: CountReports :one\n"+
+		"-- sqls: connection=analytics\n"+
+		"SELECT count(*) AS reports FROM report;\n"+
+		"SELECT count(*) AS items FROM item;", CommandExecuteQuery)
+	if err != nil {
+		t.Fatal("execute:", err)
+	}
+	for _, column := range []string{"reports", "items"} {
+		if !strings.Contains(strings.ToLower(fmt.Sprint(got)), column) {
+			t.Errorf("the result of %s is not found in %v", column, got)
+		}
+	}
+
+	if _, err := execute("-- sqls: connection=analytics\nSELECT * FROM item;", CommandExecuteQuery); err == nil {
+		t.Error("the table of the main connection is found on analytics")
+	}
+	if _, err := execute("-- sqls: connection=unknown\nSELECT 1;", CommandExecuteQuery); err == nil || !strings.Contains(err.Error(), "connection not found") {
+		t.Errorf("the unknown connection must not be found, got %v", err)
+	}
+	if _, err := execute("", CommandBeginTransaction); err != nil {
+		t.Fatal("begin:", err)
+	}
+	_, err = execute("-- sqls: connection=analytics\nSELECT * FROM report;", CommandExecuteQuery)
+	if err == nil || !strings.Contains(err.Error(), "in the transaction") {
+		t.Errorf("the statement on another connection must not run in the transaction, got %v", err)
+	}
+	if _, err := execute("", CommandRollbackTransaction); err != nil {
+		t.Fatal("rollback:", err)
+	}
+}
+
+func Test_fileConnectionComment(t *testing.T) {
+	tx := newTestContext()
+	published := make(chan *lsp.PublishDiagnosticsParams, 10)
+	tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "textDocument/publishDiagnostics" {
+			var params lsp.PublishDiagnosticsParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			published <- &params
+		}
+		return nil, nil
+	})
+	tx.setup(t)
+	defer tx.tearDown()
+
+	dir := t.TempDir()
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:          "main",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(dir, "main.db"),
+			},
+			{
+				Alias:          "analytics",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(dir, "analytics.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	tx.textDocumentDidOpen(t, uri, "")
+	execute := func(text string) (interface{}, error) {
+		tx.setText(t, uri, text)
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   CommandExecuteQuery,
+			Arguments: []interface{}{uri},
+		}, &got)
+		return got, err
+	}
+
+	// The comment at the top of the file is of all the statements
+	if _, err := execute("-- conn: analytics\n\n" +
+		"CREATE TABLE report (id int, total int);\n" +
+		"CREATE TABLE summary (id int);\n" +
+		"INSERT INTO report VALUES (1, 2);"); err != nil {
+		t.Fatal("execute:", err)
+	}
+	got, err := execute("-- conn: analytics\n\nSELECT 1;\nSELECT total AS totals FROM report;")
+	if err != nil {
+		t.Fatal("execute:", err)
+	}
+	if !strings.Contains(strings.ToLower(fmt.Sprint(got)), "totals") {
+		t.Errorf("the result of the second statement is not found in %v", got)
+	}
+	if _, err := execute("SELECT 1;\n-- conn: main\nSELECT * FROM report;"); err == nil {
+		t.Error("the table of analytics is found on main")
+	}
+
+	// The statement of the comment is completed with the tables of its
+	// connection
+	complete := func(text string, pos lsp.Position) []lsp.CompletionItem {
+		tx.setText(t, uri, text)
+		var items []lsp.CompletionItem
+		err := tx.conn.Call(tx.ctx, "textDocument/completion", lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+				Position:     pos,
+			},
+		}, &items)
+		if err != nil {
+			t.Fatal("conn.Call textDocument/completion:", err)
+		}
+		return items
+	}
+	testCompletionItem(t, []string{"total"}, nil,
+		complete("SELECT 1;\n-- conn: analytics\nSELECT  FROM report", lsp.Position{Line: 2, Character: 7}))
+	testCompletionItem(t, nil, []string{"total"},
+		complete("SELECT  FROM report", lsp.Position{Line: 0, Character: 7}))
+
+	// The statement of the comment is validated with the tables of its
+	// connection
+	ambiguous := func(uri, text string) bool {
+		t.Helper()
+		tx.textDocumentDidOpen(t, uri, text)
+		for {
+			select {
+			case p := <-published:
+				if p.URI != uri {
+					continue
+				}
+				for _, d := range p.Diagnostics {
+					if *d.Code == config.LintRuleAmbiguousColumn {
+						return true
+					}
+				}
+				return false
+			case <-time.After(time.Second):
+				t.Fatal("diagnostics are not published")
+			}
+		}
+	}
+	if !ambiguous("file:///analytics.sql", "SELECT 1;\n-- conn: analytics\nSELECT id FROM report, summary;") {
+		t.Error("the ambiguous column of the tables of analytics is not found")
+	}
+	if ambiguous("file:///main.sql", "SELECT id FROM report, summary;") {
+		t.Error("the ambiguous column is found on main")
+	}
+}
+
+func Test_switchConnection(t *testing.T) {
+	tx := newTestContext()
+	notifications := make(chan *lsp.ActiveConnectionParams, 10)
+	tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "sqls/activeConnection" {
+			var params lsp.ActiveConnectionParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			notifications <- &params
+		}
+		return nil, nil
+	})
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:  "first",
+				Driver: "mock",
+				DBName: "world",
+			},
+			{
+				Alias:          "second",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+	waitNotification := func() *lsp.ActiveConnectionParams {
+		t.Helper()
+		select {
+		case n := <-notifications:
+			return n
+		case <-time.After(time.Second):
+			t.Fatal("active connection is not notified")
+		}
+		return nil
+	}
+	if got := waitNotification(); got.Index != 1 || got.Alias != "first" {
+		t.Errorf("unexpected active connection, %+v", got)
+	}
+
+	execute := func(command string, args ...interface{}) (string, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: args,
+		}, &got)
+		res, _ := got.(string)
+		return res, err
+	}
+
+	if _, err := execute(CommandSwitchDatabase, "city"); err != nil {
+		t.Fatal("switch database:", err)
+	}
+	if got := waitNotification(); got.Index != 1 || got.Database != "city" {
+		t.Errorf("unexpected active connection, %+v", got)
+	}
+
+	if _, err := execute(CommandSwitchConnectionAlias, "second"); err != nil {
+		t.Fatal("switch connection:", err)
+	}
+	want := &lsp.ActiveConnectionParams{Index: 2, Alias: "second", Driver: "sqlite3"}
+	if got := waitNotification(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got, err := execute(CommandListConnections)
+	if err != nil {
+		t.Fatal("list connections:", err)
+	}
+	if !strings.HasPrefix(got, "  1 mock first") || !strings.Contains(got, "\n* 2 sqlite3 second") {
+		t.Errorf("unexpected connections, got %q", got)
+	}
+
+	if _, err := execute(CommandSwitchConnection, float64(1)); err != nil {
+		t.Fatal("switch connection:", err)
+	}
+	// the database switched before belongs to the other connection
+	if got := waitNotification(); got.Index != 1 || got.Database != "world" {
+		t.Errorf("unexpected active connection, %+v", got)
+	}
+	if _, err := execute(CommandSwitchConnection, "3"); err == nil {
+		t.Error("switching to missing connection must fail")
+	}
+}
+
+func TestSwitchConnectionInTransaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		canAsk     bool
+		choice     string
+		wantSwitch bool
+	}{
+		{
+			name: "cannot ask",
+		},
+		{
+			name:   "cancelled",
+			canAsk: true,
+			choice: switchCancel,
+		},
+		{
+			name:       "rolled back",
+			canAsk:     true,
+			choice:     switchRollback,
+			wantSwitch: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer()
+			tx := &TestContext{
+				h:      server.Handler(),
+				ctx:    context.Background(),
+				server: server,
+			}
+			if tt.canAsk {
+				tx.initParams.Capabilities.Window = &lsp.WindowClientCapabilities{
+					ShowMessage: &lsp.ShowMessageRequestClientCapabilities{},
+				}
+			}
+			tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+				if req.Method == "window/showMessageRequest" {
+					return lsp.MessageActionItem{Title: tt.choice}, nil
+				}
+				return nil, nil
+			})
+			tx.setup(t)
+			defer tx.tearDown()
+			defer server.Stop()
+
+			dir := t.TempDir()
+			tx.addWorkspaceConfig(t, &config.Config{
+				Connections: []*database.DBConfig{
+					{
+						Alias:          "first",
+						Driver:         "sqlite3",
+						DataSourceName: filepath.Join(dir, "first.db"),
+					},
+					{
+						Alias:          "second",
+						Driver:         "sqlite3",
+						DataSourceName: filepath.Join(dir, "second.db"),
+					},
+				},
+			})
+			uri := "file:///transaction.sql"
+			tx.textDocumentDidOpen(t, uri, "SELECT 1;")
+			execute := func(command string, args ...interface{}) error {
+				var got interface{}
+				return tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+					Command:   command,
+					Arguments: args,
+				}, &got)
+			}
+			if err := execute(CommandBeginTransaction, uri); err != nil {
+				t.Fatal("begin:", err)
+			}
+
+			err := execute(CommandSwitchConnectionAlias, "second")
+			if (err == nil) != tt.wantSwitch {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			if _, ok := tx.server.transactions[uri]; ok == tt.wantSwitch {
+				t.Errorf("transaction is open %v, want %v", ok, !tt.wantSwitch)
+			}
+			want := "first"
+			if tt.wantSwitch {
+				want = "second"
+			}
+			if got := tx.server.curDBCfg.Alias; got != want {
+				t.Errorf("unexpected connection %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+type testKeyring map[string]string
+
+func (k testKeyring) Get(service, account string) (string, error) {
+	password, ok := k[service+":"+account]
+	if !ok {
+		return "", database.ErrPasswordNotStored
+	}
+	return password, nil
+}
+
+func (k testKeyring) Set(service, account, password string) error {
+	k[service+":"+account] = password
+	return nil
+}
+
+func Test_showERDiagram(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	})
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Version:    0,
+			Text:       "SELECT * FROM city AS ci JOIN country AS co ON ci.CountryCode = co.Code;",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	execute := func(args ...interface{}) (lsp.TextDocumentItem, error) {
+		var got lsp.TextDocumentItem
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   CommandShowERDiagram,
+			Arguments: args,
+		}, &got)
+		return got, err
+	}
+
+	got, err := execute(uri)
+	if err != nil {
+		t.Fatal("show er diagram:", err)
+	}
+	if got.LanguageID != "mermaid" || got.URI != "sqls:/er-diagram.mmd" {
+		t.Errorf("unexpected document, %q %q", got.URI, got.LanguageID)
+	}
+	for _, want := range []string{"erDiagram\n", "    city {\n", "    country {\n", "        int(11) ID PK\n", "        enum Continent\n", "    country ||--o{ city : \"CountryCode\"\n"} {
+		if !strings.Contains(got.Text, want) {
+			t.Errorf("diagram does not contain %q:\n%s", want, got.Text)
+		}
+	}
+	if strings.Contains(got.Text, "countrylanguage") {
+		t.Errorf("diagram contains the table not in the query:\n%s", got.Text)
+	}
+
+	got, err = execute("-format=plantuml")
+	if err != nil {
+		t.Fatal("show er diagram:", err)
+	}
+	if got.LanguageID != "plantuml" || !strings.Contains(got.Text, "entity \"countrylanguage\" {") {
+		t.Errorf("unexpected diagram of the schema, %q:\n%s", got.LanguageID, got.Text)
+	}
+
+	if _, err := execute(uri, "-format=svg"); err == nil {
+		t.Error("unsupported format must fail")
+	}
+}
+
+func Test_diffSchemas(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "staging.db")
+	dumpPath := filepath.Join(dir, "schema.sql")
+	staging := &database.DBConfig{
+		Alias:          "staging",
+		Driver:         "sqlite3",
+		DataSourceName: dbPath,
+	}
+	dbConn, err := database.Open(staging)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbConn.Conn.Exec("CREATE TABLE item (id INTEGER NOT NULL, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	dbConn.Close()
+	dump := "CREATE TABLE item (id INTEGER NOT NULL, name TEXT, price INTEGER);\nCREATE TABLE tag (id INTEGER);\n"
+	if err := os.WriteFile(dumpPath, []byte(dump), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "prod", Driver: "mock"},
+			staging,
+		},
+	})
+	execute := func(args ...interface{}) (lsp.TextDocumentItem, error) {
+		var got lsp.TextDocumentItem
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   CommandDiffSchemas,
+			Arguments: args,
+		}, &got)
+		return got, err
+	}
+
+	got, err := execute(dumpPath, "staging")
+	if err != nil {
+		t.Fatal("diff schemas:", err)
+	}
+	want := `# Schema diff of schema.sql and staging
+
+## Tables missing in staging
+
+- tag
+
+## Columns
+
+| Table | Column | schema.sql | staging |
+|-------|--------|---|---|
+| item | price | INTEGER | missing |
+`
+	if got.LanguageID != "markdown" || got.Text != want {
+		t.Errorf("unexpected report, %q:\n%s", got.LanguageID, got.Text)
+	}
+
+	got, err = execute("staging")
+	if err != nil {
+		t.Fatal("diff schemas with current connection:", err)
+	}
+	for _, want := range []string{"# Schema diff of prod and staging\n", "- city\n", "- item\n"} {
+		if !strings.Contains(got.Text, want) {
+			t.Errorf("report does not contain %q:\n%s", want, got.Text)
+		}
+	}
+
+	if _, err := execute("prod", "unknown"); err == nil {
+		t.Error("unknown connection must fail")
+	}
+}
+
+func Test_exportDataDictionary(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Alias: "world", Driver: "mock"},
+		},
+	})
+	execute := func(args ...interface{}) (interface{}, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   CommandExportDataDictionary,
+			Arguments: args,
+		}, &got)
+		return got, err
+	}
+
+	got, err := execute()
+	if err != nil {
+		t.Fatal("export data dictionary:", err)
+	}
+	doc, ok := got.(map[string]interface{})
+	if !ok || doc["uri"] != "sqls:/data-dictionary.md" || doc["languageId"] != "markdown" {
+		t.Fatalf("unexpected document, %v", got)
+	}
+	text, _ := doc["text"].(string)
+	for _, want := range []string{"# Data dictionary of world\n", "# `city` table\n", "- `city.CountryCode -> country.Code`\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("data dictionary does not contain %q:\n%s", want, text)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "dictionary.html")
+	if _, err := execute(path); err != nil {
+		t.Fatal("export data dictionary to file:", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "<h2 id=\"country\"><code>country</code> table</h2>") {
+		t.Errorf("unexpected html data dictionary:\n%s", b)
+	}
+
+	if _, err := execute("-format=pdf"); err == nil {
+		t.Error("unsupported format must fail")
+	}
+}
+
+func Test_storePassword(t *testing.T) {
+	keyring := testKeyring{}
+	orig := database.SystemKeyring
+	database.SystemKeyring = keyring
+	defer func() { database.SystemKeyring = orig }()
+
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:          "local",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+			{
+				Alias:       "prod",
+				Driver:      "mysql",
+				Proto:       database.ProtoTCP,
+				User:        "root",
+				Host:        "127.0.0.1",
+				PasswdStore: database.PasswordStoreKeyring,
+			},
+		},
+	})
+
+	execute := func(args ...interface{}) error {
+		return tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   CommandStorePassword,
+			Arguments: args,
+		}, nil)
+	}
+	if err := execute("secret", "prod"); err != nil {
+		t.Fatal("store password:", err)
+	}
+	if got := keyring["sqls:prod"]; got != "secret" {
+		t.Errorf("stored password = %q, want %q", got, "secret")
+	}
+	// the current connection does not use the keyring
+	if err := execute("secret"); err == nil {
+		t.Error("storing the password of the connection without passwordStore must fail")
+	}
+	if err := execute(); err == nil {
+		t.Error("storing without the password must fail")
+	}
+}
+
 func Test_extractRangeText(t *testing.T) {
 	type args struct {
 		text      string
@@ -110,3 +1410,42 @@ func Test_extractRangeText(t *testing.T) {
 		})
 	}
 }
+
+func Test_statementQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "semicolons",
+			text: "select 1;\nselect 'a;b'",
+			want: []string{"select 1;", "select 'a;b'"},
+		},
+		{
+			name: "delimiter directives",
+			text: "DELIMITER //\ncreate procedure p() begin select 1; end //\nDELIMITER ;\ncall p();",
+			want: []string{"", "create procedure p() begin select 1; end", "", "call p();"},
+		},
+		{
+			name: "procedure block",
+			text: "create procedure p() begin select 1; select 2; end;\nselect 3;",
+			want: []string{"create procedure p() begin select 1; select 2; end;", "select 3;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, err := getStatements(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, stmt := range stmts {
+				got = append(got, statementQuery(stmt))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+const (
+	rowsFlag            = "-rows="
+	seedFlag            = "-seed="
+	defaultFakeDataRows = 10
+)
+
+// generateFakeData returns the INSERT statements of the rows of the fake data
+// of the table and the tables it refers to, as a document to review, or runs
+// them with -execute. -rows=<rows> is the number of the rows of each table,
+// and -seed=<seed> generates the same rows again.
+func (s *Server) generateFakeData(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) == 0 {
+		return nil, fmt.Errorf("required arguments were not provided: <Table>")
+	}
+	table, ok := params.Arguments[0].(string)
+	if !ok || table == "" {
+		return nil, fmt.Errorf("specify the table as a string")
+	}
+	rows, seed, execute := defaultFakeDataRows, time.Now().UnixNano(), false
+	for _, arg := range params.Arguments[1:] {
+		flag, ok := arg.(string)
+		if !ok {
+			continue
+		}
+		switch {
+		case flag == "-execute":
+			execute = true
+		case strings.HasPrefix(flag, rowsFlag):
+			rows, err = strconv.Atoi(strings.TrimPrefix(flag, rowsFlag))
+			if err != nil || rows < 1 {
+				return nil, fmt.Errorf("invalid number of rows, %s", flag)
+			}
+		case strings.HasPrefix(flag, seedFlag):
+			seed, err = strconv.ParseInt(strings.TrimPrefix(flag, seedFlag), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid seed, %s", flag)
+			}
+		}
+	}
+
+	cache := s.worker.Cache()
+	if cache == nil {
+		return nil, errors.New("the tables of the database are not read yet")
+	}
+	stmts, err := database.FakeInserts(cache, s.curDBCfg.Driver, table, rows, seed)
+	if err != nil {
+		return nil, err
+	}
+	if !execute {
+		return lsp.TextDocumentItem{
+			URI:        "sqls:/fake-" + table + ".sql",
+			LanguageID: "sql",
+			Text:       strings.Join(stmts, ";\n\n") + ";\n",
+		}, nil
+	}
+
+	repo, err := s.newDBRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var inserted int64
+	for _, stmt := range stmts {
+		_, count, _, err := s.exec(ctx, repo, stmt, false)
+		if err != nil {
+			return nil, fmt.Errorf("cannot insert fake data after %d rows, %w", inserted, err)
+		}
+		inserted += count
+	}
+	return fmt.Sprintf("Inserted %d rows of fake data", inserted), nil
+}
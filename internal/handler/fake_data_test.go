@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func Test_generateFakeData(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	})
+	execute := func(args ...interface{}) (interface{}, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   CommandGenerateFakeData,
+			Arguments: args,
+		}, &got)
+		return got, err
+	}
+
+	got, err := execute("city", "-rows=3", "-seed=1")
+	if err != nil {
+		t.Fatal("generate fake data:", err)
+	}
+	doc, _ := got.(map[string]interface{})
+	text, _ := doc["text"].(string)
+	if doc["uri"] != "sqls:/fake-city.sql" || !strings.HasPrefix(text, "INSERT INTO country (") || !strings.Contains(text, ";\n\nINSERT INTO city (") {
+		t.Fatalf("the countries must be inserted before the cities, %v", got)
+	}
+	again, err := execute("city", "-rows=3", "-seed=1")
+	if err != nil {
+		t.Fatal("generate fake data:", err)
+	}
+	if again.(map[string]interface{})["text"] != text {
+		t.Errorf("the rows of the same seed must be the same, %v", again)
+	}
+
+	if _, err := execute("missing"); err == nil {
+		t.Error("missing table must fail")
+	}
+	if _, err := execute("city", "-rows=0"); err == nil {
+		t.Error("invalid rows must fail")
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/sqls-server/sqls/internal/database"
+)
+
+// connectionKey is the connection of a workspace folder by its index.
+type connectionKey struct {
+	root  string
+	index int
+}
+
+// keptConnection is the connection of the documents mapped to another
+// connection than the current one, kept open with its cache and its
+// transactions to switch back to it without reconnecting.
+type keptConnection struct {
+	cfg    *database.DBConfig
+	dbName string
+	conn   *database.DBConnection
+	worker *database.Worker
+	// own is whether the worker is of the connection, not shared by Registry
+	own bool
+}
+
+func (kc *keptConnection) close() error {
+	if kc.own {
+		kc.worker.Stop()
+	}
+	return kc.conn.Close()
+}
+
+// keepConnection keeps the current connection for the documents mapped to
+// it, and leaves the server without a connection until another is used.
+func (s *Server) keepConnection() {
+	s.discardWarmup()
+	if s.dbConn == nil {
+		return
+	}
+	s.health.watch(nil, nil)
+	key := connectionKey{root: s.rootPath, index: s.curConnectionIndex}
+	if kc, ok := s.keptConns[key]; ok {
+		// Opened again by switchConnection, which the transactions prevent
+		kc.close()
+	}
+	kc := &keptConnection{
+		cfg:    s.curDBCfg,
+		dbName: s.curDBName,
+		conn:   s.dbConn,
+		worker: s.worker,
+		own:    s.worker == s.ownWorker,
+	}
+	s.keptConns[key] = kc
+	if kc.own {
+		s.ownWorker = database.NewWorker()
+		s.ownWorker.Start()
+	}
+	s.dbConn, s.worker = nil, s.ownWorker
+}
+
+// useKeptConnection makes the connection kept for the current one the
+// connection of the server. Without it the connection is opened if the server
+// was connected, or by the first request that needs the database.
+func (s *Server) useKeptConnection(ctx context.Context, conn *jsonrpc2.Conn, connected bool) error {
+	if s.dbConn != nil {
+		return nil
+	}
+	key := connectionKey{root: s.rootPath, index: s.curConnectionIndex}
+	kc, ok := s.keptConns[key]
+	if !ok {
+		if !connected {
+			return nil
+		}
+		if err := s.openDBConnection(ctx); err != nil {
+			return err
+		}
+		return s.notifyActiveConnection(ctx, conn)
+	}
+	delete(s.keptConns, key)
+	if kc.own {
+		s.ownWorker.Stop()
+		s.ownWorker = kc.worker
+	}
+	s.dbConn, s.worker = kc.conn, kc.worker
+	s.curDBCfg, s.curDBName = kc.cfg, kc.dbName
+	s.health.watch(s.dbConn, s.curDBCfg)
+	return s.notifyActiveConnection(ctx, conn)
+}
+
+// closeKeptConnections closes the connections kept for the workspace folders
+// for which the function returns true.
+func (s *Server) closeKeptConnections(match func(root string) bool) {
+	for key, kc := range s.keptConns {
+		if !match(key.root) {
+			continue
+		}
+		if err := kc.close(); err != nil {
+			slog.Warn("cannot close kept connection", "root", key.root, "index", key.index, "err", err)
+		}
+		delete(s.keptConns, key)
+	}
+}
@@ -4,10 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/sourcegraph/jsonrpc2"
+	"github.com/sqls-server/sqls/dialect"
 	"github.com/sqls-server/sqls/internal/formatter"
 	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/internal/template"
 )
 
 func (s *Server) handleTextDocumentFormatting(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
@@ -20,12 +27,12 @@ func (s *Server) handleTextDocumentFormatting(ctx context.Context, conn *jsonrpc
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	textEdits, err := formatter.Format(f.Text, params, s.getConfig())
+	textEdits, err := s.formatEdits(ctx, f, params)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +42,114 @@ func (s *Server) handleTextDocumentFormatting(ctx context.Context, conn *jsonrpc
 	return nil, nil
 }
 
+// formatEdits returns the edits formatting the document by the config.
+func (s *Server) formatEdits(ctx context.Context, f *File, params lsp.DocumentFormattingParams) ([]lsp.TextEdit, error) {
+	// The SQL embedded in the strings of the code is not formatted
+	if template.IsHostLanguage(f.LanguageID) {
+		return nil, nil
+	}
+	// The templates are formatted as the placeholders, and restored
+	tmpl := s.templateText(f, false)
+	var textEdits []lsp.TextEdit
+	var err error
+	if ext := s.getConfig().ExternalFormatter; ext != nil {
+		textEdits, err = formatter.FormatExternal(ctx, tmpl.Text, ext, s.formatDir(params.TextDocument.URI))
+	} else {
+		textEdits, err = formatter.Format(tmpl.Text, params, s.getConfig(), s.documentDriver())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.RestoreEdits(textEdits)
+}
+
+// FormatText returns the text of the file formatted as the formatting
+// requests of the documents do, for the format subcommand. The file is "-"
+// for stdin, whose external formatter runs in the workspace root.
+func (s *Server) FormatText(ctx context.Context, fpath, text string) (_ string, err error) {
+	defer recoverError(&err, "formatting "+fpath)
+	if strings.TrimSpace(text) == "" {
+		return text, nil
+	}
+	uri, err := fileURI(fpath)
+	if err != nil {
+		return "", err
+	}
+	params := lsp.DocumentFormattingParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Options:      lsp.FormattingOptions{InsertSpaces: true},
+	}
+	edits, err := s.formatEdits(ctx, &File{LanguageID: "sql", Text: text}, params)
+	if err != nil {
+		return "", err
+	}
+	formatted := applyTextEdits(text, edits)
+	// The files keep their final newlines, which the statements do not have
+	if strings.HasSuffix(text, "\n") && !strings.HasSuffix(formatted, "\n") {
+		formatted += "\n"
+	}
+	return formatted, nil
+}
+
+// fileURI returns the URI of the file of the subcommands, empty for stdin by
+// "-".
+func fileURI(fpath string) (string, error) {
+	if fpath == "-" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(fpath)
+	if err != nil {
+		return "", err
+	}
+	return "file://" + filepath.ToSlash(abs), nil
+}
+
+// OpenWorkspace loads the config and the projects of the workspace of the
+// root, as initialize does for the workspace of the client.
+func (s *Server) OpenWorkspace(root string) error {
+	s.rootPath = root
+	if err := s.loadWorkspaceConfig(); err != nil {
+		return err
+	}
+	s.loadProjects()
+	return nil
+}
+
+// applyTextEdits returns the text with the edits, which do not overlap.
+func applyTextEdits(text string, edits []lsp.TextEdit) string {
+	sorted := append([]lsp.TextEdit{}, edits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return before(sorted[j].Range.Start, sorted[i].Range.Start)
+	})
+	for _, edit := range sorted {
+		start := positionOffset(text, edit.Range.Start)
+		end := positionOffset(text, edit.Range.End)
+		if end < start {
+			end = start
+		}
+		text = text[:start] + edit.NewText + text[end:]
+	}
+	return text
+}
+
+// positionOffset returns the byte offset of the position, whose character is
+// of the runes.
+func positionOffset(text string, pos lsp.Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	for col := 0; col < pos.Character && offset < len(text) && text[offset] != '\n'; col++ {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	return offset
+}
+
 func (s *Server) handleTextDocumentRangeFormatting(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -45,7 +160,7 @@ func (s *Server) handleTextDocumentRangeFormatting(ctx context.Context, conn *js
 		return nil, err
 	}
 
-	_, ok := s.files[params.TextDocument.URI]
+	_, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
@@ -56,3 +171,29 @@ func (s *Server) handleTextDocumentRangeFormatting(ctx context.Context, conn *js
 	}
 	return nil, nil
 }
+
+// documentDriver returns the driver of the connection in use, or the one of
+// the connection to use if it is not open yet, or the engine of the sqlc
+// project, for the dialect of formatting and linting.
+func (s *Server) documentDriver() dialect.DatabaseDriver {
+	if s.dbConn != nil {
+		return s.dbConn.Driver
+	}
+	if connCfg, err := s.connectionConfig(); err == nil {
+		return connCfg.Driver
+	}
+	if s.sqlcProject != nil {
+		return s.sqlcProject.Driver()
+	}
+	return ""
+}
+
+// formatDir returns the directory of the document, or the workspace root if
+// the document is not a file, where the external formatter finds its config.
+func (s *Server) formatDir(uri string) string {
+	u, err := url.Parse(uri)
+	if err == nil && u.Scheme == "file" {
+		return filepath.Dir(filepath.FromSlash(u.Path))
+	}
+	return s.rootPath
+}
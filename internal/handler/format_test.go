@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -179,6 +180,30 @@ func TestFormattingWithOptionUpper(t *testing.T) {
 	testFormatting(t, testCase, formattingOptionTab, upperCaseConfig)
 }
 
+func TestFormattingTemplate(t *testing.T) {
+	testCases := []formattingTestCase{
+		{
+			name:  "jinja",
+			input: "{{ config(materialized='view') }}\nselect id,   {{ col }} from {{ ref('city') }} where  {% if x %}id = 1{% else %}id = 2{% endif %}",
+			want:  "{{ config(materialized='view') }}\nSELECT\n\tid,\n\t{{ col }}\nFROM\n\t{{ ref('city') }}\nWHERE\n\t{% if x %} id = 1 {% else %}id = 2 {% endif %}",
+		},
+	}
+	testFormatting(t, testCases, formattingOptionTab, &config.Config{
+		Template: &config.Template{Engine: config.TemplateEngineJinja, ResolveRefs: true},
+	})
+
+	printfCases := []formattingTestCase{
+		{
+			name:  "printf",
+			input: "select %s,  id from   city where id = %d and name like '%%a'",
+			want:  "SELECT\n\t%s,\n\tid\nFROM\n\tcity\nWHERE\n\tid = %d\n\tAND name LIKE '%%a'",
+		},
+	}
+	testFormatting(t, printfCases, formattingOptionTab, &config.Config{
+		Template: &config.Template{Engine: config.TemplateEnginePrintf},
+	})
+}
+
 func loadFormatTestCaseByTestdata(targetDir string) ([]formattingTestCase, error) {
 	packageDir, err := os.Getwd()
 	if err != nil {
@@ -222,3 +247,47 @@ func loadFormatTestCaseByTestdata(targetDir string) ([]formattingTestCase, error
 	}
 	return testCase, nil
 }
+
+func TestFormatText(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".sqls"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".sqls", "config.yml"), []byte("lowercaseKeywords: true\nindentWidth: 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	defer server.worker.Stop()
+	if err := server.OpenWorkspace(root); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []formattingTestCase{
+		{
+			name:  "statements",
+			input: "select id,name from city where name = 'Ōsaka';\nselect 1;\n",
+			want:  "select\n  id,\n  name\nfrom\n  city\nwhere\n  name = 'Ōsaka';\nselect\n  1;\n",
+		},
+		{
+			name:  "without final newline",
+			input: "select   1",
+			want:  "select\n  1",
+		},
+		{
+			name:  "empty",
+			input: "\n",
+			want:  "\n",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.FormatText(context.Background(), filepath.Join(root, "query.sql"), tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmatched value: (-want +got)\n%s", diff)
+			}
+		})
+	}
+}
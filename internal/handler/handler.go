@@ -5,14 +5,25 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
-	"runtime"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sourcegraph/jsonrpc2"
-
+	"github.com/sqls-server/sqls/internal/bookmark"
 	"github.com/sqls-server/sqls/internal/config"
 	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/dbt"
+	"github.com/sqls-server/sqls/internal/history"
+	"github.com/sqls-server/sqls/internal/logging"
 	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/internal/metrics"
+	"github.com/sqls-server/sqls/internal/migration"
+	"github.com/sqls-server/sqls/internal/sqlc"
 )
 
 var (
@@ -20,9 +31,22 @@ var (
 )
 
 type Server struct {
-	SpecificFileCfg *config.Config
-	DefaultFileCfg  *config.Config
-	WSCfg           *config.Config
+	SpecificFileCfg  *config.Config
+	DefaultFileCfg   *config.Config
+	WSCfg            *config.Config
+	WorkspaceFileCfg *config.Config
+
+	// sqlfluffCfg is the config of sqlfluff in the workspace, which sets what
+	// the configs of sqls do not set
+	sqlfluffCfg *config.Sqlfluff
+
+	// rootPath is the local path of the workspace root, which is the path
+	// of curFolder in a multi-root workspace
+	rootPath string
+	// folders are the workspace folders of a multi-root workspace, each with
+	// its own config
+	folders   []*workspaceFolder
+	curFolder *workspaceFolder
 
 	dbConn *database.DBConnection
 
@@ -36,13 +60,77 @@ type Server struct {
 	// other configuration sources (workspace and user).
 	initOptionDBConfig *database.DBConfig
 
-	worker *database.Worker
-	files  map[string]*File
-}
-
-type File struct {
-	LanguageID string
-	Text       string
+	// worker has the cache of the connection, which is ownWorker unless the
+	// connection is shared by Registry
+	worker    *database.Worker
+	ownWorker *database.Worker
+	// Registry shares the connections and their caches with the other
+	// sessions of the process if it is set
+	Registry *database.Registry
+
+	files *documentStore
+
+	// transactions holds the open transaction of each document
+	transactions map[string]*database.Transaction
+	// statementConns are the connections of the statements given by the
+	// comments such as "-- conn: analytics" by their aliases, which complete
+	// and validate the statements
+	statementConns map[string]*statementConnection
+	// keptConns are the connections of the documents mapped to the other
+	// connections than the current one by fileConnections
+	keptConns map[connectionKey]*keptConnection
+
+	// History records the executed queries. It is kept in memory unless a
+	// store with a file path is set.
+	History *history.Store
+
+	// bookmarks holds the named queries of the workspace
+	bookmarks *bookmark.Store
+
+	// notebooks are the statements executed and their results of the
+	// documents by their URIs
+	notebooks map[string]string
+
+	health *healthCheck
+
+	// showMessageRequest is whether the client answers
+	// window/showMessageRequest, by which the values of the placeholders are
+	// asked for
+	showMessageRequest bool
+
+	// trace is the value of $/setTrace, which sends $/logTrace of the
+	// requests served unless it is off
+	trace string
+
+	// warmup is the connection being opened in the background
+	warmup *dbWarmup
+	// lastConnectErr is the last error shown on connecting in the background
+	lastConnectErr string
+
+	// wizard is the addConnection command running in the background
+	wizard connectionWizard
+
+	// queue serves the requests of Handler
+	queue *requestQueue
+	// shutdown is set by shutdown, after which the requests other than exit
+	// are rejected
+	shutdown atomic.Bool
+	// exited is closed by exit
+	exited   chan struct{}
+	exitOnce sync.Once
+
+	// dbtProject and sqlcProject are the projects at the workspace root,
+	// whose relations are merged to the cache of the database
+	dbtProject  *dbt.Project
+	sqlcProject *sqlc.Project
+	// projectCache is the cache merged from projectBaseCache of the worker
+	projectBaseCache *database.DBCache
+	projectCache     *database.DBCache
+	// migrations are the migration files in the workspace, whose tables are
+	// overlaid on the cache in migrationOverlays by the paths of the
+	// documents, empty for the documents other than the migrations
+	migrations        *migration.Migrations
+	migrationOverlays map[string]*migrationOverlay
 }
 
 func NewServer() *Server {
@@ -50,43 +138,69 @@ func NewServer() *Server {
 	worker.Start()
 
 	return &Server{
-		files:  make(map[string]*File),
-		worker: worker,
+		files:          newDocumentStore(),
+		worker:         worker,
+		ownWorker:      worker,
+		transactions:   make(map[string]*database.Transaction),
+		statementConns: make(map[string]*statementConnection),
+		keptConns:      make(map[connectionKey]*keptConnection),
+		History:        history.NewStore(""),
+		bookmarks:      bookmark.NewStore(""),
+		notebooks:      make(map[string]string),
+		health:         newHealthCheck(),
+		exited:         make(chan struct{}),
 	}
 }
 
-func panicf(r interface{}, format string, v ...interface{}) error {
-	if r != nil {
-		// Same as net/http
-		const size = 64 << 10
-		buf := make([]byte, size)
-		buf = buf[:runtime.Stack(buf, false)]
-		id := fmt.Sprintf(format, v...)
-		log.Printf("panic serving %s: %v\n%s", id, r, string(buf))
-		return fmt.Errorf("unexpected panic: %v", r)
+func (s *Server) Stop() error {
+	if s.queue != nil {
+		s.queue.stop()
 	}
-	return nil
+	return s.release()
 }
 
-func (s *Server) Stop() error {
-	if err := s.dbConn.Close(); err != nil {
-		return err
+// recoverError sets the panic recovered to the error, for the entry points
+// of the subcommands, which the malformed statements must not take down.
+func recoverError(err *error, where string) {
+	if perr := logging.Recovered(slog.Default(), recover(), where); perr != nil {
+		*err = perr
 	}
-	s.worker.Stop()
-	return nil
 }
 
 func (s *Server) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
-	// Prevent any uncaught panics from taking the entire server down.
+	// The logger of the request traces its latency and its errors by its ID
+	logger := slog.Default().With("method", req.Method)
+	if !req.Notif {
+		logger = logger.With("id", req.ID.String())
+	}
+	// Prevent any uncaught panics from taking the entire server down, but
+	// answer the request with the error
 	defer func() {
-		if perr := panicf(recover(), "%v", req.Method); perr != nil {
-			err = perr
+		if perr := logging.Recovered(logger, recover(), "serving "+req.Method); perr != nil {
+			result = nil
+			err = &jsonrpc2.Error{Code: jsonrpc2.CodeInternalError, Message: perr.Error()}
 		}
 	}()
+	ctx = logging.WithLogger(ctx, logger)
+	start := time.Now()
+
+	if s.shutdown.Load() && req.Method != "exit" {
+		if req.Notif {
+			return nil, nil
+		}
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidRequest, Message: "server is shutting down"}
+	}
+
+	s.adoptAddedConnection()
 	res, err := s.handle(ctx, conn, req)
+	duration := time.Since(start)
 	if err != nil {
-		log.Printf("error serving, %+v\n", err)
+		logger.Error("error serving", "err", err, "duration", duration)
+	} else {
+		logger.Debug("served", "duration", duration)
 	}
+	metrics.ObserveRequest(req.Method, duration)
+	s.logTrace(ctx, conn, req, duration, err)
 	return res, err
 }
 func (s *Server) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
@@ -99,6 +213,8 @@ func (s *Server) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.
 		return s.handleShutdown(ctx, conn, req)
 	case "exit":
 		return s.handleExit(ctx, conn, req)
+	case "$/setTrace":
+		return s.handleSetTrace(ctx, conn, req)
 	case "textDocument/didOpen":
 		return s.handleTextDocumentDidOpen(ctx, conn, req)
 	case "textDocument/didChange":
@@ -109,14 +225,20 @@ func (s *Server) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.
 		return s.handleTextDocumentDidClose(ctx, conn, req)
 	case "textDocument/completion":
 		return s.handleTextDocumentCompletion(ctx, conn, req)
+	case "completionItem/resolve":
+		return s.handleCompletionItemResolve(ctx, conn, req)
 	case "textDocument/hover":
 		return s.handleTextDocumentHover(ctx, conn, req)
 	case "textDocument/codeAction":
 		return s.handleTextDocumentCodeAction(ctx, conn, req)
+	case "textDocument/codeLens":
+		return s.handleTextDocumentCodeLens(ctx, conn, req)
 	case "workspace/executeCommand":
 		return s.handleWorkspaceExecuteCommand(ctx, conn, req)
 	case "workspace/didChangeConfiguration":
 		return s.handleWorkspaceDidChangeConfiguration(ctx, conn, req)
+	case "workspace/didChangeWorkspaceFolders":
+		return s.handleWorkspaceDidChangeWorkspaceFolders(ctx, conn, req)
 	case "textDocument/formatting":
 		return s.handleTextDocumentFormatting(ctx, conn, req)
 	case "textDocument/rangeFormatting":
@@ -150,7 +272,9 @@ func (s *Server) handleInitialize(ctx context.Context, conn *jsonrpc2.Conn, req
 			TextDocumentSync:   lsp.TDSKFull,
 			HoverProvider:      true,
 			CodeActionProvider: true,
+			CodeLensProvider:   &lsp.CodeLensOptions{},
 			CompletionProvider: &lsp.CompletionOptions{
+				ResolveProvider:   true,
 				TriggerCharacters: []string{"(", "."},
 			},
 			SignatureHelpProvider: &lsp.SignatureHelpOptions{
@@ -164,42 +288,66 @@ func (s *Server) handleInitialize(ctx context.Context, conn *jsonrpc2.Conn, req
 			DocumentFormattingProvider:      true,
 			DocumentRangeFormattingProvider: true,
 			RenameProvider:                  true,
+			Workspace: &lsp.WorkspaceServerCapabilities{
+				WorkspaceFolders: &lsp.WorkspaceFoldersServerCapabilities{
+					Supported:           true,
+					ChangeNotifications: true,
+				},
+			},
 		},
 	}
 
 	s.initOptionDBConfig = params.InitializationOptions.ConnectionConfig
+	s.rootPath = workspacePath(params)
+	s.trace = params.Trace
+	s.showMessageRequest = params.Capabilities.Window != nil && params.Capabilities.Window.ShowMessage != nil
+	s.bookmarks = newBookmarkStore(s.rootPath)
 
-	// Initialize database database connection
-	// NOTE: If no connection is found at this point, it is possible that the connection settings are sent to workspace config, so don't make an error
 	messenger := lsp.NewMessenger(conn)
-	if err := s.reconnectionDB(ctx); err != nil {
-		if !errors.Is(ErrNoConnection, err) {
-			if err := messenger.ShowInfo(ctx, err.Error()); err != nil {
-				log.Println("send info", err.Error())
+	database.DeviceCodePrompt = func(message string) {
+		if err := messenger.ShowInfo(context.Background(), message); err != nil {
+			slog.Warn("cannot send info", "err", err)
+		}
+	}
+	if len(params.WorkspaceFolders) > 0 {
+		for _, err := range s.addFolders(params.WorkspaceFolders) {
+			logging.FromContext(ctx).Warn("cannot load workspace config", "err", err)
+			if err := messenger.ShowError(ctx, err.Error()); err != nil {
 				return nil, err
 			}
-		} else {
-			log.Println("send err", err.Error())
+		}
+		s.activateFolder(s.initialFolder())
+	} else {
+		if err := s.loadWorkspaceConfig(); err != nil {
+			logging.FromContext(ctx).Warn("cannot load workspace config", "err", err)
 			if err := messenger.ShowError(ctx, err.Error()); err != nil {
 				return nil, err
 			}
 		}
+		s.loadProjects()
 	}
+
+	// The database is connected by the first request that needs it, so that
+	// the server responds without waiting for it
+	s.health.start(conn)
 	return result, nil
 }
 
+// handleShutdown releases the connections, after the queries running are
+// canceled by Handler, and responds before the client sends exit.
 func (s *Server) handleShutdown(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
-	if s.dbConn != nil {
-		s.dbConn.Close()
+	s.shutdown.Store(true)
+	if err := s.release(); err != nil {
+		logging.FromContext(ctx).Warn("cannot close database", "err", err)
 	}
 	return nil, nil
 }
 
 func (s *Server) handleExit(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
-	if s.dbConn != nil {
-		s.dbConn.Close()
-	}
-	err = s.Stop()
+	err = s.release()
+	s.exitOnce.Do(func() {
+		close(s.exited)
+	})
 	return nil, err
 }
 
@@ -213,10 +361,11 @@ func (s *Server) handleTextDocumentDidOpen(ctx context.Context, conn *jsonrpc2.C
 		return nil, err
 	}
 
-	if err := s.openFile(params.TextDocument.URI, params.TextDocument.LanguageID); err != nil {
+	s.files.open(params.TextDocument.URI, params.TextDocument.LanguageID, params.TextDocument.Version, params.TextDocument.Text)
+	if err := s.switchFileConnection(ctx, conn, params.TextDocument.URI); err != nil {
 		return nil, err
 	}
-	if err := s.updateFile(params.TextDocument.URI, params.TextDocument.Text); err != nil {
+	if err := s.publishDiagnostics(ctx, conn, params.TextDocument.URI); err != nil {
 		return nil, err
 	}
 	return nil, nil
@@ -232,7 +381,15 @@ func (s *Server) handleTextDocumentDidChange(ctx context.Context, conn *jsonrpc2
 		return nil, err
 	}
 
-	if err := s.updateFile(params.TextDocument.URI, params.ContentChanges[0].Text); err != nil {
+	if _, err := s.files.update(params.TextDocument.URI, params.TextDocument.Version, params.ContentChanges[0].Text); err != nil {
+		if errors.Is(err, errStaleVersion) {
+			// The change is out of order, and the text is of a newer version
+			logging.FromContext(ctx).Debug("ignore change", "err", err)
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := s.publishDiagnostics(ctx, conn, params.TextDocument.URI); err != nil {
 		return nil, err
 	}
 	return nil, nil
@@ -256,6 +413,13 @@ func (s *Server) handleTextDocumentDidSave(ctx context.Context, conn *jsonrpc2.C
 	if err != nil {
 		return nil, err
 	}
+	if s.isProjectFile(params.TextDocument.URI) {
+		s.loadProjects()
+	}
+	s.checkPlans(ctx, params.TextDocument.URI)
+	if err := s.publishDiagnostics(ctx, conn, params.TextDocument.URI); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
@@ -272,30 +436,32 @@ func (s *Server) handleTextDocumentDidClose(ctx context.Context, conn *jsonrpc2.
 	if err := s.closeFile(params.TextDocument.URI); err != nil {
 		return nil, err
 	}
-	return nil, nil
-}
-
-func (s *Server) openFile(uri string, languageID string) error {
-	f := &File{
-		Text:       "",
-		LanguageID: languageID,
+	if err := s.clearDiagnostics(ctx, conn, params.TextDocument.URI); err != nil {
+		return nil, err
 	}
-	s.files[uri] = f
-	return nil
+	return nil, nil
 }
 
 func (s *Server) closeFile(uri string) error {
-	delete(s.files, uri)
+	s.files.close(uri)
+	if t, ok := s.transactions[uri]; ok {
+		delete(s.transactions, uri)
+		if err := t.Rollback(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// updateFile sets the text saved of the document, which is of the version
+// of the document.
 func (s *Server) updateFile(uri string, text string) error {
-	f, ok := s.files[uri]
+	f, ok := s.files.get(uri)
 	if !ok {
 		return fmt.Errorf("document not found: %v", uri)
 	}
-	f.Text = text
-	return nil
+	_, err := s.files.update(uri, f.Version, text)
+	return err
 }
 
 func (s *Server) saveFile(uri string) error {
@@ -310,51 +476,71 @@ func (s *Server) handleWorkspaceDidChangeConfiguration(ctx context.Context, conn
 	}
 	s.WSCfg = params.Settings.SQLS
 
-	// Skip database connection
-	if s.dbConn != nil {
-		return nil, nil
-	}
-
-	// Initialize database database connection
-	messenger := lsp.NewMessenger(conn)
-	if err := s.reconnectionDB(ctx); err != nil {
-		if !errors.Is(ErrNoConnection, err) {
-			if err := messenger.ShowInfo(ctx, err.Error()); err != nil {
-				log.Println("send info", err.Error())
-				return nil, err
-			}
-		} else {
-			log.Println("send err", err.Error())
-			if err := messenger.ShowError(ctx, err.Error()); err != nil {
-				return nil, err
-			}
+	// The rules of the linter may be changed
+	for _, uri := range s.files.uris() {
+		if err := s.publishDiagnostics(ctx, conn, uri); err != nil {
+			return nil, err
 		}
 	}
-
+	// The database is connected by the first request that needs it
 	return nil, nil
 }
 
 func (s *Server) reconnectionDB(ctx context.Context) error {
-	if err := s.dbConn.Close(); err != nil {
+	// Closing the connection would roll back the transactions
+	if err := s.transactionsInProgress(); err != nil {
 		return err
 	}
+	s.closeKeptConnections(func(string) bool { return true })
+	return s.openDBConnection(ctx)
+}
 
-	dbConn, err := s.newDBConnection(ctx)
-	if err != nil {
+// openDBConnection closes the connection if any, and opens the connection of
+// the config.
+func (s *Server) openDBConnection(ctx context.Context) error {
+	s.discardWarmup()
+	s.health.watch(nil, nil)
+	s.closeStatementConnections()
+	s.renewOwnWorker()
+	if err := s.dbConn.Close(); err != nil {
 		return err
 	}
-	s.dbConn = dbConn
-	dbRepo, err := s.newDBRepository(ctx)
+
+	dbConn, worker, err := s.newDBConnection(ctx)
 	if err != nil {
 		return err
 	}
-	if err := s.worker.ReCache(ctx, dbRepo); err != nil {
-		return err
-	}
+	s.dbConn, s.worker = dbConn, worker
+	s.health.watch(s.dbConn, s.curDBCfg)
 	return nil
 }
 
-func (s *Server) newDBConnection(ctx context.Context) (*database.DBConnection, error) {
+// renewOwnWorker stops the worker of the connection not shared, waiting for
+// the cache being updated not to query the connection closed after it, and
+// starts another for the next connection.
+func (s *Server) renewOwnWorker() {
+	if s.worker != s.ownWorker {
+		return
+	}
+	s.ownWorker.Stop()
+	s.ownWorker = database.NewWorker()
+	s.ownWorker.Start()
+	s.worker = s.ownWorker
+}
+
+func (s *Server) newDBConnection(ctx context.Context) (*database.DBConnection, *database.Worker, error) {
+	connCfg, err := s.connectionConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	s.curDBCfg = connCfg
+
+	// Connect database
+	return s.openDB(ctx, connCfg)
+}
+
+// connectionConfig returns the config of the connection to open.
+func (s *Server) connectionConfig() (*database.DBConfig, error) {
 	// Get the most preferred DB connection settings
 	connCfg := s.topConnection()
 	if connCfg == nil {
@@ -367,16 +553,12 @@ func (s *Server) newDBConnection(ctx context.Context) (*database.DBConnection, e
 		return nil, fmt.Errorf("not found database connection config, index %d", s.curConnectionIndex+1)
 	}
 	if s.curDBName != "" {
-		connCfg.DBName = s.curDBName
-	}
-	s.curDBCfg = connCfg
-
-	// Connect database
-	conn, err := database.Open(connCfg)
-	if err != nil {
-		return nil, err
+		// Copy not to overwrite the database of the config
+		c := *connCfg
+		c.DBName = s.curDBName
+		connCfg = &c
 	}
-	return conn, nil
+	return connCfg, nil
 }
 
 func (s *Server) newDBRepository(ctx context.Context) (database.DBRepository, error) {
@@ -402,7 +584,7 @@ func (s *Server) topConnection() *database.DBConfig {
 
 func (s *Server) getConnection(index int) *database.DBConfig {
 	cfg := s.getConfig()
-	if cfg == nil || (index < 0 && len(cfg.Connections) <= index) {
+	if cfg == nil || index < 0 || len(cfg.Connections) <= index {
 		return nil
 	}
 	return cfg.Connections[index]
@@ -413,6 +595,8 @@ func (s *Server) getConfig() *config.Config {
 	switch {
 	case validConfig(s.SpecificFileCfg):
 		cfg = s.SpecificFileCfg
+	case validConfig(s.WorkspaceFileCfg):
+		cfg = s.WorkspaceFileCfg
 	case validConfig(s.WSCfg):
 		cfg = s.WSCfg
 	case validConfig(s.DefaultFileCfg):
@@ -420,9 +604,105 @@ func (s *Server) getConfig() *config.Config {
 	default:
 		cfg = config.NewConfig()
 	}
+	if s.sqlfluffCfg != nil {
+		cfg = cfg.WithSqlfluff(s.sqlfluffCfg)
+	}
 	return cfg
 }
 
+// EffectiveConfig returns the config used by the server, of the file given,
+// the workspace, the client or the user, in the order of the priority.
+func (s *Server) EffectiveConfig() *config.Config {
+	return s.getConfig()
+}
+
+// loadWorkspaceConfig loads the config in the workspace, which overrides the
+// workspace settings of the client and the user config, and the config of
+// sqlfluff in the workspace.
+func (s *Server) loadWorkspaceConfig() error {
+	cfg, sf, err := loadFolderConfig(s.rootPath, s.trustsWorkspace(s.rootPath))
+	s.WorkspaceFileCfg, s.sqlfluffCfg = cfg, sf
+	return err
+}
+
+// trustsWorkspace reports whether the user config given or the default one
+// trusts the workspace of the root to run commands.
+func (s *Server) trustsWorkspace(root string) bool {
+	return s.SpecificFileCfg.TrustsWorkspace(root) || s.DefaultFileCfg.TrustsWorkspace(root)
+}
+
+// loadFolderConfig loads the config of sqls and the config of sqlfluff in the
+// workspace folder of the root, nil if they are not. The commands of the
+// config are removed unless the workspace is trusted, so that opening a
+// repository does not run them.
+func loadFolderConfig(root string, trusted bool) (*config.Config, *config.Sqlfluff, error) {
+	if root == "" {
+		return nil, nil, nil
+	}
+	var sf *config.Sqlfluff
+	if fpath := config.SqlfluffConfigPath(root); fpath != "" {
+		cfg, err := config.LoadSqlfluff(fpath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot load sqlfluff config, %w", err)
+		}
+		sf = cfg
+	}
+	fpath := config.WorkspaceConfigPath(root)
+	if !config.IsFileExist(fpath) {
+		return nil, sf, nil
+	}
+	cfg, err := config.GetConfig(fpath)
+	if err != nil {
+		return nil, sf, fmt.Errorf("cannot load workspace config, %w", err)
+	}
+	if !trusted {
+		if removed := cfg.RemoveCommands(); len(removed) > 0 {
+			return cfg, sf, fmt.Errorf("ignored %s of workspace config, add %s to trustedWorkspaces of the user config to use them", strings.Join(removed, ", "), root)
+		}
+	}
+	return cfg, sf, nil
+}
+
+// switchFileConnection switches to the connection mapped to the document by
+// the fileConnections patterns.
+func (s *Server) switchFileConnection(ctx context.Context, conn *jsonrpc2.Conn, uri string) error {
+	if s.initOptionDBConfig != nil {
+		return nil
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return nil
+	}
+	fpath := filepath.FromSlash(u.Path)
+
+	connected, switched := s.dbConn != nil, false
+	if folder := s.folderOf(fpath); folder != nil && folder != s.curFolder {
+		s.keepConnection()
+		// The aliases of the statements are of the config of the folder
+		s.closeStatementConnections()
+		s.activateFolder(folder)
+		s.curConnectionIndex, s.curDBName = 0, ""
+		switched = true
+	}
+	if s.rootPath != "" {
+		rel, err := filepath.Rel(s.rootPath, fpath)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = ""
+		}
+		if index, ok := s.getConfig().FileConnectionIndex(filepath.ToSlash(rel)); rel != "" && ok && index != s.curConnectionIndex {
+			if !switched {
+				s.keepConnection()
+			}
+			s.curConnectionIndex, s.curDBName = index, ""
+			switched = true
+		}
+	}
+	if !switched {
+		return nil
+	}
+	return s.useKeptConnection(ctx, conn, connected)
+}
+
 func validConfig(cfg *config.Config) bool {
 	// if cfg != nil && len(cfg.Connections) > 0 {
 	if cfg != nil {
@@ -5,12 +5,17 @@ import (
 	"errors"
 	"log"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/sourcegraph/jsonrpc2"
 
+	"github.com/sqls-server/sqls/ast"
 	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
 	"github.com/sqls-server/sqls/internal/lsp"
 )
 
@@ -22,6 +27,11 @@ type TestContext struct {
 	connServer *jsonrpc2.Conn
 	server     *Server
 	ctx        context.Context
+
+	// clientHandler handles the requests from the server, defaults to h
+	clientHandler jsonrpc2.Handler
+	// initParams are the params of initialize
+	initParams lsp.InitializeParams
 }
 
 func newTestContext() *TestContext {
@@ -62,18 +72,26 @@ func (tx *TestContext) initServer(t *testing.T) {
 	// Prepare the server and client connection.
 	client, server := net.Pipe()
 	tx.connServer = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(server, jsonrpc2.VSCodeObjectCodec{}), tx.h)
-	tx.conn = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), tx.h)
+	clientHandler := tx.clientHandler
+	if clientHandler == nil {
+		clientHandler = tx.h
+	}
+	tx.conn = jsonrpc2.NewConn(tx.ctx, jsonrpc2.NewBufferedStream(client, jsonrpc2.VSCodeObjectCodec{}), clientHandler)
 
 	// Initialize Language Server
-	params := lsp.InitializeParams{
-		InitializationOptions: lsp.InitializeOptions{},
-	}
-	if err := tx.conn.Call(tx.ctx, "initialize", params, nil); err != nil {
+	if err := tx.conn.Call(tx.ctx, "initialize", tx.initParams, nil); err != nil {
 		t.Fatal("conn.Call initialize:", err)
 	}
 }
 
 func (tx *TestContext) addWorkspaceConfig(t *testing.T, cfg *config.Config) {
+	t.Helper()
+	tx.didChangeConfiguration(t, cfg)
+	tx.connectDB(t)
+}
+
+func (tx *TestContext) didChangeConfiguration(t *testing.T, cfg *config.Config) {
+	t.Helper()
 	didChangeConfigurationParams := lsp.DidChangeConfigurationParams{
 		Settings: struct {
 			SQLS *config.Config "json:\"sqls\""
@@ -86,10 +104,20 @@ func (tx *TestContext) addWorkspaceConfig(t *testing.T, cfg *config.Config) {
 	}
 }
 
+// connectDB runs a command that needs the database, which connects the
+// database as the server connects it lazily.
+func (tx *TestContext) connectDB(t *testing.T) {
+	t.Helper()
+	params := lsp.ExecuteCommandParams{Command: CommandShowDatabases}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", params, nil); err != nil {
+		t.Log("connect database:", err)
+	}
+}
+
 func (tx *TestContext) textDocumentDidOpen(t *testing.T, uri, input string) {
 	didOpenParams := lsp.DidOpenTextDocumentParams{
 		TextDocument: lsp.TextDocumentItem{
-			URI:        testFileURI,
+			URI:        uri,
 			LanguageID: "sql",
 			Version:    0,
 			Text:       input,
@@ -101,6 +129,19 @@ func (tx *TestContext) textDocumentDidOpen(t *testing.T, uri, input string) {
 	tx.testFile(t, didOpenParams.TextDocument.URI, didOpenParams.TextDocument.Text)
 }
 
+// setText changes the text of the document to the next version, as the
+// client does.
+func (tx *TestContext) setText(t *testing.T, uri, text string) {
+	t.Helper()
+	f, ok := tx.server.files.get(uri)
+	if !ok {
+		t.Fatalf("document not found: %s", uri)
+	}
+	if _, err := tx.server.files.update(uri, f.Version+1, text); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestInitialized(t *testing.T) {
 	tx := newTestContext()
 	tx.setup(t)
@@ -111,6 +152,7 @@ func TestInitialized(t *testing.T) {
 			TextDocumentSync: lsp.TDSKFull,
 			HoverProvider:    true,
 			CompletionProvider: &lsp.CompletionOptions{
+				ResolveProvider:   true,
 				TriggerCharacters: []string{"(", "."},
 			},
 			SignatureHelpProvider: &lsp.SignatureHelpOptions{
@@ -121,10 +163,17 @@ func TestInitialized(t *testing.T) {
 				},
 			},
 			CodeActionProvider:              true,
+			CodeLensProvider:                &lsp.CodeLensOptions{},
 			DefinitionProvider:              true,
 			DocumentFormattingProvider:      true,
 			DocumentRangeFormattingProvider: true,
 			RenameProvider:                  true,
+			Workspace: &lsp.WorkspaceServerCapabilities{
+				WorkspaceFolders: &lsp.WorkspaceFoldersServerCapabilities{
+					Supported:           true,
+					ChangeNotifications: true,
+				},
+			},
 		},
 	}
 	var got lsp.InitializeResult
@@ -188,6 +237,16 @@ func TestFileWatch(t *testing.T) {
 	}
 	tx.testFile(t, didChangeParams.TextDocument.URI, didChangeParams.ContentChanges[0].Text)
 
+	// The change of the older version arriving late is ignored
+	staleParams := lsp.DidChangeTextDocumentParams{
+		TextDocument:   lsp.VersionedTextDocumentIdentifier{URI: uri, Version: 0},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: openText}},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didChange", staleParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didChange:", err)
+	}
+	tx.testFile(t, uri, changeText)
+
 	didSaveParams := lsp.DidSaveTextDocumentParams{
 		Text:         openText,
 		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
@@ -203,14 +262,14 @@ func TestFileWatch(t *testing.T) {
 	if err := tx.conn.Call(tx.ctx, "textDocument/didClose", didCloseParams, nil); err != nil {
 		t.Fatal("conn.Call textDocument/didClose:", err)
 	}
-	_, ok := tx.server.files[didCloseParams.TextDocument.URI]
+	_, ok := tx.server.files.get(didCloseParams.TextDocument.URI)
 	if ok {
 		t.Errorf("found opened file. URI:%s", didCloseParams.TextDocument.URI)
 	}
 }
 
 func (tx *TestContext) testFile(t *testing.T, uri, text string) {
-	f, ok := tx.server.files[uri]
+	f, ok := tx.server.files.get(uri)
 	if !ok {
 		t.Errorf("not found opened file. URI:%s", uri)
 	}
@@ -218,3 +277,485 @@ func (tx *TestContext) testFile(t *testing.T, uri, text string) {
 		t.Errorf("not match %s. got: %s", text, f.Text)
 	}
 }
+
+func TestFileConnections(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	rootPath := t.TempDir()
+	wsCfg := `connections:
+  - alias: main
+    driver: sqlite3
+    dataSourceName: ` + filepath.Join(rootPath, "main.db") + `
+  - alias: analytics
+    driver: sqlite3
+    dataSourceName: ` + filepath.Join(rootPath, "analytics.db") + `
+fileConnections:
+  - pattern: "analytics/**.sql"
+    alias: analytics
+`
+	if err := os.MkdirAll(filepath.Join(rootPath, ".sqls"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.WorkspaceConfigPath(rootPath), []byte(wsCfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tx.server.rootPath = rootPath
+	if err := tx.server.loadWorkspaceConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The workspace config overrides the workspace settings of the client
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:  "client",
+				Driver: "mock",
+			},
+		},
+	})
+	if got := tx.server.curDBCfg.Alias; got != "main" {
+		t.Fatalf("unexpected connection %q, want %q", got, "main")
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "analytics/daily/sales.sql", want: "analytics"},
+		{path: "query.sql", want: "analytics"},
+		{path: "analytics/users.sql", want: "analytics"},
+	}
+	for _, tt := range tests {
+		uri := "file://" + filepath.ToSlash(filepath.Join(rootPath, tt.path))
+		didOpenParams := lsp.DidOpenTextDocumentParams{
+			TextDocument: lsp.TextDocumentItem{
+				URI:        uri,
+				LanguageID: "sql",
+				Text:       "SELECT 1",
+			},
+		}
+		if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+			t.Fatal("conn.Call textDocument/didOpen:", err)
+		}
+		if got := tx.server.curDBCfg.Alias; got != tt.want {
+			t.Errorf("%s: unexpected connection %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFileConnectionsKept(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	rootPath := t.TempDir()
+	wsCfg := `connections:
+  - alias: main
+    driver: sqlite3
+    dataSourceName: ` + filepath.Join(rootPath, "main.db") + `
+  - alias: analytics
+    driver: sqlite3
+    dataSourceName: ` + filepath.Join(rootPath, "analytics.db") + `
+fileConnections:
+  - pattern: "main/**.sql"
+    alias: main
+  - pattern: "analytics/**.sql"
+    alias: analytics
+`
+	if err := os.MkdirAll(filepath.Join(rootPath, ".sqls"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.WorkspaceConfigPath(rootPath), []byte(wsCfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tx.server.rootPath = rootPath
+	if err := tx.server.loadWorkspaceConfig(); err != nil {
+		t.Fatal(err)
+	}
+	mainURI := "file://" + filepath.ToSlash(filepath.Join(rootPath, "main", "query.sql"))
+	analyticsURI := "file://" + filepath.ToSlash(filepath.Join(rootPath, "analytics", "query.sql"))
+	tx.textDocumentDidOpen(t, mainURI, "CREATE TABLE item (id INTEGER);")
+	tx.textDocumentDidOpen(t, analyticsURI, "SELECT 1;")
+
+	execute := func(command, uri string) (string, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: []interface{}{uri},
+		}, &got)
+		res, _ := got.(string)
+		return res, err
+	}
+	hover := func(uri string) {
+		t.Helper()
+		var got interface{}
+		if err := tx.conn.Call(tx.ctx, "textDocument/hover", lsp.HoverParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+			},
+		}, &got); err != nil {
+			t.Fatal("hover:", err)
+		}
+	}
+
+	if _, err := execute(CommandExecuteQuery, mainURI); err != nil {
+		t.Fatal("create table:", err)
+	}
+	mainConn := tx.server.dbConn
+	if _, err := execute(CommandBeginTransaction, mainURI); err != nil {
+		t.Fatal("begin:", err)
+	}
+	tx.setText(t, mainURI, "INSERT INTO item VALUES (1);")
+	if _, err := execute(CommandExecuteQuery, mainURI); err != nil {
+		t.Fatal("insert:", err)
+	}
+
+	// The documents of the other connection switch to it in the transaction
+	hover(analyticsURI)
+	if got := tx.server.curDBCfg.Alias; got != "analytics" {
+		t.Fatalf("unexpected connection %q, want %q", got, "analytics")
+	}
+	analyticsConn := tx.server.dbConn
+	hover(mainURI)
+	if tx.server.dbConn != mainConn {
+		t.Error("the connection of main is opened again")
+	}
+	hover(analyticsURI)
+	if tx.server.dbConn != analyticsConn {
+		t.Error("the connection of analytics is opened again")
+	}
+
+	// The transaction is kept with the connection of main
+	tx.setText(t, mainURI, "SELECT * FROM item;")
+	got, err := execute(CommandExecuteQuery, mainURI)
+	if err != nil {
+		t.Fatal("select:", err)
+	}
+	if !strings.Contains(got, "1 rows in set") {
+		t.Errorf("inserted row is not visible in transaction, got %q", got)
+	}
+	if _, err := execute(CommandRollbackTransaction, mainURI); err != nil {
+		t.Fatal("rollback:", err)
+	}
+}
+
+func TestWorkspaceFolders(t *testing.T) {
+	writeConfig := func(t *testing.T, root, cfg string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(root, ".sqls"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(config.WorkspaceConfigPath(root), []byte(cfg), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	connection := func(root, alias string) string {
+		return `
+  - alias: ` + alias + `
+    driver: sqlite3
+    dataSourceName: ` + filepath.Join(root, alias+".db")
+	}
+	folder := func(root, name string) lsp.WorkspaceFolder {
+		return lsp.WorkspaceFolder{URI: "file://" + filepath.ToSlash(root), Name: name}
+	}
+
+	users, billing, search := t.TempDir(), t.TempDir(), t.TempDir()
+	writeConfig(t, users, "connections:"+connection(users, "users"))
+	writeConfig(t, billing, "connections:"+connection(billing, "billing")+connection(billing, "reports")+`
+fileConnections:
+  - pattern: "reports/**.sql"
+    alias: reports
+`)
+	writeConfig(t, search, "connections:"+connection(search, "search"))
+
+	tx := newTestContext()
+	tx.initParams = lsp.InitializeParams{
+		RootURI:          "file://" + filepath.ToSlash(billing),
+		WorkspaceFolders: []lsp.WorkspaceFolder{folder(users, "users"), folder(billing, "billing")},
+	}
+	tx.setup(t)
+	defer tx.tearDown()
+
+	// The folder of the root is used until a document of another is opened
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:  "client",
+				Driver: "mock",
+			},
+		},
+	})
+	if got := tx.server.curDBCfg.Alias; got != "billing" {
+		t.Fatalf("unexpected connection %q, want %q", got, "billing")
+	}
+
+	open := func(t *testing.T, root, path, want string) {
+		t.Helper()
+		uri := "file://" + filepath.ToSlash(filepath.Join(root, path))
+		tx.textDocumentDidOpen(t, uri, "SELECT 1")
+		if got := tx.server.curDBCfg.Alias; got != want {
+			t.Errorf("%s: unexpected connection %q, want %q", path, got, want)
+		}
+	}
+	open(t, users, "query.sql", "users")
+	open(t, billing, "query.sql", "billing")
+	open(t, billing, "reports/monthly.sql", "reports")
+	open(t, users, "migrations/001.sql", "users")
+
+	// The first folder remaining is used after the current folder is removed
+	params := lsp.DidChangeWorkspaceFoldersParams{
+		Event: lsp.WorkspaceFoldersChangeEvent{
+			Added:   []lsp.WorkspaceFolder{folder(search, "search")},
+			Removed: []lsp.WorkspaceFolder{folder(users, "users")},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "workspace/didChangeWorkspaceFolders", params, nil); err != nil {
+		t.Fatal("conn.Call workspace/didChangeWorkspaceFolders:", err)
+	}
+	if got := tx.server.curDBCfg.Alias; got != "billing" {
+		t.Errorf("unexpected connection %q, want %q", got, "billing")
+	}
+	open(t, search, "query.sql", "search")
+}
+
+func TestSqlfluffConfig(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	rootPath := t.TempDir()
+	sqlfluffCfg := `[sqlfluff]
+exclude_rules = ST03
+
+[sqlfluff:rules:capitalisation.keywords]
+capitalisation_policy = lower
+`
+	if err := os.WriteFile(filepath.Join(rootPath, ".sqlfluff"), []byte(sqlfluffCfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tx.server.rootPath = rootPath
+	if err := tx.server.loadWorkspaceConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The settings of sqls override the ones of sqlfluff
+	tx.addWorkspaceConfig(t, &config.Config{
+		KeywordCase: ast.CaseUpper,
+		Connections: []*database.DBConfig{
+			{
+				Alias:  "client",
+				Driver: "mock",
+			},
+		},
+	})
+	cfg := tx.server.getConfig()
+	if cfg.KeywordCase != ast.CaseUpper {
+		t.Errorf("unexpected keyword case %q, want %q", cfg.KeywordCase, ast.CaseUpper)
+	}
+	if got := cfg.Lint.RuleSeverity(config.LintRuleUnusedCte); got != config.LintSeverityOff {
+		t.Errorf("unexpected severity %q, want %q", got, config.LintSeverityOff)
+	}
+	if got := cfg.Lint.RuleSeverity(config.LintRuleKeywordCase); got != config.LintSeverityWarning {
+		t.Errorf("unexpected severity %q, want %q", got, config.LintSeverityWarning)
+	}
+}
+
+func TestWorkspaceConfigCommands(t *testing.T) {
+	rootPath := t.TempDir()
+	wsCfg := `connections:
+  - alias: main
+    driver: mysql
+    proto: tcp
+    host: 127.0.0.1
+    user: root
+    passwordCmd: echo secret
+externalFormatter:
+  command: cat
+`
+	if err := os.MkdirAll(filepath.Join(rootPath, ".sqls"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.WorkspaceConfigPath(rootPath), []byte(wsCfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		trusted []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "untrusted",
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "other workspace",
+			trusted: []string{filepath.Join(rootPath, "other")},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "trusted",
+			trusted: []string{rootPath},
+			want:    "echo secret",
+		},
+		{
+			name:    "trusted parent",
+			trusted: []string{filepath.Dir(rootPath)},
+			want:    "echo secret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer()
+			defer server.Stop()
+			server.DefaultFileCfg = &config.Config{TrustedWorkspaces: tt.trusted}
+			server.rootPath = rootPath
+			err := server.loadWorkspaceConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadWorkspaceConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if server.WorkspaceFileCfg == nil {
+				t.Fatal("workspace config is not loaded")
+			}
+			if got := server.WorkspaceFileCfg.Connections[0].PasswdCmd; got != tt.want {
+				t.Errorf("unexpected passwordCmd %q, want %q", got, tt.want)
+			}
+			if got := server.WorkspaceFileCfg.ExternalFormatter != nil; got != (tt.want != "") {
+				t.Errorf("unexpected externalFormatter %v", server.WorkspaceFileCfg.ExternalFormatter)
+			}
+		})
+	}
+}
+
+func TestWorkspaceConfigSQLite(t *testing.T) {
+	rootPath := t.TempDir()
+	wsCfg := `connections:
+  - alias: main
+    driver: sqlite3
+    dataSourceName: ":memory:"
+    sqlite:
+      extensions:
+        - ./ext.so
+      fixture: ./schema.sql
+`
+	if err := os.MkdirAll(filepath.Join(rootPath, ".sqls"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.WorkspaceConfigPath(rootPath), []byte(wsCfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		trusted []string
+		want    bool
+	}{
+		{
+			name: "untrusted",
+			want: false,
+		},
+		{
+			name:    "trusted",
+			trusted: []string{rootPath},
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer()
+			defer server.Stop()
+			server.DefaultFileCfg = &config.Config{TrustedWorkspaces: tt.trusted}
+			server.rootPath = rootPath
+			err := server.loadWorkspaceConfig()
+			if (err != nil) == tt.want {
+				t.Fatalf("loadWorkspaceConfig() error = %v", err)
+			}
+			if err != nil && (!strings.Contains(err.Error(), "connections[0].sqlite.extensions") || !strings.Contains(err.Error(), "connections[0].sqlite.fixture")) {
+				t.Errorf("unexpected error %v", err)
+			}
+			if server.WorkspaceFileCfg == nil {
+				t.Fatal("workspace config is not loaded")
+			}
+			if got := server.WorkspaceFileCfg.Connections[0].SQLiteCfg != nil; got != tt.want {
+				t.Errorf("unexpected sqlite %+v", server.WorkspaceFileCfg.Connections[0].SQLiteCfg)
+			}
+		})
+	}
+}
+
+func TestLazyConnection(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.didChangeConfiguration(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	})
+	if tx.server.dbConn != nil {
+		t.Fatal("database must not be connected before it is needed")
+	}
+
+	tx.textDocumentDidOpen(t, testFileURI, "SELECT * FROM ")
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: testFileURI},
+			Position:     lsp.Position{Line: 0, Character: 14},
+		},
+	}
+	complete := func() []lsp.CompletionItem {
+		t.Helper()
+		var got []lsp.CompletionItem
+		if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &got); err != nil {
+			t.Fatal("conn.Call textDocument/completion:", err)
+		}
+		return got
+	}
+
+	// The first completion is served without the cache while the connection
+	// is opened in the background
+	complete()
+	if tx.server.dbConn != nil {
+		t.Fatal("database must be connected in the background")
+	}
+	if tx.server.warmup == nil {
+		t.Fatal("database connection is not started")
+	}
+	<-tx.server.warmup.done
+
+	testCompletionItem(t, []string{"city", "country"}, nil, complete())
+	if tx.server.dbConn == nil {
+		t.Error("database connected in the background is not adopted")
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	// The nil map of the files panics on didOpen
+	files := tx.server.files
+	tx.server.files = nil
+	err := tx.conn.Call(tx.ctx, "textDocument/didOpen", lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        "file:///panic.sql",
+			LanguageID: "sql",
+			Text:       "SELECT 1",
+		},
+	}, nil)
+	var rpcErr *jsonrpc2.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc2.CodeInternalError {
+		t.Fatalf("expected internal error, got %v", err)
+	}
+
+	// The server keeps serving after the panic
+	tx.server.files = files
+	tx.textDocumentDidOpen(t, "file:///panic.sql", "SELECT 1")
+}
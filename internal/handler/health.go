@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+const (
+	ConnectionStateConnected    = "connected"
+	ConnectionStateDisconnected = "disconnected"
+
+	defaultHealthCheckInterval = 30 * time.Second
+	healthCheckTimeout         = 10 * time.Second
+	reconnectMinBackoff        = time.Second
+	reconnectMaxBackoff        = time.Minute
+)
+
+// healthCheck pings the database connection in the background and opens a
+// new one with backoff when it is lost. The handlers own the connection of
+// the server, so the new connection is adopted by the next request that
+// uses the database.
+type healthCheck struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	conn    *database.DBConnection
+	cfg     *database.DBConfig
+	pending *database.DBConnection
+	// rotated is set when the pending connection replaces the one whose
+	// credentials expire, which still works until then
+	rotated   bool
+	postponed bool
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newHealthCheck() *healthCheck {
+	return &healthCheck{
+		interval: defaultHealthCheckInterval,
+		done:     make(chan struct{}),
+	}
+}
+
+// watch sets the connection to check, discarding a reopened connection of
+// the previous one.
+func (h *healthCheck) watch(conn *database.DBConnection, cfg *database.DBConfig) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conn = conn
+	h.cfg = cfg
+	if h.pending != nil {
+		h.pending.Close()
+		h.pending = nil
+	}
+	h.rotated, h.postponed = false, false
+}
+
+// take returns the reopened connection if any.
+func (h *healthCheck) take() *database.DBConnection {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	conn := h.pending
+	h.pending = nil
+	h.rotated, h.postponed = false, false
+	return conn
+}
+
+// rotating reports whether the reopened connection is for the credentials
+// rotation.
+func (h *healthCheck) rotating() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.pending != nil && h.rotated
+}
+
+// postpone keeps the reopened connection pending, and reports whether it is
+// postponed for the first time.
+func (h *healthCheck) postpone() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	first := !h.postponed
+	h.postponed = true
+	return first
+}
+
+func (h *healthCheck) target() (*database.DBConnection, *database.DBConfig, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.conn, h.cfg, h.pending != nil
+}
+
+func (h *healthCheck) start(conn *jsonrpc2.Conn) {
+	go func() {
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-h.done:
+				return
+			case <-ticker.C:
+				h.check(conn)
+			}
+		}
+	}()
+}
+
+func (h *healthCheck) stop() {
+	h.stopOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+func (h *healthCheck) check(conn *jsonrpc2.Conn) {
+	// The next check is tried again
+	defer func() {
+		logging.Recovered(slog.Default(), recover(), "health check")
+	}()
+	dbConn, cfg, pending := h.target()
+	if dbConn == nil || dbConn.Conn == nil || pending {
+		return
+	}
+	// The connection is opened again with new credentials before the
+	// credentials from Vault expire
+	rotate := dbConn.CredentialsExpiring()
+	if !rotate {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		err := dbConn.Conn.PingContext(ctx)
+		cancel()
+		if err == nil {
+			return
+		}
+		if current, _, _ := h.target(); current != dbConn {
+			// The connection has been switched or closed by a request
+			return
+		}
+		slog.Warn("database connection lost", "err", err)
+		h.notify(conn, cfg, ConnectionStateDisconnected, err)
+	} else {
+		slog.Info("database credentials expire, reconnecting")
+	}
+
+	for attempt := 0; ; attempt++ {
+		if !rotate || attempt > 0 {
+			select {
+			case <-h.done:
+				return
+			case <-time.After(reconnectBackoff(attempt)):
+			}
+		}
+		if current, _, _ := h.target(); current != dbConn {
+			return
+		}
+		newConn, err := database.Open(cfg)
+		if err != nil {
+			slog.Warn("cannot reconnect database", "attempt", attempt, "err", err)
+			continue
+		}
+
+		h.mu.Lock()
+		if h.conn != dbConn {
+			h.mu.Unlock()
+			newConn.Close()
+			return
+		}
+		h.pending = newConn
+		h.rotated = rotate
+		h.mu.Unlock()
+		if rotate {
+			slog.Info("database credentials rotated")
+			return
+		}
+		slog.Info("database connection restored")
+		h.notify(conn, cfg, ConnectionStateConnected, nil)
+		return
+	}
+}
+
+func (h *healthCheck) notify(conn *jsonrpc2.Conn, cfg *database.DBConfig, state string, err error) {
+	ctx := context.Background()
+	params := &lsp.ConnectionStateParams{
+		State:  state,
+		Alias:  cfg.Alias,
+		Driver: string(cfg.Driver),
+	}
+	messenger := lsp.NewMessenger(conn)
+	var msgErr error
+	if err != nil {
+		params.Error = err.Error()
+		msgErr = messenger.ShowWarning(ctx, "database connection lost, reconnecting: "+err.Error())
+	} else {
+		msgErr = messenger.ShowInfo(ctx, "database connection restored")
+	}
+	if msgErr != nil {
+		slog.Warn("cannot send message", "err", msgErr)
+	}
+	if err := conn.Notify(ctx, "sqls/connectionState", params); err != nil {
+		slog.Warn("cannot send connection state", "err", err)
+	}
+}
+
+// reconnectBackoff returns the wait before the attempt to reconnect, which
+// doubles up to reconnectMaxBackoff.
+func reconnectBackoff(attempt int) time.Duration {
+	d := reconnectMinBackoff
+	for i := 0; i < attempt && d < reconnectMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > reconnectMaxBackoff {
+		d = reconnectMaxBackoff
+	}
+	return d
+}
+
+// adoptReconnectedDB replaces the lost connection with the one reopened by
+// the health check, and recreates the cache from it. The connection whose
+// credentials are rotated is replaced after its transactions end.
+func (s *Server) adoptReconnectedDB(ctx context.Context, conn *jsonrpc2.Conn) error {
+	if s.health.rotating() {
+		if uris := s.transactionURIsOf(s.dbConn); len(uris) > 0 {
+			if s.health.postpone() {
+				msg := fmt.Sprintf("database credentials are rotated after the transactions of %s end", strings.Join(uris, ", "))
+				s.showReconnectMessage(ctx, conn, msg, func(m lsp.MessageDisplayer) error { return m.ShowInfo(ctx, msg) })
+			}
+			return nil
+		}
+	}
+	dbConn := s.health.take()
+	if dbConn == nil {
+		return nil
+	}
+	// The transactions were on the lost connection
+	if uris := s.rollbackTransactionsOf(s.dbConn); len(uris) > 0 {
+		msg := fmt.Sprintf("database connection lost, the transactions of %s are rolled back", strings.Join(uris, ", "))
+		s.showReconnectMessage(ctx, conn, msg, func(m lsp.MessageDisplayer) error { return m.ShowWarning(ctx, msg) })
+	}
+	s.renewOwnWorker()
+	if err := s.dbConn.Close(); err != nil {
+		logging.FromContext(ctx).Warn("cannot close lost connection", "err", err)
+	}
+	// The connection reopened is not shared with the other sessions
+	s.dbConn, s.worker = dbConn, s.ownWorker
+	s.health.watch(s.dbConn, s.curDBCfg)
+	dbRepo, err := s.newDBRepository(ctx)
+	if err != nil {
+		return err
+	}
+	return s.worker.ReCache(ctx, dbRepo)
+}
+
+// showReconnectMessage logs the message, and shows it to the user unless
+// there is no client such as in the REPL.
+func (s *Server) showReconnectMessage(ctx context.Context, conn *jsonrpc2.Conn, msg string, show func(lsp.MessageDisplayer) error) {
+	logging.FromContext(ctx).Warn(msg)
+	if conn == nil {
+		return
+	}
+	if err := show(lsp.NewMessenger(conn)); err != nil {
+		logging.FromContext(ctx).Warn("cannot send message", "err", err)
+	}
+}
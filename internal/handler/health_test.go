@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// healthTestDriver is a driver whose connections fail to ping while down is
+// set.
+type healthTestDriver struct {
+	down atomic.Bool
+}
+
+func (d *healthTestDriver) Open(name string) (driver.Conn, error) {
+	return &healthTestConn{driver: d}, nil
+}
+
+type healthTestConn struct {
+	driver *healthTestDriver
+}
+
+func (c *healthTestConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *healthTestConn) Close() error {
+	return nil
+}
+
+func (c *healthTestConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *healthTestConn) Ping(ctx context.Context) error {
+	if c.driver.down.Load() {
+		return errors.New("connection reset by peer")
+	}
+	return nil
+}
+
+var testHealthDriver = &healthTestDriver{}
+
+func init() {
+	sql.Register("healthtest", testHealthDriver)
+	database.RegisterOpen("healthtest", func(connCfg *database.DBConfig) (*database.DBConnection, error) {
+		conn, err := sql.Open("healthtest", "")
+		if err != nil {
+			return nil, err
+		}
+		return &database.DBConnection{Conn: conn}, nil
+	})
+	database.RegisterFactory("healthtest", database.NewMockDBRepository)
+}
+
+func TestHealthCheckReconnect(t *testing.T) {
+	tx := newTestContext()
+	tx.server.health.interval = 20 * time.Millisecond
+	states := make(chan *lsp.ConnectionStateParams, 10)
+	tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "sqls/connectionState" {
+			var params lsp.ConnectionStateParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			states <- &params
+		}
+		return nil, nil
+	})
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:  "main",
+				Driver: "healthtest",
+			},
+		},
+	})
+	lost := tx.server.dbConn
+	// Simulate the connection dropped under the server
+	testHealthDriver.down.Store(true)
+
+	waitState := func(want string) {
+		t.Helper()
+		select {
+		case got := <-states:
+			if got.State != want || got.Alias != "main" || got.Driver != "healthtest" {
+				t.Errorf("unexpected connection state, %+v", got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("connection state %s is not notified", want)
+		}
+	}
+	waitState(ConnectionStateDisconnected)
+	testHealthDriver.down.Store(false)
+	waitState(ConnectionStateConnected)
+
+	var got interface{}
+	err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command: CommandShowTables,
+	}, &got)
+	if err != nil {
+		t.Fatal("show tables on the reconnected database:", err)
+	}
+	if tx.server.dbConn == lost {
+		t.Error("the reconnected database is not adopted")
+	}
+}
+
+func TestHealthCheckRotateInTransaction(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	dbCfg := &database.DBConfig{
+		Driver:         "sqlite3",
+		DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+	}
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{dbCfg},
+	})
+	uri := "file:///test.sql"
+	tx.textDocumentDidOpen(t, uri, "CREATE TABLE item (id INTEGER);")
+	execute := func(command string) error {
+		var got interface{}
+		return tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: []interface{}{uri},
+		}, &got)
+	}
+	if err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("create table:", err)
+	}
+	if err := execute(CommandBeginTransaction); err != nil {
+		t.Fatal("begin:", err)
+	}
+	tx.setText(t, uri, "INSERT INTO item VALUES (1);")
+	if err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("insert:", err)
+	}
+
+	// Simulate the connection reopened before the credentials expire
+	rotated, err := database.Open(dbCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := tx.server.dbConn
+	tx.server.health.mu.Lock()
+	tx.server.health.pending = rotated
+	tx.server.health.rotated = true
+	tx.server.health.mu.Unlock()
+
+	tx.setText(t, uri, "SELECT * FROM item;")
+	if err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("select:", err)
+	}
+	if tx.server.dbConn != old {
+		t.Error("the rotated connection is adopted in the transaction")
+	}
+	if _, ok := tx.server.transactions[uri]; !ok {
+		t.Fatal("transaction is rolled back by the rotation")
+	}
+
+	if err := execute(CommandCommitTransaction); err != nil {
+		t.Fatal("commit:", err)
+	}
+	if err := execute(CommandExecuteQuery); err != nil {
+		t.Fatal("select:", err)
+	}
+	if tx.server.dbConn != rotated {
+		t.Error("the rotated connection is not adopted after the transaction")
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: time.Second},
+		{attempt: 1, want: 2 * time.Second},
+		{attempt: 3, want: 8 * time.Second},
+		{attempt: 6, want: time.Minute},
+		{attempt: 100, want: time.Minute},
+	}
+	for _, tt := range tests {
+		if got := reconnectBackoff(tt.attempt); got != tt.want {
+			t.Errorf("reconnectBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
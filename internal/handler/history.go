@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sqls-server/sqls/internal/history"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+const (
+	defaultHistoryLimit = 20
+	recentQueryLimit    = 10
+	queryLabelMaxLen    = 60
+)
+
+func (s *Server) recordHistory(query string, start time.Time, rowCount int64, err error) {
+	e := &history.Entry{
+		Query:      query,
+		Connection: s.connectionName(),
+		ExecutedAt: start,
+		Duration:   time.Since(start),
+		RowCount:   rowCount,
+		Status:     history.StatusOK,
+	}
+	if s.curDBCfg != nil {
+		e.Database = s.curDBCfg.DBName
+	}
+	if err != nil {
+		e.Status = history.StatusError
+		e.Error = err.Error()
+	}
+	if err := s.History.Add(e); err != nil {
+		slog.Warn("cannot record history", "err", err)
+	}
+}
+
+func (s *Server) connectionName() string {
+	if s.curDBCfg == nil {
+		return ""
+	}
+	if s.curDBCfg.Alias != "" {
+		return s.curDBCfg.Alias
+	}
+	return string(s.curDBCfg.Driver)
+}
+
+func (s *Server) showHistory(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	limit := defaultHistoryLimit
+	if len(params.Arguments) > 0 {
+		limit, err = intArgument(params.Arguments[0])
+		if err != nil {
+			return nil, fmt.Errorf("specify the limit as a number, %w", err)
+		}
+	}
+	entries, err := s.History.List(limit)
+	if err != nil {
+		return nil, err
+	}
+	return formatHistory(entries), nil
+}
+
+func (s *Server) searchHistory(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) == 0 {
+		return nil, fmt.Errorf("required arguments were not provided: <Keyword>")
+	}
+	keyword, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the keyword as a string")
+	}
+	entries, err := s.History.Search(keyword, defaultHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	return formatHistory(entries), nil
+}
+
+func (s *Server) executeHistory(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if s.dbConn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	if len(params.Arguments) == 0 {
+		return nil, fmt.Errorf("required arguments were not provided: <History ID>")
+	}
+	id, err := intArgument(params.Arguments[0])
+	if err != nil {
+		return nil, fmt.Errorf("specify the history id as a number, %w", err)
+	}
+	e, err := s.History.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The optional document URI runs the query in the transaction of the document
+	var uri string
+	if len(params.Arguments) > 1 {
+		uri, _ = params.Arguments[1].(string)
+	}
+	executor, err := s.executor(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	res, _, err := s.executeStatements(ctx, executor, s.curDBCfg.Driver, []string{e.Query}, false, commandBindParams(params.Arguments[1:]), commandCopyFile(params.Arguments[1:]))
+	return res, err
+}
+
+func (s *Server) recentQueryCompletionItems() []lsp.CompletionItem {
+	queries, err := s.History.RecentQueries(recentQueryLimit)
+	if err != nil {
+		slog.Warn("cannot read recent queries", "err", err)
+		return nil
+	}
+	items := []lsp.CompletionItem{}
+	for i, query := range queries {
+		items = append(items, lsp.CompletionItem{
+			Label:      queryLabel(query),
+			Kind:       lsp.SnippetCompletion,
+			Detail:     "recent query",
+			InsertText: query,
+			SortText:   fmt.Sprintf("%03d", i),
+			Documentation: &lsp.MarkupContent{
+				Kind:  lsp.Markdown,
+				Value: "```sql\n" + query + "\n```",
+			},
+		})
+	}
+	return items
+}
+
+func formatHistory(entries []*history.Entry) string {
+	buf := new(bytes.Buffer)
+	for _, e := range entries {
+		fmt.Fprintf(buf, "%d %s %s %s %d rows %s %s",
+			e.ID,
+			e.ExecutedAt.Format("2006-01-02 15:04:05"),
+			e.Connection,
+			e.Duration.Round(time.Millisecond),
+			e.RowCount,
+			e.Status,
+			queryLabel(e.Query),
+		)
+		fmt.Fprintln(buf)
+	}
+	return buf.String()
+}
+
+// queryLabel returns the query on a single line, shortened for display.
+func queryLabel(query string) string {
+	label := strings.Join(strings.Fields(query), " ")
+	if r := []rune(label); len(r) > queryLabelMaxLen {
+		label = string(r[:queryLabelMaxLen]) + "..."
+	}
+	return label
+}
+
+// commandBindParams returns the first list or map argument, which holds the
+// values of the placeholders.
+func commandBindParams(args []interface{}) interface{} {
+	for _, arg := range args {
+		switch arg.(type) {
+		case []interface{}, map[string]interface{}:
+			return arg
+		}
+	}
+	return nil
+}
+
+// copyFileFlag is the prefix of the argument giving the file whose rows
+// COPY ... FROM STDIN sends.
+const copyFileFlag = "-copy-file="
+
+// commandCopyFile returns the path given by the -copy-file argument, or "".
+func commandCopyFile(args []interface{}) string {
+	for _, arg := range args {
+		if flag, ok := arg.(string); ok && strings.HasPrefix(flag, copyFileFlag) {
+			return strings.TrimPrefix(flag, copyFileFlag)
+		}
+	}
+	return ""
+}
+
+func intArgument(arg interface{}) (int, error) {
+	switch v := arg.(type) {
+	case float64:
+		return int(v), nil
+	case string:
+		return strconv.Atoi(v)
+	}
+	return 0, fmt.Errorf("invalid argument %v", arg)
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func TestHistory(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:          "local",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///test.sql"
+	didOpenParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:        uri,
+			LanguageID: "sql",
+			Version:    0,
+			Text:       "CREATE TABLE item (id INTEGER); INSERT INTO item VALUES (1); SELECT * FROM item; SELECT * FROM missing;",
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didOpen", didOpenParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didOpen:", err)
+	}
+	execute := func(command string, args ...interface{}) (string, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: args,
+		}, &got)
+		res, _ := got.(string)
+		return res, err
+	}
+	if _, err := execute(CommandExecuteQuery, uri); err == nil {
+		t.Fatal("query for missing table must fail")
+	}
+
+	got, err := execute(CommandShowHistory)
+	if err != nil {
+		t.Fatal("show history:", err)
+	}
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("unexpected history, got %q", got)
+	}
+	if !strings.HasPrefix(lines[0], "4 ") || !strings.Contains(lines[0], " error SELECT * FROM missing;") {
+		t.Errorf("unexpected failed entry, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], " local ") || !strings.Contains(lines[1], " 1 rows ok SELECT * FROM item;") {
+		t.Errorf("unexpected query entry, got %q", lines[1])
+	}
+
+	got, err = execute(CommandSearchHistory, "insert")
+	if err != nil {
+		t.Fatal("search history:", err)
+	}
+	if !strings.HasPrefix(got, "2 ") || strings.Count(got, "\n") != 1 {
+		t.Errorf("unexpected search result, got %q", got)
+	}
+
+	got, err = execute(CommandExecuteHistory, 3)
+	if err != nil {
+		t.Fatal("execute history:", err)
+	}
+	if !strings.Contains(got, "1 rows in set") {
+		t.Errorf("unexpected result, got %q", got)
+	}
+
+	// recent queries are offered in an empty buffer
+	tx.setText(t, uri, "")
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+			Position:     lsp.Position{Line: 0, Character: 0},
+		},
+	}
+	var items []lsp.CompletionItem
+	if err := tx.conn.Call(tx.ctx, "textDocument/completion", completionParams, &items); err != nil {
+		t.Fatal("conn.Call textDocument/completion:", err)
+	}
+	if len(items) != 3 || items[0].InsertText != "SELECT * FROM item;" || items[1].InsertText != "INSERT INTO item VALUES (1);" {
+		t.Errorf("recent queries are not completed, got %+v", items)
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/sqls-server/sqls/ast/astutil"
 	"github.com/sqls-server/sqls/internal/database"
 	"github.com/sqls-server/sqls/internal/lsp"
-	"github.com/sqls-server/sqls/parser"
 	"github.com/sqls-server/sqls/parser/parseutil"
 	"github.com/sqls-server/sqls/token"
 )
@@ -28,12 +27,26 @@ func (s *Server) handleTextDocumentHover(ctx context.Context, conn *jsonrpc2.Con
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	res, err := hover(f.Text, params, s.worker.Cache())
+	if err := s.switchFileConnection(ctx, conn, params.TextDocument.URI); err != nil {
+		return nil, err
+	}
+	s.prepareDB(ctx, conn)
+
+	text := s.templateText(f, true).Text
+	s.loadCatalogs(ctx, f, text, params.Position)
+	dbCache := s.dbCache(params.TextDocument.URI)
+	if cache, _, ok := s.statementCache(ctx, f, text, params.Position); ok {
+		dbCache = cache
+	}
+	res, err := hover(f, text, params, dbCache)
+	if err == nil && s.files.modified(params.TextDocument.URI, f) {
+		return nil, errContentModified(params.TextDocument.URI)
+	}
 	if err != nil {
 		if errors.Is(ErrNoHover, err) {
 			return nil, nil
@@ -43,7 +56,7 @@ func (s *Server) handleTextDocumentHover(ctx context.Context, conn *jsonrpc2.Con
 	return res, nil
 }
 
-func hover(text string, params lsp.HoverParams, dbCache *database.DBCache) (*lsp.Hover, error) {
+func hover(f *File, text string, params lsp.HoverParams, dbCache *database.DBCache) (*lsp.Hover, error) {
 	if dbCache == nil {
 		return nil, nil
 	}
@@ -52,7 +65,7 @@ func hover(text string, params lsp.HoverParams, dbCache *database.DBCache) (*lsp
 		Line: params.Position.Line,
 		Col:  params.Position.Character + 1,
 	}
-	parsed, err := parser.Parse(text)
+	parsed, err := f.parse(text)
 	if err != nil {
 		return nil, err
 	}
@@ -136,6 +149,7 @@ type hoverEnvironment struct {
 	aliases    []ast.Node
 	tables     []*parseutil.TableInfo
 	subQueries []*parseutil.SubQueryInfo
+	ctes       []*parseutil.SubQueryInfo
 }
 
 func (e *hoverEnvironment) getTableRealName(aliasName string) (string, bool) {
@@ -147,6 +161,35 @@ func (e *hoverEnvironment) getTableRealName(aliasName string) (string, bool) {
 	return "", false
 }
 
+// getCatalogTable returns the table of another database of the server whose
+// name or alias is the name, such as db.dbo.city of "db.dbo.city AS c".
+func (e *hoverEnvironment) getCatalogTable(name string) (*parseutil.TableInfo, bool) {
+	for _, table := range e.tables {
+		if table.Catalog != "" && (table.Alias == name || table.Name == name) {
+			return table, true
+		}
+	}
+	return nil, false
+}
+
+// getAliasRealName returns the name of the table, CTE or subquery of the
+// alias, such as city of "city AS c", wherever the alias is defined.
+func (e *hoverEnvironment) getAliasRealName(aliasName string) (string, bool) {
+	if name, ok := e.getTableRealName(aliasName); ok {
+		return name, true
+	}
+	for _, v := range e.aliases {
+		alias, _ := v.(*ast.Aliased)
+		if alias.AliasedName.String() != aliasName {
+			continue
+		}
+		if ident, ok := alias.RealName.(*ast.Identifier); ok {
+			return ident.NoQuoteString(), true
+		}
+	}
+	return "", false
+}
+
 func (e *hoverEnvironment) getColumnRealName(aliasedName string) (string, bool) {
 	for _, v := range e.aliases {
 		alias, _ := v.(*ast.Aliased)
@@ -163,12 +206,22 @@ func (e *hoverEnvironment) getColumnRealName(aliasedName string) (string, bool)
 	return "", false
 }
 
+// getSubQueryView returns the subquery or the CTE of the name, or of the
+// alias of the CTE.
 func (e *hoverEnvironment) getSubQueryView(name string) (*parseutil.SubQueryInfo, bool) {
 	for _, subQuery := range e.subQueries {
 		if subQuery.Name == name {
 			return subQuery, true
 		}
 	}
+	if realName, ok := e.getAliasRealName(name); ok {
+		name = realName
+	}
+	for _, cte := range e.ctes {
+		if cte.Name == name {
+			return cte, true
+		}
+	}
 	return nil, false
 }
 
@@ -195,10 +248,15 @@ func collectEnvironment(parsed ast.TokenList, pos token.Pos) (*hoverEnvironment,
 	if err != nil {
 		return nil, err
 	}
+	ctes, err := parseutil.ExtractCTEs(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
 	environment := &hoverEnvironment{
 		aliases:    aliases,
 		tables:     definedTables,
 		subQueries: subQueries,
+		ctes:       ctes,
 	}
 	return environment, nil
 }
@@ -227,11 +285,11 @@ func hoverContentFromIdent(ctx *hoverContext, identName string, dbCache *databas
 		}
 		hoverContents := []*lsp.MarkupContent{}
 		for _, table := range hoverEnv.tables {
-			colDesc, ok := dbCache.Column(table.Name, columnName)
+			colDesc, ok := tableColumn(table, columnName, dbCache)
 			if ok {
 				hoverContents = append(
 					hoverContents,
-					columnHoverInfo(table.Name, columnName, colDesc),
+					columnHoverInfo(table.Name, columnName, colDesc, dbCache),
 				)
 			}
 		}
@@ -243,6 +301,9 @@ func hoverContentFromIdent(ctx *hoverContext, identName string, dbCache *databas
 		}
 	}
 	if hoverTypeIs(ctx.types, hoverTypeTable) {
+		if table, ok := hoverEnv.getCatalogTable(identName); ok {
+			return catalogTableHoverInfo(table, dbCache)
+		}
 		// translate table alias
 		tableName := identName
 		for _, table := range hoverEnv.tables {
@@ -253,7 +314,12 @@ func hoverContentFromIdent(ctx *hoverContext, identName string, dbCache *databas
 		// find table
 		cols, ok := dbCache.ColumnDescs(tableName)
 		if ok {
-			return tableHoverInfo(tableName, cols)
+			return tableHoverInfo(tableName, cols, dbCache)
+		}
+	}
+	if hoverTypeIs(ctx.types, hoverTypeSubQueryView) {
+		if subQueryView, ok := hoverEnv.getSubQueryView(identName); ok {
+			return subqueryHoverInfo(subQueryView, dbCache)
 		}
 	}
 	if hoverTypeIs(ctx.types, hoverTypeSubQueryColumn) {
@@ -273,14 +339,17 @@ func hoverContentFromParentIdent(ctx *hoverContext, identName string, dbCache *d
 		return nil
 	case parentTypeSchema:
 	case parentTypeTable:
+		if table, ok := hoverEnv.getCatalogTable(identName); ok {
+			return catalogTableHoverInfo(table, dbCache)
+		}
 		tableName := identName
-		realName, ok := hoverEnv.getTableRealName(tableName)
+		realName, ok := hoverEnv.getAliasRealName(tableName)
 		if ok {
 			tableName = realName
 		}
 		columns, ok := dbCache.ColumnDescs(tableName)
 		if ok {
-			return tableHoverInfo(tableName, columns)
+			return tableHoverInfo(tableName, columns, dbCache)
 		}
 	case parentTypeSubQuery:
 		subQueryName := identName
@@ -298,18 +367,27 @@ func hoverContentFromChildIdent(ctx *hoverContext, identName string, dbCache *da
 	case parentTypeNone:
 		return nil
 	case parentTypeSchema:
+		if table, ok := hoverEnv.getCatalogTable(identName); ok {
+			return catalogTableHoverInfo(table, dbCache)
+		}
 		columns, ok := dbCache.ColumnDescs(identName)
 		if ok {
-			return tableHoverInfo(identName, columns)
+			return tableHoverInfo(identName, columns, dbCache)
 		}
 	case parentTypeTable:
+		if table, ok := hoverEnv.getCatalogTable(ctx.parent.Name); ok {
+			if colDesc, ok := tableColumn(table, identName, dbCache); ok {
+				return columnHoverInfo(table.Name, identName, colDesc, dbCache)
+			}
+			return nil
+		}
 		tableName := ctx.parent.Name
-		realName, ok := hoverEnv.getTableRealName(tableName)
+		realName, ok := hoverEnv.getAliasRealName(tableName)
 		if ok {
 			tableName = realName
 		}
 		if colDesc, ok := dbCache.Column(tableName, identName); ok {
-			return columnHoverInfo(tableName, identName, colDesc)
+			return columnHoverInfo(tableName, identName, colDesc, dbCache)
 		}
 		return nil
 	case parentTypeSubQuery:
@@ -322,17 +400,40 @@ func hoverContentFromChildIdent(ctx *hoverContext, identName string, dbCache *da
 	return nil
 }
 
-func columnHoverInfo(tableName, colName string, colDesc *database.ColumnDesc) *lsp.MarkupContent {
+// tableColumn returns the column of the table of the statement, which may be
+// of another database of the server.
+func tableColumn(table *parseutil.TableInfo, colName string, dbCache *database.DBCache) (*database.ColumnDesc, bool) {
+	if table.Catalog == "" {
+		return dbCache.Column(table.Name, colName)
+	}
+	cols, _ := dbCache.TableColumns(table.Catalog, table.DatabaseSchema, table.Name)
+	for _, col := range cols {
+		if dbCache.MatchColumn(colName, col.Name) {
+			return col, true
+		}
+	}
+	return nil, false
+}
+
+func catalogTableHoverInfo(table *parseutil.TableInfo, dbCache *database.DBCache) *lsp.MarkupContent {
+	cols, ok := dbCache.TableColumns(table.Catalog, table.DatabaseSchema, table.Name)
+	if !ok {
+		return nil
+	}
+	return tableHoverInfo(table.Name, cols, nil)
+}
+
+func columnHoverInfo(tableName, colName string, colDesc *database.ColumnDesc, dbCache *database.DBCache) *lsp.MarkupContent {
 	return &lsp.MarkupContent{
 		Kind:  lsp.Markdown,
-		Value: database.ColumnDoc(tableName, colDesc),
+		Value: database.ColumnDoc(tableName, colDesc, dbCache),
 	}
 }
 
-func tableHoverInfo(tableName string, cols []*database.ColumnDesc) *lsp.MarkupContent {
+func tableHoverInfo(tableName string, cols []*database.ColumnDesc, dbCache *database.DBCache) *lsp.MarkupContent {
 	return &lsp.MarkupContent{
 		Kind:  lsp.Markdown,
-		Value: database.TableDoc(tableName, cols),
+		Value: dbCache.TableDoc(tableName, cols),
 	}
 }
 
@@ -416,16 +517,9 @@ func getHoverTypes(nw *parseutil.NodeWalker, hoverEnv *hoverEnvironment) *hoverC
 				hoverTypeView,
 				hoverTypeFunction,
 			}
-			name := mi.Parent.String()
 			p = &hoverParent{
 				Type: parentTypeTable,
-				Name: name,
-			}
-			if hoverEnv.isSubQuery(name) {
-				p = &hoverParent{
-					Type: parentTypeSubQuery,
-					Name: name,
-				}
+				Name: mi.Parent.String(),
 			}
 		} else {
 			t = []hoverType{
@@ -451,16 +545,9 @@ func getHoverTypes(nw *parseutil.NodeWalker, hoverEnv *hoverEnvironment) *hoverC
 				hoverTypeSubQueryColumn,
 				hoverTypeFunction,
 			}
-			name := mi.Parent.String()
 			p = &hoverParent{
 				Type: parentTypeTable,
-				Name: name,
-			}
-			if hoverEnv.isSubQuery(name) {
-				p = &hoverParent{
-					Type: parentTypeSubQuery,
-					Name: name,
-				}
+				Name: mi.Parent.String(),
 			}
 		} else {
 			t = []hoverType{
@@ -535,6 +622,14 @@ func getHoverTypes(nw *parseutil.NodeWalker, hoverEnv *hoverEnvironment) *hoverC
 	default:
 		// pass
 	}
+	// The parents are the subqueries and the CTEs, or their aliases, in any
+	// clause
+	if p.Type == parentTypeTable && hoverEnv.isSubQuery(p.Name) {
+		p = &hoverParent{
+			Type: parentTypeSubQuery,
+			Name: p.Name,
+		}
+	}
 	return &hoverContext{
 		types:  t,
 		parent: p,
@@ -199,6 +199,83 @@ FROM city
 		line:   2,
 		col:    6,
 	},
+	{
+		name:   "subquery alias parent in where",
+		input:  "SELECT it.ID FROM (SELECT ID, Name FROM city) it WHERE it.Name = 'a'",
+		output: "it subquery\n\n- ID(city.ID): `int(11)` PRI auto_increment\n- Name(city.Name): `char(35)`\n",
+		line:   0,
+		col:    57,
+	},
+	{
+		name:   "subquery alias child in where",
+		input:  "SELECT it.Name FROM (SELECT ID, Name FROM city) it WHERE it.ID = 1",
+		output: "ID subquery column\n\n- ID(city.ID): `int(11)` PRI auto_increment\n",
+		line:   0,
+		col:    62,
+	},
+	{
+		name:   "cte parent",
+		input:  "WITH it AS (SELECT ID, Name FROM city) SELECT it.ID FROM it",
+		output: "it subquery\n\n- ID(city.ID): `int(11)` PRI auto_increment\n- Name(city.Name): `char(35)`\n",
+		line:   0,
+		col:    47,
+	},
+	{
+		name:   "cte reference",
+		input:  "WITH it AS (SELECT ID, Name FROM city) SELECT ID FROM it",
+		output: "it subquery\n\n- ID(city.ID): `int(11)` PRI auto_increment\n- Name(city.Name): `char(35)`\n",
+		line:   0,
+		col:    56,
+	},
+	{
+		name:   "cte alias parent",
+		input:  "WITH it AS (SELECT ID, Name FROM city) SELECT i.ID FROM it i",
+		output: "it subquery\n\n- ID(city.ID): `int(11)` PRI auto_increment\n- Name(city.Name): `char(35)`\n",
+		line:   0,
+		col:    47,
+	},
+	{
+		name:   "cte alias child",
+		input:  "WITH it AS (SELECT ID, Name FROM city) SELECT i.ID FROM it i",
+		output: "ID subquery column\n\n- ID(city.ID): `int(11)` PRI auto_increment\n",
+		line:   0,
+		col:    49,
+	},
+	{
+		name:   "cte alias",
+		input:  "WITH it AS (SELECT ID, Name FROM city) SELECT ID FROM it i",
+		output: "it subquery\n\n- ID(city.ID): `int(11)` PRI auto_increment\n- Name(city.Name): `char(35)`\n",
+		line:   0,
+		col:    58,
+	},
+	{
+		name:   "column referencing foreign key",
+		input:  "SELECT CountryCode FROM city",
+		output: "`city`.`CountryCode` column\n\n`char(3)` MUL\n\n- references `country`.`Code`\n",
+		line:   0,
+		col:    8,
+	},
+	{
+		name:   "column referenced by foreign keys",
+		input:  "SELECT co.Code FROM country co",
+		output: "`country`.`Code` column\n\n`char(3)` PRI auto_increment\n\n- referenced by `city`.`CountryCode`\n- referenced by `countrylanguage`.`CountryCode`\n",
+		line:   0,
+		col:    12,
+	},
+	{
+		name:   "column of table of another database",
+		input:  "SELECT o.OrderID FROM archive.dbo.orders o",
+		output: "`orders`.`OrderID` column\n\n`int` PRIMARY KEY\n",
+		line:   0,
+		col:    11,
+	},
+	{
+		name:   "table of another database",
+		input:  "SELECT o.OrderID FROM archive.dbo.orders o",
+		output: "# `orders` table\n\n\n| Name&nbsp;&nbsp; | Type&nbsp;&nbsp; | Primary&nbsp;key&nbsp;&nbsp; | Default&nbsp;&nbsp; | Extra&nbsp;&nbsp; |\n| :--------------- | :--------------- | :---------------------- | :------------------ | :---------------- |\n| `OrderID` | `int` | `YES` | `-` |  |\n| `OrderedAt` | `datetime` | `NO` | `-` |  |\n",
+		line:   0,
+		col:    37,
+	},
 }
 
 func TestHoverMain(t *testing.T) {
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+const (
+	batchFlag          = "-batch="
+	defaultImportBatch = 100
+)
+
+// importData generates the INSERT statements of the rows of the CSV or the
+// JSON file into the table, and returns them as a document to review, or
+// runs them with -execute. The columns of the file are mapped to the columns
+// of the table of the same names, and the arguments such as name:full_name
+// map the others, or skip them such as note:. The CSV files of all the
+// columns are loaded into PostgreSQL by COPY.
+func (s *Server) importData(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if len(params.Arguments) < 2 {
+		return nil, fmt.Errorf("required arguments were not provided: <File Path> <Table>")
+	}
+	fpath, ok := params.Arguments[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("specify the file path as a string")
+	}
+	table, ok := params.Arguments[1].(string)
+	if !ok || table == "" {
+		return nil, fmt.Errorf("specify the table as a string")
+	}
+	batch, execute := defaultImportBatch, false
+	mapping := map[string]string{}
+	for _, arg := range params.Arguments[2:] {
+		flag, ok := arg.(string)
+		if !ok {
+			continue
+		}
+		switch {
+		case flag == "-execute":
+			execute = true
+		case strings.HasPrefix(flag, batchFlag):
+			batch, err = strconv.Atoi(strings.TrimPrefix(flag, batchFlag))
+			if err != nil || batch < 1 {
+				return nil, fmt.Errorf("invalid batch size, %s", flag)
+			}
+		case strings.Contains(flag, ":"):
+			i := strings.Index(flag, ":")
+			mapping[flag[:i]] = flag[i+1:]
+		}
+	}
+	if !filepath.IsAbs(fpath) && s.rootPath != "" {
+		fpath = filepath.Join(s.rootPath, fpath)
+	}
+
+	data, err := database.ReadImportFile(fpath)
+	if err != nil {
+		return nil, err
+	}
+	tableColumns, err := s.importTableColumns(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	columns, indexes, err := database.ImportColumns(data.Columns, tableColumns, mapping)
+	if err != nil {
+		return nil, err
+	}
+	driver := s.curDBCfg.Driver
+	if !execute {
+		stmts := database.ImportStatements(driver, table, data, columns, indexes, batch)
+		text := ""
+		if len(stmts) > 0 {
+			text = strings.Join(stmts, ";\n\n") + ";\n"
+		}
+		return lsp.TextDocumentItem{
+			URI:        "sqls:/import-" + table + ".sql",
+			LanguageID: "sql",
+			Text:       text,
+		}, nil
+	}
+
+	repo, err := s.newDBRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if driver == dialect.DatabaseDriverPostgreSQL && data.Format == database.ImportCSV && len(indexes) == len(data.Columns) {
+		_, count, err := s.copyFrom(ctx, repo, database.ImportCopyStatement(table, columns), fpath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import rows by COPY, %w", err)
+		}
+		return fmt.Sprintf("Imported %d rows into %s by COPY", count, table), nil
+	}
+	var imported int64
+	for _, stmt := range database.ImportStatements(driver, table, data, columns, indexes, batch) {
+		_, count, _, err := s.exec(ctx, repo, stmt, false)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import rows after %d rows, %w", imported, err)
+		}
+		imported += count
+	}
+	return fmt.Sprintf("Imported %d rows into %s", imported, table), nil
+}
+
+// importTableColumns returns the columns of the table, which is read from the
+// database if it is not cached yet, such as created after connecting.
+func (s *Server) importTableColumns(ctx context.Context, table string) ([]*database.ColumnDesc, error) {
+	schema, name := "", table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema, name = table[:i], table[i+1:]
+	}
+	if cache := s.worker.Cache(); cache != nil {
+		var cols []*database.ColumnDesc
+		var ok bool
+		if schema != "" {
+			cols, ok = cache.ColumnDatabase(schema, name)
+		} else {
+			cols, ok = cache.ColumnDescs(name)
+		}
+		if ok {
+			return cols, nil
+		}
+	}
+
+	repo, err := s.newDBRepository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var descs []*database.ColumnDesc
+	if schema != "" {
+		descs, err = repo.DescribeDatabaseTableBySchema(ctx, schema)
+	} else {
+		descs, err = repo.DescribeDatabaseTable(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read columns of %s, %w", table, err)
+	}
+	cols := []*database.ColumnDesc{}
+	for _, desc := range descs {
+		if strings.EqualFold(desc.Table, name) {
+			cols = append(cols, desc)
+		}
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %s is not found", table)
+	}
+	return cols, nil
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func Test_importData(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+	csvPath := filepath.Join(t.TempDir(), "items.csv")
+	if err := os.WriteFile(csvPath, []byte("id,Name,extra\n1,it's,x\n2,,y\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	uri := "file:///test.sql"
+	tx.textDocumentDidOpen(t, uri, "CREATE TABLE item (id INTEGER, name TEXT);")
+	execute := func(command string, args ...interface{}) (interface{}, error) {
+		var got interface{}
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: args,
+		}, &got)
+		return got, err
+	}
+	if _, err := execute(CommandExecuteQuery, uri); err != nil {
+		t.Fatal("create table:", err)
+	}
+
+	if _, err := execute(CommandImportData, csvPath, "item"); err == nil || !strings.Contains(err.Error(), "cannot map the columns extra") {
+		t.Errorf("unmapped column must fail, %v", err)
+	}
+
+	got, err := execute(CommandImportData, csvPath, "item", "extra:")
+	if err != nil {
+		t.Fatal("import data:", err)
+	}
+	doc, _ := got.(map[string]interface{})
+	want := "INSERT INTO item (id, name) VALUES\n  ('1', 'it''s'),\n  ('2', NULL);\n"
+	if doc["uri"] != "sqls:/import-item.sql" || doc["text"] != want {
+		t.Errorf("unexpected document, %v", got)
+	}
+
+	got, err = execute(CommandImportData, csvPath, "item", "extra:", "-batch=1", "-execute")
+	if err != nil {
+		t.Fatal("import data:", err)
+	}
+	if got != "Imported 2 rows into item" {
+		t.Errorf("unexpected result, %v", got)
+	}
+	tx.setText(t, uri, "SELECT count(*) FROM item WHERE name IS NULL;")
+	got, err = execute(CommandExecuteQuery, uri)
+	if err != nil {
+		t.Fatal("count:", err)
+	}
+	if !strings.Contains(got.(string), "|        1 |") {
+		t.Errorf("rows are not imported, %v", got)
+	}
+
+	if _, err := execute(CommandImportData, csvPath, "missing", "extra:"); err == nil {
+		t.Error("import into missing table must fail")
+	}
+}
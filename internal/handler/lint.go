@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/linter"
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/token"
+)
+
+// planDiagnosticCode is the code of the diagnostics of the plans by EXPLAIN.
+const planDiagnosticCode = "explain"
+
+// publishDiagnostics sends the problems of the document found by the linter.
+// The columns of the tables are checked only when the database is connected,
+// not to connect it for linting.
+func (s *Server) publishDiagnostics(ctx context.Context, conn *jsonrpc2.Conn, uri string) error {
+	f, ok := s.files.get(uri)
+	if !ok {
+		return nil
+	}
+	driver := s.documentDriver()
+	tmpl := s.templateText(f, true)
+	diagnostics, err := linter.LintDocument(f.document(driver), tmpl.Text, s.getConfig(), driver, s.lintDBCache(uri))
+	if err != nil {
+		// The document being edited may not be tokenized
+		logging.FromContext(ctx).Debug("cannot lint", "uri", uri, "err", err)
+		return nil
+	}
+	diagnostics = s.lintStatementConnections(ctx, f, tmpl.Text, diagnostics)
+	diagnostics = append(templateDiagnostics(tmpl, diagnostics), f.planDiagnostics...)
+	return conn.Notify(ctx, "textDocument/publishDiagnostics", lsp.PublishDiagnosticsParams{
+		URI:         uri,
+		Version:     f.Version,
+		Diagnostics: diagnostics,
+	})
+}
+
+// LintText returns the problems of the text of the file found as the
+// diagnostics of the documents are, for the lint subcommand. The tables are
+// of the cache if it is given, such as of a schema dump, or else of the
+// database if it is connected.
+func (s *Server) LintText(fpath, text string, cache *database.DBCache) (_ []lsp.Diagnostic, err error) {
+	defer recoverError(&err, "linting "+fpath)
+	uri, err := fileURI(fpath)
+	if err != nil {
+		return nil, err
+	}
+	if cache == nil {
+		cache = s.lintDBCache(uri)
+	}
+	f := &File{LanguageID: "sql", Text: text}
+	driver := s.documentDriver()
+	tmpl := s.templateText(f, true)
+	diagnostics, err := linter.LintDocument(f.document(driver), tmpl.Text, s.getConfig(), driver, cache)
+	if err != nil {
+		return nil, err
+	}
+	diagnostics = templateDiagnostics(tmpl, diagnostics)
+	// The problems are of the rules in turn, and printed in the order of the text
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return before(diagnostics[i].Range.Start, diagnostics[j].Range.Start)
+	})
+	return diagnostics, nil
+}
+
+// SchemaDumpCache returns the tables of the CREATE TABLE of the schema dump
+// to lint the files with.
+func (s *Server) SchemaDumpCache(fpath string) (*database.DBCache, error) {
+	cache, _, err := s.schemaDumpCache(fpath)
+	return cache, err
+}
+
+// ConnectDatabase connects the database of the config and caches its tables,
+// waiting for them unlike the requests.
+func (s *Server) ConnectDatabase(ctx context.Context) error {
+	return s.reconnectionDB(ctx)
+}
+
+// quickFixActions returns the code actions fixing the problems in the range of
+// the document.
+func (s *Server) quickFixActions(uri string, rng lsp.Range) []lsp.CodeAction {
+	actions := []lsp.CodeAction{}
+	f, ok := s.files.get(uri)
+	if !ok {
+		return actions
+	}
+	tmpl := s.templateText(f, true)
+	fixes, err := linter.QuickFixes(tmpl.Text, s.getConfig(), s.documentDriver(), s.lintDBCache(uri))
+	if err != nil {
+		slog.Debug("cannot lint", "uri", uri, "err", err)
+		return actions
+	}
+	for _, fix := range fixes {
+		if !overlaps(fix.Diagnostic.Range, rng) {
+			continue
+		}
+		if tmpl.Hides(fix.Diagnostic.Range) || checkTemplateEdits(tmpl, fix.Edits) != nil {
+			continue
+		}
+		actions = append(actions, lsp.CodeAction{
+			Title:       fix.Title,
+			Kind:        lsp.CodeActionQuickFix,
+			Diagnostics: []lsp.Diagnostic{fix.Diagnostic},
+			IsPreferred: true,
+			Edit: &lsp.WorkspaceEdit{
+				Changes: map[string][]lsp.TextEdit{uri: fix.Edits},
+			},
+		})
+	}
+	return actions
+}
+
+// checkPlans runs EXPLAIN of the SELECT statements of the saved document if it
+// is enabled, and keeps the problems and the estimated costs of the plans
+// until the document is changed. The database is not connected for it, and the statements are not
+// run in the transaction of the document not to abort it by the timeout.
+func (s *Server) checkPlans(ctx context.Context, uri string) {
+	f, ok := s.files.get(uri)
+	if !ok {
+		return
+	}
+	s.files.setPlans(uri, f, nil, nil)
+	lint := s.getConfig().Lint
+	if !lint.ExplainEnabled() || s.dbConn == nil {
+		return
+	}
+	// The statements of the templates are not explained before they are
+	// rendered
+	if len(s.templateText(f, false).Regions) > 0 {
+		return
+	}
+	stmts, err := getStatements(f.Text)
+	if err != nil {
+		logging.FromContext(ctx).Warn("cannot explain", "uri", uri, "err", err)
+		return
+	}
+	repo, err := s.newDBRepository(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Warn("cannot explain", "uri", uri, "err", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lint.Explain.TimeoutDuration())
+	defer cancel()
+	largeRows := float64(lint.Explain.LargeRowsOrDefault())
+	code, source := planDiagnosticCode, "sqls"
+	var diagnostics []lsp.Diagnostic
+	var lenses []lsp.CodeLens
+	for _, stmt := range stmts {
+		first := firstToken(stmt)
+		if first == nil {
+			continue
+		}
+		switch strings.ToUpper(first.String()) {
+		case "SELECT", "WITH":
+		default:
+			continue
+		}
+		plan, err := s.explainPlan(ctx, repo, statementQuery(stmt))
+		if err != nil {
+			logging.FromContext(ctx).Warn("cannot explain", "uri", uri, "err", err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		rng := lsp.Range{
+			Start: lsp.Position{Line: first.Pos().Line, Character: first.Pos().Col},
+			End:   lsp.Position{Line: first.End().Line, Character: first.End().Col},
+		}
+		if estimate := plan.Estimate(); estimate != "" {
+			lenses = append(lenses, lsp.CodeLens{
+				Range: rng,
+				Command: &lsp.Command{
+					Title:     "estimated " + estimate,
+					Command:   CommandExplainQuery,
+					Arguments: []interface{}{uri},
+				},
+			})
+		}
+		for _, hint := range plan.Hints(largeRows) {
+			diagnostics = append(diagnostics, lsp.Diagnostic{
+				Range:    rng,
+				Severity: lsp.SeverityInformation,
+				Code:     &code,
+				Source:   &source,
+				Message:  hint,
+			})
+		}
+	}
+	s.files.setPlans(uri, f, diagnostics, lenses)
+}
+
+// handleTextDocumentCodeLens returns the estimated costs of the plans of the
+// statements found when the document was saved.
+func (s *Server) handleTextDocumentCodeLens(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.CodeLensParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	lenses := []lsp.CodeLens{}
+	if f, ok := s.files.get(params.TextDocument.URI); ok {
+		lenses = append(lenses, f.planLenses...)
+	}
+	return lenses, nil
+}
+
+// firstToken returns the first token of the node other than the whitespaces
+// and the comments.
+func firstToken(node ast.Node) ast.Token {
+	if list, ok := node.(ast.TokenList); ok {
+		for _, n := range list.GetTokens() {
+			if tok := firstToken(n); tok != nil {
+				return tok
+			}
+		}
+		return nil
+	}
+	tok, ok := node.(ast.Token)
+	if !ok {
+		return nil
+	}
+	switch tok.GetToken().Kind {
+	case token.Whitespace, token.Comment, token.MultilineComment:
+		return nil
+	}
+	return tok
+}
+
+func lastToken(node ast.Node) ast.Token {
+	if list, ok := node.(ast.TokenList); ok {
+		toks := list.GetTokens()
+		for i := len(toks) - 1; i >= 0; i-- {
+			if tok := lastToken(toks[i]); tok != nil {
+				return tok
+			}
+		}
+		return nil
+	}
+	tok, ok := node.(ast.Token)
+	if !ok {
+		return nil
+	}
+	switch tok.GetToken().Kind {
+	case token.Whitespace, token.Comment, token.MultilineComment:
+		return nil
+	}
+	return tok
+}
+
+// lintDBCache returns the cache of the database with the relations of the
+// projects, or nil not to connect the database for linting. The tables of the
+// schema of sqlc are checked without the connection.
+func (s *Server) lintDBCache(uri string) *database.DBCache {
+	if s.dbConn == nil {
+		if s.sqlcProject != nil {
+			return s.sqlcProject.Cache()
+		}
+		return nil
+	}
+	return s.dbCache(uri)
+}
+
+func overlaps(a, b lsp.Range) bool {
+	return !before(a.End, b.Start) && !before(b.End, a.Start)
+}
+
+func before(a, b lsp.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// clearDiagnostics removes the problems of the closed document.
+func (s *Server) clearDiagnostics(ctx context.Context, conn *jsonrpc2.Conn, uri string) error {
+	return conn.Notify(ctx, "textDocument/publishDiagnostics", lsp.PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: []lsp.Diagnostic{},
+	})
+}
@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func TestPublishDiagnostics(t *testing.T) {
+	tx := newTestContext()
+	published := make(chan *lsp.PublishDiagnosticsParams, 10)
+	tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "textDocument/publishDiagnostics" {
+			var params lsp.PublishDiagnosticsParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			published <- &params
+		}
+		return nil, nil
+	})
+	tx.setup(t)
+	defer tx.tearDown()
+
+	waitDiagnostics := func() []lsp.Diagnostic {
+		t.Helper()
+		select {
+		case p := <-published:
+			if p.URI != testFileURI {
+				t.Errorf("unexpected uri, %s", p.URI)
+			}
+			return p.Diagnostics
+		case <-time.After(time.Second):
+			t.Fatal("diagnostics are not published")
+		}
+		return nil
+	}
+
+	tx.textDocumentDidOpen(t, testFileURI, "DELETE FROM city")
+	got := waitDiagnostics()
+	if len(got) != 1 || *got[0].Code != config.LintRuleDeleteWithoutWhere || got[0].Severity != lsp.SeverityWarning {
+		t.Fatalf("unexpected diagnostics, %+v", got)
+	}
+
+	// The rule is disabled by the config
+	tx.didChangeConfiguration(t, &config.Config{
+		Lint: &config.Lint{Rules: map[string]string{
+			config.LintRuleDeleteWithoutWhere: config.LintSeverityOff,
+			config.LintRuleNotEqual:           config.LintSeverityError,
+		}},
+	})
+	if got := waitDiagnostics(); len(got) != 0 {
+		t.Errorf("unexpected diagnostics, %+v", got)
+	}
+
+	changeParams := lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{
+			URI:     testFileURI,
+			Version: 1,
+		},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{Text: "DELETE FROM city WHERE ID != 1"},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didChange", changeParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didChange:", err)
+	}
+	got = waitDiagnostics()
+	if len(got) != 1 || *got[0].Code != config.LintRuleNotEqual || got[0].Severity != lsp.SeverityError {
+		t.Fatalf("unexpected diagnostics, %+v", got)
+	}
+
+	closeParams := lsp.DidCloseTextDocumentParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: testFileURI},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didClose", closeParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didClose:", err)
+	}
+	if got := waitDiagnostics(); len(got) != 0 {
+		t.Errorf("unexpected diagnostics, %+v", got)
+	}
+}
+
+func TestSqlcDiagnostics(t *testing.T) {
+	tx := newTestContext()
+	published := make(chan *lsp.PublishDiagnosticsParams, 10)
+	tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "textDocument/publishDiagnostics" {
+			var params lsp.PublishDiagnosticsParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			published <- &params
+		}
+		return nil, nil
+	})
+	tx.setup(t)
+	defer tx.tearDown()
+
+	rootPath, err := filepath.Abs("../sqlc/testdata/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.server.rootPath = rootPath
+	if err := tx.server.loadSqlcProject(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The columns are checked by the schema files without the connection
+	tx.textDocumentDidOpen(t, testFileURI, "-- name: GetAuthor :one\nSELECT id, nme FROM authors WHERE id = @id")
+	var got []lsp.Diagnostic
+	select {
+	case p := <-published:
+		got = p.Diagnostics
+	case <-time.After(time.Second):
+		t.Fatal("diagnostics are not published")
+	}
+	if len(got) != 1 || *got[0].Code != config.LintRuleSqlc || got[0].Message != "column nme does not exist in authors" {
+		t.Fatalf("unexpected diagnostics, %+v", got)
+	}
+}
+
+func TestQuickFixCodeAction(t *testing.T) {
+	tx := newTestContext()
+	tx.initServer(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "mock"},
+		},
+	})
+	tx.textDocumentDidOpen(t, testFileURI, "SELECT * FROM city ci JOIN country co")
+
+	codeAction := func(rng lsp.Range) []json.RawMessage {
+		t.Helper()
+		params := lsp.CodeActionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: testFileURI},
+			Range:        rng,
+		}
+		var got []json.RawMessage
+		if err := tx.conn.Call(tx.ctx, "textDocument/codeAction", params, &got); err != nil {
+			t.Fatal("conn.Call textDocument/codeAction:", err)
+		}
+		return got
+	}
+
+	got := codeAction(lsp.Range{Start: lsp.Position{Line: 0, Character: 30}, End: lsp.Position{Line: 0, Character: 30}})
+	var action lsp.CodeAction
+	if err := json.Unmarshal(got[0], &action); err != nil {
+		t.Fatal(err)
+	}
+	if action.Kind != lsp.CodeActionQuickFix || action.Title != "Add join condition co.Code = ci.CountryCode" {
+		t.Fatalf("unexpected code action, %+v", action)
+	}
+	want := []lsp.TextEdit{{
+		Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 37}, End: lsp.Position{Line: 0, Character: 37}},
+		NewText: " ON co.Code = ci.CountryCode",
+	}}
+	if diff := cmp.Diff(want, action.Edit.Changes[testFileURI]); diff != "" {
+		t.Errorf("unmatched edits (-want +got):\n%s", diff)
+	}
+
+	// The problem is not in the range
+	got = codeAction(lsp.Range{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 0, Character: 6}})
+	var command lsp.Command
+	if err := json.Unmarshal(got[0], &command); err != nil {
+		t.Fatal(err)
+	}
+	if command.Command != CommandExecuteQuery {
+		t.Errorf("unexpected code action, %s", got[0])
+	}
+}
+
+func TestPlanDiagnostics(t *testing.T) {
+	tx := newTestContext()
+	published := make(chan *lsp.PublishDiagnosticsParams, 10)
+	tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "textDocument/publishDiagnostics" {
+			var params lsp.PublishDiagnosticsParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			published <- &params
+		}
+		return nil, nil
+	})
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Lint: &config.Lint{Explain: &config.LintExplain{Enabled: true}},
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+	waitPlanDiagnostics := func() []lsp.Diagnostic {
+		t.Helper()
+		select {
+		case p := <-published:
+			got := []lsp.Diagnostic{}
+			for _, d := range p.Diagnostics {
+				if *d.Code == planDiagnosticCode {
+					got = append(got, d)
+				}
+			}
+			return got
+		case <-time.After(time.Second):
+			t.Fatal("diagnostics are not published")
+		}
+		return nil
+	}
+
+	tx.textDocumentDidOpen(t, testFileURI, "CREATE TABLE a (id INTEGER, x INTEGER); CREATE TABLE b (y INTEGER);")
+	waitPlanDiagnostics()
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{testFileURI},
+	}, nil); err != nil {
+		t.Fatal("create tables:", err)
+	}
+
+	saveParams := lsp.DidSaveTextDocumentParams{
+		Text:         "-- orders\nSELECT * FROM a JOIN b ON a.x = b.y ORDER BY a.id",
+		TextDocument: lsp.TextDocumentIdentifier{URI: testFileURI},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didSave", saveParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didSave:", err)
+	}
+	got := waitPlanDiagnostics()
+	want := []string{
+		"automatic index on b is built for the join, add an index on the join key",
+		"temporary B-tree for ORDER BY",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected diagnostics, %+v", got)
+	}
+	for i, d := range got {
+		rng := lsp.Range{Start: lsp.Position{Line: 1, Character: 0}, End: lsp.Position{Line: 1, Character: 6}}
+		if d.Message != want[i] || d.Range != rng || d.Severity != lsp.SeverityInformation {
+			t.Errorf("unexpected diagnostic, %+v", d)
+		}
+	}
+
+	var lenses []lsp.CodeLens
+	lensParams := lsp.CodeLensParams{TextDocument: lsp.TextDocumentIdentifier{URI: testFileURI}}
+	if err := tx.conn.Call(tx.ctx, "textDocument/codeLens", lensParams, &lenses); err != nil {
+		t.Fatal("conn.Call textDocument/codeLens:", err)
+	}
+	// The plans of SQLite have no costs but the operations on the tables
+	rng := lsp.Range{Start: lsp.Position{Line: 1, Character: 0}, End: lsp.Position{Line: 1, Character: 6}}
+	if len(lenses) != 1 || lenses[0].Range != rng || lenses[0].Command == nil ||
+		!strings.HasPrefix(lenses[0].Command.Title, "estimated SCAN a; ") ||
+		!strings.Contains(lenses[0].Command.Title, "SEARCH b") ||
+		lenses[0].Command.Command != CommandExplainQuery {
+		t.Errorf("unexpected code lenses, %+v", lenses)
+	}
+
+	// The plans are checked again on saving the changed document
+	changeParams := lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{URI: testFileURI, Version: 1},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{Text: "SELECT * FROM a WHERE id = 1"},
+		},
+	}
+	if err := tx.conn.Call(tx.ctx, "textDocument/didChange", changeParams, nil); err != nil {
+		t.Fatal("conn.Call textDocument/didChange:", err)
+	}
+	if got := waitPlanDiagnostics(); len(got) != 0 {
+		t.Errorf("unexpected diagnostics, %+v", got)
+	}
+	lenses = nil
+	if err := tx.conn.Call(tx.ctx, "textDocument/codeLens", lensParams, &lenses); err != nil {
+		t.Fatal("conn.Call textDocument/codeLens:", err)
+	}
+	if len(lenses) != 0 {
+		t.Errorf("unexpected code lenses, %+v", lenses)
+	}
+}
+
+func TestLintText(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "schema.sql"), []byte("CREATE TABLE city (id int, name text);\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	defer server.worker.Stop()
+	if err := server.OpenWorkspace(root); err != nil {
+		t.Fatal(err)
+	}
+	text := "insert into city (id, nam) values (1, 'a');\nselect * from city where id = NULL;\n"
+
+	codes := func(diagnostics []lsp.Diagnostic) []string {
+		res := []string{}
+		for _, d := range diagnostics {
+			res = append(res, fmt.Sprintf("%d:%d %s", d.Range.Start.Line, d.Range.Start.Character, *d.Code))
+		}
+		return res
+	}
+	got, err := server.LintText("-", text, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"1:28 equalsNull"}, codes(got)); diff != "" {
+		t.Errorf("unmatched diagnostics without schema: (-want +got)\n%s", diff)
+	}
+
+	cache, err := server.SchemaDumpCache("schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = server.LintText("-", text, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"0:22 schemaMismatch", "1:28 equalsNull"}, codes(got)); diff != "" {
+		t.Errorf("unmatched diagnostics with schema: (-want +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+const (
+	migrationOK      = "OK"
+	migrationFailed  = "FAILED"
+	migrationSkipped = "SKIPPED"
+
+	// migrationStatementWidth is the width of the statements in the report
+	migrationStatementWidth = 60
+)
+
+// migrationStep is the report of a statement of a migration.
+type migrationStep struct {
+	query  string
+	status string
+	result string
+}
+
+// runMigration runs all the statements of the document, or of the command
+// range, in one transaction, and returns the report of each of them. The
+// transaction is rolled back at the first statement which fails, and the
+// following ones are skipped.
+func (s *Server) runMigration(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	if s.dbConn == nil {
+		return nil, errors.New("database connection is not open")
+	}
+	uri, text, err := s.commandText(params)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.transactions[uri]; ok {
+		return nil, fmt.Errorf("cannot run the migration in the transaction of the document, %q", uri)
+	}
+	stmts, err := getStatements(text)
+	if err != nil {
+		return nil, err
+	}
+	driver := s.curDBCfg.Driver
+	fileConn := s.fileConnection(uri)
+	var steps []*migrationStep
+	for _, stmt := range stmts {
+		query := statementQuery(stmt)
+		if query == "" {
+			continue
+		}
+		if alias := statementAlias(stmt, fileConn); alias != "" && alias != s.curDBCfg.Alias {
+			return nil, fmt.Errorf("cannot run the statement on %s in the migration", alias)
+		}
+		steps = append(steps, &migrationStep{query: query, status: migrationSkipped})
+	}
+	if len(steps) == 0 {
+		return nil, errors.New("no statements to run")
+	}
+
+	t, err := database.BeginTransaction(ctx, driver, s.dbConn.Conn)
+	if err != nil {
+		return nil, err
+	}
+	bindParams := commandBindParams(params.Arguments[1:])
+	failed := -1
+	for i, step := range steps {
+		step.result, err = s.migrationStatement(ctx, t, driver, step.query, bindParams)
+		if err != nil {
+			step.status, step.result = migrationFailed, err.Error()
+			failed = i
+			break
+		}
+		step.status = migrationOK
+	}
+
+	buf := new(bytes.Buffer)
+	writeMigrationReport(buf, steps)
+	if failed >= 0 {
+		if err := t.Rollback(); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(buf, "Rolled back at statement %d of %d\n", failed+1, len(steps))
+	} else {
+		if err := t.Commit(); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(buf, "Committed %d statements\n", len(steps))
+	}
+	if !dialect.TransactionalDDL(driver) {
+		fmt.Fprintf(buf, "The DDL statements of %s are committed as they run, and are not rolled back\n", driver)
+	}
+	return buf.String(), nil
+}
+
+// migrationStatement runs a statement of a migration, and returns the
+// summary of its result.
+func (s *Server) migrationStatement(ctx context.Context, executor database.Executor, driver dialect.DatabaseDriver, query string, bindParams interface{}) (string, error) {
+	if database.IsCopyFromStdin(query) {
+		return "", errors.New("COPY FROM STDIN is not supported in the migrations")
+	}
+	args, err := statementArgs(driver, query, bindParams)
+	if err != nil {
+		return "", err
+	}
+	start := time.Now()
+	var res string
+	var count int64
+	if _, isQuery := database.QueryExecType(query, ""); isQuery {
+		_, count, _, err = s.query(ctx, executor, query, false, args...)
+		res = fmt.Sprintf("%d rows in set", count)
+	} else {
+		var summary *execSummary
+		if summary, err = s.execSummary(ctx, executor, query, args...); err == nil {
+			count = summary.rowsAffected
+			res = fmt.Sprintf("%d row affected", count)
+		}
+	}
+	s.recordHistory(query, start, count, err)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (%s)", res, time.Since(start).Round(time.Microsecond)), nil
+}
+
+func writeMigrationReport(buf *bytes.Buffer, steps []*migrationStep) {
+	table := tablewriter.NewWriter(buf)
+	table.SetHeader([]string{"#", "Status", "Statement", "Result"})
+	table.SetAutoWrapText(false)
+	for i, step := range steps {
+		table.Append([]string{fmt.Sprint(i + 1), step.status, migrationStatementText(step.query), step.result})
+	}
+	table.Render()
+}
+
+// migrationStatementText returns the statement in a line, cut to
+// migrationStatementWidth.
+func migrationStatementText(query string) string {
+	text := strings.TrimSuffix(strings.Join(strings.Fields(query), " "), ";")
+	if runes := []rune(text); len(runes) > migrationStatementWidth {
+		return string(runes[:migrationStatementWidth-1]) + "…"
+	}
+	return text
+}
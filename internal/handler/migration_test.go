@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func Test_runMigration(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+	uri := "file:///migration.sql"
+	tx.textDocumentDidOpen(t, uri, "")
+	command := func(name string) string {
+		t.Helper()
+		var got string
+		if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   name,
+			Arguments: []interface{}{uri},
+		}, &got); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+		// tables are the tables after the migration
+		tables string
+	}{
+		{
+			name: "committed",
+			input: "CREATE TABLE item (id INTEGER, name TEXT);\n" +
+				"INSERT INTO item VALUES (1, 'apple'), (2, 'banana');\n" +
+				"SELECT * FROM item;",
+			want: []string{
+				"| 1 | OK | CREATE TABLE item (id INTEGER, name TEXT) |",
+				"| 2 | OK | INSERT INTO item VALUES (1, 'apple'), (2, 'banana') | 2 row affected (",
+				"| 3 | OK | SELECT * FROM item | 2 rows in set (",
+				"Committed 3 statements",
+			},
+			tables: "item",
+		},
+		{
+			name: "rolled back",
+			input: "CREATE TABLE price (item_id INTEGER, price REAL);\n" +
+				"INSERT INTO missing VALUES (1);\n" +
+				"DROP TABLE item;",
+			want: []string{
+				"| 1 | OK | CREATE TABLE price (item_id INTEGER, price REAL) |",
+				"| 2 | FAILED | INSERT INTO missing VALUES (1) | no such table: missing",
+				"| 3 | SKIPPED | DROP TABLE item | |",
+				"Rolled back at statement 2 of 3",
+			},
+			tables: "item",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx.setText(t, uri, tt.input)
+			got := command(CommandRunMigration)
+			// The cells are compared without the padding
+			cells := strings.Join(strings.Fields(got), " ")
+			for _, want := range tt.want {
+				if !strings.Contains(cells, want) {
+					t.Errorf("%q is not in the report, got\n%s", want, got)
+				}
+			}
+			if strings.Contains(got, "not rolled back") {
+				t.Errorf("the DDL of sqlite3 is rolled back, got\n%s", got)
+			}
+
+			tx.setText(t, uri, "SELECT group_concat(name) AS tables FROM sqlite_master WHERE type = 'table';")
+			tables := command(CommandExecuteQuery)
+			if !strings.Contains(tables, "| "+tt.tables+" ") {
+				t.Errorf("unexpected tables, got\n%s", tables)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// notebookName returns the name of the document of the URI in the title and
+// the URI of its notebook.
+func notebookName(uri string) string {
+	if fpath, ok := uriPath(uri); ok {
+		return filepath.Base(fpath)
+	}
+	return path.Base(uri)
+}
+
+// appendNotebook appends the statement and its result, or its error, to the
+// notebook of the document, which is kept until the server exits.
+func (s *Server) appendNotebook(uri, query, result string, err error) {
+	text, ok := s.notebooks[uri]
+	if !ok {
+		text = fmt.Sprintf("# Notebook of %s\n", notebookName(uri))
+	}
+	text += "\n```sql\n" + strings.TrimSpace(query) + "\n```\n\n"
+	if err != nil {
+		text += "Error: " + err.Error() + "\n"
+	} else {
+		text += "```\n" + strings.TrimRight(result, "\n") + "\n```\n"
+	}
+	s.notebooks[uri] = text
+}
+
+// notebookDocument returns the notebook of the document as a virtual document
+// for the editor to open alongside it.
+func (s *Server) notebookDocument(uri string) lsp.TextDocumentItem {
+	text, ok := s.notebooks[uri]
+	if !ok {
+		text = fmt.Sprintf("# Notebook of %s\n", notebookName(uri))
+	}
+	return lsp.TextDocumentItem{
+		URI:        "sqls:/notebook/" + notebookName(uri) + ".md",
+		LanguageID: "markdown",
+		Text:       text,
+	}
+}
+
+func (s *Server) showNotebook(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	uri, err := notebookArgument(params)
+	if err != nil {
+		return nil, err
+	}
+	return s.notebookDocument(uri), nil
+}
+
+func (s *Server) clearNotebook(ctx context.Context, params lsp.ExecuteCommandParams) (result interface{}, err error) {
+	uri, err := notebookArgument(params)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.notebooks, uri)
+	return s.notebookDocument(uri), nil
+}
+
+func notebookArgument(params lsp.ExecuteCommandParams) (string, error) {
+	if len(params.Arguments) == 0 {
+		return "", fmt.Errorf("required arguments were not provided: <File URI>")
+	}
+	uri, ok := params.Arguments[0].(string)
+	if !ok {
+		return "", fmt.Errorf("specify the file uri as a string")
+	}
+	return uri, nil
+}
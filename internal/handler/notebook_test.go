@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func Test_notebook(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	})
+
+	uri := "file:///work/report.sql"
+	tx.textDocumentDidOpen(t, uri, "CREATE TABLE item (id INTEGER); INSERT INTO item VALUES (1), (2);")
+	execute := func(command string, args ...interface{}) (lsp.TextDocumentItem, error) {
+		var got lsp.TextDocumentItem
+		err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+			Command:   command,
+			Arguments: append([]interface{}{uri}, args...),
+		}, &got)
+		return got, err
+	}
+	var res interface{}
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri},
+	}, &res); err != nil {
+		t.Fatal("create table:", err)
+	}
+	if _, ok := res.(string); !ok {
+		t.Fatalf("the results must be returned without -notebook, %v", res)
+	}
+
+	tx.setText(t, uri, "SELECT count(*) AS n FROM item;")
+	if _, err := execute(CommandExecuteQuery, "-notebook"); err != nil {
+		t.Fatal("execute:", err)
+	}
+	tx.setText(t, uri, "SELECT * FROM missing;")
+	if _, err := execute(CommandExecuteQuery, "-notebook"); err == nil {
+		t.Fatal("select from missing table must fail")
+	}
+
+	got, err := execute(CommandShowNotebook)
+	if err != nil {
+		t.Fatal("show notebook:", err)
+	}
+	want := lsp.TextDocumentItem{
+		URI:        "sqls:/notebook/report.sql.md",
+		LanguageID: "markdown",
+		Text: "# Notebook of report.sql\n" +
+			"\n" +
+			"```sql\n" +
+			"SELECT count(*) AS n FROM item;\n" +
+			"```\n" +
+			"\n" +
+			"```\n" +
+			"+---+\n" +
+			"| N |\n" +
+			"+---+\n" +
+			"| 2 |\n" +
+			"+---+\n" +
+			"1 rows in set\n" +
+			"```\n" +
+			"\n" +
+			"```sql\n" +
+			"SELECT * FROM missing;\n" +
+			"```\n" +
+			"\n" +
+			"Error: no such table: missing\n",
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched notebook (-want +got):\n%s", d)
+	}
+
+	got, err = execute(CommandClearNotebook)
+	if err != nil {
+		t.Fatal("clear notebook:", err)
+	}
+	if got.Text != "# Notebook of report.sql\n" {
+		t.Errorf("notebook is not cleared, %q", got.Text)
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/dbt"
+	"github.com/sqls-server/sqls/internal/migration"
+	"github.com/sqls-server/sqls/internal/sqlc"
+)
+
+// migrationOverlay is the cache of the database overlaid with the schema of
+// the migrations for a document.
+type migrationOverlay struct {
+	base  *database.DBCache
+	text  string
+	cache *database.DBCache
+}
+
+// loadProjects loads the projects of dbt and sqlc and the migrations in the
+// workspace. The projects with the errors are not loaded, not to stop the
+// server.
+func (s *Server) loadProjects() {
+	if err := s.loadDbtProject(); err != nil {
+		slog.Warn("cannot load dbt project", "err", err)
+	}
+	if err := s.loadSqlcProject(); err != nil {
+		slog.Warn("cannot load sqlc project", "err", err)
+	}
+	if err := s.loadMigrations(); err != nil {
+		slog.Warn("cannot load migrations", "err", err)
+	}
+}
+
+// loadMigrations finds the migration files in the workspace, whose DDL is
+// applied to the schema of the database in their order.
+func (s *Server) loadMigrations() error {
+	s.migrations, s.migrationOverlays = nil, map[string]*migrationOverlay{}
+	if s.rootPath == "" {
+		return nil
+	}
+	m, err := migration.Load(s.rootPath, s.documentDriver())
+	if err != nil {
+		return err
+	}
+	s.migrations = m
+	return nil
+}
+
+// loadSqlcProject loads the sqlc project at the workspace root, if it is.
+func (s *Server) loadSqlcProject() error {
+	s.sqlcProject, s.projectCache = nil, nil
+	if s.rootPath == "" || sqlc.ConfigPath(s.rootPath) == "" {
+		return nil
+	}
+	p, err := sqlc.Load(s.rootPath)
+	if err != nil {
+		return err
+	}
+	s.sqlcProject = p
+	return nil
+}
+
+// isProjectFile reports whether the file of the URI may change the projects,
+// which are reloaded on saving it.
+func (s *Server) isProjectFile(uri string) bool {
+	if s.rootPath == "" {
+		return false
+	}
+	fpath, ok := uriPath(uri)
+	if !ok {
+		return false
+	}
+	if fpath == dbt.ProjectPath(s.rootPath) || fpath == sqlc.ConfigPath(s.rootPath) {
+		return true
+	}
+	if s.dbtProject == nil && s.sqlcProject == nil && s.migrations == nil {
+		return migration.IsMigrationName(filepath.Base(fpath))
+	}
+	if rel, err := filepath.Rel(s.rootPath, fpath); err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(fpath)) {
+	case ".sql", ".csv", ".yml", ".yaml":
+		return true
+	}
+	return false
+}
+
+// uriPath returns the path of the file of the URI.
+func uriPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+// dbCache returns the cache of the database for the document of the URI, with
+// the relations of the projects and the tables of the migrations, which are
+// the tables even if the database is not connected or not migrated yet.
+func (s *Server) dbCache(uri string) *database.DBCache {
+	cache := s.projectDBCache()
+	if s.migrations == nil {
+		return cache
+	}
+	// The migration has the tables of the migrations before it and its text
+	// being edited, and the other documents the tables of all the migrations
+	key, text := "", ""
+	if fpath, ok := uriPath(uri); ok && s.migrations.IsMigration(fpath) {
+		key = fpath
+		if f, ok := s.files.get(uri); ok {
+			text = f.Text
+		}
+	}
+	if o, ok := s.migrationOverlays[key]; ok && o.base == cache && o.text == text {
+		return o.cache
+	}
+	overlaid := s.migrations.Schema(key, text).Overlay(cache)
+	s.migrationOverlays[key] = &migrationOverlay{base: cache, text: text, cache: overlaid}
+	return overlaid
+}
+
+// projectDBCache returns the cache of the database with the relations of the
+// projects.
+func (s *Server) projectDBCache() *database.DBCache {
+	cache := s.worker.Cache()
+	if s.dbtProject == nil && s.sqlcProject == nil {
+		return cache
+	}
+	if s.projectCache == nil || s.projectBaseCache != cache {
+		merged := cache
+		if s.sqlcProject != nil {
+			merged = s.sqlcProject.Merge(merged)
+		}
+		if s.dbtProject != nil {
+			merged = s.dbtProject.Merge(merged)
+		}
+		s.projectBaseCache, s.projectCache = cache, merged
+	}
+	return s.projectCache
+}
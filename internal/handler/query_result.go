@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/database"
+)
+
+// queryResult is the result of a statement returned by executeQuery with
+// -format=json, for the clients which render the rows by themselves such as
+// in the grids to sort and filter. The result sets of the procedures after
+// the first are moreResultSets.
+type queryResult struct {
+	Query          string          `json:"query"`
+	Columns        []resultColumn  `json:"columns"`
+	Rows           [][]interface{} `json:"rows"`
+	MoreResultSets []*resultSet    `json:"moreResultSets,omitempty"`
+	Stats          resultStats     `json:"stats"`
+}
+
+type resultSet struct {
+	Columns []resultColumn  `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// resultColumn is a column of the rows, whose type is the name of the type
+// of the database, empty if the driver does not tell it.
+type resultColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// resultStats are the duration of the statement in milliseconds and the
+// number of the rows returned, or affected by the statements which return
+// no rows, with the ID of the row inserted and the warnings of the database.
+type resultStats struct {
+	Duration     float64  `json:"duration"`
+	RowCount     int64    `json:"rowCount"`
+	LastInsertID *int64   `json:"lastInsertId,omitempty"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+// executeResult runs the query as executeStatements does, and returns its
+// result as the data.
+func (s *Server) executeResult(ctx context.Context, executor database.Executor, driver dialect.DatabaseDriver, query string, bindParams interface{}, copyFile string) (*queryResult, error) {
+	args, err := statementArgs(driver, query, bindParams)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &queryResult{
+		Query:   query,
+		Columns: []resultColumn{},
+		Rows:    [][]interface{}{},
+	}
+	var count int64
+	var summary *execSummary
+	start := time.Now()
+	if database.IsCopyFromStdin(query) {
+		_, count, err = s.copyFrom(ctx, executor, query, copyFile)
+	} else if _, isQuery := database.QueryExecType(query, ""); isQuery {
+		count, err = s.queryRows(ctx, executor, query, res, args...)
+	} else if summary, err = s.execSummary(ctx, executor, query, args...); err == nil {
+		count = summary.rowsAffected
+	}
+	duration := time.Since(start)
+	s.recordHistory(query, start, count, err)
+	if err != nil {
+		return nil, err
+	}
+	res.Stats.Duration = float64(duration.Microseconds()) / 1000
+	res.Stats.RowCount = count
+	if summary != nil {
+		res.Stats.LastInsertID = summary.lastInsertID
+		res.Stats.Warnings = summary.warnings
+	}
+	return res, nil
+}
+
+// queryRows runs the query, and sets the columns and the rows of the result
+// sets and the warnings of the database.
+func (s *Server) queryRows(ctx context.Context, executor database.Executor, query string, res *queryResult, args ...interface{}) (int64, error) {
+	var count int64
+	warnings, err := s.runQuery(ctx, executor, query, func(rows *sql.Rows) error {
+		for first := true; ; first = false {
+			set, err := s.scanResultSet(rows)
+			if err != nil {
+				return err
+			}
+			count += int64(len(set.Rows))
+			switch {
+			case first:
+				res.Columns, res.Rows = set.Columns, set.Rows
+			case len(set.Columns) > 0:
+				res.MoreResultSets = append(res.MoreResultSets, set)
+			}
+			if !rows.NextResultSet() {
+				return rows.Err()
+			}
+		}
+	}, args...)
+	if err != nil {
+		return 0, err
+	}
+	res.Stats.Warnings = warnings
+	return count, nil
+}
+
+// scanResultSet scans the columns and the rows of the current result set.
+func (s *Server) scanResultSet(rows *sql.Rows) (*resultSet, error) {
+	columns, err := database.Columns(rows)
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	set := &resultSet{Columns: []resultColumn{}}
+	for i, column := range columns {
+		set.Columns = append(set.Columns, resultColumn{Name: column, Type: types[i].DatabaseTypeName()})
+	}
+	formatter, err := database.NewResultFormatter(s.getConfig().ResultFormat, rows)
+	if err != nil {
+		return nil, err
+	}
+	set.Rows, err = formatter.ScanValues(rows)
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
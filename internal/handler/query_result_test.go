@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// resultSetsDriver is the driver whose procedure two_sets returns two result
+// sets as the procedures of SQL Server do. The other queries return no rows.
+type resultSetsDriver struct{}
+
+func (resultSetsDriver) Open(string) (driver.Conn, error) { return resultSetsConn{}, nil }
+
+type resultSetsConn struct{}
+
+func (resultSetsConn) Prepare(query string) (driver.Stmt, error) {
+	return resultSetsStmt{query: query}, nil
+}
+func (resultSetsConn) Close() error              { return nil }
+func (resultSetsConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type resultSetsStmt struct {
+	query string
+}
+
+func (resultSetsStmt) Close() error  { return nil }
+func (resultSetsStmt) NumInput() int { return -1 }
+func (resultSetsStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+func (s resultSetsStmt) Query([]driver.Value) (driver.Rows, error) {
+	if !strings.Contains(s.query, "two_sets") {
+		return &resultSetsRows{sets: []testResultSet{{}}}, nil
+	}
+	return &resultSetsRows{sets: []testResultSet{
+		{columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(1), "Kabul"}, {int64(2), "Qandahar"}}},
+		{columns: []string{"total"}, rows: [][]driver.Value{{int64(2)}}},
+	}}, nil
+}
+
+type testResultSet struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+type resultSetsRows struct {
+	sets []testResultSet
+	set  int
+	row  int
+}
+
+func (r *resultSetsRows) Columns() []string { return r.sets[r.set].columns }
+func (r *resultSetsRows) Close() error      { return nil }
+func (r *resultSetsRows) Next(dest []driver.Value) error {
+	rows := r.sets[r.set].rows
+	if r.row >= len(rows) {
+		return io.EOF
+	}
+	copy(dest, rows[r.row])
+	r.row++
+	return nil
+}
+func (r *resultSetsRows) HasNextResultSet() bool { return r.set+1 < len(r.sets) }
+func (r *resultSetsRows) NextResultSet() error {
+	if !r.HasNextResultSet() {
+		return io.EOF
+	}
+	r.set++
+	r.row = 0
+	return nil
+}
+
+func init() {
+	sql.Register("resultsets", resultSetsDriver{})
+	database.RegisterOpen("resultsets", func(connCfg *database.DBConfig) (*database.DBConnection, error) {
+		conn, err := sql.Open("resultsets", "")
+		if err != nil {
+			return nil, err
+		}
+		return &database.DBConnection{Conn: conn}, nil
+	})
+	database.RegisterFactory("resultsets", database.NewSQLite3DBRepository)
+}
+
+func Test_executeQueryResultSets(t *testing.T) {
+	tx := newTestContext()
+	tx.setup(t)
+	defer tx.tearDown()
+
+	tx.addWorkspaceConfig(t, &config.Config{
+		Connections: []*database.DBConfig{
+			{Driver: "resultsets"},
+		},
+	})
+	uri := "file:///test.sql"
+	tx.textDocumentDidOpen(t, uri, "EXEC two_sets;")
+
+	var got string
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri, "-show-vertical"},
+	}, &got); err != nil {
+		t.Fatal("executeQuery:", err)
+	}
+	for _, want := range []string{"name | Qandahar", "2 rows in set", "total | 2", "1 rows in set"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q is not in the result, got %q", want, got)
+		}
+	}
+
+	var results []*queryResult
+	if err := tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+		Command:   CommandExecuteQuery,
+		Arguments: []interface{}{uri, "-format=json"},
+	}, &results); err != nil {
+		t.Fatal("executeQuery json:", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("unexpected results, got %d", len(results))
+	}
+	res := results[0]
+	if len(res.Rows) != 2 || len(res.Columns) != 2 || res.Stats.RowCount != 3 {
+		t.Errorf("unexpected first result set, got %+v", res)
+	}
+	want := []*resultSet{
+		{Columns: []resultColumn{{Name: "total"}}, Rows: [][]interface{}{{float64(2)}}},
+	}
+	if !reflect.DeepEqual(res.MoreResultSets, want) {
+		t.Errorf("unexpected more result sets, got %+v, want %+v", res.MoreResultSets, want)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/sqls-server/sqls/ast"
 	"github.com/sqls-server/sqls/ast/astutil"
 	"github.com/sqls-server/sqls/internal/lsp"
-	"github.com/sqls-server/sqls/parser"
 	"github.com/sqls-server/sqls/parser/parseutil"
 	"github.com/sqls-server/sqls/token"
 )
@@ -24,20 +23,26 @@ func (s *Server) handleTextDocumentRename(ctx context.Context, conn *jsonrpc2.Co
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	res, err := rename(f.Text, params)
+	tmpl := s.templateText(f, true)
+	res, err := rename(f, tmpl.Text, params)
 	if err != nil {
 		return nil, err
 	}
+	for _, change := range res.DocumentChanges {
+		if err := checkTemplateEdits(tmpl, change.Edits); err != nil {
+			return nil, err
+		}
+	}
 	return res, nil
 }
 
-func rename(text string, params lsp.RenameParams) (*lsp.WorkspaceEdit, error) {
-	parsed, err := parser.Parse(text)
+func rename(f *File, text string, params lsp.RenameParams) (*lsp.WorkspaceEdit, error) {
+	parsed, err := f.parse(text)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// replURI is the document of the statements of the exec subcommand.
+const replURI = "sqls:/repl.sql"
+
+// UseConnection connects the connection of the alias or the 1-based index,
+// or the first one if it is empty, for the exec subcommand.
+func (s *Server) UseConnection(ctx context.Context, alias string) error {
+	if alias != "" {
+		index, err := s.connectionIndex(alias)
+		if err != nil {
+			return err
+		}
+		s.curConnectionIndex = index
+	}
+	if err := s.ConnectDatabase(ctx); err != nil {
+		return fmt.Errorf("cannot connect database, %w", err)
+	}
+	return nil
+}
+
+// ExecuteText runs the statements of the text as executeQuery does for a
+// document, and returns their results.
+func (s *Server) ExecuteText(ctx context.Context, text string, vertical bool) (_ string, err error) {
+	defer recoverError(&err, "executing statements")
+	if err := s.adoptReconnectedDB(ctx, nil); err != nil {
+		return "", err
+	}
+	s.files.put(replURI, &File{LanguageID: "sql", Text: text})
+	args := []interface{}{replURI}
+	if vertical {
+		args = append(args, "-show-vertical")
+	}
+	res, err := s.executeQuery(ctx, nil, lsp.ExecuteCommandParams{Command: CommandExecuteQuery, Arguments: args})
+	if err != nil {
+		return "", err
+	}
+	switch res := res.(type) {
+	case string:
+		return res, nil
+	case lsp.TextDocumentItem:
+		// The notebook of the statements
+		return res.Text, nil
+	}
+	return "", nil
+}
+
+// CompleteText returns the items to complete at the position of the text, as
+// the completion of a document does.
+func (s *Server) CompleteText(text string, pos lsp.Position) (_ []lsp.CompletionItem, err error) {
+	defer recoverError(&err, "completing statements")
+	f := &File{LanguageID: "sql", Text: text}
+	s.files.put(replURI, f)
+	return s.completionItems(context.Background(), f, lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: replURI},
+			Position:     pos,
+		},
+	})
+}
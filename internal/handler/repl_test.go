@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func TestExecuteText(t *testing.T) {
+	server := NewServer()
+	defer server.Stop()
+	server.SpecificFileCfg = &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:  "main",
+				Driver: "mock",
+			},
+			{
+				Alias:          "local",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	}
+	ctx := context.Background()
+	if err := server.UseConnection(ctx, "local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.UseConnection(ctx, "unknown"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	got, err := server.ExecuteText(ctx, "CREATE TABLE city (id INTEGER, name TEXT); INSERT INTO city VALUES (1, 'Tokyo');", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Query OK, 1 row affected") {
+		t.Errorf("unmatched result: %q", got)
+	}
+	got, err = server.ExecuteText(ctx, "SELECT name FROM city", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "name | Tokyo") {
+		t.Errorf("unmatched vertical result: %q", got)
+	}
+	if _, err := server.ExecuteText(ctx, "SELECT * FROM town", false); err == nil {
+		t.Error("expected error")
+	}
+
+	// The tables created are completed after connecting again
+	if err := server.UseConnection(ctx, "local"); err != nil {
+		t.Fatal(err)
+	}
+	items, err := server.CompleteText("SELECT * FROM ci", lsp.Position{Line: 0, Character: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, item := range items {
+		if item.Label == "city" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("city is not completed: %+v", items)
+	}
+}
+
+func TestDumpSchema(t *testing.T) {
+	server := NewServer()
+	defer server.Stop()
+	server.SpecificFileCfg = &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+		},
+	}
+	ctx := context.Background()
+	if err := server.UseConnection(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.ExecuteText(ctx, "CREATE TABLE country (code TEXT PRIMARY KEY, name TEXT NOT NULL); CREATE TABLE city (id INTEGER PRIMARY KEY, name TEXT DEFAULT 'unknown', country_code TEXT REFERENCES country (code));", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.UseConnection(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	dump, err := server.DumpSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dump, "FOREIGN KEY (country_code) REFERENCES country (code)") {
+		t.Errorf("foreign key is not dumped: %q", dump)
+	}
+
+	// The schema dump is read as the tables of the database
+	fpath := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(fpath, []byte(dump), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := server.SchemaDumpCache(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"city", "country"} {
+		want, _ := server.worker.Cache().ColumnDescs(name)
+		got, _ := cache.ColumnDescs(name)
+		if len(want) == 0 || len(got) != len(want) {
+			t.Fatalf("unmatched columns of %s: want %d, got %d", name, len(want), len(got))
+		}
+		for i := range want {
+			if got[i].Name != want[i].Name || got[i].Type != want[i].Type || got[i].Null != want[i].Null || got[i].IsPrimaryKey() != want[i].IsPrimaryKey() {
+				t.Errorf("unmatched column: want %+v, got %+v", want[i], got[i])
+			}
+		}
+	}
+}
+
+func TestSharedConnection(t *testing.T) {
+	registry := database.NewRegistry()
+	cfg := &config.Config{
+		Connections: []*database.DBConfig{
+			{
+				Alias:          "local",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+			},
+			{
+				Alias:          "other",
+				Driver:         "sqlite3",
+				DataSourceName: filepath.Join(t.TempDir(), "other.db"),
+			},
+		},
+	}
+	ctx := context.Background()
+	servers := []*Server{}
+	for i := 0; i < 2; i++ {
+		server := NewServer()
+		server.Registry = registry
+		server.SpecificFileCfg = cfg
+		if err := server.UseConnection(ctx, "local"); err != nil {
+			t.Fatal(err)
+		}
+		servers = append(servers, server)
+	}
+	first, second := servers[0], servers[1]
+	if first.dbConn.Conn != second.dbConn.Conn || first.worker != second.worker || registry.Len() != 1 {
+		t.Fatal("connection is not shared")
+	}
+	if _, err := first.ExecuteText(ctx, "CREATE TABLE city (id INTEGER, name TEXT)", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The documents and the connections chosen are of each session
+	if err := second.UseConnection(ctx, "other"); err != nil {
+		t.Fatal(err)
+	}
+	if registry.Len() != 2 || first.worker == second.worker {
+		t.Errorf("connection chosen by a session is shared, %d connections", registry.Len())
+	}
+	if err := second.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if registry.Len() != 1 {
+		t.Errorf("connection of the session stopped is kept, %d connections", registry.Len())
+	}
+	if _, err := first.ExecuteText(ctx, "SELECT * FROM city", false); err != nil {
+		t.Errorf("connection of the other session is closed, %v", err)
+	}
+	if err := first.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if registry.Len() != 0 {
+		t.Errorf("connection not used is kept, %d connections", registry.Len())
+	}
+}
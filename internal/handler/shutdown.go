@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// requestQueue serves the requests of a connection in their order on a
+// goroutine of its own, so that the connection keeps reading while a request
+// is served, and shutdown and $/cancelRequest can cancel the requests.
+type requestQueue struct {
+	h jsonrpc2.Handler
+
+	mu      sync.Mutex
+	pending []*queuedRequest
+	running *queuedRequest
+	ready   chan struct{}
+
+	stopOnce sync.Once
+	stopped  chan struct{}
+	exited   chan struct{}
+}
+
+type queuedRequest struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	conn   *jsonrpc2.Conn
+	req    *jsonrpc2.Request
+}
+
+func newRequestQueue(h jsonrpc2.Handler) *requestQueue {
+	q := &requestQueue{
+		h:       h,
+		ready:   make(chan struct{}, 1),
+		stopped: make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Handle queues the request. The requests queued and running are canceled
+// by shutdown, and the request of the ID by $/cancelRequest.
+func (q *requestQueue) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	switch req.Method {
+	case "shutdown":
+		q.cancel(func(*jsonrpc2.Request) bool { return true })
+	case "$/cancelRequest":
+		var params struct {
+			ID jsonrpc2.ID `json:"id"`
+		}
+		if req.Params != nil && json.Unmarshal(*req.Params, &params) == nil {
+			q.cancel(func(r *jsonrpc2.Request) bool { return !r.Notif && r.ID == params.ID })
+		}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	q.mu.Lock()
+	q.pending = append(q.pending, &queuedRequest{ctx: ctx, cancel: cancel, conn: conn, req: req})
+	q.mu.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// cancel cancels the requests queued and running which match.
+func (q *requestQueue) cancel(match func(*jsonrpc2.Request) bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running != nil && match(q.running.req) {
+		q.running.cancel()
+	}
+	for _, r := range q.pending {
+		if match(r.req) {
+			r.cancel()
+		}
+	}
+}
+
+func (q *requestQueue) run() {
+	defer close(q.exited)
+	for {
+		r := q.next()
+		if r == nil {
+			return
+		}
+		q.h.Handle(r.ctx, r.conn, r.req)
+		r.cancel()
+
+		q.mu.Lock()
+		q.running = nil
+		q.mu.Unlock()
+	}
+}
+
+// next returns the request to serve next, waiting for one to be queued. It
+// returns nil once the queue is stopped and empty.
+func (q *requestQueue) next() *queuedRequest {
+	for {
+		q.mu.Lock()
+		if len(q.pending) > 0 {
+			r := q.pending[0]
+			q.pending = q.pending[1:]
+			q.running = r
+			q.mu.Unlock()
+			return r
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.ready:
+		case <-q.stopped:
+			q.mu.Lock()
+			empty := len(q.pending) == 0
+			q.mu.Unlock()
+			if empty {
+				return nil
+			}
+		}
+	}
+}
+
+// stop cancels the requests, and waits for them to end. The requests queued
+// are still served with their contexts canceled, so that the notifications
+// such as exit read before the client disconnects take effect.
+func (q *requestQueue) stop() {
+	q.stopOnce.Do(func() {
+		q.cancel(func(*jsonrpc2.Request) bool { return true })
+		close(q.stopped)
+	})
+	<-q.exited
+}
+
+// Handler returns the handler of the connection of the client, which serves
+// the requests in their order while the connection keeps reading, so that
+// shutdown cancels the queries running.
+func (s *Server) Handler() jsonrpc2.Handler {
+	if s.queue == nil {
+		s.queue = newRequestQueue(jsonrpc2.HandlerWithError(s.Handle))
+	}
+	return s.queue
+}
+
+// Exited returns the channel closed once the client sends exit.
+func (s *Server) Exited() <-chan struct{} {
+	return s.exited
+}
+
+// ShutdownRequested reports whether the client has sent shutdown, which it
+// must before exit.
+func (s *Server) ShutdownRequested() bool {
+	return s.shutdown.Load()
+}
+
+// release rolls back the transactions and closes the connections, and stops
+// the background work on them.
+func (s *Server) release() error {
+	s.health.stop()
+	if reopened := s.health.take(); reopened != nil {
+		reopened.Close()
+	}
+	s.abandonWarmup()
+	s.rollbackTransactions()
+	s.closeStatementConnections()
+	s.closeKeptConnections(func(string) bool { return true })
+	s.ownWorker.Stop()
+	err := s.dbConn.Close()
+	s.dbConn = nil
+	return err
+}
+
+// abandonWarmup closes the connection being opened in the background once it
+// is opened, without waiting for it.
+func (s *Server) abandonWarmup() {
+	w := s.warmup
+	if w == nil {
+		return
+	}
+	s.warmup = nil
+	go func() {
+		<-w.done
+		if w.conn != nil {
+			w.conn.Close()
+		}
+	}()
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// endlessQuery runs until it is canceled.
+const endlessQuery = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c;"
+
+func TestRequestCancel(t *testing.T) {
+	tests := []struct {
+		name string
+		// cancel cancels the query of the request ID
+		cancel func(tx *TestContext, id jsonrpc2.ID) error
+		// shutdown is whether the server is shut down by cancel
+		shutdown bool
+	}{
+		{
+			name: "cancel request",
+			cancel: func(tx *TestContext, id jsonrpc2.ID) error {
+				return tx.conn.Notify(tx.ctx, "$/cancelRequest", map[string]interface{}{"id": id})
+			},
+		},
+		{
+			name: "shutdown",
+			cancel: func(tx *TestContext, id jsonrpc2.ID) error {
+				return tx.conn.Call(tx.ctx, "shutdown", nil, nil)
+			},
+			shutdown: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer()
+			tx := &TestContext{
+				h:      server.Handler(),
+				ctx:    context.Background(),
+				server: server,
+			}
+			tx.setup(t)
+			defer tx.tearDown()
+			defer server.Stop()
+
+			tx.addWorkspaceConfig(t, &config.Config{
+				Connections: []*database.DBConfig{
+					{
+						Driver:         "sqlite3",
+						DataSourceName: filepath.Join(t.TempDir(), "test.db"),
+					},
+				},
+			})
+			uri := "file:///endless.sql"
+			tx.textDocumentDidOpen(t, uri, endlessQuery)
+
+			id := jsonrpc2.ID{Num: 100}
+			var result interface{}
+			errc := make(chan error, 1)
+			go func() {
+				errc <- tx.conn.Call(tx.ctx, "workspace/executeCommand", lsp.ExecuteCommandParams{
+					Command:   CommandExecuteQuery,
+					Arguments: []interface{}{uri},
+				}, &result, jsonrpc2.PickID(id))
+			}()
+			waitRunning(t, server, id)
+
+			if err := tt.cancel(tx, id); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case err := <-errc:
+				if err == nil {
+					t.Fatalf("expected error of the query canceled, got %v", result)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("query is not canceled")
+			}
+			if got := server.ShutdownRequested(); got != tt.shutdown {
+				t.Errorf("unexpected shutdown %v, want %v", got, tt.shutdown)
+			}
+			if !tt.shutdown {
+				return
+			}
+
+			// The requests after shutdown are rejected
+			if server.dbConn != nil {
+				t.Error("database is not closed by shutdown")
+			}
+			err := tx.conn.Call(tx.ctx, "textDocument/hover", lsp.HoverParams{
+				TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+				},
+			}, nil)
+			var rpcErr *jsonrpc2.Error
+			if !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc2.CodeInvalidRequest {
+				t.Errorf("expected invalid request error, got %v", err)
+			}
+			if err := tx.conn.Notify(tx.ctx, "exit", nil); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-server.Exited():
+			case <-time.After(5 * time.Second):
+				t.Fatal("server is not exited")
+			}
+		})
+	}
+}
+
+// waitRunning waits for the request of the ID to be served.
+func waitRunning(t *testing.T, s *Server, id jsonrpc2.ID) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.queue.mu.Lock()
+		running := s.queue.running != nil && s.queue.running.req.ID == id
+		s.queue.mu.Unlock()
+		if running {
+			// Wait for the query to be sent to the database
+			time.Sleep(100 * time.Millisecond)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("request is not served")
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/sourcegraph/jsonrpc2"
 	"github.com/sqls-server/sqls/internal/database"
 	"github.com/sqls-server/sqls/internal/lsp"
-	"github.com/sqls-server/sqls/parser"
 	"github.com/sqls-server/sqls/parser/parseutil"
 	"github.com/sqls-server/sqls/token"
 )
@@ -23,24 +22,25 @@ func (s *Server) handleTextDocumentSignatureHelp(ctx context.Context, conn *json
 		return nil, err
 	}
 
-	f, ok := s.files[params.TextDocument.URI]
+	f, ok := s.files.get(params.TextDocument.URI)
 	if !ok {
 		return nil, fmt.Errorf("document not found: %s", params.TextDocument.URI)
 	}
 
-	res, err := SignatureHelp(f.Text, params, s.worker.Cache())
+	s.prepareDB(ctx, conn)
+	res, err := SignatureHelp(f, s.templateText(f, true).Text, params, s.dbCache(params.TextDocument.URI))
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
-func SignatureHelp(text string, params lsp.SignatureHelpParams, dbCache *database.DBCache) (*lsp.SignatureHelp, error) {
+func SignatureHelp(f *File, text string, params lsp.SignatureHelpParams, dbCache *database.DBCache) (*lsp.SignatureHelp, error) {
 	if dbCache == nil {
 		return nil, nil
 	}
 
-	parsed, err := parser.Parse(text)
+	parsed, err := f.parse(text)
 	if err != nil {
 		return nil, err
 	}
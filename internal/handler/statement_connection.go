@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/linter"
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/parser/parseutil"
+	"github.com/sqls-server/sqls/token"
+)
+
+// statementConnection is the connection of the statements given by their
+// comments, other than the connection of the document, with the cache of its
+// tables.
+type statementConnection struct {
+	conn   *database.DBConnection
+	worker *database.Worker
+	// own is whether the worker is of the connection, not shared by Registry
+	own bool
+	// err is the error of opening the connection, not to open it again until
+	// the connection of the document is reconnected
+	err error
+}
+
+// openStatementConnection returns the connection of the alias, which is
+// opened and whose tables are cached on the first call.
+func (s *Server) openStatementConnection(ctx context.Context, alias string) (*statementConnection, error) {
+	if sc, ok := s.statementConns[alias]; ok {
+		return sc, sc.err
+	}
+	sc := &statementConnection{}
+	index, err := s.connectionIndex(alias)
+	if err != nil {
+		return nil, err
+	}
+	connCfg := s.getConnection(index)
+	if s.Registry != nil {
+		sc.conn, sc.worker, sc.err = s.Registry.Acquire(ctx, connCfg)
+	} else {
+		sc.conn, sc.worker, sc.err = openWithOwnWorker(ctx, connCfg)
+		sc.own = true
+	}
+	if sc.err != nil {
+		sc.err = fmt.Errorf("cannot open connection %s, %w", alias, sc.err)
+	}
+	s.statementConns[alias] = sc
+	return sc, sc.err
+}
+
+// openWithOwnWorker opens the connection of the config with a worker of its
+// own caching its tables.
+func openWithOwnWorker(ctx context.Context, connCfg *database.DBConfig) (*database.DBConnection, *database.Worker, error) {
+	dbConn, err := database.Open(connCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, err := database.CreateRepository(connCfg.Driver, dbConn.Conn)
+	if err != nil {
+		dbConn.Close()
+		return nil, nil, err
+	}
+	worker := database.NewWorker()
+	worker.Start()
+	if err := worker.ReCache(ctx, repo); err != nil {
+		worker.Stop()
+		dbConn.Close()
+		return nil, nil, err
+	}
+	return dbConn, worker, nil
+}
+
+// closeStatementConnections closes the connections of the statements, which
+// are opened again with the config of the connection of the document.
+func (s *Server) closeStatementConnections() {
+	for alias, sc := range s.statementConns {
+		if sc.own && sc.worker != nil {
+			sc.worker.Stop()
+		}
+		sc.conn.Close()
+		delete(s.statementConns, alias)
+	}
+}
+
+// statementAlias returns the alias of the connection of the statement, given
+// by its comments or by the comments at the top of the file.
+func statementAlias(stmt *ast.Statement, fileConn string) string {
+	if alias := parseutil.ExtractMetadata(stmt).Connection(); alias != "" {
+		return alias
+	}
+	return fileConn
+}
+
+// otherStatementAlias returns the alias of the connection of the statement,
+// or empty if it is the connection of the document.
+func (s *Server) otherStatementAlias(stmt *ast.Statement, fileConn string) string {
+	alias := statementAlias(stmt, fileConn)
+	if s.curDBCfg != nil && alias == s.curDBCfg.Alias {
+		return ""
+	}
+	return alias
+}
+
+func parsedStatements(parsed ast.TokenList) []*ast.Statement {
+	var stmts []*ast.Statement
+	for _, node := range parsed.GetTokens() {
+		if stmt, ok := node.(*ast.Statement); ok {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// statementCache returns the cache and the driver of the connection of the
+// statement at the position, if the comments give another connection than the
+// one of the document.
+func (s *Server) statementCache(ctx context.Context, f *File, text string, pos lsp.Position) (*database.DBCache, dialect.DatabaseDriver, bool) {
+	if s.dbConn == nil {
+		return nil, "", false
+	}
+	parsed, err := f.parse(text)
+	if err != nil {
+		return nil, "", false
+	}
+	stmts := parsedStatements(parsed)
+	fileConn := parseutil.FileConnection(stmts)
+	p := token.Pos{Line: pos.Line, Col: pos.Character}
+	alias := ""
+	for _, stmt := range stmts {
+		if token.ComparePos(stmt.Pos(), p) <= 0 {
+			alias = s.otherStatementAlias(stmt, fileConn)
+		}
+	}
+	if alias == "" {
+		return nil, "", false
+	}
+	sc, err := s.openStatementConnection(ctx, alias)
+	if err != nil {
+		logging.FromContext(ctx).Warn("cannot use connection of statement", "alias", alias, "err", err)
+		return nil, "", false
+	}
+	return sc.worker.Cache(), sc.conn.Driver, true
+}
+
+// lintStatementConnections validates the statements of the other connections
+// than the one of the document with the tables of their connections, in place
+// of the problems found with the tables of the document.
+func (s *Server) lintStatementConnections(ctx context.Context, f *File, text string, diagnostics []lsp.Diagnostic) []lsp.Diagnostic {
+	if s.dbConn == nil {
+		return diagnostics
+	}
+	parsed, err := f.parse(text)
+	if err != nil {
+		return diagnostics
+	}
+	stmts := parsedStatements(parsed)
+	fileConn := parseutil.FileConnection(stmts)
+	ranges := map[string][]lsp.Range{}
+	for _, stmt := range stmts {
+		alias := s.otherStatementAlias(stmt, fileConn)
+		if alias == "" {
+			continue
+		}
+		ranges[alias] = append(ranges[alias], lsp.Range{
+			Start: lsp.Position{Line: stmt.Pos().Line, Character: stmt.Pos().Col},
+			End:   lsp.Position{Line: stmt.End().Line, Character: stmt.End().Col},
+		})
+	}
+	if len(ranges) == 0 {
+		return diagnostics
+	}
+
+	aliases := []string{}
+	for alias := range ranges {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	others := map[string][]lsp.Diagnostic{}
+	for _, alias := range aliases {
+		sc, err := s.openStatementConnection(ctx, alias)
+		if err != nil {
+			logging.FromContext(ctx).Warn("cannot use connection of statement", "alias", alias, "err", err)
+			continue
+		}
+		ds, err := linter.LintDocument(f.document(sc.conn.Driver), text, s.getConfig(), sc.conn.Driver, sc.worker.Cache())
+		if err != nil {
+			continue
+		}
+		others[alias] = ds
+	}
+
+	// The problems of the statements whose connections cannot be used are the
+	// ones found with the tables of the document
+	res := []lsp.Diagnostic{}
+	for _, d := range diagnostics {
+		alias, ok := diagnosticAlias(d, ranges)
+		if _, linted := others[alias]; !ok || !linted {
+			res = append(res, d)
+		}
+	}
+	for _, alias := range aliases {
+		for _, d := range others[alias] {
+			if a, ok := diagnosticAlias(d, ranges); ok && a == alias {
+				res = append(res, d)
+			}
+		}
+	}
+	return res
+}
+
+// diagnosticAlias returns the alias of the connection of the statement of the
+// problem.
+func diagnosticAlias(d lsp.Diagnostic, ranges map[string][]lsp.Range) (string, bool) {
+	for alias, rs := range ranges {
+		for _, r := range rs {
+			if !before(d.Range.Start, r.Start) && before(d.Range.Start, r.End) {
+				return alias, true
+			}
+		}
+	}
+	return "", false
+}
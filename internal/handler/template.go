@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/internal/template"
+)
+
+// templateText returns the text of the file to parse, whose regions of the
+// templates are replaced by the placeholders if the templates are enabled.
+// ref() and source() are replaced by the relations if resolveRefs is true and
+// they are configured to be. The files of the host languages such as Go are
+// the SQL in their strings.
+func (s *Server) templateText(f *File, resolveRefs bool) *template.Text {
+	if template.IsHostLanguage(f.LanguageID) {
+		return template.Embedded(f.Text, f.LanguageID)
+	}
+	cfg := s.getConfig().Template
+	if !cfg.Enabled() {
+		// The models of dbt are the templates of Jinja
+		if s.dbtProject != nil {
+			return template.Jinja(f.Text, resolveRefs)
+		}
+		return template.Plain(f.Text)
+	}
+	switch cfg.Engine {
+	case config.TemplateEngineGo:
+		return template.GoTemplate(f.Text)
+	case config.TemplateEnginePrintf:
+		return template.Printf(f.Text)
+	}
+	return template.Jinja(f.Text, resolveRefs && cfg.ResolveRefs)
+}
+
+// templateDiagnostics returns the diagnostics out of the placeholders, whose
+// problems are not of the source.
+func templateDiagnostics(tmpl *template.Text, diagnostics []lsp.Diagnostic) []lsp.Diagnostic {
+	if len(tmpl.Regions) == 0 {
+		return diagnostics
+	}
+	res := []lsp.Diagnostic{}
+	for _, d := range diagnostics {
+		if !tmpl.Hides(d.Range) {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+// checkTemplateEdits returns an error if the edits change the regions of the
+// templates.
+func checkTemplateEdits(tmpl *template.Text, edits []lsp.TextEdit) error {
+	for _, edit := range edits {
+		if tmpl.Overlaps(edit.Range) {
+			return fmt.Errorf("cannot edit the template at line %d", edit.Range.Start.Line+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// maxTraceParams is the bytes of the params of the requests in the verbose
+// traces, which are cut not to flood the client by the texts of the documents.
+const maxTraceParams = 1000
+
+func (s *Server) handleSetTrace(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+	var params lsp.SetTraceParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+	switch params.Value {
+	case lsp.TraceOff, lsp.TraceMessages, lsp.TraceVerbose:
+	default:
+		return nil, fmt.Errorf("invalid trace value %q", params.Value)
+	}
+	s.trace = params.Value
+	return nil, nil
+}
+
+// logTrace sends $/logTrace of the request served with its latency, and with
+// its params and its error if the trace is verbose.
+func (s *Server) logTrace(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request, duration time.Duration, err error) {
+	if s.trace == "" || s.trace == lsp.TraceOff {
+		return
+	}
+	switch req.Method {
+	case "$/setTrace", "exit":
+		return
+	}
+	kind := "request"
+	if req.Notif {
+		kind = "notification"
+	}
+	params := lsp.LogTraceParams{
+		Message: fmt.Sprintf("Served %s '%s' in %s.", kind, req.Method, duration.Round(time.Microsecond)),
+	}
+	if err != nil {
+		params.Message = fmt.Sprintf("Failed %s '%s' in %s.", kind, req.Method, duration.Round(time.Microsecond))
+	}
+	if s.trace == lsp.TraceVerbose {
+		if req.Params != nil {
+			text := string(*req.Params)
+			if len(text) > maxTraceParams {
+				text = text[:maxTraceParams] + "..."
+			}
+			params.Verbose = "Params: " + text
+		}
+		if err != nil {
+			if params.Verbose != "" {
+				params.Verbose += "\n"
+			}
+			params.Verbose += "Error: " + err.Error()
+		}
+	}
+	if err := conn.Notify(ctx, "$/logTrace", params); err != nil {
+		logging.FromContext(ctx).Debug("cannot send trace", "err", err)
+	}
+}
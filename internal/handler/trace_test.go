@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func TestSetTrace(t *testing.T) {
+	tx := newTestContext()
+	traces := make(chan *lsp.LogTraceParams, 10)
+	tx.clientHandler = jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method == "$/logTrace" {
+			var params lsp.LogTraceParams
+			if err := json.Unmarshal(*req.Params, &params); err != nil {
+				return nil, err
+			}
+			traces <- &params
+		}
+		return nil, nil
+	})
+	tx.setup(t)
+	defer tx.tearDown()
+
+	waitTrace := func() *lsp.LogTraceParams {
+		t.Helper()
+		select {
+		case params := <-traces:
+			return params
+		case <-time.After(5 * time.Second):
+			t.Fatal("no trace")
+		}
+		return nil
+	}
+	hover := func() {
+		t.Helper()
+		params := lsp.HoverParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{URI: testFileURI},
+			},
+		}
+		var got interface{}
+		if err := tx.conn.Call(tx.ctx, "textDocument/hover", params, &got); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	// The hovers fail as the document is not opened
+	if err := tx.conn.Notify(tx.ctx, "$/setTrace", lsp.SetTraceParams{Value: lsp.TraceMessages}); err != nil {
+		t.Fatal(err)
+	}
+	hover()
+	got := waitTrace()
+	if !strings.HasPrefix(got.Message, "Failed request 'textDocument/hover' in ") || got.Verbose != "" {
+		t.Errorf("unmatched trace: %+v", got)
+	}
+
+	if err := tx.conn.Notify(tx.ctx, "$/setTrace", lsp.SetTraceParams{Value: lsp.TraceVerbose}); err != nil {
+		t.Fatal(err)
+	}
+	hover()
+	got = waitTrace()
+	if !strings.Contains(got.Verbose, `"textDocument":{"uri":"`+testFileURI+`"}`) || !strings.Contains(got.Verbose, "Error: ") {
+		t.Errorf("unmatched verbose trace: %+v", got)
+	}
+
+	if err := tx.conn.Notify(tx.ctx, "$/setTrace", lsp.SetTraceParams{Value: lsp.TraceOff}); err != nil {
+		t.Fatal(err)
+	}
+	hover()
+	select {
+	case params := <-traces:
+		t.Errorf("unexpected trace: %+v", params)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
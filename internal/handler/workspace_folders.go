@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// workspaceFolder is a folder of a multi-root workspace, such as a service
+// of a monorepo, which has its own config and connections.
+type workspaceFolder struct {
+	uri  string
+	path string
+
+	cfg      *config.Config
+	sqlfluff *config.Sqlfluff
+}
+
+// folderPath returns the local path of the URI of a workspace folder.
+func folderPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path)), true
+}
+
+// addFolders loads the configs of the workspace folders and adds them, and
+// returns the errors of the configs which cannot be loaded. The folders are
+// added without the configs then.
+func (s *Server) addFolders(folders []lsp.WorkspaceFolder) []error {
+	var errs []error
+	for _, wf := range folders {
+		fpath, ok := folderPath(wf.URI)
+		if !ok || s.findFolder(wf.URI) != nil {
+			continue
+		}
+		f := &workspaceFolder{
+			uri:  wf.URI,
+			path: fpath,
+		}
+		cfg, sf, err := loadFolderConfig(fpath, s.trustsWorkspace(fpath))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("workspace folder %s, %w", wf.Name, err))
+		}
+		f.cfg, f.sqlfluff = cfg, sf
+		s.folders = append(s.folders, f)
+	}
+	return errs
+}
+
+// removeFolders removes the workspace folders, and reports whether the
+// current folder is removed.
+func (s *Server) removeFolders(folders []lsp.WorkspaceFolder) bool {
+	removed := false
+	for _, wf := range folders {
+		f := s.findFolder(wf.URI)
+		if f == nil {
+			continue
+		}
+		if f == s.curFolder {
+			removed = true
+		}
+		for i := range s.folders {
+			if s.folders[i] == f {
+				s.folders = append(s.folders[:i], s.folders[i+1:]...)
+				break
+			}
+		}
+	}
+	return removed
+}
+
+func (s *Server) findFolder(uri string) *workspaceFolder {
+	for _, f := range s.folders {
+		if f.uri == uri {
+			return f
+		}
+	}
+	return nil
+}
+
+// folderOf returns the innermost workspace folder of the path, nil if the
+// path is in none of them.
+func (s *Server) folderOf(fpath string) *workspaceFolder {
+	var found *workspaceFolder
+	for _, f := range s.folders {
+		if fpath != f.path && !strings.HasPrefix(fpath, f.path+string(filepath.Separator)) {
+			continue
+		}
+		if found == nil || len(f.path) > len(found.path) {
+			found = f
+		}
+	}
+	return found
+}
+
+// initialFolder returns the folder of the root path sent by the client, or
+// the first folder.
+func (s *Server) initialFolder() *workspaceFolder {
+	if len(s.folders) == 0 {
+		return nil
+	}
+	for _, f := range s.folders {
+		if f.path == s.rootPath {
+			return f
+		}
+	}
+	return s.folders[0]
+}
+
+// activateFolder makes the folder the root of the workspace, whose config
+// and projects are used until a document of another folder is opened.
+func (s *Server) activateFolder(f *workspaceFolder) {
+	s.curFolder = f
+	if f == nil {
+		s.rootPath, s.WorkspaceFileCfg, s.sqlfluffCfg = "", nil, nil
+	} else {
+		s.rootPath, s.WorkspaceFileCfg, s.sqlfluffCfg = f.path, f.cfg, f.sqlfluff
+	}
+	s.bookmarks = newBookmarkStore(s.rootPath)
+	s.loadProjects()
+}
+
+func (s *Server) handleWorkspaceDidChangeWorkspaceFolders(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.DidChangeWorkspaceFoldersParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	removed := s.removeFolders(params.Event.Removed)
+	messenger := lsp.NewMessenger(conn)
+	for _, err := range s.addFolders(params.Event.Added) {
+		logging.FromContext(ctx).Warn("cannot load workspace config", "err", err)
+		if err := messenger.ShowError(ctx, err.Error()); err != nil {
+			return nil, err
+		}
+	}
+	if !removed {
+		s.closeRemovedConnections()
+		return nil, nil
+	}
+
+	// The documents of the other folders switch to their own folders
+	if err := s.confirmSwitch(ctx, conn); err != nil {
+		// Keep the connection of the removed folder until the transactions end
+		return nil, messenger.ShowError(ctx, err.Error())
+	}
+	connected := s.dbConn != nil
+	s.keepConnection()
+	s.closeStatementConnections()
+	s.activateFolder(s.initialFolder())
+	s.curConnectionIndex, s.curDBName = 0, ""
+	s.closeRemovedConnections()
+	if err := s.useKeptConnection(ctx, conn, connected); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// closeRemovedConnections closes the connections kept for the workspace
+// folders removed, unless the transactions which may be of them are open.
+func (s *Server) closeRemovedConnections() {
+	if s.transactionsInProgress() != nil {
+		return
+	}
+	s.closeKeptConnections(func(root string) bool {
+		for _, f := range s.folders {
+			if f.path == root {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,171 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultMaxEntries = 1000
+
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+type Entry struct {
+	ID         int           `json:"id"`
+	Query      string        `json:"query"`
+	Connection string        `json:"connection"`
+	Database   string        `json:"database"`
+	ExecutedAt time.Time     `json:"executedAt"`
+	Duration   time.Duration `json:"duration"`
+	RowCount   int64         `json:"rowCount"`
+	Status     string        `json:"status"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Store keeps the executed queries. If path is not empty the entries are
+// persisted to it as JSON.
+type Store struct {
+	MaxEntries int
+
+	path    string
+	mu      sync.Mutex
+	loaded  bool
+	entries []*Entry
+}
+
+func NewStore(path string) *Store {
+	return &Store{
+		MaxEntries: DefaultMaxEntries,
+		path:       path,
+	}
+}
+
+func (s *Store) load() error {
+	if s.loaded || s.path == "" {
+		s.loaded = true
+		return nil
+	}
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.loaded = true
+			return nil
+		}
+		return fmt.Errorf("cannot read history, %w", err)
+	}
+	if err := json.Unmarshal(b, &s.entries); err != nil {
+		return fmt.Errorf("cannot unmarshal history, %w", err)
+	}
+	s.loaded = true
+	return nil
+}
+
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	b, err := json.Marshal(s.entries)
+	if err != nil {
+		return fmt.Errorf("cannot marshal history, %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("cannot create history directory, %w", err)
+	}
+	if err := os.WriteFile(s.path, b, 0600); err != nil {
+		return fmt.Errorf("cannot write history, %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Add(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	e.ID = 1
+	if len(s.entries) > 0 {
+		e.ID = s.entries[len(s.entries)-1].ID + 1
+	}
+	s.entries = append(s.entries, e)
+	if s.MaxEntries > 0 && len(s.entries) > s.MaxEntries {
+		s.entries = s.entries[len(s.entries)-s.MaxEntries:]
+	}
+	return s.save()
+}
+
+// List returns the latest entries, newest first. A limit of zero or less
+// returns all entries.
+func (s *Store) List(limit int) ([]*Entry, error) {
+	return s.Search("", limit)
+}
+
+// Search returns the latest entries whose query contains keyword, ignoring
+// case, newest first.
+func (s *Store) Search(keyword string, limit int) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	keyword = strings.ToLower(keyword)
+	results := []*Entry{}
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		e := s.entries[i]
+		if !strings.Contains(strings.ToLower(e.Query), keyword) {
+			continue
+		}
+		results = append(results, e)
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (s *Store) Get(id int) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	for _, e := range s.entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("history not found, id %d", id)
+}
+
+// RecentQueries returns up to limit distinct queries that succeeded, newest
+// first.
+func (s *Store) RecentQueries(limit int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	results := []string{}
+	for i := len(s.entries) - 1; i >= 0 && len(results) < limit; i-- {
+		e := s.entries[i]
+		if e.Status != StatusOK || seen[e.Query] {
+			continue
+		}
+		seen[e.Query] = true
+		results = append(results, e.Query)
+	}
+	return results, nil
+}
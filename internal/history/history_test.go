@@ -0,0 +1,95 @@
+package history
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func queries(entries []*Entry) []string {
+	res := []string{}
+	for _, e := range entries {
+		res = append(res, e.Query)
+	}
+	return res
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sqls", "history.json")
+	store := NewStore(path)
+	store.MaxEntries = 3
+	for _, e := range []*Entry{
+		{Query: "SELECT 1", Status: StatusOK},
+		{Query: "SELECT * FROM city", Status: StatusOK},
+		{Query: "SELECT * FROM country", Status: StatusError},
+		{Query: "select * from city", Status: StatusOK},
+		{Query: "SELECT * FROM city", Status: StatusOK},
+	} {
+		if err := store.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// reload from the persisted file
+	store = NewStore(path)
+
+	tests := []struct {
+		name string
+		fn   func() ([]string, error)
+		want []string
+	}{
+		{
+			name: "list",
+			fn: func() ([]string, error) {
+				entries, err := store.List(0)
+				return queries(entries), err
+			},
+			want: []string{"SELECT * FROM city", "select * from city", "SELECT * FROM country"},
+		},
+		{
+			name: "list with limit",
+			fn: func() ([]string, error) {
+				entries, err := store.List(1)
+				return queries(entries), err
+			},
+			want: []string{"SELECT * FROM city"},
+		},
+		{
+			name: "search ignore case",
+			fn: func() ([]string, error) {
+				entries, err := store.Search("FROM CITY", 0)
+				return queries(entries), err
+			},
+			want: []string{"SELECT * FROM city", "select * from city"},
+		},
+		{
+			name: "recent queries",
+			fn: func() ([]string, error) {
+				return store.RecentQueries(5)
+			},
+			want: []string{"SELECT * FROM city", "select * from city"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	e, err := store.Get(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Query != "SELECT * FROM city" {
+		t.Errorf("got %q", e.Query)
+	}
+	if _, err := store.Get(1); err == nil {
+		t.Error("trimmed entry must not be found")
+	}
+}
@@ -0,0 +1,124 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/token"
+)
+
+// featureAt returns the feature of the syntax starting at the index and the
+// index of its last token.
+func featureAt(toks []*lintToken, i int) (dialect.Feature, int, bool) {
+	tok := toks[i]
+	if tok.Kind == token.DoubleColon {
+		return dialect.FeatureDoubleColonCast, i, true
+	}
+	if tok.Kind != token.SQLKeyword || (i > 0 && toks[i-1].Kind == token.Period) {
+		return "", 0, false
+	}
+	next := func(n int) string {
+		if i+n < len(toks) {
+			return upperWord(toks[i+n])
+		}
+		return ""
+	}
+	prev := ""
+	if i > 0 {
+		prev = upperWord(toks[i-1])
+	}
+	isCall := i+1 < len(toks) && toks[i+1].Kind == token.LParen
+
+	switch upperWord(tok) {
+	case "RETURNING":
+		return dialect.FeatureReturning, i, true
+	case "FULL":
+		if next(1) == "OUTER" && next(2) == "JOIN" {
+			return dialect.FeatureFullOuterJoin, i + 2, true
+		}
+		if next(1) == "JOIN" {
+			return dialect.FeatureFullOuterJoin, i + 1, true
+		}
+	case "LIMIT":
+		return dialect.FeatureLimit, i, true
+	case "TOP":
+		switch prev {
+		case "SELECT", "DISTINCT", "ALL", "DELETE", "UPDATE":
+			return dialect.FeatureTop, i, true
+		}
+	case "FETCH":
+		if n := next(1); n == "FIRST" || n == "NEXT" {
+			return dialect.FeatureFetchFirst, i + 1, true
+		}
+	case "ILIKE":
+		return dialect.FeatureIlike, i, true
+	case "WITH":
+		// not WITH ROLLUP of GROUP BY and WITH (NOLOCK)
+		if (i == 0 || toks[i-1].Kind == token.LParen) && !isCall {
+			return dialect.FeatureCte, i, true
+		}
+	case "OVER":
+		if i > 0 && toks[i-1].Kind == token.RParen {
+			return dialect.FeatureWindowFunction, i, true
+		}
+	case "LATERAL":
+		return dialect.FeatureLateral, i, true
+	case "APPLY":
+		if prev == "CROSS" || prev == "OUTER" {
+			return dialect.FeatureApply, i, true
+		}
+	case "ON":
+		switch next(1) {
+		case "CONFLICT":
+			return dialect.FeatureOnConflict, i + 1, true
+		case "DUPLICATE":
+			return dialect.FeatureOnDuplicateKey, i + 1, true
+		}
+	case "DISTINCT":
+		if next(1) == "ON" && i+2 < len(toks) && toks[i+2].Kind == token.LParen {
+			return dialect.FeatureDistinctOn, i + 1, true
+		}
+	case "MERGE":
+		if i == 0 {
+			return dialect.FeatureMerge, i, true
+		}
+	}
+	return "", 0, false
+}
+
+// checkDialectCompatibility finds the syntax and the functions that the
+// database of the connection does not support.
+func checkDialectCompatibility(c *lintContext) []problem {
+	if c.driver == "" {
+		return nil
+	}
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		for i, tok := range toks {
+			if feature, end, ok := featureAt(toks, i); ok {
+				alternative, unsupported := dialect.Unsupported(c.driver, feature)
+				if !unsupported {
+					continue
+				}
+				message := fmt.Sprintf("%s is not supported by %s", feature, c.driver)
+				if alternative != "" {
+					message += ", " + alternative
+				}
+				problems = append(problems, problem{from: tok.From, to: toks[end].To, message: message})
+				continue
+			}
+			if i+1 < len(toks) && toks[i+1].Kind == token.LParen && (i == 0 || toks[i-1].Kind != token.Period) {
+				name := upperWord(tok)
+				if name != "" && dialect.UnsupportedFunction(c.driver, name) {
+					problems = append(problems, problem{
+						from:    tok.From,
+						to:      tok.To,
+						message: fmt.Sprintf("function %s is not supported by %s", name, c.driver),
+					})
+				}
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,497 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/token"
+)
+
+// tableRef is a table, a subquery or a function of FROM.
+type tableRef struct {
+	toks []*lintToken
+	// start is the index of the first token in the statement
+	start int
+	from  token.Pos
+	to    token.Pos
+	// nameEnd is the last token of the table name or the subquery
+	nameEnd  *lintToken
+	aliasTok *lintToken
+	// name is the table name without the schema, empty for the subqueries
+	name  string
+	alias string
+	join  bool
+	// intended is true for the joins that have no condition on purpose, such
+	// as CROSS JOIN, and for LATERAL and the functions that can refer to the
+	// other tables
+	intended bool
+	// condition is the tokens of ON or USING
+	condition    []*lintToken
+	hasCondition bool
+}
+
+func (r *tableRef) qualifier() string {
+	if r.alias != "" {
+		return r.alias
+	}
+	return r.name
+}
+
+func (r *tableRef) isReferredBy(qualifier string) bool {
+	return strings.EqualFold(r.alias, qualifier) || strings.EqualFold(r.name, qualifier)
+}
+
+// fromClause is FROM of a query with WHERE following it.
+type fromClause struct {
+	refs []*tableRef
+	end  token.Pos
+	// where is nil if the query has no WHERE
+	where     *lintToken
+	whereEnd  token.Pos
+	predicate []*lintToken
+}
+
+// checkMissingJoinCondition finds the tables that are joined without ON or
+// USING, or by comma without the condition in WHERE, which make the cartesian
+// products. The fixes add the conditions of the foreign keys.
+func checkMissingJoinCondition(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		for i, tok := range stmt.tokens {
+			if upperWord(tok) != "FROM" || tok.clause != "FROM" {
+				continue
+			}
+			fc := parseFrom(stmt.tokens, i)
+			problems = append(problems, c.checkFrom(fc)...)
+		}
+	}
+	return problems
+}
+
+func (c *lintContext) checkFrom(fc *fromClause) []problem {
+	refs := fc.refs
+	parents := make([]int, len(refs))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+	union := func(i, j int) {
+		parents[find(i)] = find(j)
+	}
+
+	for i, ref := range refs {
+		if i > 0 && (ref.join || ref.intended) {
+			union(i, i-1)
+		}
+		for _, pred := range splitPredicates(ref.condition) {
+			for _, j := range c.predicateRefs(pred, refs) {
+				union(i, j)
+			}
+		}
+	}
+	for _, pred := range splitPredicates(fc.predicate) {
+		in := c.predicateRefs(pred, refs)
+		for _, j := range in {
+			union(in[0], j)
+		}
+	}
+
+	problems := []problem{}
+	for i, ref := range refs {
+		if i == 0 || ref.intended {
+			continue
+		}
+		if ref.join {
+			if ref.hasCondition {
+				continue
+			}
+		} else if connected(i, find) {
+			continue
+		}
+		problems = append(problems, problem{
+			from:    ref.from,
+			to:      ref.to,
+			message: fmt.Sprintf("%s is joined without the join condition, which makes the cartesian product", ref.qualifier()),
+			fix:     c.joinConditionFix(fc, i),
+		})
+	}
+	return problems
+}
+
+// connected reports whether the table is joined to any of the tables before
+// it.
+func connected(i int, find func(int) int) bool {
+	for j := 0; j < i; j++ {
+		if find(i) == find(j) {
+			return true
+		}
+	}
+	return false
+}
+
+// predicateRefs returns the indexes of the tables that the predicate refers
+// to, or all the tables if it has a column that cannot be resolved. A
+// predicate with less than two columns does not join the tables.
+func (c *lintContext) predicateRefs(pred []*lintToken, refs []*tableRef) []int {
+	qualifiers, columns := columnRefs(pred)
+	if len(qualifiers)+len(columns) < 2 {
+		return nil
+	}
+	in := []int{}
+	for _, q := range qualifiers {
+		for i, ref := range refs {
+			if ref.isReferredBy(q) {
+				in = append(in, i)
+				break
+			}
+		}
+	}
+	for _, col := range columns {
+		resolved := c.tablesOfColumn(col, refs)
+		if len(resolved) == 0 {
+			all := []int{}
+			for i := range refs {
+				all = append(all, i)
+			}
+			return all
+		}
+		in = append(in, resolved...)
+	}
+	return in
+}
+
+// tablesOfColumn returns the indexes of the tables having the column.
+func (c *lintContext) tablesOfColumn(column string, refs []*tableRef) []int {
+	if c.dbCache == nil {
+		return nil
+	}
+	in := []int{}
+	for i, ref := range refs {
+		if ref.name == "" {
+			continue
+		}
+		cols, _ := c.dbCache.ColumnDescs(ref.name)
+		for _, col := range cols {
+			if strings.EqualFold(col.Name, column) {
+				in = append(in, i)
+				break
+			}
+		}
+	}
+	return in
+}
+
+// joinConditionFix returns the fix adding the condition of the foreign key
+// between the table and one of the tables before it.
+func (c *lintContext) joinConditionFix(fc *fromClause, i int) *fix {
+	if c.dbCache == nil {
+		return nil
+	}
+	ref := fc.refs[i]
+	var cond string
+	for _, other := range fc.refs[:i] {
+		fks := c.foreignKeys(ref.name, other.name)
+		if len(fks) == 0 {
+			continue
+		}
+		conds := []string{}
+		for _, cols := range *fks[0] {
+			own, others := cols[0], cols[1]
+			if !strings.EqualFold(own.Table, ref.name) {
+				own, others = others, own
+			}
+			conds = append(conds, fmt.Sprintf("%s.%s = %s.%s", ref.qualifier(), own.Name, other.qualifier(), others.Name))
+		}
+		cond = strings.Join(conds, " "+c.keyword("AND")+" ")
+		break
+	}
+	if cond == "" {
+		return nil
+	}
+
+	f := &fix{title: "Add join condition " + cond}
+	switch {
+	case ref.join:
+		f.edits = []edit{insertAt(ref.to, " "+c.keyword("ON")+" "+cond)}
+	case fc.where == nil:
+		f.edits = []edit{insertAt(fc.end, " "+c.keyword("WHERE")+" "+cond)}
+	case hasOr(fc.predicate):
+		f.edits = []edit{
+			insertAt(fc.where.To, " "+cond+" "+c.keyword("AND")+" ("),
+			insertAt(fc.whereEnd, ")"),
+		}
+	default:
+		f.edits = []edit{insertAt(fc.where.To, " "+cond+" "+c.keyword("AND"))}
+	}
+	return f
+}
+
+func (c *lintContext) foreignKeys(table, other string) []*database.ForeignKey {
+	if table == "" || other == "" {
+		return nil
+	}
+	for name, refs := range c.dbCache.ForeignKeys {
+		if !c.dbCache.MatchIdentifier(table, name) {
+			continue
+		}
+		for name, fks := range refs {
+			if c.dbCache.MatchIdentifier(other, name) {
+				return fks
+			}
+		}
+	}
+	return nil
+}
+
+// keyword returns the keyword in the case of the config, which is the same
+// as of the completion.
+func (c *lintContext) keyword(kw string) string {
+	keywordCase := c.cfg.KeywordCase
+	if keywordCase == "" {
+		keywordCase = ast.CaseUpper
+		if c.cfg.LowercaseKeywords {
+			keywordCase = ast.CaseLower
+		}
+	}
+	return keywordCase.Apply(kw)
+}
+
+var joinModifiers = map[string]bool{
+	"INNER":   true,
+	"LEFT":    true,
+	"RIGHT":   true,
+	"FULL":    true,
+	"OUTER":   true,
+	"CROSS":   true,
+	"NATURAL": true,
+}
+
+// parseFrom parses FROM at the index and WHERE after it.
+func parseFrom(toks []*lintToken, start int) *fromClause {
+	depth := toks[start].depth
+	inFrom := func(tok *lintToken) bool {
+		if tok.depth > depth {
+			return true
+		}
+		switch tok.clause {
+		case "FROM", "JOIN", "ON", "USING", "WITH":
+			// WITH of the table hints of T-SQL
+			return tok.depth == depth
+		}
+		return false
+	}
+
+	fc := &fromClause{end: toks[start].To}
+	var cur *tableRef
+	inCondition := false
+	join, intended := false, false
+	i := start + 1
+loop:
+	for ; i < len(toks) && inFrom(toks[i]); i++ {
+		tok := toks[i]
+		fc.end = tok.To
+		if tok.depth > depth {
+			if inCondition {
+				cur.condition = append(cur.condition, tok)
+			} else if cur != nil {
+				cur.to = tok.To
+			}
+			continue
+		}
+		word := upperWord(tok)
+		isFunc := i+1 < len(toks) && toks[i+1].Kind == token.LParen
+		switch {
+		case tok.Kind == token.Comma:
+			cur, inCondition = nil, false
+		case joinModifiers[word] && !isFunc:
+			if word == "CROSS" || word == "NATURAL" {
+				intended = true
+			}
+			cur, inCondition = nil, false
+		case word == "JOIN" || word == "STRAIGHT_JOIN" || word == "APPLY":
+			if word == "APPLY" {
+				intended = true
+			}
+			join = true
+			cur, inCondition = nil, false
+		case word == "ON" || word == "USING":
+			if cur == nil || !cur.join {
+				// USING of DELETE
+				break loop
+			}
+			cur.hasCondition = true
+			inCondition = true
+		case inCondition:
+			cur.condition = append(cur.condition, tok)
+		case cur == nil:
+			cur = &tableRef{start: i, from: tok.From, to: tok.To, join: join, intended: intended}
+			cur.toks = append(cur.toks, tok)
+			fc.refs = append(fc.refs, cur)
+			join, intended = false, false
+		default:
+			cur.toks = append(cur.toks, tok)
+			cur.to = tok.To
+		}
+	}
+	for _, ref := range fc.refs {
+		ref.resolve()
+	}
+
+	if i < len(toks) && toks[i].depth == depth && upperWord(toks[i]) == "WHERE" {
+		fc.where = toks[i]
+		fc.whereEnd = toks[i].To
+		for i++; i < len(toks); i++ {
+			tok := toks[i]
+			if tok.depth < depth || (tok.depth == depth && tok.clause != "WHERE") {
+				break
+			}
+			fc.predicate = append(fc.predicate, tok)
+			fc.whereEnd = tok.To
+		}
+	}
+	return fc
+}
+
+// resolve finds the name and the alias of the table from its tokens.
+func (r *tableRef) resolve() {
+	toks := r.toks
+	i := 0
+	switch {
+	case toks[0].Kind == token.LParen:
+		for i < len(toks) && toks[i].Kind != token.RParen {
+			i++
+		}
+		i++
+	case toks[0].Kind != token.SQLKeyword || upperWord(toks[0]) == "LATERAL":
+		r.intended = true
+		return
+	default:
+		for i < len(toks) && toks[i].Kind == token.SQLKeyword {
+			r.name = toks[i].Value.(*token.SQLWord).Value
+			i++
+			if i >= len(toks) || toks[i].Kind != token.Period {
+				break
+			}
+			i++
+		}
+		if i < len(toks) && toks[i].Kind == token.LParen {
+			r.name = ""
+			r.intended = true
+		}
+	}
+	if i > 0 && i <= len(toks) {
+		r.nameEnd = toks[i-1]
+	}
+	for ; i < len(toks); i++ {
+		if toks[i].Kind == token.LParen || toks[i].Kind == token.RParen {
+			continue
+		}
+		if upperWord(toks[i]) == "AS" && i+1 < len(toks) && toks[i+1].Kind == token.SQLKeyword {
+			r.aliasTok = toks[i+1]
+		} else if toks[i].Kind == token.SQLKeyword && keyword(toks[i].Token) == "" && !clauseKeywords[upperWord(toks[i])] {
+			r.aliasTok = toks[i]
+		}
+		if r.aliasTok != nil {
+			r.alias = r.aliasTok.Value.(*token.SQLWord).Value
+		}
+		return
+	}
+}
+
+// splitPredicates splits the condition by AND other than of BETWEEN.
+func splitPredicates(toks []*lintToken) [][]*lintToken {
+	if len(toks) == 0 {
+		return nil
+	}
+	depth := toks[0].depth
+	preds := [][]*lintToken{}
+	cur := []*lintToken{}
+	between := false
+	for _, tok := range toks {
+		if tok.depth == depth {
+			switch upperWord(tok) {
+			case "BETWEEN":
+				between = true
+			case "AND":
+				if between {
+					between = false
+					break
+				}
+				preds = append(preds, cur)
+				cur = []*lintToken{}
+				continue
+			}
+		}
+		cur = append(cur, tok)
+	}
+	return append(preds, cur)
+}
+
+// hasOr reports whether the condition has OR that is not in the parentheses.
+func hasOr(toks []*lintToken) bool {
+	for _, tok := range toks {
+		if tok.depth == toks[0].depth && upperWord(tok) == "OR" {
+			return true
+		}
+	}
+	return false
+}
+
+// columnRefs returns the tables of the qualified columns and the names of the
+// other columns of the predicate. The columns without the tables in the
+// subqueries are of the tables of the subqueries, so they are skipped.
+func columnRefs(toks []*lintToken) (qualifiers []string, columns []string) {
+	subquery := -1
+	for i := 0; i < len(toks); i++ {
+		tok := toks[i]
+		if subquery >= 0 && tok.depth < subquery {
+			subquery = -1
+		}
+		if tok.Kind == token.LParen && i+1 < len(toks) && subquery < 0 {
+			switch upperWord(toks[i+1]) {
+			case "SELECT", "WITH":
+				subquery = toks[i+1].depth
+			}
+		}
+		if tok.Kind != token.SQLKeyword || keyword(tok.Token) != "" {
+			continue
+		}
+		if i > 0 && toks[i-1].Kind == token.DoubleColon {
+			// type of the cast
+			continue
+		}
+		parts := []string{tok.Value.(*token.SQLWord).Value}
+		for i+2 < len(toks) && toks[i+1].Kind == token.Period && toks[i+2].Kind == token.SQLKeyword {
+			parts = append(parts, toks[i+2].Value.(*token.SQLWord).Value)
+			i += 2
+		}
+		if i+1 < len(toks) && toks[i+1].Kind == token.LParen {
+			// function
+			continue
+		}
+		if len(parts) > 1 {
+			qualifiers = append(qualifiers, parts[len(parts)-2])
+		} else if subquery < 0 {
+			columns = append(columns, parts[0])
+		}
+	}
+	return qualifiers, columns
+}
+
+// upperWord returns the upper case word of the token that is not quoted,
+// whether it is a keyword of the dialect or not.
+func upperWord(tok *lintToken) string {
+	word, ok := tok.Value.(*token.SQLWord)
+	if !ok || word.QuoteStyle != 0 {
+		return ""
+	}
+	return strings.ToUpper(word.Value)
+}
@@ -0,0 +1,319 @@
+package linter
+
+import (
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+	"github.com/sqls-server/sqls/parser"
+	"github.com/sqls-server/sqls/token"
+)
+
+const diagnosticSource = "sqls"
+
+// problem is a part of the text that breaks a rule.
+type problem struct {
+	from    token.Pos
+	to      token.Pos
+	message string
+	// fix is nil when the problem cannot be fixed automatically
+	fix *fix
+	// unused is true for the code that can be removed
+	unused bool
+}
+
+type fix struct {
+	title string
+	edits []edit
+}
+
+// edit replaces the text between the positions, which inserts the text if
+// they are the same.
+type edit struct {
+	from token.Pos
+	to   token.Pos
+	text string
+}
+
+func insertAt(pos token.Pos, text string) edit {
+	return edit{from: pos, to: pos, text: text}
+}
+
+// QuickFix is the edits fixing the problem of the diagnostic.
+type QuickFix struct {
+	Title      string
+	Diagnostic lsp.Diagnostic
+	Edits      []lsp.TextEdit
+}
+
+type rule func(c *lintContext) []problem
+
+var rules = map[string]rule{
+	config.LintRuleSelectStar:           checkSelectStar,
+	config.LintRuleImplicitCrossJoin:    checkImplicitCrossJoin,
+	config.LintRuleDeleteWithoutWhere:   checkDeleteWithoutWhere,
+	config.LintRuleKeywordCase:          checkKeywordCase,
+	config.LintRuleAmbiguousColumn:      checkAmbiguousColumn,
+	config.LintRuleNotEqual:             checkNotEqual,
+	config.LintRuleMissingJoinCondition: checkMissingJoinCondition,
+	config.LintRuleTypeMismatch:         checkTypeMismatch,
+	config.LintRuleEqualsNull:           checkEqualsNull,
+	config.LintRuleNotInNullable:        checkNotInNullable,
+	config.LintRuleUnusedCte:            checkUnusedCte,
+	config.LintRuleUnusedAlias:          checkUnusedAlias,
+	config.LintRuleUnusedColumn:         checkUnusedColumn,
+	config.LintRuleDialectCompatibility: checkDialectCompatibility,
+	config.LintRuleReservedWord:         checkReservedWord,
+	config.LintRuleSchemaMismatch:       checkSchemaMismatch,
+	config.LintRuleSetOperationColumns:  checkSetOperationColumns,
+	config.LintRuleSqlc:                 checkSqlc,
+}
+
+// ruleOrder is the order of the diagnostics of the rules at the same position.
+var ruleOrder = []string{
+	config.LintRuleSelectStar,
+	config.LintRuleImplicitCrossJoin,
+	config.LintRuleDeleteWithoutWhere,
+	config.LintRuleKeywordCase,
+	config.LintRuleAmbiguousColumn,
+	config.LintRuleNotEqual,
+	config.LintRuleMissingJoinCondition,
+	config.LintRuleTypeMismatch,
+	config.LintRuleEqualsNull,
+	config.LintRuleNotInNullable,
+	config.LintRuleUnusedCte,
+	config.LintRuleUnusedAlias,
+	config.LintRuleUnusedColumn,
+	config.LintRuleDialectCompatibility,
+	config.LintRuleReservedWord,
+	config.LintRuleSchemaMismatch,
+	config.LintRuleSetOperationColumns,
+	config.LintRuleSqlc,
+}
+
+var severities = map[string]lsp.DiagnosticSeverity{
+	config.LintSeverityError:       lsp.SeverityError,
+	config.LintSeverityWarning:     lsp.SeverityWarning,
+	config.LintSeverityInformation: lsp.SeverityInformation,
+	config.LintSeverityHint:        lsp.SeverityHint,
+}
+
+type lintContext struct {
+	cfg        *config.Config
+	driver     dialect.DatabaseDriver
+	statements []*statement
+	parsed     ast.TokenList
+	// dbCache is nil when the database is not connected
+	dbCache *database.DBCache
+}
+
+// Lint checks the text in the dialect of the database driver with the rules
+// enabled by the config. dbCache may be nil, then the rules that need the
+// columns of the tables are skipped.
+func Lint(text string, cfg *config.Config, driver dialect.DatabaseDriver, dbCache *database.DBCache) ([]lsp.Diagnostic, error) {
+	return LintDocument(nil, text, cfg, driver, dbCache)
+}
+
+// LintDocument is Lint of the new version of the document, parsing again only
+// the statements changed. doc must be of the dialect of the driver, and may be
+// nil to parse the whole text.
+func LintDocument(doc *parser.Document, text string, cfg *config.Config, driver dialect.DatabaseDriver, dbCache *database.DBCache) ([]lsp.Diagnostic, error) {
+	results, err := lint(doc, text, cfg, driver, dbCache)
+	if err != nil {
+		return nil, err
+	}
+	diagnostics := []lsp.Diagnostic{}
+	for _, r := range results {
+		diagnostics = append(diagnostics, r.diagnostic)
+	}
+	return diagnostics, nil
+}
+
+// QuickFixes returns the fixes of the problems that Lint finds in the text.
+func QuickFixes(text string, cfg *config.Config, driver dialect.DatabaseDriver, dbCache *database.DBCache) ([]QuickFix, error) {
+	results, err := lint(nil, text, cfg, driver, dbCache)
+	if err != nil {
+		return nil, err
+	}
+	fixes := []QuickFix{}
+	for _, r := range results {
+		if r.fix == nil {
+			continue
+		}
+		edits := []lsp.TextEdit{}
+		for _, e := range r.fix.edits {
+			edits = append(edits, lsp.TextEdit{
+				Range: lsp.Range{
+					Start: lsp.Position{Line: e.from.Line, Character: e.from.Col},
+					End:   lsp.Position{Line: e.to.Line, Character: e.to.Col},
+				},
+				NewText: e.text,
+			})
+		}
+		fixes = append(fixes, QuickFix{
+			Title:      r.fix.title,
+			Diagnostic: r.diagnostic,
+			Edits:      edits,
+		})
+	}
+	return fixes, nil
+}
+
+type result struct {
+	diagnostic lsp.Diagnostic
+	fix        *fix
+}
+
+func lint(doc *parser.Document, text string, cfg *config.Config, driver dialect.DatabaseDriver, dbCache *database.DBCache) ([]result, error) {
+	d := dialect.ForDriver(driver)
+	tokens, err := token.NewTokenizer(strings.NewReader(text), d).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		doc = parser.NewDocument(d)
+	}
+	parsed, err := doc.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	c := &lintContext{
+		cfg:        cfg,
+		driver:     driver,
+		statements: splitStatements(tokens),
+		parsed:     parsed,
+		dbCache:    dbCache,
+	}
+
+	results := []result{}
+	for _, name := range ruleOrder {
+		severity, ok := severities[cfg.Lint.RuleSeverity(name)]
+		if !ok {
+			continue
+		}
+		code := name
+		source := diagnosticSource
+		for _, p := range rules[name](c) {
+			var tags []lsp.DiagnosticTag
+			if p.unused {
+				tags = []lsp.DiagnosticTag{lsp.DiagnosticTagUnnecessary}
+			}
+			results = append(results, result{
+				diagnostic: lsp.Diagnostic{
+					Range: lsp.Range{
+						Start: lsp.Position{Line: p.from.Line, Character: p.from.Col},
+						End:   lsp.Position{Line: p.to.Line, Character: p.to.Col},
+					},
+					Severity: severity,
+					Code:     &code,
+					Source:   &source,
+					Message:  p.message,
+					Tags:     tags,
+				},
+				fix: p.fix,
+			})
+		}
+	}
+	return results, nil
+}
+
+// lintToken is a token with the nesting of the parentheses and the clause
+// that it is in.
+type lintToken struct {
+	*token.Token
+	depth int
+	// clause is the keyword starting the clause at the depth, such as SELECT
+	// and FROM
+	clause string
+}
+
+type statement struct {
+	tokens []*lintToken
+}
+
+var clauseKeywords = map[string]bool{
+	"SELECT":    true,
+	"FROM":      true,
+	"WHERE":     true,
+	"GROUP":     true,
+	"ORDER":     true,
+	"HAVING":    true,
+	"LIMIT":     true,
+	"OFFSET":    true,
+	"UNION":     true,
+	"INTERSECT": true,
+	"EXCEPT":    true,
+	"INSERT":    true,
+	"INTO":      true,
+	"VALUES":    true,
+	"UPDATE":    true,
+	"SET":       true,
+	"DELETE":    true,
+	"JOIN":      true,
+	"ON":        true,
+	"USING":     true,
+	"RETURNING": true,
+	"WINDOW":    true,
+	"WITH":      true,
+}
+
+// splitStatements splits the tokens other than the whitespaces and the
+// comments into the statements.
+func splitStatements(tokens []*token.Token) []*statement {
+	statements := []*statement{}
+	cur := &statement{}
+	clauses := []string{""}
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case token.Whitespace, token.Comment, token.MultilineComment:
+			continue
+		case token.Semicolon:
+			if len(cur.tokens) > 0 {
+				statements = append(statements, cur)
+			}
+			cur = &statement{}
+			clauses = []string{""}
+			continue
+		case token.LParen:
+			cur.tokens = append(cur.tokens, &lintToken{Token: tok, depth: len(clauses) - 1, clause: clauses[len(clauses)-1]})
+			clauses = append(clauses, "")
+			continue
+		case token.RParen:
+			if len(clauses) > 1 {
+				clauses = clauses[:len(clauses)-1]
+			}
+		}
+		if kw := keyword(tok); clauseKeywords[kw] && !isDistinctFrom(kw, cur.tokens) && !isQualified(cur.tokens) {
+			clauses[len(clauses)-1] = kw
+		}
+		cur.tokens = append(cur.tokens, &lintToken{Token: tok, depth: len(clauses) - 1, clause: clauses[len(clauses)-1]})
+	}
+	if len(cur.tokens) > 0 {
+		statements = append(statements, cur)
+	}
+	return statements
+}
+
+// isDistinctFrom reports whether the keyword is FROM of IS DISTINCT FROM.
+func isDistinctFrom(kw string, prev []*lintToken) bool {
+	return kw == "FROM" && len(prev) > 0 && keyword(prev[len(prev)-1].Token) == "DISTINCT"
+}
+
+// isQualified reports whether the next token is qualified by the previous
+// tokens, such as order of o.order, which is not a keyword.
+func isQualified(prev []*lintToken) bool {
+	return len(prev) > 0 && prev[len(prev)-1].Kind == token.Period
+}
+
+// keyword returns the upper case keyword of the token, or an empty string if
+// the token is not a keyword.
+func keyword(tok *token.Token) string {
+	word, ok := tok.Value.(*token.SQLWord)
+	if !ok || tok.Kind != token.SQLKeyword || word.Kind == dialect.Unmatched || word.QuoteStyle != 0 {
+		return ""
+	}
+	return strings.ToUpper(word.Value)
+}
@@ -0,0 +1,732 @@
+package linter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+type lintResult struct {
+	Code     string
+	Severity lsp.DiagnosticSeverity
+	Range    lsp.Range
+	Message  string
+}
+
+func rng(startLine, startChar, endLine, endChar int) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: startLine, Character: startChar},
+		End:   lsp.Position{Line: endLine, Character: endChar},
+	}
+}
+
+func allRules(severity string) *config.Lint {
+	return &config.Lint{
+		Rules: map[string]string{
+			config.LintRuleSelectStar:           severity,
+			config.LintRuleImplicitCrossJoin:    severity,
+			config.LintRuleDeleteWithoutWhere:   severity,
+			config.LintRuleKeywordCase:          severity,
+			config.LintRuleAmbiguousColumn:      severity,
+			config.LintRuleNotEqual:             severity,
+			config.LintRuleMissingJoinCondition: severity,
+			config.LintRuleTypeMismatch:         severity,
+			config.LintRuleEqualsNull:           severity,
+			config.LintRuleNotInNullable:        severity,
+			config.LintRuleUnusedCte:            severity,
+			config.LintRuleUnusedAlias:          severity,
+			config.LintRuleUnusedColumn:         severity,
+			config.LintRuleDialectCompatibility: severity,
+			config.LintRuleReservedWord:         severity,
+			config.LintRuleSchemaMismatch:       severity,
+			config.LintRuleSetOperationColumns:  severity,
+			config.LintRuleSqlc:                 severity,
+		},
+	}
+}
+
+func onlyRule(rule, severity string) *config.Lint {
+	lint := allRules(config.LintSeverityOff)
+	lint.Rules[rule] = severity
+	return lint
+}
+
+func TestLint(t *testing.T) {
+	dbCache, err := database.NewDBCacheUpdater(database.NewMockDBRepository(nil)).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name     string
+		input    string
+		cfg      *config.Config
+		driver   dialect.DatabaseDriver
+		noCache  bool
+		expected []lintResult
+	}{
+		{
+			name:  "SelectStar",
+			input: "SELECT * FROM city; SELECT c.*, COUNT(*), a * b FROM city c",
+			cfg:   &config.Config{Lint: allRules(config.LintSeverityInformation)},
+			expected: []lintResult{
+				{config.LintRuleSelectStar, lsp.SeverityInformation, rng(0, 7, 0, 8), "SELECT * depends on the columns of the tables, list the columns"},
+				{config.LintRuleSelectStar, lsp.SeverityInformation, rng(0, 27, 0, 30), "SELECT * depends on the columns of the tables, list the columns"},
+			},
+		},
+		{
+			name:   "SelectTopStar",
+			input:  "SELECT TOP 10 * FROM city",
+			cfg:    &config.Config{Lint: allRules(config.LintSeverityHint)},
+			driver: dialect.DatabaseDriverMssql,
+			expected: []lintResult{
+				{config.LintRuleSelectStar, lsp.SeverityHint, rng(0, 14, 0, 15), "SELECT * depends on the columns of the tables, list the columns"},
+			},
+		},
+		{
+			name:  "ImplicitCrossJoin",
+			input: "SELECT ID FROM city, (SELECT Code, Name FROM country) c WHERE ID IN (1, 2)",
+			cfg:   &config.Config{},
+			expected: []lintResult{
+				{config.LintRuleImplicitCrossJoin, lsp.SeverityWarning, rng(0, 19, 0, 20), "implicit cross join, use JOIN with the join condition"},
+				{config.LintRuleMissingJoinCondition, lsp.SeverityWarning, rng(0, 21, 0, 55), "c is joined without the join condition, which makes the cartesian product"},
+			},
+		},
+		{
+			name:  "DeleteWithoutWhere",
+			input: "DELETE FROM city;\nDELETE FROM city WHERE ID IN (SELECT ID FROM city);\ndelete from city",
+			cfg:   &config.Config{},
+			expected: []lintResult{
+				{config.LintRuleDeleteWithoutWhere, lsp.SeverityWarning, rng(0, 0, 0, 6), "DELETE without WHERE deletes all the rows"},
+				{config.LintRuleDeleteWithoutWhere, lsp.SeverityWarning, rng(2, 0, 2, 6), "DELETE without WHERE deletes all the rows"},
+			},
+		},
+		{
+			name:     "DeleteInSubqueryWithoutWhere",
+			input:    "WITH d AS (DELETE FROM city RETURNING ID) SELECT ID FROM d WHERE ID = 1",
+			cfg:      &config.Config{},
+			expected: []lintResult{},
+		},
+		{
+			name:  "KeywordCaseOfFirstKeyword",
+			input: "select ID From city WHERE ID = 1",
+			cfg: &config.Config{Lint: &config.Lint{Rules: map[string]string{
+				config.LintRuleKeywordCase: config.LintSeverityWarning,
+			}}},
+			expected: []lintResult{
+				{config.LintRuleKeywordCase, lsp.SeverityWarning, rng(0, 10, 0, 14), "keyword From is not in lower case"},
+				{config.LintRuleKeywordCase, lsp.SeverityWarning, rng(0, 20, 0, 25), "keyword WHERE is not in lower case"},
+			},
+		},
+		{
+			name:  "KeywordCaseOfConfig",
+			input: "select ID FROM city",
+			cfg: &config.Config{
+				KeywordCase: ast.CaseUpper,
+				Lint: &config.Lint{Rules: map[string]string{
+					config.LintRuleKeywordCase: config.LintSeverityError,
+				}},
+			},
+			expected: []lintResult{
+				{config.LintRuleKeywordCase, lsp.SeverityError, rng(0, 0, 0, 6), "keyword select is not in upper case"},
+			},
+		},
+		{
+			name:  "NotEqual",
+			input: "SELECT ID FROM city WHERE ID != 1 AND ID <> 2",
+			cfg:   &config.Config{Lint: allRules(config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleNotEqual, lsp.SeverityWarning, rng(0, 29, 0, 31), "use <> instead of !=, which is the standard SQL operator"},
+			},
+		},
+		{
+			name:  "AmbiguousColumn",
+			input: "SELECT Name, ID, ci.CountryCode FROM city ci JOIN country co ON ci.CountryCode = co.Code WHERE Name = 'Tokyo'",
+			cfg:   &config.Config{},
+			expected: []lintResult{
+				{config.LintRuleAmbiguousColumn, lsp.SeverityWarning, rng(0, 7, 0, 11), "ambiguous column Name, which is in city, country"},
+				{config.LintRuleAmbiguousColumn, lsp.SeverityWarning, rng(0, 95, 0, 99), "ambiguous column Name, which is in city, country"},
+			},
+		},
+		{
+			name:     "AmbiguousColumnWithoutCache",
+			input:    "SELECT Name FROM city JOIN country ON city.CountryCode = country.Code",
+			cfg:      &config.Config{},
+			noCache:  true,
+			expected: []lintResult{},
+		},
+		{
+			name: "MissingJoinCondition",
+			input: "SELECT * FROM city ci JOIN country co;\n" +
+				"SELECT * FROM city ci LEFT OUTER JOIN country AS co ON ci.CountryCode = co.Code JOIN countrylanguage cl USING (CountryCode);\n" +
+				"SELECT * FROM city CROSS JOIN country NATURAL JOIN countrylanguage",
+			cfg: &config.Config{Lint: onlyRule(config.LintRuleMissingJoinCondition, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleMissingJoinCondition, lsp.SeverityWarning, rng(0, 27, 0, 37), "co is joined without the join condition, which makes the cartesian product"},
+			},
+		},
+		{
+			name: "MissingJoinConditionOfComma",
+			input: "SELECT * FROM city ci, country co WHERE ci.ID = 1;\n" +
+				"SELECT * FROM city ci, country co WHERE ci.ID = 1 AND co.Code = ci.CountryCode;\n" +
+				"SELECT * FROM city ci, country co, countrylanguage cl WHERE cl.CountryCode = co.Code AND co.Population BETWEEN 1 AND 10;\n" +
+				"SELECT * FROM city ci, country co WHERE ci.CountryCode IN (SELECT Code FROM country WHERE Code = co.Code)",
+			cfg: &config.Config{Lint: onlyRule(config.LintRuleMissingJoinCondition, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleMissingJoinCondition, lsp.SeverityWarning, rng(0, 23, 0, 33), "co is joined without the join condition, which makes the cartesian product"},
+				{config.LintRuleMissingJoinCondition, lsp.SeverityWarning, rng(2, 23, 2, 33), "co is joined without the join condition, which makes the cartesian product"},
+			},
+		},
+		{
+			name:  "MissingJoinConditionOfColumnsWithoutTables",
+			input: "SELECT * FROM city, country WHERE CountryCode = Code; SELECT * FROM city, country WHERE Name = 'Tokyo' AND Continent = 'Asia'",
+			cfg:   &config.Config{Lint: onlyRule(config.LintRuleMissingJoinCondition, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleMissingJoinCondition, lsp.SeverityWarning, rng(0, 74, 0, 81), "country is joined without the join condition, which makes the cartesian product"},
+			},
+		},
+		{
+			name:    "MissingJoinConditionWithoutCache",
+			input:   "SELECT * FROM city, country WHERE CountryCode = Code; SELECT * FROM city, country WHERE city.ID = 1",
+			noCache: true,
+			cfg:     &config.Config{Lint: onlyRule(config.LintRuleMissingJoinCondition, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleMissingJoinCondition, lsp.SeverityWarning, rng(0, 74, 0, 81), "country is joined without the join condition, which makes the cartesian product"},
+			},
+		},
+		{
+			name:     "MissingJoinConditionOfIntendedJoins",
+			input:    "SELECT * FROM city c, LATERAL (SELECT * FROM country WHERE Code = c.CountryCode) co; SELECT * FROM city c, generate_series(1, 3) g; SELECT * FROM city c CROSS APPLY f(c.ID) x",
+			cfg:      &config.Config{Lint: onlyRule(config.LintRuleMissingJoinCondition, config.LintSeverityWarning)},
+			expected: []lintResult{},
+		},
+		{
+			name: "EqualsNull",
+			input: "SELECT ID FROM city WHERE District = NULL OR NULL <> c.Name OR ID = NULL + 1;\n" +
+				"UPDATE city SET District = NULL WHERE District IS NULL",
+			cfg: &config.Config{Lint: onlyRule(config.LintRuleEqualsNull, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleEqualsNull, lsp.SeverityWarning, rng(0, 35, 0, 41), "comparison with NULL is never true, use IS NULL"},
+				{config.LintRuleEqualsNull, lsp.SeverityWarning, rng(0, 45, 0, 52), "comparison with NULL is never true, use IS NOT NULL"},
+			},
+		},
+		{
+			name: "NotInNullable",
+			input: "SELECT Name FROM city WHERE ID NOT IN (SELECT Capital FROM country);\n" +
+				"SELECT Name FROM city WHERE ID NOT IN (SELECT DISTINCT co.Capital FROM country co WHERE co.Capital IS NOT NULL);\n" +
+				"SELECT Name FROM city WHERE CountryCode NOT IN (SELECT Code FROM country) AND ID IN (SELECT Capital FROM country)",
+			cfg: &config.Config{Lint: onlyRule(config.LintRuleNotInNullable, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleNotInNullable, lsp.SeverityWarning, rng(0, 31, 0, 37), "NOT IN returns no rows if Capital of the subquery is NULL, use NOT EXISTS or exclude NULL"},
+			},
+		},
+		{
+			name: "UnusedCte",
+			input: "WITH a AS (SELECT 1), b (x) AS (SELECT 2), r AS (SELECT 1 UNION ALL SELECT n FROM r) SELECT * FROM b;\n" +
+				"WITH d AS (DELETE FROM city WHERE ID = 1 RETURNING ID) SELECT 1",
+			cfg: &config.Config{Lint: onlyRule(config.LintRuleUnusedCte, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleUnusedCte, lsp.SeverityWarning, rng(0, 5, 0, 6), "CTE a is not used"},
+				{config.LintRuleUnusedCte, lsp.SeverityWarning, rng(0, 43, 0, 44), "CTE r is not used"},
+			},
+		},
+		{
+			name:  "UnusedAlias",
+			input: "SELECT ID FROM city c JOIN country AS co ON c.CountryCode = Code; SELECT e.ID FROM city e JOIN city m ON e.ID = m.ID; DELETE c FROM city c",
+			cfg:   &config.Config{Lint: onlyRule(config.LintRuleUnusedAlias, config.LintSeverityHint)},
+			expected: []lintResult{
+				{config.LintRuleUnusedAlias, lsp.SeverityHint, rng(0, 38, 0, 40), "alias co of country is not used"},
+			},
+		},
+		{
+			name: "UnusedColumn",
+			input: "SELECT t.a, b FROM (SELECT ID AS a, Name b, CountryCode, COUNT(*) n, Population + 1 FROM city GROUP BY 1 ORDER BY n) t;\n" +
+				"SELECT * FROM (SELECT ID, Name FROM city) t;\n" +
+				"SELECT x.ID FROM (SELECT DISTINCT ID, Name FROM city) x",
+			cfg: &config.Config{Lint: onlyRule(config.LintRuleUnusedColumn, config.LintSeverityInformation)},
+			expected: []lintResult{
+				{config.LintRuleUnusedColumn, lsp.SeverityInformation, rng(0, 44, 0, 55), "column CountryCode of t is not used"},
+			},
+		},
+		{
+			name: "DialectCompatibilityOfMySQL57",
+			input: "WITH c AS (SELECT ID, ROW_NUMBER() OVER (ORDER BY ID) FROM city) SELECT * FROM c LIMIT 1;\n" +
+				"SELECT * FROM city FULL OUTER JOIN country ON city.CountryCode = country.Code GROUP BY ID WITH ROLLUP;\n" +
+				"DELETE FROM city WHERE ID = 1 RETURNING ID",
+			cfg:    &config.Config{Lint: onlyRule(config.LintRuleDialectCompatibility, config.LintSeverityWarning)},
+			driver: dialect.DatabaseDriverMySQL57,
+			expected: []lintResult{
+				{config.LintRuleDialectCompatibility, lsp.SeverityWarning, rng(0, 0, 0, 4), "WITH is not supported by mysql57, use a subquery"},
+				{config.LintRuleDialectCompatibility, lsp.SeverityWarning, rng(0, 35, 0, 39), "OVER is not supported by mysql57"},
+				{config.LintRuleDialectCompatibility, lsp.SeverityWarning, rng(1, 19, 1, 34), "FULL OUTER JOIN is not supported by mysql57, use UNION of LEFT JOIN and RIGHT JOIN"},
+				{config.LintRuleDialectCompatibility, lsp.SeverityWarning, rng(2, 30, 2, 39), "RETURNING is not supported by mysql57"},
+			},
+		},
+		{
+			name:   "DialectCompatibilityOfMssql",
+			input:  "SELECT TOP 1 Name, LEN(Name) FROM city WHERE Name ILIKE 'a%' AND ID::int > 1 ORDER BY ID LIMIT 10",
+			cfg:    &config.Config{Lint: onlyRule(config.LintRuleDialectCompatibility, config.LintSeverityWarning)},
+			driver: dialect.DatabaseDriverMssql,
+			expected: []lintResult{
+				{config.LintRuleDialectCompatibility, lsp.SeverityWarning, rng(0, 50, 0, 55), "ILIKE is not supported by mssql, use LIKE, which is case insensitive with the default collation"},
+				{config.LintRuleDialectCompatibility, lsp.SeverityWarning, rng(0, 67, 0, 69), ":: is not supported by mssql, use CAST"},
+				{config.LintRuleDialectCompatibility, lsp.SeverityWarning, rng(0, 89, 0, 94), "LIMIT is not supported by mssql, use TOP or OFFSET ... FETCH"},
+			},
+		},
+		{
+			name:   "DialectCompatibilityOfFunctions",
+			input:  "SELECT NVL(Name, ''), IFNULL(Name, ''), COALESCE(Name, ''), c.now() FROM city c",
+			cfg:    &config.Config{Lint: onlyRule(config.LintRuleDialectCompatibility, config.LintSeverityWarning)},
+			driver: dialect.DatabaseDriverPostgreSQL,
+			expected: []lintResult{
+				{config.LintRuleDialectCompatibility, lsp.SeverityWarning, rng(0, 7, 0, 10), "function NVL is not supported by postgresql"},
+				{config.LintRuleDialectCompatibility, lsp.SeverityWarning, rng(0, 22, 0, 28), "function IFNULL is not supported by postgresql"},
+			},
+		},
+		{
+			name:     "DialectCompatibilityWithoutDriver",
+			input:    "SELECT TOP 1 * FROM city LIMIT 1",
+			cfg:      &config.Config{Lint: onlyRule(config.LintRuleDialectCompatibility, config.LintSeverityWarning)},
+			expected: []lintResult{},
+		},
+		{
+			name: "ReservedWordOfMySQL",
+			input: "SELECT o.order, o.id AS rank FROM order o JOIN `group` g ON o.group_id = g.id;\n" +
+				"INSERT INTO order (key, id) VALUES (1, 2);\n" +
+				"UPDATE order SET key = 1, id = 2 WHERE id = 1 ORDER BY id",
+			cfg:    &config.Config{Lint: onlyRule(config.LintRuleReservedWord, config.LintSeverityWarning)},
+			driver: dialect.DatabaseDriverMySQL,
+			expected: []lintResult{
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(0, 9, 0, 14), "order is a reserved word of mysql, quote it as `order`"},
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(0, 24, 0, 28), "rank is a reserved word of mysql, quote it as `rank`"},
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(0, 34, 0, 39), "order is a reserved word of mysql, quote it as `order`"},
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(1, 12, 1, 17), "order is a reserved word of mysql, quote it as `order`"},
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(1, 19, 1, 22), "key is a reserved word of mysql, quote it as `key`"},
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(2, 7, 2, 12), "order is a reserved word of mysql, quote it as `order`"},
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(2, 17, 2, 20), "key is a reserved word of mysql, quote it as `key`"},
+			},
+		},
+		{
+			name:     "ReservedWordOfMySQL57",
+			input:    "SELECT id AS rank FROM t",
+			cfg:      &config.Config{Lint: onlyRule(config.LintRuleReservedWord, config.LintSeverityWarning)},
+			driver:   dialect.DatabaseDriverMySQL57,
+			expected: []lintResult{},
+		},
+		{
+			name: "ReservedWordOfPostgreSQL",
+			input: "CREATE TABLE IF NOT EXISTS User (id int, order int, PRIMARY KEY (id));\n" +
+				"SELECT EXTRACT(YEAR FROM created), CAST(id AS varchar) FROM User",
+			cfg:    &config.Config{Lint: onlyRule(config.LintRuleReservedWord, config.LintSeverityWarning)},
+			driver: dialect.DatabaseDriverPostgreSQL,
+			expected: []lintResult{
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(0, 27, 0, 31), `User is a reserved word of postgresql, quote it as "user"`},
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(0, 41, 0, 46), `order is a reserved word of postgresql, quote it as "order"`},
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(1, 60, 1, 64), `User is a reserved word of postgresql, quote it as "user"`},
+			},
+		},
+		{
+			name:   "ReservedWordOfMssql",
+			input:  "SELECT t.[key], t.key FROM dbo.[user] t",
+			cfg:    &config.Config{Lint: onlyRule(config.LintRuleReservedWord, config.LintSeverityWarning)},
+			driver: dialect.DatabaseDriverMssql,
+			expected: []lintResult{
+				{config.LintRuleReservedWord, lsp.SeverityWarning, rng(0, 18, 0, 21), "key is a reserved word of mssql, quote it as [key]"},
+			},
+		},
+		{
+			name:     "ReservedWordWithoutDriver",
+			input:    "SELECT * FROM order",
+			cfg:      &config.Config{Lint: onlyRule(config.LintRuleReservedWord, config.LintSeverityWarning)},
+			expected: []lintResult{},
+		},
+		{
+			name: "SchemaMismatchOfInsert",
+			input: "INSERT INTO city (Name, CountryCode, Mayor) VALUES ('a', 'JPN', 'b'), ('c', 'JPN');\n" +
+				"INSERT INTO city VALUES (1, 'a', 'JPN', 'd', 1), (2, 'a');\n" +
+				"INSERT INTO city (Name, CountryCode, District, Population) SELECT Name, Code, Region, Population FROM country",
+			cfg: &config.Config{Lint: onlyRule(config.LintRuleSchemaMismatch, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(0, 37, 0, 42), "column Mayor does not exist in city"},
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(0, 12, 0, 16), "NOT NULL columns of city without defaults are not inserted: District, Population"},
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(0, 70, 0, 82), "VALUES has 2 values for 3 columns"},
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(1, 49, 1, 57), "VALUES has 2 values for 5 columns of city"},
+			},
+		},
+		{
+			name:  "SchemaMismatchOfUpdate",
+			input: "UPDATE city c SET c.Name = 'a', Mayor = 'b', Population = Population + 1 WHERE ID = 1",
+			cfg:   &config.Config{Lint: onlyRule(config.LintRuleSchemaMismatch, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(0, 32, 0, 37), "column Mayor does not exist in city"},
+			},
+		},
+		{
+			name:    "SchemaMismatchWithoutCache",
+			input:   "INSERT INTO city (Name, Mayor) VALUES (1, 2, 3)",
+			cfg:     &config.Config{Lint: onlyRule(config.LintRuleSchemaMismatch, config.LintSeverityWarning)},
+			noCache: true,
+			expected: []lintResult{
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(0, 38, 0, 47), "VALUES has 3 values for 2 columns"},
+			},
+		},
+		{
+			name: "SetOperationColumns",
+			input: "SELECT ID, Name FROM city UNION ALL SELECT Code FROM country;\n" +
+				"SELECT 1, 2 INTERSECT (SELECT 1, 2, 3) ORDER BY 1, 2;\n" +
+				"SELECT * FROM city UNION SELECT ID FROM city EXCEPT SELECT Code, Name FROM country;\n" +
+				"SELECT ID FROM city WHERE ID IN (SELECT 1 UNION SELECT 2, 3)",
+			cfg: &config.Config{Lint: onlyRule(config.LintRuleSetOperationColumns, config.LintSeverityWarning)},
+			expected: []lintResult{
+				{config.LintRuleSetOperationColumns, lsp.SeverityWarning, rng(0, 36, 0, 47), "SELECT has 1 columns for 2 columns of the first SELECT"},
+				{config.LintRuleSetOperationColumns, lsp.SeverityWarning, rng(1, 23, 1, 37), "SELECT has 3 columns for 2 columns of the first SELECT"},
+				{config.LintRuleSetOperationColumns, lsp.SeverityWarning, rng(2, 52, 2, 69), "SELECT has 2 columns for 1 columns of the first SELECT"},
+				{config.LintRuleSetOperationColumns, lsp.SeverityWarning, rng(3, 48, 3, 59), "SELECT has 2 columns for 1 columns of the first SELECT"},
+			},
+		},
+		{
+			name: "Sqlc",
+			input: "-- name: GetCity :one\nSELECT ID, Name, Nme FROM city LIMIT 10;\n" +
+				"-- name: ListCities :many\nSELECT ID FROM city WHERE ID = @id AND Population > $1;\n" +
+				"-- name: GetCity :exec\nDELETE FROM city WHERE Nam = sqlc.arg(name);\n" +
+				"-- name: CreateCity :one\nINSERT INTO city (ID) VALUES (sqlc.param(id));\n" +
+				"-- name: CountCities :one\nSELECT COUNT(*) FROM city;\n" +
+				"-- name: AllCities :one\nSELECT ci.ID AS city_id, ci.Name n FROM city AS ci;\n" +
+				"-- name: Unknown :foo\nSELECT 1;\n" +
+				"SELECT Nme FROM city LIMIT 10",
+			cfg:    &config.Config{Lint: onlyRule(config.LintRuleSqlc, config.LintSeverityWarning)},
+			driver: dialect.DatabaseDriverPostgreSQL,
+			expected: []lintResult{
+				{config.LintRuleSqlc, lsp.SeverityWarning, rng(1, 37, 1, 39), "the :one query GetCity returns the first of up to 10 rows"},
+				{config.LintRuleSqlc, lsp.SeverityWarning, rng(1, 17, 1, 20), "column Nme does not exist in city"},
+				{config.LintRuleSqlc, lsp.SeverityWarning, rng(3, 52, 3, 54), "the query ListCities mixes the positional and the named parameters of sqlc"},
+				{config.LintRuleSqlc, lsp.SeverityWarning, rng(4, 0, 4, 22), "duplicate query name GetCity of sqlc"},
+				{config.LintRuleSqlc, lsp.SeverityWarning, rng(5, 23, 5, 26), "column Nam does not exist in city, which the parameter name is compared with"},
+				{config.LintRuleSqlc, lsp.SeverityWarning, rng(7, 0, 7, 6), "the :one query CreateCity returns no rows without RETURNING"},
+				{config.LintRuleSqlc, lsp.SeverityWarning, rng(7, 30, 7, 40), "unknown function sqlc.param, which is one of sqlc.arg, sqlc.narg, sqlc.slice and sqlc.embed"},
+				{config.LintRuleSqlc, lsp.SeverityWarning, rng(11, 0, 11, 6), "the :one query AllCities may return more than a row without WHERE or LIMIT"},
+				{config.LintRuleSqlc, lsp.SeverityWarning, rng(12, 0, 12, 21), "unknown command :foo of sqlc, which is one of :batchexec, :batchmany, :batchone, :copyfrom, :exec, :execlastid, :execresult, :execrows, :many, :one"},
+			},
+		},
+		{
+			name:     "RulesOff",
+			input:    "SELECT * FROM city, country; DELETE FROM city",
+			cfg:      &config.Config{Lint: allRules(config.LintSeverityOff)},
+			expected: []lintResult{},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := dbCache
+			if tt.noCache {
+				cache = nil
+			}
+			diagnostics, err := Lint(tt.input, tt.cfg, tt.driver, cache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := []lintResult{}
+			for _, d := range diagnostics {
+				actual = append(actual, lintResult{*d.Code, d.Severity, d.Range, d.Message})
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("unmatched diagnostics (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQuickFixes(t *testing.T) {
+	dbCache, err := database.NewDBCacheUpdater(database.NewMockDBRepository(nil)).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type quickFix struct {
+		Title string
+		Edits []lsp.TextEdit
+	}
+	insert := func(line, char int, text string) lsp.TextEdit {
+		return lsp.TextEdit{Range: rng(line, char, line, char), NewText: text}
+	}
+
+	testcases := []struct {
+		name     string
+		input    string
+		cfg      *config.Config
+		driver   dialect.DatabaseDriver
+		expected []quickFix
+	}{
+		{
+			name:  "JoinWithoutOn",
+			input: "SELECT * FROM city ci JOIN country co WHERE ci.ID = 1",
+			cfg:   &config.Config{Lint: onlyRule(config.LintRuleMissingJoinCondition, config.LintSeverityWarning)},
+			expected: []quickFix{
+				{"Add join condition co.Code = ci.CountryCode", []lsp.TextEdit{insert(0, 37, " ON co.Code = ci.CountryCode")}},
+			},
+		},
+		{
+			name:  "CommaWithoutWhere",
+			input: "SELECT * FROM city,\n  country\nORDER BY 1",
+			cfg:   &config.Config{},
+			expected: []quickFix{
+				{"Add join condition country.Code = city.CountryCode", []lsp.TextEdit{insert(1, 9, " WHERE country.Code = city.CountryCode")}},
+			},
+		},
+		{
+			name:  "CommaWithWhere",
+			input: "select * from countrylanguage cl, country c where c.Code = 'JPN'",
+			cfg:   &config.Config{LowercaseKeywords: true, Lint: onlyRule(config.LintRuleMissingJoinCondition, config.LintSeverityWarning)},
+			expected: []quickFix{
+				{"Add join condition c.Code = cl.CountryCode", []lsp.TextEdit{insert(0, 49, " c.Code = cl.CountryCode and")}},
+			},
+		},
+		{
+			name:  "CommaWithWhereOfOr",
+			input: "SELECT * FROM city c, country co WHERE c.ID = 1 OR c.ID = 2 ORDER BY c.ID",
+			cfg:   &config.Config{Lint: onlyRule(config.LintRuleMissingJoinCondition, config.LintSeverityWarning)},
+			expected: []quickFix{
+				{"Add join condition co.Code = c.CountryCode", []lsp.TextEdit{
+					insert(0, 38, " co.Code = c.CountryCode AND ("),
+					insert(0, 59, ")"),
+				}},
+			},
+		},
+		{
+			name:  "EqualsNull",
+			input: "select ID from city c where c.District != null and null = c.Name",
+			cfg:   &config.Config{LowercaseKeywords: true},
+			expected: []quickFix{
+				{"Replace with is not null", []lsp.TextEdit{{Range: rng(0, 39, 0, 46), NewText: "is not null"}}},
+				{"Replace with is null", []lsp.TextEdit{{Range: rng(0, 51, 0, 64), NewText: "c.Name is null"}}},
+			},
+		},
+		{
+			name:  "UnusedCte",
+			input: "WITH a AS (SELECT 1), b AS (SELECT 2) SELECT * FROM b;\nWITH b AS (SELECT 2), a AS (SELECT 1) SELECT * FROM b;\nWITH a AS (SELECT 1)\nSELECT 1",
+			cfg:   &config.Config{},
+			expected: []quickFix{
+				{"Remove CTE a", []lsp.TextEdit{{Range: rng(0, 5, 0, 22), NewText: ""}}},
+				{"Remove CTE a", []lsp.TextEdit{{Range: rng(1, 20, 1, 37), NewText: ""}}},
+				{"Remove CTE a", []lsp.TextEdit{{Range: rng(2, 0, 3, 0), NewText: ""}}},
+			},
+		},
+		{
+			name:  "UnusedAliasAndColumn",
+			input: "SELECT a FROM (SELECT ID a, Name FROM city AS c) t",
+			cfg:   &config.Config{Lint: &config.Lint{Rules: map[string]string{config.LintRuleUnusedColumn: config.LintSeverityHint}}},
+			expected: []quickFix{
+				{"Remove alias c", []lsp.TextEdit{{Range: rng(0, 42, 0, 47), NewText: ""}}},
+				{"Remove column Name", []lsp.TextEdit{{Range: rng(0, 26, 0, 32), NewText: ""}}},
+			},
+		},
+		{
+			name:   "ReservedWord",
+			input:  "SELECT u.Name FROM User u",
+			cfg:    &config.Config{},
+			driver: dialect.DatabaseDriverPostgreSQL,
+			expected: []quickFix{
+				{"Quote User", []lsp.TextEdit{{Range: rng(0, 19, 0, 23), NewText: `"user"`}}},
+			},
+		},
+		{
+			name:     "WithoutForeignKey",
+			input:    "SELECT * FROM city JOIN countrylanguage",
+			cfg:      &config.Config{},
+			expected: []quickFix{},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			fixes, err := QuickFixes(tt.input, tt.cfg, tt.driver, dbCache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := []quickFix{}
+			for _, f := range fixes {
+				actual = append(actual, quickFix{f.Title, f.Edits})
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("unmatched quick fixes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTypeMismatch(t *testing.T) {
+	repo := database.NewMockDBRepository(nil).(*database.MockDBRepository)
+	describe := repo.MockDescribeDatabaseTableBySchema
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*database.ColumnDesc, error) {
+		cols, err := describe(ctx, schemaName)
+		for _, c := range [][2]string{{"OrderedOn", "date"}, {"ShippedAt", "timestamp without time zone"}, {"Opens", "time"}} {
+			cols = append(cols, &database.ColumnDesc{
+				ColumnBase: database.ColumnBase{Schema: "world", Table: "shop", Name: c[0]},
+				Type:       c[1],
+			})
+		}
+		return cols, err
+	}
+	dbCache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name     string
+		input    string
+		expected []lintResult
+	}{
+		{
+			name:  "StringColumnWithNumber",
+			input: "SELECT ID FROM city c WHERE c.CountryCode = 392 AND 1 <> District AND Name = '1' AND CountryCode = 'JPN'",
+			expected: []lintResult{
+				{config.LintRuleTypeMismatch, lsp.SeverityWarning, rng(0, 28, 0, 47), "column CountryCode of type char(3) is compared with the number 392"},
+				{config.LintRuleTypeMismatch, lsp.SeverityWarning, rng(0, 52, 0, 65), "column District of type char(20) is compared with the number 1"},
+			},
+		},
+		{
+			name:  "NumberColumnWithString",
+			input: "SELECT ID FROM city WHERE ID = 'abc' OR ID >= '10' OR Population < -1",
+			expected: []lintResult{
+				{config.LintRuleTypeMismatch, lsp.SeverityWarning, rng(0, 26, 0, 36), "column ID of type int(11) is compared with 'abc', which is not a number"},
+			},
+		},
+		{
+			name:  "InList",
+			input: "SELECT ID FROM city WHERE CountryCode NOT IN ('JPN', 1, 2 + 3) AND ID IN (SELECT 'a')",
+			expected: []lintResult{
+				{config.LintRuleTypeMismatch, lsp.SeverityWarning, rng(0, 53, 0, 54), "column CountryCode of type char(3) is compared with the number 1"},
+			},
+		},
+		{
+			name: "DateColumns",
+			input: "SELECT * FROM shop WHERE OrderedOn = '2024-02-30' AND OrderedOn > '2024-02-29' AND OrderedOn < 20240101;\n" +
+				"SELECT * FROM shop WHERE ShippedAt >= '2024-01-01 25:00' AND ShippedAt < '2024-01-01T10:00:00.5+09:00' AND ShippedAt <> 'today';\n" +
+				"SELECT * FROM shop WHERE Opens = '9:30' AND Opens = '09:60'",
+			expected: []lintResult{
+				{config.LintRuleTypeMismatch, lsp.SeverityWarning, rng(0, 25, 0, 49), "column OrderedOn of type date is compared with '2024-02-30', which is not a valid date"},
+				{config.LintRuleTypeMismatch, lsp.SeverityWarning, rng(0, 83, 0, 103), "column OrderedOn of type date is compared with the number 20240101"},
+				{config.LintRuleTypeMismatch, lsp.SeverityWarning, rng(1, 25, 1, 56), "column ShippedAt of type timestamp without time zone is compared with '2024-01-01 25:00', which is not a valid timestamp"},
+				{config.LintRuleTypeMismatch, lsp.SeverityWarning, rng(2, 44, 2, 59), "column Opens of type time is compared with '09:60', which is not a valid time"},
+			},
+		},
+		{
+			name:     "Expressions",
+			input:    "SELECT ID FROM city WHERE CountryCode::int = 1 AND CountryCode = 1 + ID AND UPPER(Name) = 1 AND ID = '1'::int AND OrderedOn = DATE '2024-13-01'",
+			expected: []lintResult{},
+		},
+	}
+
+	cfg := &config.Config{Lint: onlyRule(config.LintRuleTypeMismatch, config.LintSeverityWarning)}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics, err := Lint(tt.input, cfg, "", dbCache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := []lintResult{}
+			for _, d := range diagnostics {
+				actual = append(actual, lintResult{*d.Code, d.Severity, d.Range, d.Message})
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("unmatched diagnostics (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLintUnusedTag(t *testing.T) {
+	diagnostics, err := Lint("WITH a AS (SELECT 1) SELECT 1 FROM city WHERE ID = NULL", &config.Config{}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := map[string][]lsp.DiagnosticTag{}
+	for _, d := range diagnostics {
+		tags[*d.Code] = d.Tags
+	}
+	expected := map[string][]lsp.DiagnosticTag{
+		config.LintRuleUnusedCte:  {lsp.DiagnosticTagUnnecessary},
+		config.LintRuleEqualsNull: nil,
+	}
+	if diff := cmp.Diff(expected, tags); diff != "" {
+		t.Errorf("unmatched tags (-want +got):\n%s", diff)
+	}
+}
+
+func TestUnassignableColumns(t *testing.T) {
+	repo := database.NewMockDBRepository(nil).(*database.MockDBRepository)
+	describe := repo.MockDescribeDatabaseTableBySchema
+	repo.MockDescribeDatabaseTableBySchema = func(ctx context.Context, schemaName string) ([]*database.ColumnDesc, error) {
+		cols, err := describe(ctx, schemaName)
+		cols = append(cols,
+			&database.ColumnDesc{ColumnBase: database.ColumnBase{Schema: "world", Table: "invoice", Name: "ID"}, Null: "NO", Extra: "GENERATED ALWAYS AS IDENTITY", Identity: database.IdentityAlways},
+			&database.ColumnDesc{ColumnBase: database.ColumnBase{Schema: "world", Table: "invoice", Name: "Amount"}, Null: "NO"},
+			&database.ColumnDesc{ColumnBase: database.ColumnBase{Schema: "world", Table: "invoice", Name: "Total"}, Null: "NO", Extra: "GENERATED ALWAYS AS ((Amount * 1.1)) STORED", Generated: true},
+		)
+		return cols, err
+	}
+	dbCache, err := database.NewDBCacheUpdater(repo).GenerateDBCachePrimary(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name     string
+		input    string
+		expected []lintResult
+	}{
+		{
+			name:  "Insert",
+			input: "INSERT INTO invoice (ID, Amount, Total) VALUES (1, 2, 3)",
+			expected: []lintResult{
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(0, 21, 0, 23), "column ID of invoice is an identity column GENERATED ALWAYS and cannot be assigned"},
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(0, 33, 0, 38), "column Total of invoice is generated and cannot be assigned"},
+			},
+		},
+		{
+			name:  "InsertOverridingSystemValue",
+			input: "INSERT INTO invoice (ID, Amount) OVERRIDING SYSTEM VALUE VALUES (1, 2, 3)",
+			expected: []lintResult{
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(0, 64, 0, 73), "VALUES has 3 values for 2 columns"},
+			},
+		},
+		{
+			name:  "Update",
+			input: "UPDATE invoice SET Amount = 2, Total = 3, ID = DEFAULT",
+			expected: []lintResult{
+				{config.LintRuleSchemaMismatch, lsp.SeverityWarning, rng(0, 31, 0, 36), "column Total of invoice is generated and cannot be assigned"},
+			},
+		},
+		{
+			name:     "AutoIncrement",
+			input:    "INSERT INTO city (ID, Name, CountryCode, District, Population) VALUES (1, 'a', 'JPN', 'b', 1)",
+			expected: []lintResult{},
+		},
+	}
+
+	cfg := &config.Config{Lint: onlyRule(config.LintRuleSchemaMismatch, config.LintSeverityWarning)}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics, err := Lint(tt.input, cfg, "", dbCache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := []lintResult{}
+			for _, d := range diagnostics {
+				actual = append(actual, lintResult{*d.Code, d.Severity, d.Range, d.Message})
+			}
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Errorf("unmatched diagnostics (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
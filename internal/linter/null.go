@@ -0,0 +1,123 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sqls-server/sqls/token"
+)
+
+// checkEqualsNull finds the comparisons with NULL by = and <>, which are never
+// true. The fixes replace them with IS NULL and IS NOT NULL.
+func checkEqualsNull(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		for i, tok := range toks {
+			if tok.Kind != token.Eq && tok.Kind != token.Neq {
+				continue
+			}
+			// SET col = NULL is an assignment
+			if tok.clause == "SET" || tok.clause == "UPDATE" {
+				continue
+			}
+			isNull := c.keyword("IS") + " " + c.keyword("NULL")
+			if tok.Kind == token.Neq {
+				isNull = c.keyword("IS") + " " + c.keyword("NOT") + " " + c.keyword("NULL")
+			}
+			message := fmt.Sprintf("comparison with NULL is never true, use %s", strings.ToUpper(isNull))
+			title := "Replace with " + isNull
+
+			switch {
+			case i+1 < len(toks) && upperWord(toks[i+1]) == "NULL" && !isOperator(toks, i+2):
+				null := toks[i+1]
+				problems = append(problems, problem{
+					from:    tok.From,
+					to:      null.To,
+					message: message,
+					fix: &fix{
+						title: title,
+						edits: []edit{{from: tok.From, to: null.To, text: isNull}},
+					},
+				})
+			case i > 0 && upperWord(toks[i-1]) == "NULL" && !isOperator(toks, i-2):
+				null := toks[i-1]
+				p := problem{from: null.From, to: tok.To, message: message}
+				if col, ok := columnAfter(toks, i+1); ok {
+					p.fix = &fix{
+						title: title,
+						edits: []edit{{from: null.From, to: col.to, text: col.text + " " + isNull}},
+					}
+				}
+				problems = append(problems, p)
+			}
+		}
+	}
+	return problems
+}
+
+// checkNotInNullable finds NOT IN with the subquery of the nullable column,
+// which returns no rows if the subquery returns NULL.
+func checkNotInNullable(c *lintContext) []problem {
+	if c.dbCache == nil {
+		return nil
+	}
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		for i, tok := range toks {
+			if upperWord(tok) != "NOT" || i+3 >= len(toks) || upperWord(toks[i+1]) != "IN" ||
+				toks[i+2].Kind != token.LParen || upperWord(toks[i+3]) != "SELECT" {
+				continue
+			}
+			subquery := subqueryTokens(toks, i+2)
+			start := 1
+			if w := upperWord(subquery[start]); w == "DISTINCT" || w == "ALL" {
+				start++
+			}
+			if start >= len(subquery) || !isIdentifier(subquery[start]) {
+				continue
+			}
+			end := chainEnd(subquery, start)
+			if end+1 >= len(subquery) || upperWord(subquery[end+1]) != "FROM" {
+				continue
+			}
+			col := newColumn(subquery, start, end)
+			desc := c.columnDesc(col)
+			if desc == nil || !strings.EqualFold(desc.Null, "YES") || excludesNull(subquery, col.name) {
+				continue
+			}
+			problems = append(problems, problem{
+				from:    tok.From,
+				to:      toks[i+1].To,
+				message: fmt.Sprintf("NOT IN returns no rows if %s of the subquery is NULL, use NOT EXISTS or exclude NULL", col.name),
+			})
+		}
+	}
+	return problems
+}
+
+// subqueryTokens returns the tokens in the parentheses starting at the index.
+func subqueryTokens(toks []*lintToken, lparen int) []*lintToken {
+	depth := toks[lparen].depth
+	for i := lparen + 1; i < len(toks); i++ {
+		if toks[i].depth == depth && toks[i].Kind == token.RParen {
+			return toks[lparen+1 : i]
+		}
+	}
+	return toks[lparen+1:]
+}
+
+// excludesNull reports whether the subquery has the condition that the
+// column IS NOT NULL.
+func excludesNull(toks []*lintToken, name string) bool {
+	for i := 0; i+3 < len(toks); i++ {
+		if !isIdentifier(toks[i]) || !strings.EqualFold(toks[i].Value.(*token.SQLWord).Value, name) {
+			continue
+		}
+		if upperWord(toks[i+1]) == "IS" && upperWord(toks[i+2]) == "NOT" && upperWord(toks[i+3]) == "NULL" {
+			return true
+		}
+	}
+	return false
+}
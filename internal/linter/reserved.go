@@ -0,0 +1,199 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/token"
+)
+
+// notTableNames are the keywords that can be after FROM, JOIN, INTO, UPDATE
+// and TABLE in the places of the table names.
+var notTableNames = map[string]bool{
+	"ONLY":         true,
+	"LATERAL":      true,
+	"IF":           true,
+	"LOW_PRIORITY": true,
+	"IGNORE":       true,
+	"DUAL":         true,
+	"OUTFILE":      true,
+	"DUMPFILE":     true,
+	"SELECT":       true,
+	"VALUES":       true,
+	"WITH":         true,
+}
+
+// notColumnNames are the keywords starting the constraints in the column
+// definitions of CREATE TABLE.
+var notColumnNames = map[string]bool{
+	"CONSTRAINT": true,
+	"PRIMARY":    true,
+	"UNIQUE":     true,
+	"FOREIGN":    true,
+	"CHECK":      true,
+	"KEY":        true,
+	"INDEX":      true,
+	"FULLTEXT":   true,
+	"SPATIAL":    true,
+	"LIKE":       true,
+	"EXCLUDE":    true,
+	"PERIOD":     true,
+}
+
+// checkReservedWord finds the identifiers without the quotes that are
+// reserved words of the database of the connection. The fixes quote them.
+func checkReservedWord(c *lintContext) []problem {
+	if c.driver == "" {
+		return nil
+	}
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		idents := identifierIndexes(toks)
+		for i, tok := range toks {
+			if !idents[i] || !dialect.IsReserved(c.driver, upperWord(tok)) {
+				continue
+			}
+			name := tok.Value.(*token.SQLWord).Value
+			quoted := dialect.QuoteIdentifier(c.driver, name)
+			problems = append(problems, problem{
+				from:    tok.From,
+				to:      tok.To,
+				message: fmt.Sprintf("%s is a reserved word of %s, quote it as %s", name, c.driver, quoted),
+				fix: &fix{
+					title: "Quote " + name,
+					edits: []edit{{from: tok.From, to: tok.To, text: quoted}},
+				},
+			})
+		}
+	}
+	return problems
+}
+
+// identifierIndexes returns the indexes of the words in the places that only
+// the identifiers can be: the qualified names, the table names, the aliases
+// after AS, the columns of INSERT and SET, and the column definitions.
+func identifierIndexes(toks []*lintToken) map[int]bool {
+	idents := map[int]bool{}
+	for i, tok := range toks {
+		if upperWord(tok) == "" {
+			continue
+		}
+		if (i > 0 && toks[i-1].Kind == token.Period) || (i+1 < len(toks) && toks[i+1].Kind == token.Period) {
+			idents[i] = true
+			continue
+		}
+		if i == 0 {
+			continue
+		}
+		nextIsParen := i+1 < len(toks) && toks[i+1].Kind == token.LParen
+		prev, prevIdx := upperWord(toks[i-1]), i-1
+		if prev == "EXISTS" {
+			// TABLE IF NOT EXISTS and TABLE IF EXISTS
+			prevIdx = skipIfExists(toks, i-1)
+			prev = upperWord(toks[prevIdx])
+		}
+		switch prev {
+		case "FROM", "JOIN":
+			if !notTableNames[upperWord(tok)] && !nextIsParen && (prev == "JOIN" || isTableFrom(toks, i-1)) {
+				idents[i] = true
+			}
+		case "INTO", "TABLE":
+			if !notTableNames[upperWord(tok)] {
+				idents[i] = true
+				if prev == "INTO" || isCreateTable(toks, prevIdx) {
+					markColumnList(toks, i, prev == "TABLE", idents)
+				}
+			}
+		case "UPDATE":
+			// FOR UPDATE OF
+			if !notTableNames[upperWord(tok)] && (i < 2 || upperWord(toks[i-2]) != "FOR") {
+				idents[i] = true
+			}
+		case "AS":
+			// AS OF of the temporal tables
+			if upperWord(tok) != "OF" && !nextIsParen && (tok.clause == "SELECT" || tok.clause == "FROM" || tok.clause == "JOIN") {
+				idents[i] = true
+			}
+		case "COLUMN":
+			if upperWord(tok) != "IF" {
+				idents[i] = true
+			}
+		case "SET":
+			if i+1 < len(toks) && toks[i+1].Kind == token.Eq {
+				idents[i] = true
+			}
+		}
+		if toks[i-1].Kind == token.Comma && tok.clause == "SET" && i+1 < len(toks) && toks[i+1].Kind == token.Eq {
+			idents[i] = true
+		}
+	}
+	return idents
+}
+
+// skipIfExists returns the index of the token before IF EXISTS or IF NOT
+// EXISTS ending at the index.
+func skipIfExists(toks []*lintToken, exists int) int {
+	i := exists - 1
+	if i >= 0 && upperWord(toks[i]) == "NOT" {
+		i--
+	}
+	if i < 1 || upperWord(toks[i]) != "IF" {
+		return exists
+	}
+	return i - 1
+}
+
+// isTableFrom reports whether FROM at the index is the one of SELECT, DELETE
+// and UPDATE, not of the functions such as EXTRACT(YEAR FROM d).
+func isTableFrom(toks []*lintToken, from int) bool {
+	depth := toks[from].depth
+	for i := from - 1; i >= 0 && toks[i].depth >= depth; i-- {
+		if toks[i].depth != depth {
+			continue
+		}
+		switch upperWord(toks[i]) {
+		case "SELECT", "DELETE", "UPDATE":
+			return true
+		}
+	}
+	return false
+}
+
+// isCreateTable reports whether TABLE at the index is the one of CREATE
+// TABLE.
+func isCreateTable(toks []*lintToken, table int) bool {
+	return upperWord(toks[0]) == "CREATE" && toks[table].depth == 0
+}
+
+// markColumnList marks the columns in the parentheses after the table name
+// starting at the index, which are the columns of INSERT or the column
+// definitions of CREATE TABLE.
+func markColumnList(toks []*lintToken, name int, definitions bool, idents map[int]bool) {
+	lparen := chainEnd(toks, name) + 1
+	if lparen >= len(toks) || toks[lparen].Kind != token.LParen {
+		return
+	}
+	rparen := matchParen(toks, lparen)
+	if rparen < 0 {
+		return
+	}
+	depth := toks[lparen].depth + 1
+	for i := lparen + 1; i < rparen; i++ {
+		if toks[i].depth != depth || (toks[i-1].Kind != token.LParen && toks[i-1].Kind != token.Comma) {
+			continue
+		}
+		if upperWord(toks[i]) == "" || toks[i+1].Kind == token.Period {
+			continue
+		}
+		if definitions {
+			if !notColumnNames[upperWord(toks[i])] && upperWord(toks[i+1]) != "" {
+				idents[i] = true
+			}
+			continue
+		}
+		if toks[i+1].Kind == token.Comma || i+1 == rparen {
+			idents[i] = true
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/parser/parseutil"
+	"github.com/sqls-server/sqls/token"
+)
+
+func tokenProblem(tok *lintToken, message string) problem {
+	return problem{from: tok.From, to: tok.To, message: message}
+}
+
+// checkSelectStar finds * and table.* in the select lists, which are not
+// COUNT(*) or multiplications.
+func checkSelectStar(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		for i, tok := range toks {
+			if tok.Kind != token.Mult || tok.clause != "SELECT" {
+				continue
+			}
+			start := i
+			if i >= 2 && toks[i-1].Kind == token.Period {
+				start = i - 2
+			}
+			if start == 0 || !startsSelectItem(toks[:start]) {
+				continue
+			}
+			problems = append(problems, problem{
+				from:    toks[start].From,
+				to:      tok.To,
+				message: "SELECT * depends on the columns of the tables, list the columns",
+			})
+		}
+	}
+	return problems
+}
+
+// startsSelectItem reports whether an item of the select list starts after
+// the tokens.
+func startsSelectItem(toks []*lintToken) bool {
+	prev := toks[len(toks)-1]
+	if prev.Kind == token.Comma {
+		return true
+	}
+	switch keyword(prev.Token) {
+	case "SELECT", "DISTINCT", "ALL":
+		return true
+	}
+	// SELECT TOP 10 * of T-SQL
+	if prev.Kind == token.Number && len(toks) >= 2 && keyword(toks[len(toks)-2].Token) == "TOP" {
+		return true
+	}
+	return false
+}
+
+// checkImplicitCrossJoin finds the commas between the tables of FROM.
+func checkImplicitCrossJoin(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		for _, tok := range stmt.tokens {
+			if tok.Kind != token.Comma {
+				continue
+			}
+			switch tok.clause {
+			case "FROM", "JOIN", "ON":
+				problems = append(problems, tokenProblem(tok, "implicit cross join, use JOIN with the join condition"))
+			}
+		}
+	}
+	return problems
+}
+
+// checkDeleteWithoutWhere finds DELETE that deletes all the rows of the
+// table.
+func checkDeleteWithoutWhere(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		for i, tok := range stmt.tokens {
+			if keyword(tok.Token) != "DELETE" || tok.depth != 0 {
+				continue
+			}
+			hasWhere := false
+			for _, after := range stmt.tokens[i+1:] {
+				if after.depth == 0 && keyword(after.Token) == "WHERE" {
+					hasWhere = true
+					break
+				}
+			}
+			if !hasWhere {
+				problems = append(problems, tokenProblem(tok, "DELETE without WHERE deletes all the rows"))
+			}
+		}
+	}
+	return problems
+}
+
+// checkKeywordCase finds the keywords that are not in the case of the
+// keywordCase config, or of the first keyword of the text if it is not set.
+func checkKeywordCase(c *lintContext) []problem {
+	expected := c.cfg.KeywordCase
+	if expected == ast.CasePreserve {
+		expected = ""
+	}
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		for _, tok := range stmt.tokens {
+			if keyword(tok.Token) == "" {
+				continue
+			}
+			word := tok.Value.(*token.SQLWord).Value
+			if strings.ToUpper(word) == strings.ToLower(word) {
+				continue
+			}
+			if expected == "" {
+				expected = keywordCaseOf(word)
+				continue
+			}
+			if expected.Apply(word) != word {
+				problems = append(problems, tokenProblem(tok, fmt.Sprintf("keyword %s is not in %s case", word, expected)))
+			}
+		}
+	}
+	return problems
+}
+
+func keywordCaseOf(word string) ast.Case {
+	for _, cs := range []ast.Case{ast.CaseUpper, ast.CaseLower, ast.CaseCapitalize} {
+		if cs.Apply(word) == word {
+			return cs
+		}
+	}
+	return ""
+}
+
+// checkNotEqual finds != that is not the standard SQL operator.
+func checkNotEqual(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		for _, tok := range stmt.tokens {
+			if tok.Kind == token.Neq && tok.Value == "!=" {
+				problems = append(problems, tokenProblem(tok, "use <> instead of !=, which is the standard SQL operator"))
+			}
+		}
+	}
+	return problems
+}
+
+// checkAmbiguousColumn finds the columns without the table that are in more
+// than one of the tables of the query.
+func checkAmbiguousColumn(c *lintContext) []problem {
+	if c.dbCache == nil {
+		return nil
+	}
+	problems := []problem{}
+	aliases := map[string]bool{}
+	for _, aliased := range parseutil.ExtractAliasedIdentifier(c.parsed) {
+		if a, ok := aliased.(*ast.Aliased); ok {
+			aliases[strings.ToUpper(a.AliasedName.String())] = true
+		}
+	}
+	// The columns after the tables, such as city.Name, are not ambiguous
+	qualified := map[token.Pos]bool{}
+	for _, stmt := range c.statements {
+		for i, tok := range stmt.tokens {
+			if i > 0 && stmt.tokens[i-1].Kind == token.Period {
+				qualified[tok.From] = true
+			}
+		}
+	}
+	for _, stmt := range c.parsed.GetTokens() {
+		idents, err := parseutil.ExtractIdenfiers(c.parsed, stmt.End())
+		if err != nil {
+			continue
+		}
+		for _, ident := range idents {
+			name := ident.(*ast.Identifier).NoQuoteString()
+			if qualified[ident.Pos()] || aliases[strings.ToUpper(name)] {
+				continue
+			}
+			tables, err := parseutil.ExtractTable(c.parsed, ident.Pos())
+			if err != nil {
+				continue
+			}
+			if in := c.tablesWithColumn(tables, name); len(in) > 1 {
+				problems = append(problems, problem{
+					from:    ident.Pos(),
+					to:      ident.End(),
+					message: fmt.Sprintf("ambiguous column %s, which is in %s", name, strings.Join(in, ", ")),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// tablesWithColumn returns the names of the tables that have the column, or
+// nil if the name is one of the tables.
+func (c *lintContext) tablesWithColumn(tables []*parseutil.TableInfo, column string) []string {
+	names := []string{}
+	for _, table := range tables {
+		if strings.EqualFold(table.Name, column) || strings.EqualFold(table.Alias, column) {
+			return nil
+		}
+		cols, ok := c.dbCache.ColumnDescs(table.Name)
+		if table.DatabaseSchema != "" {
+			cols, ok = c.dbCache.ColumnDatabase(table.DatabaseSchema, table.Name)
+		}
+		if !ok {
+			continue
+		}
+		for _, col := range cols {
+			if strings.EqualFold(col.Name, column) {
+				names = append(names, table.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,318 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/token"
+)
+
+// insertModifiers are the keywords between INSERT and INTO.
+var insertModifiers = map[string]bool{
+	"IGNORE":        true,
+	"LOW_PRIORITY":  true,
+	"DELAYED":       true,
+	"HIGH_PRIORITY": true,
+	"OR":            true,
+	"REPLACE":       true,
+	"ABORT":         true,
+	"ROLLBACK":      true,
+	"FAIL":          true,
+}
+
+// checkSchemaMismatch finds the columns of INSERT and UPDATE that do not match
+// the tables: the numbers of the values of VALUES that are not the ones of the
+// columns, the columns that the tables do not have, the generated and identity
+// columns that cannot be assigned, and the NOT NULL columns without the
+// defaults that INSERT does not set.
+func checkSchemaMismatch(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		for i, tok := range toks {
+			switch upperWord(tok) {
+			case "INTO":
+				if isInsertInto(toks, i) {
+					problems = append(problems, c.checkInsert(toks, i)...)
+				}
+			case "UPDATE":
+				// not FOR UPDATE and ON DUPLICATE KEY UPDATE
+				if i == 0 || (upperWord(toks[i-1]) != "FOR" && upperWord(toks[i-1]) != "KEY" && toks[i-1].Kind != token.Period) {
+					problems = append(problems, c.checkUpdate(toks, i)...)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// isInsertInto reports whether INTO at the index is the one of INSERT or of
+// REPLACE of MySQL.
+func isInsertInto(toks []*lintToken, into int) bool {
+	if into > 0 && upperWord(toks[into-1]) == "REPLACE" {
+		return true
+	}
+	i := into - 1
+	for i >= 0 && insertModifiers[upperWord(toks[i])] {
+		i--
+	}
+	return i >= 0 && upperWord(toks[i]) == "INSERT"
+}
+
+// tableColumns returns the columns of the table of the name ending at the
+// index, or false if the table is not in the cache.
+func (c *lintContext) tableColumns(toks []*lintToken, start, end int) ([]*database.ColumnDesc, bool) {
+	if c.dbCache == nil {
+		return nil, false
+	}
+	name := newColumn(toks, start, end)
+	if name.qualifier != "" {
+		return c.dbCache.ColumnDatabase(name.qualifier, name.name)
+	}
+	return c.dbCache.ColumnDescs(name.name)
+}
+
+func (c *lintContext) checkInsert(toks []*lintToken, into int) []problem {
+	start := into + 1
+	if start >= len(toks) || !isIdentifier(toks[start]) {
+		return nil
+	}
+	end := chainEnd(toks, start)
+	table := newColumn(toks, start, end).name
+	i := end + 1
+	if i+1 < len(toks) && upperWord(toks[i]) == "AS" {
+		i += 2
+	}
+
+	var columns []*lintToken
+	if i < len(toks) && toks[i].Kind == token.LParen {
+		columns = []*lintToken{}
+		rparen := matchParen(toks, i)
+		// INSERT INTO t (SELECT ...)
+		if rparen < 0 || clauseKeywords[upperWord(toks[i+1])] {
+			return nil
+		}
+		for k := i + 1; k < rparen; k++ {
+			if !isIdentifier(toks[k]) {
+				return nil
+			}
+			k = chainEnd(toks, k)
+			columns = append(columns, toks[k])
+			if k+1 < rparen && toks[k+1].Kind != token.Comma {
+				return nil
+			}
+			k++
+		}
+		i = rparen + 1
+	}
+	// OVERRIDING SYSTEM VALUE of PostgreSQL assigns the identity columns
+	overriding := false
+	if i+2 < len(toks) && upperWord(toks[i]) == "OVERRIDING" {
+		overriding = upperWord(toks[i+1]) == "SYSTEM"
+		i += 3
+	}
+
+	problems := []problem{}
+	cols, known := c.tableColumns(toks, start, end)
+	if known {
+		problems = append(problems, unknownColumns(columns, cols, table)...)
+		problems = append(problems, unassignableColumns(columns, cols, table, overriding)...)
+		// The columns of ClickHouse without the defaults have the ones of
+		// the types
+		if columns != nil && c.driver != dialect.DatabaseDriverClickhouse {
+			problems = append(problems, missingColumns(toks[start], toks[end], columns, cols, table)...)
+		}
+	}
+
+	if i >= len(toks) || (upperWord(toks[i]) != "VALUES" && upperWord(toks[i]) != "VALUE") {
+		return problems
+	}
+	want := len(columns)
+	target := fmt.Sprintf("%d columns", want)
+	if columns == nil {
+		if !known {
+			return problems
+		}
+		want = len(cols)
+		target = fmt.Sprintf("%d columns of %s", want, table)
+	}
+	for k := i + 1; k < len(toks) && toks[k].Kind == token.LParen; {
+		rparen := matchParen(toks, k)
+		if rparen < 0 {
+			break
+		}
+		if n := countValues(toks, k, rparen); n != want {
+			problems = append(problems, problem{
+				from:    toks[k].From,
+				to:      toks[rparen].To,
+				message: fmt.Sprintf("VALUES has %d values for %s", n, target),
+			})
+		}
+		k = rparen + 1
+		if k >= len(toks) || toks[k].Kind != token.Comma {
+			break
+		}
+		k++
+	}
+	return problems
+}
+
+// countValues returns the number of the values in the parentheses.
+func countValues(toks []*lintToken, lparen, rparen int) int {
+	if rparen == lparen+1 {
+		return 0
+	}
+	n := 1
+	for k := lparen + 1; k < rparen; k++ {
+		if toks[k].depth == toks[lparen].depth+1 && toks[k].Kind == token.Comma {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *lintContext) checkUpdate(toks []*lintToken, update int) []problem {
+	start := update + 1
+	if start < len(toks) && upperWord(toks[start]) == "ONLY" {
+		start++
+	}
+	if start >= len(toks) || !isIdentifier(toks[start]) {
+		return nil
+	}
+	end := chainEnd(toks, start)
+	table := newColumn(toks, start, end).name
+	alias := ""
+	i := end + 1
+	if i < len(toks) && upperWord(toks[i]) == "AS" {
+		i++
+	}
+	if i < len(toks) && upperWord(toks[i]) != "SET" && isIdentifier(toks[i]) {
+		alias = toks[i].Value.(*token.SQLWord).Value
+		i++
+	}
+	// UPDATE of the joined tables of MySQL
+	if i >= len(toks) || upperWord(toks[i]) != "SET" {
+		return nil
+	}
+	cols, ok := c.tableColumns(toks, start, end)
+	if !ok {
+		return nil
+	}
+
+	columns := []*lintToken{}
+	// The generated columns can be set to DEFAULT
+	assigned := []*lintToken{}
+	depth := toks[i].depth
+	for k := i + 1; k < len(toks) && toks[k].depth >= depth; k++ {
+		if toks[k].depth != depth {
+			continue
+		}
+		if toks[k].clause != "SET" {
+			break
+		}
+		if toks[k-1].Kind != token.Comma && k != i+1 {
+			continue
+		}
+		if !isIdentifier(toks[k]) {
+			continue
+		}
+		last := chainEnd(toks, k)
+		if last+1 >= len(toks) || toks[last+1].Kind != token.Eq {
+			continue
+		}
+		if last > k {
+			qualifier := toks[last-2].Value.(*token.SQLWord).Value
+			if !strings.EqualFold(qualifier, table) && !strings.EqualFold(qualifier, alias) {
+				continue
+			}
+		}
+		columns = append(columns, toks[last])
+		if last+2 >= len(toks) || upperWord(toks[last+2]) != "DEFAULT" {
+			assigned = append(assigned, toks[last])
+		}
+	}
+	return append(unknownColumns(columns, cols, table), unassignableColumns(assigned, cols, table, false)...)
+}
+
+func hasColumn(cols []*database.ColumnDesc, name string) bool {
+	for _, desc := range cols {
+		if strings.EqualFold(desc.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func unknownColumns(columns []*lintToken, cols []*database.ColumnDesc, table string) []problem {
+	problems := []problem{}
+	for _, col := range columns {
+		name := col.Value.(*token.SQLWord).Value
+		if hasColumn(cols, name) {
+			continue
+		}
+		problems = append(problems, problem{
+			from:    col.From,
+			to:      col.To,
+			message: fmt.Sprintf("column %s does not exist in %s", name, table),
+		})
+	}
+	return problems
+}
+
+// unassignableColumns finds the columns whose values the database computes,
+// the generated columns and the identity columns GENERATED ALWAYS, unless
+// OVERRIDING SYSTEM VALUE assigns the identity columns.
+func unassignableColumns(columns []*lintToken, cols []*database.ColumnDesc, table string, overriding bool) []problem {
+	problems := []problem{}
+	for _, col := range columns {
+		name := col.Value.(*token.SQLWord).Value
+		for _, desc := range cols {
+			if !strings.EqualFold(desc.Name, name) {
+				continue
+			}
+			var message string
+			switch {
+			case desc.Generated:
+				message = fmt.Sprintf("column %s of %s is generated and cannot be assigned", name, table)
+			case desc.Identity == database.IdentityAlways && !overriding:
+				message = fmt.Sprintf("column %s of %s is an identity column GENERATED ALWAYS and cannot be assigned", name, table)
+			default:
+				continue
+			}
+			problems = append(problems, problem{
+				from:    col.From,
+				to:      col.To,
+				message: message,
+			})
+			break
+		}
+	}
+	return problems
+}
+
+// missingColumns finds the NOT NULL columns without the defaults that are not
+// in the columns of INSERT. The columns filled by the databases, such as
+// auto_increment and identity, have the extras.
+func missingColumns(from, to *lintToken, columns []*lintToken, cols []*database.ColumnDesc, table string) []problem {
+	inserted := map[string]bool{}
+	for _, col := range columns {
+		inserted[strings.ToUpper(col.Value.(*token.SQLWord).Value)] = true
+	}
+	missing := []string{}
+	for _, desc := range cols {
+		if inserted[strings.ToUpper(desc.Name)] || !strings.EqualFold(desc.Null, "NO") || desc.Default.Valid || desc.Extra != "" {
+			continue
+		}
+		missing = append(missing, desc.Name)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []problem{{
+		from:    from.From,
+		to:      to.To,
+		message: fmt.Sprintf("NOT NULL columns of %s without defaults are not inserted: %s", table, strings.Join(missing, ", ")),
+	}}
+}
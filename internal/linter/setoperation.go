@@ -0,0 +1,119 @@
+package linter
+
+import (
+	"fmt"
+
+	"github.com/sqls-server/sqls/token"
+)
+
+// setOperators are the keywords combining the results of the SELECTs.
+var setOperators = map[string]bool{
+	"UNION":     true,
+	"INTERSECT": true,
+	"EXCEPT":    true,
+	"MINUS":     true,
+}
+
+// checkSetOperationColumns finds the SELECTs of UNION, INTERSECT and EXCEPT
+// whose numbers of the columns are not the one of the first SELECT. The
+// SELECTs with * are not checked.
+func checkSetOperationColumns(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		checked := map[int]bool{}
+		for i, tok := range toks {
+			if !setOperators[keyword(tok.Token)] {
+				continue
+			}
+			// The set operation is the tokens around the operator at the
+			// same depth
+			depth := tok.depth
+			start, end := i, i
+			for start > 0 && toks[start-1].depth >= depth {
+				start--
+			}
+			for end < len(toks) && toks[end].depth >= depth {
+				end++
+			}
+			if checked[start] {
+				continue
+			}
+			checked[start] = true
+			problems = append(problems, checkSetOperation(toks[start:end], depth)...)
+		}
+	}
+	return problems
+}
+
+func checkSetOperation(toks []*lintToken, depth int) []problem {
+	want := -1
+	problems := []problem{}
+	for _, sel := range branchSelects(toks, depth) {
+		list, ok := selectList(toks, sel)
+		if !ok || len(list) == 0 {
+			continue
+		}
+		items, _ := selectItems(list, toks[sel].depth)
+		if selectsAll(append([]*lintToken{toks[sel]}, list...), "") {
+			continue
+		}
+		if want < 0 {
+			want = len(items)
+			continue
+		}
+		if len(items) == want {
+			continue
+		}
+		problems = append(problems, problem{
+			from:    toks[sel].From,
+			to:      list[len(list)-1].To,
+			message: fmt.Sprintf("SELECT has %d columns for %d columns of the first SELECT", len(items), want),
+		})
+	}
+	return problems
+}
+
+// branchSelects returns the indexes of SELECT of the branches of the set
+// operation, which are at the depth or in the parentheses of (SELECT ...).
+func branchSelects(toks []*lintToken, depth int) []int {
+	selects := []int{}
+	for i, tok := range toks {
+		if tok.depth != depth {
+			continue
+		}
+		if upperWord(tok) == "SELECT" {
+			selects = append(selects, i)
+			continue
+		}
+		if tok.Kind != token.LParen || i+1 >= len(toks) || upperWord(toks[i+1]) != "SELECT" {
+			continue
+		}
+		if i == 0 || setOperators[keyword(toks[i-1].Token)] || keyword(toks[i-1].Token) == "ALL" || keyword(toks[i-1].Token) == "DISTINCT" {
+			selects = append(selects, i+1)
+		}
+	}
+	return selects
+}
+
+// selectList returns the tokens of the select list of SELECT at the index,
+// without DISTINCT and ALL. ok is false for DISTINCT ON of PostgreSQL and TOP
+// of T-SQL, whose lists are not the columns.
+func selectList(toks []*lintToken, sel int) ([]*lintToken, bool) {
+	depth := toks[sel].depth
+	start := sel + 1
+	if start < len(toks) {
+		switch keyword(toks[start].Token) {
+		case "DISTINCT", "ALL":
+			start++
+		}
+	}
+	if start < len(toks) && (keyword(toks[start].Token) == "ON" || keyword(toks[start].Token) == "TOP") {
+		return nil, false
+	}
+	end := start
+	for end < len(toks) && toks[end].depth >= depth && (toks[end].depth > depth || toks[end].clause == "SELECT") {
+		end++
+	}
+	return toks[start:end], true
+}
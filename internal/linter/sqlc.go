@@ -0,0 +1,381 @@
+package linter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/ast/astutil"
+	"github.com/sqls-server/sqls/parser/parseutil"
+	"github.com/sqls-server/sqls/token"
+)
+
+// sqlcCommands are the commands of the queries of sqlc, and whether their
+// queries return the rows.
+var sqlcCommands = map[string]bool{
+	"one":        true,
+	"many":       true,
+	"exec":       false,
+	"execrows":   false,
+	"execresult": false,
+	"execlastid": false,
+	"copyfrom":   false,
+	"batchexec":  false,
+	"batchmany":  true,
+	"batchone":   true,
+}
+
+// sqlcFunctions are the functions of sqlc in the queries, such as
+// sqlc.arg(name).
+var sqlcFunctions = map[string]bool{
+	"ARG":   true,
+	"NARG":  true,
+	"SLICE": true,
+	"EMBED": true,
+}
+
+// aggregateFunctions return a row for all the rows of the query without
+// GROUP BY.
+var aggregateFunctions = map[string]bool{
+	"COUNT":        true,
+	"SUM":          true,
+	"AVG":          true,
+	"MIN":          true,
+	"MAX":          true,
+	"BOOL_AND":     true,
+	"BOOL_OR":      true,
+	"EVERY":        true,
+	"ARRAY_AGG":    true,
+	"STRING_AGG":   true,
+	"JSON_AGG":     true,
+	"JSONB_AGG":    true,
+	"GROUP_CONCAT": true,
+}
+
+// sqlcQuery is a statement annotated by -- name: of sqlc.
+type sqlcQuery struct {
+	name    string
+	command string
+	// from and to are of the comment of the annotation
+	from, to token.Pos
+	toks     []*lintToken
+}
+
+func (q *sqlcQuery) commentProblem(message string) problem {
+	return problem{from: q.from, to: q.to, message: message}
+}
+
+// sqlcQueries returns the statements annotated by sqlc.
+func (c *lintContext) sqlcQueries() []*sqlcQuery {
+	queries := []*sqlcQuery{}
+	for _, node := range c.parsed.GetTokens() {
+		list, ok := node.(ast.TokenList)
+		if !ok {
+			continue
+		}
+		meta := parseutil.ExtractMetadata(list)
+		if meta == nil || meta.Name == "" {
+			continue
+		}
+		q := &sqlcQuery{name: meta.Name, command: meta.Command}
+		for _, n := range list.GetTokens() {
+			tok, ok := n.(ast.Token)
+			if !ok {
+				break
+			}
+			if t := tok.GetToken(); t.Kind == token.Comment {
+				if v, _ := t.Value.(string); strings.HasPrefix(strings.TrimSpace(v), "name:") {
+					q.from, q.to = t.From, t.To
+				}
+			}
+		}
+		for _, stmt := range c.statements {
+			if astutil.IsEnclose(list, stmt.tokens[0].From) {
+				q.toks = stmt.tokens
+				break
+			}
+		}
+		if len(q.toks) > 0 {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// checkSqlc finds the problems of the queries of sqlc: the unknown commands
+// and functions of sqlc, the duplicate names, the commands returning the rows
+// of the statements that return none, the :one queries returning more than a
+// row, the positional and the named parameters mixed, and the columns of the
+// results and of the named parameters that the tables do not have.
+func checkSqlc(c *lintContext) []problem {
+	problems := []problem{}
+	names := map[string]bool{}
+	for _, q := range c.sqlcQueries() {
+		if names[q.name] {
+			problems = append(problems, q.commentProblem(fmt.Sprintf("duplicate query name %s of sqlc", q.name)))
+		}
+		names[q.name] = true
+		returnsRows, known := sqlcCommands[q.command]
+		if !known {
+			commands := []string{}
+			for command := range sqlcCommands {
+				commands = append(commands, ":"+command)
+			}
+			sort.Strings(commands)
+			problems = append(problems, q.commentProblem(fmt.Sprintf("unknown command :%s of sqlc, which is one of %s", q.command, strings.Join(commands, ", "))))
+		}
+		first := q.toks[0]
+		switch {
+		case returnsRows && !returnsResult(q.toks):
+			problems = append(problems, tokenProblem(first, fmt.Sprintf("the :%s query %s returns no rows without RETURNING", q.command, q.name)))
+		case q.command == "copyfrom" && keyword(first.Token) != "INSERT":
+			problems = append(problems, tokenProblem(first, fmt.Sprintf("the :copyfrom query %s must be INSERT", q.name)))
+		case q.command == "one":
+			problems = append(problems, checkSqlcOne(q)...)
+		}
+		problems = append(problems, checkSqlcParameters(q)...)
+		if c.dbCache != nil {
+			problems = append(problems, c.checkSqlcColumns(q)...)
+		}
+	}
+	return problems
+}
+
+// returnsResult reports whether the statement returns the rows, a query or a
+// statement with RETURNING.
+func returnsResult(toks []*lintToken) bool {
+	switch keyword(toks[0].Token) {
+	case "SELECT", "VALUES", "SHOW", "EXPLAIN", "TABLE":
+		return true
+	}
+	for _, tok := range toks {
+		if tok.depth == 0 && upperWord(tok) == "RETURNING" {
+			return true
+		}
+	}
+	// WITH followed by SELECT
+	if keyword(toks[0].Token) == "WITH" {
+		for _, tok := range toks {
+			if tok.depth == 0 {
+				switch keyword(tok.Token) {
+				case "SELECT":
+					return true
+				case "INSERT", "UPDATE", "DELETE":
+					return false
+				}
+			}
+		}
+	}
+	return false
+}
+
+// checkSqlcOne finds the :one queries returning more than a row, whose rows
+// after the first are dropped: LIMIT of more than a row, and SELECT of the
+// tables without WHERE, LIMIT and the aggregate functions.
+func checkSqlcOne(q *sqlcQuery) []problem {
+	toks := q.toks
+	hasFrom, hasFilter, sel := false, false, -1
+	for i, tok := range toks {
+		if tok.depth != 0 {
+			continue
+		}
+		switch upperWord(tok) {
+		case "SELECT":
+			if sel < 0 {
+				sel = i
+			}
+		case "FROM":
+			hasFrom = true
+		case "WHERE", "HAVING", "FETCH", "TOP":
+			hasFilter = true
+		case "GROUP", "UNION", "INTERSECT", "EXCEPT":
+			// The rows of the groups and of the set operations are not
+			// checked
+			return nil
+		case "LIMIT":
+			hasFilter = true
+			if i+1 >= len(toks) || toks[i+1].Kind != token.Number {
+				continue
+			}
+			if n, err := strconv.Atoi(toks[i+1].Value.(string)); err == nil && n > 1 {
+				return []problem{tokenProblem(toks[i+1], fmt.Sprintf("the :one query %s returns the first of up to %d rows", q.name, n))}
+			}
+		}
+	}
+	if sel < 0 || !hasFrom || hasFilter {
+		return nil
+	}
+	for i, tok := range toks {
+		if tok.depth == 0 && tok.clause == "SELECT" && aggregateFunctions[upperWord(tok)] && i+1 < len(toks) && toks[i+1].Kind == token.LParen {
+			return nil
+		}
+	}
+	return []problem{tokenProblem(toks[sel], fmt.Sprintf("the :one query %s may return more than a row without WHERE or LIMIT", q.name))}
+}
+
+// sqlcParameter is a parameter of a query, named by @name and sqlc.arg(name)
+// or positional by $1 and ?.
+type sqlcParameter struct {
+	name string
+	// start and end are the indexes of the tokens of the parameter
+	start, end int
+}
+
+// sqlcParameters returns the named and the positional parameters of the
+// query, and the problems of the unknown functions of sqlc.
+func sqlcParameters(toks []*lintToken) (named, positional []*sqlcParameter, problems []problem) {
+	for i, tok := range toks {
+		switch {
+		case tok.Kind == token.Char && tok.Value == "?":
+			positional = append(positional, &sqlcParameter{start: i, end: i})
+		case tok.Kind == token.Char && tok.Value == "$" && i+1 < len(toks) && toks[i+1].Kind == token.Number:
+			positional = append(positional, &sqlcParameter{start: i, end: i + 1})
+		case isIdentifier(tok) && strings.HasPrefix(tok.Value.(*token.SQLWord).Value, "@") && tok.Value.(*token.SQLWord).QuoteStyle == 0:
+			name := strings.TrimPrefix(tok.Value.(*token.SQLWord).Value, "@")
+			// @@variables of MySQL and SQL Server are not parameters
+			if name != "" && !strings.HasPrefix(name, "@") {
+				named = append(named, &sqlcParameter{name: name, start: i, end: i})
+			}
+		case upperWord(tok) == "SQLC" && i+3 < len(toks) && toks[i+1].Kind == token.Period && toks[i+3].Kind == token.LParen:
+			fn := upperWord(toks[i+2])
+			if !sqlcFunctions[fn] {
+				problems = append(problems, problem{
+					from:    tok.From,
+					to:      toks[i+2].To,
+					message: fmt.Sprintf("unknown function sqlc.%s, which is one of sqlc.arg, sqlc.narg, sqlc.slice and sqlc.embed", toks[i+2].Value.(*token.SQLWord).Value),
+				})
+				continue
+			}
+			end := matchParen(toks, i+3)
+			if fn == "EMBED" || end < 0 || end != i+5 {
+				continue
+			}
+			var name string
+			switch arg := toks[i+4]; {
+			case isIdentifier(arg):
+				name = arg.Value.(*token.SQLWord).Value
+			case arg.Kind == token.SingleQuotedString:
+				name = strings.Trim(arg.Value.(string), "'")
+			}
+			named = append(named, &sqlcParameter{name: name, start: i, end: end})
+		}
+	}
+	return named, positional, problems
+}
+
+// checkSqlcParameters finds the unknown functions of sqlc and the queries
+// mixing the positional and the named parameters, which sqlc cannot generate.
+func checkSqlcParameters(q *sqlcQuery) []problem {
+	named, positional, problems := sqlcParameters(q.toks)
+	if len(named) > 0 && len(positional) > 0 {
+		p := positional[0]
+		problems = append(problems, problem{
+			from:    q.toks[p.start].From,
+			to:      q.toks[p.end].To,
+			message: fmt.Sprintf("the query %s mixes the positional and the named parameters of sqlc", q.name),
+		})
+	}
+	return problems
+}
+
+// checkSqlcColumns finds the columns of the results and the ones compared
+// with the named parameters that are not in the tables of the query. The
+// columns of the tables not in the cache are not checked.
+func (c *lintContext) checkSqlcColumns(q *sqlcQuery) []problem {
+	toks := q.toks
+	problems := []problem{}
+	for i, tok := range toks {
+		if tok.depth != 0 {
+			continue
+		}
+		var list []*lintToken
+		switch keyword(tok.Token) {
+		case "SELECT":
+			list, _ = selectList(toks, i)
+		case "RETURNING":
+			end := i + 1
+			for end < len(toks) && toks[end].depth >= tok.depth {
+				end++
+			}
+			list = toks[i+1 : end]
+		default:
+			continue
+		}
+		items, _ := selectItems(list, tok.depth)
+		for _, item := range items {
+			if len(item.toks) == 0 || !isIdentifier(item.toks[0]) {
+				continue
+			}
+			if keyword(item.toks[0].Token) != "" {
+				continue
+			}
+			// Only the columns, such as id and a.id AS author_id
+			end, last := chainEnd(item.toks, 0), len(item.toks)-1
+			switch {
+			case end == last:
+			case end+2 == last && upperWord(item.toks[end+1]) == "AS":
+			case end+1 == last && isIdentifier(item.toks[last]) && keyword(item.toks[last].Token) == "":
+			default:
+				continue
+			}
+			col := newColumn(item.toks, 0, end)
+			if msg, ok := c.unknownColumn(col); ok {
+				problems = append(problems, problem{from: col.from, to: col.to, message: msg})
+			}
+		}
+	}
+
+	named, _, _ := sqlcParameters(toks)
+	for _, p := range named {
+		if p.start < 2 || !isComparison(toks[p.start-1]) {
+			continue
+		}
+		col, ok := columnBefore(toks, p.start-2)
+		if !ok {
+			continue
+		}
+		if msg, ok := c.unknownColumn(col); ok {
+			problems = append(problems, problem{
+				from:    col.from,
+				to:      col.to,
+				message: fmt.Sprintf("%s, which the parameter %s is compared with", msg, p.name),
+			})
+		}
+	}
+	return problems
+}
+
+// unknownColumn returns the message of the column that is not in the tables
+// of the query, or false if it is or the tables are not known.
+func (c *lintContext) unknownColumn(col *column) (string, bool) {
+	if strings.HasPrefix(col.name, "@") {
+		return "", false
+	}
+	tables, err := parseutil.ExtractTable(c.parsed, col.from)
+	if err != nil || len(tables) == 0 {
+		return "", false
+	}
+	names := []string{}
+	for _, table := range tables {
+		if col.qualifier != "" && !strings.EqualFold(table.Alias, col.qualifier) && !strings.EqualFold(table.Name, col.qualifier) {
+			continue
+		}
+		cols, ok := c.dbCache.ColumnDescs(table.Name)
+		if table.DatabaseSchema != "" {
+			cols, ok = c.dbCache.ColumnDatabase(table.DatabaseSchema, table.Name)
+		}
+		if !ok {
+			return "", false
+		}
+		if hasColumn(cols, col.name) {
+			return "", false
+		}
+		names = append(names, table.Name)
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	return fmt.Sprintf("column %s does not exist in %s", col.name, strings.Join(names, ", ")), true
+}
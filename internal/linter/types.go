@@ -0,0 +1,431 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/parser/parseutil"
+	"github.com/sqls-server/sqls/token"
+)
+
+type typeClass int
+
+const (
+	classOther typeClass = iota
+	classString
+	classNumber
+	classDate
+	classTimestamp
+	classTime
+)
+
+var typeClasses = map[string]typeClass{
+	"char":       classString,
+	"varchar":    classString,
+	"varchar2":   classString,
+	"nchar":      classString,
+	"nvarchar":   classString,
+	"nvarchar2":  classString,
+	"character":  classString,
+	"bpchar":     classString,
+	"text":       classString,
+	"tinytext":   classString,
+	"mediumtext": classString,
+	"longtext":   classString,
+	"ntext":      classString,
+	"citext":     classString,
+	"clob":       classString,
+	"nclob":      classString,
+	"string":     classString,
+	"enum":       classString,
+
+	"int":       classNumber,
+	"integer":   classNumber,
+	"tinyint":   classNumber,
+	"smallint":  classNumber,
+	"mediumint": classNumber,
+	"bigint":    classNumber,
+	"int2":      classNumber,
+	"int4":      classNumber,
+	"int8":      classNumber,
+	"serial":    classNumber,
+	"bigserial": classNumber,
+	"decimal":   classNumber,
+	"numeric":   classNumber,
+	"number":    classNumber,
+	"float":     classNumber,
+	"float4":    classNumber,
+	"float8":    classNumber,
+	"double":    classNumber,
+	"real":      classNumber,
+	"money":     classNumber,
+
+	"date":           classDate,
+	"datetime":       classTimestamp,
+	"datetime2":      classTimestamp,
+	"smalldatetime":  classTimestamp,
+	"datetimeoffset": classTimestamp,
+	"timestamp":      classTimestamp,
+	"timestamptz":    classTimestamp,
+	"time":           classTime,
+	"timetz":         classTime,
+}
+
+// classifyType returns the class of the column type such as varchar(10) and
+// timestamp without time zone.
+func classifyType(typ string) typeClass {
+	base := strings.ToLower(typ)
+	if i := strings.IndexAny(base, "( "); i >= 0 {
+		base = base[:i]
+	}
+	return typeClasses[base]
+}
+
+var (
+	dateRe = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})`)
+	timeRe = regexp.MustCompile(`^(\d{1,2}):(\d{2})(?::(\d{2})(?:\.\d+)?)?\s*(?:Z|[+-]\d{2}(?::?\d{2})?|[A-Za-z][A-Za-z/_]*)?$`)
+	// compactDateRe is YYYYMMDD of MySQL
+	compactDateRe = regexp.MustCompile(`^(\d{4})(\d{2})(\d{2})$`)
+)
+
+// specialDates are the values of the dates that PostgreSQL accepts.
+var specialDates = map[string]bool{
+	"now":       true,
+	"today":     true,
+	"tomorrow":  true,
+	"yesterday": true,
+	"infinity":  true,
+	"-infinity": true,
+	"epoch":     true,
+	"allballs":  true,
+}
+
+// isValidDateTime reports whether the string is the date, the timestamp or the
+// time of the class, in ISO 8601 format.
+func isValidDateTime(s string, class typeClass) bool {
+	s = strings.TrimSpace(s)
+	if specialDates[strings.ToLower(s)] {
+		return true
+	}
+	if class == classTime {
+		return isValidTime(s)
+	}
+	if m := compactDateRe.FindStringSubmatch(s); m != nil {
+		return isValidDate(m[1], m[2], m[3])
+	}
+	m := dateRe.FindStringSubmatch(s)
+	if m == nil || !isValidDate(m[1], m[2], m[3]) {
+		return false
+	}
+	rest := s[len(m[0]):]
+	if rest == "" {
+		return true
+	}
+	if rest[0] != ' ' && rest[0] != 'T' {
+		return false
+	}
+	return isValidTime(strings.TrimSpace(rest[1:]))
+}
+
+func isValidDate(year, month, day string) bool {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	t := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	return t.Year() == y && int(t.Month()) == m && t.Day() == d
+}
+
+func isValidTime(s string) bool {
+	m := timeRe.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	second, _ := strconv.Atoi(m[3])
+	return hour < 24 && minute < 60 && second < 60
+}
+
+// literal is a number or a string that a column is compared with.
+type literal struct {
+	value    string
+	isNumber bool
+	from     token.Pos
+	to       token.Pos
+}
+
+func (l *literal) String() string {
+	if l.isNumber {
+		return "the number " + l.value
+	}
+	return "'" + l.value + "'"
+}
+
+// column is a column that may be qualified by the table.
+type column struct {
+	// text is the column as it is written
+	text      string
+	qualifier string
+	name      string
+	from      token.Pos
+	to        token.Pos
+}
+
+// checkTypeMismatch finds the comparisons of the columns with the literals of
+// the other types, which convert the columns not to use the indexes or fail.
+func checkTypeMismatch(c *lintContext) []problem {
+	if c.dbCache == nil {
+		return nil
+	}
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		for i, tok := range toks {
+			switch {
+			case isComparison(tok):
+				if col, ok := columnBefore(toks, i-1); ok {
+					if lit, ok := literalAfter(toks, i+1); ok {
+						problems = append(problems, c.compare(col, lit, col.from, lit.to)...)
+					}
+				} else if lit, ok := literalBefore(toks, i-1); ok {
+					if col, ok := columnAfter(toks, i+1); ok {
+						problems = append(problems, c.compare(col, lit, lit.from, col.to)...)
+					}
+				}
+			case upperWord(tok) == "IN":
+				end := i - 1
+				if end >= 0 && upperWord(toks[end]) == "NOT" {
+					end--
+				}
+				col, ok := columnBefore(toks, end)
+				if !ok {
+					continue
+				}
+				for _, lit := range inListLiterals(toks, i+1) {
+					problems = append(problems, c.compare(col, lit, lit.from, lit.to)...)
+				}
+			}
+		}
+	}
+	return problems
+}
+
+func (c *lintContext) compare(col *column, lit *literal, from, to token.Pos) []problem {
+	desc := c.columnDesc(col)
+	if desc == nil {
+		return nil
+	}
+	var message string
+	switch class := classifyType(desc.Type); class {
+	case classString:
+		if lit.isNumber {
+			message = fmt.Sprintf("column %s of type %s is compared with %s", col.name, desc.Type, lit)
+		}
+	case classNumber:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(lit.value), 64); err != nil {
+			message = fmt.Sprintf("column %s of type %s is compared with %s, which is not a number", col.name, desc.Type, lit)
+		}
+	case classDate, classTimestamp, classTime:
+		if lit.isNumber {
+			message = fmt.Sprintf("column %s of type %s is compared with %s", col.name, desc.Type, lit)
+		} else if !isValidDateTime(lit.value, class) {
+			message = fmt.Sprintf("column %s of type %s is compared with %s, which is not a valid %s", col.name, desc.Type, lit, className(class))
+		}
+	}
+	if message == "" {
+		return nil
+	}
+	return []problem{{from: from, to: to, message: message}}
+}
+
+func className(class typeClass) string {
+	switch class {
+	case classDate:
+		return "date"
+	case classTime:
+		return "time"
+	}
+	return "timestamp"
+}
+
+// columnDesc returns the column of the tables of the query, or nil if it is
+// not found or is in more than one of the tables.
+func (c *lintContext) columnDesc(col *column) *database.ColumnDesc {
+	tables, err := parseutil.ExtractTable(c.parsed, col.from)
+	if err != nil {
+		return nil
+	}
+	var found *database.ColumnDesc
+	for _, table := range tables {
+		if col.qualifier != "" && !strings.EqualFold(table.Alias, col.qualifier) && !strings.EqualFold(table.Name, col.qualifier) {
+			continue
+		}
+		cols, ok := c.dbCache.ColumnDescs(table.Name)
+		if table.DatabaseSchema != "" {
+			cols, ok = c.dbCache.ColumnDatabase(table.DatabaseSchema, table.Name)
+		}
+		if !ok {
+			continue
+		}
+		for _, desc := range cols {
+			if !strings.EqualFold(desc.Name, col.name) {
+				continue
+			}
+			if found != nil {
+				return nil
+			}
+			found = desc
+		}
+	}
+	return found
+}
+
+func isComparison(tok *lintToken) bool {
+	switch tok.Kind {
+	case token.Eq, token.Neq, token.Lt, token.Gt, token.LtEq, token.GtEq:
+		return true
+	}
+	return false
+}
+
+// isOperator reports whether the token makes an expression with the operand
+// next to it, so that the operand is not compared as it is.
+func isOperator(toks []*lintToken, i int) bool {
+	if i < 0 || i >= len(toks) {
+		return false
+	}
+	switch toks[i].Kind {
+	case token.Plus, token.Minus, token.Mult, token.Div, token.Caret, token.Mod,
+		token.Char, token.Period, token.DoubleColon, token.Ampersand, token.LBracket:
+		return true
+	}
+	return upperWord(toks[i]) == "COLLATE"
+}
+
+func isIdentifier(tok *lintToken) bool {
+	return tok.Kind == token.SQLKeyword
+}
+
+// columnBefore returns the column ending at the index.
+func columnBefore(toks []*lintToken, end int) (*column, bool) {
+	if end < 0 || !isIdentifier(toks[end]) {
+		return nil, false
+	}
+	start := end
+	for start >= 2 && toks[start-1].Kind == token.Period && isIdentifier(toks[start-2]) {
+		start -= 2
+	}
+	if isOperator(toks, start-1) {
+		return nil, false
+	}
+	return newColumn(toks, start, end), true
+}
+
+// columnAfter returns the column starting at the index.
+func columnAfter(toks []*lintToken, start int) (*column, bool) {
+	if start >= len(toks) || !isIdentifier(toks[start]) {
+		return nil, false
+	}
+	end := chainEnd(toks, start)
+	if isOperator(toks, end+1) || (end+1 < len(toks) && toks[end+1].Kind == token.LParen) {
+		return nil, false
+	}
+	return newColumn(toks, start, end), true
+}
+
+// chainEnd returns the index of the last word of the words joined by the
+// periods, such as schema.table.column.
+func chainEnd(toks []*lintToken, start int) int {
+	end := start
+	for end+2 < len(toks) && toks[end+1].Kind == token.Period && isIdentifier(toks[end+2]) {
+		end += 2
+	}
+	return end
+}
+
+func newColumn(toks []*lintToken, start, end int) *column {
+	words := []string{}
+	for i := start; i <= end; i += 2 {
+		words = append(words, toks[i].Value.(*token.SQLWord).String())
+	}
+	col := &column{
+		text: strings.Join(words, "."),
+		name: toks[end].Value.(*token.SQLWord).Value,
+		from: toks[start].From,
+		to:   toks[end].To,
+	}
+	if end-start >= 2 {
+		col.qualifier = toks[end-2].Value.(*token.SQLWord).Value
+	}
+	return col
+}
+
+func newLiteral(tok *lintToken) (*literal, bool) {
+	switch tok.Kind {
+	case token.Number:
+		return &literal{value: tok.Value.(string), isNumber: true, from: tok.From, to: tok.To}, true
+	case token.SingleQuotedString, token.NationalStringLiteral:
+		value := strings.TrimSuffix(strings.TrimPrefix(tok.Value.(string), "'"), "'")
+		return &literal{value: value, from: tok.From, to: tok.To}, true
+	}
+	return nil, false
+}
+
+// literalAfter returns the literal starting at the index, with the sign of
+// the number.
+func literalAfter(toks []*lintToken, start int) (*literal, bool) {
+	if start >= len(toks) {
+		return nil, false
+	}
+	sign := ""
+	from := toks[start].From
+	if toks[start].Kind == token.Minus && start+1 < len(toks) && toks[start+1].Kind == token.Number {
+		sign = "-"
+		start++
+	}
+	lit, ok := newLiteral(toks[start])
+	if !ok || isOperator(toks, start+1) {
+		return nil, false
+	}
+	lit.value = sign + lit.value
+	lit.from = from
+	return lit, true
+}
+
+// literalBefore returns the literal ending at the index.
+func literalBefore(toks []*lintToken, end int) (*literal, bool) {
+	if end < 0 || isOperator(toks, end-1) {
+		return nil, false
+	}
+	return newLiteral(toks[end])
+}
+
+// inListLiterals returns the literals of IN list starting at the index, which
+// are not in the expressions.
+func inListLiterals(toks []*lintToken, start int) []*literal {
+	if start >= len(toks) || toks[start].Kind != token.LParen {
+		return nil
+	}
+	depth := toks[start].depth
+	literals := []*literal{}
+	item := []*lintToken{}
+	for _, tok := range toks[start+1:] {
+		end := tok.depth == depth && tok.Kind == token.RParen
+		if end || (tok.depth == depth+1 && tok.Kind == token.Comma) {
+			if lit, ok := literalAfter(item, 0); ok && (len(item) == 1 || (len(item) == 2 && item[0].Kind == token.Minus)) {
+				literals = append(literals, lit)
+			}
+			if end {
+				break
+			}
+			item = []*lintToken{}
+			continue
+		}
+		item = append(item, tok)
+	}
+	return literals
+}
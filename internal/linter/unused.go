@@ -0,0 +1,359 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sqls-server/sqls/token"
+)
+
+// cte is a common table expression of WITH.
+type cte struct {
+	name *lintToken
+	// lparen and rparen are the indexes of the parentheses of the query
+	lparen int
+	rparen int
+	// modifies is true for INSERT, UPDATE and DELETE of PostgreSQL, which run
+	// even if they are not referenced
+	modifies bool
+}
+
+// checkUnusedCte finds the common table expressions that are not referenced.
+func checkUnusedCte(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		for i, tok := range toks {
+			// WITH (NOLOCK) is the table hint of T-SQL
+			if upperWord(tok) != "WITH" || i+1 >= len(toks) || toks[i+1].Kind == token.LParen {
+				continue
+			}
+			ctes, main := parseWith(toks, i)
+			for k, e := range ctes {
+				if e.modifies || isCteReferenced(toks, e) {
+					continue
+				}
+				var remove edit
+				switch {
+				case len(ctes) == 1:
+					remove = edit{from: tok.From, to: toks[main].From}
+				case k < len(ctes)-1:
+					remove = edit{from: e.name.From, to: ctes[k+1].name.From}
+				default:
+					remove = edit{from: toks[ctes[k-1].rparen].To, to: toks[e.rparen].To}
+				}
+				name := e.name.Value.(*token.SQLWord).Value
+				problems = append(problems, problem{
+					from:    e.name.From,
+					to:      e.name.To,
+					message: fmt.Sprintf("CTE %s is not used", name),
+					unused:  true,
+					fix:     &fix{title: "Remove CTE " + name, edits: []edit{remove}},
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// parseWith parses the common table expressions of WITH at the index, and
+// returns them with the index of the query using them.
+func parseWith(toks []*lintToken, with int) ([]*cte, int) {
+	ctes := []*cte{}
+	i := with + 1
+	if i < len(toks) && upperWord(toks[i]) == "RECURSIVE" {
+		i++
+	}
+	for {
+		if i >= len(toks) || !isIdentifier(toks[i]) {
+			return nil, 0
+		}
+		e := &cte{name: toks[i]}
+		i++
+		if i < len(toks) && toks[i].Kind == token.LParen {
+			// column names
+			i = matchParen(toks, i) + 1
+		}
+		if i >= len(toks) || upperWord(toks[i]) != "AS" {
+			return nil, 0
+		}
+		i++
+		if i < len(toks) && upperWord(toks[i]) == "NOT" {
+			i++
+		}
+		if i < len(toks) && upperWord(toks[i]) == "MATERIALIZED" {
+			i++
+		}
+		if i >= len(toks) || toks[i].Kind != token.LParen {
+			return nil, 0
+		}
+		e.lparen = i
+		e.rparen = matchParen(toks, i)
+		if e.rparen < 0 || e.rparen+1 >= len(toks) {
+			return nil, 0
+		}
+		switch upperWord(toks[i+1]) {
+		case "INSERT", "UPDATE", "DELETE", "MERGE":
+			e.modifies = true
+		}
+		ctes = append(ctes, e)
+		i = e.rparen + 1
+		if toks[i].Kind != token.Comma {
+			return ctes, i
+		}
+		i++
+	}
+}
+
+// matchParen returns the index of the parenthesis closing the one at the
+// index, or -1 if it is not closed.
+func matchParen(toks []*lintToken, lparen int) int {
+	for i := lparen + 1; i < len(toks); i++ {
+		if toks[i].depth == toks[lparen].depth && toks[i].Kind == token.RParen {
+			return i
+		}
+	}
+	return -1
+}
+
+// isCteReferenced reports whether the name of the CTE is in the statement
+// other than its definition. The references of a recursive CTE to itself do
+// not count.
+func isCteReferenced(toks []*lintToken, e *cte) bool {
+	name := e.name.Value.(*token.SQLWord).Value
+	for i, tok := range toks {
+		if tok == e.name || (i > e.lparen && i < e.rparen) {
+			continue
+		}
+		if isWord(toks, i, name) && (i == 0 || toks[i-1].Kind != token.Period) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWord(toks []*lintToken, i int, word string) bool {
+	return isIdentifier(toks[i]) && strings.EqualFold(toks[i].Value.(*token.SQLWord).Value, word)
+}
+
+// checkUnusedAlias finds the aliases of the tables that do not qualify any
+// column.
+func checkUnusedAlias(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		for i, tok := range toks {
+			if upperWord(tok) != "FROM" || tok.clause != "FROM" {
+				continue
+			}
+			fc := parseFrom(toks, i)
+			names := map[string]int{}
+			for _, ref := range fc.refs {
+				names[strings.ToUpper(ref.name)]++
+			}
+			for _, ref := range fc.refs {
+				// The aliases are needed for the same tables joined twice
+				if ref.aliasTok == nil || ref.name == "" || names[strings.ToUpper(ref.name)] > 1 {
+					continue
+				}
+				if isAliasUsed(toks, ref.aliasTok) {
+					continue
+				}
+				problems = append(problems, problem{
+					from:    ref.aliasTok.From,
+					to:      ref.aliasTok.To,
+					message: fmt.Sprintf("alias %s of %s is not used", ref.alias, ref.name),
+					unused:  true,
+					fix: &fix{
+						title: "Remove alias " + ref.alias,
+						edits: []edit{{from: ref.nameEnd.To, to: ref.aliasTok.To}},
+					},
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// isAliasUsed reports whether the alias qualifies a column, or is the table
+// of DELETE and UPDATE of MySQL.
+func isAliasUsed(toks []*lintToken, alias *lintToken) bool {
+	name := alias.Value.(*token.SQLWord).Value
+	for i, tok := range toks {
+		if tok == alias || !isWord(toks, i, name) || (i > 0 && toks[i-1].Kind == token.Period) {
+			continue
+		}
+		if i+1 < len(toks) && toks[i+1].Kind == token.Period {
+			return true
+		}
+		if tok.clause == "DELETE" || tok.clause == "UPDATE" {
+			return true
+		}
+	}
+	return false
+}
+
+// selectItem is a column of the select list.
+type selectItem struct {
+	toks []*lintToken
+	// name is empty for the expressions without the aliases
+	name string
+}
+
+// checkUnusedColumn finds the columns of the derived tables that the outer
+// queries do not use.
+func checkUnusedColumn(c *lintContext) []problem {
+	problems := []problem{}
+	for _, stmt := range c.statements {
+		toks := stmt.tokens
+		for i, tok := range toks {
+			if upperWord(tok) != "FROM" || tok.clause != "FROM" {
+				continue
+			}
+			for _, ref := range parseFrom(toks, i).refs {
+				if toks[ref.start].Kind != token.LParen || ref.aliasTok == nil {
+					continue
+				}
+				problems = append(problems, checkDerivedTable(toks, ref)...)
+			}
+		}
+	}
+	return problems
+}
+
+func checkDerivedTable(toks []*lintToken, ref *tableRef) []problem {
+	lparen := ref.start
+	rparen := matchParen(toks, lparen)
+	if rparen < 0 || upperWord(toks[lparen+1]) != "SELECT" {
+		return nil
+	}
+	inner := toks[lparen+1 : rparen]
+	depth := inner[0].depth
+	for _, tok := range inner {
+		if tok.depth != depth {
+			continue
+		}
+		switch upperWord(tok) {
+		case "UNION", "INTERSECT", "EXCEPT", "MINUS":
+			// The columns are matched by the positions
+			return nil
+		}
+	}
+	if len(inner) < 2 || upperWord(inner[1]) == "DISTINCT" {
+		// The columns decide the rows
+		return nil
+	}
+
+	items, rest := selectItems(inner[1:], depth)
+	outer := append(append([]*lintToken{}, toks[:lparen]...), toks[rparen+1:]...)
+	if len(items) < 2 || selectsAll(outer, ref.alias) {
+		return nil
+	}
+
+	problems := []problem{}
+	for k, item := range items {
+		if item.name == "" || isColumnUsed(outer, item.name, ref.alias) || isColumnUsed(rest, item.name, "") {
+			continue
+		}
+		var remove edit
+		if k < len(items)-1 {
+			remove = edit{from: item.toks[0].From, to: items[k+1].toks[0].From}
+		} else {
+			prev := items[k-1].toks
+			remove = edit{from: prev[len(prev)-1].To, to: item.toks[len(item.toks)-1].To}
+		}
+		problems = append(problems, problem{
+			from:    item.toks[0].From,
+			to:      item.toks[len(item.toks)-1].To,
+			message: fmt.Sprintf("column %s of %s is not used", item.name, ref.alias),
+			unused:  true,
+			fix:     &fix{title: "Remove column " + item.name, edits: []edit{remove}},
+		})
+	}
+	return problems
+}
+
+// selectItems splits the select list into the columns, and returns them with
+// the tokens after the list.
+func selectItems(toks []*lintToken, depth int) ([]*selectItem, []*lintToken) {
+	items := []*selectItem{}
+	cur := []*lintToken{}
+	i := 0
+	for ; i < len(toks); i++ {
+		tok := toks[i]
+		if tok.depth == depth && upperWord(tok) == "FROM" {
+			break
+		}
+		if tok.depth == depth && tok.Kind == token.Comma {
+			items = append(items, newSelectItem(cur))
+			cur = []*lintToken{}
+			continue
+		}
+		cur = append(cur, tok)
+	}
+	if len(cur) > 0 {
+		items = append(items, newSelectItem(cur))
+	}
+	return items, toks[i:]
+}
+
+func newSelectItem(toks []*lintToken) *selectItem {
+	item := &selectItem{toks: toks}
+	last := len(toks) - 1
+	if last < 0 || !isIdentifier(toks[last]) {
+		return item
+	}
+	name := toks[last].Value.(*token.SQLWord).Value
+	switch {
+	case chainEnd(toks, 0) == last:
+		// column
+		item.name = name
+	case upperWord(toks[last-1]) == "AS":
+		item.name = name
+	case keyword(toks[last].Token) == "" && toks[last-1].Kind != token.Period && !isOperator(toks, last-1):
+		// alias without AS
+		item.name = name
+	}
+	return item
+}
+
+// selectsAll reports whether the tokens select all the columns of the table by
+// * or table.*, or join it by NATURAL JOIN.
+func selectsAll(toks []*lintToken, alias string) bool {
+	for i, tok := range toks {
+		if upperWord(tok) == "NATURAL" {
+			return true
+		}
+		if tok.Kind != token.Mult || i == 0 {
+			continue
+		}
+		prev := toks[i-1]
+		if prev.Kind == token.Period && i >= 2 && isWord(toks, i-2, alias) {
+			return true
+		}
+		if prev.clause == "SELECT" {
+			switch {
+			case prev.Kind == token.Comma, upperWord(prev) == "SELECT", upperWord(prev) == "DISTINCT", upperWord(prev) == "ALL":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isColumnUsed reports whether the tokens have the column without the table,
+// or qualified by the table if it is not empty.
+func isColumnUsed(toks []*lintToken, name, table string) bool {
+	for i := range toks {
+		if !isWord(toks, i, name) {
+			continue
+		}
+		if i == 0 || toks[i-1].Kind != token.Period {
+			return true
+		}
+		if table != "" && i >= 2 && isWord(toks, i-2, table) {
+			return true
+		}
+	}
+	return false
+}
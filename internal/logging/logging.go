@@ -0,0 +1,123 @@
+// Package logging sets up the leveled structured logger of the server, which
+// writes to stderr and optionally to a file rotated by its size.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// Options configure the logger of the server.
+type Options struct {
+	// Level is debug, info, warn or error, info if empty
+	Level string
+	// JSON writes the records as JSON objects instead of key=value texts
+	JSON bool
+	// File is the path of the file logged to in addition to stderr
+	File string
+	// MaxSize is the megabytes of the file to rotate it, never rotated if 0
+	MaxSize int
+	// MaxBackups is the number of the rotated files kept,
+	// defaultMaxBackups if 0
+	MaxBackups int
+}
+
+const defaultMaxBackups = 3
+
+// ParseLevel returns the level of the name, info if it is empty.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q, debug, info, warn or error", name)
+}
+
+// New returns the logger writing to w and the file of the options, and the
+// closer of the file.
+func New(opts Options, w io.Writer) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	var closer io.Closer = nopCloser{}
+	if opts.File != "" {
+		maxBackups := opts.MaxBackups
+		if maxBackups == 0 {
+			maxBackups = defaultMaxBackups
+		}
+		f, err := OpenRotatingFile(opts.File, int64(opts.MaxSize)*1024*1024, maxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		w = io.MultiWriter(w, f)
+		closer = f
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if opts.JSON {
+		h = slog.NewJSONHandler(w, handlerOpts)
+	} else {
+		h = slog.NewTextHandler(w, handlerOpts)
+	}
+	return slog.New(h), closer, nil
+}
+
+// Setup makes the logger of the options the default one, which the log
+// package also writes to.
+func Setup(opts Options, w io.Writer) (io.Closer, error) {
+	logger, closer, err := New(opts, w)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return closer, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+type contextKey struct{}
+
+// WithLogger returns the context of the logger, such as the one with the ID
+// of the request being served.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger of the context, or the default one.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
+
+// Recovered logs the value recovered from a panic with the stack of the
+// goroutine, and returns it as an error, or nil if r is nil. It is called
+// with recover() in the deferred functions of the requests and the
+// goroutines, not to take the whole server down.
+func Recovered(logger *slog.Logger, r interface{}, where string) error {
+	if r == nil {
+		return nil
+	}
+	// Same as net/http
+	const size = 64 << 10
+	buf := make([]byte, size)
+	buf = buf[:runtime.Stack(buf, false)]
+	logger.Error("panic in "+where, "panic", r, "stack", string(buf))
+	return fmt.Errorf("unexpected panic: %v", r)
+}
@@ -0,0 +1,151 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{name: "", want: slog.LevelInfo},
+		{name: "debug", want: slog.LevelDebug},
+		{name: "INFO", want: slog.LevelInfo},
+		{name: "warning", want: slog.LevelWarn},
+		{name: "error", want: slog.LevelError},
+		{name: "trace", wantErr: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("unmatched level: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, closer, err := New(Options{Level: "warn", JSON: true}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+
+	ctx := WithLogger(context.Background(), logger.With("method", "textDocument/completion", "id", "3"))
+	FromContext(ctx).Info("ignored")
+	FromContext(ctx).Error("error serving", "err", "no connection")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("unmatched records: %q", buf.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		"level":  "ERROR",
+		"msg":    "error serving",
+		"method": "textDocument/completion",
+		"id":     "3",
+		"err":    "no connection",
+	} {
+		if record[k] != v {
+			t.Errorf("unmatched %s: got %v, want %v", k, record[k], v)
+		}
+	}
+}
+
+func TestFromContextDefault(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("expected the default logger")
+	}
+}
+
+func TestRecovered(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	if err := Recovered(logger, nil, "nothing"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected record: %q", buf.String())
+	}
+
+	err := func() (err error) {
+		defer func() {
+			err = Recovered(logger, recover(), "serving textDocument/hover")
+		}()
+		var m map[string]int
+		m["a"]++
+		return nil
+	}()
+	if err == nil || !strings.Contains(err.Error(), "assignment to entry in nil map") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["msg"] != "panic in serving textDocument/hover" {
+		t.Errorf("unmatched msg: %v", record["msg"])
+	}
+	if stack, _ := record["stack"].(string); !strings.Contains(stack, "TestRecovered") {
+		t.Errorf("expected the stack of the panic, got %q", stack)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sqls.log")
+	f, err := OpenRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for p, text := range want {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != text {
+			t.Errorf("unmatched %s: got %q, want %q", filepath.Base(p), b, text)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected %s.3 to be removed, %v", filepath.Base(path), err)
+	}
+}
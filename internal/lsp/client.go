@@ -2,9 +2,9 @@ package lsp
 
 import (
 	"context"
-	"log"
 
 	"github.com/sourcegraph/jsonrpc2"
+	"github.com/sqls-server/sqls/internal/logging"
 )
 
 type MessageDisplayer interface {
@@ -25,7 +25,7 @@ func NewMessenger(conn *jsonrpc2.Conn) MessageDisplayer {
 }
 
 func (m *Messenger) ShowLog(ctx context.Context, message string) error {
-	log.Println("Send Message:", message)
+	logging.FromContext(ctx).Debug("send message", "message", message)
 	params := &ShowMessageParams{
 		Type:    Log,
 		Message: message,
@@ -34,7 +34,7 @@ func (m *Messenger) ShowLog(ctx context.Context, message string) error {
 }
 
 func (m *Messenger) ShowInfo(ctx context.Context, message string) error {
-	log.Println("Send Message:", message)
+	logging.FromContext(ctx).Debug("send message", "message", message)
 	params := &ShowMessageParams{
 		Type:    Info,
 		Message: message,
@@ -43,7 +43,7 @@ func (m *Messenger) ShowInfo(ctx context.Context, message string) error {
 }
 
 func (m *Messenger) ShowWarning(ctx context.Context, message string) error {
-	log.Println("Send Message:", message)
+	logging.FromContext(ctx).Debug("send message", "message", message)
 	params := &ShowMessageParams{
 		Type:    Warning,
 		Message: message,
@@ -52,7 +52,7 @@ func (m *Messenger) ShowWarning(ctx context.Context, message string) error {
 }
 
 func (m *Messenger) ShowError(ctx context.Context, message string) error {
-	log.Println("Send Message:", message)
+	logging.FromContext(ctx).Debug("send message", "message", message)
 	params := &ShowMessageParams{
 		Type:    Error,
 		Message: message,
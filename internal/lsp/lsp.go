@@ -11,6 +11,7 @@ type InitializeParams struct {
 	ProcessID             int                `json:"processId,omitempty"`
 	RootPath              string             `json:"rootPath,omitempty"`
 	RootURI               string             `json:"rootUri,omitempty"`
+	WorkspaceFolders      []WorkspaceFolder  `json:"workspaceFolders,omitempty"`
 	InitializationOptions InitializeOptions  `json:"initializationOptions,omitempty"`
 	Capabilities          ClientCapabilities `json:"capabilities,omitempty"`
 	Trace                 string             `json:"trace,omitempty"`
@@ -24,6 +25,18 @@ type InitializeOptions struct {
 }
 
 type ClientCapabilities struct {
+	Window *WindowClientCapabilities `json:"window,omitempty"`
+}
+
+type WindowClientCapabilities struct {
+	// ShowMessage is set if the client answers window/showMessageRequest
+	ShowMessage *ShowMessageRequestClientCapabilities `json:"showMessage,omitempty"`
+}
+
+type ShowMessageRequestClientCapabilities struct {
+	MessageActionItem *struct {
+		AdditionalPropertiesSupport bool `json:"additionalPropertiesSupport,omitempty"`
+	} `json:"messageActionItem,omitempty"`
 }
 
 type InitializeResult struct {
@@ -38,6 +51,10 @@ const (
 	TDSKIncremental TextDocumentSyncKind = 2
 )
 
+// CodeContentModified is the error of the requests whose results are of the
+// text changed while they are served.
+const CodeContentModified int64 = -32801
+
 type ServerCapabilities struct {
 	TextDocumentSync                 TextDocumentSyncKind             `json:"textDocumentSync,omitempty"`
 	HoverProvider                    bool                             `json:"hoverProvider,omitempty"`
@@ -61,6 +78,16 @@ type ServerCapabilities struct {
 	FoldingRangeProvider             bool                             `json:"foldingRangeProvider,omitempty"`
 	DeclarationProvider              bool                             `json:"declarationProvider,omitempty"`
 	ExecuteCommandProvider           *ExecuteCommandOptions           `json:"executeCommandProvider,omitempty"`
+	Workspace                        *WorkspaceServerCapabilities     `json:"workspace,omitempty"`
+}
+
+type WorkspaceServerCapabilities struct {
+	WorkspaceFolders *WorkspaceFoldersServerCapabilities `json:"workspaceFolders,omitempty"`
+}
+
+type WorkspaceFoldersServerCapabilities struct {
+	Supported           bool `json:"supported,omitempty"`
+	ChangeNotifications bool `json:"changeNotifications,omitempty"`
 }
 
 type CompletionOptions struct {
@@ -135,22 +162,30 @@ type CompletionList struct {
 }
 
 type CompletionItem struct {
-	Label               string              `json:"label"`
-	Kind                CompletionItemKind  `json:"kind,omitempty"`
-	Tags                []CompletionItemTag `json:"tags,omitempty"`
-	Detail              string              `json:"detail,omitempty"`
-	Documentation       MarkupContent       `json:"documentation,omitempty"` // string | MarkupContent
-	Deprecated          bool                `json:"deprecated,omitempty"`
-	Preselect           bool                `json:"preselect,omitempty"`
-	SortText            string              `json:"sortText,omitempty"`
-	FilterText          string              `json:"filterText,omitempty"`
-	InsertText          string              `json:"insertText,omitempty"`
-	InsertTextFormat    InsertTextFormat    `json:"insertTextFormat,omitempty"`
-	TextEdit            *TextEdit           `json:"textEdit,omitempty"`
-	AdditionalTextEdits []TextEdit          `json:"additionalTextEdits,omitempty"`
-	CommitCharacters    []string            `json:"commitCharacters,omitempty"`
-	Command             *Command            `json:"command,omitempty"`
-	Data                interface{}         `json:"data,omitempty"`
+	Label               string                      `json:"label"`
+	LabelDetails        *CompletionItemLabelDetails `json:"labelDetails,omitempty"`
+	Kind                CompletionItemKind          `json:"kind,omitempty"`
+	Tags                []CompletionItemTag         `json:"tags,omitempty"`
+	Detail              string                      `json:"detail,omitempty"`
+	Documentation       *MarkupContent              `json:"documentation,omitempty"` // string | MarkupContent
+	Deprecated          bool                        `json:"deprecated,omitempty"`
+	Preselect           bool                        `json:"preselect,omitempty"`
+	SortText            string                      `json:"sortText,omitempty"`
+	FilterText          string                      `json:"filterText,omitempty"`
+	InsertText          string                      `json:"insertText,omitempty"`
+	InsertTextFormat    InsertTextFormat            `json:"insertTextFormat,omitempty"`
+	TextEdit            *TextEdit                   `json:"textEdit,omitempty"`
+	AdditionalTextEdits []TextEdit                  `json:"additionalTextEdits,omitempty"`
+	CommitCharacters    []string                    `json:"commitCharacters,omitempty"`
+	Command             *Command                    `json:"command,omitempty"`
+	Data                interface{}                 `json:"data,omitempty"`
+}
+
+// CompletionItemLabelDetails is shown next to the label, the detail right
+// after it and the description less prominently.
+type CompletionItemLabelDetails struct {
+	Detail      string `json:"detail,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 type CompletionItemKind int
@@ -185,6 +220,10 @@ const (
 
 type CompletionItemTag int
 
+const (
+	CompletionItemTagDeprecated CompletionItemTag = 1
+)
+
 type InsertTextFormat int
 
 const (
@@ -253,6 +292,10 @@ type TextDocumentPositionParams struct {
 
 type CodeActionKind string
 
+const (
+	CodeActionQuickFix CodeActionKind = "quickfix"
+)
+
 type Location struct {
 	URI   string `json:"uri"`
 	Range Range  `json:"range"`
@@ -263,15 +306,38 @@ type DiagnosticRelatedInformation struct {
 	Message  string   `json:"message"`
 }
 
+type DiagnosticSeverity int
+
+const (
+	SeverityError       DiagnosticSeverity = 1
+	SeverityWarning     DiagnosticSeverity = 2
+	SeverityInformation DiagnosticSeverity = 3
+	SeverityHint        DiagnosticSeverity = 4
+)
+
+type DiagnosticTag int
+
+const (
+	DiagnosticTagUnnecessary DiagnosticTag = 1
+	DiagnosticTagDeprecated  DiagnosticTag = 2
+)
+
 type Diagnostic struct {
 	Range              Range                          `json:"range"`
-	Severity           int                            `json:"severity,omitempty"`
+	Severity           DiagnosticSeverity             `json:"severity,omitempty"`
 	Code               *string                        `json:"code,omitempty"`
 	Source             *string                        `json:"source,omitempty"`
 	Message            string                         `json:"message"`
+	Tags               []DiagnosticTag                `json:"tags,omitempty"`
 	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
 }
 
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     int          `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
 type WorkDoneProgressParams struct {
 	WorkDoneToken interface{} `json:"workDoneToken"`
 }
@@ -294,6 +360,30 @@ type CodeActionParams struct {
 	Context      CodeActionContext      `json:"context"`
 }
 
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        CodeActionKind `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+	Command     *Command       `json:"command,omitempty"`
+}
+
+// https://microsoft.github.io/language-server-protocol/specifications/specification-3-14/#textDocument_codeLens
+
+type CodeLensParams struct {
+	WorkDoneProgressParams
+	PartialResultParams
+
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type CodeLens struct {
+	Range   Range       `json:"range"`
+	Command *Command    `json:"command,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
 // https://microsoft.github.io/language-server-protocol/specifications/specification-3-14/#workspace_executeCommand
 
 type ExecuteCommandParams struct {
@@ -313,6 +403,22 @@ type DidChangeConfigurationParams struct {
 	} `json:"settings"`
 }
 
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#workspace_workspaceFolders
+
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+type DidChangeWorkspaceFoldersParams struct {
+	Event WorkspaceFoldersChangeEvent `json:"event"`
+}
+
+type WorkspaceFoldersChangeEvent struct {
+	Added   []WorkspaceFolder `json:"added"`
+	Removed []WorkspaceFolder `json:"removed"`
+}
+
 type MarkupKind string
 
 const (
@@ -408,6 +514,26 @@ type ShowMessageParams struct {
 	Message string      `json:"message"`
 }
 
+type LogMessageParams struct {
+	Type    MessageType `json:"type"`
+	Message string      `json:"message"`
+}
+
+const (
+	TraceOff      = "off"
+	TraceMessages = "messages"
+	TraceVerbose  = "verbose"
+)
+
+type SetTraceParams struct {
+	Value string `json:"value"`
+}
+
+type LogTraceParams struct {
+	Message string `json:"message"`
+	Verbose string `json:"verbose,omitempty"`
+}
+
 type ShowMessageRequestParams struct {
 	Type    MessageType         `json:"type"`
 	Message string              `json:"message"`
@@ -507,3 +633,32 @@ type TypeDefinitionParams struct {
 }
 
 type Definition = []Location
+
+// ActiveConnectionParams is sent by the sqls specific sqls/activeConnection
+// notification when the active connection changes.
+type ActiveConnectionParams struct {
+	// Index is the 1-based index of the connection in the config
+	Index    int    `json:"index"`
+	Alias    string `json:"alias,omitempty"`
+	Driver   string `json:"driver"`
+	Database string `json:"database,omitempty"`
+}
+
+// ConnectionStateParams is sent by the sqls specific sqls/connectionState
+// notification when the database connection is lost or restored.
+type ConnectionStateParams struct {
+	// State is either "connected" or "disconnected"
+	State  string `json:"state"`
+	Alias  string `json:"alias,omitempty"`
+	Driver string `json:"driver"`
+	Error  string `json:"error,omitempty"`
+}
+
+// InputBoxParams is sent by the sqls specific sqls/inputBox request to ask
+// the user for a value. The client responds with the entered string, or null
+// when the input is cancelled.
+type InputBoxParams struct {
+	Prompt   string `json:"prompt"`
+	Value    string `json:"value,omitempty"`
+	Password bool   `json:"password,omitempty"`
+}
@@ -0,0 +1,217 @@
+// Package metrics records the latencies of the requests, the queries to the
+// database and the sizes of the cache, and serves them in the text format of
+// Prometheus and by expvar.
+package metrics
+
+import (
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Buckets are the upper bounds in seconds of the buckets of the latencies.
+var Buckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	// counts are of the latencies in each bucket, not cumulative, and the
+	// last one is of the ones over all the buckets
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(seconds float64) {
+	i := sort.SearchFloat64s(Buckets, seconds)
+	h.counts[i]++
+	h.count++
+	h.sum += seconds
+}
+
+// Registry is the metrics of the server.
+type Registry struct {
+	mu        sync.Mutex
+	requests  map[string]*histogram
+	queries   map[string]uint64
+	cacheSize map[string]int
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		requests:  make(map[string]*histogram),
+		queries:   make(map[string]uint64),
+		cacheSize: make(map[string]int),
+	}
+}
+
+// ObserveRequest records the latency of the request of the method.
+func (r *Registry) ObserveRequest(method string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.requests[method]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(Buckets)+1)}
+		r.requests[method] = h
+	}
+	h.observe(d.Seconds())
+}
+
+// CountQuery counts a query to the database of the kind, such as query, exec
+// and explain.
+func (r *Registry) CountQuery(kind string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries[kind]++
+}
+
+// SetCacheSize records the number of the entries of the cache of the kind,
+// such as tables and columns.
+func (r *Registry) SetCacheSize(kind string, n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cacheSize[kind] = n
+}
+
+// Snapshot returns the metrics as the value of expvar.
+func (r *Registry) Snapshot() interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requests := map[string]interface{}{}
+	for method, h := range r.requests {
+		buckets := map[string]uint64{}
+		var cumulative uint64
+		for i, le := range Buckets {
+			cumulative += h.counts[i]
+			buckets[formatFloat(le)] = cumulative
+		}
+		requests[method] = map[string]interface{}{
+			"count":       h.count,
+			"sum_seconds": h.sum,
+			"buckets":     buckets,
+		}
+	}
+	queries := map[string]uint64{}
+	for kind, n := range r.queries {
+		queries[kind] = n
+	}
+	cacheSize := map[string]int{}
+	for kind, n := range r.cacheSize {
+		cacheSize[kind] = n
+	}
+	return map[string]interface{}{
+		"requests":   requests,
+		"db_queries": queries,
+		"cache_size": cacheSize,
+	}
+}
+
+// WritePrometheus writes the metrics in the text exposition format of
+// Prometheus.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := &printer{w: w}
+	p.printf("# HELP sqls_request_duration_seconds The latencies of the LSP requests.\n")
+	p.printf("# TYPE sqls_request_duration_seconds histogram\n")
+	for _, method := range sortedKeys(r.requests) {
+		h := r.requests[method]
+		var cumulative uint64
+		for i, le := range Buckets {
+			cumulative += h.counts[i]
+			p.printf("sqls_request_duration_seconds_bucket{method=%q,le=%q} %d\n", method, formatFloat(le), cumulative)
+		}
+		p.printf("sqls_request_duration_seconds_bucket{method=%q,le=\"+Inf\"} %d\n", method, h.count)
+		p.printf("sqls_request_duration_seconds_sum{method=%q} %s\n", method, formatFloat(h.sum))
+		p.printf("sqls_request_duration_seconds_count{method=%q} %d\n", method, h.count)
+	}
+	p.printf("# HELP sqls_db_queries_total The queries to the database.\n")
+	p.printf("# TYPE sqls_db_queries_total counter\n")
+	for _, kind := range sortedKeys(r.queries) {
+		p.printf("sqls_db_queries_total{kind=%q} %d\n", kind, r.queries[kind])
+	}
+	p.printf("# HELP sqls_cache_size The entries of the cache of the database.\n")
+	p.printf("# TYPE sqls_cache_size gauge\n")
+	for _, kind := range sortedKeys(r.cacheSize) {
+		p.printf("sqls_cache_size{kind=%q} %d\n", kind, r.cacheSize[kind])
+	}
+	return p.err
+}
+
+type printer struct {
+	w   io.Writer
+	err error
+}
+
+func (p *printer) printf(format string, v ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, v...)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var std = NewRegistry()
+
+func init() {
+	expvar.Publish("sqls", expvar.Func(std.Snapshot))
+}
+
+// Default returns the registry the server records to.
+func Default() *Registry {
+	return std
+}
+
+func ObserveRequest(method string, d time.Duration) {
+	std.ObserveRequest(method, d)
+}
+
+func CountQuery(kind string) {
+	std.CountQuery(kind)
+}
+
+func SetCacheSize(kind string, n int) {
+	std.SetCacheSize(kind, n)
+}
+
+// Handler serves the metrics of Prometheus at /metrics and the ones of
+// expvar at /debug/vars.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		std.WritePrometheus(w)
+	})
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// Listen starts serving the metrics on the address, such as localhost:9090.
+func Listen(addr string) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen metrics address, %w", err)
+	}
+	srv := &http.Server{
+		Addr:              ln.Addr().String(),
+		Handler:           Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go srv.Serve(ln)
+	return srv, nil
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveRequest("textDocument/completion", 3*time.Millisecond)
+	r.ObserveRequest("textDocument/completion", 30*time.Millisecond)
+	r.ObserveRequest("textDocument/completion", 20*time.Second)
+	r.ObserveRequest("textDocument/hover", time.Millisecond)
+	r.CountQuery("query")
+	r.CountQuery("query")
+	r.CountQuery("exec")
+	r.SetCacheSize("tables", 12)
+
+	var buf bytes.Buffer
+	if err := r.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`sqls_request_duration_seconds_bucket{method="textDocument/completion",le="0.001"} 0`,
+		`sqls_request_duration_seconds_bucket{method="textDocument/completion",le="0.005"} 1`,
+		`sqls_request_duration_seconds_bucket{method="textDocument/completion",le="0.05"} 2`,
+		`sqls_request_duration_seconds_bucket{method="textDocument/completion",le="10"} 2`,
+		`sqls_request_duration_seconds_bucket{method="textDocument/completion",le="+Inf"} 3`,
+		`sqls_request_duration_seconds_sum{method="textDocument/completion"} 20.033`,
+		`sqls_request_duration_seconds_count{method="textDocument/completion"} 3`,
+		`sqls_request_duration_seconds_bucket{method="textDocument/hover",le="0.001"} 1`,
+		`sqls_db_queries_total{kind="exec"} 1`,
+		`sqls_db_queries_total{kind="query"} 2`,
+		`sqls_cache_size{kind="tables"} 12`,
+		"# TYPE sqls_request_duration_seconds histogram",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q is not in\n%s", want, got)
+		}
+	}
+	if strings.Index(got, `method="textDocument/completion"`) > strings.Index(got, `method="textDocument/hover"`) {
+		t.Errorf("methods are not sorted\n%s", got)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveRequest("textDocument/completion", 3*time.Millisecond)
+	r.CountQuery("explain")
+	r.SetCacheSize("columns", 40)
+
+	b, err := json.Marshal(r.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Requests map[string]struct {
+			Count   uint64            `json:"count"`
+			Buckets map[string]uint64 `json:"buckets"`
+		} `json:"requests"`
+		DBQueries map[string]uint64 `json:"db_queries"`
+		CacheSize map[string]int    `json:"cache_size"`
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	completion := got.Requests["textDocument/completion"]
+	if completion.Count != 1 || completion.Buckets["0.005"] != 1 || completion.Buckets["0.001"] != 0 {
+		t.Errorf("unmatched requests: %s", b)
+	}
+	if got.DBQueries["explain"] != 1 || got.CacheSize["columns"] != 40 {
+		t.Errorf("unmatched snapshot: %s", b)
+	}
+}
+
+func TestListen(t *testing.T) {
+	srv, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	ObserveRequest("initialize", time.Millisecond)
+
+	for path, want := range map[string]string{
+		"/metrics":    `sqls_request_duration_seconds_count{method="initialize"}`,
+		"/debug/vars": `"sqls":`,
+	} {
+		res, err := http.Get("http://" + srv.Addr + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), want) {
+			t.Errorf("%q is not in %s\n%s", want, path, b)
+		}
+	}
+}
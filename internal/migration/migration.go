@@ -0,0 +1,273 @@
+package migration
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+)
+
+// Tool is the migration tool of the names of the migration files.
+type Tool int
+
+const (
+	// ToolGolangMigrate is 000001_create_users.up.sql of golang-migrate
+	ToolGolangMigrate Tool = iota
+	// ToolFlyway is V1.1__create_users.sql and R__views.sql of Flyway
+	ToolFlyway
+	// ToolGoose is 20170506082420_create_users.sql of goose, dbmate and
+	// sql-migrate, whose migrations up and down are in the same file
+	ToolGoose
+)
+
+var (
+	golangMigratePattern    = regexp.MustCompile(`^(\d+)_.*\.up\.sql$`)
+	flywayPattern           = regexp.MustCompile(`^V(\d+(?:[._]\d+)*)__.*\.sql$`)
+	flywayRepeatablePattern = regexp.MustCompile(`^R__.*\.sql$`)
+	goosePattern            = regexp.MustCompile(`^(\d+)_.*\.sql$`)
+	// upMarkerPattern matches the markers of the migrations up of goose,
+	// sql-migrate and dbmate
+	upMarkerPattern = regexp.MustCompile(`(?im)^\s*--\s*(\+goose\s+up|\+migrate\s+up|migrate:up)\b`)
+)
+
+// downMarkers start the migrations down in the files of goose, sql-migrate
+// and dbmate.
+var downMarkers = []string{"-- +goose down", "-- +migrate down", "-- migrate:down"}
+
+// skipDirs are the directories not to find the migrations in, the
+// dependencies and the outputs of the builds copying the migrations.
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+}
+
+// File is a migration file.
+type File struct {
+	Path string
+	Tool Tool
+	// Version is the numbers of the version, nil for the repeatable
+	// migrations of Flyway applied after the others
+	Version []string
+	// up is the text of the migration up
+	up string
+}
+
+// Set is the migration files of a directory in the order they are applied.
+type Set struct {
+	Dir   string
+	Files []*File
+	// schemas are the schemas before the files of the indexes, applied once
+	schemas map[int]*Schema
+}
+
+// Migrations is the sets of the migrations of a workspace, whose DDL makes
+// the schema of the database migrated.
+type Migrations struct {
+	Sets   []*Set
+	driver dialect.DatabaseDriver
+	// all is the schema of all the migrations
+	all *Schema
+}
+
+// UpMigration returns the text of the migration up, before the marker of the
+// migration down.
+func UpMigration(text string) string {
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.ToLower(strings.TrimSpace(line))
+		for _, marker := range downMarkers {
+			if strings.HasPrefix(trimmed, marker) {
+				return text[:offset]
+			}
+		}
+		offset += len(line)
+	}
+	return text
+}
+
+// IsMigrationName reports whether the name of the file is of the migration
+// tools, which the content of the files of goose must confirm.
+func IsMigrationName(name string) bool {
+	return golangMigratePattern.MatchString(name) || flywayPattern.MatchString(name) ||
+		flywayRepeatablePattern.MatchString(name) || (goosePattern.MatchString(name) && !strings.HasSuffix(name, ".down.sql"))
+}
+
+// readFile returns the migration file of the path, or false if it is not of
+// the migration tools.
+func readFile(fpath string) (*File, bool) {
+	name := filepath.Base(fpath)
+	if !IsMigrationName(name) {
+		return nil, false
+	}
+	b, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, false
+	}
+	text := string(b)
+	f := &File{Path: fpath, up: UpMigration(text)}
+	switch {
+	case golangMigratePattern.MatchString(name):
+		f.Tool = ToolGolangMigrate
+		f.Version = []string{golangMigratePattern.FindStringSubmatch(name)[1]}
+	case flywayPattern.MatchString(name):
+		f.Tool = ToolFlyway
+		f.Version = strings.FieldsFunc(flywayPattern.FindStringSubmatch(name)[1], func(r rune) bool {
+			return r == '.' || r == '_'
+		})
+	case flywayRepeatablePattern.MatchString(name):
+		f.Tool = ToolFlyway
+	default:
+		// The numbers of the other files are not the versions without the
+		// markers of the tools
+		if !upMarkerPattern.MatchString(text) {
+			return nil, false
+		}
+		f.Tool = ToolGoose
+		f.Version = []string{goosePattern.FindStringSubmatch(name)[1]}
+	}
+	return f, true
+}
+
+// Find returns the sets of the migration files in the directories under the
+// root path, the files in each in the order of their versions.
+func Find(rootPath string) ([]*Set, error) {
+	sets := map[string]*Set{}
+	err := filepath.WalkDir(rootPath, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if fpath != rootPath && (strings.HasPrefix(d.Name(), ".") || skipDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		f, ok := readFile(fpath)
+		if !ok {
+			return nil
+		}
+		dir := filepath.Dir(fpath)
+		if sets[dir] == nil {
+			sets[dir] = &Set{Dir: dir, schemas: map[int]*Schema{}}
+		}
+		sets[dir].Files = append(sets[dir].Files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := []*Set{}
+	for _, s := range sets {
+		sort.SliceStable(s.Files, func(i, j int) bool {
+			return s.Files[i].before(s.Files[j])
+		})
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Dir < res[j].Dir
+	})
+	return res, nil
+}
+
+// before reports whether the file is applied before the other, the versions
+// in their numbers and the repeatable migrations in their names after them.
+func (f *File) before(other *File) bool {
+	if (f.Version == nil) != (other.Version == nil) {
+		return f.Version != nil
+	}
+	for i := 0; i < len(f.Version) && i < len(other.Version); i++ {
+		if c := compareNumbers(f.Version[i], other.Version[i]); c != 0 {
+			return c < 0
+		}
+	}
+	if len(f.Version) != len(other.Version) {
+		return len(f.Version) < len(other.Version)
+	}
+	return filepath.Base(f.Path) < filepath.Base(other.Path)
+}
+
+// compareNumbers compares the numbers of the digits, which may be larger than
+// the integers such as the timestamps.
+func compareNumbers(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// Load finds the migrations under the root path, or returns nil if there are
+// none. The DDL is read in the dialect of the driver.
+func Load(rootPath string, driver dialect.DatabaseDriver) (*Migrations, error) {
+	sets, err := Find(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(sets) == 0 {
+		return nil, nil
+	}
+	m := &Migrations{Sets: sets, driver: driver, all: NewSchema()}
+	for _, s := range sets {
+		for _, f := range s.Files {
+			m.all.apply(f.up, driver)
+		}
+	}
+	return m, nil
+}
+
+// Schema returns the schema of the migrations applied to the document of the
+// path. The migration of the path has the schema of the migrations before it
+// and the text of the document, and the other documents the schema of all
+// the migrations.
+func (m *Migrations) Schema(fpath, text string) *Schema {
+	for _, s := range m.Sets {
+		for i, f := range s.Files {
+			if f.Path != fpath {
+				continue
+			}
+			schema := s.before(i, m.driver).Clone()
+			schema.apply(UpMigration(text), m.driver)
+			return schema
+		}
+	}
+	return m.all
+}
+
+// IsMigration reports whether the path is of a migration file found.
+func (m *Migrations) IsMigration(fpath string) bool {
+	for _, s := range m.Sets {
+		for _, f := range s.Files {
+			if f.Path == fpath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// before returns the schema of the files before the index.
+func (s *Set) before(index int, driver dialect.DatabaseDriver) *Schema {
+	if schema, ok := s.schemas[index]; ok {
+		return schema
+	}
+	var schema *Schema
+	if index == 0 {
+		schema = NewSchema()
+	} else {
+		schema = s.before(index-1, driver).Clone()
+		schema.apply(s.Files[index-1].up, driver)
+	}
+	s.schemas[index] = schema
+	return schema
+}
+
+// apply applies the DDL of the text, skipping the text that cannot be read
+// not to drop the schema of the other migrations.
+func (s *Schema) apply(text string, driver dialect.DatabaseDriver) {
+	_ = s.Apply(text, driver)
+}
@@ -0,0 +1,166 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestFind(t *testing.T) {
+	sets, err := Find("testdata/workspace")
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	got := map[string][]string{}
+	for _, s := range sets {
+		names := []string{}
+		for _, f := range s.Files {
+			names = append(names, filepath.Base(f.Path))
+		}
+		got[filepath.Base(s.Dir)] = names
+	}
+	want := map[string][]string{
+		"migrations": {
+			"000001_create_users.up.sql",
+			"000002_add_email.up.sql",
+			"000010_create_posts.up.sql",
+		},
+		"flyway": {
+			"V1__init.sql",
+			"V1.1__add_balance.sql",
+			"V2__rename.sql",
+			"R__ledger.sql",
+		},
+		"goose": {
+			"20230102150405_create_tags.sql",
+		},
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched migrations (-want +got):\n%s", d)
+	}
+}
+
+func TestUpMigration(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "goose",
+			input: "-- +goose Up\nCREATE TABLE t (id int);\n-- +goose Down\nDROP TABLE t;\n",
+			want:  "-- +goose Up\nCREATE TABLE t (id int);\n",
+		},
+		{
+			name:  "dbmate",
+			input: "-- migrate:up\nCREATE TABLE t (id int);\n-- migrate:down\nDROP TABLE t;\n",
+			want:  "-- migrate:up\nCREATE TABLE t (id int);\n",
+		},
+		{
+			name:  "sql-migrate",
+			input: "-- +migrate Up\nCREATE TABLE t (id int);\n-- +migrate Down\nDROP TABLE t;\n",
+			want:  "-- +migrate Up\nCREATE TABLE t (id int);\n",
+		},
+		{
+			name:  "no marker",
+			input: "CREATE TABLE t (id int);\n",
+			want:  "CREATE TABLE t (id int);\n",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UpMigration(tt.input); got != tt.want {
+				t.Errorf("unmatched up migration, want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMigrationsSchema(t *testing.T) {
+	m, err := Load("testdata/workspace", dialect.DatabaseDriverPostgreSQL)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	abs := func(fpath string) string {
+		return filepath.Join("testdata/workspace", fpath)
+	}
+	read := func(fpath string) string {
+		b, err := os.ReadFile(abs(fpath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	columns := func(s *Schema, table string) []string {
+		cols, _ := s.Cache().ColumnDescs(table)
+		res := []string{}
+		for _, c := range cols {
+			res = append(res, c.Name)
+		}
+		return res
+	}
+
+	cases := []struct {
+		name    string
+		path    string
+		text    string
+		tables  []string
+		table   string
+		columns []string
+	}{
+		{
+			name:    "first migration",
+			path:    "migrations/000001_create_users.up.sql",
+			text:    read("migrations/000001_create_users.up.sql"),
+			tables:  []string{"users"},
+			table:   "users",
+			columns: []string{"id", "name"},
+		},
+		{
+			name:    "later migration",
+			path:    "migrations/000010_create_posts.up.sql",
+			text:    "SELECT ",
+			tables:  []string{"users"},
+			table:   "users",
+			columns: []string{"id", "name", "email"},
+		},
+		{
+			name:    "migration being edited",
+			path:    "migrations/000002_add_email.up.sql",
+			text:    "ALTER TABLE users ADD COLUMN nickname TEXT;\n",
+			tables:  []string{"users"},
+			table:   "users",
+			columns: []string{"id", "name", "nickname"},
+		},
+		{
+			name:    "renamed by flyway",
+			path:    "flyway/R__ledger.sql",
+			text:    "",
+			tables:  []string{"wallets"},
+			table:   "wallets",
+			columns: []string{"id", "owner", "balance"},
+		},
+		{
+			name:    "other document",
+			path:    "query.sql",
+			text:    "SELECT ",
+			tables:  []string{"ledger", "posts", "tags", "users", "wallets"},
+			table:   "posts",
+			columns: []string{"id", "user_id", "body"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := m.Schema(abs(tt.path), tt.text)
+			if d := cmp.Diff(tt.tables, s.Cache().SortedTables()); d != "" {
+				t.Errorf("unmatched tables (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.columns, columns(s, tt.table)); d != "" {
+				t.Errorf("unmatched columns of %s (-want +got):\n%s", tt.table, d)
+			}
+		})
+	}
+}
@@ -0,0 +1,454 @@
+package migration
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/token"
+)
+
+// defaultSchemas are the schemas of the tables not qualified, whose tables
+// are the ones not qualified of the cache.
+var defaultSchemas = map[string]bool{
+	"PUBLIC": true,
+	"MAIN":   true,
+}
+
+// columnConstraints are the keywords ending the types of the columns.
+var columnConstraints = map[string]bool{
+	"NOT":            true,
+	"NULL":           true,
+	"DEFAULT":        true,
+	"PRIMARY":        true,
+	"UNIQUE":         true,
+	"REFERENCES":     true,
+	"CHECK":          true,
+	"CONSTRAINT":     true,
+	"GENERATED":      true,
+	"COLLATE":        true,
+	"AUTO_INCREMENT": true,
+	"AUTOINCREMENT":  true,
+	"COMMENT":        true,
+	"ON":             true,
+}
+
+// tableConstraints are the keywords starting the constraints of the tables
+// instead of the columns.
+var tableConstraints = map[string]bool{
+	"CONSTRAINT": true,
+	"PRIMARY":    true,
+	"UNIQUE":     true,
+	"FOREIGN":    true,
+	"CHECK":      true,
+	"KEY":        true,
+	"INDEX":      true,
+	"EXCLUDE":    true,
+	"FULLTEXT":   true,
+	"SPATIAL":    true,
+	"LIKE":       true,
+}
+
+// table is a table of the schema files.
+type table struct {
+	schema  string
+	name    string
+	columns []*database.ColumnDesc
+}
+
+// Schema is the tables of the schema files and the migrations in the order of
+// their creation, altered by the statements after them.
+type Schema struct {
+	tables []*table
+}
+
+func NewSchema() *Schema {
+	return &Schema{}
+}
+
+// Clone returns a copy of the schema that the statements applied to do not
+// change the schema.
+func (s *Schema) Clone() *Schema {
+	clone := &Schema{}
+	for _, t := range s.tables {
+		ct := &table{schema: t.schema, name: t.name}
+		for _, col := range t.columns {
+			c := *col
+			ct.columns = append(ct.columns, &c)
+		}
+		clone.tables = append(clone.tables, ct)
+	}
+	return clone
+}
+
+func (s *Schema) find(schemaName, name string) (int, *table) {
+	for i, t := range s.tables {
+		if strings.EqualFold(t.schema, schemaName) && strings.EqualFold(t.name, name) {
+			return i, t
+		}
+	}
+	return -1, nil
+}
+
+// Cache returns the cache of the tables of the schema.
+func (s *Schema) Cache() *database.DBCache {
+	cache := database.NewDBCache("")
+	for _, t := range s.tables {
+		cache.AddTable(t.schema, t.name, t.columns)
+	}
+	return cache
+}
+
+// Apply reads CREATE TABLE, ALTER TABLE and DROP TABLE of the text. The other
+// statements, such as the indexes and the functions, are skipped.
+func (s *Schema) Apply(text string, driver dialect.DatabaseDriver) error {
+	tokens, err := token.NewTokenizer(strings.NewReader(text), dialect.ForDriver(driver)).Tokenize()
+	if err != nil {
+		return err
+	}
+	stmt := []*token.Token{}
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case token.Whitespace, token.Comment, token.MultilineComment:
+			continue
+		case token.Semicolon:
+			s.statement(stmt)
+			stmt = []*token.Token{}
+			continue
+		}
+		stmt = append(stmt, tok)
+	}
+	s.statement(stmt)
+	return nil
+}
+
+// Overlay returns the cache with the tables of the schema replacing the ones
+// of the cache, as the database migrated. The cache is not changed, and may be
+// nil not to be connected to the database.
+func (s *Schema) Overlay(cache *database.DBCache) *database.DBCache {
+	if cache == nil {
+		return s.Cache()
+	}
+	overlaid := cache.Clone()
+	for _, t := range s.tables {
+		overlaid.AddTable(database.Coalesce(t.schema, cache.DefaultSchema()), t.name, t.columns)
+	}
+	return overlaid
+}
+
+func (s *Schema) statement(toks []*token.Token) {
+	if len(toks) < 3 {
+		return
+	}
+	switch word(toks[0]) {
+	case "CREATE":
+		s.createTable(toks)
+	case "ALTER":
+		s.alterTable(toks)
+	case "DROP":
+		s.dropTable(toks)
+	}
+}
+
+func (s *Schema) createTable(toks []*token.Token) {
+	i := 1
+	for i < len(toks) && word(toks[i]) != "TABLE" {
+		switch word(toks[i]) {
+		case "OR", "REPLACE", "TEMP", "TEMPORARY", "UNLOGGED", "GLOBAL", "LOCAL":
+			i++
+		default:
+			return
+		}
+	}
+	i = skipWords(toks, i+1, "IF", "NOT", "EXISTS")
+	schemaName, name, i, ok := tableName(toks, i)
+	if !ok || i >= len(toks) || toks[i].Kind != token.LParen {
+		return
+	}
+	t := &table{schema: schemaName, name: name}
+	primaryKeys := []string{}
+	for _, def := range splitList(toks, i) {
+		if len(def) == 0 {
+			continue
+		}
+		if kw := word(def[0]); tableConstraints[kw] {
+			primaryKeys = append(primaryKeys, primaryKeyColumns(def)...)
+			continue
+		}
+		if col := newColumn(schemaName, name, def); col != nil {
+			t.columns = append(t.columns, col)
+		}
+	}
+	for _, key := range primaryKeys {
+		for _, col := range t.columns {
+			if strings.EqualFold(col.Name, key) {
+				col.Key, col.Null = "PRI", "NO"
+			}
+		}
+	}
+	if n, _ := s.find(schemaName, name); n >= 0 {
+		s.tables[n] = t
+		return
+	}
+	s.tables = append(s.tables, t)
+}
+
+func (s *Schema) alterTable(toks []*token.Token) {
+	if word(toks[1]) != "TABLE" {
+		return
+	}
+	i := skipWords(toks, 2, "IF", "EXISTS")
+	i = skipWords(toks, i, "ONLY")
+	schemaName, name, i, ok := tableName(toks, i)
+	if !ok {
+		return
+	}
+	_, t := s.find(schemaName, name)
+	if t == nil {
+		return
+	}
+	// The actions are separated by the commas
+	actions := [][]*token.Token{{}}
+	depth := 0
+	for _, tok := range toks[i:] {
+		switch tok.Kind {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+		case token.Comma:
+			if depth == 0 {
+				actions = append(actions, []*token.Token{})
+				continue
+			}
+		}
+		actions[len(actions)-1] = append(actions[len(actions)-1], tok)
+	}
+	for _, action := range actions {
+		t.alter(action)
+	}
+}
+
+func (t *table) alter(action []*token.Token) {
+	if len(action) < 2 {
+		return
+	}
+	switch word(action[0]) {
+	case "ADD":
+		i := skipWords(action, 1, "COLUMN")
+		i = skipWords(action, i, "IF", "NOT", "EXISTS")
+		if i >= len(action) || tableConstraints[word(action[i])] {
+			return
+		}
+		if col := newColumn(t.schema, t.name, action[i:]); col != nil {
+			t.columns = append(t.columns, col)
+		}
+	case "DROP":
+		i := skipWords(action, 1, "COLUMN")
+		i = skipWords(action, i, "IF", "EXISTS")
+		if i >= len(action) || tableConstraints[word(action[i])] {
+			return
+		}
+		name := identifier(action[i])
+		for n, col := range t.columns {
+			if strings.EqualFold(col.Name, name) {
+				t.columns = append(t.columns[:n:n], t.columns[n+1:]...)
+				break
+			}
+		}
+	case "RENAME":
+		i := skipWords(action, 1, "COLUMN")
+		if word(action[1]) == "TO" {
+			if len(action) > 2 {
+				t.name = identifier(action[len(action)-1])
+				for _, col := range t.columns {
+					col.Table = t.name
+				}
+			}
+			return
+		}
+		if i+2 >= len(action) || word(action[i+1]) != "TO" {
+			return
+		}
+		for _, col := range t.columns {
+			if strings.EqualFold(col.Name, identifier(action[i])) {
+				col.Name = identifier(action[i+2])
+			}
+		}
+	}
+}
+
+func (s *Schema) dropTable(toks []*token.Token) {
+	if word(toks[1]) != "TABLE" {
+		return
+	}
+	i := skipWords(toks, 2, "IF", "EXISTS")
+	for i < len(toks) {
+		schemaName, name, next, ok := tableName(toks, i)
+		if !ok {
+			return
+		}
+		if n, _ := s.find(schemaName, name); n >= 0 {
+			s.tables = append(s.tables[:n], s.tables[n+1:]...)
+		}
+		if next >= len(toks) || toks[next].Kind != token.Comma {
+			return
+		}
+		i = next + 1
+	}
+}
+
+// newColumn returns the column of the definition, the name followed by the
+// type and the constraints.
+func newColumn(schemaName, tbl string, def []*token.Token) *database.ColumnDesc {
+	if def[0].Kind != token.SQLKeyword {
+		return nil
+	}
+	col := &database.ColumnDesc{
+		ColumnBase: database.ColumnBase{Schema: schemaName, Table: tbl, Name: identifier(def[0])},
+		Null:       "YES",
+	}
+	i := 1
+	typ := []*token.Token{}
+	for ; i < len(def) && !columnConstraints[word(def[i])]; i++ {
+		typ = append(typ, def[i])
+	}
+	col.Type = render(typ)
+	switch strings.ToUpper(col.Type) {
+	case "SERIAL", "BIGSERIAL", "SMALLSERIAL", "SERIAL4", "SERIAL8", "SERIAL2":
+		col.Extra = "auto_increment"
+	}
+	for ; i < len(def); i++ {
+		switch word(def[i]) {
+		case "NOT":
+			if i+1 < len(def) && word(def[i+1]) == "NULL" {
+				col.Null = "NO"
+				i++
+			}
+		case "PRIMARY":
+			col.Key, col.Null = "PRI", "NO"
+		case "DEFAULT":
+			expr := []*token.Token{}
+			for i+1 < len(def) && !columnConstraints[word(def[i+1])] {
+				i++
+				expr = append(expr, def[i])
+			}
+			col.Default = sql.NullString{String: render(expr), Valid: true}
+		case "AUTO_INCREMENT", "AUTOINCREMENT":
+			col.Extra = "auto_increment"
+		case "GENERATED":
+			col.Extra = "generated"
+		}
+	}
+	return col
+}
+
+// primaryKeyColumns returns the columns of PRIMARY KEY of the table
+// constraint.
+func primaryKeyColumns(def []*token.Token) []string {
+	for i := 0; i+2 < len(def); i++ {
+		if word(def[i]) != "PRIMARY" || word(def[i+1]) != "KEY" || def[i+2].Kind != token.LParen {
+			continue
+		}
+		keys := []string{}
+		for _, key := range splitList(def, i+2) {
+			if len(key) > 0 {
+				keys = append(keys, identifier(key[0]))
+			}
+		}
+		return keys
+	}
+	return nil
+}
+
+// tableName returns the table of the name starting at the index, and the
+// index after it.
+func tableName(toks []*token.Token, i int) (string, string, int, bool) {
+	if i >= len(toks) || toks[i].Kind != token.SQLKeyword {
+		return "", "", i, false
+	}
+	name := identifier(toks[i])
+	if i+2 < len(toks) && toks[i+1].Kind == token.Period && toks[i+2].Kind == token.SQLKeyword {
+		schemaName := identifier(toks[i])
+		if defaultSchemas[strings.ToUpper(schemaName)] {
+			schemaName = ""
+		}
+		return schemaName, identifier(toks[i+2]), i + 3, true
+	}
+	return "", name, i + 1, true
+}
+
+// splitList splits the tokens in the parentheses starting at the index by the
+// commas.
+func splitList(toks []*token.Token, lparen int) [][]*token.Token {
+	items := [][]*token.Token{{}}
+	depth := 0
+	for _, tok := range toks[lparen+1:] {
+		switch tok.Kind {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			if depth == 0 {
+				return items
+			}
+			depth--
+		case token.Comma:
+			if depth == 0 {
+				items = append(items, []*token.Token{})
+				continue
+			}
+		}
+		items[len(items)-1] = append(items[len(items)-1], tok)
+	}
+	return items
+}
+
+// skipWords returns the index after the words at the index, or the index if
+// they are not.
+func skipWords(toks []*token.Token, i int, words ...string) int {
+	for n, w := range words {
+		if i+n >= len(toks) || word(toks[i+n]) != w {
+			return i
+		}
+	}
+	return i + len(words)
+}
+
+// word returns the upper case word of the token without the quotes, or empty.
+func word(tok *token.Token) string {
+	w, ok := tok.Value.(*token.SQLWord)
+	if !ok || w.QuoteStyle != 0 {
+		return ""
+	}
+	return strings.ToUpper(w.Value)
+}
+
+func identifier(tok *token.Token) string {
+	if w, ok := tok.Value.(*token.SQLWord); ok {
+		return w.Value
+	}
+	return ""
+}
+
+// render returns the text of the tokens, separating the words by the spaces.
+func render(toks []*token.Token) string {
+	var b strings.Builder
+	for i, tok := range toks {
+		w, isWord := tok.Value.(*token.SQLWord)
+		if i > 0 {
+			_, prevWord := toks[i-1].Value.(*token.SQLWord)
+			if isWord && prevWord {
+				b.WriteByte(' ')
+			}
+		}
+		if isWord {
+			b.WriteString(w.String())
+			continue
+		}
+		if s, ok := tok.Value.(string); ok {
+			b.WriteString(s)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,4 @@
+CREATE TABLE ledger (
+  wallet_id INT,
+  amount NUMERIC(12, 2)
+);
@@ -0,0 +1 @@
+ALTER TABLE wallets RENAME TO accounts;
@@ -0,0 +1 @@
+ALTER TABLE accounts ADD COLUMN balance NUMERIC(12, 2);
@@ -0,0 +1,4 @@
+CREATE TABLE accounts (
+  id INT PRIMARY KEY,
+  owner VARCHAR(100)
+);
@@ -0,0 +1 @@
+ALTER TABLE accounts RENAME TO wallets;
@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE TABLE tags (
+  id INT PRIMARY KEY,
+  label TEXT
+);
+
+-- +goose Down
+DROP TABLE tags;
@@ -0,0 +1,193 @@
+package sqlc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/migration"
+	"gopkg.in/yaml.v2"
+)
+
+// configNames are the names of the config of sqlc at the root of a project.
+var configNames = []string{"sqlc.yaml", "sqlc.yml", "sqlc.json"}
+
+// engineDrivers are the drivers of the engines of sqlc.
+var engineDrivers = map[string]dialect.DatabaseDriver{
+	"postgresql": dialect.DatabaseDriverPostgreSQL,
+	"mysql":      dialect.DatabaseDriverMySQL,
+	"sqlite":     dialect.DatabaseDriverSQLite3,
+}
+
+// Package is a set of the queries of sqlc and the schema they are generated
+// with, the packages of version 1 and the sql of version 2 of the config.
+type Package struct {
+	Engine  string `yaml:"engine"`
+	Queries paths  `yaml:"queries"`
+	Schema  paths  `yaml:"schema"`
+}
+
+// paths are the files or the directories given by a string or a list.
+type paths []string
+
+func (p *paths) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*p = paths{s}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*p = list
+	return nil
+}
+
+type configFile struct {
+	Version  string     `yaml:"version"`
+	Packages []*Package `yaml:"packages"`
+	SQL      []*Package `yaml:"sql"`
+}
+
+// Project is a project of sqlc, whose schema files give the tables of the
+// queries without a database connection.
+type Project struct {
+	Root     string
+	Packages []*Package
+	// cache is the tables of the schema files
+	cache *database.DBCache
+}
+
+// ConfigPath returns the path of the config of sqlc at the root path, or
+// empty if it is not a project of sqlc.
+func ConfigPath(rootPath string) string {
+	for _, name := range configNames {
+		fpath := filepath.Join(rootPath, name)
+		if _, err := os.Stat(fpath); err == nil {
+			return fpath
+		}
+	}
+	return ""
+}
+
+// Load reads the config of sqlc at the root path and the schema files of its
+// packages.
+func Load(rootPath string) (*Project, error) {
+	fpath := ConfigPath(rootPath)
+	if fpath == "" {
+		return nil, fmt.Errorf("cannot find sqlc config in %s", rootPath)
+	}
+	b, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read sqlc config, %w", err)
+	}
+	// The JSON is read as YAML
+	var cf configFile
+	if err := yaml.Unmarshal(b, &cf); err != nil {
+		return nil, fmt.Errorf("cannot parse sqlc config, %w", err)
+	}
+	p := &Project{Root: rootPath, Packages: append(cf.Packages, cf.SQL...)}
+
+	s := migration.NewSchema()
+	for _, pkg := range p.Packages {
+		files, err := schemaFiles(rootPath, pkg.Schema)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read sqlc schema, %w", err)
+			}
+			if err := s.Apply(migration.UpMigration(string(b)), pkg.Driver()); err != nil {
+				return nil, fmt.Errorf("cannot parse sqlc schema %s, %w", file, err)
+			}
+		}
+	}
+	p.cache = s.Cache()
+	return p, nil
+}
+
+// Driver returns the driver of the engine of the package.
+func (pkg *Package) Driver() dialect.DatabaseDriver {
+	return engineDrivers[strings.ToLower(pkg.Engine)]
+}
+
+// Driver returns the driver of the engine of the first package, which is the
+// dialect of the queries without a database connection.
+func (p *Project) Driver() dialect.DatabaseDriver {
+	if len(p.Packages) == 0 {
+		return ""
+	}
+	return p.Packages[0].Driver()
+}
+
+// Cache returns the tables of the schema files.
+func (p *Project) Cache() *database.DBCache {
+	return p.cache
+}
+
+// Merge returns the cache with the tables of the schema files that are not in
+// the database, such as the ones of the migrations not applied yet. The cache
+// is not changed, and may be nil not to be connected to the database.
+func (p *Project) Merge(cache *database.DBCache) *database.DBCache {
+	if cache == nil {
+		return p.cache
+	}
+	merged := cache.Clone()
+	for _, schemaName := range append([]string{""}, p.cache.SortedSchemas()...) {
+		tables, _ := p.cache.SortedTablesByDBName(schemaName)
+		for _, table := range tables {
+			target := database.Coalesce(schemaName, cache.DefaultSchema())
+			if _, ok := merged.ColumnDatabase(target, table); ok {
+				continue
+			}
+			cols, _ := p.cache.ColumnDatabase(schemaName, table)
+			merged.AddTable(target, table, cols)
+		}
+	}
+	return merged
+}
+
+// schemaFiles returns the SQL files of the schema, the files in the
+// directories in the order of their names as the migrations. The migrations
+// down of golang-migrate are not of the schema.
+func schemaFiles(rootPath string, schema []string) ([]string, error) {
+	files := []string{}
+	for _, s := range schema {
+		fpath := s
+		if !filepath.IsAbs(fpath) {
+			fpath = filepath.Join(rootPath, s)
+		}
+		info, err := os.Stat(fpath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read sqlc schema, %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, fpath)
+			continue
+		}
+		entries, err := os.ReadDir(fpath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read sqlc schema, %w", err)
+		}
+		names := []string{}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !strings.EqualFold(filepath.Ext(name), ".sql") || strings.HasSuffix(name, ".down.sql") {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, filepath.Join(fpath, name))
+		}
+	}
+	return files, nil
+}
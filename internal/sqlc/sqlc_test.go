@@ -0,0 +1,73 @@
+package sqlc
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/internal/database"
+)
+
+func TestLoad(t *testing.T) {
+	p, err := Load("testdata/project")
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	if got := p.Driver(); got != dialect.DatabaseDriverPostgreSQL {
+		t.Errorf("unmatched driver %s", got)
+	}
+	cache := p.Cache()
+	if d := cmp.Diff([]string{"authors", "books"}, cache.SortedTables()); d != "" {
+		t.Errorf("unmatched tables (-want +got):\n%s", d)
+	}
+
+	type column struct {
+		Name, Type, Null, Key, Default, Extra string
+	}
+	columns := func(table string) []column {
+		cols, _ := cache.ColumnDescs(table)
+		res := []column{}
+		for _, c := range cols {
+			res = append(res, column{c.Name, c.Type, c.Null, c.Key, c.Default.String, c.Extra})
+		}
+		return res
+	}
+	wantAuthors := []column{
+		{Name: "id", Type: "BIGSERIAL", Null: "NO", Key: "PRI", Extra: "auto_increment"},
+		{Name: "name", Type: "text", Null: "NO"},
+		{Name: "email", Type: "text", Null: "NO"},
+	}
+	if d := cmp.Diff(wantAuthors, columns("authors")); d != "" {
+		t.Errorf("unmatched columns of authors (-want +got):\n%s", d)
+	}
+	wantBooks := []column{
+		{Name: "id", Type: "bigint", Null: "NO", Key: "PRI"},
+		{Name: "author_id", Type: "bigint", Null: "NO"},
+		{Name: "title", Type: "varchar(255)", Null: "NO", Default: "'untitled'"},
+		{Name: "published_at", Type: "timestamp with time zone", Null: "YES"},
+	}
+	if d := cmp.Diff(wantBooks, columns("books")); d != "" {
+		t.Errorf("unmatched columns of books (-want +got):\n%s", d)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	p, err := Load("testdata/project")
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	db := database.NewDBCache("public")
+	db.AddTable("public", "authors", []*database.ColumnDesc{
+		{ColumnBase: database.ColumnBase{Schema: "public", Table: "authors", Name: "id"}},
+	})
+	cache := p.Merge(db)
+	if d := cmp.Diff([]string{"authors", "books"}, cache.SortedTables()); d != "" {
+		t.Errorf("unmatched tables (-want +got):\n%s", d)
+	}
+	if cols, _ := cache.ColumnDescs("authors"); len(cols) != 1 {
+		t.Errorf("the tables of the database must be kept")
+	}
+	if tables := db.SortedTables(); len(tables) != 1 {
+		t.Errorf("the cache merged must not be changed")
+	}
+}
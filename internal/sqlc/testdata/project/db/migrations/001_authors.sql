@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE authors (
+  id   BIGSERIAL PRIMARY KEY,
+  name text      NOT NULL,
+  bio  text
+);
+
+CREATE TABLE public.books (
+  id         bigint NOT NULL,
+  author_id  bigint NOT NULL REFERENCES authors (id),
+  title      varchar(255) NOT NULL DEFAULT 'untitled',
+  published  timestamp with time zone,
+  PRIMARY KEY (id)
+);
+
+-- +goose Down
+DROP TABLE books;
+DROP TABLE authors;
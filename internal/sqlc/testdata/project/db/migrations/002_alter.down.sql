@@ -0,0 +1 @@
+CREATE TABLE ignored (id int);
@@ -0,0 +1,4 @@
+ALTER TABLE authors ADD COLUMN email text NOT NULL, DROP COLUMN bio;
+ALTER TABLE books RENAME COLUMN published TO published_at;
+CREATE TABLE drafts (id int);
+DROP TABLE IF EXISTS drafts;
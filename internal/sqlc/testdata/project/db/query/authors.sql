@@ -0,0 +1,3 @@
+-- name: GetAuthor :one
+SELECT id, name, email FROM authors
+WHERE id = $1 LIMIT 1;
@@ -0,0 +1,250 @@
+package template
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// hostLanguage is the syntax of the comments and the strings of a host
+// language of the embedded SQL.
+type hostLanguage struct {
+	lineComments []string
+	blockComment bool
+	// strings are the delimiters of the strings, the longer first
+	strings []hostString
+	// heredoc is true for <<<ID ... ID of PHP
+	heredoc bool
+}
+
+type hostString struct {
+	quote     string
+	multiline bool
+	escape    bool
+	// code is true for the literals that are not strings, such as the runes
+	// of Go
+	code bool
+}
+
+// hostLanguages are the host languages by the language identifiers of LSP.
+var hostLanguages = map[string]*hostLanguage{
+	"go": {
+		lineComments: []string{"//"},
+		blockComment: true,
+		strings: []hostString{
+			{quote: "`", multiline: true},
+			{quote: `"`, escape: true},
+			{quote: "'", escape: true, code: true},
+		},
+	},
+	"python": {
+		lineComments: []string{"#"},
+		strings: []hostString{
+			{quote: `"""`, multiline: true, escape: true},
+			{quote: "'''", multiline: true, escape: true},
+			{quote: `"`, escape: true},
+			{quote: "'", escape: true},
+		},
+	},
+	"php": {
+		lineComments: []string{"//", "#"},
+		blockComment: true,
+		strings: []hostString{
+			{quote: `"`, multiline: true, escape: true},
+			{quote: "'", multiline: true, escape: true},
+		},
+		heredoc: true,
+	},
+}
+
+// IsHostLanguage reports whether the documents of the language identifier
+// have the SQL in their strings.
+func IsHostLanguage(languageID string) bool {
+	_, ok := hostLanguages[languageID]
+	return ok
+}
+
+var (
+	// embeddedSQLPattern matches the strings starting with the keywords of
+	// the statements
+	embeddedSQLPattern    = regexp.MustCompile(`(?i)^\s*(SELECT|INSERT|UPDATE|DELETE|WITH|CREATE|ALTER|DROP|MERGE|REPLACE|TRUNCATE|EXPLAIN|CALL|UPSERT)\b`)
+	embeddedClausePattern = regexp.MustCompile(`(?i)\b(FROM|INTO|SET|WHERE|VALUES|TABLE|VIEW|INDEX|JOIN)\b`)
+	// embeddedContextPattern matches the code before the strings passed to
+	// the functions or assigned to the variables of the queries
+	embeddedContextPattern = regexp.MustCompile(`(?i)((query|exec|prepare|sql|select)\w*\s*\([^()]*|(sql|query|stmt)\w*\s*:?=\s*)$`)
+	heredocPattern         = regexp.MustCompile(`^<<<[ \t]*(["']?)([A-Za-z_]\w*)(["']?)\r?\n`)
+)
+
+// Embedded returns the SQL in the strings of the document of the host
+// language, such as the queries of Go, Python and PHP applications. The code
+// around the strings starting with the keywords of SQL is the regions of
+// RegionHost replaced by the whitespaces, and the closing quotes by the
+// semicolons ending the statements. The escape sequences are replaced by the
+// whitespaces except for the escaped quotes.
+func Embedded(source, languageID string) *Text {
+	lang, ok := hostLanguages[languageID]
+	if !ok {
+		return Plain(source)
+	}
+	t := &Text{Source: source}
+	var b strings.Builder
+	// host is the start of the code not written yet, whose first byte is the
+	// closing quote replaced by the semicolon if closed
+	host, closed := 0, false
+	writeHost := func(end int) {
+		if end <= host {
+			return
+		}
+		t.Regions = append(t.Regions, &Region{
+			Kind:   RegionHost,
+			Range:  lsp.Range{Start: position(source, host), End: position(source, end)},
+			Source: source[host:end],
+		})
+		code := blank(source[host:end])
+		if closed {
+			code = ";" + code[1:]
+		}
+		b.WriteString(code)
+	}
+	for i := 0; i < len(source); {
+		if end, ok := lang.skipComment(source, i); ok {
+			i = end
+			continue
+		}
+		lit, ok := lang.scanString(source, i)
+		if !ok {
+			_, size := utf8.DecodeRuneInString(source[i:])
+			i += size
+			continue
+		}
+		if lit.code || !isEmbeddedSQL(source[lit.start:lit.end], source[:i]) {
+			i = lit.close
+			continue
+		}
+		writeHost(lit.start)
+		b.WriteString(unescape(source[lit.start:lit.end], lit.escape, lit.quote))
+		host, closed = lit.end, lit.close > lit.end
+		i = lit.close
+	}
+	writeHost(len(source))
+	t.Text = b.String()
+	return t
+}
+
+// isEmbeddedSQL reports whether the string starts with the keywords of SQL and
+// has the clauses of SQL or is passed to the functions or assigned to the
+// variables of the queries by the code before it.
+func isEmbeddedSQL(s, before string) bool {
+	if !embeddedSQLPattern.MatchString(s) {
+		return false
+	}
+	if embeddedClausePattern.MatchString(s) {
+		return true
+	}
+	if n := strings.LastIndexByte(before, '\n'); n >= 0 {
+		before = before[n+1:]
+	}
+	return embeddedContextPattern.MatchString(before)
+}
+
+// literal is a string of the host language. The content is from start to end,
+// and the closing quote from end to close, which is end if it is not closed.
+type literal struct {
+	start, end, close int
+	quote             string
+	escape            bool
+	code              bool
+}
+
+func (lang *hostLanguage) skipComment(source string, i int) (int, bool) {
+	for _, c := range lang.lineComments {
+		if strings.HasPrefix(source[i:], c) {
+			if n := strings.IndexByte(source[i:], '\n'); n >= 0 {
+				return i + n, true
+			}
+			return len(source), true
+		}
+	}
+	if lang.blockComment && strings.HasPrefix(source[i:], "/*") {
+		if n := strings.Index(source[i+2:], "*/"); n >= 0 {
+			return i + 2 + n + 2, true
+		}
+		return len(source), true
+	}
+	return 0, false
+}
+
+func (lang *hostLanguage) scanString(source string, i int) (literal, bool) {
+	if lang.heredoc {
+		if m := heredocPattern.FindStringSubmatch(source[i:]); m != nil && m[1] == m[3] {
+			start := i + len(m[0])
+			// The closing identifier is on its own line, may be indented
+			closing := regexp.MustCompile(`(?m)^[ \t]*` + m[2] + `\b`)
+			if loc := closing.FindStringIndex(source[start:]); loc != nil {
+				return literal{start: start, end: start + loc[0], close: start + loc[1], quote: m[2]}, true
+			}
+			return literal{start: start, end: len(source), close: len(source), quote: m[2]}, true
+		}
+	}
+	for _, s := range lang.strings {
+		if !strings.HasPrefix(source[i:], s.quote) {
+			continue
+		}
+		start := i + len(s.quote)
+		for j := start; j < len(source); j++ {
+			switch {
+			case s.escape && source[j] == '\\':
+				j++
+			case !s.multiline && source[j] == '\n':
+				return literal{start: start, end: j, close: j, quote: s.quote, escape: s.escape, code: s.code}, true
+			case strings.HasPrefix(source[j:], s.quote):
+				return literal{start: start, end: j, close: j + len(s.quote), quote: s.quote, escape: s.escape, code: s.code}, true
+			}
+		}
+		return literal{start: start, end: len(source), close: len(source), quote: s.quote, escape: s.escape, code: s.code}, true
+	}
+	return literal{}, false
+}
+
+// unescape replaces the escape sequences of the string by the whitespaces of
+// the same length, keeping the escaped quotes.
+func unescape(s string, escape bool, quote string) string {
+	if !escape || !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		b.WriteByte(' ')
+		i++
+		if s[i] == quote[0] {
+			b.WriteByte(s[i])
+		} else if s[i] == '\n' {
+			b.WriteByte('\n')
+		} else {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			b.WriteByte(' ')
+			i += size - 1
+		}
+	}
+	return b.String()
+}
+
+// blank returns the whitespaces of the length of the text, keeping the line
+// breaks.
+func blank(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\n' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
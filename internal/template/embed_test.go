@@ -0,0 +1,85 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func TestEmbedded(t *testing.T) {
+	testcases := []struct {
+		name       string
+		languageID string
+		source     string
+		want       string
+	}{
+		{
+			name:       "go",
+			languageID: "go",
+			source:     "// \"SELECT 1\"\nrows, err := db.Query(\"SELECT ID FROM city WHERE Name = \\\"a\\\"\", '\"')\ndb.Exec(`\n\tDELETE FROM city`)\nfmt.Println(\"select the city\")",
+			want:       "             \n                       SELECT ID FROM city WHERE Name =  \"a \";      \n         \n\tDELETE FROM city; \n                              ",
+		},
+		{
+			name:       "python",
+			languageID: "python",
+			source:     "# 'SELECT 1'\ncur.execute(\"\"\"\n    select * from city\"\"\", (1,))\nprint('update: %s' % x)",
+			want:       "            \n               \n    select * from city;         \n                       ",
+		},
+		{
+			name:       "php",
+			languageID: "php",
+			source:     "<?php\n$q = 'SELECT * FROM city';\n$r = <<<SQL\nUPDATE city SET Name = 'a'\nSQL;",
+			want:       "     \n      SELECT * FROM city; \n           \nUPDATE city SET Name = 'a'\n;   ",
+		},
+		{
+			name:       "query being typed",
+			languageID: "go",
+			source:     "db.QueryContext(ctx, \"SELECT \")\nlog.Print(\"SELECT the city\")",
+			want:       "                      SELECT ; \n                            ",
+		},
+		{
+			name:       "not closed",
+			languageID: "go",
+			source:     "db.Query(\"SELECT * FROM \nx := 1",
+			want:       "          SELECT * FROM \n      ",
+		},
+		{
+			name:       "not a host language",
+			languageID: "sql",
+			source:     "SELECT 'a'",
+			want:       "SELECT 'a'",
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Embedded(tt.source, tt.languageID)
+			if d := cmp.Diff(tt.want, got.Text); d != "" {
+				t.Errorf("unmatched text (-want +got):\n%s", d)
+			}
+			if len(strings.Split(got.Text, "\n")) != len(strings.Split(tt.source, "\n")) {
+				t.Errorf("the lines are not kept")
+			}
+		})
+	}
+}
+
+func TestEmbeddedContains(t *testing.T) {
+	tmpl := Embedded("db.Query(\"SELECT  FROM city\")", "go")
+	testcases := []struct {
+		col  int
+		want bool
+	}{
+		{col: 3, want: true},
+		{col: 10, want: false},
+		{col: 16, want: false},
+		{col: 27, want: false},
+		{col: 28, want: true},
+	}
+	for _, tt := range testcases {
+		if got := tmpl.Contains(lsp.Position{Line: 0, Character: tt.col}); got != tt.want {
+			t.Errorf("Contains(%d) = %v, want %v", tt.col, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package template
+
+import (
+	"regexp"
+	"strings"
+)
+
+// goTemplateKeywords are the actions of text/template that are not replaced
+// by the values.
+var goTemplateKeywords = map[string]bool{
+	"if":       true,
+	"else":     true,
+	"end":      true,
+	"range":    true,
+	"with":     true,
+	"define":   true,
+	"template": true,
+	"block":    true,
+	"break":    true,
+	"continue": true,
+}
+
+// GoTemplate replaces the actions of text/template of Go by the placeholders.
+func GoTemplate(source string) *Text {
+	return preprocess(source, scanGoTemplate, false)
+}
+
+func scanGoTemplate(source string, i int) (int, int, RegionKind) {
+	n := strings.Index(source[i:], "{{")
+	if n < 0 {
+		return -1, -1, 0
+	}
+	start := i + n
+	action := strings.TrimLeft(strings.TrimPrefix(source[start+2:], "-"), " \t\r\n")
+	if strings.HasPrefix(action, "/*") {
+		// The comment may have }} up to */
+		if n := strings.Index(source[start:], "*/"); n >= 0 {
+			return start, regionEnd(source, start+n, RegionComment, "}}"), RegionComment
+		}
+		return start, regionEnd(source, start, RegionComment, "}}"), RegionComment
+	}
+	kind := RegionExpression
+	if goTemplateKeywords[leadingWord(action)] {
+		kind = RegionStatement
+	}
+	return start, regionEnd(source, start, kind, "}}"), kind
+}
+
+func leadingWord(s string) string {
+	for i, r := range s {
+		if r < 'a' || r > 'z' {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// printfVerbPattern matches the verbs of fmt.Sprintf. The space flag is not
+// matched not to take the modulo operator for it.
+var printfVerbPattern = regexp.MustCompile(`^%(?:\[\d+\])?[-+#0]*(?:\d+|\*)?(?:\.(?:\d+|\*)?)?(?:\[\d+\])?[vTtbcdoOqxXUeEfFgGsp]`)
+
+// Printf replaces the verbs of fmt.Sprintf such as %s by the placeholders.
+// %% is kept as it is.
+func Printf(source string) *Text {
+	return preprocess(source, scanPrintf, false)
+}
+
+func scanPrintf(source string, i int) (int, int, RegionKind) {
+	for {
+		n := strings.IndexByte(source[i:], '%')
+		if n < 0 {
+			return -1, -1, 0
+		}
+		start := i + n
+		if strings.HasPrefix(source[start:], "%%") {
+			i = start + 2
+			continue
+		}
+		if loc := printfVerbPattern.FindStringIndex(source[start:]); loc != nil {
+			return start, start + loc[1], RegionExpression
+		}
+		i = start + 1
+	}
+}
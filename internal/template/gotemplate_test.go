@@ -0,0 +1,80 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGoTemplate(t *testing.T) {
+	testcases := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "values",
+			source: "SELECT {{ .Columns }} FROM {{.Table}}",
+			want:   "SELECT j0____________ FROM j1________",
+		},
+		{
+			name:   "actions",
+			source: "SELECT * FROM city\n{{- if .ID }}\nWHERE ID = {{ .ID }}\n{{- end }}",
+			want:   "SELECT * FROM city\n/*j0_      */\nWHERE ID = j1_______\n/*j2_   */",
+		},
+		{
+			name:   "comment and strings",
+			source: "SELECT {{/* the }} name */}} {{ printf \"%s}}\" `}}` }}",
+			want:   "SELECT /*j0_              */ j1______________________",
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GoTemplate(tt.source)
+			if d := cmp.Diff(tt.want, got.Text); d != "" {
+				t.Errorf("unmatched text (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestPrintf(t *testing.T) {
+	testcases := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "verbs",
+			source: "SELECT %s FROM city WHERE ID = %d AND Name = '%[2]q' LIMIT %-5v",
+			want:   "SELECT p_ FROM city WHERE ID = p_ AND Name = 'j2___' LIMIT j3__",
+		},
+		{
+			name:   "percents",
+			source: "SELECT ID % 2 FROM city WHERE Name LIKE 'a%%' OR Name LIKE '%'",
+			want:   "SELECT ID % 2 FROM city WHERE Name LIKE 'a%%' OR Name LIKE '%'",
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Printf(tt.source)
+			if d := cmp.Diff(tt.want, got.Text); d != "" {
+				t.Errorf("unmatched text (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestRestorePrintf(t *testing.T) {
+	source := "select %s, id from %s where p_id = %d"
+	tmpl := Printf(source)
+	got, err := tmpl.Restore(strings.ToUpper(tmpl.Text))
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	want := "SELECT %s, ID FROM %s WHERE P_ID = %d"
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched text (-want +got):\n%s", d)
+	}
+}
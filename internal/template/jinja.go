@@ -0,0 +1,52 @@
+package template
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	refPattern    = regexp.MustCompile(`^\{\{-?\s*ref\(\s*(?:(['"])\w+['"]\s*,\s*)?(['"])(\w+)['"]\s*\)\s*-?\}\}$`)
+	sourcePattern = regexp.MustCompile(`^\{\{-?\s*source\(\s*['"](\w+)['"]\s*,\s*['"](\w+)['"]\s*\)\s*-?\}\}$`)
+)
+
+var jinjaDelimiters = map[string]struct {
+	kind RegionKind
+	end  string
+}{
+	"{{": {RegionExpression, "}}"},
+	"{%": {RegionStatement, "%}"},
+	"{#": {RegionComment, "#}"},
+}
+
+// Jinja replaces the regions of the templates of Jinja, such as the models of
+// dbt, by the placeholders. If resolveRefs is true, ref() and source() are
+// replaced by the relations.
+func Jinja(source string, resolveRefs bool) *Text {
+	return preprocess(source, scanJinja, resolveRefs)
+}
+
+func scanJinja(source string, i int) (int, int, RegionKind) {
+	for {
+		n := strings.IndexByte(source[i:], '{')
+		if n < 0 || i+n+1 >= len(source) {
+			return -1, -1, 0
+		}
+		start := i + n
+		if delim, ok := jinjaDelimiters[source[start:start+2]]; ok {
+			return start, regionEnd(source, start, delim.kind, delim.end), delim.kind
+		}
+		i = start + 1
+	}
+}
+
+// refRelation returns the relation of ref() and source(), or empty.
+func refRelation(expr string) string {
+	if m := refPattern.FindStringSubmatch(expr); m != nil {
+		return m[3]
+	}
+	if m := sourcePattern.FindStringSubmatch(expr); m != nil {
+		return m[1] + "." + m[2]
+	}
+	return ""
+}
@@ -0,0 +1,327 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+// RegionKind is the kind of a region of the templates.
+type RegionKind int
+
+const (
+	// RegionExpression is {{ ... }}, replaced by an identifier
+	RegionExpression RegionKind = iota
+	// RegionStatement is {% ... %}, replaced by a comment
+	RegionStatement
+	// RegionComment is {# ... #}, replaced by a comment
+	RegionComment
+	// RegionHost is the code of the host language around the embedded SQL,
+	// replaced by the whitespaces
+	RegionHost
+)
+
+// Region is a part of the source that is not SQL, such as the templates.
+type Region struct {
+	Kind   RegionKind
+	Range  lsp.Range
+	Source string
+	// Placeholder is the text replacing the region, empty if the region is
+	// replaced by the whitespaces and cannot be restored
+	Placeholder string
+	// Relation is the relation of {{ ref('model') }} and
+	// {{ source('schema', 'table') }} replacing the region when the
+	// references are resolved
+	Relation string
+}
+
+// Text is the source of a document of the templates or of a host language,
+// and the text of it parsed as SQL, whose positions are the same as the
+// source.
+type Text struct {
+	Source  string
+	Text    string
+	Regions []*Region
+}
+
+// Plain returns the text of the source that has no templates.
+func Plain(source string) *Text {
+	return &Text{Source: source, Text: source}
+}
+
+// scanner returns the next region of the source from the offset i, or -1 if
+// there are no more regions.
+type scanner func(source string, i int) (start, end int, kind RegionKind)
+
+// preprocess replaces the regions of the source by the placeholders of the
+// same length, keeping the positions of the rest of the source. The
+// expressions are replaced by the identifiers and the statements and the
+// comments by the SQL comments, as are the expressions on their own lines.
+func preprocess(source string, scan scanner, resolveRefs bool) *Text {
+	t := &Text{Source: source}
+	var b strings.Builder
+	i := 0
+	for {
+		start, end, kind := scan(source, i)
+		if start < 0 {
+			break
+		}
+		b.WriteString(source[i:start])
+		r := &Region{
+			Kind:   kind,
+			Range:  lsp.Range{Start: position(source, start), End: position(source, end)},
+			Source: source[start:end],
+		}
+		standalone := isLineStart(source, start) && isLineEnd(source, end)
+		b.WriteString(t.replace(r, len(t.Regions), standalone, resolveRefs))
+		t.Regions = append(t.Regions, r)
+		i = end
+	}
+	b.WriteString(source[i:])
+	t.Text = b.String()
+	return t
+}
+
+// regionEnd returns the end of the region starting at start, skipping the
+// strings of the expressions and the statements. The region not closed ends at
+// the end of the line, as it is being typed.
+func regionEnd(source string, start int, kind RegionKind, end string) int {
+	var quote byte
+	for i := start + 2; i < len(source); i++ {
+		c := source[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			if kind != RegionComment {
+				quote = c
+			}
+		case strings.HasPrefix(source[i:], end):
+			return i + len(end)
+		}
+	}
+	if n := strings.IndexByte(source[start:], '\n'); n >= 0 {
+		return start + n
+	}
+	return len(source)
+}
+
+// replace sets the placeholder of the region and returns the text replacing
+// it.
+func (t *Text) replace(r *Region, index int, standalone, resolveRefs bool) string {
+	lines := strings.Split(r.Source, "\n")
+	blank := make([]string, len(lines))
+	for n, line := range lines {
+		blank[n] = strings.Repeat(" ", utf8.RuneCountInString(line))
+	}
+	marker := "j" + strconv.Itoa(index) + "_"
+	firstLen := utf8.RuneCountInString(lines[0])
+
+	if r.Kind == RegionExpression && !standalone && len(lines) == 1 {
+		if resolveRefs {
+			if relation := refRelation(r.Source); relation != "" && len(relation) <= firstLen {
+				r.Relation = relation
+				return relation + blank[0][len(relation):]
+			}
+		}
+		if len(marker) <= firstLen {
+			r.Placeholder = marker + strings.Repeat("_", firstLen-len(marker))
+			return r.Placeholder
+		}
+		// Too short for the marker, such as %s, and restored by the order
+		if firstLen >= 2 {
+			r.Placeholder = "p" + strings.Repeat("_", firstLen-1)
+			return r.Placeholder
+		}
+	}
+
+	// The comment with the marker on the first line it fits in
+	last := len(lines) - 1
+	if utf8.RuneCountInString(lines[last]) < 2 || (last == 0 && firstLen < 4) {
+		return strings.Join(blank, "\n")
+	}
+	blank[0] = "/*" + blank[0][2:]
+	blank[last] = blank[last][:len(blank[last])-2] + "*/"
+	for n := range blank {
+		from, to := 0, len(blank[n])
+		if n == 0 {
+			from = 2
+		}
+		if n == last {
+			to -= 2
+		}
+		if to-from >= len(marker) {
+			blank[n] = blank[n][:from] + marker + blank[n][from+len(marker):]
+			r.Placeholder = strings.Join(blank, "\n")
+			return r.Placeholder
+		}
+	}
+	return strings.Join(blank, "\n")
+}
+
+// Restore replaces the placeholders in the text made of the text of t, such
+// as the text formatted, by the regions of the source. All the regions must be
+// in the text, in the order of the source.
+func (t *Text) Restore(text string) (string, error) {
+	restored := map[*Region]bool{}
+	text = t.restore(text, t.Regions, restored)
+	for _, r := range t.Regions {
+		if !restored[r] {
+			return "", fmt.Errorf("cannot restore the template at line %d", r.Range.Start.Line+1)
+		}
+	}
+	return text, nil
+}
+
+// RestoreEdits replaces the placeholders in the new texts of the edits of the
+// text of t. The regions in the ranges of the edits must be in their new
+// texts.
+func (t *Text) RestoreEdits(edits []lsp.TextEdit) ([]lsp.TextEdit, error) {
+	if len(t.Regions) == 0 {
+		return edits, nil
+	}
+	restored := map[*Region]bool{}
+	res := make([]lsp.TextEdit, len(edits))
+	for n, edit := range edits {
+		var regions []*Region
+		for _, r := range t.Regions {
+			if overlaps(r.Range, edit.Range) {
+				regions = append(regions, r)
+			}
+		}
+		edit.NewText = t.restore(edit.NewText, regions, restored)
+		res[n] = edit
+	}
+	for _, r := range t.Regions {
+		if restored[r] {
+			continue
+		}
+		for _, edit := range edits {
+			if overlaps(r.Range, edit.Range) {
+				return nil, fmt.Errorf("cannot restore the template at line %d", r.Range.Start.Line+1)
+			}
+		}
+	}
+	return res, nil
+}
+
+// restore replaces the placeholders of the regions in the text in their
+// order, and marks the regions restored.
+func (t *Text) restore(text string, regions []*Region, restored map[*Region]bool) string {
+	var b strings.Builder
+	for _, r := range regions {
+		if r.Placeholder == "" {
+			continue
+		}
+		start, end := r.find(text)
+		if start < 0 {
+			continue
+		}
+		b.WriteString(text[:start])
+		b.WriteString(r.Source)
+		text = text[end:]
+		restored[r] = true
+	}
+	b.WriteString(text)
+	return b.String()
+}
+
+// find returns the first placeholder of the region in the text, whose case
+// and whitespaces may be changed by the formatter, or -1.
+func (r *Region) find(text string) (int, int) {
+	var pattern string
+	if strings.HasPrefix(r.Placeholder, "/*") {
+		marker := strings.Fields(strings.Trim(r.Placeholder, "/*"))[0]
+		pattern = `(?i)/\*\s*` + regexp.QuoteMeta(marker) + `[\s_]*\*/`
+	} else {
+		pattern = `(?i)` + regexp.QuoteMeta(r.Placeholder)
+	}
+	for _, loc := range regexp.MustCompile(pattern).FindAllStringIndex(text, -1) {
+		// The short placeholders are not a part of the other identifiers
+		if r.Placeholder[0] == 'p' && (isWordByte(text, loc[0]-1) || isWordByte(text, loc[1])) {
+			continue
+		}
+		return loc[0], loc[1]
+	}
+	return -1, -1
+}
+
+func isWordByte(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// Hides reports whether the range overlaps a region replaced by a
+// placeholder, whose problems are not of the source.
+func (t *Text) Hides(rng lsp.Range) bool {
+	for _, r := range t.Regions {
+		if r.Relation == "" && overlaps(r.Range, rng) {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether the range overlaps a region, which is not to be
+// edited.
+func (t *Text) Overlaps(rng lsp.Range) bool {
+	for _, r := range t.Regions {
+		if overlaps(r.Range, rng) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the position is in a region, not at its edges.
+func (t *Text) Contains(pos lsp.Position) bool {
+	for _, r := range t.Regions {
+		if before(r.Range.Start, pos) && before(pos, r.Range.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether the range overlaps the region, or is empty at a
+// position of it.
+func overlaps(region, rng lsp.Range) bool {
+	if rng.Start == rng.End {
+		return !before(rng.Start, region.Start) && before(rng.Start, region.End)
+	}
+	return before(region.Start, rng.End) && before(rng.Start, region.End)
+}
+
+func before(a, b lsp.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// position returns the position of the byte offset of the source, in the
+// characters of the lines.
+func position(source string, offset int) lsp.Position {
+	line := strings.Count(source[:offset], "\n")
+	lineStart := strings.LastIndex(source[:offset], "\n") + 1
+	return lsp.Position{Line: line, Character: utf8.RuneCountInString(source[lineStart:offset])}
+}
+
+func isLineStart(source string, offset int) bool {
+	lineStart := strings.LastIndex(source[:offset], "\n") + 1
+	return strings.TrimSpace(source[lineStart:offset]) == ""
+}
+
+func isLineEnd(source string, offset int) bool {
+	rest := source[offset:]
+	if n := strings.IndexByte(rest, '\n'); n >= 0 {
+		rest = rest[:n]
+	}
+	return strings.TrimSpace(rest) == ""
+}
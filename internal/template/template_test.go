@@ -0,0 +1,122 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+func TestJinja(t *testing.T) {
+	testcases := []struct {
+		name        string
+		source      string
+		resolveRefs bool
+		want        string
+	}{
+		{
+			name:   "no templates",
+			source: "SELECT * FROM city WHERE Name = '{'",
+			want:   "SELECT * FROM city WHERE Name = '{'",
+		},
+		{
+			name:   "expressions",
+			source: "SELECT {{ col }} FROM {{ ref('city') }}",
+			want:   "SELECT j0_______ FROM j1_______________",
+		},
+		{
+			name:        "resolved references",
+			source:      "SELECT * FROM {{ ref('city') }} JOIN {{ source('world', 'country') }} USING (ID)",
+			resolveRefs: true,
+			want:        "SELECT * FROM city              JOIN world.country                    USING (ID)",
+		},
+		{
+			name:        "reference of package",
+			source:      `SELECT * FROM {{ ref("world", "city") }}`,
+			resolveRefs: true,
+			want:        `SELECT * FROM city                      `,
+		},
+		{
+			name:   "statements and comments",
+			source: "{{ config(materialized='table') }}\n{# the cities #}\nSELECT *\nFROM city\n{% if is_incremental() %}\nWHERE ID > 10\n{% endif %}",
+			want:   "/*j0_                           */\n/*j1_         */\nSELECT *\nFROM city\n/*j2_                  */\nWHERE ID > 10\n/*j3_    */",
+		},
+		{
+			name:   "multiple lines",
+			source: "SELECT {%\n  set x = '%}'\n%} 1",
+			want:   "SELECT /*\nj0_           \n*/ 1",
+		},
+		{
+			name:   "not closed",
+			source: "SELECT * FROM {{ ref('ci\nWHERE ID = 1",
+			want:   "SELECT * FROM j0________\nWHERE ID = 1",
+		},
+		{
+			name:   "multibyte",
+			source: "SELECT '{{ \"日本\" }}', 1",
+			want:   "SELECT 'j0________', 1",
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Jinja(tt.source, tt.resolveRefs)
+			if d := cmp.Diff(tt.want, got.Text); d != "" {
+				t.Errorf("unmatched text (-want +got):\n%s", d)
+			}
+			if strings.Count(got.Text, "\n") != strings.Count(tt.source, "\n") {
+				t.Errorf("the lines are not kept")
+			}
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	source := "{{ config(materialized='view') }}\nselect id, {{ col }}_name, {%- if x -%} 1 {%- endif -%}\nfrom {{ ref('city') }}"
+	tmpl := Jinja(source, false)
+	formatted := strings.ToUpper(strings.ReplaceAll(tmpl.Text, "\n", "\n  "))
+	got, err := tmpl.Restore(formatted)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	want := "{{ config(materialized='view') }}\n  SELECT ID, {{ col }}_NAME, {%- if x -%} 1 {%- endif -%}\n  FROM {{ ref('city') }}"
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched text (-want +got):\n%s", d)
+	}
+
+	if _, err := tmpl.Restore("SELECT ID"); err == nil {
+		t.Errorf("the templates dropped must not be restored")
+	}
+}
+
+func TestTextRegions(t *testing.T) {
+	tmpl := Jinja("SELECT {{ col }}\nFROM {{ ref('city') }}", true)
+	rng := func(line, from, to int) lsp.Range {
+		return lsp.Range{
+			Start: lsp.Position{Line: line, Character: from},
+			End:   lsp.Position{Line: line, Character: to},
+		}
+	}
+	testcases := []struct {
+		name     string
+		rng      lsp.Range
+		hides    bool
+		overlaps bool
+	}{
+		{name: "before", rng: rng(0, 0, 7)},
+		{name: "placeholder", rng: rng(0, 7, 16), hides: true, overlaps: true},
+		{name: "in placeholder", rng: rng(0, 9, 9), hides: true, overlaps: true},
+		{name: "after placeholder", rng: rng(0, 16, 16)},
+		{name: "relation", rng: rng(1, 5, 9), overlaps: true},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tmpl.Hides(tt.rng); got != tt.hides {
+				t.Errorf("Hides() = %v, want %v", got, tt.hides)
+			}
+			if got := tmpl.Overlaps(tt.rng); got != tt.overlaps {
+				t.Errorf("Overlaps() = %v, want %v", got, tt.overlaps)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// Package transport serves the language server to the clients connecting by
+// TCP or WebSocket instead of stdio, each client in its own session.
+package transport
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+
+	"github.com/sqls-server/sqls/internal/logging"
+)
+
+// ErrServerClosed is returned by the Serve methods after Shutdown.
+var ErrServerClosed = errors.New("transport: server closed")
+
+// errUnauthorized is returned by the streams of the clients which do not
+// send the token of the server.
+var errUnauthorized = errors.New("transport: client is not authorized")
+
+// Session serves a client by the stream of the JSON-RPC objects until the
+// client disconnects or the stream is closed.
+type Session func(ctx context.Context, stream jsonrpc2.ObjectStream) error
+
+// Server accepts the clients and runs a session for each of them
+// concurrently.
+type Server struct {
+	// Token is required from the clients if it is not empty. The WebSocket
+	// clients send it by the Authorization header as a bearer token or the
+	// token query parameter for the browsers which cannot set the headers,
+	// and the TCP clients by the token of the initializationOptions of the
+	// initialize request.
+	Token string
+	// AllowedOrigins are the origins of the web pages allowed to connect by
+	// WebSocket. The browsers send the origins, and the other clients do not,
+	// so that a page cannot talk to the server running on the machine of the
+	// user unless it is allowed.
+	AllowedOrigins []string
+
+	session Session
+
+	mu        sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+	https     map[*http.Server]struct{}
+	streams   map[jsonrpc2.ObjectStream]struct{}
+	sessions  sync.WaitGroup
+}
+
+func NewServer(session Session) *Server {
+	return &Server{
+		session:   session,
+		listeners: make(map[net.Listener]struct{}),
+		https:     make(map[*http.Server]struct{}),
+		streams:   make(map[jsonrpc2.ObjectStream]struct{}),
+	}
+}
+
+// ServeTCP accepts the clients of the listener, which send the messages with
+// the Content-Length headers as on stdio. It returns ErrServerClosed after
+// Shutdown.
+func (s *Server) ServeTCP(ln net.Listener) error {
+	if !s.trackListener(ln) {
+		return ErrServerClosed
+	}
+	defer s.untrackListener(ln)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		var stream jsonrpc2.ObjectStream = jsonrpc2.NewBufferedStream(conn, jsonrpc2.VSCodeObjectCodec{})
+		if s.Token != "" {
+			stream = &tokenStream{ObjectStream: stream, token: s.Token}
+		}
+		s.serveStream(stream, "tcp", conn.RemoteAddr().String())
+	}
+}
+
+// ServeWebSocket accepts the WebSocket clients of the listener on any path,
+// which send a JSON-RPC object in each text message. It returns
+// ErrServerClosed after Shutdown.
+func (s *Server) ServeWebSocket(ln net.Listener) error {
+	srv := &http.Server{Handler: s}
+	if !s.trackHTTP(srv) {
+		return ErrServerClosed
+	}
+	defer s.untrackHTTP(srv)
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ErrServerClosed
+}
+
+// ServeHTTP upgrades the request to WebSocket and runs the session of the
+// client.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.allowedOrigin(r) {
+		slog.Warn("transport: websocket origin is not allowed", "remote", r.RemoteAddr, "origin", r.Header.Get("Origin"))
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="sqls"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	ws, err := s.upgrade(w, r)
+	if err != nil {
+		slog.Debug("transport: cannot upgrade to websocket", "remote", r.RemoteAddr, "err", err)
+		return
+	}
+	s.serveStream(newWebSocketStream(ws), "websocket", r.RemoteAddr)
+}
+
+// IsLoopback reports whether the address to listen on is of the loopback
+// interface only, which the other machines cannot connect to.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !ip.IsLoopback() {
+			return false
+		}
+	}
+	return true
+}
+
+// Shutdown stops accepting the clients and closes the streams of the
+// sessions, and waits for the sessions to end until the context is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	for ln := range s.listeners {
+		ln.Close()
+	}
+	for srv := range s.https {
+		// The connections upgraded are hijacked, which the streams close
+		srv.Close()
+	}
+	for stream := range s.streams {
+		// The WebSocket streams wait for the clients to answer the close
+		// messages, which the sessions wait for
+		go stream.Close()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.sessions.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) serveStream(stream jsonrpc2.ObjectStream, transport, remote string) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		stream.Close()
+		return
+	}
+	s.streams[stream] = struct{}{}
+	s.sessions.Add(1)
+	s.mu.Unlock()
+
+	logger := slog.Default().With("transport", transport, "remote", remote)
+	run := func() {
+		defer s.sessions.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.streams, stream)
+			s.mu.Unlock()
+			stream.Close()
+		}()
+		defer func() {
+			logging.Recovered(logger, recover(), "transport session")
+		}()
+		logger.Info("transport: client connected")
+		if err := s.session(context.Background(), stream); err != nil {
+			logger.Error("transport: session failed", "err", err)
+			return
+		}
+		logger.Info("transport: client disconnected")
+	}
+	if transport == "websocket" {
+		// The handler of net/http is already on its own goroutine
+		run()
+		return
+	}
+	go run()
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) trackListener(ln net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.listeners[ln] = struct{}{}
+	return true
+}
+
+func (s *Server) untrackListener(ln net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.listeners, ln)
+}
+
+func (s *Server) trackHTTP(srv *http.Server) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.https[srv] = struct{}{}
+	return true
+}
+
+func (s *Server) untrackHTTP(srv *http.Server) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.https, srv)
+}
+
+// tokenStream is the stream of a TCP client, whose first message must be the
+// initialize request with the token of the server.
+type tokenStream struct {
+	jsonrpc2.ObjectStream
+	token      string
+	authorized bool
+}
+
+func (s *tokenStream) ReadObject(v interface{}) error {
+	if s.authorized {
+		return s.ObjectStream.ReadObject(v)
+	}
+	var msg json.RawMessage
+	if err := s.ObjectStream.ReadObject(&msg); err != nil {
+		return err
+	}
+	var req struct {
+		ID     *jsonrpc2.ID `json:"id"`
+		Method string       `json:"method"`
+		Params *struct {
+			InitializationOptions *struct {
+				Token string `json:"token"`
+			} `json:"initializationOptions"`
+		} `json:"params"`
+	}
+	token := ""
+	if err := json.Unmarshal(msg, &req); err == nil && req.Method == "initialize" && req.Params != nil && req.Params.InitializationOptions != nil {
+		token = req.Params.InitializationOptions.Token
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		if req.ID != nil {
+			s.ObjectStream.WriteObject(&jsonrpc2.Response{
+				ID:    *req.ID,
+				Error: &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidRequest, Message: "unauthorized, send the token by initializationOptions.token"},
+			})
+		}
+		s.ObjectStream.Close()
+		return errUnauthorized
+	}
+	s.authorized = true
+	return json.Unmarshal(msg, v)
+}
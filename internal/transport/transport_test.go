@@ -0,0 +1,352 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// echoSession answers the params of the echo requests.
+func echoSession(ctx context.Context, stream jsonrpc2.ObjectStream) error {
+	h := jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if req.Method != "echo" {
+			return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound}
+		}
+		var s string
+		if err := json.Unmarshal(*req.Params, &s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	})
+	<-jsonrpc2.NewConn(ctx, stream, h).DisconnectNotify()
+	return nil
+}
+
+func startServer(t *testing.T, srv *Server, serve func(net.Listener) error) (string, chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- serve(ln)
+	}()
+	return ln.Addr().String(), errc
+}
+
+func TestServeTCP(t *testing.T) {
+	srv := NewServer(echoSession)
+	addr, errc := startServer(t, srv, srv.ServeTCP)
+
+	// The clients have their own sessions
+	conns := []*jsonrpc2.Conn{}
+	for i := 0; i < 3; i++ {
+		nc, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(nc, jsonrpc2.VSCodeObjectCodec{}), nil)
+		conns = append(conns, conn)
+	}
+	for i, conn := range conns {
+		var got string
+		want := fmt.Sprintf("client %d", i)
+		if err := conn.Call(context.Background(), "echo", want, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("unmatched result: want %q, got %q", want, got)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, conn := range conns {
+		select {
+		case <-conn.DisconnectNotify():
+		case <-time.After(5 * time.Second):
+			t.Fatal("client is not disconnected")
+		}
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Error("expected error of connecting after shutdown")
+	}
+}
+
+func TestServeTCPToken(t *testing.T) {
+	srv := NewServer(func(ctx context.Context, stream jsonrpc2.ObjectStream) error {
+		h := jsonrpc2.HandlerWithError(func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+			return req.Method, nil
+		})
+		<-jsonrpc2.NewConn(ctx, stream, h).DisconnectNotify()
+		return nil
+	})
+	srv.Token = "secret"
+	addr, _ := startServer(t, srv, srv.ServeTCP)
+	defer srv.Shutdown(context.Background())
+
+	type initializeParams struct {
+		InitializationOptions map[string]string `json:"initializationOptions,omitempty"`
+	}
+	tests := []struct {
+		name    string
+		method  string
+		params  interface{}
+		wantErr bool
+	}{
+		{
+			name:    "no token",
+			method:  "initialize",
+			params:  initializeParams{},
+			wantErr: true,
+		},
+		{
+			name:    "wrong token",
+			method:  "initialize",
+			params:  initializeParams{InitializationOptions: map[string]string{"token": "wrong"}},
+			wantErr: true,
+		},
+		{
+			name:    "not initialize",
+			method:  "workspace/executeCommand",
+			params:  initializeParams{InitializationOptions: map[string]string{"token": "secret"}},
+			wantErr: true,
+		},
+		{
+			name:   "token",
+			method: "initialize",
+			params: initializeParams{InitializationOptions: map[string]string{"token": "secret"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nc, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(nc, jsonrpc2.VSCodeObjectCodec{}), nil)
+			defer conn.Close()
+			var got string
+			err = conn.Call(context.Background(), tt.method, tt.params, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Call() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				select {
+				case <-conn.DisconnectNotify():
+				case <-time.After(5 * time.Second):
+					t.Fatal("client is not disconnected")
+				}
+				return
+			}
+			// The messages after the initialize request are served
+			if err := conn.Call(context.Background(), "shutdown", nil, &got); err != nil || got != "shutdown" {
+				t.Errorf("unexpected result %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func dialWebSocket(addr, path string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return websocket.Dial(ctx, "ws://"+addr+path, &websocket.DialOptions{HTTPHeader: header})
+}
+
+// writeFragments writes the payload in a message of the fragments of the size.
+func writeFragments(c *websocket.Conn, payload []byte, size int) error {
+	w, err := c.Writer(context.Background(), websocket.MessageText)
+	if err != nil {
+		return err
+	}
+	for len(payload) > 0 {
+		n := len(payload)
+		if n > size {
+			n = size
+		}
+		if _, err := w.Write(payload[:n]); err != nil {
+			return err
+		}
+		payload = payload[n:]
+	}
+	return w.Close()
+}
+
+func TestServeWebSocket(t *testing.T) {
+	srv := NewServer(echoSession)
+	srv.Token = "secret"
+	addr, errc := startServer(t, srv, srv.ServeWebSocket)
+
+	t.Run("unauthorized", func(t *testing.T) {
+		for _, header := range []http.Header{nil, {"Authorization": {"Bearer wrong"}}} {
+			c, res, err := dialWebSocket(addr, "/", header)
+			if err == nil {
+				c.CloseNow()
+				t.Fatal("expected error of unauthorized")
+			}
+			if res == nil || res.StatusCode != http.StatusUnauthorized {
+				t.Errorf("expected unauthorized, got %v", err)
+			}
+		}
+	})
+
+	t.Run("origin", func(t *testing.T) {
+		srv.AllowedOrigins = []string{"https://editor.example.com/"}
+		defer func() { srv.AllowedOrigins = nil }()
+		for origin, want := range map[string]bool{
+			"https://editor.example.com": true,
+			"https://evil.example.com":   false,
+			"null":                       false,
+		} {
+			c, res, err := dialWebSocket(addr, "/", http.Header{"Authorization": {"Bearer secret"}, "Origin": {origin}})
+			if err == nil {
+				c.CloseNow()
+			}
+			if got := err == nil; got != want {
+				t.Errorf("origin %s allowed %v, want %v, %v", origin, got, want, err)
+			}
+			if !want && (res == nil || res.StatusCode != http.StatusForbidden) {
+				t.Errorf("expected forbidden, got %v", err)
+			}
+		}
+	})
+
+	t.Run("not upgrade", func(t *testing.T) {
+		res, err := http.Get("http://" + addr + "/?token=secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusUpgradeRequired {
+			t.Errorf("expected upgrade required, got %d", res.StatusCode)
+		}
+	})
+
+	cases := []struct {
+		name   string
+		path   string
+		header http.Header
+		params string
+		size   int
+	}{
+		{
+			name:   "bearer token",
+			path:   "/",
+			header: http.Header{"Authorization": {"Bearer secret"}},
+			params: "hello",
+			size:   1024,
+		},
+		{
+			name:   "query token and fragments",
+			path:   "/lsp?token=secret",
+			params: strings.Repeat("select ", 20000),
+			size:   1000,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, err := dialWebSocket(addr, tt.path, tt.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.CloseNow()
+			c.SetReadLimit(-1)
+
+			type message struct {
+				typ     websocket.MessageType
+				payload []byte
+				err     error
+			}
+			read := make(chan message, 1)
+			go func() {
+				typ, payload, err := c.Read(context.Background())
+				read <- message{typ, payload, err}
+			}()
+			// The pong is read while the response is waited for
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := c.Ping(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			params, _ := json.Marshal(tt.params)
+			req := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"echo","params":%s}`, params)
+			if err := writeFragments(c, []byte(req), tt.size); err != nil {
+				t.Fatal(err)
+			}
+			msg := <-read
+			if msg.err != nil {
+				t.Fatal(msg.err)
+			}
+			var got struct {
+				ID     int    `json:"id"`
+				Result string `json:"result"`
+			}
+			if err := json.Unmarshal(msg.payload, &got); err != nil {
+				t.Fatal(err)
+			}
+			if msg.typ != websocket.MessageText || got.ID != 1 || got.Result != tt.params {
+				t.Errorf("unmatched response: %v %s", msg.typ, msg.payload)
+			}
+		})
+	}
+
+	// The clients are closed as the server is going away
+	c, _, err := dialWebSocket(addr, "/", http.Header{"Authorization": {"Bearer secret"}})
+	if err != nil {
+		t.Fatalf("cannot upgrade, %v", err)
+	}
+	defer c.CloseNow()
+	closed := make(chan error, 1)
+	go func() {
+		_, _, err := c.Read(context.Background())
+		closed <- err
+	}()
+	// Wait for the session of the client to start
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := <-closed; websocket.CloseStatus(err) != websocket.StatusGoingAway {
+		t.Errorf("expected close of going away, got %v", err)
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"localhost:2088": true,
+		"127.0.0.1:2088": true,
+		"[::1]:2088":     true,
+		":2088":          false,
+		"0.0.0.0:2088":   false,
+		"10.0.0.1:2088":  false,
+		"localhost":      false,
+	} {
+		if got := IsLoopback(addr); got != want {
+			t.Errorf("IsLoopback(%q) = %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/coder/websocket"
+)
+
+// maxMessageSize is the limit of the size of a message from the clients,
+// larger than the documents that the editors send.
+const maxMessageSize = 64 << 20
+
+// authorized reports whether the request has the token of the server.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.Token == "" {
+		return true
+	}
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, value, _ := strings.Cut(auth, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(value)
+		}
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+// allowedOrigin reports whether the request has no origin, as of the clients
+// other than the browsers, or one of AllowedOrigins.
+func (s *Server) allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range s.AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// upgrade replies to the opening handshake of the request, whose origin is
+// already allowed.
+func (s *Server) upgrade(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
+	var hosts []string
+	for _, allowed := range s.AllowedOrigins {
+		if u, err := url.Parse(allowed); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	ws, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: hosts})
+	if err != nil {
+		return nil, err
+	}
+	ws.SetReadLimit(maxMessageSize)
+	return ws, nil
+}
+
+// websocketStream is the stream of the JSON-RPC objects in the text messages
+// of a WebSocket connection.
+type websocketStream struct {
+	conn *websocket.Conn
+}
+
+func newWebSocketStream(conn *websocket.Conn) *websocketStream {
+	return &websocketStream{conn: conn}
+}
+
+func (s *websocketStream) WriteObject(obj interface{}) error {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return s.conn.Write(context.Background(), websocket.MessageText, b)
+}
+
+func (s *websocketStream) ReadObject(v interface{}) error {
+	_, msg, err := s.conn.Read(context.Background())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(msg, v)
+}
+
+// Close closes the connection as the server is going away.
+func (s *websocketStream) Close() error {
+	return s.conn.Close(websocket.StatusGoingAway, "")
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/handler"
+	"github.com/sqls-server/sqls/internal/lsp"
+)
+
+const (
+	lintFormatHuman  = "human"
+	lintFormatJSON   = "json"
+	lintFormatGitHub = "github"
+)
+
+// lintFinding is a problem of a file, whose lines and columns start from 1.
+type lintFinding struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Severity  string `json:"severity"`
+	Code      string `json:"code,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Message   string `json:"message"`
+}
+
+// lint prints the problems of the files, or stdin by -, found by the rules of
+// the diagnostics. The columns of the tables are checked with the tables of a
+// schema dump, or of the database connected.
+func lint(c *cli.Context) error {
+	outFormat := c.String("format")
+	switch outFormat {
+	case lintFormatHuman, lintFormatJSON, lintFormatGitHub:
+	default:
+		return fmt.Errorf("invalid format %q, human, json or github", outFormat)
+	}
+	files := c.Args().Slice()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	server := handler.NewServer()
+	defer server.Stop()
+	if err := loadConfig(server, c.String("config")); err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := server.OpenWorkspace(wd); err != nil {
+		return err
+	}
+	var cache *database.DBCache
+	if schema := c.String("schema"); schema != "" {
+		cache, err = server.SchemaDumpCache(schema)
+		if err != nil {
+			return err
+		}
+	} else if c.Bool("connect") {
+		if err := server.ConnectDatabase(context.Background()); err != nil {
+			return fmt.Errorf("cannot connect database, %w", err)
+		}
+	}
+
+	findings := []*lintFinding{}
+	for _, fpath := range files {
+		text, err := readInput(fpath)
+		if err != nil {
+			return err
+		}
+		diagnostics, err := server.LintText(fpath, text, cache)
+		if err != nil {
+			return fmt.Errorf("cannot lint %s, %w", fpath, err)
+		}
+		for _, d := range diagnostics {
+			findings = append(findings, newLintFinding(inputName(fpath), d))
+		}
+	}
+	if err := printLintFindings(c.App.Writer, outFormat, findings); err != nil {
+		return err
+	}
+	for _, f := range findings {
+		if f.Severity == "error" || f.Severity == "warning" {
+			return cli.Exit("", 1)
+		}
+	}
+	return nil
+}
+
+func newLintFinding(file string, d lsp.Diagnostic) *lintFinding {
+	f := &lintFinding{
+		File:      file,
+		Line:      d.Range.Start.Line + 1,
+		Column:    d.Range.Start.Character + 1,
+		EndLine:   d.Range.End.Line + 1,
+		EndColumn: d.Range.End.Character + 1,
+		Severity:  lintSeverity(d.Severity),
+		Message:   d.Message,
+	}
+	if d.Code != nil {
+		f.Code = *d.Code
+	}
+	if d.Source != nil {
+		f.Source = *d.Source
+	}
+	return f
+}
+
+func lintSeverity(severity lsp.DiagnosticSeverity) string {
+	switch severity {
+	case lsp.SeverityError:
+		return "error"
+	case lsp.SeverityInformation:
+		return "info"
+	case lsp.SeverityHint:
+		return "hint"
+	}
+	return "warning"
+}
+
+func printLintFindings(w io.Writer, outFormat string, findings []*lintFinding) error {
+	switch outFormat {
+	case lintFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	case lintFormatGitHub:
+		for _, f := range findings {
+			// The annotations of GitHub Actions are errors, warnings and notices
+			level := f.Severity
+			if level == "info" || level == "hint" {
+				level = "notice"
+			}
+			props := fmt.Sprintf("file=%s,line=%d,col=%d,endLine=%d,endColumn=%d", githubProperty(f.File), f.Line, f.Column, f.EndLine, f.EndColumn)
+			if f.Code != "" {
+				props += ",title=" + githubProperty(f.Code)
+			}
+			if _, err := fmt.Fprintf(w, "::%s %s::%s\n", level, props, githubMessage(f.Message)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, f := range findings {
+		code := ""
+		if f.Code != "" {
+			code = " [" + f.Code + "]"
+		}
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s: %s%s\n", f.File, f.Line, f.Column, f.Severity, f.Message, code); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var githubMessageReplacer = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+var githubPropertyReplacer = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+func githubMessage(s string) string {
+	return githubMessageReplacer.Replace(s)
+}
+
+func githubProperty(s string) string {
+	return githubPropertyReplacer.Replace(s)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/urfave/cli/v2"
+
+	"github.com/sqls-server/sqls/internal/database"
+	"github.com/sqls-server/sqls/internal/handler"
+	"github.com/sqls-server/sqls/internal/history"
+	"github.com/sqls-server/sqls/internal/transport"
+)
+
+// errExitWithoutShutdown is returned by the sessions of the clients which
+// exit without shutdown, which the exit code of the process reports.
+var errExitWithoutShutdown = errors.New("client exited without shutdown")
+
+// runSession serves a client by the language server of its own, with its
+// own documents and connection chosen, until the client disconnects. The
+// connections of the same settings are shared by the registry.
+func runSession(ctx context.Context, stream jsonrpc2.ObjectStream, configFile string, store *history.Store, registry *database.Registry, connOpt []jsonrpc2.ConnOpt) error {
+	server := handler.NewServer()
+	server.History = store
+	server.Registry = registry
+	stop := func() {
+		if err := server.Stop(); err != nil {
+			slog.Error("stop server", "err", err)
+		}
+	}
+	if err := loadConfig(server, configFile); err != nil {
+		stop()
+		stream.Close()
+		return err
+	}
+	conn := jsonrpc2.NewConn(ctx, stream, server.Handler(), connOpt...)
+	select {
+	case <-conn.DisconnectNotify():
+	case <-ctx.Done():
+		conn.Close()
+	case <-server.Exited():
+		// The client is not expected to send any more
+		conn.Close()
+	}
+	// The requests read are served before the exit is checked
+	stop()
+	select {
+	case <-server.Exited():
+		if !server.ShutdownRequested() {
+			return errExitWithoutShutdown
+		}
+	default:
+	}
+	return nil
+}
+
+// listen serves the clients connecting by TCP and WebSocket until SIGINT or
+// SIGTERM, and then waits for their sessions to end.
+func listen(c *cli.Context, session transport.Session) error {
+	srv := transport.NewServer(session)
+	srv.Token = c.String("token")
+	srv.AllowedOrigins = c.StringSlice("websocket-allowed-origin")
+
+	errc := make(chan error, 2)
+	serve := func(addr, name string, fn func(net.Listener) error) error {
+		if srv.Token == "" {
+			// The clients may run the queries and the commands of the
+			// connections
+			if !transport.IsLoopback(addr) {
+				return fmt.Errorf("cannot listen on %s for the %s clients without --token", addr, name)
+			}
+			slog.Warn("sqls: the clients are not authenticated without --token", "transport", name)
+		}
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		slog.Info("sqls: listening", "transport", name, "addr", ln.Addr().String())
+		go func() {
+			errc <- fn(ln)
+		}()
+		return nil
+	}
+	if addr := c.String("tcp"); addr != "" {
+		if err := serve(addr, "tcp", srv.ServeTCP); err != nil {
+			return err
+		}
+	}
+	if addr := c.String("websocket"); addr != "" {
+		if err := serve(addr, "websocket", srv.ServeWebSocket); err != nil {
+			srv.Shutdown(context.Background())
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("sqls: shutting down")
+	case err = <-errc:
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.Duration("shutdown-timeout"))
+	defer cancel()
+	if serr := srv.Shutdown(shutdownCtx); serr != nil {
+		slog.Warn("sqls: sessions did not end", "err", serr)
+	}
+	if err != nil && !errors.Is(err, transport.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
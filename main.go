@@ -4,18 +4,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/sourcegraph/jsonrpc2"
 	"github.com/urfave/cli/v2"
 
 	"github.com/sqls-server/sqls/internal/config"
+	"github.com/sqls-server/sqls/internal/database"
 	"github.com/sqls-server/sqls/internal/handler"
+	"github.com/sqls-server/sqls/internal/history"
+	"github.com/sqls-server/sqls/internal/logging"
+	"github.com/sqls-server/sqls/internal/metrics"
 )
 
 const name = "sqls"
@@ -43,11 +49,56 @@ func realMain() error {
 				Aliases: []string{"l"},
 				Usage:   "Also log to this file. (in addition to stderr)",
 			},
+			&cli.StringFlag{
+				Name:  "log-level",
+				Value: "info",
+				Usage: "Log level: debug, info, warn or error. The requests are logged with their latencies at debug.",
+			},
+			&cli.BoolFlag{
+				Name:  "log-json",
+				Usage: "Log as JSON objects.",
+			},
+			&cli.IntFlag{
+				Name:  "log-max-size",
+				Usage: "Rotate the log file when it reaches this size in megabytes.",
+			},
+			&cli.IntFlag{
+				Name:  "log-max-backups",
+				Value: 3,
+				Usage: "Number of the rotated log files to keep.",
+			},
+			&cli.StringFlag{
+				Name:  "metrics-addr",
+				Usage: "Serve the metrics of Prometheus at /metrics and expvar at /debug/vars on this address, e.g. localhost:9090.",
+			},
 			&cli.StringFlag{
 				Name:    "config",
 				Aliases: []string{"c"},
 				Usage:   "Specifies an alternative per-user configuration file. If a configuration file is given on the command line, the workspace option (initializationOptions) will be ignored.",
 			},
+			&cli.StringFlag{
+				Name:  "tcp",
+				Usage: "Listen on this TCP address, e.g. localhost:2087, instead of stdio. The messages have the Content-Length headers as on stdio.",
+			},
+			&cli.StringFlag{
+				Name:  "websocket",
+				Usage: "Listen on this address for the WebSocket clients, e.g. localhost:2088, instead of stdio. Each text message is a JSON-RPC message.",
+			},
+			&cli.StringFlag{
+				Name:    "token",
+				Aliases: []string{"websocket-token"},
+				EnvVars: []string{"SQLS_TOKEN", "SQLS_WEBSOCKET_TOKEN"},
+				Usage:   "Require this token from the clients, by initializationOptions.token of the initialize request on TCP, and by the Authorization header as a bearer token or the token query parameter on WebSocket. It is required to listen on an address other than the loopback.",
+			},
+			&cli.StringSliceFlag{
+				Name:  "websocket-allowed-origin",
+				Usage: "Allow the web pages of this origin, e.g. https://editor.example.com, to connect by WebSocket. The browsers of the other origins are rejected.",
+			},
+			&cli.DurationFlag{
+				Name:  "shutdown-timeout",
+				Value: 10 * time.Second,
+				Usage: "Time to wait for the sessions of the clients to end on SIGINT or SIGTERM when listening.",
+			},
 			&cli.BoolFlag{
 				Name:    "trace",
 				Aliases: []string{"t"},
@@ -66,6 +117,121 @@ func realMain() error {
 					}
 					return openEditor(editorEnv, config.YamlConfigPath)
 				},
+				Subcommands: cli.Commands{
+					{
+						Name:      "validate",
+						Usage:     "check the config file, the user config by default, and connect to its connections",
+						ArgsUsage: "[file]",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "skip-connections",
+								Usage: "Check the file without connecting to the connections.",
+							},
+							&cli.DurationFlag{
+								Name:  "timeout",
+								Value: 10 * time.Second,
+								Usage: "Timeout of connecting to each connection.",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return validateConfig(c)
+						},
+					},
+					{
+						Name:  "list",
+						Usage: "print the config used in the current directory, with the secrets masked",
+						Action: func(c *cli.Context) error {
+							return listConfig(c)
+						},
+					},
+				},
+			},
+			{
+				Name:  "schema",
+				Usage: "schema of the database",
+				Subcommands: cli.Commands{
+					{
+						Name:  "dump",
+						Usage: "write the CREATE TABLE of the tables of the database, for lint --schema and diffSchemas",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "connection",
+								Aliases: []string{"c"},
+								Usage:   "Alias or 1-based index of the connection of the config, the first one by default.",
+							},
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "Write the schema dump to this file instead of stdout.",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return dumpSchema(c)
+						},
+					},
+				},
+			},
+			{
+				Name:      "format",
+				Usage:     "format SQL files, or stdin by -",
+				ArgsUsage: "[files|-]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "write",
+						Aliases: []string{"w"},
+						Usage:   "Write the formatted text to the files instead of stdout.",
+					},
+					&cli.BoolFlag{
+						Name:  "check",
+						Usage: "Print the files not formatted, and exit with 1 if there are any.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return format(c)
+				},
+			},
+			{
+				Name:      "lint",
+				Usage:     "lint SQL files, or stdin by -",
+				ArgsUsage: "[files|-]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Value:   lintFormatHuman,
+						Usage:   "Output format: human, json or github (the annotations of GitHub Actions).",
+					},
+					&cli.StringFlag{
+						Name:  "schema",
+						Usage: "Check the tables and the columns with the CREATE TABLE of this schema dump.",
+					},
+					&cli.BoolFlag{
+						Name:  "connect",
+						Usage: "Check the tables and the columns with the database of the config.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return lint(c)
+				},
+			},
+			{
+				Name:      "exec",
+				Usage:     "run SQL statements, or read them in the REPL",
+				ArgsUsage: "[query]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "connection",
+						Aliases: []string{"c"},
+						Usage:   "Alias or 1-based index of the connection of the config, the first one by default.",
+					},
+					&cli.BoolFlag{
+						Name:  "vertical",
+						Usage: "Print the rows vertically.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return execute(c)
+				},
 			},
 		},
 		Action: func(c *cli.Context) error {
@@ -96,65 +262,80 @@ func serve(c *cli.Context) error {
 	configFile := c.String("config")
 	trace := c.Bool("trace")
 
-	// Initialize log writer
-	var logWriter io.Writer
-	if logfile != "" {
-		f, err := os.OpenFile(logfile, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0660)
-		if err != nil {
-			log.Fatal(err)
-		}
-		defer f.Close()
-		logWriter = io.MultiWriter(os.Stderr, f)
-	} else {
-		logWriter = io.MultiWriter(os.Stderr)
+	// Initialize logger
+	logCloser, err := logging.Setup(logging.Options{
+		Level:      c.String("log-level"),
+		JSON:       c.Bool("log-json"),
+		File:       logfile,
+		MaxSize:    c.Int("log-max-size"),
+		MaxBackups: c.Int("log-max-backups"),
+	}, os.Stderr)
+	if err != nil {
+		return err
 	}
-	log.SetOutput(logWriter)
-	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
-
-	// Initialize language server
-	server := handler.NewServer()
-	defer func() {
-		if err := server.Stop(); err != nil {
-			log.Println(err)
-		}
-	}()
-	h := jsonrpc2.HandlerWithError(server.Handle)
+	defer logCloser.Close()
 
-	// Load specific config
-	if configFile != "" {
-		cfg, err := config.GetConfig(configFile)
+	// Initialize metrics endpoint
+	if addr := c.String("metrics-addr"); addr != "" {
+		srv, err := metrics.Listen(addr)
 		if err != nil {
-			return fmt.Errorf("cannot read specified config, %w", err)
+			return err
 		}
-		server.SpecificFileCfg = cfg
-	} else {
-		// Load default config
-		cfg, err := config.GetDefaultConfig()
-		if err != nil && !errors.Is(config.ErrNotFoundConfig, err) {
-			return fmt.Errorf("cannot read default config, %w", err)
-		}
-		server.DefaultFileCfg = cfg
+		defer srv.Close()
+		slog.Info("sqls: serving metrics", "addr", srv.Addr)
 	}
 
 	// Set connect option
 	var connOpt []jsonrpc2.ConnOpt
 	if trace {
-		connOpt = append(connOpt, jsonrpc2.LogMessages(log.New(logWriter, "", 0)))
+		connOpt = append(connOpt, jsonrpc2.LogMessages(slog.NewLogLogger(slog.Default().Handler(), slog.LevelInfo)))
+	}
+
+	// The history, the connections and their caches are shared by the
+	// sessions of the clients
+	store := history.NewStore(config.HistoryFilePath)
+	registry := database.NewRegistry()
+	session := func(ctx context.Context, stream jsonrpc2.ObjectStream) error {
+		return runSession(ctx, stream, configFile, store, registry, connOpt)
 	}
 
 	// Start language server
-	log.Println("sqls: reading on stdin, writing on stdout")
-	<-jsonrpc2.NewConn(
-		context.Background(),
-		jsonrpc2.NewBufferedStream(stdrwc{}, jsonrpc2.VSCodeObjectCodec{}),
-		h,
-		connOpt...,
-	).DisconnectNotify()
-	log.Println("sqls: connections closed")
+	if c.String("tcp") != "" || c.String("websocket") != "" {
+		return listen(c, session)
+	}
+	slog.Info("sqls: reading on stdin, writing on stdout")
+	// The connections are closed and the logs are flushed on the signals too
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := session(ctx, jsonrpc2.NewBufferedStream(stdrwc{}, jsonrpc2.VSCodeObjectCodec{})); err != nil {
+		return err
+	}
+	slog.Info("sqls: connections closed")
 
 	return nil
 }
 
+// loadConfig loads the config of the file, or the default config of the
+// user.
+func loadConfig(server *handler.Server, configFile string) error {
+	// Load specific config
+	if configFile != "" {
+		cfg, err := config.GetConfig(configFile)
+		if err != nil {
+			return fmt.Errorf("cannot read specified config, %w", err)
+		}
+		server.SpecificFileCfg = cfg
+		return nil
+	}
+	// Load default config
+	cfg, err := config.GetDefaultConfig()
+	if err != nil && !errors.Is(config.ErrNotFoundConfig, err) {
+		return fmt.Errorf("cannot read default config, %w", err)
+	}
+	server.DefaultFileCfg = cfg
+	return nil
+}
+
 type stdrwc struct{}
 
 func (stdrwc) Read(p []byte) (int, error) {
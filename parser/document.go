@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
+	"github.com/sqls-server/sqls/token"
+)
+
+// delimiterPattern finds the DELIMITER directives, which change the ends of
+// all the statements following them.
+var delimiterPattern = regexp.MustCompile(`(?i)\bdelimiter\b`)
+
+// Document parses the versions of a text being edited. Only the statements
+// whose text intersects the change from the last version are parsed again,
+// and the nodes of the other statements are shared between the versions with
+// their positions moved by the lines and the columns changed before them. The
+// nodes must not be changed by the callers.
+type Document struct {
+	mu      sync.Mutex
+	dialect dialect.Dialect
+	text    string
+	stmts   []*parsedStatement
+	// delimited is true when the text has the DELIMITER directives, then the
+	// whole text is parsed
+	delimited bool
+}
+
+// parsedStatement is a statement of the document, and the byte offsets of its
+// text including the whitespaces and the comments before it.
+type parsedStatement struct {
+	node       ast.Node
+	start, end int
+}
+
+func NewDocument(d dialect.Dialect) *Document {
+	return &Document{dialect: d}
+}
+
+// Parse parses the text, the new version of the document.
+func (doc *Document) Parse(text string) (ast.TokenList, error) {
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+	if err := doc.update(text); err != nil {
+		// The next version is parsed from the start
+		doc.text, doc.stmts = "", nil
+		return nil, err
+	}
+	var nodes []ast.Node
+	for _, stmt := range doc.stmts {
+		nodes = append(nodes, stmt.node)
+	}
+	return &ast.Query{Toks: nodes}, nil
+}
+
+func (doc *Document) update(text string) error {
+	if len(doc.stmts) == 0 || doc.delimited || delimiterPattern.MatchString(text) {
+		return doc.parseAll(text)
+	}
+	if text == doc.text {
+		return nil
+	}
+
+	// The changed bytes are [prefix, oldEnd) of the last version
+	prefix := 0
+	for prefix < len(text) && prefix < len(doc.text) && text[prefix] == doc.text[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(text)-prefix && suffix < len(doc.text)-prefix &&
+		text[len(text)-suffix-1] == doc.text[len(doc.text)-suffix-1] {
+		suffix++
+	}
+	oldEnd := len(doc.text) - suffix
+	delta := len(text) - len(doc.text)
+
+	// The statements [i, j] intersect the change. The statement ending at the
+	// change is parsed too, as its last token may be continued.
+	i := 0
+	for i < len(doc.stmts)-1 && doc.stmts[i].end < prefix {
+		i++
+	}
+	j := i
+	for j+1 < len(doc.stmts) && doc.stmts[j+1].start <= oldEnd {
+		j++
+	}
+	start := doc.stmts[i].start
+	stmts, ended, err := parseStatements(text[start:doc.stmts[j].end+delta], doc.dialect)
+	if (err != nil || !ended) && j+1 < len(doc.stmts) {
+		// The last statement continues to the ones following it, such as
+		// after the semicolon is removed or a comment is not closed
+		j = len(doc.stmts) - 1
+		stmts, _, err = parseStatements(text[start:], doc.dialect)
+	}
+	if err != nil {
+		// The statements before the change may be parsed with it, such as
+		// after a parenthesis is opened
+		return doc.parseAll(text)
+	}
+
+	from, ok := edgePos(doc.stmts[i].node, false)
+	if !ok {
+		return doc.parseAll(text)
+	}
+	to := from
+	for _, stmt := range stmts {
+		moveTokens(stmt.node, token.Pos{}, from)
+		stmt.start += start
+		stmt.end += start
+		if end, ok := edgePos(stmt.node, true); ok {
+			to = end
+		}
+	}
+	following := doc.stmts[j+1:]
+	if len(following) > 0 {
+		moved, ok := edgePos(following[0].node, false)
+		if !ok {
+			return doc.parseAll(text)
+		}
+		for _, stmt := range following {
+			moveTokens(stmt.node, moved, to)
+			stmt.start += delta
+			stmt.end += delta
+		}
+	}
+
+	doc.text = text
+	doc.stmts = append(append(append([]*parsedStatement{}, doc.stmts[:i]...), stmts...), following...)
+	return nil
+}
+
+// parseAll parses the whole text.
+func (doc *Document) parseAll(text string) error {
+	stmts, _, err := parseStatements(text, doc.dialect)
+	if err != nil {
+		return err
+	}
+	doc.text, doc.stmts = text, stmts
+	doc.delimited = delimiterPattern.MatchString(text)
+	return nil
+}
+
+// parseStatements parses the text, and reports whether the last statement
+// ends in the text.
+func parseStatements(text string, d dialect.Dialect) (_ []*parsedStatement, _ bool, err error) {
+	// The parser panics on some of the statements being typed, such as the
+	// list ended by a comment, which is parsed again with the ones following it
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cannot parse statements, %v", r)
+		}
+	}()
+	nodes, offsets, err := tokenize(text, d)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The ends of the statements split by the parser
+	splitter := newStatementSplitter(nodes)
+	ended := true
+	var ends []int
+	for i := range nodes {
+		ended = splitter.isEnd(i)
+		if ended {
+			ends = append(ends, offsets[i])
+		}
+	}
+	if !ended {
+		ends = append(ends, len(text))
+	}
+	if len(ends) > 0 {
+		ends[len(ends)-1] = len(text)
+	}
+
+	p := &Parser{root: &ast.Query{Toks: nodes}}
+	parsed, err := p.Parse()
+	if err != nil {
+		return nil, false, err
+	}
+	nodes = parsed.GetTokens()
+	if len(nodes) != len(ends) {
+		return nil, false, fmt.Errorf("cannot split statements, %d statements for %d ends", len(nodes), len(ends))
+	}
+	stmts := make([]*parsedStatement, len(nodes))
+	start := 0
+	for i, node := range nodes {
+		stmts[i] = &parsedStatement{node: node, start: start, end: ends[i]}
+		start = ends[i]
+	}
+	return stmts, ended, nil
+}
+
+// edgePos returns the start of the first token of the node, or the end of the
+// last one if last is true. Unlike Pos and End of the node, it skips the
+// empty lists left by the parser in the statements being typed.
+func edgePos(node ast.Node, last bool) (token.Pos, bool) {
+	switch node := node.(type) {
+	case ast.Token:
+		if last {
+			return node.GetToken().To, true
+		}
+		return node.GetToken().From, true
+	case ast.TokenList:
+		nodes := node.GetTokens()
+		for k := range nodes {
+			n := nodes[k]
+			if last {
+				n = nodes[len(nodes)-1-k]
+			}
+			if pos, ok := edgePos(n, last); ok {
+				return pos, true
+			}
+		}
+	}
+	return token.Pos{}, false
+}
+
+// moveTokens moves the positions of the tokens of the node as the text at the
+// position from is moved to the position to.
+func moveTokens(node ast.Node, from, to token.Pos) {
+	if from == to {
+		return
+	}
+	move := func(pos token.Pos) token.Pos {
+		if pos.Line == from.Line {
+			pos.Col += to.Col - from.Col
+		}
+		pos.Line += to.Line - from.Line
+		return pos
+	}
+	// A token may be of several nodes, such as the member identifier and the
+	// list of it, and is moved once
+	moved := make(map[*ast.SQLToken]bool)
+	var walk func(node ast.Node)
+	walk = func(node ast.Node) {
+		switch node := node.(type) {
+		case ast.Token:
+			tok := node.GetToken()
+			if moved[tok] {
+				return
+			}
+			moved[tok] = true
+			tok.From = move(tok.From)
+			tok.To = move(tok.To)
+		case ast.TokenList:
+			for _, n := range node.GetTokens() {
+				walk(n)
+			}
+		}
+	}
+	walk(node)
+}
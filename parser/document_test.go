@@ -0,0 +1,239 @@
+package parser
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/dialect"
+)
+
+func TestDocumentParse(t *testing.T) {
+	base := "SELECT ID, Name FROM city WHERE ID = 1;\n" +
+		"-- the countries\n" +
+		"SELECT c.Code FROM country AS c; SELECT 2;\n" +
+		"\tINSERT INTO city (ID, Name) VALUES (1, 'a;b');\n" +
+		"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END;\n" +
+		"UPDATE city SET Name = 'x' WHERE ID IN (SELECT ID FROM city);\n"
+	testcases := []struct {
+		name  string
+		edits []string
+	}{
+		{
+			name: "typing in a statement",
+			edits: []string{
+				strings.Replace(base, "WHERE ID = 1", "WHERE ID = 12", 1),
+				strings.Replace(base, "WHERE ID = 1", "WHERE ID = 123 AND", 1),
+			},
+		},
+		{
+			name: "new line before statements",
+			edits: []string{
+				strings.Replace(base, "FROM city WHERE", "FROM city\n\tWHERE", 1),
+				strings.Replace(base, "FROM city WHERE", "FROM city WHERE", 1),
+			},
+		},
+		{
+			name: "statements on the same line",
+			edits: []string{
+				strings.Replace(base, "c.Code FROM", "c.Code, c.Name FROM", 1),
+			},
+		},
+		{
+			name: "semicolon removed",
+			edits: []string{
+				strings.Replace(base, "AS c;", "AS c", 1),
+				strings.Replace(base, "AS c;", "AS c;;", 1),
+			},
+		},
+		{
+			name: "string not closed",
+			edits: []string{
+				strings.Replace(base, "VALUES (1, 'a;b')", "VALUES (1, 'a;b", 1),
+				strings.Replace(base, "VALUES (1, 'a;b')", "VALUES (1, 'a;b'", 1),
+			},
+		},
+		{
+			name: "block not ended",
+			edits: []string{
+				strings.Replace(base, "SELECT 2; END;", "SELECT 2;", 1),
+				base,
+			},
+		},
+		{
+			name: "comment opened",
+			edits: []string{
+				strings.Replace(base, "-- the countries\n", "/* the countries\n", 1),
+				strings.Replace(base, "-- the countries\n", "/* the countries */\n", 1),
+			},
+		},
+		{
+			name: "statement appended",
+			edits: []string{
+				base + "SEL",
+				base + "SELECT * FROM ",
+				base + "SELECT * FROM city;\n",
+			},
+		},
+		{
+			name: "all deleted",
+			edits: []string{
+				"",
+				"SELECT 1;",
+				"SELECT 1; SELECT 2",
+			},
+		},
+		{
+			name: "delimiter",
+			edits: []string{
+				"DELIMITER //\n" + base + "//\nDELIMITER ;\n",
+				base,
+			},
+		},
+		{
+			name: "multibyte",
+			edits: []string{
+				strings.Replace(base, "'x'", "'日本'", 1),
+				strings.Replace(base, "'x'", "'日本語'", 1),
+			},
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &dialect.MySQLDialect{}
+			doc := NewDocument(d)
+			for i, text := range append([]string{base}, tt.edits...) {
+				got, gotErr := doc.Parse(text)
+				want, wantErr := ParseDialect(text, d)
+				if (gotErr != nil) != (wantErr != nil) {
+					t.Fatalf("unmatched error of version %d, got %v, want %v", i, gotErr, wantErr)
+				}
+				if wantErr != nil {
+					continue
+				}
+				if g, w := dumpNode(got), dumpNode(want); g != w {
+					t.Errorf("unmatched parse of version %d, got:\n%s\nwant:\n%s", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestDocumentParseMovesSharedTokens(t *testing.T) {
+	// The token of the column name is of the member identifier and of the
+	// identifier list, and is moved once
+	d := &dialect.GenericSQLDialect{}
+	doc := NewDocument(d)
+	for i, text := range []string{
+		"SELECT 1;\nSELECT c. name FROM city c;",
+		"SELECT\n1;\nSELECT c. name FROM city c;",
+		"SELECT\n\n1;\nSELECT c. name FROM city c;",
+		"SELECT 1;\nSELECT c. name FROM city c;",
+	} {
+		got, err := doc.Parse(text)
+		if err != nil {
+			t.Fatalf("error: %+v", err)
+		}
+		want, err := ParseDialect(text, d)
+		if err != nil {
+			t.Fatalf("error: %+v", err)
+		}
+		if g, w := dumpNode(got), dumpNode(want); g != w {
+			t.Errorf("unmatched parse of version %d, got:\n%s\nwant:\n%s", i, g, w)
+		}
+	}
+}
+
+func TestDocumentParseRandomEdits(t *testing.T) {
+	base := "SELECT 1;\n" +
+		"SELECT c. name, c.ID FROM city c WHERE c.ID IN (SELECT ID FROM city);\n" +
+		"INSERT INTO city (ID, Name) VALUES (1, 'a');\n" +
+		"UPDATE city SET Name = 'x' WHERE ID = 1;\n"
+	inserts := []string{" ", "\n", "\t", ";", ".", "x", "1", "'", "(", ")", ", ", "--", "\n\n"}
+	d := &dialect.MySQLDialect{}
+	r := rand.New(rand.NewSource(1))
+	for run := 0; run < 50; run++ {
+		doc := NewDocument(d)
+		text := base
+		for i := 0; i < 30; i++ {
+			pos := r.Intn(len(text) + 1)
+			if r.Intn(3) == 0 && pos < len(text) {
+				text = text[:pos] + text[pos+1:]
+			} else {
+				text = text[:pos] + inserts[r.Intn(len(inserts))] + text[pos:]
+			}
+			// The parser panics on some of the broken texts, which the document
+			// returns as the errors
+			want, wantErr := recoverParse(func() (ast.TokenList, error) { return ParseDialect(text, d) })
+			got, gotErr := doc.Parse(text)
+			if wantErr != nil {
+				continue
+			}
+			if gotErr != nil {
+				t.Fatalf("unexpected error of %q, %v", text, gotErr)
+			}
+			if g, w := dumpNode(got), dumpNode(want); g != w {
+				t.Fatalf("unmatched parse of %q, got:\n%s\nwant:\n%s", text, g, w)
+			}
+		}
+	}
+}
+
+func TestDocumentParseReusesStatements(t *testing.T) {
+	text := "SELECT 1;\nSELECT 2;\nSELECT 3;\n"
+	doc := NewDocument(&dialect.GenericSQLDialect{})
+	before, err := doc.Parse(text)
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	after, err := doc.Parse(strings.Replace(text, "SELECT 2", "SELECT 20\n", 1))
+	if err != nil {
+		t.Fatalf("error: %+v", err)
+	}
+	b, a := before.GetTokens(), after.GetTokens()
+	if len(b) != len(a) {
+		t.Fatalf("unmatched statements, before %d, after %d", len(b), len(a))
+	}
+	// The whitespaces before a statement are of the statement
+	for i, reused := range []bool{true, false, true, true} {
+		if (a[i] == b[i]) != reused {
+			t.Errorf("statement %d reused %v, want %v", i, a[i] == b[i], reused)
+		}
+	}
+	if got := a[2].Pos().Line; got != 2 {
+		t.Errorf("the line of the statement moved %d, want 2", got)
+	}
+}
+
+func recoverParse(parse func() (ast.TokenList, error)) (list ast.TokenList, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return parse()
+}
+
+// dumpNode renders the node types, the tokens and their positions.
+func dumpNode(node ast.Node) string {
+	var b strings.Builder
+	var dump func(node ast.Node, depth int)
+	dump = func(node ast.Node, depth int) {
+		indent := strings.Repeat("  ", depth)
+		if tok, ok := node.(ast.Token); ok {
+			t := tok.GetToken()
+			fmt.Fprintf(&b, "%s%T %s %q %v-%v\n", indent, node, t.Kind, t.String(), t.From, t.To)
+			return
+		}
+		fmt.Fprintf(&b, "%s%T\n", indent, node)
+		if list, ok := node.(ast.TokenList); ok {
+			for _, n := range list.GetTokens() {
+				dump(n, depth+1)
+			}
+		}
+	}
+	dump(node, 0)
+	return b.String()
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -54,8 +55,13 @@ func parseInfixGroup(reader *astutil.NodeReader, matcher astutil.NodeMatcher, ig
 }
 
 func Parse(text string) (ast.TokenList, error) {
+	return ParseDialect(text, &dialect.GenericSQLDialect{})
+}
+
+// ParseDialect parses the text reading the tokens in the way of the dialect.
+func ParseDialect(text string, d dialect.Dialect) (ast.TokenList, error) {
 	src := bytes.NewBuffer([]byte(text))
-	p, err := NewParser(src, &dialect.GenericSQLDialect{})
+	p, err := NewParser(src, d)
 	if err != nil {
 		return nil, err
 	}
@@ -71,15 +77,13 @@ type Parser struct {
 }
 
 func NewParser(src io.Reader, d dialect.Dialect) (*Parser, error) {
-	tokenizer := token.NewTokenizer(src, d)
-	tokens, err := tokenizer.Tokenize()
+	b, err := io.ReadAll(src)
 	if err != nil {
-		return nil, fmt.Errorf("tokenize err failed: %w", err)
+		return nil, fmt.Errorf("cannot read source, %w", err)
 	}
-
-	parsed := []ast.Node{}
-	for _, tok := range tokens {
-		parsed = append(parsed, ast.NewItem(tok))
+	parsed, _, err := tokenize(string(b), d)
+	if err != nil {
+		return nil, err
 	}
 
 	parser := &Parser{
@@ -89,6 +93,36 @@ func NewParser(src io.Reader, d dialect.Dialect) (*Parser, error) {
 	return parser, nil
 }
 
+// tokenize returns the items of the tokens of the text, which keep their
+// texts in the source, and the byte offsets of the ends of the tokens.
+func tokenize(text string, d dialect.Dialect) ([]ast.Node, []int, error) {
+	tokenizer := token.NewTokenizer(strings.NewReader(text), d)
+	parsed := []ast.Node{}
+	var ends []int
+	start := 0
+	for {
+		tok, err := tokenizer.NextToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("tokenize err failed: %w", err)
+		}
+		end := tokenizer.Offset()
+		if end > len(text) {
+			end = len(text)
+		}
+		item := &ast.Item{Tok: ast.NewSQLToken(tok)}
+		if start < end {
+			item.Tok.Raw = text[start:end]
+		}
+		parsed = append(parsed, item)
+		ends = append(ends, end)
+		start = end
+	}
+	return parsed, ends, nil
+}
+
 func (p *Parser) Parse() (ast.TokenList, error) {
 	root := p.root
 	root = parseStatement(astutil.NewNodeReader(root))
@@ -97,6 +131,7 @@ func (p *Parser) Parse() (ast.TokenList, error) {
 	root = parsePrefixGroup(astutil.NewNodeReader(root), functionPrefixMatcher, parseFunctions)
 	root = parsePrefixGroup(astutil.NewNodeReader(root), identifierPrefixMatcher, parseIdentifier)
 	root = parseInfixGroup(astutil.NewNodeReader(root), memberIdentifierInfixMatcher, false, parseMemberIdentifier)
+	root = parseInfixGroup(astutil.NewNodeReader(root), postfixOperatorInfixMatcher, true, parsePostfixOperator)
 	root = parsePrefixGroup(astutil.NewNodeReader(root), switchCaseOpenMatcher, parseCase)
 
 	root = parsePrefixGroup(astutil.NewNodeReader(root), expressionPrefixMatcher, parseExpressionInParenthesis)
@@ -110,32 +145,24 @@ func (p *Parser) Parse() (ast.TokenList, error) {
 	return root, nil
 }
 
-var statementMatcher = astutil.NodeMatcher{
-	ExpectTokens: []token.Kind{
-		token.Semicolon,
-	},
-}
-
 func parseStatement(reader *astutil.NodeReader) ast.TokenList {
 	var replaceNodes []ast.Node
+	nodes := reader.Node.GetTokens()
+	splitter := newStatementSplitter(nodes)
 	var startIndex int
-	for reader.NextNode(false) {
-		if list, ok := reader.CurNode.(ast.TokenList); ok {
+	for i, node := range nodes {
+		if list, ok := node.(ast.TokenList); ok {
 			replaceNodes = append(replaceNodes, parseStatement(astutil.NewNodeReader(list)))
+			startIndex = i + 1
 			continue
 		}
-
-		tmpReader, node := reader.FindNode(true, statementMatcher)
-		if node != nil {
-			stmt := &ast.Statement{Toks: reader.NodesWithRange(startIndex, tmpReader.Index)}
-			replaceNodes = append(replaceNodes, stmt)
-			reader = tmpReader
-			startIndex = reader.Index
+		if splitter.isEnd(i) {
+			replaceNodes = append(replaceNodes, &ast.Statement{Toks: nodes[startIndex : i+1]})
+			startIndex = i + 1
 		}
 	}
-	if reader.Index != startIndex {
-		stmt := &ast.Statement{Toks: reader.NodesWithRange(startIndex, reader.Index)}
-		replaceNodes = append(replaceNodes, stmt)
+	if startIndex < len(nodes) {
+		replaceNodes = append(replaceNodes, &ast.Statement{Toks: nodes[startIndex:]})
 	}
 	reader.Node.SetTokens(replaceNodes)
 	return reader.Node
@@ -200,8 +227,19 @@ var functionArgsMatcher = astutil.NodeMatcher{
 	NodeTypes: []ast.NodeType{ast.TypeParenthesis},
 }
 
+// notFunctionMatcher matches the keywords followed by the parentheses that
+// are not the function calls, such as the window of OVER(...).
+var notFunctionMatcher = astutil.NodeMatcher{
+	ExpectKeyword: []string{
+		"OVER",
+	},
+}
+
 func parseFunctions(reader *astutil.NodeReader) ast.Node {
 	funcName := reader.CurNode
+	if notFunctionMatcher.IsMatchKeyword(funcName) {
+		return funcName
+	}
 	if reader.PeekNodeIs(false, functionArgsMatcher) {
 		_, funcArgs := reader.PeekNode(false)
 		function := &ast.FunctionLiteral{Toks: []ast.Node{funcName, funcArgs}}
@@ -248,10 +286,126 @@ func parseMemberIdentifier(reader *astutil.NodeReader) ast.Node {
 		child,
 	)
 
+	reader.NextNode(false)
+	// The names of three parts, such as db.schema.table of SQL Server
+	if _, ok := child.(*ast.Identifier); !ok || !reader.PeekNodeIs(false, memberIdentifierInfixMatcher) {
+		return memberIdentifier
+	}
+	catalog, parent := parent, child
+	reader.NextNode(false)
+	if !reader.PeekNodeIs(false, memberIdentifierTargetMatcher) {
+		return ast.NewMemberIdentifierCatalog(
+			reader.NodesWithRange(startIndex, reader.Index),
+			catalog,
+			parent,
+			nil,
+		)
+	}
+	endIndex, child = reader.PeekNode(false)
+	memberIdentifier = ast.NewMemberIdentifierCatalog(
+		reader.NodesWithRange(startIndex, endIndex+1),
+		catalog,
+		parent,
+		child,
+	)
 	reader.NextNode(false)
 	return memberIdentifier
 }
 
+var postfixOperatorInfixMatcher = astutil.NodeMatcher{
+	ExpectTokens: []token.Kind{
+		token.DoubleColon,
+		token.LBracket,
+	},
+}
+var postfixOperatorTargetMatcher = astutil.NodeMatcher{
+	NodeTypes: []ast.NodeType{
+		ast.TypeIdentifier,
+		ast.TypeMemberIdentifier,
+		ast.TypeParenthesis,
+		ast.TypeFunctionLiteral,
+	},
+	ExpectTokens: []token.Kind{
+		token.Number,
+		token.SingleQuotedString,
+		token.NationalStringLiteral,
+		token.DollarQuotedString,
+	},
+}
+var castTypeMatcher = astutil.NodeMatcher{
+	NodeTypes: []ast.NodeType{
+		ast.TypeIdentifier,
+		ast.TypeFunctionLiteral,
+	},
+	ExpectTokens: []token.Kind{
+		token.SQLKeyword,
+	},
+}
+
+// parsePostfixOperator parses the casts and the array subscripts of
+// PostgreSQL, such as id::text and tags[1], into the operators. The right of
+// a subscript is the first node in the brackets.
+func parsePostfixOperator(reader *astutil.NodeReader) ast.Node {
+	if !reader.CurNodeIs(postfixOperatorTargetMatcher) {
+		return reader.CurNode
+	}
+
+	left := reader.CurNode
+	startIndex := reader.Index - 1
+	for reader.PeekNodeIs(true, postfixOperatorInfixMatcher) {
+		tmpReader := reader.CopyReader()
+		tmpReader.NextNode(true)
+		operator := tmpReader.CurNode
+
+		var right ast.Node
+		ok := true
+		if operator.(ast.Token).GetToken().Kind == token.DoubleColon {
+			ok = tmpReader.PeekNodeIs(true, castTypeMatcher)
+			tmpReader.NextNode(true)
+			right = tmpReader.CurNode
+		} else {
+			right, ok = parseSubscript(tmpReader)
+		}
+		if !ok {
+			break
+		}
+
+		reader.Index = tmpReader.Index
+		reader.CurNode = tmpReader.CurNode
+		left = &ast.Operator{
+			Toks:     reader.NodesWithRange(startIndex, reader.Index),
+			Left:     left,
+			Operator: operator,
+			Right:    right,
+		}
+	}
+	return left
+}
+
+// parseSubscript reads the nodes to the closing bracket, and returns the first
+// node in the brackets.
+func parseSubscript(reader *astutil.NodeReader) (ast.Node, bool) {
+	var first ast.Node
+	depth := 1
+	for reader.NextNode(true) {
+		if tok, ok := reader.CurNode.(ast.Token); ok {
+			switch tok.GetToken().Kind {
+			case token.LBracket:
+				depth++
+			case token.RBracket:
+				depth--
+				if depth == 0 {
+					return first, true
+				}
+			}
+		}
+		if first == nil {
+			first = reader.CurNode
+		}
+	}
+	return nil, false
+}
+
 var multiKeywordMap = map[string][]string{
 	"ORDER":   {"BY"},
 	"GROUP":   {"BY"},
@@ -263,6 +417,21 @@ var multiKeywordMap = map[string][]string{
 	"LEFT":    {"OUTER", "JOIN"},
 	"RIGHT":   {"OUTER", "JOIN"},
 	"NATURAL": {"LEFT", "RIGHT", "OUTER", "JOIN"},
+	// MERGE and the upserts of PostgreSQL and MySQL
+	"MERGE":     {"INTO"},
+	"ON":        {"CONFLICT", "DUPLICATE"},
+	"DUPLICATE": {"KEY"},
+	"KEY":       {"UPDATE"},
+	"DO":        {"UPDATE", "NOTHING"},
+	// Window specifications and their frames
+	"PARTITION": {"BY"},
+	"ROWS":      {"BETWEEN"},
+	"RANGE":     {"BETWEEN"},
+	"GROUPS":    {"BETWEEN"},
+	"UNBOUNDED": {"PRECEDING", "FOLLOWING"},
+	"CURRENT":   {"ROW"},
+	// LOAD DATA of MySQL
+	"LOAD": {"DATA"},
 }
 
 func genMultiKeywordPrefixMatcher() astutil.NodeMatcher {
@@ -316,6 +485,7 @@ var operatorInfixMatcher = astutil.NodeMatcher{
 		token.Div,
 		token.Mod,
 		token.Caret,
+		token.Operator,
 	},
 }
 var operatorTargetMatcher = astutil.NodeMatcher{
@@ -331,6 +501,7 @@ var operatorTargetMatcher = astutil.NodeMatcher{
 		token.Char,
 		token.SingleQuotedString,
 		token.NationalStringLiteral,
+		token.DollarQuotedString,
 	},
 }
 
@@ -407,6 +578,7 @@ var comparisonTargetMatcher = astutil.NodeMatcher{
 		token.Char,
 		token.SingleQuotedString,
 		token.NationalStringLiteral,
+		token.DollarQuotedString,
 	},
 	ExpectKeyword: []string{
 		"TRUE",
@@ -474,6 +646,39 @@ var aliasRightMatcher = astutil.NodeMatcher{
 	},
 }
 
+// aliasColumnsMatcher matches the names of the aliases with the column lists,
+// such as r(a int, b text) of the table functions and t(a, b) of the
+// subqueries, which are not the keywords like VALUES.
+var aliasColumnsMatcher = astutil.NodeMatcher{
+	ExpectSQLType: []dialect.KeywordKind{
+		dialect.Unmatched,
+	},
+}
+
+var aliasColumnsTargetMatcher = astutil.NodeMatcher{
+	NodeTypes: []ast.NodeType{
+		ast.TypeParenthesis,
+		ast.TypeFunctionLiteral,
+	},
+}
+
+// peekAliasIs reports whether the next node is the alias of the real name.
+func peekAliasIs(reader *astutil.NodeReader, realName ast.Node) bool {
+	if reader.PeekNodeIs(true, aliasRightMatcher) {
+		return true
+	}
+	if !aliasColumnsTargetMatcher.IsMatchNodeTypes(realName) {
+		return false
+	}
+	_, node := reader.PeekNode(true)
+	fl, ok := node.(*ast.FunctionLiteral)
+	if !ok {
+		return false
+	}
+	name, ok := fl.Toks[0].(ast.Token)
+	return ok && aliasColumnsMatcher.IsMatchSQLType(name.GetToken())
+}
+
 var aliasRecursionMatcher = astutil.NodeMatcher{
 	NodeTypes: []ast.NodeType{
 		ast.TypeParenthesis,
@@ -481,7 +686,7 @@ var aliasRecursionMatcher = astutil.NodeMatcher{
 }
 
 func parseAliasedWithoutAs(reader *astutil.NodeReader) ast.Node {
-	if !reader.PeekNodeIs(true, aliasRightMatcher) {
+	if !peekAliasIs(reader, reader.CurNode) {
 		return reader.CurNode
 	}
 
@@ -509,7 +714,7 @@ func parseAliased(reader *astutil.NodeReader) ast.Node {
 	tmpReader := reader.CopyReader()
 	tmpReader.NextNode(true)
 
-	if !tmpReader.PeekNodeIs(true, aliasRightMatcher) {
+	if !peekAliasIs(tmpReader, realName) {
 		return reader.CurNode
 	}
 	endIndex, aliasedName := tmpReader.PeekNode(true)
@@ -543,6 +748,7 @@ var identifierListTargetMatcher = astutil.NodeMatcher{
 		token.Char,
 		token.SingleQuotedString,
 		token.NationalStringLiteral,
+		token.DollarQuotedString,
 	},
 	NodeTypes: []ast.NodeType{
 		ast.TypeFunctionLiteral,
@@ -639,7 +845,8 @@ func parseCase(reader *astutil.NodeReader) ast.Node {
 		}
 		nodes = append(nodes, tmpReader.CurNode)
 	}
-	return reader.Node
+	// The CASE without END, such as the one being typed, is left as it is
+	return reader.CurNode
 }
 
 var expressionPrefixMatcher = astutil.NodeMatcher{
@@ -74,6 +74,93 @@ func TestParseStatement(t *testing.T) {
 	}
 }
 
+func TestParseStatementBlocks(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "semicolon in string",
+			input: "select 'a;b';select 2",
+			want:  []string{"select 'a;b';", "select 2"},
+		},
+		{
+			name:  "dollar quoted body",
+			input: "create function f() returns int as $$ select 1; $$ language sql;select 2",
+			want:  []string{"create function f() returns int as $$ select 1; $$ language sql;", "select 2"},
+		},
+		{
+			name:  "tagged dollar quoted body",
+			input: "do $body$ begin perform 1; end $body$;select $1",
+			want:  []string{"do $body$ begin perform 1; end $body$;", "select $1"},
+		},
+		{
+			name:  "procedure block",
+			input: "create procedure p() begin if x then select 1; end if; case y when 1 then select 2; end case; end;\nselect 3;",
+			want:  []string{"create procedure p() begin if x then select 1; end if; case y when 1 then select 2; end case; end;", "\nselect 3;"},
+		},
+		{
+			name:  "trigger block",
+			input: "create trigger t before insert on a for each row begin set new.x = 1; end;select 1",
+			want:  []string{"create trigger t before insert on a for each row begin set new.x = 1; end;", "select 1"},
+		},
+		{
+			name:  "anonymous block",
+			input: "begin\nupdate a set x = 1;\nend;select 1",
+			want:  []string{"begin\nupdate a set x = 1;\nend;", "select 1"},
+		},
+		{
+			name:  "transaction",
+			input: "begin;select 1;begin transaction;commit",
+			want:  []string{"begin;", "select 1;", "begin transaction;", "commit"},
+		},
+		{
+			name:  "column named begin",
+			input: "select begin from a;select 1",
+			want:  []string{"select begin from a;", "select 1"},
+		},
+		{
+			name:  "pl/sql declarations",
+			input: "create procedure p is v number; begin v := 1; end;declare x number; begin null; end;select 1",
+			want:  []string{"create procedure p is v number; begin v := 1; end;", "declare x number; begin null; end;", "select 1"},
+		},
+		{
+			name:  "t-sql declarations",
+			input: "declare @x int;select @x;create procedure p as select a as b from c;select 1",
+			want:  []string{"declare @x int;", "select @x;", "create procedure p as select a as b from c;", "select 1"},
+		},
+		{
+			name:  "delimiter directive",
+			input: "DELIMITER //\ncreate procedure p() begin select 1; end //\nselect 2; select 3//\nDELIMITER ;\nselect 4;",
+			want:  []string{"DELIMITER //", "\ncreate procedure p() begin select 1; end //", "\nselect 2; select 3//", "\nDELIMITER ;", "\nselect 4;"},
+		},
+		{
+			name:  "delimiter not in directive",
+			input: "copy a from 'f' delimiter ',';select 1",
+			want:  []string{"copy a from 'f' delimiter ',';", "select 1"},
+		},
+		{
+			name:  "psql meta-command",
+			input: "\\copy a from 'f' with (format csv)\nselect 1;\ncopy a\nfrom stdin;",
+			want:  []string{"\\copy a from 'f' with (format csv)\n", "select 1;", "\ncopy a\nfrom stdin;"},
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts := parseInit(t, tt.input)
+			got := []string{}
+			for _, stmt := range stmts {
+				got = append(got, stmt.String())
+			}
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestParseComments(t *testing.T) {
 	testcases := []struct {
 		name    string
@@ -244,6 +331,17 @@ func TestParseFunction(t *testing.T) {
 				testFunction(t, list[0], "foo(a, b, c)")
 			},
 		},
+		{
+			name:  "window of over",
+			input: "count(*) over(partition by a)",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				list := stmts[0].GetTokens()
+				testFunction(t, list[0], "count(*)")
+				testItem(t, list[2], "over")
+				parenthesis := testParenthesis(t, list[3], "(partition by a)")
+				testMultiKeyword(t, parenthesis.Inner().GetTokens()[0], "partition by")
+			},
+		},
 	}
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -493,6 +591,26 @@ func TestMemberIdentifier(t *testing.T) {
 				testMemberIdentifier(t, list[6], "myschema.abc", "myschema", "abc")
 			},
 		},
+		{
+			name:  "three part member identifier",
+			input: "select foo from mydb.myschema.abc",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 7, input)
+				list := stmts[0].GetTokens()
+				testMemberIdentifier(t, list[6], "mydb.myschema.abc", "myschema", "abc")
+				testCatalog(t, list[6], "mydb")
+			},
+		},
+		{
+			name:  "invalid three part member identifier",
+			input: "select foo from mydb.myschema.",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 7, input)
+				list := stmts[0].GetTokens()
+				testMemberIdentifier(t, list[6], "mydb.myschema.", "myschema", "")
+				testCatalog(t, list[6], "mydb")
+			},
+		},
 	}
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -583,6 +701,32 @@ func TestParseMultiKeyword(t *testing.T) {
 				testIdentifierList(t, list[10], "d, e, f")
 			},
 		},
+		{
+			name:  "window frame keywords",
+			input: "rows between unbounded preceding and current row",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 7, input)
+				list := stmts[0].GetTokens()
+				testMultiKeyword(t, list[0], "rows between")
+				testItem(t, list[1], " ")
+				testMultiKeyword(t, list[2], "unbounded preceding")
+				testItem(t, list[3], " ")
+				testItem(t, list[4], "and")
+				testItem(t, list[5], " ")
+				testMultiKeyword(t, list[6], "current row")
+			},
+		},
+		{
+			name:  "load data",
+			input: "load data local infile 'f'",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 7, input)
+				list := stmts[0].GetTokens()
+				testMultiKeyword(t, list[0], "load data")
+				testItem(t, list[1], " ")
+				testItem(t, list[2], "local")
+			},
+		},
 	}
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -725,6 +869,55 @@ func TestParseOperator(t *testing.T) {
 				testOperator(t, list[0], input, "foo", "+", "")
 			},
 		},
+		{
+			name:  "json operators",
+			input: "doc->'a'->>'b'",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 1, input)
+				list := stmts[0].GetTokens()
+				operator := testOperator(t, list[0], input, "doc->'a'", "->>", "'b'")
+				testOperator(t, operator.GetLeft(), "doc->'a'", "doc", "->", "'a'")
+			},
+		},
+		{
+			name:  "containment operator",
+			input: "tags @> '{a}'",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 1, input)
+				list := stmts[0].GetTokens()
+				testOperator(t, list[0], input, "tags", "@>", "'{a}'")
+			},
+		},
+		{
+			name:  "cast",
+			input: "t.id::text",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 1, input)
+				list := stmts[0].GetTokens()
+				testOperator(t, list[0], input, "t.id", "::", "text")
+			},
+		},
+		{
+			name:  "array subscripts",
+			input: "tags[1][2:3]",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 1, input)
+				list := stmts[0].GetTokens()
+				operator := testOperator(t, list[0], input, "tags[1]", "[", "2")
+				testOperator(t, operator.GetLeft(), "tags[1]", "tags", "[", "1")
+			},
+		},
+		{
+			name:  "cast to array type",
+			input: "'{1}'::int[] + 1",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 1, input)
+				list := stmts[0].GetTokens()
+				operator := testOperator(t, list[0], input, "'{1}'::int[]", "+", "1")
+				cast := testOperator(t, operator.GetLeft(), "'{1}'::int[]", "'{1}'::int", "[", "")
+				testOperator(t, cast.GetLeft(), "'{1}'::int", "'{1}'", "::", "int")
+			},
+		},
 	}
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -960,6 +1153,34 @@ func TestParseAliased(t *testing.T) {
 				testItem(t, parenthesis[8], ")")
 			},
 		},
+		{
+			name:  "alias with column list",
+			input: "jsonb_to_recordset(j) AS r(a int, b text)",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 1, input)
+				list := stmts[0].GetTokens()
+				testAliased(t, list[0], input, "jsonb_to_recordset(j)", "r(a int, b text)")
+			},
+		},
+		{
+			name:  "alias with column list without AS",
+			input: "unnest(a) u(val)",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 1, input)
+				list := stmts[0].GetTokens()
+				testAliased(t, list[0], input, "unnest(a)", "u(val)")
+			},
+		},
+		{
+			name:  "function not alias",
+			input: "t(a) VALUES(1)",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 3, input)
+				list := stmts[0].GetTokens()
+				testFunction(t, list[0], "t(a)")
+				testFunction(t, list[2], "VALUES(1)")
+			},
+		},
 	}
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1222,6 +1443,13 @@ func TestParseCase(t *testing.T) {
 				testIdentifierList(t, list[0], input)
 			},
 		},
+		{
+			name:  "case without end",
+			input: "SET a = CASE WHEN",
+			checkFn: func(t *testing.T, stmts []*ast.Statement, input string) {
+				testStatement(t, stmts[0], 6, input)
+			},
+		},
 	}
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1309,6 +1537,14 @@ func testMemberIdentifier(t *testing.T, node ast.Node, expect, parent, child str
 	}
 }
 
+func testCatalog(t *testing.T, node ast.Node, catalog string) {
+	t.Helper()
+	mi := node.(*ast.MemberIdentifier)
+	if mi.Catalog == nil || mi.Catalog.String() != catalog {
+		t.Errorf("catalog expected %q, got %v", catalog, mi.Catalog)
+	}
+}
+
 func testIdentifier(t *testing.T, node ast.Node, expect string) {
 	t.Helper()
 	_, ok := node.(*ast.Identifier)
@@ -0,0 +1,43 @@
+package parseutil
+
+import (
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// castFunctions are the functions whose arguments end with AS and the type.
+var castFunctions = []string{"CAST", "TRY_CAST", "SAFE_CAST"}
+
+// IsCastType reports whether the position is of the type of a cast, after ::
+// of PostgreSQL or after AS in CAST().
+func IsCastType(parsed ast.TokenList, pos token.Pos) (bool, error) {
+	stmt, err := extractFocusedStatement(parsed, pos)
+	if err != nil {
+		return false, err
+	}
+	toks := ddlTokens(stmt, pos)
+	if len(toks) == 0 {
+		return false, nil
+	}
+	if toks[len(toks)-1].Kind == token.DoubleColon {
+		return true, nil
+	}
+	r := &ddlReader{toks: toks, i: len(toks) - 1}
+	if !r.is("AS") {
+		return false, nil
+	}
+	depth := 0
+	for r.i--; r.i >= 0; r.i-- {
+		switch r.kind() {
+		case token.RParen:
+			depth++
+		case token.LParen:
+			if depth == 0 {
+				r.i--
+				return r.i >= 0 && r.is(castFunctions...), nil
+			}
+			depth--
+		}
+	}
+	return false, nil
+}
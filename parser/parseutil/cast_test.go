@@ -0,0 +1,63 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/sqls-server/sqls/token"
+)
+
+func TestIsCastType(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{
+			name:  "double colon",
+			input: "SELECT '1'::",
+			want:  true,
+		},
+		{
+			name:  "double colon typing",
+			input: "SELECT mood::te",
+			want:  true,
+		},
+		{
+			name:  "cast as",
+			input: "SELECT CAST(price * (1 + rate) AS ",
+			want:  true,
+		},
+		{
+			name:  "try cast as",
+			input: "SELECT TRY_CAST(code AS ",
+			want:  true,
+		},
+		{
+			name:  "alias",
+			input: "SELECT name AS ",
+			want:  false,
+		},
+		{
+			name:  "alias in function",
+			input: "SELECT COUNT(*) FROM (SELECT id AS ",
+			want:  false,
+		},
+		{
+			name:  "column",
+			input: "SELECT ",
+			want:  false,
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			got, err := IsCastType(query, token.Pos{Line: 0, Col: len(tt.input)})
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected cast type, got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
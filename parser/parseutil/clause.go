@@ -0,0 +1,157 @@
+package parseutil
+
+import (
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// Clause is the clause of the query at a position, which tells the keywords
+// that may follow.
+type Clause int
+
+const (
+	// ClauseUnknown is the position of the statements which are not queries,
+	// where any keyword may follow
+	ClauseUnknown Clause = iota
+	// ClauseStatement is the start of a statement
+	ClauseStatement
+	ClauseSelect
+	ClauseFrom
+	ClauseJoinOn
+	ClauseWhere
+	ClauseGroupBy
+	ClauseHaving
+	ClauseOrderBy
+	ClauseLimit
+	// ClauseInto is the table of INSERT INTO, REPLACE INTO and MERGE INTO
+	ClauseInto
+	ClauseValues
+	// ClauseUpdate is the table of UPDATE
+	ClauseUpdate
+	ClauseSet
+)
+
+// queryKeywords are the keywords which start the statements whose clauses
+// are told.
+var queryKeywords = map[string]bool{
+	"SELECT":      true,
+	"INSERT":      true,
+	"INSERT INTO": true,
+	"REPLACE":     true,
+	"UPDATE":      true,
+	"DELETE":      true,
+	"DELETE FROM": true,
+	"MERGE":       true,
+	"MERGE INTO":  true,
+	"VALUES":      true,
+}
+
+var clauseKeywords = map[string]Clause{
+	"SELECT":           ClauseSelect,
+	"FROM":             ClauseFrom,
+	"DELETE FROM":      ClauseFrom,
+	"USING":            ClauseFrom,
+	"JOIN":             ClauseFrom,
+	"INNER JOIN":       ClauseFrom,
+	"OUTER JOIN":       ClauseFrom,
+	"LEFT JOIN":        ClauseFrom,
+	"RIGHT JOIN":       ClauseFrom,
+	"LEFT OUTER JOIN":  ClauseFrom,
+	"RIGHT OUTER JOIN": ClauseFrom,
+	"FULL JOIN":        ClauseFrom,
+	"FULL OUTER JOIN":  ClauseFrom,
+	"CROSS JOIN":       ClauseFrom,
+	"NATURAL JOIN":     ClauseFrom,
+	"ON":               ClauseJoinOn,
+	"WHERE":            ClauseWhere,
+	"GROUP BY":         ClauseGroupBy,
+	"HAVING":           ClauseHaving,
+	"QUALIFY":          ClauseHaving,
+	"ORDER BY":         ClauseOrderBy,
+	"LIMIT":            ClauseLimit,
+	"OFFSET":           ClauseLimit,
+	"FETCH":            ClauseLimit,
+	"INTO":             ClauseInto,
+	"INSERT":           ClauseInto,
+	"REPLACE":          ClauseInto,
+	"INSERT INTO":      ClauseInto,
+	"MERGE INTO":       ClauseInto,
+	"VALUES":           ClauseValues,
+	"UPDATE":           ClauseUpdate,
+	"SET":              ClauseSet,
+}
+
+// ExtractClause returns the clause of the query or the subquery at the
+// position, by the last keyword of the clauses before it.
+func ExtractClause(parsed ast.TokenList, pos token.Pos) (Clause, error) {
+	stmt, err := extractFocusedStatement(parsed, pos)
+	if err != nil {
+		return ClauseUnknown, err
+	}
+	list := stmt
+	if encloseIsSubQuery(stmt, pos) {
+		list = extractFocusedSubQuery(stmt, pos)
+	}
+
+	clause := ClauseStatement
+	first := true
+	for _, node := range list.GetTokens() {
+		// The word at the position is being typed
+		if token.ComparePos(node.End(), pos) >= 0 {
+			break
+		}
+		if isClauseFiller(node) {
+			continue
+		}
+		keyword := strings.ToUpper(node.String())
+		if first {
+			first = false
+			switch {
+			case isKeywordNode(node) && keyword == "WITH":
+				// The common table expressions are not told until the query
+				clause = ClauseUnknown
+				continue
+			case !isKeywordNode(node) || !queryKeywords[keyword]:
+				return ClauseUnknown, nil
+			}
+		} else if keyword == "UPDATE" {
+			// UPDATE of FOR UPDATE and ON DUPLICATE KEY UPDATE
+			continue
+		}
+		if !isKeywordNode(node) {
+			continue
+		}
+		// ON of ON CONFLICT is not of JOIN
+		if c, ok := clauseKeywords[keyword]; ok && !(c == ClauseJoinOn && clause == ClauseValues) {
+			clause = c
+		}
+	}
+	return clause, nil
+}
+
+// isClauseFiller reports whether the node is the whitespace, the comment or
+// the parenthesis opening the subquery, which do not change the clause.
+func isClauseFiller(node ast.Node) bool {
+	tok, ok := node.(ast.Token)
+	if !ok {
+		return false
+	}
+	switch tok.GetToken().Kind {
+	case token.Whitespace, token.Comment, token.MultilineComment, token.LParen:
+		return true
+	}
+	return false
+}
+
+func isKeywordNode(node ast.Node) bool {
+	switch node.Type() {
+	case ast.TypeMultiKeyword:
+		return true
+	case ast.TypeItem:
+		tok, ok := node.(ast.Token)
+		return ok && tok.GetToken().Kind == token.SQLKeyword
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/sqls-server/sqls/parser"
+	"github.com/sqls-server/sqls/token"
+)
+
+func TestExtractClause(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		pos   token.Pos
+		want  Clause
+	}{
+		{
+			name:  "statement start",
+			input: "SELECT 1;\n",
+			pos:   token.Pos{Line: 1, Col: 0},
+			want:  ClauseStatement,
+		},
+		{
+			name:  "typing the first keyword",
+			input: "SEL",
+			pos:   token.Pos{Line: 0, Col: 3},
+			want:  ClauseStatement,
+		},
+		{
+			name:  "select",
+			input: "SELECT ID ",
+			pos:   token.Pos{Line: 0, Col: 10},
+			want:  ClauseSelect,
+		},
+		{
+			name:  "from",
+			input: "SELECT ID FROM city ",
+			pos:   token.Pos{Line: 0, Col: 20},
+			want:  ClauseFrom,
+		},
+		{
+			name:  "typing a keyword",
+			input: "SELECT ID FROM city WHERE",
+			pos:   token.Pos{Line: 0, Col: 25},
+			want:  ClauseFrom,
+		},
+		{
+			name:  "join on",
+			input: "SELECT * FROM city c LEFT JOIN country co ON c.CountryCode = co.Code ",
+			pos:   token.Pos{Line: 0, Col: 69},
+			want:  ClauseJoinOn,
+		},
+		{
+			name:  "where",
+			input: "SELECT ID FROM city WHERE ID = 1 ",
+			pos:   token.Pos{Line: 0, Col: 33},
+			want:  ClauseWhere,
+		},
+		{
+			name:  "group by",
+			input: "SELECT CountryCode FROM city GROUP BY CountryCode ",
+			pos:   token.Pos{Line: 0, Col: 50},
+			want:  ClauseGroupBy,
+		},
+		{
+			name:  "order by",
+			input: "SELECT ID FROM city ORDER BY ID ",
+			pos:   token.Pos{Line: 0, Col: 32},
+			want:  ClauseOrderBy,
+		},
+		{
+			name:  "subquery",
+			input: "SELECT * FROM city WHERE ID IN (SELECT ID FROM country c ) AND 1",
+			pos:   token.Pos{Line: 0, Col: 57},
+			want:  ClauseFrom,
+		},
+		{
+			name:  "after subquery",
+			input: "SELECT * FROM city WHERE ID IN (SELECT ID FROM country c ) ",
+			pos:   token.Pos{Line: 0, Col: 59},
+			want:  ClauseWhere,
+		},
+		{
+			name:  "with",
+			input: "WITH it AS (SELECT ID FROM city) SELECT * FROM it ",
+			pos:   token.Pos{Line: 0, Col: 50},
+			want:  ClauseFrom,
+		},
+		{
+			name:  "update set",
+			input: "UPDATE city SET Name = 'a' ",
+			pos:   token.Pos{Line: 0, Col: 27},
+			want:  ClauseSet,
+		},
+		{
+			name:  "on conflict",
+			input: "INSERT INTO city (ID) VALUES (1) ON ",
+			pos:   token.Pos{Line: 0, Col: 36},
+			want:  ClauseValues,
+		},
+		{
+			name:  "not a query",
+			input: "DROP TABLE city ",
+			pos:   token.Pos{Line: 0, Col: 16},
+			want:  ClauseUnknown,
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := parser.Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ExtractClause(parsed, tt.pos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected clause %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
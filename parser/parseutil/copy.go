@@ -0,0 +1,356 @@
+package parseutil
+
+import (
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// CopyPosition is the part of COPY, \copy of psql or LOAD DATA at the
+// position.
+type CopyPosition string
+
+const (
+	// CopyKeyword is where only the keywords of the syntax follow
+	CopyKeyword CopyPosition = "copy_keyword"
+	// CopyTable is the table copied or loaded
+	CopyTable CopyPosition = "copy_table"
+	// CopyColumn is a column of the table
+	CopyColumn CopyPosition = "copy_column"
+	// CopyFile is the path of the file in the quotes
+	CopyFile CopyPosition = "copy_file"
+)
+
+// Copy is the bulk statement at the position.
+type Copy struct {
+	Position CopyPosition
+	// Table is the table copied or loaded
+	Table *TableInfo
+	// Keywords are the keywords following at CopyKeyword
+	Keywords []string
+	// Path is the part of the path before the position at CopyFile
+	Path string
+}
+
+var (
+	copyOptionKeywords = []string{
+		"FORMAT",
+		"FREEZE",
+		"DELIMITER",
+		"NULL",
+		"DEFAULT",
+		"HEADER",
+		"QUOTE",
+		"ESCAPE",
+		"FORCE_QUOTE",
+		"FORCE_NOT_NULL",
+		"FORCE_NULL",
+		"ENCODING",
+	}
+	copyFormatKeywords = []string{
+		"TEXT",
+		"CSV",
+		"BINARY",
+	}
+	loadDataClauseKeywords = []string{
+		"PARTITION",
+		"CHARACTER SET",
+		"FIELDS",
+		"COLUMNS",
+		"LINES",
+		"IGNORE",
+		"SET",
+	}
+	fieldsKeywords = []string{
+		"TERMINATED BY",
+		"OPTIONALLY ENCLOSED BY",
+		"ENCLOSED BY",
+		"ESCAPED BY",
+	}
+	linesKeywords = []string{
+		"STARTING BY",
+		"TERMINATED BY",
+	}
+)
+
+// ExtractCopy returns the bulk statement at the position, or nil if the
+// position is not in COPY, \copy nor LOAD DATA.
+func ExtractCopy(parsed ast.TokenList, pos token.Pos) (*Copy, error) {
+	stmt, err := extractFocusedStatement(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	r := &copyReader{ddlReader: &ddlReader{toks: ddlTokens(stmt, pos)}}
+	if str := stringAt(stmt, pos); str != nil {
+		r.inPath = true
+		r.path = string([]rune(str.String())[1 : pos.Col-str.From.Col])
+		// The string not closed ends at the position
+		if len(r.toks) > 0 && r.toks[len(r.toks)-1] == str {
+			r.toks = r.toks[:len(r.toks)-1]
+		}
+	}
+	if !r.done() && r.kind() == token.Backslash {
+		r.i++
+	}
+
+	var c *Copy
+	switch {
+	case r.accept("COPY"):
+		c = r.copy()
+	case r.accept("LOAD"):
+		c = r.loadData()
+	}
+	// The strings other than the paths, such as DELIMITER ','
+	if c == nil || (r.inPath && c.Position != CopyFile) {
+		return nil, nil
+	}
+	return c, nil
+}
+
+// stringAt returns the single quoted string on a line that is not closed
+// before the position, or nil.
+func stringAt(stmt ast.TokenList, pos token.Pos) *ast.SQLToken {
+	var str *ast.SQLToken
+	var walk func(node ast.Node)
+	walk = func(node ast.Node) {
+		if list, ok := node.(ast.TokenList); ok {
+			for _, n := range list.GetTokens() {
+				walk(n)
+			}
+			return
+		}
+		tok, ok := node.(ast.Token)
+		if !ok {
+			return
+		}
+		t := tok.GetToken()
+		if t.Kind != token.SingleQuotedString || t.From.Line != pos.Line || t.To.Line != pos.Line {
+			return
+		}
+		if t.From.Col < pos.Col && (pos.Col < t.To.Col || (pos.Col == t.To.Col && !isClosedString(t))) {
+			str = t
+		}
+	}
+	walk(stmt)
+	return str
+}
+
+func isClosedString(tok *ast.SQLToken) bool {
+	s := tok.String()
+	return len(s) >= 2 && s[len(s)-1] == '\''
+}
+
+type copyReader struct {
+	*ddlReader
+	// inPath is true when the position is in a quoted string, and path is
+	// the part of the string before the position
+	inPath bool
+	path   string
+}
+
+func (r *copyReader) file() *Copy {
+	if !r.inPath {
+		return nil
+	}
+	return &Copy{Position: CopyFile, Path: r.path}
+}
+
+func (r *copyReader) copy() *Copy {
+	if r.done() {
+		return &Copy{Position: CopyTable}
+	}
+	c := &Copy{}
+	// COPY (SELECT ...) TO
+	if r.kind() == token.LParen {
+		if !r.skipParens() {
+			return nil
+		}
+		if r.done() {
+			return c.keywords([]string{"TO"})
+		}
+	} else {
+		c.Table = r.name()
+		if c.Table == nil {
+			return nil
+		}
+		if !r.done() && r.kind() == token.LParen {
+			r.i++
+			if _, closed := r.elements(); !closed {
+				return c.at(CopyColumn)
+			}
+			r.i++
+		}
+		if r.done() {
+			return c.keywords([]string{"FROM", "TO"})
+		}
+	}
+
+	std := "STDOUT"
+	switch {
+	case r.accept("FROM"):
+		std = "STDIN"
+	case r.accept("TO"):
+	default:
+		return nil
+	}
+	if r.done() {
+		if f := r.file(); f != nil {
+			return f
+		}
+		return c.keywords([]string{std, "PROGRAM"})
+	}
+	if !r.accept(std) {
+		if r.kind() != token.SingleQuotedString {
+			return nil
+		}
+		r.i++
+	}
+	if r.done() {
+		return c.keywords([]string{"WITH"})
+	}
+
+	// The options, WITH (FORMAT csv, HEADER)
+	if !r.accept("WITH") || r.done() || r.kind() != token.LParen {
+		return nil
+	}
+	r.i++
+	options, closed := r.elements()
+	if closed {
+		return nil
+	}
+	option := options[len(options)-1]
+	if option.done() {
+		return c.keywords(copyOptionKeywords)
+	}
+	if option.accept("FORMAT") && option.done() {
+		return c.keywords(copyFormatKeywords)
+	}
+	return nil
+}
+
+func (r *copyReader) loadData() *Copy {
+	c := &Copy{}
+	if r.done() {
+		return c.keywords([]string{"DATA"})
+	}
+	if !r.accept("DATA", "XML") {
+		return nil
+	}
+	for r.accept("LOW_PRIORITY", "CONCURRENT", "LOCAL") {
+	}
+	if r.done() {
+		if r.prevWord() == "LOCAL" {
+			return c.keywords([]string{"INFILE"})
+		}
+		return c.keywords([]string{"LOCAL INFILE", "INFILE"})
+	}
+	if !r.accept("INFILE") {
+		return nil
+	}
+	if r.done() {
+		return r.file()
+	}
+	if r.kind() != token.SingleQuotedString {
+		return nil
+	}
+	r.i++
+	if r.done() {
+		return c.keywords([]string{"REPLACE", "IGNORE", "INTO TABLE"})
+	}
+	r.accept("REPLACE", "IGNORE")
+	if r.done() {
+		return c.keywords([]string{"INTO TABLE"})
+	}
+	if !r.accept("INTO") {
+		return nil
+	}
+	if r.done() {
+		return c.keywords([]string{"TABLE"})
+	}
+	r.accept("TABLE")
+	if r.done() {
+		return c.at(CopyTable)
+	}
+	c.Table = r.name()
+	if c.Table == nil {
+		return nil
+	}
+	return c.loadDataClauses(r)
+}
+
+// loadDataClauses returns the position in the clauses of LOAD DATA after the
+// table.
+func (c *Copy) loadDataClauses(r *copyReader) *Copy {
+	clause := ""
+	for !r.done() {
+		switch {
+		case r.kind() == token.LParen:
+			partition := r.prevWord() == "PARTITION"
+			if !r.skipParens() {
+				if partition {
+					return nil
+				}
+				return c.at(CopyColumn)
+			}
+		case r.is("SET"):
+			if r.prevWord() == "CHARACTER" {
+				clause = "CHARACTER SET"
+			} else {
+				clause = "SET"
+			}
+			r.i++
+		case r.accept("FIELDS", "COLUMNS", "LINES", "IGNORE"):
+			// IGNORE 1 LINES
+			if clause == "IGNORE" {
+				clause = ""
+				continue
+			}
+			clause = r.prevWord()
+		default:
+			r.i++
+		}
+	}
+
+	last := r.toks[r.i-1]
+	if clause == "SET" {
+		if last.Kind == token.Comma || r.prevWord() == "SET" {
+			return c.at(CopyColumn)
+		}
+		return nil
+	}
+	switch r.prevWord() {
+	case "CHARACTER":
+		return c.keywords([]string{"SET"})
+	case "OPTIONALLY":
+		return c.keywords([]string{"ENCLOSED BY"})
+	case "TERMINATED", "ENCLOSED", "ESCAPED", "STARTING":
+		return c.keywords([]string{"BY"})
+	case "FIELDS", "COLUMNS":
+		return c.keywords(fieldsKeywords)
+	case "LINES":
+		if clause == "LINES" {
+			return c.keywords(linesKeywords)
+		}
+	case "BY", "IGNORE", "PARTITION", "SET":
+		return nil
+	}
+	if last.Kind == token.Number {
+		return c.keywords([]string{"LINES", "ROWS"})
+	}
+	switch clause {
+	case "FIELDS", "COLUMNS":
+		return c.keywords(append(append([]string{}, fieldsKeywords...), "LINES", "IGNORE", "SET"))
+	case "LINES":
+		return c.keywords(append(append([]string{}, linesKeywords...), "IGNORE", "SET"))
+	}
+	return c.keywords(loadDataClauseKeywords)
+}
+
+func (c *Copy) at(position CopyPosition) *Copy {
+	c.Position = position
+	return c
+}
+
+func (c *Copy) keywords(keywords []string) *Copy {
+	c.Keywords = keywords
+	return c.at(CopyKeyword)
+}
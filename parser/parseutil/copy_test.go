@@ -0,0 +1,184 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/token"
+)
+
+func TestExtractCopy(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		pos   *token.Pos
+		want  *Copy
+	}{
+		{
+			name:  "not copy",
+			input: "SELECT * FROM city WHERE ",
+			want:  nil,
+		},
+		{
+			name:  "copy table",
+			input: "COPY ci",
+			want:  &Copy{Position: CopyTable},
+		},
+		{
+			name:  "copy columns",
+			input: "COPY world.city (ID, ",
+			want: &Copy{
+				Position: CopyColumn,
+				Table:    &TableInfo{DatabaseSchema: "world", Name: "city"},
+			},
+		},
+		{
+			name:  "copy direction",
+			input: "COPY city (ID, Name) ",
+			want: &Copy{
+				Position: CopyKeyword,
+				Table:    &TableInfo{Name: "city"},
+				Keywords: []string{"FROM", "TO"},
+			},
+		},
+		{
+			name:  "copy from",
+			input: "COPY city FROM ",
+			want: &Copy{
+				Position: CopyKeyword,
+				Table:    &TableInfo{Name: "city"},
+				Keywords: []string{"STDIN", "PROGRAM"},
+			},
+		},
+		{
+			name:  "copy query to",
+			input: "COPY (SELECT * FROM city) TO ",
+			want: &Copy{
+				Position: CopyKeyword,
+				Keywords: []string{"STDOUT", "PROGRAM"},
+			},
+		},
+		{
+			name:  "copy file",
+			input: "COPY city FROM '/tmp/ci",
+			want:  &Copy{Position: CopyFile, Path: "/tmp/ci"},
+		},
+		{
+			name:  "psql copy file in closed string",
+			input: "\\copy city to 'data/city.csv'",
+			pos:   &token.Pos{Line: 0, Col: 20},
+			want:  &Copy{Position: CopyFile, Path: "data/"},
+		},
+		{
+			name:  "copy with",
+			input: "COPY city FROM STDIN ",
+			want: &Copy{
+				Position: CopyKeyword,
+				Table:    &TableInfo{Name: "city"},
+				Keywords: []string{"WITH"},
+			},
+		},
+		{
+			name:  "copy options",
+			input: "COPY city FROM 'city.csv' WITH (HEADER, ",
+			want: &Copy{
+				Position: CopyKeyword,
+				Table:    &TableInfo{Name: "city"},
+				Keywords: copyOptionKeywords,
+			},
+		},
+		{
+			name:  "copy format",
+			input: "COPY city TO STDOUT WITH (FORMAT ",
+			want: &Copy{
+				Position: CopyKeyword,
+				Table:    &TableInfo{Name: "city"},
+				Keywords: copyFormatKeywords,
+			},
+		},
+		{
+			name:  "copy delimiter",
+			input: "COPY city FROM STDIN WITH (DELIMITER '",
+			want:  nil,
+		},
+		{
+			name:  "load data infile",
+			input: "LOAD DATA LOCAL ",
+			want:  &Copy{Position: CopyKeyword, Keywords: []string{"INFILE"}},
+		},
+		{
+			name:  "load data file",
+			input: "LOAD DATA INFILE '",
+			want:  &Copy{Position: CopyFile, Path: ""},
+		},
+		{
+			name:  "load data into",
+			input: "LOAD DATA LOCAL INFILE '/tmp/city.csv' REPLACE ",
+			want:  &Copy{Position: CopyKeyword, Keywords: []string{"INTO TABLE"}},
+		},
+		{
+			name:  "load data table",
+			input: "LOAD DATA INFILE 'city.csv' INTO TABLE ",
+			want:  &Copy{Position: CopyTable},
+		},
+		{
+			name:  "load data clauses",
+			input: "LOAD DATA INFILE 'city.csv' INTO TABLE city ",
+			want: &Copy{
+				Position: CopyKeyword,
+				Table:    &TableInfo{Name: "city"},
+				Keywords: loadDataClauseKeywords,
+			},
+		},
+		{
+			name:  "load data fields",
+			input: "LOAD DATA INFILE 'city.csv' INTO TABLE city FIELDS TERMINATED BY ',' ",
+			want: &Copy{
+				Position: CopyKeyword,
+				Table:    &TableInfo{Name: "city"},
+				Keywords: append(append([]string{}, fieldsKeywords...), "LINES", "IGNORE", "SET"),
+			},
+		},
+		{
+			name:  "load data ignore lines",
+			input: "LOAD DATA INFILE 'city.csv' INTO TABLE city IGNORE 1 ",
+			want: &Copy{
+				Position: CopyKeyword,
+				Table:    &TableInfo{Name: "city"},
+				Keywords: []string{"LINES", "ROWS"},
+			},
+		},
+		{
+			name:  "load data columns",
+			input: "LOAD DATA INFILE 'city.csv' INTO TABLE city IGNORE 1 LINES (ID, ",
+			want: &Copy{
+				Position: CopyColumn,
+				Table:    &TableInfo{Name: "city"},
+			},
+		},
+		{
+			name:  "load data set",
+			input: "LOAD DATA INFILE 'city.csv' INTO TABLE city (ID, @name) SET ",
+			want: &Copy{
+				Position: CopyColumn,
+				Table:    &TableInfo{Name: "city"},
+			},
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			pos := token.Pos{Line: 0, Col: len(tt.input)}
+			if tt.pos != nil {
+				pos = *tt.pos
+			}
+			got, err := ExtractCopy(query, pos)
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched copy (- want, + got): %s", d)
+			}
+		})
+	}
+}
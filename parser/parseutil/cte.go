@@ -0,0 +1,89 @@
+package parseutil
+
+import (
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/ast/astutil"
+	"github.com/sqls-server/sqls/token"
+)
+
+var (
+	withMatcher      = astutil.NodeMatcher{ExpectKeyword: []string{"WITH"}}
+	recursiveMatcher = astutil.NodeMatcher{ExpectKeyword: []string{"RECURSIVE"}}
+	asMatcher        = astutil.NodeMatcher{ExpectKeyword: []string{"AS"}}
+)
+
+// ExtractCTEs returns the common table expressions of WITH of the statement
+// at the position as the views of the subqueries, such as it of
+// "WITH it AS (SELECT ...) SELECT ...".
+func ExtractCTEs(parsed ast.TokenList, pos token.Pos) ([]*SubQueryInfo, error) {
+	stmt, err := extractFocusedStatement(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	toks := []ast.Node{}
+	for _, node := range stmt.GetTokens() {
+		if tok, ok := node.(ast.Token); ok {
+			switch tok.GetToken().Kind {
+			case token.Whitespace, token.Comment, token.MultilineComment:
+				continue
+			}
+		}
+		toks = append(toks, node)
+	}
+	if len(toks) == 0 || !withMatcher.IsMatchKeyword(toks[0]) {
+		return nil, nil
+	}
+	toks = toks[1:]
+	if len(toks) > 0 && recursiveMatcher.IsMatchKeyword(toks[0]) {
+		toks = toks[1:]
+	}
+
+	// name [(columns)] AS (query) [, ...]
+	results := []*SubQueryInfo{}
+	for len(toks) >= 3 && asMatcher.IsMatchKeyword(toks[1]) {
+		var name string
+		var cols []string
+		switch v := toks[0].(type) {
+		case *ast.Identifier:
+			name = v.NoQuoteString()
+		case *ast.FunctionLiteral:
+			name = v.Toks[0].String()
+			if parenthesis, ok := v.Toks[1].(*ast.Parenthesis); ok {
+				cols = columnDefinitionNames(parenthesis.Inner())
+			}
+		default:
+			return results, nil
+		}
+		parenthesis, ok := toks[2].(*ast.Parenthesis)
+		if !ok {
+			return results, nil
+		}
+		// The expressions of the columns not known, such as the literals,
+		// are left out
+		if subqueryCols, _, err := extractSubQueryColumns(parenthesis.Inner()); isSubQuery(parenthesis) && err == nil {
+			for i, subqueryCol := range subqueryCols {
+				if i < len(cols) {
+					subqueryCol.AliasName = cols[i]
+				}
+			}
+			results = append(results, &SubQueryInfo{
+				Name: name,
+				Views: []*SubQueryView{
+					{
+						SubQueryColumns: subqueryCols,
+					},
+				},
+			})
+		}
+		toks = toks[3:]
+		if len(toks) == 0 {
+			break
+		}
+		tok, ok := toks[0].(ast.Token)
+		if !ok || tok.GetToken().Kind != token.Comma {
+			break
+		}
+		toks = toks[1:]
+	}
+	return results, nil
+}
@@ -0,0 +1,96 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/token"
+)
+
+func TestExtractCTEs(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		pos   token.Pos
+		want  []*SubQueryInfo
+	}{
+		{
+			name:  "not with",
+			input: "SELECT ID FROM city",
+			pos:   token.Pos{Line: 0, Col: 7},
+			want:  nil,
+		},
+		{
+			name:  "single",
+			input: "WITH it AS (SELECT c.ID FROM city c) SELECT i.ID FROM it i",
+			pos:   token.Pos{Line: 0, Col: 45},
+			want: []*SubQueryInfo{
+				{
+					Name: "it",
+					Views: []*SubQueryView{
+						{
+							SubQueryColumns: []*SubQueryColumn{
+								{
+									ParentTable: &TableInfo{Name: "city", Alias: "c"},
+									ParentName:  "c",
+									ColumnName:  "ID",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "recursive with column list",
+			input: "WITH RECURSIVE it(a, b) AS (SELECT ID, Name FROM city), n AS (SELECT 1 AS x), co AS (SELECT Code FROM country) SELECT * FROM it",
+			pos:   token.Pos{Line: 0, Col: 113},
+			want: []*SubQueryInfo{
+				{
+					Name: "it",
+					Views: []*SubQueryView{
+						{
+							SubQueryColumns: []*SubQueryColumn{
+								{
+									ParentTable: &TableInfo{Name: "city"},
+									ColumnName:  "ID",
+									AliasName:   "a",
+								},
+								{
+									ParentTable: &TableInfo{Name: "city"},
+									ColumnName:  "Name",
+									AliasName:   "b",
+								},
+							},
+						},
+					},
+				},
+				{
+					Name: "co",
+					Views: []*SubQueryView{
+						{
+							SubQueryColumns: []*SubQueryColumn{
+								{
+									ParentTable: &TableInfo{Name: "country"},
+									ColumnName:  "Code",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			got, err := ExtractCTEs(query, tt.pos)
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched value(- want, + got): %s", d)
+			}
+		})
+	}
+}
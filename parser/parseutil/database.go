@@ -0,0 +1,98 @@
+package parseutil
+
+import (
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// DatabasePosition is the part of the statement on a database at the
+// position.
+type DatabasePosition string
+
+const (
+	// DatabaseKeyword is where only the keywords of the syntax follow
+	DatabaseKeyword DatabasePosition = "database_keyword"
+	// DatabaseName is the name of a database, which the keywords may precede
+	DatabaseName DatabasePosition = "database_name"
+)
+
+// Database is the statement on a database at the position, USE, CREATE,
+// ALTER and DROP DATABASE, or ATTACH and DETACH of SQLite.
+type Database struct {
+	Position DatabasePosition
+	// Create is true when the name is of a new database, which is a
+	// duplicate if the database exists
+	Create bool
+	// Keywords are the keywords following at the position
+	Keywords []string
+}
+
+// ExtractDatabase returns the statement on a database at the position, or nil
+// if the position is not where the name of a database or its keywords follow.
+func ExtractDatabase(parsed ast.TokenList, pos token.Pos) (*Database, error) {
+	stmt, err := extractFocusedStatement(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	r := &ddlReader{toks: ddlTokens(stmt, pos)}
+	switch {
+	case r.accept("USE"):
+		if r.done() {
+			return &Database{Position: DatabaseName}, nil
+		}
+	case r.accept("CREATE"):
+		if r.accept("DATABASE") {
+			return r.databaseName(&Database{Create: true}, []string{"IF", "NOT", "EXISTS"}), nil
+		}
+	case r.accept("DROP"):
+		if r.accept("DATABASE") {
+			return r.databaseName(&Database{}, []string{"IF", "EXISTS"}), nil
+		}
+	case r.accept("ALTER"):
+		if r.accept("DATABASE") && r.done() {
+			return &Database{Position: DatabaseName}, nil
+		}
+	case r.accept("ATTACH"):
+		// ATTACH DATABASE 'file' AS name
+		for !r.done() && !r.is("AS") {
+			r.i++
+		}
+		if r.accept("AS") && r.done() {
+			return &Database{Position: DatabaseName, Create: true}, nil
+		}
+	case r.accept("DETACH"):
+		if r.done() {
+			return &Database{Position: DatabaseName, Keywords: []string{"DATABASE"}}, nil
+		}
+		if r.accept("DATABASE") && r.done() {
+			return &Database{Position: DatabaseName}, nil
+		}
+	}
+	return nil, nil
+}
+
+// databaseName reads the words of the condition, IF NOT EXISTS or IF EXISTS,
+// before the name of the database.
+func (r *ddlReader) databaseName(d *Database, condition []string) *Database {
+	for i, word := range condition {
+		switch {
+		case r.done() && i == 0:
+			d.Position = DatabaseName
+			d.Keywords = []string{strings.Join(condition, " ")}
+			return d
+		case r.done():
+			d.Position = DatabaseKeyword
+			d.Keywords = []string{strings.Join(condition[i:], " ")}
+			return d
+		case !r.accept(word):
+			return nil
+		}
+	}
+	if !r.done() {
+		return nil
+	}
+	d.Position = DatabaseName
+	return d
+}
@@ -0,0 +1,102 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/token"
+)
+
+func TestExtractDatabase(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		want  *Database
+	}{
+		{
+			name:  "not database",
+			input: "SELECT * FROM ",
+			want:  nil,
+		},
+		{
+			name:  "use",
+			input: "USE wor",
+			want:  &Database{Position: DatabaseName},
+		},
+		{
+			name:  "use ended",
+			input: "USE world ",
+			want:  nil,
+		},
+		{
+			name:  "create database",
+			input: "CREATE DATABASE ",
+			want: &Database{
+				Position: DatabaseName,
+				Create:   true,
+				Keywords: []string{"IF NOT EXISTS"},
+			},
+		},
+		{
+			name:  "create database if",
+			input: "CREATE DATABASE IF ",
+			want: &Database{
+				Position: DatabaseKeyword,
+				Create:   true,
+				Keywords: []string{"NOT EXISTS"},
+			},
+		},
+		{
+			name:  "create database if not exists",
+			input: "CREATE DATABASE IF NOT EXISTS w",
+			want:  &Database{Position: DatabaseName, Create: true},
+		},
+		{
+			name:  "create table",
+			input: "CREATE TABLE ",
+			want:  nil,
+		},
+		{
+			name:  "drop database if exists",
+			input: "DROP DATABASE IF EXISTS ",
+			want:  &Database{Position: DatabaseName},
+		},
+		{
+			name:  "alter database",
+			input: "ALTER DATABASE ",
+			want:  &Database{Position: DatabaseName},
+		},
+		{
+			name:  "attach as",
+			input: "ATTACH DATABASE 'archive.db' AS ",
+			want:  &Database{Position: DatabaseName, Create: true},
+		},
+		{
+			name:  "attach file",
+			input: "ATTACH DATABASE ",
+			want:  nil,
+		},
+		{
+			name:  "detach",
+			input: "DETACH ",
+			want:  &Database{Position: DatabaseName, Keywords: []string{"DATABASE"}},
+		},
+		{
+			name:  "detach database",
+			input: "DETACH DATABASE ",
+			want:  &Database{Position: DatabaseName},
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			got, err := ExtractDatabase(query, token.Pos{Line: 0, Col: len(tt.input)})
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched database (- want, + got): %s", d)
+			}
+		})
+	}
+}
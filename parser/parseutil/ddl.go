@@ -0,0 +1,565 @@
+package parseutil
+
+import (
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// DDLPosition is the part of the definition of CREATE TABLE or ALTER TABLE
+// at the position.
+type DDLPosition string
+
+const (
+	// DDLKeyword is where only the keywords of the syntax follow
+	DDLKeyword DDLPosition = "ddl_keyword"
+	// DDLDataType is the data type of a column
+	DDLDataType DDLPosition = "data_type"
+	// DDLTable is the table of ALTER TABLE and REFERENCES
+	DDLTable DDLPosition = "ddl_table"
+	// DDLTableColumn is a column of the table created or altered
+	DDLTableColumn DDLPosition = "table_column"
+	// DDLReferencedColumn is a column of the table of REFERENCES
+	DDLReferencedColumn DDLPosition = "referenced_column"
+)
+
+// DDL is the definition of the table at the position.
+type DDL struct {
+	Position DDLPosition
+	// Table is the table created or altered
+	Table *TableInfo
+	// Columns are the columns defined before the position in CREATE TABLE
+	Columns []string
+	// Referenced is the table of REFERENCES
+	Referenced *TableInfo
+	// Keywords are the keywords following at DDLKeyword
+	Keywords []string
+}
+
+var (
+	tableElementKeywords = []string{
+		"CONSTRAINT",
+		"PRIMARY KEY",
+		"FOREIGN KEY",
+		"UNIQUE",
+		"CHECK",
+	}
+	columnConstraintKeywords = []string{
+		"NOT NULL",
+		"NULL",
+		"PRIMARY KEY",
+		"UNIQUE",
+		"DEFAULT",
+		"REFERENCES",
+		"CHECK",
+		"CONSTRAINT",
+		"COLLATE",
+		"GENERATED",
+	}
+	referentialTriggerKeywords = []string{
+		"ON DELETE",
+		"ON UPDATE",
+	}
+	referentialActionKeywords = []string{
+		"CASCADE",
+		"SET NULL",
+		"SET DEFAULT",
+		"RESTRICT",
+		"NO ACTION",
+	}
+	alterTableKeywords = []string{
+		"ADD",
+		"ADD COLUMN",
+		"ADD CONSTRAINT",
+		"DROP COLUMN",
+		"DROP CONSTRAINT",
+		"ALTER COLUMN",
+		"RENAME COLUMN",
+		"RENAME TO",
+	}
+	alterColumnKeywords = []string{
+		"TYPE",
+		"SET DEFAULT",
+		"DROP DEFAULT",
+		"SET NOT NULL",
+		"DROP NOT NULL",
+	}
+)
+
+// tableConstraintWords are the words starting the constraints of the tables,
+// not the column definitions.
+var tableConstraintWords = []string{
+	"PRIMARY",
+	"FOREIGN",
+	"UNIQUE",
+	"CHECK",
+	"KEY",
+	"INDEX",
+}
+
+// columnConstraintWords are the words ending the data types of the column
+// definitions.
+var columnConstraintWords = []string{
+	"CONSTRAINT",
+	"NOT",
+	"NULL",
+	"PRIMARY",
+	"UNIQUE",
+	"DEFAULT",
+	"REFERENCES",
+	"CHECK",
+	"COLLATE",
+	"GENERATED",
+	"AUTO_INCREMENT",
+	"AUTOINCREMENT",
+	"IDENTITY",
+	"COMMENT",
+	"ON",
+	"AS",
+}
+
+// nextWords are the words after the constraint words that do not complete
+// the constraints.
+var nextWords = map[string][]string{
+	"NOT":       {"NULL"},
+	"PRIMARY":   {"KEY"},
+	"FOREIGN":   {"KEY"},
+	"GENERATED": {"ALWAYS", "BY DEFAULT"},
+}
+
+// valueWords are the words followed by the values or the names, where no
+// keyword is completed.
+var valueWords = map[string]bool{
+	"CONSTRAINT": true,
+	"DEFAULT":    true,
+	"COLLATE":    true,
+	"COMMENT":    true,
+	"AS":         true,
+}
+
+// ExtractDDL returns the definition of CREATE TABLE or ALTER TABLE at the
+// position, or nil if the position is not in them.
+func ExtractDDL(parsed ast.TokenList, pos token.Pos) (*DDL, error) {
+	stmt, err := extractFocusedStatement(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	r := &ddlReader{toks: ddlTokens(stmt, pos)}
+	switch {
+	case r.accept("CREATE"):
+		if r.accept("OR") {
+			r.accept("REPLACE")
+		}
+		for r.accept("GLOBAL", "LOCAL", "TEMP", "TEMPORARY", "UNLOGGED") {
+		}
+		if !r.accept("TABLE") {
+			return nil, nil
+		}
+		return r.createTable(), nil
+	case r.accept("ALTER"):
+		if !r.accept("TABLE") {
+			return nil, nil
+		}
+		return r.alterTable(), nil
+	}
+	return nil, nil
+}
+
+// ddlTokens returns the tokens of the statement before the position without
+// the whitespaces, the comments and the word at the position that is being
+// typed.
+func ddlTokens(stmt ast.TokenList, pos token.Pos) []*ast.SQLToken {
+	toks := []*ast.SQLToken{}
+	var walk func(node ast.Node)
+	walk = func(node ast.Node) {
+		if list, ok := node.(ast.TokenList); ok {
+			for _, n := range list.GetTokens() {
+				walk(n)
+			}
+			return
+		}
+		tok, ok := node.(ast.Token)
+		if !ok || token.ComparePos(tok.GetToken().To, pos) > 0 {
+			return
+		}
+		switch tok.GetToken().Kind {
+		case token.Whitespace, token.Comment, token.MultilineComment:
+			return
+		}
+		toks = append(toks, tok.GetToken())
+	}
+	walk(stmt)
+	if len(toks) > 0 {
+		last := toks[len(toks)-1]
+		if last.Kind == token.SQLKeyword && token.ComparePos(last.To, pos) == 0 {
+			toks = toks[:len(toks)-1]
+		}
+	}
+	return toks
+}
+
+type ddlReader struct {
+	toks []*ast.SQLToken
+	i    int
+}
+
+func (r *ddlReader) done() bool {
+	return r.i >= len(r.toks)
+}
+
+func (r *ddlReader) kind() token.Kind {
+	return r.toks[r.i].Kind
+}
+
+// word returns the current word in upper case, or "" for the other tokens
+// and the quoted identifiers.
+func (r *ddlReader) word() string {
+	if r.done() || r.kind() != token.SQLKeyword {
+		return ""
+	}
+	w, ok := r.toks[r.i].Value.(*token.SQLWord)
+	if !ok || w.QuoteStyle != 0 {
+		return ""
+	}
+	return strings.ToUpper(w.Value)
+}
+
+func (r *ddlReader) is(words ...string) bool {
+	word := r.word()
+	for _, w := range words {
+		if word == w {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ddlReader) accept(words ...string) bool {
+	if !r.is(words...) {
+		return false
+	}
+	r.i++
+	return true
+}
+
+// prevWord returns the word before the current token in upper case.
+func (r *ddlReader) prevWord() string {
+	prev := &ddlReader{toks: r.toks, i: r.i - 1}
+	if prev.i < 0 {
+		return ""
+	}
+	return prev.word()
+}
+
+// name reads the name of a table qualified by the schema, or returns nil
+// when the name is not ended.
+func (r *ddlReader) name() *TableInfo {
+	if r.done() || r.kind() != token.SQLKeyword {
+		return nil
+	}
+	table := &TableInfo{Name: r.toks[r.i].NoQuoteString()}
+	r.i++
+	if r.done() || r.kind() != token.Period {
+		return table
+	}
+	r.i++
+	if r.done() || r.kind() != token.SQLKeyword {
+		return nil
+	}
+	table.DatabaseSchema = table.Name
+	table.Name = r.toks[r.i].NoQuoteString()
+	r.i++
+	return table
+}
+
+// skipParens skips the tokens in the parentheses at the current token, and
+// reports whether they are closed.
+func (r *ddlReader) skipParens() bool {
+	depth := 0
+	for ; !r.done(); r.i++ {
+		switch r.kind() {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+			if depth == 0 {
+				r.i++
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// elements splits the tokens from the current token at the commas out of
+// the parentheses. closed is true when the parentheses enclosing the
+// elements are closed before the position.
+func (r *ddlReader) elements() (elems []*ddlReader, closed bool) {
+	depth := 0
+	start := r.i
+	for ; !r.done(); r.i++ {
+		switch r.kind() {
+		case token.LParen:
+			depth++
+		case token.RParen:
+			depth--
+			if depth < 0 {
+				return elems, true
+			}
+		case token.Comma:
+			if depth == 0 {
+				elems = append(elems, &ddlReader{toks: r.toks[start:r.i]})
+				start = r.i + 1
+			}
+		}
+	}
+	return append(elems, &ddlReader{toks: r.toks[start:]}), false
+}
+
+func (r *ddlReader) createTable() *DDL {
+	if r.accept("IF") {
+		r.accept("NOT")
+		r.accept("EXISTS")
+	}
+	table := r.name()
+	// CREATE TABLE ... AS SELECT is not completed here
+	if table == nil || r.done() || r.kind() != token.LParen {
+		return nil
+	}
+	r.i++
+	elems, closed := r.elements()
+	if closed {
+		return nil
+	}
+	ddl := &DDL{Table: table}
+	for _, elem := range elems[:len(elems)-1] {
+		if !elem.done() && elem.kind() == token.SQLKeyword && !elem.is(append(tableConstraintWords, "CONSTRAINT")...) {
+			ddl.Columns = append(ddl.Columns, elem.toks[0].NoQuoteString())
+		}
+	}
+	elem := elems[len(elems)-1]
+	if elem.done() {
+		return ddl.keywords(tableElementKeywords)
+	}
+	return ddl.definition(elem)
+}
+
+func (r *ddlReader) alterTable() *DDL {
+	if r.accept("IF") {
+		r.accept("EXISTS")
+	}
+	r.accept("ONLY")
+	if r.done() {
+		return &DDL{Position: DDLTable}
+	}
+	table := r.name()
+	if table == nil {
+		return nil
+	}
+	ddl := &DDL{Table: table}
+	actions, _ := r.elements()
+	action := actions[len(actions)-1]
+	if action.done() {
+		return ddl.keywords(alterTableKeywords)
+	}
+	switch {
+	case action.accept("ADD"):
+		if action.done() {
+			return ddl.keywords(append([]string{"COLUMN"}, tableElementKeywords...))
+		}
+		action.accept("COLUMN")
+		if action.accept("IF") {
+			action.accept("NOT")
+			action.accept("EXISTS")
+		}
+		if action.done() {
+			return nil
+		}
+		return ddl.definition(action)
+	case action.accept("DROP"):
+		if action.done() {
+			return ddl.keywords([]string{"COLUMN", "CONSTRAINT"})
+		}
+		if !action.accept("COLUMN") {
+			return nil
+		}
+		if action.accept("IF") {
+			action.accept("EXISTS")
+		}
+		if action.done() {
+			return ddl.at(DDLTableColumn)
+		}
+	case action.accept("ALTER"):
+		action.accept("COLUMN")
+		if action.done() {
+			return ddl.at(DDLTableColumn)
+		}
+		action.i++
+		if action.done() {
+			return ddl.keywords(alterColumnKeywords)
+		}
+		if action.accept("SET") {
+			action.accept("DATA")
+		}
+		if action.accept("TYPE") && action.done() {
+			return ddl.at(DDLDataType)
+		}
+	case action.accept("MODIFY"):
+		action.accept("COLUMN")
+		if action.done() {
+			return ddl.at(DDLTableColumn)
+		}
+		return ddl.column(action)
+	case action.accept("RENAME"):
+		if action.done() {
+			return ddl.keywords([]string{"COLUMN", "TO"})
+		}
+		if action.accept("COLUMN") && action.done() {
+			return ddl.at(DDLTableColumn)
+		}
+	}
+	return nil
+}
+
+func (ddl *DDL) at(position DDLPosition) *DDL {
+	ddl.Position = position
+	return ddl
+}
+
+func (ddl *DDL) keywords(keywords []string) *DDL {
+	ddl.Keywords = keywords
+	return ddl.at(DDLKeyword)
+}
+
+// definition returns the position in the column definition or the table
+// constraint.
+func (ddl *DDL) definition(r *ddlReader) *DDL {
+	if r.accept("CONSTRAINT") {
+		if r.done() {
+			return nil
+		}
+		r.i++
+		if r.done() {
+			return ddl.keywords(tableElementKeywords[1:])
+		}
+	}
+	if r.is(tableConstraintWords...) {
+		return ddl.tableConstraint(r)
+	}
+	return ddl.column(r)
+}
+
+// column returns the position in the column definition from the column name.
+func (ddl *DDL) column(r *ddlReader) *DDL {
+	r.i++
+	if r.done() {
+		return ddl.at(DDLDataType)
+	}
+	// The data type with its arguments and the words such as DOUBLE
+	// PRECISION and WITH TIME ZONE
+	for !r.done() && !r.is(columnConstraintWords...) {
+		if r.kind() != token.LParen {
+			r.i++
+			continue
+		}
+		if !r.skipParens() {
+			return nil
+		}
+	}
+	return ddl.constraints(r, columnConstraintKeywords)
+}
+
+// tableConstraint returns the position in PRIMARY KEY, UNIQUE, FOREIGN KEY
+// and CHECK of the table.
+func (ddl *DDL) tableConstraint(r *ddlReader) *DDL {
+	foreign := r.is("FOREIGN")
+	for !r.done() && r.kind() != token.LParen {
+		r.i++
+	}
+	if r.done() {
+		if next, ok := nextWords[r.prevWord()]; ok {
+			return ddl.keywords(next)
+		}
+		return nil
+	}
+	if !r.skipParens() {
+		return ddl.at(DDLTableColumn)
+	}
+	if !foreign {
+		return nil
+	}
+	if r.done() {
+		return ddl.keywords([]string{"REFERENCES"})
+	}
+	return ddl.constraints(r, nil)
+}
+
+// constraints returns the position in the constraints of the column or after
+// FOREIGN KEY. keywords are the ones of the constraints following the ended
+// ones.
+func (ddl *DDL) constraints(r *ddlReader, keywords []string) *DDL {
+	referenced := false
+	for !r.done() {
+		switch {
+		case r.accept("REFERENCES"):
+			if r.done() {
+				return ddl.at(DDLTable)
+			}
+			ddl.Referenced = r.name()
+			if ddl.Referenced == nil {
+				return nil
+			}
+			referenced = true
+			if !r.done() && r.kind() == token.LParen && !r.skipParens() {
+				return ddl.at(DDLReferencedColumn)
+			}
+		case r.accept("ON"):
+			if r.done() {
+				return ddl.keywords([]string{"DELETE", "UPDATE"})
+			}
+			if !r.accept("DELETE", "UPDATE") {
+				continue
+			}
+			if r.done() {
+				return ddl.keywords(referentialActionKeywords)
+			}
+			switch {
+			case r.accept("SET"):
+				if r.done() {
+					return ddl.keywords([]string{"NULL", "DEFAULT"})
+				}
+			case r.accept("NO"):
+				if r.done() {
+					return ddl.keywords([]string{"ACTION"})
+				}
+			}
+			r.i++
+		case r.accept("CHECK"):
+			if !r.done() && r.kind() == token.LParen && !r.skipParens() {
+				return ddl.at(DDLTableColumn)
+			}
+		default:
+			if r.kind() == token.LParen {
+				if !r.skipParens() {
+					return nil
+				}
+				continue
+			}
+			r.i++
+		}
+	}
+	prev := r.prevWord()
+	if next, ok := nextWords[prev]; ok {
+		return ddl.keywords(next)
+	}
+	if valueWords[prev] || prev == "CHECK" {
+		return nil
+	}
+	if referenced {
+		keywords = append(append([]string{}, keywords...), referentialTriggerKeywords...)
+	} else if keywords == nil {
+		keywords = []string{"REFERENCES"}
+	}
+	return ddl.keywords(keywords)
+}
@@ -0,0 +1,189 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/token"
+)
+
+func TestExtractDDL(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		want  *DDL
+	}{
+		{
+			name:  "not ddl",
+			input: "SELECT * FROM city WHERE ",
+			want:  nil,
+		},
+		{
+			name:  "table element",
+			input: "CREATE TABLE city2 (",
+			want: &DDL{
+				Position: DDLKeyword,
+				Table:    &TableInfo{Name: "city2"},
+				Keywords: tableElementKeywords,
+			},
+		},
+		{
+			name:  "data type",
+			input: "CREATE TABLE IF NOT EXISTS world.city2 (ID int, Name va",
+			want: &DDL{
+				Position: DDLDataType,
+				Table:    &TableInfo{DatabaseSchema: "world", Name: "city2"},
+				Columns:  []string{"ID"},
+			},
+		},
+		{
+			name:  "type arguments",
+			input: "CREATE TABLE city2 (Name varchar(",
+			want:  nil,
+		},
+		{
+			name:  "column constraint",
+			input: "CREATE TEMPORARY TABLE city2 (Amount double precision ",
+			want: &DDL{
+				Position: DDLKeyword,
+				Table:    &TableInfo{Name: "city2"},
+				Keywords: columnConstraintKeywords,
+			},
+		},
+		{
+			name:  "not null",
+			input: "CREATE TABLE city2 (ID int NOT ",
+			want: &DDL{
+				Position: DDLKeyword,
+				Table:    &TableInfo{Name: "city2"},
+				Keywords: []string{"NULL"},
+			},
+		},
+		{
+			name:  "default value",
+			input: "CREATE TABLE city2 (ID int DEFAULT ",
+			want:  nil,
+		},
+		{
+			name:  "references table",
+			input: "CREATE TABLE city2 (CountryCode char(3) NOT NULL REFERENCES ",
+			want: &DDL{
+				Position: DDLTable,
+				Table:    &TableInfo{Name: "city2"},
+			},
+		},
+		{
+			name:  "references column",
+			input: "CREATE TABLE city2 (CountryCode char(3) REFERENCES country (",
+			want: &DDL{
+				Position:   DDLReferencedColumn,
+				Table:      &TableInfo{Name: "city2"},
+				Referenced: &TableInfo{Name: "country"},
+			},
+		},
+		{
+			name:  "after references",
+			input: "CREATE TABLE city2 (CountryCode char(3) REFERENCES country(Code) ",
+			want: &DDL{
+				Position:   DDLKeyword,
+				Table:      &TableInfo{Name: "city2"},
+				Referenced: &TableInfo{Name: "country"},
+				Keywords:   append(append([]string{}, columnConstraintKeywords...), referentialTriggerKeywords...),
+			},
+		},
+		{
+			name:  "referential action",
+			input: "CREATE TABLE city2 (CountryCode char(3) REFERENCES country(Code) ON UPDATE CASCADE ON DELETE ",
+			want: &DDL{
+				Position:   DDLKeyword,
+				Table:      &TableInfo{Name: "city2"},
+				Referenced: &TableInfo{Name: "country"},
+				Keywords:   referentialActionKeywords,
+			},
+		},
+		{
+			name:  "primary key columns",
+			input: "CREATE TABLE city2 (ID int, Name text, CONSTRAINT pk PRIMARY KEY (ID, ",
+			want: &DDL{
+				Position: DDLTableColumn,
+				Table:    &TableInfo{Name: "city2"},
+				Columns:  []string{"ID", "Name"},
+			},
+		},
+		{
+			name:  "foreign key",
+			input: "CREATE TABLE city2 (CountryCode char(3), FOREIGN KEY (CountryCode) ",
+			want: &DDL{
+				Position: DDLKeyword,
+				Table:    &TableInfo{Name: "city2"},
+				Columns:  []string{"CountryCode"},
+				Keywords: []string{"REFERENCES"},
+			},
+		},
+		{
+			name:  "after definitions",
+			input: "CREATE TABLE city2 (ID int) ",
+			want:  nil,
+		},
+		{
+			name:  "alter table",
+			input: "ALTER TABLE ",
+			want: &DDL{
+				Position: DDLTable,
+			},
+		},
+		{
+			name:  "alter table action",
+			input: "ALTER TABLE city ",
+			want: &DDL{
+				Position: DDLKeyword,
+				Table:    &TableInfo{Name: "city"},
+				Keywords: alterTableKeywords,
+			},
+		},
+		{
+			name:  "drop column",
+			input: "ALTER TABLE city ADD COLUMN Area int, DROP COLUMN IF EXISTS ",
+			want: &DDL{
+				Position: DDLTableColumn,
+				Table:    &TableInfo{Name: "city"},
+			},
+		},
+		{
+			name:  "add column type",
+			input: "ALTER TABLE city ADD Area ",
+			want: &DDL{
+				Position: DDLDataType,
+				Table:    &TableInfo{Name: "city"},
+			},
+		},
+		{
+			name:  "alter column type",
+			input: "ALTER TABLE city ALTER COLUMN Name TYPE ",
+			want: &DDL{
+				Position: DDLDataType,
+				Table:    &TableInfo{Name: "city"},
+			},
+		},
+		{
+			name:  "add foreign key",
+			input: "ALTER TABLE city ADD CONSTRAINT fk FOREIGN KEY (CountryCode) REFERENCES ",
+			want: &DDL{
+				Position: DDLTable,
+				Table:    &TableInfo{Name: "city"},
+			},
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			got, err := ExtractDDL(query, token.Pos{Line: 0, Col: len(tt.input)})
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched ddl (- want, + got): %s", d)
+			}
+		})
+	}
+}
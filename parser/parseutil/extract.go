@@ -69,6 +69,8 @@ func ExtractTableReference(parsed ast.TokenList) []ast.Node {
 		ExpectKeyword: []string{
 			"INSERT INTO",
 			"DELETE FROM",
+			"MERGE INTO",
+			"USING",
 		},
 	}
 	peekMatcher := astutil.NodeMatcher{
@@ -92,6 +94,7 @@ func ExtractTableFactor(parsed ast.TokenList) []ast.Node {
 			"RIGHT JOIN",
 			"LEFT OUTER JOIN",
 			"RIGHT OUTER JOIN",
+			"LATERAL",
 		},
 	}
 	peekMatcher := astutil.NodeMatcher{
@@ -0,0 +1,250 @@
+package parseutil
+
+import (
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// GrantPosition is the part of GRANT, REVOKE or the role statements at the
+// position.
+type GrantPosition string
+
+const (
+	// GrantKeyword is where only the keywords of the syntax follow
+	GrantKeyword GrantPosition = "grant_keyword"
+	// GrantPrivilege is a privilege or a role granted or revoked
+	GrantPrivilege GrantPosition = "privilege"
+	// GrantObject is the object of ON, or its type
+	GrantObject GrantPosition = "grant_object"
+	// GrantTable is the table of ON TABLE
+	GrantTable GrantPosition = "grant_table"
+	// GrantSchema is the schema of ON SCHEMA and IN SCHEMA
+	GrantSchema GrantPosition = "grant_schema"
+	// GrantRole is a role or a user
+	GrantRole GrantPosition = "role"
+)
+
+// Grant is the privilege statement at the position.
+type Grant struct {
+	Position GrantPosition
+	// Keywords are the keywords following at the position
+	Keywords []string
+}
+
+var (
+	privilegeKeywords = []string{
+		"ALL PRIVILEGES",
+		"SELECT",
+		"INSERT",
+		"UPDATE",
+		"DELETE",
+		"TRUNCATE",
+		"REFERENCES",
+		"TRIGGER",
+		"CREATE",
+		"CONNECT",
+		"TEMPORARY",
+		"EXECUTE",
+		"USAGE",
+	}
+	objectKeywords = []string{
+		"TABLE",
+		"SCHEMA",
+		"SEQUENCE",
+		"FUNCTION",
+		"PROCEDURE",
+		"DATABASE",
+		"ALL TABLES IN SCHEMA",
+		"ALL SEQUENCES IN SCHEMA",
+		"ALL FUNCTIONS IN SCHEMA",
+	}
+	allObjectKeywords = []string{
+		"TABLES IN SCHEMA",
+		"SEQUENCES IN SCHEMA",
+		"FUNCTIONS IN SCHEMA",
+	}
+	granteeKeywords = []string{
+		"PUBLIC",
+	}
+)
+
+// ExtractGrant returns the privilege statement at the position, or nil if
+// the position is not in GRANT, REVOKE, SET ROLE nor the statements dropping
+// or altering the roles and the users.
+func ExtractGrant(parsed ast.TokenList, pos token.Pos) (*Grant, error) {
+	stmt, err := extractFocusedStatement(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	r := &ddlReader{toks: ddlTokens(stmt, pos)}
+	switch {
+	case r.accept("GRANT"):
+		return r.grant("TO"), nil
+	case r.accept("REVOKE"):
+		return r.grant("FROM"), nil
+	case r.accept("SET"):
+		if r.accept("ROLE") && r.done() {
+			return &Grant{Position: GrantRole}, nil
+		}
+	case r.accept("ALTER"):
+		if r.accept("ROLE", "USER") && r.done() {
+			return &Grant{Position: GrantRole}, nil
+		}
+	case r.accept("DROP"):
+		if !r.accept("ROLE", "USER") {
+			return nil, nil
+		}
+		if r.accept("IF") {
+			r.accept("EXISTS")
+		}
+		if r.done() {
+			return &Grant{Position: GrantRole}, nil
+		}
+		if names, _ := r.elements(); len(names) > 1 && names[len(names)-1].done() {
+			return &Grant{Position: GrantRole}, nil
+		}
+	}
+	return nil, nil
+}
+
+// grant returns the position in GRANT and REVOKE. to is the word preceding
+// the grantees, TO or FROM.
+func (r *ddlReader) grant(to string) *Grant {
+	revoke := to == "FROM"
+	if revoke && r.accept("GRANT", "ADMIN") {
+		if r.done() {
+			return keywordsOf([]string{"OPTION FOR"})
+		}
+		r.accept("OPTION")
+		if r.done() {
+			return keywordsOf([]string{"FOR"})
+		}
+		r.accept("FOR")
+	}
+
+	// The privileges, or the roles granted to the grantees
+	start := r.i
+	for !r.done() && !r.is("ON", to) {
+		if r.kind() != token.LParen {
+			r.i++
+			continue
+		}
+		// The columns of the privileges are not completed
+		if !r.skipParens() {
+			return nil
+		}
+	}
+	if r.done() {
+		if r.i == start || r.toks[r.i-1].Kind == token.Comma {
+			keywords := privilegeKeywords
+			if revoke && r.i == start {
+				keywords = append([]string{"GRANT OPTION FOR"}, keywords...)
+			}
+			return &Grant{Position: GrantPrivilege, Keywords: keywords}
+		}
+		if r.prevWord() == "ALL" {
+			return keywordsOf([]string{"PRIVILEGES", "ON"})
+		}
+		return keywordsOf([]string{"ON", to})
+	}
+
+	onObject := r.accept("ON")
+	if onObject {
+		if g, ended := r.object(to); !ended {
+			return g
+		}
+	}
+
+	// The grantees
+	r.accept(to)
+	start = r.i
+	for !r.done() && !r.is("WITH", "GRANTED", "CASCADE", "RESTRICT") {
+		r.i++
+	}
+	if r.done() {
+		if r.i == start || r.toks[r.i-1].Kind == token.Comma {
+			return &Grant{Position: GrantRole, Keywords: granteeKeywords}
+		}
+		switch {
+		case revoke:
+			return keywordsOf([]string{"CASCADE", "RESTRICT"})
+		case onObject:
+			return keywordsOf([]string{"WITH GRANT OPTION"})
+		}
+		return keywordsOf([]string{"WITH ADMIN OPTION"})
+	}
+	if r.accept("WITH") && r.done() {
+		if onObject {
+			return keywordsOf([]string{"GRANT OPTION"})
+		}
+		return keywordsOf([]string{"ADMIN OPTION"})
+	}
+	return nil
+}
+
+// object returns the position in the object of ON. ended is true when the
+// position is after the object.
+func (r *ddlReader) object(to string) (g *Grant, ended bool) {
+	if r.done() {
+		return &Grant{Position: GrantObject, Keywords: objectKeywords}, false
+	}
+	position := GrantTable
+	switch {
+	case r.accept("ALL"):
+		if r.done() {
+			return keywordsOf(allObjectKeywords), false
+		}
+		r.i++
+		if r.done() {
+			return keywordsOf([]string{"IN SCHEMA"}), false
+		}
+		r.accept("IN")
+		if r.done() {
+			return keywordsOf([]string{"SCHEMA"}), false
+		}
+		r.accept("SCHEMA")
+		position = GrantSchema
+	case r.accept("SCHEMA"):
+		position = GrantSchema
+	case r.accept("TABLE"):
+	case r.accept("SEQUENCE", "FUNCTION", "PROCEDURE", "DATABASE"):
+		// The objects other than the tables and the schemas are not cached
+		position = ""
+	}
+	if r.done() {
+		if position == "" {
+			return nil, false
+		}
+		return &Grant{Position: position}, false
+	}
+
+	// The names of the objects
+	for !r.done() && !r.is(to) {
+		if r.kind() != token.LParen {
+			r.i++
+			continue
+		}
+		if !r.skipParens() {
+			return nil, false
+		}
+	}
+	if !r.done() {
+		return nil, true
+	}
+	switch r.toks[r.i-1].Kind {
+	case token.Comma:
+		if position == "" {
+			return nil, false
+		}
+		return &Grant{Position: position}, false
+	case token.Period, token.Mult:
+		// The tables qualified by the schema are completed as the ones of
+		// the other statements
+		return nil, false
+	}
+	return keywordsOf([]string{to}), false
+}
+
+func keywordsOf(keywords []string) *Grant {
+	return &Grant{Position: GrantKeyword, Keywords: keywords}
+}
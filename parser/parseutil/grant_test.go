@@ -0,0 +1,137 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/token"
+)
+
+func TestExtractGrant(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		want  *Grant
+	}{
+		{
+			name:  "not grant",
+			input: "SELECT * FROM city WHERE ",
+			want:  nil,
+		},
+		{
+			name:  "privilege",
+			input: "GRANT ",
+			want:  &Grant{Position: GrantPrivilege, Keywords: privilegeKeywords},
+		},
+		{
+			name:  "typing privilege",
+			input: "GRANT SELECT, INS",
+			want:  &Grant{Position: GrantPrivilege, Keywords: privilegeKeywords},
+		},
+		{
+			name:  "revoke privilege",
+			input: "REVOKE ",
+			want: &Grant{
+				Position: GrantPrivilege,
+				Keywords: append([]string{"GRANT OPTION FOR"}, privilegeKeywords...),
+			},
+		},
+		{
+			name:  "after privilege",
+			input: "GRANT SELECT (ID, Name) ",
+			want:  &Grant{Position: GrantKeyword, Keywords: []string{"ON", "TO"}},
+		},
+		{
+			name:  "all privileges",
+			input: "GRANT ALL ",
+			want:  &Grant{Position: GrantKeyword, Keywords: []string{"PRIVILEGES", "ON"}},
+		},
+		{
+			name:  "object",
+			input: "GRANT SELECT ON ",
+			want:  &Grant{Position: GrantObject, Keywords: objectKeywords},
+		},
+		{
+			name:  "table",
+			input: "GRANT SELECT ON TABLE city, ",
+			want:  &Grant{Position: GrantTable},
+		},
+		{
+			name:  "schema",
+			input: "GRANT USAGE ON SCHEMA ",
+			want:  &Grant{Position: GrantSchema},
+		},
+		{
+			name:  "all tables in schema",
+			input: "GRANT SELECT ON ALL TABLES IN SCHEMA ",
+			want:  &Grant{Position: GrantSchema},
+		},
+		{
+			name:  "all objects",
+			input: "GRANT SELECT ON ALL ",
+			want:  &Grant{Position: GrantKeyword, Keywords: allObjectKeywords},
+		},
+		{
+			name:  "qualified table",
+			input: "GRANT SELECT ON world.",
+			want:  nil,
+		},
+		{
+			name:  "function",
+			input: "GRANT EXECUTE ON FUNCTION ",
+			want:  nil,
+		},
+		{
+			name:  "after object",
+			input: "REVOKE INSERT ON city ",
+			want:  &Grant{Position: GrantKeyword, Keywords: []string{"FROM"}},
+		},
+		{
+			name:  "grantee",
+			input: "GRANT SELECT ON city TO reporting, ",
+			want:  &Grant{Position: GrantRole, Keywords: granteeKeywords},
+		},
+		{
+			name:  "with grant option",
+			input: "GRANT SELECT ON city TO reporting ",
+			want:  &Grant{Position: GrantKeyword, Keywords: []string{"WITH GRANT OPTION"}},
+		},
+		{
+			name:  "role grant",
+			input: "GRANT admin TO reporting WITH ",
+			want:  &Grant{Position: GrantKeyword, Keywords: []string{"ADMIN OPTION"}},
+		},
+		{
+			name:  "revoke grantee",
+			input: "REVOKE GRANT OPTION FOR SELECT ON city FROM reporting ",
+			want:  &Grant{Position: GrantKeyword, Keywords: []string{"CASCADE", "RESTRICT"}},
+		},
+		{
+			name:  "drop role",
+			input: "DROP ROLE IF EXISTS admin, ",
+			want:  &Grant{Position: GrantRole},
+		},
+		{
+			name:  "set role",
+			input: "SET ROLE ",
+			want:  &Grant{Position: GrantRole},
+		},
+		{
+			name:  "drop table",
+			input: "DROP TABLE ",
+			want:  nil,
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			got, err := ExtractGrant(query, token.Pos{Line: 0, Col: len(tt.input)})
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched grant (- want, + got): %s", d)
+			}
+		})
+	}
+}
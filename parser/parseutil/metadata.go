@@ -0,0 +1,130 @@
+package parseutil
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// The comments giving the metadata of the statements to the tools:
+//
+//	-- name: GetUser :one              the query of sqlc
+//	-- sqls: connection=analytics      the options of sqls
+//	-- conn: analytics                 the connection of sqls
+var (
+	sqlcNamePattern   = regexp.MustCompile(`^name:\s*(\S+)(?:\s+:(\w+))?`)
+	sqlsOptionPattern = regexp.MustCompile(`^sqls:\s*(.*)$`)
+	connPattern       = regexp.MustCompile(`^conn:\s*(\S+)$`)
+)
+
+// MetadataConnection is the option of sqls giving the alias of the
+// connection to run the statement on.
+const MetadataConnection = "connection"
+
+// Metadata is the information of a statement given by the comments on the
+// lines before it.
+type Metadata struct {
+	// Name and Command are of the query of sqlc
+	Name    string
+	Command string
+	// Options are the options of sqls, the bare options are "true"
+	Options map[string]string
+}
+
+// Connection returns the alias of the connection to run the statement on, or
+// empty for the connection of the document.
+func (m *Metadata) Connection() string {
+	if m == nil {
+		return ""
+	}
+	return m.Options[MetadataConnection]
+}
+
+// ExtractMetadata returns the metadata in the comments before the statement,
+// or nil if there are none. The comments following the previous statement on
+// its line are not of the statement.
+func ExtractMetadata(stmt ast.TokenList) *Metadata {
+	var m *Metadata
+	ownLine := false
+	for i, node := range stmt.GetTokens() {
+		tok, ok := node.(ast.Token)
+		if !ok {
+			return m
+		}
+		t := tok.GetToken()
+		if i == 0 {
+			ownLine = t.From.Col == 0
+		}
+		switch t.Kind {
+		case token.Whitespace:
+			if v, _ := t.Value.(string); v == "\n" {
+				ownLine = true
+			}
+		case token.Comment, token.MultilineComment:
+			v, _ := t.Value.(string)
+			if ownLine {
+				m = m.parseComment(strings.TrimSpace(v))
+			}
+		default:
+			return m
+		}
+	}
+	return m
+}
+
+// parseComment adds the metadata of the comment to m, creating it if m is nil
+// and the comment has the metadata.
+func (m *Metadata) parseComment(comment string) *Metadata {
+	if match := sqlcNamePattern.FindStringSubmatch(comment); match != nil {
+		if m == nil {
+			m = &Metadata{}
+		}
+		m.Name, m.Command = match[1], match[2]
+		return m
+	}
+	if match := connPattern.FindStringSubmatch(comment); match != nil {
+		if m == nil {
+			m = &Metadata{}
+		}
+		if m.Options == nil {
+			m.Options = map[string]string{}
+		}
+		m.Options[MetadataConnection] = strings.Trim(match[1], `"'`)
+		return m
+	}
+	match := sqlsOptionPattern.FindStringSubmatch(comment)
+	if match == nil {
+		return m
+	}
+	for _, field := range strings.FieldsFunc(match[1], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			value = "true"
+		}
+		if key == "" {
+			continue
+		}
+		if m == nil {
+			m = &Metadata{}
+		}
+		if m.Options == nil {
+			m.Options = map[string]string{}
+		}
+		m.Options[strings.ToLower(key)] = strings.Trim(value, `"'`)
+	}
+	return m
+}
+
+// FileConnection returns the alias of the connection given by the comments at
+// the top of the file, before its first statement, which is the connection
+// of the statements of the file without their own.
+func FileConnection(stmts []*ast.Statement) string {
+	if len(stmts) == 0 {
+		return ""
+	}
+	return ExtractMetadata(stmts[0]).Connection()
+}
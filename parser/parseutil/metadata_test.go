@@ -0,0 +1,125 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/ast"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		index int
+		want  *Metadata
+	}{
+		{
+			name:  "no comments",
+			input: "SELECT 1",
+			want:  nil,
+		},
+		{
+			name:  "other comments",
+			input: "-- the cities\nSELECT * FROM city",
+			want:  nil,
+		},
+		{
+			name:  "sqlc query",
+			input: "-- name: GetCity :one\nSELECT * FROM city WHERE ID = $1",
+			want:  &Metadata{Name: "GetCity", Command: "one"},
+		},
+		{
+			name:  "sqls options",
+			input: "-- sqls: connection=analytics, readonly\nSELECT 1",
+			want: &Metadata{Options: map[string]string{
+				"connection": "analytics",
+				"readonly":   "true",
+			}},
+		},
+		{
+			name:  "sqlc and sqls",
+			input: "SELECT 1;\n-- name: ListCities :many\n/* sqls: connection='world' */\nSELECT * FROM city;",
+			index: 1,
+			want: &Metadata{
+				Name:    "ListCities",
+				Command: "many",
+				Options: map[string]string{"connection": "world"},
+			},
+		},
+		{
+			name:  "connection",
+			input: "-- conn: analytics\nSELECT 1",
+			want:  &Metadata{Options: map[string]string{"connection": "analytics"}},
+		},
+		{
+			name:  "sqlc and connection",
+			input: "-- name: ListCities :many\n-- conn: world\nSELECT * FROM city;",
+			want: &Metadata{
+				Name:    "ListCities",
+				Command: "many",
+				Options: map[string]string{"connection": "world"},
+			},
+		},
+		{
+			name:  "comment of previous statement",
+			input: "SELECT 1; -- sqls: connection=analytics\nSELECT 2;",
+			index: 1,
+			want:  nil,
+		},
+		{
+			name:  "comment in statement",
+			input: "SELECT 1\n-- sqls: connection=analytics\nFROM city",
+			want:  nil,
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			stmt, ok := query.GetTokens()[tt.index].(ast.TokenList)
+			if !ok {
+				t.Fatalf("not a statement, %T", query.GetTokens()[tt.index])
+			}
+			got := ExtractMetadata(stmt)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched metadata (- want, + got): %s", d)
+			}
+		})
+	}
+}
+
+func TestFileConnection(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "no comments",
+			input: "SELECT 1;\nSELECT 2;",
+			want:  "",
+		},
+		{
+			name:  "top of file",
+			input: "-- conn: analytics\n\nSELECT 1;\nSELECT 2;",
+			want:  "analytics",
+		},
+		{
+			name:  "second statement",
+			input: "SELECT 1;\n-- conn: analytics\nSELECT 2;",
+			want:  "",
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			var stmts []*ast.Statement
+			for _, node := range query.GetTokens() {
+				stmts = append(stmts, node.(*ast.Statement))
+			}
+			if got := FileConnection(stmts); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
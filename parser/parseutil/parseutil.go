@@ -9,6 +9,9 @@ import (
 )
 
 type TableInfo struct {
+	// Catalog is the database of the names of three parts, such as db of
+	// db.schema.table, which is not the database connected
+	Catalog         string
 	DatabaseSchema  string
 	Name            string
 	Alias           string
@@ -63,7 +66,34 @@ func extractFocusedStatement(parsed ast.TokenList, pos token.Pos) (ast.TokenList
 		return nil, fmt.Errorf("Not found statement, Node: %q, Position: (%d, %d)", parsed.String(), pos.Line, pos.Col)
 	}
 	stmt := nodeWalker.CurNodeTopMatched(matcher).(ast.TokenList)
-	return stmt, nil
+	return extractInnerStatement(stmt, pos), nil
+}
+
+// extractInnerStatement returns the statement between the semicolons around
+// the position in the blocks of the procedures, whose statements are not
+// split by them.
+func extractInnerStatement(stmt ast.TokenList, pos token.Pos) ast.TokenList {
+	toks := stmt.GetTokens()
+	if len(toks) == 0 {
+		return stmt
+	}
+	start, end := 0, len(toks)
+	for i, node := range toks[:len(toks)-1] {
+		tok, ok := node.(ast.Token)
+		if !ok || tok.GetToken().Kind != token.Semicolon {
+			continue
+		}
+		if token.ComparePos(node.End(), pos) < 0 {
+			start = i + 1
+			continue
+		}
+		end = i + 1
+		break
+	}
+	if start == 0 && end == len(toks) {
+		return stmt
+	}
+	return &ast.Statement{Toks: toks[start:end]}
 }
 
 func encloseIsSubQuery(stmt ast.TokenList, pos token.Pos) bool {
@@ -106,6 +136,134 @@ func isSubQueryByNode(node ast.Node) bool {
 	return isSubQuery(list)
 }
 
+// isTableFunctionByNode reports whether the node is a set returning function
+// in the table references, such as unnest(a) AS u.
+func isTableFunctionByNode(node ast.Node) bool {
+	alias, ok := node.(*ast.Aliased)
+	if !ok {
+		return false
+	}
+	_, ok = alias.RealName.(*ast.FunctionLiteral)
+	return ok
+}
+
+// aliasColumns returns the name of the alias and the names of the columns of
+// its column list, such as r and a, b of r(a int, b text).
+func aliasColumns(aliased *ast.Aliased) (string, []string) {
+	fl, ok := aliased.AliasedName.(*ast.FunctionLiteral)
+	if !ok {
+		return aliased.AliasedName.String(), nil
+	}
+	name := fl.Toks[0].String()
+	parenthesis, ok := fl.Toks[1].(*ast.Parenthesis)
+	if !ok {
+		return name, nil
+	}
+	return name, columnDefinitionNames(parenthesis.Inner())
+}
+
+// columnDefinitionNames returns the first identifiers of the comma separated
+// column definitions.
+func columnDefinitionNames(list ast.TokenList) []string {
+	names := []string{}
+	first := true
+	for _, node := range list.GetTokens() {
+		switch v := node.(type) {
+		case *ast.IdentifierList:
+			for _, ident := range v.GetIdentifiers() {
+				names = append(names, columnDefinitionNames(&ast.Statement{Toks: []ast.Node{ident}})...)
+			}
+			first = false
+		case *ast.Aliased:
+			if ident, ok := v.RealName.(*ast.Identifier); ok && first {
+				names = append(names, ident.NoQuoteString())
+			}
+			first = false
+		case *ast.Identifier:
+			if first {
+				names = append(names, v.NoQuoteString())
+			}
+			first = false
+		case ast.Token:
+			switch v.GetToken().Kind {
+			case token.Comma:
+				first = true
+			case token.Whitespace, token.Comment, token.MultilineComment:
+			default:
+				first = false
+			}
+		default:
+			first = false
+		}
+	}
+	return names
+}
+
+// extractTableFunctionViews returns the columns defined by the set returning
+// functions in the table references: the columns of the column lists of the
+// aliases, or the column of the alias for the functions returning the scalars
+// such as unnest(a) AS u.
+func extractTableFunctionViews(stmt ast.TokenList) []*SubQueryInfo {
+	nodes := []ast.Node{}
+	for _, node := range append(ExtractTableReferences(stmt), ExtractTableFactor(stmt)...) {
+		if list, ok := node.(*ast.IdentifierList); ok {
+			nodes = append(nodes, list.GetIdentifiers()...)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+
+	results := []*SubQueryInfo{}
+	for _, node := range nodes {
+		if !isTableFunctionByNode(node) {
+			continue
+		}
+		aliased := node.(*ast.Aliased)
+		name, cols := aliasColumns(aliased)
+		if cols == nil {
+			cols = []string{name}
+		}
+		fl := aliased.RealName.(*ast.FunctionLiteral)
+		parent := &TableInfo{Name: fl.Toks[0].String(), Alias: name}
+		subqueryCols := make([]*SubQueryColumn, len(cols))
+		for i, col := range cols {
+			subqueryCols[i] = &SubQueryColumn{
+				ParentTable: parent,
+				ColumnName:  col,
+			}
+		}
+		results = append(results, &SubQueryInfo{
+			Name: name,
+			Views: []*SubQueryView{
+				{
+					SubQueryColumns: subqueryCols,
+				},
+			},
+		})
+	}
+	return results
+}
+
+// extractFocusedLateral returns the LATERAL subquery of the focused subquery,
+// or nil.
+func extractFocusedLateral(stmt, subQuery ast.TokenList) ast.Node {
+	if subQuery == stmt {
+		return nil
+	}
+	prefixMatcher := astutil.NodeMatcher{ExpectKeyword: []string{"LATERAL"}}
+	peekMatcher := astutil.NodeMatcher{NodeTypes: []ast.NodeType{ast.TypeAliased, ast.TypeParenthesis}}
+	for _, node := range filterPrefixGroup(astutil.NewNodeReader(stmt), prefixMatcher, peekMatcher) {
+		realName := node
+		if alias, ok := node.(*ast.Aliased); ok {
+			realName = alias.RealName
+		}
+		if realName == subQuery {
+			return node
+		}
+	}
+	return nil
+}
+
 func extractFocusedSubQuery(stmt ast.TokenList, pos token.Pos) ast.TokenList {
 	nodeWalker := NewNodeWalker(stmt, pos)
 	matcher := astutil.NodeMatcher{NodeTypes: []ast.NodeType{ast.TypeParenthesis}}
@@ -122,14 +280,16 @@ func ExtractSubQueryViews(parsed ast.TokenList, pos token.Pos) ([]*SubQueryInfo,
 		return nil, err
 	}
 
-	reader := astutil.NewNodeReader(parsed)
+	reader := astutil.NewNodeReader(stmt)
 	matcher := astutil.NodeMatcher{NodeTypes: []ast.NodeType{ast.TypeAliased}}
 	aliases := reader.FindRecursive(matcher)
 
 	var subQueries []*ast.Aliased
 	var before *ast.Aliased
 	for _, alias := range aliases {
-		if token.ComparePos(alias.Pos(), pos) < 0 {
+		// The subquery enclosing the position is not in its scope, but the
+		// ones before it are, such as of WHERE
+		if token.ComparePos(alias.Pos(), pos) < 0 && token.ComparePos(alias.End(), pos) >= 0 {
 			continue
 		}
 		if before != nil && token.ComparePos(alias.End(), before.End()) < 0 {
@@ -149,11 +309,7 @@ func ExtractSubQueryViews(parsed ast.TokenList, pos token.Pos) ([]*SubQueryInfo,
 			before = alias
 		}
 	}
-	if len(subQueries) == 0 {
-		return nil, nil
-	}
-
-	results := []*SubQueryInfo{}
+	results := extractTableFunctionViews(stmt)
 	for _, subQuery := range subQueries {
 		parenthesis, ok := subQuery.RealName.(*ast.Parenthesis)
 		if !ok {
@@ -165,13 +321,16 @@ func ExtractSubQueryViews(parsed ast.TokenList, pos token.Pos) ([]*SubQueryInfo,
 			return nil, err
 		}
 
-		cols := make([]string, len(subqueryCols))
+		// (SELECT ...) AS t(a, b) renames the columns
+		name, cols := aliasColumns(subQuery)
 		for i, subqueryCol := range subqueryCols {
-			cols[i] = subqueryCol.ColumnName
+			if i < len(cols) {
+				subqueryCol.AliasName = cols[i]
+			}
 		}
 
 		info := &SubQueryInfo{
-			Name: subQuery.AliasedName.String(),
+			Name: name,
 			Views: []*SubQueryView{
 				{
 					SubQueryColumns: subqueryCols,
@@ -180,6 +339,9 @@ func ExtractSubQueryViews(parsed ast.TokenList, pos token.Pos) ([]*SubQueryInfo,
 		}
 		results = append(results, info)
 	}
+	if len(results) == 0 {
+		return nil, nil
+	}
 	return results, nil
 }
 
@@ -208,7 +370,7 @@ func ExtractLastTable(parsed ast.TokenList, pos token.Pos) (*TableInfo, error) {
 			continue
 		}
 
-		if isSubQueryByNode(ident) {
+		if isSubQueryByNode(ident) || isTableFunctionByNode(ident) {
 			continue
 		}
 		infos, err := parseTableInfo(ident)
@@ -238,23 +400,71 @@ func extractTables(parsed ast.TokenList, pos token.Pos, stopOnPos bool) ([]*Tabl
 	if stopOnPos {
 		stopPos = &pos
 	}
-	tables, err := extractTableIdentifier(list, false, stopPos)
+	// the branches of UNION, INTERSECT and EXCEPT have their own tables
+	scope := list
+	if so := newSetOperation(list); so != nil {
+		scope = so.focusedBranch(pos)
+	}
+	tables, err := extractTableIdentifier(scope, false, stopPos)
 	if err != nil {
 		return nil, err
 	}
-
-	tableMap := map[string]*TableInfo{}
-	for _, table := range tables {
-		tableMap[table.DatabaseSchema+"\t"+table.Name] = table
+	// the subqueries of LATERAL refer to the tables before them
+	if lateral := extractFocusedLateral(stmt, list); lateral != nil {
+		lateralPos := lateral.Pos()
+		outer, err := extractTableIdentifier(stmt, false, &lateralPos)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(outer, tables...)
 	}
+
+	// The tables listed twice are kept in the order of their first ones
+	tableIndex := map[string]int{}
 	cleanTables := []*TableInfo{}
-	for _, table := range tableMap {
+	for _, table := range tables {
+		key := table.Catalog + "\t" + table.DatabaseSchema + "\t" + table.Name
+		if i, ok := tableIndex[key]; ok {
+			cleanTables[i] = table
+			continue
+		}
+		tableIndex[key] = len(cleanTables)
 		cleanTables = append(cleanTables, table)
 	}
+	excluded, err := extractExcludedTable(list)
+	if err != nil {
+		return nil, err
+	}
+	if excluded != nil {
+		cleanTables = append(cleanTables, excluded)
+	}
 
 	return cleanTables, nil
 }
 
+// extractExcludedTable returns the table of INSERT as the excluded pseudo
+// table of ON CONFLICT of PostgreSQL and SQLite, which has the row proposed
+// for the insertion, or nil.
+func extractExcludedTable(list ast.TokenList) (*TableInfo, error) {
+	conflicts := filterTokenList(astutil.NewNodeReader(list), genKeywordMatcher([]string{"ON CONFLICT"}))
+	if len(conflicts.GetTokens()) == 0 {
+		return nil, nil
+	}
+	nodes := ExtractTableReference(list)
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+	infos, err := parseTableInfo(nodes[0])
+	if err != nil || len(infos) == 0 {
+		return nil, err
+	}
+	return &TableInfo{
+		DatabaseSchema: infos[0].DatabaseSchema,
+		Name:           infos[0].Name,
+		Alias:          "excluded",
+	}, nil
+}
+
 func isFollowedByOn(parsed ast.TokenList, pos token.Pos) bool {
 	nw := NewNodeWalker(parsed, pos)
 	for _, n := range nw.Paths {
@@ -347,6 +557,9 @@ func extractTableIdentifier(list ast.TokenList, isSubQuery bool, stopPos *token.
 		if !isSubQuery && isSubQueryByNode(ident) {
 			continue
 		}
+		if isTableFunctionByNode(ident) {
+			continue
+		}
 
 		if stopPos != nil && token.ComparePos(ident.Pos(), *stopPos) > 0 {
 			continue
@@ -392,11 +605,7 @@ func parseTableInfo(idents ast.Node) ([]*TableInfo, error) {
 		res = append(res, tis...)
 	case *ast.MemberIdentifier:
 		if v.Parent != nil {
-			ti := &TableInfo{
-				DatabaseSchema: v.Parent.String(),
-				Name:           v.GetChild().String(),
-			}
-			res = append(res, ti)
+			res = append(res, memberTableInfo(v))
 		}
 	case *ast.Aliased:
 		tis, err := aliasedToTableInfo(v)
@@ -420,13 +629,16 @@ func identifierListToTableInfo(il *ast.IdentifierList) ([]*TableInfo, error) {
 			}
 			tis = append(tis, ti)
 		case *ast.MemberIdentifier:
-			ti := &TableInfo{
-				DatabaseSchema: v.Parent.String(),
-				Name:           v.GetChild().String(),
+			tis = append(tis, memberTableInfo(v))
+		case *ast.Aliased:
+			if isSubQueryByNode(v) || isTableFunctionByNode(v) {
+				continue
+			}
+			ti, err := aliasedToTableInfo(v)
+			if err != nil {
+				return nil, err
 			}
 			tis = append(tis, ti)
-		case *ast.Aliased:
-			// pass
 		default:
 			return nil, fmt.Errorf("failed parse table info, unknown node type %T, value %q in %q", ident, ident, il)
 		}
@@ -434,6 +646,19 @@ func identifierListToTableInfo(il *ast.IdentifierList) ([]*TableInfo, error) {
 	return tis, nil
 }
 
+// memberTableInfo returns the table of the name qualified by the schema, or
+// by the database and the schema.
+func memberTableInfo(mi *ast.MemberIdentifier) *TableInfo {
+	ti := &TableInfo{
+		DatabaseSchema: mi.Parent.String(),
+		Name:           mi.GetChild().String(),
+	}
+	if mi.Catalog != nil {
+		ti.Catalog = mi.Catalog.String()
+	}
+	return ti
+}
+
 func aliasedToTableInfo(aliased *ast.Aliased) (*TableInfo, error) {
 	ti := &TableInfo{}
 	// fetch table schema and name
@@ -441,13 +666,16 @@ func aliasedToTableInfo(aliased *ast.Aliased) (*TableInfo, error) {
 	case *ast.Identifier:
 		ti.Name = v.NoQuoteString()
 	case *ast.MemberIdentifier:
-		ti.DatabaseSchema = v.Parent.String()
-		ti.Name = v.GetChild().String()
+		ti = memberTableInfo(v)
 	case *ast.Parenthesis:
 		tables, err := extractAllTableIdentifiers(v.Inner(), true)
 		if err != nil {
-			panic(err)
+			return nil, err
+		}
+		if len(tables) == 0 {
+			return nil, fmt.Errorf("failed parse real name of alias, no table in %q", v)
 		}
+		ti.Catalog = tables[0].Catalog
 		ti.DatabaseSchema = tables[0].DatabaseSchema
 		ti.Name = tables[0].Name
 	default:
@@ -462,6 +690,10 @@ func aliasedToTableInfo(aliased *ast.Aliased) (*TableInfo, error) {
 	switch v := aliased.AliasedName.(type) {
 	case *ast.Identifier:
 		ti.Alias = v.NoQuoteString()
+	case *ast.FunctionLiteral:
+		if ident, ok := v.Toks[0].(*ast.Identifier); ok {
+			ti.Alias = ident.NoQuoteString()
+		}
 	default:
 		return nil, fmt.Errorf(
 			"failed parse aliased name of alias, unknown node type %T, value %q",
@@ -30,6 +30,18 @@ func Test_extractFocusedStatement(t *testing.T) {
 			pos:   token.Pos{Line: 0, Col: 10},
 			want:  "select 2;",
 		},
+		{
+			name:  "in procedure block",
+			input: "create procedure p() begin select 1 from a; select 2 from b; end;select 3;",
+			pos:   token.Pos{Line: 0, Col: 50},
+			want:  " select 2 from b;",
+		},
+		{
+			name:  "end of procedure block",
+			input: "create procedure p() begin select 1 from a; select 2 from b; end;select 3;",
+			pos:   token.Pos{Line: 0, Col: 63},
+			want:  " end;",
+		},
 	}
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -568,6 +580,102 @@ func TestExtractSubQueryViews(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "table function column definitions",
+			input: "SELECT * FROM jsonb_to_recordset('[]') AS r(a int, b text)",
+			pos:   token.Pos{Line: 0, Col: 7},
+			want: []*SubQueryInfo{
+				{
+					Name: "r",
+					Views: []*SubQueryView{
+						{
+							SubQueryColumns: []*SubQueryColumn{
+								{
+									ParentTable: &TableInfo{Name: "jsonb_to_recordset", Alias: "r"},
+									ColumnName:  "a",
+								},
+								{
+									ParentTable: &TableInfo{Name: "jsonb_to_recordset", Alias: "r"},
+									ColumnName:  "b",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "table function alias",
+			input: "SELECT * FROM city c CROSS JOIN LATERAL unnest(ARRAY[1, 2]) AS u",
+			pos:   token.Pos{Line: 0, Col: 7},
+			want: []*SubQueryInfo{
+				{
+					Name: "u",
+					Views: []*SubQueryView{
+						{
+							SubQueryColumns: []*SubQueryColumn{
+								{
+									ParentTable: &TableInfo{Name: "unnest", Alias: "u"},
+									ColumnName:  "u",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "subquery column list",
+			input: "SELECT * FROM (SELECT ID, Name FROM city) AS sub(a, b)",
+			pos:   token.Pos{Line: 0, Col: 7},
+			want: []*SubQueryInfo{
+				{
+					Name: "sub",
+					Views: []*SubQueryView{
+						{
+							SubQueryColumns: []*SubQueryColumn{
+								{
+									ParentTable: &TableInfo{Name: "city"},
+									ColumnName:  "ID",
+									AliasName:   "a",
+								},
+								{
+									ParentTable: &TableInfo{Name: "city"},
+									ColumnName:  "Name",
+									AliasName:   "b",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "subquery before position",
+			input: "SELECT * FROM (SELECT ID FROM city) AS sub WHERE sub.ID = 1",
+			pos:   token.Pos{Line: 0, Col: 53},
+			want: []*SubQueryInfo{
+				{
+					Name: "sub",
+					Views: []*SubQueryView{
+						{
+							SubQueryColumns: []*SubQueryColumn{
+								{
+									ParentTable: &TableInfo{Name: "city"},
+									ColumnName:  "ID",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "enclosing subquery",
+			input: "SELECT * FROM (SELECT ID FROM city) AS sub",
+			pos:   token.Pos{Line: 0, Col: 23},
+			want:  nil,
+		},
 	}
 	for _, tt := range testcases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -731,6 +839,36 @@ func TestExtractTable(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "with database, database schema and alias",
+			input: "select * from abc.dbo.def ghi",
+			pos:   token.Pos{Line: 0, Col: 1},
+			want: []*TableInfo{
+				{
+					Catalog:        "abc",
+					DatabaseSchema: "dbo",
+					Name:           "def",
+					Alias:          "ghi",
+				},
+			},
+		},
+		{
+			name:  "with databases",
+			input: "select * from abc.dbo.def, xyz.dbo.def",
+			pos:   token.Pos{Line: 0, Col: 1},
+			want: []*TableInfo{
+				{
+					Catalog:        "abc",
+					DatabaseSchema: "dbo",
+					Name:           "def",
+				},
+				{
+					Catalog:        "xyz",
+					DatabaseSchema: "dbo",
+					Name:           "def",
+				},
+			},
+		},
 		{
 			name:  "sub query",
 			input: "FROM (SELECT ID as city_id, Name as city_name FROM city) as t",
@@ -773,6 +911,31 @@ func TestExtractTable(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "on conflict excluded",
+			input: "insert into abc (a) values (1) on conflict (a) do update set a = excluded.a",
+			pos:   token.Pos{Line: 0, Col: 75},
+			want: []*TableInfo{
+				{
+					Name: "abc",
+				},
+				{
+					Name:  "abc",
+					Alias: "excluded",
+				},
+			},
+		},
+		{
+			name:  "merge into",
+			input: "merge into abc as a using",
+			pos:   token.Pos{Line: 0, Col: 25},
+			want: []*TableInfo{
+				{
+					Name:  "abc",
+					Alias: "a",
+				},
+			},
+		},
 		{
 			name:  "insert",
 			input: "insert into abc",
@@ -19,18 +19,31 @@ const (
 	InsertValue    SyntaxPosition = "insert_value"
 	JoinClause     SyntaxPosition = "join_clause"
 	JoinOn         SyntaxPosition = "join_on"
+	WindowFrame    SyntaxPosition = "window_frame"
+	FrameStart     SyntaxPosition = "frame_start"
+	FrameEnd       SyntaxPosition = "frame_end"
 	Unknown        SyntaxPosition = "unknown"
 )
 
 func CheckSyntaxPosition(nw *NodeWalker) SyntaxPosition {
 	var res SyntaxPosition
 	switch {
+	case nw.PrevNodesIs(true, frameUnitMatcher):
+		res = WindowFrame
+	case nw.PrevNodesIs(true, frameBetweenMatcher):
+		res = FrameStart
+	case isFrameEnd(nw):
+		res = FrameEnd
 	case nw.PrevNodesIs(true, genKeywordMatcher([]string{
 		// UPDATE Statement
 		"SET",
 		// SELECT Statement
 		"ORDER BY",
 		"GROUP BY",
+		// Window Specification
+		"PARTITION BY",
+		// INSERT Statement of MySQL
+		"ON DUPLICATE KEY UPDATE",
 	})):
 		res = ColName
 	case nw.PrevNodesIs(true, genKeywordMatcher([]string{
@@ -50,6 +63,7 @@ func CheckSyntaxPosition(nw *NodeWalker) SyntaxPosition {
 		// WHERE Clause
 		"WHERE",
 		"HAVING",
+		"QUALIFY",
 		// Operator
 		"AND",
 		"OR",
@@ -73,6 +87,8 @@ func CheckSyntaxPosition(nw *NodeWalker) SyntaxPosition {
 		"DELETE FROM",
 		// INSERT Statement
 		"INSERT INTO",
+		// MERGE Statement
+		"MERGE INTO",
 		// JOIN Clause
 		"CROSS JOIN",
 		// DESCRIBE Statement
@@ -82,6 +98,11 @@ func CheckSyntaxPosition(nw *NodeWalker) SyntaxPosition {
 		"TRUNCATE",
 	})):
 		res = TableReference
+	case nw.PrevNodesIs(true, genKeywordMatcher([]string{
+		// MERGE Statement, not USING (columns) of JOIN
+		"USING",
+	})) && !nw.CurNodeIs(parenthesisMatcher):
+		res = TableReference
 	case nw.PrevNodesIs(true, genKeywordMatcher([]string{
 		"ON",
 	})):
@@ -108,6 +129,40 @@ func CheckSyntaxPosition(nw *NodeWalker) SyntaxPosition {
 	return res
 }
 
+var frameUnitMatcher = genKeywordMatcher([]string{
+	"ROWS",
+	"RANGE",
+	"GROUPS",
+})
+
+var frameBetweenMatcher = genKeywordMatcher([]string{
+	"ROWS BETWEEN",
+	"RANGE BETWEEN",
+	"GROUPS BETWEEN",
+})
+
+// isFrameEnd reports whether the position is after AND of the frame of a
+// window, such as ROWS BETWEEN 1 PRECEDING AND, not of the conditions.
+func isFrameEnd(nw *NodeWalker) bool {
+	andMatcher := genKeywordMatcher([]string{"AND"})
+	for _, reader := range nw.Paths {
+		index, node := reader.PrevNode(true)
+		if node == nil || !andMatcher.IsMatch(node) {
+			continue
+		}
+		toks := reader.Node.GetTokens()
+		for i := index - 1; i >= 0; i-- {
+			if frameBetweenMatcher.IsMatch(toks[i]) {
+				return true
+			}
+			if andMatcher.IsMatch(toks[i]) {
+				break
+			}
+		}
+	}
+	return false
+}
+
 func getJoinCondition(nw *NodeWalker) SyntaxPosition {
 	for _, n := range nw.Paths {
 		if n.PeekNodeIs(true, genKeywordMatcher([]string{"ON"})) {
@@ -141,6 +196,12 @@ func genTokenMatcher(tokens []token.Kind) astutil.NodeMatcher {
 	}
 }
 
+var parenthesisMatcher = astutil.NodeMatcher{
+	NodeTypes: []ast.NodeType{
+		ast.TypeParenthesis,
+	},
+}
+
 func isInsertColumns(nw *NodeWalker) bool {
 	ParenthesisMatcher := astutil.NodeMatcher{
 		NodeTypes: []ast.NodeType{
@@ -185,6 +185,69 @@ func TestCheckSyntaxPosition(t *testing.T) {
 			},
 			want: TableReference,
 		},
+		{
+			name: "merge target",
+			text: "merge into ",
+			pos: token.Pos{
+				Line: 0,
+				Col:  11,
+			},
+			want: TableReference,
+		},
+		{
+			name: "merge source",
+			text: "merge into city as t using ",
+			pos: token.Pos{
+				Line: 0,
+				Col:  27,
+			},
+			want: TableReference,
+		},
+		{
+			name: "join using columns",
+			text: "select * from city join country using (",
+			pos: token.Pos{
+				Line: 0,
+				Col:  40,
+			},
+			want: Unknown,
+		},
+		{
+			name: "on duplicate key update",
+			text: "insert into city (ID) values (1) on duplicate key update ",
+			pos: token.Pos{
+				Line: 0,
+				Col:  57,
+			},
+			want: ColName,
+		},
+		{
+			name: "frame end",
+			text: "select sum(x) over (order by a rows between 1 preceding and ",
+			pos: token.Pos{
+				Line: 0,
+				Col:  60,
+			},
+			want: FrameEnd,
+		},
+		{
+			name: "and of between",
+			text: "select * from t where a between 1 and ",
+			pos: token.Pos{
+				Line: 0,
+				Col:  38,
+			},
+			want: WhereCondition,
+		},
+		{
+			name: "qualify",
+			text: "select a from t qualify ",
+			pos: token.Pos{
+				Line: 0,
+				Col:  24,
+			},
+			want: WhereCondition,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
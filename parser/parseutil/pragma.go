@@ -0,0 +1,65 @@
+package parseutil
+
+import (
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// PragmaPosition is the part of PRAGMA of SQLite at the position.
+type PragmaPosition string
+
+const (
+	// PragmaName is the name of the pragma, which the schema may qualify
+	PragmaName PragmaPosition = "pragma_name"
+	// PragmaValue is the value or the argument of the pragma
+	PragmaValue PragmaPosition = "pragma_value"
+)
+
+// Pragma is PRAGMA of SQLite at the position.
+type Pragma struct {
+	Position PragmaPosition
+	// Name is the name of the pragma at PragmaValue
+	Name string
+}
+
+// ExtractPragma returns PRAGMA at the position, or nil if the position is not
+// in PRAGMA nor where its name or its value follows.
+func ExtractPragma(parsed ast.TokenList, pos token.Pos) (*Pragma, error) {
+	stmt, err := extractFocusedStatement(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	r := &ddlReader{toks: ddlTokens(stmt, pos)}
+	if !r.accept("PRAGMA") {
+		return nil, nil
+	}
+	if r.done() {
+		return &Pragma{Position: PragmaName}, nil
+	}
+	if r.kind() != token.SQLKeyword {
+		return nil, nil
+	}
+	name := r.toks[r.i].NoQuoteString()
+	r.i++
+	// PRAGMA main.journal_mode
+	if !r.done() && r.kind() == token.Period {
+		r.i++
+		if r.done() {
+			return &Pragma{Position: PragmaName}, nil
+		}
+		if r.kind() != token.SQLKeyword {
+			return nil, nil
+		}
+		name = r.toks[r.i].NoQuoteString()
+		r.i++
+	}
+	// PRAGMA journal_mode = WAL and PRAGMA table_info(city)
+	if r.done() || (r.kind() != token.Eq && r.kind() != token.LParen) {
+		return nil, nil
+	}
+	r.i++
+	if !r.done() {
+		return nil, nil
+	}
+	return &Pragma{Position: PragmaValue, Name: name}, nil
+}
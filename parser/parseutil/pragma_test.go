@@ -0,0 +1,69 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/token"
+)
+
+func TestExtractPragma(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		want  *Pragma
+	}{
+		{
+			name:  "not pragma",
+			input: "SELECT ",
+			want:  nil,
+		},
+		{
+			name:  "pragma name",
+			input: "PRAGMA jour",
+			want:  &Pragma{Position: PragmaName},
+		},
+		{
+			name:  "pragma name of schema",
+			input: "PRAGMA main.",
+			want:  &Pragma{Position: PragmaName},
+		},
+		{
+			name:  "pragma value",
+			input: "PRAGMA journal_mode = ",
+			want:  &Pragma{Position: PragmaValue, Name: "journal_mode"},
+		},
+		{
+			name:  "pragma value of schema",
+			input: "PRAGMA main.synchronous=N",
+			want:  &Pragma{Position: PragmaValue, Name: "synchronous"},
+		},
+		{
+			name:  "pragma argument",
+			input: "PRAGMA table_info(",
+			want:  &Pragma{Position: PragmaValue, Name: "table_info"},
+		},
+		{
+			name:  "pragma ended",
+			input: "PRAGMA foreign_keys = ON ",
+			want:  nil,
+		},
+		{
+			name:  "pragma query",
+			input: "PRAGMA foreign_keys ",
+			want:  nil,
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			got, err := ExtractPragma(query, token.Pos{Line: 0, Col: len(tt.input)})
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("unmatched pragma (- want, + got): %s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package parseutil
+
+import (
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/ast/astutil"
+	"github.com/sqls-server/sqls/token"
+)
+
+// SetOperation is the query combining the results of the SELECTs by UNION,
+// INTERSECT and EXCEPT.
+type SetOperation struct {
+	// Branches are the SELECTs, without the ORDER BY and the LIMIT of the
+	// whole results after the last one
+	Branches []ast.TokenList
+	// OrderBy is the ORDER BY and the clauses after it, or nil
+	OrderBy ast.TokenList
+	// prefix is the nodes before the first SELECT, such as WITH and INSERT
+	// INTO
+	prefix []ast.Node
+}
+
+var setOperatorMatcher = astutil.NodeMatcher{
+	ExpectKeyword: []string{
+		"UNION",
+		"INTERSECT",
+		"EXCEPT",
+		"MINUS",
+	},
+}
+
+var setQuantifierMatcher = astutil.NodeMatcher{
+	ExpectKeyword: []string{
+		"ALL",
+		"DISTINCT",
+	},
+}
+
+var setOperationTailMatcher = astutil.NodeMatcher{
+	ExpectKeyword: []string{
+		"ORDER BY",
+		"LIMIT",
+		"OFFSET",
+		"FETCH",
+	},
+}
+
+// ExtractSetOperation returns the set operation of the statement or the
+// subquery at the position, or nil if it does not combine the SELECTs.
+func ExtractSetOperation(parsed ast.TokenList, pos token.Pos) (*SetOperation, error) {
+	stmt, err := extractFocusedStatement(parsed, pos)
+	if err != nil {
+		return nil, err
+	}
+	list := stmt
+	if encloseIsSubQuery(stmt, pos) {
+		list = extractFocusedSubQuery(stmt, pos)
+	}
+	return newSetOperation(list), nil
+}
+
+func newSetOperation(list ast.TokenList) *SetOperation {
+	toks := list.GetTokens()
+	operators := []int{}
+	for i, node := range toks {
+		if setOperatorMatcher.IsMatchKeyword(node) {
+			operators = append(operators, i)
+		}
+	}
+	if len(operators) == 0 {
+		return nil
+	}
+
+	// The first branch starts with SELECT, or is the last (SELECT ...) after
+	// the ones of WITH
+	so := &SetOperation{}
+	start := 0
+	selectMatcher := astutil.NodeMatcher{ExpectKeyword: []string{"SELECT"}}
+	for i, node := range toks[:operators[0]] {
+		if selectMatcher.IsMatchKeyword(node) {
+			start = i
+			break
+		}
+		if parenthesis, ok := node.(*ast.Parenthesis); ok && isSubQuery(parenthesis) {
+			start = i
+		}
+	}
+	so.prefix = toks[:start]
+	so.Branches = append(so.Branches, branchOf(toks[start:operators[0]]))
+	for k, op := range operators {
+		start := op + 1
+		for start < len(toks) && (isWhitespace(toks[start]) || setQuantifierMatcher.IsMatchKeyword(toks[start])) {
+			start++
+		}
+		end := len(toks)
+		if k+1 < len(operators) {
+			end = operators[k+1]
+		} else {
+			for i := start; i < len(toks); i++ {
+				if setOperationTailMatcher.IsMatchKeyword(toks[i]) {
+					end = i
+					so.OrderBy = &ast.Statement{Toks: toks[i:]}
+					break
+				}
+			}
+		}
+		so.Branches = append(so.Branches, branchOf(toks[start:end]))
+	}
+	return so
+}
+
+func isWhitespace(node ast.Node) bool {
+	tok, ok := node.(ast.Token)
+	return ok && tok.GetToken().Kind == token.Whitespace
+}
+
+// branchOf returns the SELECT of the nodes, unwrapping the parentheses of
+// (SELECT ...).
+func branchOf(nodes []ast.Node) ast.TokenList {
+	significant := []ast.Node{}
+	for _, node := range nodes {
+		if !isWhitespace(node) {
+			significant = append(significant, node)
+		}
+	}
+	if len(significant) == 1 {
+		if parenthesis, ok := significant[0].(*ast.Parenthesis); ok && isSubQuery(parenthesis) {
+			return parenthesis.Inner()
+		}
+	}
+	return &ast.Statement{Toks: nodes}
+}
+
+// IsOrderBy reports whether the position is in the ORDER BY of the whole
+// results.
+func (so *SetOperation) IsOrderBy(pos token.Pos) bool {
+	return so.OrderBy != nil && token.ComparePos(pos, so.OrderBy.Pos()) > 0
+}
+
+// focusedBranch returns the nodes of the branch at the position with the
+// nodes before the first branch, or the first branch for the ORDER BY that
+// refers to its columns.
+func (so *SetOperation) focusedBranch(pos token.Pos) ast.TokenList {
+	branch := so.Branches[0]
+	for _, b := range so.Branches[1:] {
+		if len(b.GetTokens()) > 0 && token.ComparePos(pos, b.Pos()) >= 0 && !so.IsOrderBy(pos) {
+			branch = b
+		}
+	}
+	toks := append(append([]ast.Node{}, so.prefix...), branch.GetTokens()...)
+	return &ast.Statement{Toks: toks}
+}
+
+// Columns returns the output columns of the first SELECT, which name the
+// columns of the results. The columns of * have the tables.
+func (so *SetOperation) Columns() ([]*SubQueryColumn, error) {
+	branch := so.Branches[0]
+	exprs := ExtractSelectExpr(branch)
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+	tables, err := extractAllTableIdentifiers(branch, true)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []ast.Node{exprs[0]}
+	if list, ok := exprs[0].(*ast.IdentifierList); ok {
+		items = list.GetIdentifiers()
+	}
+	cols := []*SubQueryColumn{}
+	for _, item := range items {
+		col := outputColumn(item)
+		if col == nil {
+			continue
+		}
+		for _, table := range tables {
+			if (col.ParentName == "" && len(tables) == 1) || table.isMatchTableName(col.ParentName) {
+				col.ParentTable = table
+			}
+		}
+		if col.ColumnName != "*" {
+			cols = append(cols, col)
+			continue
+		}
+		// * and table.*
+		for _, table := range tables {
+			if col.ParentName == "" || table.isMatchTableName(col.ParentName) {
+				cols = append(cols, &SubQueryColumn{ParentTable: table, ColumnName: "*"})
+			}
+		}
+	}
+	return cols, nil
+}
+
+// outputColumn returns the column of the item of the select list, or nil for
+// the expressions without the names.
+func outputColumn(item ast.Node) *SubQueryColumn {
+	switch v := item.(type) {
+	case *ast.Identifier:
+		return &SubQueryColumn{ColumnName: v.NoQuoteString()}
+	case *ast.MemberIdentifier:
+		if v.ParentTok == nil || v.ChildTok == nil {
+			return nil
+		}
+		return &SubQueryColumn{
+			ParentName: v.ParentTok.NoQuoteString(),
+			ColumnName: v.ChildTok.NoQuoteString(),
+		}
+	case *ast.Aliased:
+		col := outputColumn(v.RealName)
+		if col == nil {
+			col = &SubQueryColumn{}
+		}
+		col.AliasName = v.GetAliasedNameIdent().NoQuoteString()
+		if col.ColumnName == "" {
+			col.ColumnName = col.AliasName
+		}
+		return col
+	}
+	return nil
+}
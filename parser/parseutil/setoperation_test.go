@@ -0,0 +1,96 @@
+package parseutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sqls-server/sqls/token"
+)
+
+func TestExtractSetOperation(t *testing.T) {
+	testcases := []struct {
+		name     string
+		input    string
+		pos      token.Pos
+		branches []string
+		orderBy  bool
+		cols     []*SubQueryColumn
+	}{
+		{
+			name:     "not set operation",
+			input:    "SELECT ID FROM city ORDER BY ID",
+			pos:      token.Pos{Line: 0, Col: 7},
+			branches: nil,
+		},
+		{
+			name:  "union",
+			input: "SELECT ID AS city_id, c.Name FROM city c UNION ALL SELECT Code, Name FROM country ORDER BY 1",
+			pos:   token.Pos{Line: 0, Col: 92},
+			branches: []string{
+				"SELECT ID AS city_id, c.Name FROM city c ",
+				"SELECT Code, Name FROM country ",
+			},
+			orderBy: true,
+			cols: []*SubQueryColumn{
+				{
+					ParentTable: &TableInfo{Name: "city", Alias: "c"},
+					ColumnName:  "ID",
+					AliasName:   "city_id",
+				},
+				{
+					ParentTable: &TableInfo{Name: "city", Alias: "c"},
+					ParentName:  "c",
+					ColumnName:  "Name",
+				},
+			},
+		},
+		{
+			name:  "parenthesized branches",
+			input: "WITH w AS (SELECT 1) (SELECT * FROM city) EXCEPT (SELECT * FROM city WHERE ID = 1) INTERSECT SELECT * FROM city",
+			pos:   token.Pos{Line: 0, Col: 7},
+			branches: []string{
+				"SELECT * FROM city",
+				"SELECT * FROM city WHERE ID = 1",
+				"SELECT * FROM city",
+			},
+			cols: []*SubQueryColumn{
+				{
+					ParentTable: &TableInfo{Name: "city"},
+					ColumnName:  "*",
+				},
+			},
+		},
+	}
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := initExtractTable(t, tt.input)
+			got, err := ExtractSetOperation(query, tt.pos)
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if tt.branches == nil {
+				if got != nil {
+					t.Errorf("unexpected set operation: %+v", got)
+				}
+				return
+			}
+			branches := []string{}
+			for _, branch := range got.Branches {
+				branches = append(branches, branch.String())
+			}
+			if d := cmp.Diff(tt.branches, branches); d != "" {
+				t.Errorf("unmatched branches (- want, + got): %s", d)
+			}
+			if got.IsOrderBy(tt.pos) != tt.orderBy {
+				t.Errorf("IsOrderBy() = %v, want %v", got.IsOrderBy(tt.pos), tt.orderBy)
+			}
+			cols, err := got.Columns()
+			if err != nil {
+				t.Fatalf("error: %+v", err)
+			}
+			if d := cmp.Diff(tt.cols, cols); d != "" {
+				t.Errorf("unmatched columns (- want, + got): %s", d)
+			}
+		})
+	}
+}
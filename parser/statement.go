@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/sqls-server/sqls/ast"
+	"github.com/sqls-server/sqls/token"
+)
+
+// transactionBeginWords are the words after BEGIN starting a transaction, not
+// a block.
+var transactionBeginWords = map[string]bool{
+	"TRANSACTION": true,
+	"TRAN":        true,
+	"WORK":        true,
+	"DEFERRED":    true,
+	"IMMEDIATE":   true,
+	"EXCLUSIVE":   true,
+	"ISOLATION":   true,
+	"READ":        true,
+	"NOT":         true,
+	"DEFERRABLE":  true,
+	"DISTRIBUTED": true,
+}
+
+// routineWords are the objects whose definitions have the blocks.
+var routineWords = map[string]bool{
+	"PROCEDURE": true,
+	"PROC":      true,
+	"FUNCTION":  true,
+	"TRIGGER":   true,
+	"EVENT":     true,
+	"PACKAGE":   true,
+}
+
+// bodyStatementWords are the words starting the statements of the bodies of
+// T-SQL after AS, which are not the declarations of PL/SQL.
+var bodyStatementWords = map[string]bool{
+	"BEGIN":     true,
+	"SELECT":    true,
+	"INSERT":    true,
+	"UPDATE":    true,
+	"DELETE":    true,
+	"MERGE":     true,
+	"WITH":      true,
+	"VALUES":    true,
+	"SET":       true,
+	"DECLARE":   true,
+	"IF":        true,
+	"WHILE":     true,
+	"EXEC":      true,
+	"EXECUTE":   true,
+	"RETURN":    true,
+	"TRUNCATE":  true,
+	"PRINT":     true,
+	"RAISERROR": true,
+	"THROW":     true,
+	"LANGUAGE":  true,
+	"EXTERNAL":  true,
+}
+
+// endedWords are the words after END closing the compound statements that do
+// not start with BEGIN or CASE.
+var endedWords = map[string]bool{
+	"IF":     true,
+	"LOOP":   true,
+	"WHILE":  true,
+	"REPEAT": true,
+	"FOR":    true,
+}
+
+// statementSplitter finds the ends of the statements: the semicolons out of
+// the blocks of the procedures, or the delimiters set by the DELIMITER
+// directives of the MySQL client instead of the semicolons. The meta-commands
+// of psql such as \copy end at the ends of the lines.
+type statementSplitter struct {
+	nodes     []ast.Node
+	delimiter string
+	// start is the index of the first node of the statement
+	start int
+	// create is true in CREATE and ALTER statements, and routine is true
+	// after the words of the routines in them
+	create  bool
+	routine bool
+	// body is true after the words starting the statements out of the
+	// blocks, whose AS are the ones of the aliases
+	body bool
+	// depth is the depth of the blocks, and declare is the depth of the
+	// declarations of PL/SQL waiting for BEGIN, or -1
+	depth   int
+	declare int
+	parens  int
+	// metaCommand is true in the meta-commands of psql
+	metaCommand bool
+}
+
+func newStatementSplitter(nodes []ast.Node) *statementSplitter {
+	return &statementSplitter{nodes: nodes, delimiter: ";", declare: -1}
+}
+
+func (s *statementSplitter) token(i int) *ast.SQLToken {
+	if i < 0 || i >= len(s.nodes) {
+		return nil
+	}
+	tok, ok := s.nodes[i].(ast.Token)
+	if !ok {
+		return nil
+	}
+	return tok.GetToken()
+}
+
+func (s *statementSplitter) word(i int) string {
+	tok := s.token(i)
+	if tok == nil || tok.Kind != token.SQLKeyword {
+		return ""
+	}
+	w, ok := tok.Value.(*token.SQLWord)
+	if !ok || w.QuoteStyle != 0 {
+		return ""
+	}
+	return strings.ToUpper(w.Value)
+}
+
+// significant returns the index of the token that is not a whitespace nor a
+// comment from the index in the direction, or -1.
+func (s *statementSplitter) significant(i, step int) int {
+	for ; i >= s.start && i < len(s.nodes); i += step {
+		tok := s.token(i)
+		if tok == nil {
+			return i
+		}
+		switch tok.Kind {
+		case token.Whitespace, token.Comment, token.MultilineComment:
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+func (s *statementSplitter) nextWord(i int) string {
+	return s.word(s.significant(i+1, 1))
+}
+
+// isEnd reports whether the node at the index ends the statement.
+func (s *statementSplitter) isEnd(i int) bool {
+	tok := s.token(i)
+	if tok == nil {
+		return false
+	}
+	first := s.significant(s.start, 1) == i
+	switch tok.Kind {
+	case token.Backslash:
+		if first {
+			s.metaCommand = true
+		}
+	case token.Whitespace:
+		value, _ := tok.Value.(string)
+		if s.metaCommand && strings.Contains(value, "\n") {
+			s.reset(i)
+			return true
+		}
+	case token.LParen:
+		s.parens++
+	case token.RParen:
+		if s.parens > 0 {
+			s.parens--
+		}
+	case token.Semicolon:
+		value, _ := tok.Value.(string)
+		// DELIMITER //
+		if prev := s.significant(i-1, -1); prev >= 0 && prev == s.significant(s.start, 1) && s.word(prev) == "DELIMITER" {
+			s.delimiter = value
+			s.reset(i)
+			return true
+		}
+		if value != s.delimiter || (s.delimiter == ";" && s.depth > 0) {
+			return false
+		}
+		s.reset(i)
+		return true
+	case token.SQLKeyword:
+		if s.parens > 0 {
+			return false
+		}
+		s.block(i, first)
+	}
+	return false
+}
+
+// block tracks the depth of the blocks by the word at the index.
+func (s *statementSplitter) block(i int, first bool) {
+	word := s.word(i)
+	if first && (word == "CREATE" || word == "ALTER") {
+		s.create = true
+	}
+	if s.create && routineWords[word] {
+		s.routine = true
+	}
+	switch word {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "WITH", "VALUES":
+		if s.depth == 0 {
+			s.body = true
+		}
+	case "BEGIN":
+		next := s.nextWord(i)
+		if transactionBeginWords[next] || s.significant(i+1, 1) < 0 || s.token(s.significant(i+1, 1)).Kind == token.Semicolon {
+			return
+		}
+		if s.depth == 0 && !first && !s.routine {
+			return
+		}
+		if s.declare == s.depth && s.depth > 0 {
+			s.declare = -1
+			return
+		}
+		s.depth++
+	case "DECLARE":
+		// DECLARE of PL/SQL, not of the variables of T-SQL and the cursors
+		next := s.nextWord(i)
+		if s.depth > 0 || (!first && !s.routine) || next == "" || strings.HasPrefix(next, "@") {
+			return
+		}
+		switch s.word(s.significant(s.significant(i+1, 1)+1, 1)) {
+		case "CURSOR", "BINARY", "INSENSITIVE", "ASENSITIVE", "SCROLL", "NO":
+			return
+		}
+		s.depth++
+		s.declare = s.depth
+	case "IS", "AS":
+		next := s.nextWord(i)
+		if s.depth > 0 || !s.routine || s.body || next == "" || bodyStatementWords[next] {
+			return
+		}
+		s.depth++
+		s.declare = s.depth
+	case "CASE":
+		// not END CASE
+		if s.depth > 0 && s.word(s.significant(i-1, -1)) != "END" {
+			s.depth++
+		}
+	case "END":
+		if s.depth > 0 && !endedWords[s.nextWord(i)] {
+			s.depth--
+		}
+	}
+}
+
+func (s *statementSplitter) reset(end int) {
+	s.start = end + 1
+	s.create = false
+	s.routine = false
+	s.body = false
+	s.depth = 0
+	s.declare = -1
+	s.parens = 0
+	s.metaCommand = false
+}
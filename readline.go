@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// errInterrupted is returned by readLine when the line is cancelled by
+// Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+// completeFunc returns the words to complete the line before the cursor and
+// the number of the runes of the word they replace.
+type completeFunc func(line []rune) (words []string, replace int)
+
+// lineEditor reads the lines from the terminal in the raw mode, with the
+// history of the lines and the completion by Tab.
+type lineEditor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	complete completeFunc
+	history  []string
+}
+
+func newLineEditor(in io.Reader, out io.Writer, complete completeFunc) *lineEditor {
+	return &lineEditor{
+		in:       bufio.NewReader(in),
+		out:      out,
+		complete: complete,
+	}
+}
+
+const (
+	keyCtrlA     = 1
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyBackspace = 8
+	keyTab       = 9
+	keyEnter     = 13
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// readLine reads a line after the prompt. It returns io.EOF by Ctrl-D on an
+// empty line.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	var line []rune
+	pos := 0
+	historyIndex := len(e.history)
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(line))
+		if back := len(line) - pos; back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	fmt.Fprint(e.out, prompt)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case keyEnter, '\n':
+			fmt.Fprint(e.out, "\r\n")
+			text := string(line)
+			if strings.TrimSpace(text) != "" {
+				e.history = append(e.history, text)
+			}
+			return text, nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+		case keyCtrlA:
+			pos = 0
+		case keyCtrlE:
+			pos = len(line)
+		case keyCtrlU:
+			line, pos = line[pos:], 0
+		case keyBackspace, keyDelete:
+			if pos > 0 {
+				line = append(line[:pos-1], line[pos:]...)
+				pos--
+			}
+		case keyTab:
+			line, pos = e.completeLine(prompt, line, pos)
+		case keyEscape:
+			seq := e.escapeSequence()
+			switch seq {
+			case "[A", "[B":
+				if seq == "[A" && historyIndex > 0 {
+					historyIndex--
+				} else if seq == "[B" && historyIndex < len(e.history) {
+					historyIndex++
+				}
+				line = nil
+				if historyIndex < len(e.history) {
+					line = []rune(e.history[historyIndex])
+				}
+				pos = len(line)
+			case "[C":
+				if pos < len(line) {
+					pos++
+				}
+			case "[D":
+				if pos > 0 {
+					pos--
+				}
+			case "[H":
+				pos = 0
+			case "[F":
+				pos = len(line)
+			}
+		default:
+			if !unicode.IsPrint(r) {
+				continue
+			}
+			line = append(line[:pos], append([]rune{r}, line[pos:]...)...)
+			pos++
+		}
+		redraw()
+	}
+}
+
+// escapeSequence reads the rest of the escape sequence of a key, such as [A
+// of the up arrow.
+func (e *lineEditor) escapeSequence() string {
+	var seq []rune
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return string(seq)
+		}
+		seq = append(seq, r)
+		// The sequences end with a letter or ~ after [ and the parameters
+		if len(seq) > 1 && (unicode.IsLetter(r) || r == '~') {
+			return string(seq)
+		}
+		if len(seq) == 1 && r != '[' && r != 'O' {
+			return string(seq)
+		}
+	}
+}
+
+// completeLine completes the word before the cursor by the word of the
+// completion, or by the common prefix of the words after printing them.
+func (e *lineEditor) completeLine(prompt string, line []rune, pos int) ([]rune, int) {
+	if e.complete == nil {
+		return line, pos
+	}
+	words, replace := e.complete(line[:pos])
+	if len(words) == 0 {
+		return line, pos
+	}
+	insert := []rune(words[0])
+	if len(words) > 1 {
+		insert = []rune(commonPrefix(words))
+		fmt.Fprint(e.out, "\r\n"+strings.Join(words, "  ")+"\r\n")
+		if len(insert) < replace {
+			return line, pos
+		}
+	}
+	rest := append([]rune{}, line[pos:]...)
+	line = append(append(line[:pos-replace], insert...), rest...)
+	return line, pos - replace + len(insert)
+}
+
+// commonPrefix returns the prefix of the words ignoring the case, in the case
+// of the first word.
+func commonPrefix(words []string) string {
+	prefix := []rune(words[0])
+	for _, w := range words[1:] {
+		r := []rune(w)
+		n := 0
+		for n < len(prefix) && n < len(r) && unicode.ToLower(prefix[n]) == unicode.ToLower(r[n]) {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/sqls-server/sqls/internal/handler"
+)
+
+// dumpSchema writes the CREATE TABLE of the tables of the connection of the
+// config to the file or stdout, as the schema dump that lint --schema and
+// diffSchemas read without the database.
+func dumpSchema(c *cli.Context) error {
+	server := handler.NewServer()
+	defer server.Stop()
+	if err := loadConfig(server, c.String("config")); err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := server.OpenWorkspace(wd); err != nil {
+		return err
+	}
+	if err := server.UseConnection(context.Background(), c.String("connection")); err != nil {
+		return err
+	}
+	dump, err := server.DumpSchema()
+	if err != nil {
+		return err
+	}
+
+	output := c.String("output")
+	if output == "" || output == "-" {
+		fmt.Fprint(c.App.Writer, dump)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(dump), 0644); err != nil {
+		return fmt.Errorf("cannot write %s, %w", output, err)
+	}
+	return nil
+}
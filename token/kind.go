@@ -70,6 +70,10 @@ const (
 	LBrace
 	// Right brace `}`
 	RBrace
+	// Dollar quoted string i.e: $$string$$ or $tag$string$tag$
+	DollarQuotedString
+	// The operators of PostgreSQL such as ->, ->>, @> and ||
+	Operator
 	// ILLEGAL sqltoken
 	ILLEGAL
 )
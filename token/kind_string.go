@@ -41,12 +41,14 @@ func _() {
 	_ = x[Ampersand-30]
 	_ = x[LBrace-31]
 	_ = x[RBrace-32]
-	_ = x[ILLEGAL-33]
+	_ = x[DollarQuotedString-33]
+	_ = x[Operator-34]
+	_ = x[ILLEGAL-35]
 }
 
-const _Kind_name = "SQLKeywordNumberCharSingleQuotedStringNationalStringLiteralCommaWhitespaceCommentMultilineCommentEqNeqLtGtLtEqGtEqPlusMinusMultDivCaretModLParenRParenPeriodColonDoubleColonSemicolonBackslashLBracketRBracketAmpersandLBraceRBraceILLEGAL"
+const _Kind_name = "SQLKeywordNumberCharSingleQuotedStringNationalStringLiteralCommaWhitespaceCommentMultilineCommentEqNeqLtGtLtEqGtEqPlusMinusMultDivCaretModLParenRParenPeriodColonDoubleColonSemicolonBackslashLBracketRBracketAmpersandLBraceRBraceDollarQuotedStringOperatorILLEGAL"
 
-var _Kind_index = [...]uint8{0, 10, 16, 20, 38, 59, 64, 74, 81, 97, 99, 102, 104, 106, 110, 114, 118, 123, 127, 130, 135, 138, 144, 150, 156, 161, 172, 181, 190, 198, 206, 215, 221, 227, 234}
+var _Kind_index = [...]uint16{0, 10, 16, 20, 38, 59, 64, 74, 81, 97, 99, 102, 104, 106, 110, 114, 118, 123, 127, 130, 135, 138, 144, 150, 156, 161, 172, 181, 190, 198, 206, 215, 221, 227, 245, 253, 260}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 	"text/scanner"
+	"unicode/utf8"
 
 	"github.com/sqls-server/sqls/dialect"
 )
@@ -112,15 +113,35 @@ type Tokenizer struct {
 	Scanner *scanner.Scanner
 	Line    int
 	Col     int
+
+	// offset is the byte offset of the position in the source
+	offset int
+	// lookahead holds the runes read from the scanner ahead of the position
+	lookahead []rune
+	// delimiter is the statement delimiter set by the DELIMITER directive of
+	// the MySQL client, empty for the semicolon
+	delimiter string
+	// statementStart is true until a token other than the whitespaces and
+	// the comments starts a statement
+	statementStart bool
+	// lineStart is true until a token other than the whitespaces starts a line
+	lineStart bool
+	// readDelimiter is true after DELIMITER starting a statement and a line,
+	// whose argument is the new delimiter
+	readDelimiter bool
+	prevKind      Kind
 }
 
 func NewTokenizer(src io.Reader, dialect dialect.Dialect) *Tokenizer {
 	var scan scanner.Scanner
 	return &Tokenizer{
-		Dialect: dialect,
-		Scanner: scan.Init(src),
-		Line:    0,
-		Col:     0,
+		Dialect:        dialect,
+		Scanner:        scan.Init(src),
+		Line:           0,
+		Col:            0,
+		statementStart: true,
+		lineStart:      true,
+		prevKind:       Whitespace,
 	}
 }
 
@@ -151,9 +172,87 @@ func (t *Tokenizer) NextToken() (*Token, error) {
 		return &Token{Kind: ILLEGAL, Value: "", From: pos, To: t.Pos()}, fmt.Errorf("tokenize failed: %w", err)
 	}
 
+	switch {
+	case tok == Whitespace:
+		if str == "\n" {
+			t.lineStart = true
+			t.readDelimiter = false
+		}
+	case tok == Semicolon:
+		t.statementStart = true
+		t.lineStart = false
+	case tok == Comment || tok == MultilineComment:
+		t.lineStart = false
+	default:
+		t.statementStart = false
+		t.lineStart = false
+	}
+	t.prevKind = tok
 	return &Token{Kind: tok, Value: str, From: pos, To: t.Pos()}, nil
 }
 
+func (t *Tokenizer) peek() rune {
+	if len(t.lookahead) > 0 {
+		return t.lookahead[0]
+	}
+	return t.Scanner.Peek()
+}
+
+func (t *Tokenizer) read() rune {
+	var r rune
+	if len(t.lookahead) > 0 {
+		r = t.lookahead[0]
+		t.lookahead = t.lookahead[1:]
+	} else {
+		r = t.Scanner.Next()
+	}
+	if r != scanner.EOF {
+		t.offset += utf8.RuneLen(r)
+	}
+	return r
+}
+
+// peekAt returns the rune at the offset from the position without reading it.
+func (t *Tokenizer) peekAt(offset int) rune {
+	for len(t.lookahead) <= offset {
+		r := t.Scanner.Next()
+		if r == scanner.EOF {
+			return scanner.EOF
+		}
+		t.lookahead = append(t.lookahead, r)
+	}
+	return t.lookahead[offset]
+}
+
+// hasPrefix reports whether the text from the position starts with s.
+func (t *Tokenizer) hasPrefix(s string) bool {
+	i := 0
+	for _, r := range s {
+		if t.peekAt(i) != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// advance moves the position over the runes, which may have the new lines.
+func (t *Tokenizer) advance(rs []rune) {
+	for _, r := range rs {
+		if r == '\n' {
+			t.Line++
+			t.Col = 0
+			continue
+		}
+		t.Col++
+	}
+}
+
+// Offset returns the byte offset of the position in the source.
+func (t *Tokenizer) Offset() int {
+	return t.offset
+}
+
 func (t *Tokenizer) Pos() Pos {
 	return Pos{
 		Line: t.Line,
@@ -162,55 +261,82 @@ func (t *Tokenizer) Pos() Pos {
 }
 
 func (t *Tokenizer) next() (Kind, interface{}, error) {
-	r := t.Scanner.Peek()
+	r := t.peek()
+	if t.delimiter != "" && t.hasPrefix(t.delimiter) {
+		for range t.delimiter {
+			t.read()
+		}
+		t.Col += len([]rune(t.delimiter))
+		return Semicolon, t.delimiter, nil
+	}
+	if t.readDelimiter && r != ' ' && r != '\t' && r != '\n' && r != '\r' && r != scanner.EOF {
+		return Semicolon, t.tokenizeDelimiter(), nil
+	}
+	if op := t.operator(); op != "" {
+		for range op {
+			t.read()
+		}
+		t.Col += len(op)
+		return Operator, op, nil
+	}
 	switch {
 	case r == ' ':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Whitespace, " ", nil
 
 	case r == '\t':
-		t.Scanner.Next()
+		t.read()
 		t.Col += 4
 		return Whitespace, "\t", nil
 
 	case r == '\n':
-		t.Scanner.Next()
+		t.read()
 		t.Line++
 		t.Col = 0
 		return Whitespace, "\n", nil
 
 	case r == '\r':
-		t.Scanner.Next()
-		n := t.Scanner.Peek()
+		t.read()
+		n := t.peek()
 		if n == '\n' {
-			t.Scanner.Next()
+			t.read()
 		}
 		t.Line++
 		t.Col = 0
 		return Whitespace, "\n", nil
 
 	case r == 'N':
-		t.Scanner.Next()
-		n := t.Scanner.Peek()
+		t.read()
+		n := t.peek()
 		if n == '\'' {
 			t.Col++
 			str := t.tokenizeSingleQuotedString()
 			return NationalStringLiteral, str, nil
 		}
 		s := t.tokenizeWord('N')
-		v := MakeKeyword(s, 0)
-		return SQLKeyword, v, nil
+		return SQLKeyword, t.makeKeyword(s), nil
 
 	case t.Dialect.IsIdentifierStart(r):
-		t.Scanner.Next()
+		t.read()
 		s := t.tokenizeWord(r)
-		return SQLKeyword, MakeKeyword(s, 0), nil
+		if t.statementStart && t.lineStart && strings.EqualFold(s, "DELIMITER") {
+			t.readDelimiter = true
+		}
+		return SQLKeyword, t.makeKeyword(s), nil
 
 	case r == '\'':
 		s := t.tokenizeSingleQuotedString()
 		return SingleQuotedString, s, nil
 
+	case r == '$' && t.prevKind != SQLKeyword:
+		if tag, ok := t.dollarQuoteTag(); ok {
+			return DollarQuotedString, t.tokenizeDollarQuotedString(tag), nil
+		}
+		t.read()
+		t.Col++
+		return Char, "$", nil
+
 	case t.Dialect.IsDelimitedIdentifierStart(r):
 		s := t.tokenizeDelimitedIdentifier(r)
 		return SQLKeyword, s, nil
@@ -218,10 +344,10 @@ func (t *Tokenizer) next() (Kind, interface{}, error) {
 	case '0' <= r && r <= '9':
 		var s []rune
 		for {
-			n := t.Scanner.Peek()
+			n := t.peek()
 			if ('0' <= n && n <= '9') || n == '.' {
 				s = append(s, n)
-				t.Scanner.Next()
+				t.read()
 			} else {
 				break
 			}
@@ -230,31 +356,31 @@ func (t *Tokenizer) next() (Kind, interface{}, error) {
 		return Number, string(s), nil
 
 	case r == '(':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return LParen, "(", nil
 
 	case r == ')':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return RParen, ")", nil
 
 	case r == ',':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Comma, ",", nil
 
 	case r == '-':
-		t.Scanner.Next()
+		t.read()
 
-		if t.Scanner.Peek() == '-' {
-			t.Scanner.Next()
+		if t.peek() == '-' {
+			t.read()
 
 			var s []rune
 			for {
-				ch := t.Scanner.Peek()
+				ch := t.peek()
 				if ch != scanner.EOF && ch != '\n' {
-					t.Scanner.Next()
+					t.read()
 					s = append(s, ch)
 				} else {
 					t.Col += len(s) + 2
@@ -266,10 +392,10 @@ func (t *Tokenizer) next() (Kind, interface{}, error) {
 		return Minus, "-", nil
 
 	case r == '/':
-		t.Scanner.Next()
+		t.read()
 
-		if t.Scanner.Peek() == '*' {
-			t.Scanner.Next()
+		if t.peek() == '*' {
+			t.read()
 			str, err := t.tokenizeMultilineComment()
 			if err != nil {
 				return ILLEGAL, str, err
@@ -280,49 +406,51 @@ func (t *Tokenizer) next() (Kind, interface{}, error) {
 		return Div, "/", nil
 
 	case r == '+':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Plus, "+", nil
 	case r == '*':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Mult, "*", nil
 	case r == '%':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Mod, "%", nil
 	case r == '^':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Caret, "^", nil
 	case r == '=':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Eq, "=", nil
 	case r == '.':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Period, ".", nil
 
 	case r == '!':
-		t.Scanner.Next()
-		n := t.Scanner.Peek()
+		t.read()
+		n := t.peek()
 		if n == '=' {
-			t.Scanner.Next()
+			t.read()
 			t.Col += 2
 			return Neq, "!=", nil
 		}
-		return ILLEGAL, "", fmt.Errorf("tokenizer error: illegal sequence %s%s", string(r), string(n))
+		// !< and !> of T-SQL and !! of PostgreSQL
+		t.Col++
+		return Char, "!", nil
 
 	case r == '<':
-		t.Scanner.Next()
-		switch t.Scanner.Peek() {
+		t.read()
+		switch t.peek() {
 		case '=':
-			t.Scanner.Next()
+			t.read()
 			t.Col += 2
 			return LtEq, "<=", nil
 		case '>':
-			t.Scanner.Next()
+			t.read()
 			t.Col += 2
 			return Neq, "<>", nil
 		default:
@@ -330,10 +458,10 @@ func (t *Tokenizer) next() (Kind, interface{}, error) {
 			return Lt, "<", nil
 		}
 	case r == '>':
-		t.Scanner.Next()
-		switch t.Scanner.Peek() {
+		t.read()
+		switch t.peek() {
 		case '=':
-			t.Scanner.Next()
+			t.read()
 			t.Col += 2
 			return GtEq, ">=", nil
 		default:
@@ -341,60 +469,69 @@ func (t *Tokenizer) next() (Kind, interface{}, error) {
 			return Gt, ">", nil
 		}
 	case r == ':':
-		t.Scanner.Next()
-		n := t.Scanner.Peek()
+		t.read()
+		n := t.peek()
 		if n == ':' {
-			t.Scanner.Next()
+			t.read()
 			t.Col += 2
 			return DoubleColon, "::", nil
 		}
 		t.Col++
 		return Colon, ":", nil
 	case r == ';':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Semicolon, ";", nil
 	case r == '\\':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Backslash, "\\", nil
 	case r == '[':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return LBracket, "[", nil
 	case r == ']':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return RBracket, "]", nil
 	case r == '&':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Ampersand, "&", nil
 	case r == '{':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return LBrace, "{", nil
 	case r == '}':
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return RBrace, "}", nil
 	case scanner.EOF == r:
 		return ILLEGAL, "", io.EOF
 	default:
-		t.Scanner.Next()
+		t.read()
 		t.Col++
 		return Char, string(r), nil
 	}
 }
 
+// makeKeyword makes the word, recognizing the keywords of the dialect too.
+func (t *Tokenizer) makeKeyword(word string) *SQLWord {
+	v := MakeKeyword(word, 0)
+	if kd, ok := t.Dialect.(dialect.KeywordDialect); ok && v.Kind == dialect.Unmatched && kd.IsKeyword(v.Keyword) {
+		v.Kind = dialect.Matched
+	}
+	return v
+}
+
 func (t *Tokenizer) tokenizeWord(f rune) string {
 	var str []rune
 	str = append(str, f)
 
 	for {
-		r := t.Scanner.Peek()
+		r := t.peek()
 		if t.Dialect.IsIdentifierPart(r) {
-			t.Scanner.Next()
+			t.read()
 			str = append(str, r)
 		} else {
 			break
@@ -406,16 +543,16 @@ func (t *Tokenizer) tokenizeWord(f rune) string {
 
 func (t *Tokenizer) tokenizeSingleQuotedString() string {
 	var str []rune
-	t.Scanner.Next()
+	raw := []rune{t.read()}
 	isClosed := false
 
 	for {
-		n := t.Scanner.Peek()
+		n := t.peek()
 		if n == '\'' {
-			t.Scanner.Next()
-			if t.Scanner.Peek() == '\'' {
+			raw = append(raw, t.read())
+			if t.peek() == '\'' {
 				str = append(str, '\'')
-				t.Scanner.Next()
+				raw = append(raw, t.read())
 			} else {
 				isClosed = true
 				break
@@ -426,26 +563,105 @@ func (t *Tokenizer) tokenizeSingleQuotedString() string {
 			break
 		}
 
-		t.Scanner.Next()
+		raw = append(raw, t.read())
 		str = append(str, n)
 	}
 
+	t.advance(raw)
 	if isClosed {
-		t.Col += 2 + len(str)
 		return "'" + string(str) + "'"
 	}
-	t.Col += 1 + len(str)
 	return "'" + string(str)
 }
 
+// dollarQuoteTag returns the tag of the dollar-quoted string of PostgreSQL
+// starting at the position, such as $$ and $body$. The $ of the placeholders
+// such as $1 are not the ones.
+func (t *Tokenizer) dollarQuoteTag() (string, bool) {
+	tag := []rune{'$'}
+	for i := 1; ; i++ {
+		r := t.peekAt(i)
+		if r == '$' {
+			return string(append(tag, r)), true
+		}
+		isTagStart := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+		if !isTagStart && (i == 1 || r < '0' || r > '9') {
+			return "", false
+		}
+		tag = append(tag, r)
+	}
+}
+
+// tokenizeDollarQuotedString reads the string to the closing tag, or to the
+// end of the text if it is not closed.
+func (t *Tokenizer) tokenizeDollarQuotedString(tag string) string {
+	var str []rune
+	for range tag {
+		str = append(str, t.read())
+	}
+	for !t.hasPrefix(tag) {
+		r := t.read()
+		if r == scanner.EOF {
+			t.advance(str)
+			return string(str)
+		}
+		str = append(str, r)
+	}
+	for range tag {
+		str = append(str, t.read())
+	}
+	t.advance(str)
+	return string(str)
+}
+
+// operators are the operators of PostgreSQL for the JSON, the arrays and the
+// strings, the longest first.
+var operators = []string{"->>", "#>>", "->", "#>", "@>", "<@", "?|", "?&", "||"}
+
+// operator returns the operator of PostgreSQL at the position, or an empty
+// string.
+func (t *Tokenizer) operator() string {
+	for _, op := range operators {
+		if !t.hasPrefix(op) {
+			continue
+		}
+		// x<@var of the variables of T-SQL and MySQL
+		if op == "<@" && t.Dialect.IsIdentifierPart(t.peekAt(2)) {
+			continue
+		}
+		return op
+	}
+	return ""
+}
+
+// tokenizeDelimiter reads the argument of the DELIMITER directive, which is
+// the statement delimiter until the next directive.
+func (t *Tokenizer) tokenizeDelimiter() string {
+	var str []rune
+	for {
+		r := t.peek()
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == scanner.EOF {
+			break
+		}
+		str = append(str, t.read())
+	}
+	t.Col += len(str)
+	t.readDelimiter = false
+	t.delimiter = string(str)
+	if t.delimiter == ";" {
+		t.delimiter = ""
+	}
+	return string(str)
+}
+
 func (t *Tokenizer) tokenizeDelimitedIdentifier(r rune) *SQLWord {
-	t.Scanner.Next()
+	t.read()
 	end := matchingEndQuote(r)
 	isClosed := false
 
 	var s []rune
 	for {
-		n := t.Scanner.Next()
+		n := t.read()
 		if n == scanner.EOF {
 			break
 		}
@@ -454,7 +670,7 @@ func (t *Tokenizer) tokenizeDelimitedIdentifier(r rune) *SQLWord {
 			break
 		}
 		s = append(s, n)
-		if t.Scanner.Peek() == ' ' {
+		if t.peek() == ' ' {
 			break
 		}
 	}
@@ -472,11 +688,11 @@ func (t *Tokenizer) tokenizeMultilineComment() (string, error) {
 	var mayBeClosingComment bool
 	t.Col += 2
 	for {
-		n := t.Scanner.Next()
+		n := t.read()
 
 		if n == '\r' {
-			if t.Scanner.Peek() == '\n' {
-				t.Scanner.Next()
+			if t.peek() == '\n' {
+				t.read()
 			}
 			t.Col = 0
 			t.Line++
@@ -577,6 +577,114 @@ comment */`,
 				},
 			},
 		},
+		{
+			name: "json operators",
+			in:   "a->>'b'#>c @>d",
+			out: []*Token{
+				{
+					Kind:  SQLKeyword,
+					Value: MakeKeyword("a", 0),
+					From:  Pos{Line: 0, Col: 0},
+					To:    Pos{Line: 0, Col: 1},
+				},
+				{
+					Kind:  Operator,
+					Value: "->>",
+					From:  Pos{Line: 0, Col: 1},
+					To:    Pos{Line: 0, Col: 4},
+				},
+				{
+					Kind:  SingleQuotedString,
+					Value: "'b'",
+					From:  Pos{Line: 0, Col: 4},
+					To:    Pos{Line: 0, Col: 7},
+				},
+				{
+					Kind:  Operator,
+					Value: "#>",
+					From:  Pos{Line: 0, Col: 7},
+					To:    Pos{Line: 0, Col: 9},
+				},
+				{
+					Kind:  SQLKeyword,
+					Value: MakeKeyword("c", 0),
+					From:  Pos{Line: 0, Col: 9},
+					To:    Pos{Line: 0, Col: 10},
+				},
+				{
+					Kind:  Whitespace,
+					Value: " ",
+					From:  Pos{Line: 0, Col: 10},
+					To:    Pos{Line: 0, Col: 11},
+				},
+				{
+					Kind:  Operator,
+					Value: "@>",
+					From:  Pos{Line: 0, Col: 11},
+					To:    Pos{Line: 0, Col: 13},
+				},
+				{
+					Kind:  SQLKeyword,
+					Value: MakeKeyword("d", 0),
+					From:  Pos{Line: 0, Col: 13},
+					To:    Pos{Line: 0, Col: 14},
+				},
+			},
+		},
+		{
+			name: "less than variable",
+			in:   "1<@a",
+			out: []*Token{
+				{
+					Kind:  Number,
+					Value: "1",
+					From:  Pos{Line: 0, Col: 0},
+					To:    Pos{Line: 0, Col: 1},
+				},
+				{
+					Kind:  Lt,
+					Value: "<",
+					From:  Pos{Line: 0, Col: 1},
+					To:    Pos{Line: 0, Col: 2},
+				},
+				{
+					Kind:  SQLKeyword,
+					Value: MakeKeyword("@a", 0),
+					From:  Pos{Line: 0, Col: 2},
+					To:    Pos{Line: 0, Col: 4},
+				},
+			},
+		},
+		{
+			name: "not less than of T-SQL",
+			in:   "1!<2",
+			out: []*Token{
+				{
+					Kind:  Number,
+					Value: "1",
+					From:  Pos{Line: 0, Col: 0},
+					To:    Pos{Line: 0, Col: 1},
+				},
+				{
+					Kind:  Char,
+					Value: "!",
+					From:  Pos{Line: 0, Col: 1},
+					To:    Pos{Line: 0, Col: 2},
+				},
+				{
+					Kind:  Lt,
+					Value: "<",
+					From:  Pos{Line: 0, Col: 2},
+					To:    Pos{Line: 0, Col: 3},
+				},
+				{
+					Kind:  Number,
+					Value: "2",
+					From:  Pos{Line: 0, Col: 3},
+					To:    Pos{Line: 0, Col: 4},
+				},
+			},
+		},
 		{
 			name: "Lts",
 			in:   "<<=<>",
@@ -831,6 +939,16 @@ test comment
 				src:    "select * from /* test table */ test_table where id != 123",
 				expect: Pos{Line: 0, Col: 57},
 			},
+			{
+				name:   "multiline string",
+				src:    "select 'it''s\na'",
+				expect: Pos{Line: 1, Col: 2},
+			},
+			{
+				name:   "multiline dollar quoted string",
+				src:    "as $$\nbegin\nend $$ x",
+				expect: Pos{Line: 2, Col: 8},
+			},
 		}
 
 		for _, c := range cases {
@@ -876,3 +994,161 @@ test
 		}
 	})
 }
+
+func TestTokenizer_Delimiters(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		out  []*Token
+	}{
+		{
+			name: "dollar quoted string",
+			in:   "$$a;'b$$",
+			out: []*Token{
+				{Kind: DollarQuotedString, Value: "$$a;'b$$", From: Pos{Line: 0, Col: 0}, To: Pos{Line: 0, Col: 8}},
+			},
+		},
+		{
+			name: "tagged dollar quoted string",
+			in:   "$fn$a$$b$fn$",
+			out: []*Token{
+				{Kind: DollarQuotedString, Value: "$fn$a$$b$fn$", From: Pos{Line: 0, Col: 0}, To: Pos{Line: 0, Col: 12}},
+			},
+		},
+		{
+			name: "unclosed dollar quoted string",
+			in:   "$$a;",
+			out: []*Token{
+				{Kind: DollarQuotedString, Value: "$$a;", From: Pos{Line: 0, Col: 0}, To: Pos{Line: 0, Col: 4}},
+			},
+		},
+		{
+			name: "placeholder",
+			in:   "$1",
+			out: []*Token{
+				{Kind: Char, Value: "$", From: Pos{Line: 0, Col: 0}, To: Pos{Line: 0, Col: 1}},
+				{Kind: Number, Value: "1", From: Pos{Line: 0, Col: 1}, To: Pos{Line: 0, Col: 2}},
+			},
+		},
+		{
+			name: "dollar in identifier",
+			in:   "v$a$",
+			out: []*Token{
+				{Kind: SQLKeyword, Value: MakeKeyword("v", 0), From: Pos{Line: 0, Col: 0}, To: Pos{Line: 0, Col: 1}},
+				{Kind: Char, Value: "$", From: Pos{Line: 0, Col: 1}, To: Pos{Line: 0, Col: 2}},
+				{Kind: SQLKeyword, Value: MakeKeyword("a", 0), From: Pos{Line: 0, Col: 2}, To: Pos{Line: 0, Col: 3}},
+				{Kind: Char, Value: "$", From: Pos{Line: 0, Col: 3}, To: Pos{Line: 0, Col: 4}},
+			},
+		},
+		{
+			name: "delimiter directive",
+			in:   "delimiter $$\n;$$",
+			out: []*Token{
+				{Kind: SQLKeyword, Value: MakeKeyword("delimiter", 0), From: Pos{Line: 0, Col: 0}, To: Pos{Line: 0, Col: 9}},
+				{Kind: Whitespace, Value: " ", From: Pos{Line: 0, Col: 9}, To: Pos{Line: 0, Col: 10}},
+				{Kind: Semicolon, Value: "$$", From: Pos{Line: 0, Col: 10}, To: Pos{Line: 0, Col: 12}},
+				{Kind: Whitespace, Value: "\n", From: Pos{Line: 0, Col: 12}, To: Pos{Line: 1, Col: 0}},
+				{Kind: Semicolon, Value: ";", From: Pos{Line: 1, Col: 0}, To: Pos{Line: 1, Col: 1}},
+				{Kind: Semicolon, Value: "$$", From: Pos{Line: 1, Col: 1}, To: Pos{Line: 1, Col: 3}},
+			},
+		},
+		{
+			name: "delimiter not starting statement",
+			in:   "copy\ndelimiter ','",
+			out: []*Token{
+				{Kind: SQLKeyword, Value: MakeKeyword("copy", 0), From: Pos{Line: 0, Col: 0}, To: Pos{Line: 0, Col: 4}},
+				{Kind: Whitespace, Value: "\n", From: Pos{Line: 0, Col: 4}, To: Pos{Line: 1, Col: 0}},
+				{Kind: SQLKeyword, Value: MakeKeyword("delimiter", 0), From: Pos{Line: 1, Col: 0}, To: Pos{Line: 1, Col: 9}},
+				{Kind: Whitespace, Value: " ", From: Pos{Line: 1, Col: 9}, To: Pos{Line: 1, Col: 10}},
+				{Kind: SingleQuotedString, Value: "','", From: Pos{Line: 1, Col: 10}, To: Pos{Line: 1, Col: 13}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tokens, err := NewTokenizer(strings.NewReader(c.in), &dialect.GenericSQLDialect{}).Tokenize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(c.out, tokens); d != "" {
+				t.Errorf("unmatched tokens (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestTokenizer_Dialect(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect dialect.Dialect
+		in      string
+		out     []*SQLWord
+	}{
+		{
+			name:    "mssql brackets and temporary table",
+			dialect: dialect.ForDriver(dialect.DatabaseDriverMssql),
+			in:      "TOP [dbo] #tmp",
+			out: []*SQLWord{
+				{Value: "TOP", Keyword: "TOP", Kind: dialect.Matched},
+				{Value: "dbo", Keyword: "DBO", QuoteStyle: '[', Kind: dialect.Unmatched},
+				{Value: "#tmp", Keyword: "#TMP", Kind: dialect.Unmatched},
+			},
+		},
+		{
+			name:    "generic",
+			dialect: dialect.ForDriver(""),
+			in:      "TOP NOLOCK",
+			out: []*SQLWord{
+				{Value: "TOP", Keyword: "TOP", Kind: dialect.Unmatched},
+				{Value: "NOLOCK", Keyword: "NOLOCK", Kind: dialect.Unmatched},
+			},
+		},
+		{
+			name:    "postgresql keywords",
+			dialect: dialect.ForDriver(dialect.DatabaseDriverPostgreSQL),
+			in:      "ilike returning",
+			out: []*SQLWord{
+				{Value: "ilike", Keyword: "ILIKE", Kind: dialect.Matched},
+				{Value: "returning", Keyword: "RETURNING", Kind: dialect.Matched},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tokens, err := NewTokenizer(strings.NewReader(c.in), c.dialect).Tokenize()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var words []*SQLWord
+			for _, tok := range tokens {
+				if w, ok := tok.Value.(*SQLWord); ok {
+					words = append(words, w)
+				}
+			}
+			if d := cmp.Diff(c.out, words); d != "" {
+				t.Errorf("unmatched words (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestTokenizer_Offset(t *testing.T) {
+	in := "SELECT '日本'\r\n\t-- a\n$$x$$"
+	tokenizer := NewTokenizer(strings.NewReader(in), &dialect.PostgreSQLDialect{})
+	var got []string
+	start := 0
+	for {
+		_, err := tokenizer.NextToken()
+		if err != nil {
+			break
+		}
+		got = append(got, in[start:tokenizer.Offset()])
+		start = tokenizer.Offset()
+	}
+	want := []string{"SELECT", " ", "'日本'", "\r\n", "\t", "-- a", "\n", "$$x$$"}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("unmatched texts of the tokens (-want +got):\n%s", d)
+	}
+}